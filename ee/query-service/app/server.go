@@ -42,6 +42,7 @@ import (
 	"go.signoz.io/signoz/pkg/query-service/app/dashboards"
 	baseexplorer "go.signoz.io/signoz/pkg/query-service/app/explorer"
 	"go.signoz.io/signoz/pkg/query-service/app/integrations"
+	"go.signoz.io/signoz/pkg/query-service/app/logmetrics"
 	"go.signoz.io/signoz/pkg/query-service/app/logparsingpipeline"
 	"go.signoz.io/signoz/pkg/query-service/app/opamp"
 	opAmpModel "go.signoz.io/signoz/pkg/query-service/app/opamp/model"
@@ -53,6 +54,7 @@ import (
 	baseint "go.signoz.io/signoz/pkg/query-service/interfaces"
 	basemodel "go.signoz.io/signoz/pkg/query-service/model"
 	pqle "go.signoz.io/signoz/pkg/query-service/pqlEngine"
+	"go.signoz.io/signoz/pkg/query-service/querylimits"
 	baserules "go.signoz.io/signoz/pkg/query-service/rules"
 	"go.signoz.io/signoz/pkg/query-service/telemetry"
 	"go.signoz.io/signoz/pkg/query-service/utils"
@@ -74,6 +76,7 @@ type ServerOptions struct {
 	MaxOpenConns      int
 	DialTimeout       time.Duration
 	CacheConfigPath   string
+	QueryLimitsPath   string
 	FluxInterval      string
 	Cluster           string
 	GatewayUrl        string
@@ -177,6 +180,11 @@ func NewServer(serverOptions *ServerOptions) (*Server, error) {
 			return nil, err
 		}
 		c = cache.NewCache(cacheOpts)
+		if c != nil {
+			if err := c.Connect(); err != nil {
+				return nil, err
+			}
+		}
 	}
 
 	<-readerReady
@@ -223,11 +231,19 @@ func NewServer(serverOptions *ServerOptions) (*Server, error) {
 		return nil, err
 	}
 
+	// metrics derived from logs by the collectors
+	logMetricsController, err := logmetrics.NewLogMetricsController(localDB, "sqlite")
+	if err != nil {
+		return nil, err
+	}
+
 	// initiate agent config handler
 	agentConfMgr, err := agentConf.Initiate(&agentConf.ManagerOptions{
-		DB:            localDB,
-		DBEngine:      AppDbEngine,
-		AgentFeatures: []agentConf.AgentFeature{logParsingPipelineController},
+		DB:       localDB,
+		DBEngine: AppDbEngine,
+		AgentFeatures: []agentConf.AgentFeature{
+			logParsingPipelineController, logMetricsController,
+		},
 	})
 	if err != nil {
 		return nil, err
@@ -252,6 +268,14 @@ func NewServer(serverOptions *ServerOptions) (*Server, error) {
 		return nil, err
 	}
 
+	var queryLimits *querylimits.Config
+	if serverOptions.QueryLimitsPath != "" {
+		queryLimits, err = querylimits.LoadFromYAMLConfigFile(serverOptions.QueryLimitsPath)
+		if err != nil {
+			return nil, err
+		}
+	}
+
 	apiOpts := api.APIHandlerOptions{
 		DataConnector:                 reader,
 		SkipConfig:                    skipConfig,
@@ -266,10 +290,12 @@ func NewServer(serverOptions *ServerOptions) (*Server, error) {
 		LicenseManager:                lm,
 		IntegrationsController:        integrationsController,
 		LogsParsingPipelineController: logParsingPipelineController,
+		LogMetricsController:          logMetricsController,
 		Cache:                         c,
 		FluxInterval:                  fluxInterval,
 		Gateway:                       gatewayProxy,
 		UseLogsNewSchema:              serverOptions.UseLogsNewSchema,
+		QueryLimits:                   queryLimits,
 	}
 
 	apiHandler, err := api.NewAPIHandler(apiOpts)
@@ -95,6 +95,7 @@ func main() {
 
 	var useLogsNewSchema bool
 	var cacheConfigPath, fluxInterval string
+	var queryLimitsPath string
 	var enableQueryServiceLogOTLPExport bool
 	var preferSpanMetrics bool
 
@@ -113,6 +114,7 @@ func main() {
 	flag.DurationVar(&dialTimeout, "dial-timeout", 5*time.Second, "(the maximum time to establish a connection.)")
 	flag.StringVar(&ruleRepoURL, "rules.repo-url", baseconst.AlertHelpPage, "(host address used to build rule link in alert messages)")
 	flag.StringVar(&cacheConfigPath, "experimental.cache-config", "", "(cache config to use)")
+	flag.StringVar(&queryLimitsPath, "query-limits-config", "", "(config file with the query limits of the roles)")
 	flag.StringVar(&fluxInterval, "flux-interval", "5m", "(the interval to exclude data from being cached to avoid incorrect cache for data in motion)")
 	flag.BoolVar(&enableQueryServiceLogOTLPExport, "enable.query.service.log.otlp.export", false, "(enable query service log otlp export)")
 	flag.StringVar(&cluster, "cluster", "cluster", "(cluster name - defaults to 'cluster')")
@@ -139,6 +141,7 @@ func main() {
 		MaxOpenConns:      maxOpenConns,
 		DialTimeout:       dialTimeout,
 		CacheConfigPath:   cacheConfigPath,
+		QueryLimitsPath:   queryLimitsPath,
 		FluxInterval:      fluxInterval,
 		Cluster:           cluster,
 		GatewayUrl:        gatewayUrl,
@@ -222,7 +222,7 @@ func (r *AnomalyRule) Eval(ctx context.Context, ts time.Time) (interface{}, erro
 		}
 
 		value := valueFormatter.Format(smpl.V, r.Unit())
-		threshold := valueFormatter.Format(r.TargetVal(), r.Unit())
+		threshold := valueFormatter.Format(smpl.Threshold, r.Unit())
 		zap.L().Debug("Alert template data for rule", zap.String("name", r.Name()), zap.String("formatter", valueFormatter.Name()), zap.String("value", value), zap.String("threshold", threshold))
 
 		tmplData := baserules.AlertTemplateData(l, value, threshold)
@@ -255,6 +255,9 @@ func (r *AnomalyRule) Eval(ctx context.Context, ts time.Time) (interface{}, erro
 		for name, value := range r.Labels().Map() {
 			lb.Set(name, expand(value))
 		}
+		if smpl.Severity != "" {
+			lb.Set(labels.AlertSeverityLabel, smpl.Severity)
+		}
 
 		lb.Set(labels.AlertNameLabel, r.Name())
 		lb.Set(labels.AlertRuleIdLabel, r.ID())
@@ -269,7 +272,7 @@ func (r *AnomalyRule) Eval(ctx context.Context, ts time.Time) (interface{}, erro
 		}
 
 		lbs := lb.Labels()
-		h := lbs.Hash()
+		h := r.AlertFingerprint(lbs)
 		resultFPs[h] = struct{}{}
 
 		if _, ok := alerts[h]; ok {
@@ -288,11 +291,14 @@ func (r *AnomalyRule) Eval(ctx context.Context, ts time.Time) (interface{}, erro
 			GeneratorURL:      r.GeneratorURL(),
 			Receivers:         r.PreferredChannels(),
 			Missing:           smpl.IsMissing,
+			Severity:          smpl.Severity,
 		}
 	}
 
 	zap.L().Info("number of alerts found", zap.String("name", r.Name()), zap.Int("count", len(alerts)))
 
+	severityChanged := map[uint64]bool{}
+
 	// alerts[h] is ready, add or update active list now
 	for h, a := range alerts {
 		// Check whether we already have alerting state for the identifying label set.
@@ -302,6 +308,8 @@ func (r *AnomalyRule) Eval(ctx context.Context, ts time.Time) (interface{}, erro
 			alert.Value = a.Value
 			alert.Annotations = a.Annotations
 			alert.Receivers = r.PreferredChannels()
+			// moving between threshold levels keeps the alert active
+			severityChanged[h] = alert.UpdateSeverity(a)
 			continue
 		}
 
@@ -334,6 +342,7 @@ func (r *AnomalyRule) Eval(ctx context.Context, ts time.Time) (interface{}, erro
 					Labels:       model.LabelsString(labelsJSON),
 					Fingerprint:  a.QueryResultLables.Hash(),
 					Value:        a.Value,
+					Severity:     a.Severity,
 				})
 			}
 			continue
@@ -355,6 +364,19 @@ func (r *AnomalyRule) Eval(ctx context.Context, ts time.Time) (interface{}, erro
 				Labels:       model.LabelsString(labelsJSON),
 				Fingerprint:  a.QueryResultLables.Hash(),
 				Value:        a.Value,
+				Severity:     a.Severity,
+			})
+		} else if a.State == model.StateFiring && severityChanged[fp] {
+			itemsToAdd = append(itemsToAdd, model.RuleStateHistory{
+				RuleID:       r.ID(),
+				RuleName:     r.Name(),
+				State:        model.StateFiring,
+				StateChanged: false,
+				UnixMilli:    ts.UnixMilli(),
+				Labels:       model.LabelsString(labelsJSON),
+				Fingerprint:  a.QueryResultLables.Hash(),
+				Value:        a.Value,
+				Severity:     a.Severity,
 			})
 		}
 	}
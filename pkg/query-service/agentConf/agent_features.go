@@ -23,3 +23,13 @@ type AgentFeature interface {
 		apiErr *model.ApiError,
 	)
 }
+
+// AgentCapabilityFeature is implemented by the features whose config needs
+// components not all the agents have. The agents having them set the
+// capability to 1 in the attributes of their description, the settings of
+// the feature are left out of the config recommended to the other agents.
+type AgentCapabilityFeature interface {
+	AgentFeature
+
+	RequiredAgentCapability() string
+}
@@ -145,7 +145,8 @@ func (r *Repo) insertConfig(
 	}
 
 	// allowing empty elements for logs - use case is deleting all pipelines
-	if len(elements) == 0 && c.ElementType != ElementTypeLogPipelines {
+	// or all log metrics
+	if len(elements) == 0 && c.ElementType != ElementTypeLogPipelines && c.ElementType != ElementTypeLogMetrics {
 		zap.L().Error("insert config called with no elements ", zap.String("ElementType", string(c.ElementType)))
 		return model.BadRequest(fmt.Errorf("config must have atleast one element"))
 	}
@@ -277,3 +278,148 @@ func (r *Repo) updateDeployStatusByHash(
 
 	return nil
 }
+
+// getRollout returns the rollout of the config versions of the type, nil
+// when the latest version is deployed to all the agents
+func (r *Repo) getRollout(
+	ctx context.Context, typ ElementTypeDef,
+) (*ConfigRollout, *model.ApiError) {
+	var rollout ConfigRollout
+	err := r.db.GetContext(ctx, &rollout, `SELECT 
+		element_type, 
+		fleet_version, 
+		staged_version, 
+		selector, 
+		COALESCE(updated_by, '') as updated_by, 
+		updated_at 
+		FROM agent_config_rollouts 
+		WHERE element_type = $1`, typ)
+
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, model.InternalError(errors.Wrap(err, "failed to get config rollout"))
+	}
+
+	return &rollout, nil
+}
+
+func (r *Repo) upsertRollout(ctx context.Context, rollout *ConfigRollout) *model.ApiError {
+	upsertQuery := `INSERT INTO agent_config_rollouts(
+		element_type, 
+		fleet_version, 
+		staged_version, 
+		selector, 
+		updated_by, 
+		updated_at) 
+	VALUES ($1, $2, $3, $4, $5, CURRENT_TIMESTAMP) 
+	ON CONFLICT(element_type) DO UPDATE SET 
+		fleet_version = excluded.fleet_version, 
+		staged_version = excluded.staged_version, 
+		selector = excluded.selector, 
+		updated_by = excluded.updated_by, 
+		updated_at = excluded.updated_at`
+
+	_, err := r.db.ExecContext(ctx, upsertQuery,
+		rollout.ElementType,
+		rollout.FleetVersion,
+		rollout.StagedVersion,
+		rollout.Selector,
+		rollout.UpdatedBy)
+	if err != nil {
+		zap.L().Error("failed to upsert config rollout", zap.Error(err))
+		return model.InternalError(errors.Wrap(err, "failed to upsert config rollout"))
+	}
+
+	return nil
+}
+
+func (r *Repo) deleteRollout(ctx context.Context, typ ElementTypeDef) *model.ApiError {
+	_, err := r.db.ExecContext(ctx, `DELETE FROM agent_config_rollouts WHERE element_type = $1`, typ)
+	if err != nil {
+		zap.L().Error("failed to delete config rollout", zap.Error(err))
+		return model.InternalError(errors.Wrap(err, "failed to delete config rollout"))
+	}
+	return nil
+}
+
+func (r *Repo) GetAgentDeployments(
+	ctx context.Context, typ ElementTypeDef,
+) ([]AgentDeployment, *model.ApiError) {
+	deployments := []AgentDeployment{}
+	err := r.db.SelectContext(ctx, &deployments, `SELECT 
+		agent_id, 
+		element_type, 
+		version, 
+		labels, 
+		deploy_status, 
+		COALESCE(deploy_result, '') as deploy_result, 
+		updated_at 
+		FROM agent_config_deployments 
+		WHERE element_type = $1 
+		ORDER BY agent_id`, typ)
+	if err != nil {
+		return nil, model.InternalError(errors.Wrap(err, "failed to get agent deployments"))
+	}
+
+	return deployments, nil
+}
+
+// upsertAgentDeployment records the config version recommended to an agent
+func (r *Repo) upsertAgentDeployment(
+	ctx context.Context, typ ElementTypeDef, agentId string, version int, labels AgentLabels,
+) *model.ApiError {
+	upsertQuery := `INSERT INTO agent_config_deployments(
+		element_type, 
+		agent_id, 
+		version, 
+		labels, 
+		deploy_status, 
+		deploy_result, 
+		updated_at) 
+	VALUES ($1, $2, $3, $4, $5, $6, CURRENT_TIMESTAMP) 
+	ON CONFLICT(element_type, agent_id) DO UPDATE SET 
+		version = excluded.version, 
+		labels = excluded.labels, 
+		deploy_status = excluded.deploy_status, 
+		deploy_result = excluded.deploy_result, 
+		updated_at = excluded.updated_at 
+	WHERE agent_config_deployments.version != excluded.version 
+	OR agent_config_deployments.deploy_status = $7`
+
+	// the status of a version already deployed to the agent is kept when
+	// the same version is recommended again
+	_, err := r.db.ExecContext(ctx, upsertQuery,
+		typ, agentId, version, labels, DeployInitiated, "Deployment has started", DeployFailed)
+	if err != nil {
+		zap.L().Error("failed to upsert agent deployment", zap.Error(err))
+		return model.InternalError(errors.Wrap(err, "failed to upsert agent deployment"))
+	}
+
+	return nil
+}
+
+func (r *Repo) updateAgentDeploymentStatus(
+	ctx context.Context,
+	typ ElementTypeDef,
+	agentId string,
+	version int,
+	status DeployStatus,
+	result string,
+) *model.ApiError {
+	updateQuery := `UPDATE agent_config_deployments 
+	SET deploy_status = $1, 
+	deploy_result = $2, 
+	updated_at = CURRENT_TIMESTAMP 
+	WHERE element_type = $3 
+	AND agent_id = $4 
+	AND version = $5`
+
+	_, err := r.db.ExecContext(ctx, updateQuery, status, result, typ, agentId, version)
+	if err != nil {
+		return model.InternalError(errors.Wrap(err, "failed to update agent deployment status"))
+	}
+
+	return nil
+}
@@ -95,7 +95,9 @@ func (m *Manager) notifyConfigUpdateSubscribers() {
 }
 
 // Implements opamp.AgentConfigProvider
-func (m *Manager) RecommendAgentConfig(currentConfYaml []byte) (
+func (m *Manager) RecommendAgentConfig(
+	agentId string, agentLabels map[string]string, currentConfYaml []byte,
+) (
 	recommendedConfYaml []byte,
 	// Opaque id of the recommended config, used for reporting deployment status updates
 	configId string,
@@ -106,9 +108,12 @@ func (m *Manager) RecommendAgentConfig(currentConfYaml []byte) (
 
 	for _, feature := range m.agentFeatures {
 		featureType := ElementTypeDef(feature.AgentFeatureType())
-		latestConfig, apiErr := GetLatestVersion(context.Background(), featureType)
-		if apiErr != nil && apiErr.Type() != model.ErrorNotFound {
-			return nil, "", errors.Wrap(apiErr.ToError(), "failed to get latest agent config version")
+		latestConfig, apiErr := m.configVersionForAgent(context.Background(), featureType, agentLabels)
+		if apiErr != nil {
+			return nil, "", errors.Wrap(apiErr.ToError(), "failed to get agent config version")
+		}
+		if !agentHasFeatureCapability(feature, agentLabels) {
+			latestConfig = nil
 		}
 
 		updatedConf, serializedSettingsUsed, apiErr := feature.RecommendAgentConfig(
@@ -144,6 +149,14 @@ func (m *Manager) RecommendAgentConfig(currentConfYaml []byte) (
 			serializedSettingsUsed,
 		)
 
+		if agentId != "" {
+			apiErr = m.upsertAgentDeployment(
+				context.Background(), featureType, agentId, configVersion, agentLabels,
+			)
+			if apiErr != nil {
+				zap.L().Error("failed to record agent deployment", zap.String("agentId", agentId), zap.Error(apiErr))
+			}
+		}
 	}
 
 	if len(settingVersionsUsed) > 0 {
@@ -159,6 +172,16 @@ func (m *Manager) RecommendAgentConfig(currentConfYaml []byte) (
 	return recommendation, configId, nil
 }
 
+// agentHasFeatureCapability returns whether the agent with the labels can
+// load the config of the feature
+func agentHasFeatureCapability(feature AgentFeature, agentLabels map[string]string) bool {
+	capabilityFeature, ok := feature.(AgentCapabilityFeature)
+	if !ok {
+		return true
+	}
+	return agentLabels[capabilityFeature.RequiredAgentCapability()] == "1"
+}
+
 // Implements opamp.AgentConfigProvider
 func (m *Manager) ReportConfigDeploymentStatus(
 	agentId string,
@@ -167,15 +190,16 @@ func (m *Manager) ReportConfigDeploymentStatus(
 ) {
 	featureConfigIds := strings.Split(configId, ",")
 	for _, featureConfId := range featureConfigIds {
-		newStatus := string(Deployed)
+		newStatus := Deployed
 		message := "Deployment was successful"
 		if err != nil {
-			newStatus = string(DeployFailed)
+			newStatus = DeployFailed
 			message = fmt.Sprintf("%s: %s", agentId, err.Error())
 		}
 		m.updateDeployStatusByHash(
-			context.Background(), featureConfId, newStatus, message,
+			context.Background(), featureConfId, string(newStatus), message,
 		)
+		m.reportAgentDeploymentStatus(agentId, featureConfId, newStatus, message)
 	}
 }
 
@@ -211,6 +235,13 @@ func StartNewVersion(
 		return nil, err
 	}
 
+	// the new version is deployed to all the agents, replacing any
+	// pinned or staged version
+	err = m.deleteRollout(ctx, eleType)
+	if err != nil {
+		return nil, err
+	}
+
 	m.notifyConfigUpdateSubscribers()
 
 	return cfg, nil
@@ -228,6 +259,20 @@ func Redeploy(ctx context.Context, typ ElementTypeDef, version int) *model.ApiEr
 		return model.WrapApiError(err, "failed to fetch details of the config version")
 	}
 
+	// log pipelines are recommended to each agent by version and don't
+	// need the last deployed conf
+	if typ == ElementTypeLogPipelines {
+		apiErr := m.pinFleetVersion(ctx, &ConfigRollout{
+			ElementType:  typ,
+			FleetVersion: configVersion.Version,
+		})
+		if apiErr != nil {
+			return model.WrapApiError(apiErr, "failed to pin the config version")
+		}
+		m.notifyConfigUpdateSubscribers()
+		return nil
+	}
+
 	if configVersion == nil || (configVersion != nil && configVersion.LastConf == "") {
 		zap.L().Debug("config version has no conf yaml", zap.Any("configVersion", configVersion))
 		return model.BadRequest(fmt.Errorf("the config version can not be redeployed"))
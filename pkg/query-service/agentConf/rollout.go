@@ -0,0 +1,275 @@
+package agentConf
+
+import (
+	"context"
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	"go.signoz.io/signoz/pkg/query-service/model"
+	"go.uber.org/zap"
+)
+
+// AgentLabels are the labels of an opamp agent, a selector of a staged
+// rollout matches the agents having all of its labels
+type AgentLabels map[string]string
+
+// For serializing from db
+func (l *AgentLabels) Scan(src interface{}) error {
+	switch data := src.(type) {
+	case []byte:
+		return json.Unmarshal(data, l)
+	case string:
+		return json.Unmarshal([]byte(data), l)
+	}
+	return nil
+}
+
+// For serializing to db
+func (l AgentLabels) Value() (driver.Value, error) {
+	labelsJson, err := json.Marshal(map[string]string(l))
+	if err != nil {
+		return nil, errors.Wrap(err, "could not serialize agent labels to JSON")
+	}
+	return string(labelsJson), nil
+}
+
+// Matches returns whether the agent labels have all the labels of the selector
+func (l AgentLabels) Matches(agentLabels map[string]string) bool {
+	if len(l) == 0 {
+		return false
+	}
+	for k, v := range l {
+		if agentValue, ok := agentLabels[k]; !ok || agentValue != v {
+			return false
+		}
+	}
+	return true
+}
+
+// ConfigRollout pins the config version deployed to the agents. A staged
+// version is deployed only to the agents matching the selector until it is
+// promoted to the whole fleet. Without a rollout, the latest version is
+// deployed to all the agents.
+type ConfigRollout struct {
+	ElementType ElementTypeDef `json:"elementType" db:"element_type"`
+	// -1 when the fleet doesn't get any user created version
+	FleetVersion  int         `json:"fleetVersion" db:"fleet_version"`
+	StagedVersion *int        `json:"stagedVersion,omitempty" db:"staged_version"`
+	Selector      AgentLabels `json:"selector,omitempty" db:"selector"`
+
+	UpdatedBy string    `json:"updatedBy" db:"updated_by"`
+	UpdatedAt time.Time `json:"updatedAt" db:"updated_at"`
+}
+
+// versionForAgent returns the version deployed to an agent with the labels
+func (r *ConfigRollout) versionForAgent(agentLabels map[string]string) int {
+	if r.StagedVersion != nil && r.Selector.Matches(agentLabels) {
+		return *r.StagedVersion
+	}
+	return r.FleetVersion
+}
+
+// AgentDeployment is the status of the last config version deployed to an agent
+type AgentDeployment struct {
+	AgentID      string         `json:"agentId" db:"agent_id"`
+	ElementType  ElementTypeDef `json:"elementType" db:"element_type"`
+	Version      int            `json:"version" db:"version"`
+	Labels       AgentLabels    `json:"labels" db:"labels"`
+	DeployStatus DeployStatus   `json:"deployStatus" db:"deploy_status"`
+	DeployResult string         `json:"deployResult" db:"deploy_result"`
+	UpdatedAt    time.Time      `json:"updatedAt" db:"updated_at"`
+}
+
+// configVersionForAgent returns the config version to be deployed to an agent,
+// nil when there are no user created versions to deploy
+func (m *Manager) configVersionForAgent(
+	ctx context.Context, typ ElementTypeDef, agentLabels map[string]string,
+) (*ConfigVersion, *model.ApiError) {
+	rollout, apiErr := m.getRollout(ctx, typ)
+	if apiErr != nil {
+		return nil, apiErr
+	}
+
+	if rollout == nil {
+		latestConfig, apiErr := m.GetLatestVersion(ctx, typ)
+		if apiErr != nil && apiErr.Type() != model.ErrorNotFound {
+			return nil, apiErr
+		}
+		return latestConfig, nil
+	}
+
+	version := rollout.versionForAgent(agentLabels)
+	if version < 0 {
+		return nil, nil
+	}
+	return m.GetConfigVersion(ctx, typ, version)
+}
+
+// reportAgentDeploymentStatus updates the deployment status of the agent
+// for the feature config ids of a recommended config, like log_pipelines:5
+func (m *Manager) reportAgentDeploymentStatus(
+	agentId string, featureConfId string, status DeployStatus, message string,
+) {
+	typ, versionString, found := strings.Cut(featureConfId, ":")
+	if !found {
+		return
+	}
+	version, err := strconv.Atoi(versionString)
+	if err != nil {
+		return
+	}
+	apiErr := m.updateAgentDeploymentStatus(
+		context.Background(), ElementTypeDef(typ), agentId, version, status, message,
+	)
+	if apiErr != nil {
+		zap.L().Error(
+			"failed to update agent deployment status",
+			zap.String("agentId", agentId), zap.String("configId", featureConfId), zap.Error(apiErr),
+		)
+	}
+}
+
+// currentFleetVersion returns the version deployed to the agents not
+// matching the selector of a staged rollout
+func (m *Manager) currentFleetVersion(
+	ctx context.Context, typ ElementTypeDef,
+) (int, *model.ApiError) {
+	rollout, apiErr := m.getRollout(ctx, typ)
+	if apiErr != nil {
+		return 0, apiErr
+	}
+	if rollout != nil {
+		return rollout.FleetVersion, nil
+	}
+
+	latestConfig, apiErr := m.GetLatestVersion(ctx, typ)
+	if apiErr != nil {
+		if apiErr.Type() == model.ErrorNotFound {
+			return -1, nil
+		}
+		return 0, apiErr
+	}
+	return latestConfig.Version, nil
+}
+
+func GetRollout(ctx context.Context, typ ElementTypeDef) (*ConfigRollout, *model.ApiError) {
+	return m.getRollout(ctx, typ)
+}
+
+func GetAgentDeployments(ctx context.Context, typ ElementTypeDef) ([]AgentDeployment, *model.ApiError) {
+	return m.GetAgentDeployments(ctx, typ)
+}
+
+// StartNewStagedVersion launches a new config version for given set of
+// elements, deployed only to the agents matching the selector. The other
+// agents keep their current version until the new version is promoted.
+func StartNewStagedVersion(
+	ctx context.Context, userId string, eleType ElementTypeDef, elementIds []string, selector AgentLabels,
+) (*ConfigVersion, *model.ApiError) {
+	if len(selector) == 0 {
+		return nil, model.BadRequest(fmt.Errorf("a selector is required for a staged rollout"))
+	}
+
+	fleetVersion, apiErr := m.currentFleetVersion(ctx, eleType)
+	if apiErr != nil {
+		return nil, model.WrapApiError(apiErr, "failed to get the deployed config version")
+	}
+
+	cfg := NewConfigVersion(eleType)
+	apiErr = m.insertConfig(ctx, userId, cfg, elementIds)
+	if apiErr != nil {
+		return nil, apiErr
+	}
+
+	apiErr = m.upsertRollout(ctx, &ConfigRollout{
+		ElementType:   eleType,
+		FleetVersion:  fleetVersion,
+		StagedVersion: &cfg.Version,
+		Selector:      selector,
+		UpdatedBy:     userId,
+	})
+	if apiErr != nil {
+		return nil, model.WrapApiError(apiErr, "failed to stage the config version")
+	}
+
+	m.notifyConfigUpdateSubscribers()
+
+	return cfg, nil
+}
+
+// StageVersion deploys an existing config version only to the agents
+// matching the selector, replacing any version staged earlier
+func StageVersion(
+	ctx context.Context, userId string, typ ElementTypeDef, version int, selector AgentLabels,
+) *model.ApiError {
+	if len(selector) == 0 {
+		return model.BadRequest(fmt.Errorf("a selector is required for a staged rollout"))
+	}
+
+	if _, apiErr := GetConfigVersion(ctx, typ, version); apiErr != nil {
+		return model.WrapApiError(apiErr, "failed to fetch details of the config version")
+	}
+
+	fleetVersion, apiErr := m.currentFleetVersion(ctx, typ)
+	if apiErr != nil {
+		return model.WrapApiError(apiErr, "failed to get the deployed config version")
+	}
+
+	apiErr = m.upsertRollout(ctx, &ConfigRollout{
+		ElementType:   typ,
+		FleetVersion:  fleetVersion,
+		StagedVersion: &version,
+		Selector:      selector,
+		UpdatedBy:     userId,
+	})
+	if apiErr != nil {
+		return model.WrapApiError(apiErr, "failed to stage the config version")
+	}
+
+	m.notifyConfigUpdateSubscribers()
+	return nil
+}
+
+// PromoteStagedVersion deploys the staged config version to all the agents
+func PromoteStagedVersion(
+	ctx context.Context, userId string, typ ElementTypeDef,
+) (*ConfigRollout, *model.ApiError) {
+	rollout, apiErr := m.getRollout(ctx, typ)
+	if apiErr != nil {
+		return nil, model.WrapApiError(apiErr, "failed to get the config rollout")
+	}
+	if rollout == nil || rollout.StagedVersion == nil {
+		return nil, model.BadRequest(fmt.Errorf("there is no staged config version to promote"))
+	}
+
+	promoted := &ConfigRollout{
+		ElementType:  typ,
+		FleetVersion: *rollout.StagedVersion,
+		UpdatedBy:    userId,
+	}
+	apiErr = m.pinFleetVersion(ctx, promoted)
+	if apiErr != nil {
+		return nil, model.WrapApiError(apiErr, "failed to promote the staged config version")
+	}
+
+	m.notifyConfigUpdateSubscribers()
+	return promoted, nil
+}
+
+// pinFleetVersion deploys a version to all the agents. The rollout is
+// removed when it is the latest version so that new versions get deployed.
+func (m *Manager) pinFleetVersion(ctx context.Context, rollout *ConfigRollout) *model.ApiError {
+	latestConfig, apiErr := m.GetLatestVersion(ctx, rollout.ElementType)
+	if apiErr != nil {
+		return apiErr
+	}
+	if latestConfig.Version == rollout.FleetVersion {
+		return m.deleteRollout(ctx, rollout.ElementType)
+	}
+	return m.upsertRollout(ctx, rollout)
+}
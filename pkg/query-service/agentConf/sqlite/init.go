@@ -55,6 +55,26 @@ func InitDB(db *sqlx.DB) error {
 	CREATE UNIQUE INDEX IF NOT EXISTS agent_config_elements_u1 
 	ON agent_config_elements(version_id, element_id, element_type);
 
+	CREATE TABLE IF NOT EXISTS agent_config_rollouts(
+		element_type VARCHAR(120) PRIMARY KEY,
+		fleet_version INTEGER NOT NULL,
+		staged_version INTEGER,
+		selector TEXT,
+		updated_by TEXT,
+		updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+	);
+
+	CREATE TABLE IF NOT EXISTS agent_config_deployments(
+		element_type VARCHAR(120) NOT NULL,
+		agent_id TEXT NOT NULL,
+		version INTEGER NOT NULL,
+		labels TEXT,
+		deploy_status VARCHAR(80) NOT NULL,
+		deploy_result TEXT,
+		updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+		PRIMARY KEY(element_type, agent_id)
+	);
+
 	`
 
 	_, err = db.Exec(table_schema)
@@ -13,6 +13,7 @@ const (
 	ElementTypeDropRules     ElementTypeDef = "drop_rules"
 	ElementTypeLogPipelines  ElementTypeDef = "log_pipelines"
 	ElementTypeLbExporter    ElementTypeDef = "lb_exporter"
+	ElementTypeLogMetrics    ElementTypeDef = "log_metrics"
 )
 
 type DeployStatus string
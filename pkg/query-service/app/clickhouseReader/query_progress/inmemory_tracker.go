@@ -18,7 +18,7 @@ type inMemoryQueryProgressTracker struct {
 }
 
 func (tracker *inMemoryQueryProgressTracker) ReportQueryStarted(
-	queryId string,
+	queryId string, cancel func(),
 ) (postQueryCleanup func(), err *model.ApiError) {
 	tracker.lock.Lock()
 	defer tracker.lock.Unlock()
@@ -30,7 +30,7 @@ func (tracker *inMemoryQueryProgressTracker) ReportQueryStarted(
 		))
 	}
 
-	tracker.queries[queryId] = newQueryTracker(queryId)
+	tracker.queries[queryId] = newQueryTracker(queryId, cancel)
 
 	return func() {
 		tracker.onQueryFinished(queryId)
@@ -60,6 +60,26 @@ func (tracker *inMemoryQueryProgressTracker) SubscribeToQueryProgress(
 	return queryTracker.subscribe()
 }
 
+func (tracker *inMemoryQueryProgressTracker) CancelQuery(
+	queryId string,
+) *model.ApiError {
+	tracker.lock.Lock()
+	queryTracker := tracker.queries[queryId]
+	if queryTracker != nil {
+		delete(tracker.queries, queryId)
+	}
+	tracker.lock.Unlock()
+
+	if queryTracker == nil {
+		return model.NotFoundError(fmt.Errorf(
+			"query %s doesn't exist", queryId,
+		))
+	}
+
+	queryTracker.onCancelled()
+	return nil
+}
+
 func (tracker *inMemoryQueryProgressTracker) onQueryFinished(
 	queryId string,
 ) {
@@ -95,6 +115,7 @@ func (tracker *inMemoryQueryProgressTracker) getQueryTracker(
 type queryTracker struct {
 	queryId    string
 	isFinished bool
+	cancel     func()
 
 	progress      *model.QueryProgress
 	subscriptions map[string]*queryProgressSubscription
@@ -102,9 +123,10 @@ type queryTracker struct {
 	lock sync.Mutex
 }
 
-func newQueryTracker(queryId string) *queryTracker {
+func newQueryTracker(queryId string, cancel func()) *queryTracker {
 	return &queryTracker{
 		queryId:       queryId,
+		cancel:        cancel,
 		subscriptions: map[string]*queryProgressSubscription{},
 	}
 }
@@ -198,6 +220,34 @@ func (qt *queryTracker) onFinished() {
 	qt.isFinished = true
 }
 
+func (qt *queryTracker) onCancelled() {
+	qt.lock.Lock()
+	defer qt.lock.Unlock()
+
+	if qt.isFinished {
+		return
+	}
+
+	if qt.cancel != nil {
+		qt.cancel()
+	}
+
+	// let the subscribers know the query won't make any more progress
+	progress := model.QueryProgress{}
+	if qt.progress != nil {
+		progress = *qt.progress
+	}
+	progress.Cancelled = true
+
+	for subId, sub := range qt.subscriptions {
+		sub.send(progress)
+		sub.close()
+		delete(qt.subscriptions, subId)
+	}
+
+	qt.isFinished = true
+}
+
 type queryProgressSubscription struct {
 	ch       chan model.QueryProgress
 	isClosed bool
@@ -8,8 +8,9 @@ import (
 type QueryProgressTracker interface {
 	// Tells the tracker that query with id `queryId` has started.
 	// Progress can only be reported for and tracked for a query that is in progress.
+	// `cancel` is called if the query gets cancelled before it finishes.
 	// Returns a cleanup function that must be called after the query finishes.
-	ReportQueryStarted(queryId string) (postQueryCleanup func(), err *model.ApiError)
+	ReportQueryStarted(queryId string, cancel func()) (postQueryCleanup func(), err *model.ApiError)
 
 	// Report progress stats received from clickhouse for `queryId`
 	ReportQueryProgress(queryId string, chProgress *clickhouse.Progress) *model.ApiError
@@ -19,6 +20,11 @@ type QueryProgressTracker interface {
 	// the latest state of query progress stats. Also returns a function that
 	// can be called to unsubscribe before the query finishes, if needed.
 	SubscribeToQueryProgress(queryId string) (ch <-chan model.QueryProgress, unsubscribe func(), err *model.ApiError)
+
+	// Cancel the query with id `queryId` if it is still in progress.
+	// Subscribers receive a last update with `Cancelled` set before their
+	// channels get closed.
+	CancelQuery(queryId string) *model.ApiError
 }
 
 func NewQueryProgressTracker() QueryProgressTracker {
@@ -27,7 +27,7 @@ func TestQueryProgressTracking(t *testing.T) {
 	require.Nil(ch)
 	require.Nil(unsubscribe)
 
-	reportQueryFinished, err := tracker.ReportQueryStarted(testQueryId)
+	reportQueryFinished, err := tracker.ReportQueryStarted(testQueryId, func() {})
 	require.Nil(err, "should be able to report start of a query to be tracked")
 
 	testProgress1 := &clickhouse.Progress{
@@ -99,3 +99,56 @@ func TestQueryProgressTracking(t *testing.T) {
 	require.Nil(ch)
 	require.Nil(unsubscribe)
 }
+
+func TestQueryCancellation(t *testing.T) {
+	require := require.New(t)
+
+	tracker := NewQueryProgressTracker()
+
+	testQueryId := "test-query"
+
+	err := tracker.CancelQuery(testQueryId)
+	require.NotNil(err, "shouldn't be able to cancel a query that hasn't been started")
+	require.Equal(err.Type(), model.ErrorNotFound)
+
+	isCancelled := false
+	reportQueryFinished, err := tracker.ReportQueryStarted(testQueryId, func() {
+		isCancelled = true
+	})
+	require.Nil(err)
+
+	testProgress := &clickhouse.Progress{
+		Rows:    10,
+		Bytes:   20,
+		Elapsed: 20 * time.Millisecond,
+	}
+	err = tracker.ReportQueryProgress(testQueryId, testProgress)
+	require.Nil(err)
+
+	ch, _, err := tracker.SubscribeToQueryProgress(testQueryId)
+	require.Nil(err)
+	<-ch
+
+	err = tracker.CancelQuery(testQueryId)
+	require.Nil(err, "should be able to cancel a query in progress")
+	require.True(isCancelled, "the query should get cancelled")
+
+	expectedProgress := model.QueryProgress{}
+	updateQueryProgress(&expectedProgress, testProgress)
+	expectedProgress.Cancelled = true
+	select {
+	case qp := <-ch:
+		require.Equal(expectedProgress, qp, "subscribers should receive the cancelled state")
+	default:
+		require.Fail("subscribers should receive the cancelled state")
+	}
+	_, isSubscriptionChannelOpen := <-ch
+	require.False(isSubscriptionChannelOpen, "subscription channels should get closed after query is cancelled")
+
+	err = tracker.CancelQuery(testQueryId)
+	require.NotNil(err, "shouldn't be able to cancel a query twice")
+	require.Equal(err.Type(), model.ErrorNotFound)
+
+	// the cleanup after the cancelled query returns is a no-op
+	reportQueryFinished()
+}
@@ -50,6 +50,7 @@ import (
 	v3 "go.signoz.io/signoz/pkg/query-service/model/v3"
 	"go.signoz.io/signoz/pkg/query-service/telemetry"
 	"go.signoz.io/signoz/pkg/query-service/utils"
+	"go.signoz.io/signoz/pkg/query-service/utils/labels"
 )
 
 const (
@@ -182,7 +183,7 @@ func NewReaderFromClickhouseConnection(
 	cluster string,
 	useLogsNewSchema bool,
 ) *ClickHouseReader {
-	alertManager, err := am.New()
+	alertManager, err := am.GetManager()
 	if err != nil {
 		zap.L().Error("failed to initialize alert manager", zap.Error(err))
 		zap.L().Error("check if the alert manager URL is correctly set and valid")
@@ -3390,6 +3391,41 @@ func (r *ClickHouseReader) GetLogs(ctx context.Context, params *model.LogsFilter
 	return &response, nil
 }
 
+func (r *ClickHouseReader) GetLogsByQuery(ctx context.Context, query string) ([]model.SignozLog, *model.ApiError) {
+	if !r.useLogsNewSchema {
+		response := []model.SignozLog{}
+		if err := r.db.Select(ctx, &response, query); err != nil {
+			return nil, &model.ApiError{Err: err, Typ: model.ErrorInternal}
+		}
+		return response, nil
+	}
+
+	// the number attributes of the new schema are read as float attributes
+	responseV2 := []model.SignozLogV2{}
+	if err := r.db.Select(ctx, &responseV2, query); err != nil {
+		return nil, &model.ApiError{Err: err, Typ: model.ErrorInternal}
+	}
+	response := make([]model.SignozLog, 0, len(responseV2))
+	for _, log := range responseV2 {
+		response = append(response, model.SignozLog{
+			Timestamp:          log.Timestamp,
+			ID:                 log.ID,
+			TraceID:            log.TraceID,
+			SpanID:             log.SpanID,
+			TraceFlags:         log.TraceFlags,
+			SeverityText:       log.SeverityText,
+			SeverityNumber:     log.SeverityNumber,
+			Body:               log.Body,
+			Resources_string:   log.Resources_string,
+			Attributes_string:  log.Attributes_string,
+			Attributes_int64:   map[string]int64{},
+			Attributes_float64: log.Attributes_number,
+			Attributes_bool:    log.Attributes_bool,
+		})
+	}
+	return response, nil
+}
+
 func (r *ClickHouseReader) TailLogs(ctx context.Context, client *model.LogsTailClient) {
 
 	fields, apiErr := r.GetLogFields(ctx)
@@ -4320,6 +4356,45 @@ func logCommentKVs(ctx context.Context) map[string]string {
 	return logCommentKVs
 }
 
+// clickhouseQueryIdPrefix is the prefix of the clickhouse query ids of the
+// statements run for the query with id `queryId`
+func clickhouseQueryIdPrefix(queryId string) string {
+	return queryId + "-"
+}
+
+// withQueryProgressTracking hooks up progress reporting for the statement if the
+// context has a query id reported for progress tracking. The statement gets a
+// clickhouse query id prefixed with the query id so that it can be killed if the
+// query gets cancelled.
+func (r *ClickHouseReader) withQueryProgressTracking(ctx context.Context) context.Context {
+	queryId := ctx.Value("queryId")
+	if queryId == nil {
+		return ctx
+	}
+	qid, ok := queryId.(string)
+	if !ok {
+		zap.L().Error("queryId in ctx not a string as expected", zap.Any("queryId", queryId))
+		return ctx
+	}
+
+	return clickhouse.Context(ctx,
+		clickhouse.WithQueryID(clickhouseQueryIdPrefix(qid)+uuid.NewString()),
+		clickhouse.WithProgress(
+			func(p *clickhouse.Progress) {
+				go func() {
+					err := r.queryProgressTracker.ReportQueryProgress(qid, p)
+					if err != nil {
+						zap.L().Error(
+							"Couldn't report query progress",
+							zap.String("queryId", qid), zap.Error(err),
+						)
+					}
+				}()
+			},
+		),
+	)
+}
+
 // GetTimeSeriesResultV3 runs the query and returns list of time series
 func (r *ClickHouseReader) GetTimeSeriesResultV3(ctx context.Context, query string) ([]*v3.Series, error) {
 
@@ -4331,28 +4406,7 @@ func (r *ClickHouseReader) GetTimeSeriesResultV3(ctx context.Context, query stri
 	defer utils.Elapsed("GetTimeSeriesResultV3", ctxArgs)()
 
 	// Hook up query progress reporting if requested.
-	queryId := ctx.Value("queryId")
-	if queryId != nil {
-		qid, ok := queryId.(string)
-		if !ok {
-			zap.L().Error("GetTimeSeriesResultV3: queryId in ctx not a string as expected", zap.Any("queryId", queryId))
-
-		} else {
-			ctx = clickhouse.Context(ctx, clickhouse.WithProgress(
-				func(p *clickhouse.Progress) {
-					go func() {
-						err := r.queryProgressTracker.ReportQueryProgress(qid, p)
-						if err != nil {
-							zap.L().Error(
-								"Couldn't report query progress",
-								zap.String("queryId", qid), zap.Error(err),
-							)
-						}
-					}()
-				},
-			))
-		}
-	}
+	ctx = r.withQueryProgressTracking(ctx)
 
 	rows, err := r.db.Query(ctx, query)
 
@@ -4391,6 +4445,27 @@ func (r *ClickHouseReader) GetTimeSeriesResultV3(ctx context.Context, query stri
 	return readRowsForTimeSeriesResult(rows, vars, columnNames, countOfNumberCols)
 }
 
+// EstimateRowsScanned returns the number of rows clickhouse estimates to read
+// for the query, summed over the tables it reads
+func (r *ClickHouseReader) EstimateRowsScanned(ctx context.Context, query string) (uint64, error) {
+	rows, err := r.db.Query(ctx, "EXPLAIN ESTIMATE "+query)
+	if err != nil {
+		return 0, err
+	}
+	defer rows.Close()
+
+	var total uint64
+	for rows.Next() {
+		var database, table string
+		var parts, rowCount, marks uint64
+		if err := rows.Scan(&database, &table, &parts, &rowCount, &marks); err != nil {
+			return 0, err
+		}
+		total += rowCount
+	}
+	return total, rows.Err()
+}
+
 // GetListResultV3 runs the query and returns list of rows
 func (r *ClickHouseReader) GetListResultV3(ctx context.Context, query string) ([]*v3.Row, error) {
 
@@ -4401,6 +4476,9 @@ func (r *ClickHouseReader) GetListResultV3(ctx context.Context, query string) ([
 
 	defer utils.Elapsed("GetListResultV3", ctxArgs)()
 
+	// Hook up query progress reporting if requested.
+	ctx = r.withQueryProgressTracking(ctx)
+
 	rows, err := r.db.Query(ctx, query)
 
 	if err != nil {
@@ -4838,7 +4916,7 @@ func (r *ClickHouseReader) AddRuleStateHistory(ctx context.Context, ruleStateHis
 		}
 	}()
 
-	statement, err = r.db.PrepareBatch(ctx, fmt.Sprintf("INSERT INTO %s.%s (rule_id, rule_name, overall_state, overall_state_changed, state, state_changed, unix_milli, labels, fingerprint, value) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)",
+	statement, err = r.db.PrepareBatch(ctx, fmt.Sprintf("INSERT INTO %s.%s (rule_id, rule_name, overall_state, overall_state_changed, state, state_changed, unix_milli, labels, fingerprint, value, severity) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)",
 		signozHistoryDBName, ruleStateHistoryTableName))
 
 	if err != nil {
@@ -4846,7 +4924,7 @@ func (r *ClickHouseReader) AddRuleStateHistory(ctx context.Context, ruleStateHis
 	}
 
 	for _, history := range ruleStateHistory {
-		err = statement.Append(history.RuleID, history.RuleName, history.OverallState, history.OverallStateChanged, history.State, history.StateChanged, history.UnixMilli, history.Labels, history.Fingerprint, history.Value)
+		err = statement.Append(history.RuleID, history.RuleName, history.OverallState, history.OverallStateChanged, history.State, history.StateChanged, history.UnixMilli, history.Labels, history.Fingerprint, history.Value, history.Severity)
 		if err != nil {
 			return err
 		}
@@ -4859,6 +4937,71 @@ func (r *ClickHouseReader) AddRuleStateHistory(ctx context.Context, ruleStateHis
 	return nil
 }
 
+func (r *ClickHouseReader) AddMetricSamples(ctx context.Context, metricName string, unit string, series []*v3.Series) error {
+	var tsStatement, samplesStatement driver.Batch
+	var err error
+
+	defer func() {
+		if tsStatement != nil {
+			tsStatement.Abort()
+		}
+		if samplesStatement != nil {
+			samplesStatement.Abort()
+		}
+	}()
+
+	tsStatement, err = r.db.PrepareBatch(ctx, fmt.Sprintf("INSERT INTO %s.%s (env, temporality, metric_name, description, unit, type, is_monotonic, fingerprint, unix_milli, labels) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)",
+		signozMetricDBName, signozTSTableNameV4))
+	if err != nil {
+		return err
+	}
+
+	samplesStatement, err = r.db.PrepareBatch(ctx, fmt.Sprintf("INSERT INTO %s.%s (env, temporality, metric_name, fingerprint, unix_milli, value) VALUES ($1, $2, $3, $4, $5, $6)",
+		signozMetricDBName, signozSampleTableName))
+	if err != nil {
+		return err
+	}
+
+	for _, s := range series {
+		lbls := make(map[string]string, len(s.Labels)+1)
+		for k, v := range s.Labels {
+			lbls[k] = v
+		}
+		lbls["__name__"] = metricName
+		labelsJSON, err := json.Marshal(lbls)
+		if err != nil {
+			return err
+		}
+		fingerprint := labels.FromMap(lbls).Hash()
+
+		// the time series tables are written once per hour, the same
+		// way the collector does it
+		hours := map[int64]struct{}{}
+		for _, p := range s.Points {
+			if math.IsNaN(p.Value) || math.IsInf(p.Value, 0) {
+				continue
+			}
+			hour := p.Timestamp / time.Hour.Milliseconds() * time.Hour.Milliseconds()
+			if _, ok := hours[hour]; !ok {
+				hours[hour] = struct{}{}
+				err = tsStatement.Append("default", string(v3.Unspecified), metricName, "", unit, "Gauge", false, fingerprint, hour, string(labelsJSON))
+				if err != nil {
+					return err
+				}
+			}
+			err = samplesStatement.Append("default", string(v3.Unspecified), metricName, fingerprint, p.Timestamp, p.Value)
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	if err = tsStatement.Send(); err != nil {
+		return err
+	}
+	return samplesStatement.Send()
+}
+
 func (r *ClickHouseReader) GetLastSavedRuleStateHistory(ctx context.Context, ruleID string) ([]model.RuleStateHistory, error) {
 	query := fmt.Sprintf("SELECT * FROM %s.%s WHERE rule_id = '%s' AND state_changed = true ORDER BY unix_milli DESC LIMIT 1 BY fingerprint",
 		signozHistoryDBName, ruleStateHistoryTableName, ruleID)
@@ -5303,9 +5446,9 @@ func (r *ClickHouseReader) GetMinAndMaxTimestampForTraceID(ctx context.Context,
 }
 
 func (r *ClickHouseReader) ReportQueryStartForProgressTracking(
-	queryId string,
+	queryId string, cancel func(),
 ) (func(), *model.ApiError) {
-	return r.queryProgressTracker.ReportQueryStarted(queryId)
+	return r.queryProgressTracker.ReportQueryStarted(queryId, cancel)
 }
 
 func (r *ClickHouseReader) SubscribeToQueryProgress(
@@ -5313,3 +5456,22 @@ func (r *ClickHouseReader) SubscribeToQueryProgress(
 ) (<-chan model.QueryProgress, func(), *model.ApiError) {
 	return r.queryProgressTracker.SubscribeToQueryProgress(queryId)
 }
+
+// CancelQuery cancels the query with id `queryId` reported for progress tracking
+// and kills the statements of the query still running in clickhouse
+func (r *ClickHouseReader) CancelQuery(
+	ctx context.Context, queryId string,
+) *model.ApiError {
+	apiErr := r.queryProgressTracker.CancelQuery(queryId)
+	if apiErr != nil {
+		return apiErr
+	}
+
+	killQuery := fmt.Sprintf("KILL QUERY ON CLUSTER %s WHERE startsWith(initial_query_id, ?) ASYNC", r.cluster)
+	err := r.db.Exec(ctx, killQuery, clickhouseQueryIdPrefix(queryId))
+	if err != nil {
+		zap.L().Error("couldn't kill the clickhouse queries of cancelled query", zap.String("queryId", queryId), zap.Error(err))
+		return model.InternalError(fmt.Errorf("couldn't kill the clickhouse queries of query %s: %w", queryId, err))
+	}
+	return nil
+}
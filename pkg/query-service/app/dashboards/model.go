@@ -99,6 +99,37 @@ func InitDB(dataSourceName string) (*sqlx.DB, error) {
 		return nil, fmt.Errorf("error in creating planned_maintenance table: %s", err.Error())
 	}
 
+	table_schema = `CREATE TABLE IF NOT EXISTS slos (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		created_at datetime NOT NULL,
+		created_by TEXT,
+		updated_at datetime NOT NULL,
+		updated_by TEXT,
+		data TEXT NOT NULL
+	);`
+
+	_, err = db.Exec(table_schema)
+	if err != nil {
+		return nil, fmt.Errorf("error in creating slos table: %s", err.Error())
+	}
+
+	table_schema = `CREATE TABLE IF NOT EXISTS notification_templates (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		name TEXT NOT NULL UNIQUE,
+		channel_type TEXT NOT NULL,
+		title TEXT NOT NULL DEFAULT '',
+		body TEXT NOT NULL,
+		created_at datetime NOT NULL,
+		created_by TEXT,
+		updated_at datetime NOT NULL,
+		updated_by TEXT
+	);`
+
+	_, err = db.Exec(table_schema)
+	if err != nil {
+		return nil, fmt.Errorf("error in creating notification_templates table: %s", err.Error())
+	}
+
 	table_schema = `CREATE TABLE IF NOT EXISTS ttl_status (
 		id INTEGER PRIMARY KEY AUTOINCREMENT,
 		transaction_id TEXT NOT NULL,
@@ -152,6 +183,12 @@ func InitDB(dataSourceName string) (*sqlx.DB, error) {
 		return nil, fmt.Errorf("error in adding column locked to dashboards table: %s", err.Error())
 	}
 
+	labelSelectors := `ALTER TABLE planned_maintenance ADD COLUMN label_selectors TEXT;`
+	_, err = db.Exec(labelSelectors)
+	if err != nil && !strings.Contains(err.Error(), "duplicate column name") {
+		return nil, fmt.Errorf("error in adding column label_selectors to planned_maintenance table: %s", err.Error())
+	}
+
 	telemetry.GetInstance().SetDashboardsInfoCallback(GetDashboardsInfo)
 
 	return db, nil
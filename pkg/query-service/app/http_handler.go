@@ -44,12 +44,15 @@ import (
 	"go.signoz.io/signoz/pkg/query-service/common"
 	"go.signoz.io/signoz/pkg/query-service/constants"
 	"go.signoz.io/signoz/pkg/query-service/contextlinks"
+	chErrors "go.signoz.io/signoz/pkg/query-service/errors"
 	v3 "go.signoz.io/signoz/pkg/query-service/model/v3"
 	"go.signoz.io/signoz/pkg/query-service/postprocess"
+	"go.signoz.io/signoz/pkg/query-service/querylimits"
 
 	"go.uber.org/zap"
 
 	mq "go.signoz.io/signoz/pkg/query-service/app/integrations/messagingQueues/kafka"
+	"go.signoz.io/signoz/pkg/query-service/app/logmetrics"
 	"go.signoz.io/signoz/pkg/query-service/app/logparsingpipeline"
 	"go.signoz.io/signoz/pkg/query-service/dao"
 	am "go.signoz.io/signoz/pkg/query-service/integrations/alertManager"
@@ -102,6 +105,8 @@ type APIHandler struct {
 
 	LogsParsingPipelineController *logparsingpipeline.LogParsingPipelineController
 
+	LogMetricsController *logmetrics.LogMetricsController
+
 	// SetupCompleted indicates if SigNoz is ready for general use.
 	// at the moment, we mark the app ready when the first user
 	// is registers.
@@ -114,6 +119,10 @@ type APIHandler struct {
 
 	hostsRepo     *inframetrics.HostsRepo
 	processesRepo *inframetrics.ProcessesRepo
+
+	// cache and keyGenerator of the query cache inspected by the admin APIs
+	cache        cache.Cache
+	keyGenerator cache.KeyGenerator
 }
 
 type APIHandlerOpts struct {
@@ -144,6 +153,9 @@ type APIHandlerOpts struct {
 	// Log parsing pipelines
 	LogsParsingPipelineController *logparsingpipeline.LogParsingPipelineController
 
+	// Metrics derived from logs by the collectors
+	LogMetricsController *logmetrics.LogMetricsController
+
 	// cache
 	Cache cache.Cache
 
@@ -152,12 +164,15 @@ type APIHandlerOpts struct {
 
 	// Use Logs New schema
 	UseLogsNewSchema bool
+
+	// Query limits of the roles
+	QueryLimits *querylimits.Config
 }
 
 // NewAPIHandler returns an APIHandler
 func NewAPIHandler(opts APIHandlerOpts) (*APIHandler, error) {
 
-	alertManager, err := am.New()
+	alertManager, err := am.GetManager()
 	if err != nil {
 		return nil, err
 	}
@@ -169,6 +184,7 @@ func NewAPIHandler(opts APIHandlerOpts) (*APIHandler, error) {
 		FluxInterval:     opts.FluxInterval,
 		FeatureLookup:    opts.FeatureFlags,
 		UseLogsNewSchema: opts.UseLogsNewSchema,
+		QueryLimits:      opts.QueryLimits,
 	}
 
 	querierOptsV2 := querierV2.QuerierOptions{
@@ -178,6 +194,7 @@ func NewAPIHandler(opts APIHandlerOpts) (*APIHandler, error) {
 		FluxInterval:     opts.FluxInterval,
 		FeatureLookup:    opts.FeatureFlags,
 		UseLogsNewSchema: opts.UseLogsNewSchema,
+		QueryLimits:      opts.QueryLimits,
 	}
 
 	querier := querier.NewQuerier(querierOpts)
@@ -200,11 +217,14 @@ func NewAPIHandler(opts APIHandlerOpts) (*APIHandler, error) {
 		featureFlags:                  opts.FeatureFlags,
 		IntegrationsController:        opts.IntegrationsController,
 		LogsParsingPipelineController: opts.LogsParsingPipelineController,
+		LogMetricsController:          opts.LogMetricsController,
 		querier:                       querier,
 		querierV2:                     querierv2,
 		UseLogsNewSchema:              opts.UseLogsNewSchema,
 		hostsRepo:                     hostsRepo,
 		processesRepo:                 processesRepo,
+		cache:                         opts.Cache,
+		keyGenerator:                  queryBuilder.NewKeyGenerator(),
 	}
 
 	logsQueryBuilder := logsv3.PrepareLogsQuery
@@ -345,9 +365,11 @@ func (aH *APIHandler) RegisterQueryRangeV3Routes(router *mux.Router, am *AuthMid
 	subRouter.HandleFunc("/query_range/format", am.ViewAccess(aH.QueryRangeV3Format)).Methods(http.MethodPost)
 
 	subRouter.HandleFunc("/filter_suggestions", am.ViewAccess(aH.getQueryBuilderSuggestions)).Methods(http.MethodGet)
+	subRouter.HandleFunc("/filter_expression", am.ViewAccess(aH.convertFilterExpression)).Methods(http.MethodPost)
 
 	// TODO(Raj): Remove this handler after /ws based path has been completely rolled out.
 	subRouter.HandleFunc("/query_progress", am.ViewAccess(aH.GetQueryProgressUpdates)).Methods(http.MethodGet)
+	subRouter.HandleFunc("/query_progress/cancel", am.EditAccess(aH.cancelQuery)).Methods(http.MethodPost)
 
 	// live logs
 	subRouter.HandleFunc("/logs/livetail", am.ViewAccess(aH.liveTailLogs)).Methods(http.MethodGet)
@@ -400,22 +422,40 @@ func (aH *APIHandler) RegisterRoutes(router *mux.Router, am *AuthMiddleware) {
 	router.HandleFunc("/api/v1/alerts", am.ViewAccess(aH.getAlerts)).Methods(http.MethodGet)
 
 	router.HandleFunc("/api/v1/rules", am.ViewAccess(aH.listRules)).Methods(http.MethodGet)
+	router.HandleFunc("/api/v1/rules/bundle", am.ViewAccess(aH.exportRulesBundle)).Methods(http.MethodGet)
+	router.HandleFunc("/api/v1/rules/bundle", am.EditAccess(aH.applyRulesBundle)).Methods(http.MethodPost)
 	router.HandleFunc("/api/v1/rules/{id}", am.ViewAccess(aH.getRule)).Methods(http.MethodGet)
 	router.HandleFunc("/api/v1/rules", am.EditAccess(aH.createRule)).Methods(http.MethodPost)
 	router.HandleFunc("/api/v1/rules/{id}", am.EditAccess(aH.editRule)).Methods(http.MethodPut)
 	router.HandleFunc("/api/v1/rules/{id}", am.EditAccess(aH.deleteRule)).Methods(http.MethodDelete)
 	router.HandleFunc("/api/v1/rules/{id}", am.EditAccess(aH.patchRule)).Methods(http.MethodPatch)
 	router.HandleFunc("/api/v1/testRule", am.EditAccess(aH.testRule)).Methods(http.MethodPost)
+	router.HandleFunc("/api/v1/backtestRule", am.EditAccess(aH.backtestRule)).Methods(http.MethodPost)
 	router.HandleFunc("/api/v1/rules/{id}/history/stats", am.ViewAccess(aH.getRuleStats)).Methods(http.MethodPost)
 	router.HandleFunc("/api/v1/rules/{id}/history/timeline", am.ViewAccess(aH.getRuleStateHistory)).Methods(http.MethodPost)
 	router.HandleFunc("/api/v1/rules/{id}/history/top_contributors", am.ViewAccess(aH.getRuleStateHistoryTopContributors)).Methods(http.MethodPost)
 	router.HandleFunc("/api/v1/rules/{id}/history/overall_status", am.ViewAccess(aH.getOverallStateTransitions)).Methods(http.MethodPost)
 
-	router.HandleFunc("/api/v1/downtime_schedules", am.OpenAccess(aH.listDowntimeSchedules)).Methods(http.MethodGet)
-	router.HandleFunc("/api/v1/downtime_schedules/{id}", am.OpenAccess(aH.getDowntimeSchedule)).Methods(http.MethodGet)
-	router.HandleFunc("/api/v1/downtime_schedules", am.OpenAccess(aH.createDowntimeSchedule)).Methods(http.MethodPost)
-	router.HandleFunc("/api/v1/downtime_schedules/{id}", am.OpenAccess(aH.editDowntimeSchedule)).Methods(http.MethodPut)
-	router.HandleFunc("/api/v1/downtime_schedules/{id}", am.OpenAccess(aH.deleteDowntimeSchedule)).Methods(http.MethodDelete)
+	router.HandleFunc("/api/v1/downtime_schedules", am.ViewAccess(aH.listDowntimeSchedules)).Methods(http.MethodGet)
+	router.HandleFunc("/api/v1/downtime_schedules/{id}", am.ViewAccess(aH.getDowntimeSchedule)).Methods(http.MethodGet)
+	router.HandleFunc("/api/v1/downtime_schedules/{id}/occurrences", am.ViewAccess(aH.getDowntimeScheduleOccurrences)).Methods(http.MethodGet)
+	router.HandleFunc("/api/v1/downtime_schedules", am.EditAccess(aH.createDowntimeSchedule)).Methods(http.MethodPost)
+	router.HandleFunc("/api/v1/downtime_schedules/{id}", am.EditAccess(aH.editDowntimeSchedule)).Methods(http.MethodPut)
+	router.HandleFunc("/api/v1/downtime_schedules/{id}", am.EditAccess(aH.deleteDowntimeSchedule)).Methods(http.MethodDelete)
+
+	router.HandleFunc("/api/v1/slos", am.ViewAccess(aH.listSLOs)).Methods(http.MethodGet)
+	router.HandleFunc("/api/v1/slos", am.EditAccess(aH.createSLO)).Methods(http.MethodPost)
+	router.HandleFunc("/api/v1/slos/{id}", am.ViewAccess(aH.getSLO)).Methods(http.MethodGet)
+	router.HandleFunc("/api/v1/slos/{id}", am.EditAccess(aH.editSLO)).Methods(http.MethodPut)
+	router.HandleFunc("/api/v1/slos/{id}", am.EditAccess(aH.deleteSLO)).Methods(http.MethodDelete)
+	router.HandleFunc("/api/v1/slos/{id}/status", am.ViewAccess(aH.getSLOStatus)).Methods(http.MethodGet)
+
+	router.HandleFunc("/api/v1/notification_templates", am.ViewAccess(aH.listNotificationTemplates)).Methods(http.MethodGet)
+	router.HandleFunc("/api/v1/notification_templates", am.EditAccess(aH.createNotificationTemplate)).Methods(http.MethodPost)
+	router.HandleFunc("/api/v1/notification_templates/preview", am.EditAccess(aH.previewNotificationTemplate)).Methods(http.MethodPost)
+	router.HandleFunc("/api/v1/notification_templates/{id}", am.ViewAccess(aH.getNotificationTemplate)).Methods(http.MethodGet)
+	router.HandleFunc("/api/v1/notification_templates/{id}", am.EditAccess(aH.editNotificationTemplate)).Methods(http.MethodPut)
+	router.HandleFunc("/api/v1/notification_templates/{id}", am.EditAccess(aH.deleteNotificationTemplate)).Methods(http.MethodDelete)
 
 	router.HandleFunc("/api/v1/dashboards", am.ViewAccess(aH.getDashboards)).Methods(http.MethodGet)
 	router.HandleFunc("/api/v1/dashboards", am.EditAccess(aH.createDashboards)).Methods(http.MethodPost)
@@ -449,6 +489,13 @@ func (aH *APIHandler) RegisterRoutes(router *mux.Router, am *AuthMiddleware) {
 	router.HandleFunc("/api/v1/settings/ingestion_key", am.AdminAccess(aH.insertIngestionKey)).Methods(http.MethodPost)
 	router.HandleFunc("/api/v1/settings/ingestion_key", am.ViewAccess(aH.getIngestionKeys)).Methods(http.MethodGet)
 
+	router.HandleFunc("/api/v1/query_cache/stats", am.AdminAccess(aH.getQueryCacheStats)).Methods(http.MethodGet)
+	router.HandleFunc("/api/v1/query_cache/entries", am.AdminAccess(aH.listQueryCacheEntries)).Methods(http.MethodGet)
+	router.HandleFunc("/api/v1/query_cache/entries/{hash}", am.AdminAccess(aH.getQueryCacheEntry)).Methods(http.MethodGet)
+	router.HandleFunc("/api/v1/query_cache/entries/{hash}", am.AdminAccess(aH.deleteQueryCacheEntry)).Methods(http.MethodDelete)
+	router.HandleFunc("/api/v1/query_cache/dashboards/{uuid}", am.AdminAccess(aH.listDashboardQueryCacheEntries)).Methods(http.MethodGet)
+	router.HandleFunc("/api/v1/query_cache/dashboards/{uuid}", am.AdminAccess(aH.purgeDashboardQueryCache)).Methods(http.MethodDelete)
+
 	router.HandleFunc("/api/v1/version", am.OpenAccess(aH.getVersion)).Methods(http.MethodGet)
 	router.HandleFunc("/api/v1/featureFlags", am.OpenAccess(aH.getFeatureFlags)).Methods(http.MethodGet)
 	router.HandleFunc("/api/v1/configs", am.OpenAccess(aH.getConfigs)).Methods(http.MethodGet)
@@ -640,6 +687,36 @@ func (aH *APIHandler) getDowntimeSchedule(w http.ResponseWriter, r *http.Request
 	aH.Respond(w, schedule)
 }
 
+// getDowntimeScheduleOccurrences lists the upcoming windows of a schedule,
+// including the window in progress. The number of windows is set with the
+// count query param, 10 by default.
+func (aH *APIHandler) getDowntimeScheduleOccurrences(w http.ResponseWriter, r *http.Request) {
+	id := mux.Vars(r)["id"]
+
+	count := 10
+	if v := r.URL.Query().Get("count"); v != "" {
+		c, err := strconv.Atoi(v)
+		if err != nil || c <= 0 || c > 100 {
+			RespondError(w, &model.ApiError{Typ: model.ErrorBadData, Err: fmt.Errorf("count must be between 1 and 100")}, nil)
+			return
+		}
+		count = c
+	}
+
+	schedule, err := aH.ruleManager.RuleDB().GetPlannedMaintenanceByID(r.Context(), id)
+	if err != nil {
+		RespondError(w, &model.ApiError{Typ: model.ErrorInternal, Err: err}, nil)
+		return
+	}
+
+	occurrences, err := schedule.Occurrences(time.Now(), count)
+	if err != nil {
+		RespondError(w, &model.ApiError{Typ: model.ErrorBadData, Err: err}, nil)
+		return
+	}
+	aH.Respond(w, occurrences)
+}
+
 func (aH *APIHandler) createDowntimeSchedule(w http.ResponseWriter, r *http.Request) {
 	var schedule rules.PlannedMaintenance
 	err := json.NewDecoder(r.Body).Decode(&schedule)
@@ -819,7 +896,8 @@ func (aH *APIHandler) metaForLinks(ctx context.Context, rule *rules.GettableRule
 			if rule.RuleCondition.QueryType() == v3.QueryTypeBuilder {
 				selectedQuery := rule.RuleCondition.GetSelectedQueryName()
 				if rule.RuleCondition.CompositeQuery.BuilderQueries[selectedQuery] != nil &&
-					rule.RuleCondition.CompositeQuery.BuilderQueries[selectedQuery].Filters != nil {
+					rule.RuleCondition.CompositeQuery.BuilderQueries[selectedQuery].Filters != nil &&
+					rule.RuleCondition.CompositeQuery.BuilderQueries[selectedQuery].Filters.IsConjunction() {
 					filterItems = rule.RuleCondition.CompositeQuery.BuilderQueries[selectedQuery].Filters.Items
 				}
 				if rule.RuleCondition.CompositeQuery.BuilderQueries[selectedQuery] != nil &&
@@ -1165,6 +1243,49 @@ func (aH *APIHandler) testRule(w http.ResponseWriter, r *http.Request) {
 	aH.Respond(w, response)
 }
 
+// backtestRule replays the rule in the body over the window given by the
+// start and end query params in epoch millis, the last 7 days by default
+func (aH *APIHandler) backtestRule(w http.ResponseWriter, r *http.Request) {
+
+	defer r.Body.Close()
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		zap.L().Error("Error in getting req body in backtest rule API", zap.Error(err))
+		RespondError(w, &model.ApiError{Typ: model.ErrorBadData, Err: err}, nil)
+		return
+	}
+
+	end := time.Now()
+	if v := r.URL.Query().Get("end"); v != "" {
+		ms, err := strconv.ParseInt(v, 10, 64)
+		if err != nil {
+			RespondError(w, &model.ApiError{Typ: model.ErrorBadData, Err: fmt.Errorf("invalid end: %s", v)}, nil)
+			return
+		}
+		end = time.UnixMilli(ms)
+	}
+	start := end.Add(-7 * 24 * time.Hour)
+	if v := r.URL.Query().Get("start"); v != "" {
+		ms, err := strconv.ParseInt(v, 10, 64)
+		if err != nil {
+			RespondError(w, &model.ApiError{Typ: model.ErrorBadData, Err: fmt.Errorf("invalid start: %s", v)}, nil)
+			return
+		}
+		start = time.UnixMilli(ms)
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), 10*time.Minute)
+	defer cancel()
+
+	result, apiErr := aH.ruleManager.Backtest(ctx, string(body), start, end)
+	if apiErr != nil {
+		RespondError(w, apiErr, nil)
+		return
+	}
+
+	aH.Respond(w, result)
+}
+
 func (aH *APIHandler) deleteRule(w http.ResponseWriter, r *http.Request) {
 
 	id := mux.Vars(r)["id"]
@@ -1376,6 +1497,52 @@ func (aH *APIHandler) createRule(w http.ResponseWriter, r *http.Request) {
 
 }
 
+// exportRulesBundle writes all the rules and planned maintenances as a
+// bundle, yaml by default or json with format=json
+func (aH *APIHandler) exportRulesBundle(w http.ResponseWriter, r *http.Request) {
+	kind, contentType := rules.RuleDataKindYaml, "application/yaml"
+	if r.URL.Query().Get("format") == "json" {
+		kind, contentType = rules.RuleDataKindJson, "application/json"
+	}
+
+	bundle, err := aH.ruleManager.ExportBundle(r.Context(), kind)
+	if err != nil {
+		RespondError(w, &model.ApiError{Typ: model.ErrorInternal, Err: err}, nil)
+		return
+	}
+
+	w.Header().Set("Content-Type", contentType)
+	w.Write(bundle)
+}
+
+// applyRulesBundle syncs the rules and planned maintenances with the bundle
+// in the request body. With dryRun=true only the diff is returned, with
+// prune=true the rules and maintenances missing in the bundle are deleted.
+func (aH *APIHandler) applyRulesBundle(w http.ResponseWriter, r *http.Request) {
+	defer r.Body.Close()
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		zap.L().Error("Error in getting req body for apply rules bundle API", zap.Error(err))
+		RespondError(w, &model.ApiError{Typ: model.ErrorBadData, Err: err}, nil)
+		return
+	}
+
+	dryRun := r.URL.Query().Get("dryRun") == "true"
+	prune := r.URL.Query().Get("prune") == "true"
+
+	diff, err := aH.ruleManager.ApplyBundle(r.Context(), body, dryRun, prune)
+	if err != nil {
+		apiErr, ok := err.(*model.ApiError)
+		if !ok {
+			apiErr = &model.ApiError{Typ: model.ErrorInternal, Err: err}
+		}
+		RespondError(w, apiErr, diff)
+		return
+	}
+
+	aH.Respond(w, diff)
+}
+
 func (aH *APIHandler) queryRangeMetrics(w http.ResponseWriter, r *http.Request) {
 
 	query, apiErrorObj := parseQueryRangeRequest(r)
@@ -3450,8 +3617,18 @@ func (aH *APIHandler) RegisterLogsRoutes(router *mux.Router, am *AuthMiddleware)
 
 	// log pipelines
 	subRouter.HandleFunc("/pipelines/preview", am.ViewAccess(aH.PreviewLogsPipelinesHandler)).Methods(http.MethodPost)
+	subRouter.HandleFunc("/pipelines/preview/operators", am.ViewAccess(aH.PreviewPipelineOperatorsHandler)).Methods(http.MethodPost)
+	subRouter.HandleFunc("/pipelines/diff", am.ViewAccess(aH.DiffLogsPipelinesHandler)).Methods(http.MethodGet)
+	subRouter.HandleFunc("/pipelines/rollout", am.ViewAccess(aH.GetLogsPipelinesRolloutHandler)).Methods(http.MethodGet)
+	subRouter.HandleFunc("/pipelines/rollout/promote", am.EditAccess(aH.PromoteLogsPipelinesHandler)).Methods(http.MethodPost)
 	subRouter.HandleFunc("/pipelines/{version}", am.ViewAccess(aH.ListLogsPipelinesHandler)).Methods(http.MethodGet)
+	subRouter.HandleFunc("/pipelines/{version}/rollback", am.EditAccess(aH.RollbackLogsPipelinesHandler)).Methods(http.MethodPost)
+	subRouter.HandleFunc("/pipelines/{version}/stage", am.EditAccess(aH.StageLogsPipelinesHandler)).Methods(http.MethodPost)
 	subRouter.HandleFunc("/pipelines", am.EditAccess(aH.CreateLogsPipeline)).Methods(http.MethodPost)
+
+	// metrics derived from logs
+	subRouter.HandleFunc("/derived_metrics/{version}", am.ViewAccess(aH.ListLogMetricsHandler)).Methods(http.MethodGet)
+	subRouter.HandleFunc("/derived_metrics", am.EditAccess(aH.CreateLogMetricsHandler)).Methods(http.MethodPost)
 }
 
 func (aH *APIHandler) logFields(w http.ResponseWriter, r *http.Request) {
@@ -3603,6 +3780,88 @@ func (aH *APIHandler) PreviewLogsPipelinesHandler(w http.ResponseWriter, r *http
 	aH.Respond(w, resultLogs)
 }
 
+// PreviewPipelineOperatorsHandler returns the state of the logs after every
+// operator of a pipeline. The logs are sampled from the stored logs matching
+// the filter of the pipeline when none are sent.
+func (aH *APIHandler) PreviewPipelineOperatorsHandler(w http.ResponseWriter, r *http.Request) {
+	req := logparsingpipeline.OperatorPreviewRequest{}
+
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		RespondError(w, model.BadRequest(err), nil)
+		return
+	}
+
+	if len(req.Logs) == 0 {
+		logs, apiErr := aH.sampleLogsForPipelinePreview(r.Context(), &req)
+		if apiErr != nil {
+			RespondError(w, apiErr, "Failed to sample logs for the preview")
+			return
+		}
+		req.Logs = logs
+	}
+
+	result, apiErr := aH.LogsParsingPipelineController.PreviewPipelineOperators(
+		r.Context(), &req,
+	)
+	if apiErr != nil {
+		RespondError(w, apiErr, nil)
+		return
+	}
+
+	aH.Respond(w, result)
+}
+
+// sampleLogsForPipelinePreview returns the most recent logs matching the filter
+// of the pipeline in the time range of the request, the last hour by default
+func (aH *APIHandler) sampleLogsForPipelinePreview(
+	ctx context.Context, req *logparsingpipeline.OperatorPreviewRequest,
+) ([]model.SignozLog, *model.ApiError) {
+	limit := req.Limit
+	if limit <= 0 {
+		limit = logparsingpipeline.DefaultOperatorPreviewLogsLimit
+	}
+	if limit > logparsingpipeline.MaxOperatorPreviewLogsLimit {
+		limit = logparsingpipeline.MaxOperatorPreviewLogsLimit
+	}
+	end := req.End
+	if end == 0 {
+		end = time.Now().UnixMilli()
+	}
+	start := req.Start
+	if start == 0 {
+		start = end - time.Hour.Milliseconds()
+	}
+
+	query := &v3.BuilderQuery{
+		QueryName:         "A",
+		Expression:        "A",
+		DataSource:        v3.DataSourceLogs,
+		AggregateOperator: v3.AggregateOperatorNoOp,
+		StepInterval:      60,
+		Filters:           req.Pipeline.Filter,
+		PageSize:          uint64(limit),
+		OrderBy: []v3.OrderBy{
+			{ColumnName: constants.TIMESTAMP, Order: "desc"},
+		},
+	}
+	if query.Filters == nil {
+		query.Filters = &v3.FilterSet{Operator: "AND"}
+	}
+
+	var queryString string
+	var err error
+	if aH.UseLogsNewSchema {
+		queryString, err = logsv4.PrepareLogsQuery(start, end, v3.QueryTypeBuilder, v3.PanelTypeList, query, v3.LogQBOptions{})
+	} else {
+		queryString, err = logsv3.PrepareLogsQuery(start, end, v3.QueryTypeBuilder, v3.PanelTypeList, query, v3.LogQBOptions{})
+	}
+	if err != nil {
+		return nil, model.BadRequest(err)
+	}
+
+	return aH.reader.GetLogsByQuery(ctx, queryString)
+}
+
 func (aH *APIHandler) ListLogsPipelinesHandler(w http.ResponseWriter, r *http.Request) {
 
 	version, err := parseAgentConfigVersion(r)
@@ -3700,7 +3959,7 @@ func (aH *APIHandler) CreateLogsPipeline(w http.ResponseWriter, r *http.Request)
 			}
 		}
 
-		return aH.LogsParsingPipelineController.ApplyPipelines(ctx, postable)
+		return aH.LogsParsingPipelineController.ApplyPipelines(ctx, postable, req.StagedSelector)
 	}
 
 	res, err := createPipeline(r.Context(), req.Pipelines)
@@ -3712,6 +3971,137 @@ func (aH *APIHandler) CreateLogsPipeline(w http.ResponseWriter, r *http.Request)
 	aH.Respond(w, res)
 }
 
+// DiffLogsPipelinesHandler returns the pipelines added, changed and removed
+// between the versions in the from and to query params
+func (aH *APIHandler) DiffLogsPipelinesHandler(w http.ResponseWriter, r *http.Request) {
+	fromVersion, err := strconv.Atoi(r.URL.Query().Get("from"))
+	if err != nil || fromVersion <= 0 {
+		RespondError(w, model.BadRequestStr("invalid from version number"), nil)
+		return
+	}
+	toVersion, err := strconv.Atoi(r.URL.Query().Get("to"))
+	if err != nil || toVersion <= 0 {
+		RespondError(w, model.BadRequestStr("invalid to version number"), nil)
+		return
+	}
+
+	diff, apiErr := aH.LogsParsingPipelineController.DiffPipelineVersions(
+		r.Context(), fromVersion, toVersion,
+	)
+	if apiErr != nil {
+		RespondError(w, apiErr, nil)
+		return
+	}
+	aH.Respond(w, diff)
+}
+
+// RollbackLogsPipelinesHandler re-deploys the pipelines of an earlier version
+// to all the agents
+func (aH *APIHandler) RollbackLogsPipelinesHandler(w http.ResponseWriter, r *http.Request) {
+	version, apiErr := parseAgentConfigVersion(r)
+	if apiErr != nil || version == -1 {
+		RespondError(w, model.BadRequestStr("invalid version number"), nil)
+		return
+	}
+
+	payload, apiErr := aH.LogsParsingPipelineController.RollbackPipelines(r.Context(), version)
+	if apiErr != nil {
+		RespondError(w, apiErr, nil)
+		return
+	}
+	aH.Respond(w, payload)
+}
+
+type stageLogsPipelinesRequest struct {
+	Selector agentConf.AgentLabels `json:"selector"`
+}
+
+// StageLogsPipelinesHandler deploys the pipelines of a version only to the
+// agents with all the labels of the selector in the request
+func (aH *APIHandler) StageLogsPipelinesHandler(w http.ResponseWriter, r *http.Request) {
+	version, apiErr := parseAgentConfigVersion(r)
+	if apiErr != nil || version == -1 {
+		RespondError(w, model.BadRequestStr("invalid version number"), nil)
+		return
+	}
+
+	req := stageLogsPipelinesRequest{}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		RespondError(w, model.BadRequest(err), nil)
+		return
+	}
+
+	payload, apiErr := aH.LogsParsingPipelineController.StagePipelines(
+		r.Context(), version, req.Selector,
+	)
+	if apiErr != nil {
+		RespondError(w, apiErr, nil)
+		return
+	}
+	aH.Respond(w, payload)
+}
+
+func (aH *APIHandler) PromoteLogsPipelinesHandler(w http.ResponseWriter, r *http.Request) {
+	payload, apiErr := aH.LogsParsingPipelineController.PromoteStagedPipelines(r.Context())
+	if apiErr != nil {
+		RespondError(w, apiErr, nil)
+		return
+	}
+	aH.Respond(w, payload)
+}
+
+// GetLogsPipelinesRolloutHandler returns the pipelines version deployed to
+// the agents and the deployment status of each agent
+func (aH *APIHandler) GetLogsPipelinesRolloutHandler(w http.ResponseWriter, r *http.Request) {
+	payload, apiErr := aH.LogsParsingPipelineController.GetPipelinesRollout(r.Context())
+	if apiErr != nil {
+		RespondError(w, apiErr, nil)
+		return
+	}
+	aH.Respond(w, payload)
+}
+
+// ListLogMetricsHandler lists the metrics derived from logs in a version,
+// the latest version by default
+func (aH *APIHandler) ListLogMetricsHandler(w http.ResponseWriter, r *http.Request) {
+	version, err := parseAgentConfigVersion(r)
+	if err != nil {
+		RespondError(w, model.WrapApiError(err, "Failed to parse agent config version"), nil)
+		return
+	}
+
+	payload, apiErr := aH.LogMetricsController.GetLogMetricsByVersion(r.Context(), version)
+	if apiErr != nil {
+		RespondError(w, apiErr, nil)
+		return
+	}
+	aH.Respond(w, payload)
+}
+
+// CreateLogMetricsHandler saves the metrics derived from logs as a new version
+// deployed to the collectors
+func (aH *APIHandler) CreateLogMetricsHandler(w http.ResponseWriter, r *http.Request) {
+	req := logmetrics.PostableLogMetrics{}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		RespondError(w, model.BadRequest(err), nil)
+		return
+	}
+
+	for _, m := range req.Metrics {
+		if err := m.IsValid(); err != nil {
+			RespondError(w, model.BadRequestStr(err.Error()), nil)
+			return
+		}
+	}
+
+	payload, apiErr := aH.LogMetricsController.ApplyLogMetrics(r.Context(), req.Metrics)
+	if apiErr != nil {
+		RespondError(w, apiErr, nil)
+		return
+	}
+	aH.Respond(w, payload)
+}
+
 func (aH *APIHandler) getSavedViews(w http.ResponseWriter, r *http.Request) {
 	// get sourcePage, name, and category from the query params
 	sourcePage := r.URL.Query().Get("sourcePage")
@@ -3936,8 +4326,41 @@ func (aH *APIHandler) QueryRangeV3Format(w http.ResponseWriter, r *http.Request)
 	aH.Respond(w, queryRangeParams)
 }
 
+// filterExpression is a filter set in both the textual and the builder form
+type filterExpression struct {
+	Expression string        `json:"expression"`
+	Filters    *v3.FilterSet `json:"filters,omitempty"`
+}
+
+// convertFilterExpression compiles the expression of the request into a
+// filter set, or prints the filter set of the request as an expression
+// when no expression is given
+func (aH *APIHandler) convertFilterExpression(w http.ResponseWriter, r *http.Request) {
+	var req filterExpression
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		RespondError(w, &model.ApiError{Typ: model.ErrorBadData, Err: err}, nil)
+		return
+	}
+
+	if req.Expression == "" {
+		req.Expression = v3.FormatFilterExpression(req.Filters)
+	}
+	filters, err := v3.ParseFilterExpression(req.Expression)
+	if err != nil {
+		RespondError(w, &model.ApiError{Typ: model.ErrorBadData, Err: err}, err)
+		return
+	}
+	req.Filters = filters
+
+	aH.Respond(w, req)
+}
+
 func (aH *APIHandler) queryRangeV3(ctx context.Context, queryRangeParams *v3.QueryRangeParamsV3, w http.ResponseWriter, r *http.Request) {
 
+	// the previous period of the comparisons is queried and cached like any
+	// other query, the post processing finds it in the params
+	queryRangeParams = queryRangeParams.WithComparisonQueries()
+
 	var result []*v3.Result
 	var err error
 	var errQuriesByName map[string]error
@@ -3982,30 +4405,23 @@ func (aH *APIHandler) queryRangeV3(ctx context.Context, queryRangeParams *v3.Que
 	}
 
 	// Hook up query progress tracking if requested
-	queryIdHeader := r.Header.Get("X-SIGNOZ-QUERY-ID")
-	if len(queryIdHeader) > 0 {
-		onQueryFinished, err := aH.reader.ReportQueryStartForProgressTracking(queryIdHeader)
+	ctx, onQueryFinished := aH.trackQueryProgress(ctx, r)
+	defer onQueryFinished()
 
-		if err != nil {
-			zap.L().Error(
-				"couldn't report query start for progress tracking",
-				zap.String("queryId", queryIdHeader), zap.Error(err),
-			)
-
-		} else {
-			// Adding queryId to the context signals clickhouse queries to report progress
-			//lint:ignore SA1029 ignore for now
-			ctx = context.WithValue(ctx, "queryId", queryIdHeader)
-
-			defer func() {
-				go onQueryFinished()
-			}()
-		}
+	if r.Header.Get(querylimits.OverrideHeader) == "true" {
+		ctx = querylimits.WithOverride(ctx)
 	}
 
 	result, errQuriesByName, err = aH.querier.QueryRange(ctx, queryRangeParams)
 
 	if err != nil {
+		if respondQueryLimitError(w, err, errQuriesByName) {
+			return
+		}
+		if errors.Is(ctx.Err(), context.Canceled) {
+			RespondError(w, &model.ApiError{Typ: model.ErrorCanceled, Err: fmt.Errorf("query was cancelled")}, nil)
+			return
+		}
 		queryErrors := map[string]string{}
 		for name, err := range errQuriesByName {
 			queryErrors[fmt.Sprintf("Query-%s", name)] = err.Error()
@@ -4023,12 +4439,17 @@ func (aH *APIHandler) queryRangeV3(ctx context.Context, queryRangeParams *v3.Que
 	// are executed in clickhouse directly and we wanted to add support for timeshift
 	if queryRangeParams.CompositeQuery.QueryType == v3.QueryTypeBuilder {
 		postprocess.ApplyFunctions(result, queryRangeParams)
+		result = postprocess.ApplyComparison(result, queryRangeParams)
 	}
 
 	if queryRangeParams.CompositeQuery.FillGaps {
 		postprocess.FillGaps(result, queryRangeParams)
 	}
 
+	if queryRangeParams.CompositeQuery.QueryType == v3.QueryTypeBuilder {
+		postprocess.TransformToHeatmap(result, queryRangeParams)
+	}
+
 	if queryRangeParams.CompositeQuery.PanelType == v3.PanelTypeTable && queryRangeParams.FormatForWeb {
 		if queryRangeParams.CompositeQuery.QueryType == v3.QueryTypeClickHouseSQL {
 			result = postprocess.TransformToTableForClickHouseQueries(result)
@@ -4136,6 +4557,21 @@ func sendQueryResultEvents(r *http.Request, result []*v3.Result, queryRangeParam
 	}
 }
 
+// respondQueryLimitError responds with the structured error of the query limit
+// exceeded by the queries, it returns false when no query limit was exceeded
+func respondQueryLimitError(w http.ResponseWriter, err error, errQueriesByName map[string]error) bool {
+	errs := []error{err}
+	for _, queryErr := range errQueriesByName {
+		errs = append(errs, queryErr)
+	}
+	limitErr := chErrors.AsQueryLimitError(errs...)
+	if limitErr == nil {
+		return false
+	}
+	RespondError(w, &model.ApiError{Typ: model.ErrorExec, Err: limitErr}, limitErr)
+	return true
+}
+
 func (aH *APIHandler) QueryRangeV3(w http.ResponseWriter, r *http.Request) {
 	queryRangeParams, apiErrorObj := ParseQueryRangeParams(r)
 
@@ -4227,6 +4663,74 @@ func (aH *APIHandler) GetQueryProgressUpdates(w http.ResponseWriter, r *http.Req
 	}
 }
 
+// trackQueryProgress hooks up progress tracking and cancellation for the query if
+// the request has a query id. The query gets cancelled if the client disconnects
+// before it finishes. The returned function must be called after the query finishes.
+func (aH *APIHandler) trackQueryProgress(ctx context.Context, r *http.Request) (context.Context, func()) {
+	queryIdHeader := r.Header.Get("X-SIGNOZ-QUERY-ID")
+	if len(queryIdHeader) == 0 {
+		return ctx, func() {}
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	onQueryFinished, apiErr := aH.reader.ReportQueryStartForProgressTracking(queryIdHeader, cancel)
+	if apiErr != nil {
+		zap.L().Error(
+			"couldn't report query start for progress tracking",
+			zap.String("queryId", queryIdHeader), zap.Error(apiErr),
+		)
+		return ctx, cancel
+	}
+
+	// Adding queryId to the context signals clickhouse queries to report progress
+	//lint:ignore SA1029 ignore for now
+	ctx = context.WithValue(ctx, "queryId", queryIdHeader)
+
+	finished := make(chan struct{})
+	go func() {
+		select {
+		case <-finished:
+		case <-r.Context().Done():
+			select {
+			case <-finished:
+				return
+			default:
+			}
+			// the client went away before the query finished
+			apiErr := aH.reader.CancelQuery(context.Background(), queryIdHeader)
+			if apiErr != nil {
+				zap.L().Warn(
+					"couldn't cancel query after client disconnected",
+					zap.String("queryId", queryIdHeader), zap.Error(apiErr),
+				)
+			}
+		}
+	}()
+
+	return ctx, func() {
+		close(finished)
+		cancel()
+		go onQueryFinished()
+	}
+}
+
+// cancelQuery cancels the query with the id in the `q` query param, the
+// subscribers to the progress of the query get notified of the cancellation
+func (aH *APIHandler) cancelQuery(w http.ResponseWriter, r *http.Request) {
+	queryId := r.URL.Query().Get("q")
+	if len(queryId) == 0 {
+		RespondError(w, model.BadRequest(fmt.Errorf("query id is required")), nil)
+		return
+	}
+
+	apiErr := aH.reader.CancelQuery(r.Context(), queryId)
+	if apiErr != nil {
+		RespondError(w, apiErr, nil)
+		return
+	}
+	aH.Respond(w, nil)
+}
+
 func (aH *APIHandler) liveTailLogsV2(w http.ResponseWriter, r *http.Request) {
 
 	// get the param from url and add it to body
@@ -4408,6 +4912,10 @@ func (aH *APIHandler) getMetricMetadata(w http.ResponseWriter, r *http.Request)
 
 func (aH *APIHandler) queryRangeV4(ctx context.Context, queryRangeParams *v3.QueryRangeParamsV3, w http.ResponseWriter, r *http.Request) {
 
+	// the previous period of the comparisons is queried and cached like any
+	// other query, the post processing finds it in the params
+	queryRangeParams = queryRangeParams.WithComparisonQueries()
+
 	var result []*v3.Result
 	var err error
 	var errQuriesByName map[string]error
@@ -4451,9 +4959,24 @@ func (aH *APIHandler) queryRangeV4(ctx context.Context, queryRangeParams *v3.Que
 		}
 	}
 
+	// Hook up query progress tracking if requested
+	ctx, onQueryFinished := aH.trackQueryProgress(ctx, r)
+	defer onQueryFinished()
+
+	if r.Header.Get(querylimits.OverrideHeader) == "true" {
+		ctx = querylimits.WithOverride(ctx)
+	}
+
 	result, errQuriesByName, err = aH.querierV2.QueryRange(ctx, queryRangeParams)
 
 	if err != nil {
+		if respondQueryLimitError(w, err, errQuriesByName) {
+			return
+		}
+		if errors.Is(ctx.Err(), context.Canceled) {
+			RespondError(w, &model.ApiError{Typ: model.ErrorCanceled, Err: fmt.Errorf("query was cancelled")}, nil)
+			return
+		}
 		queryErrors := map[string]string{}
 		for name, err := range errQuriesByName {
 			queryErrors[fmt.Sprintf("Query-%s", name)] = err.Error()
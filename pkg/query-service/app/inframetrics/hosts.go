@@ -262,20 +262,20 @@ func (h *HostsRepo) getHostsForQuery(ctx context.Context,
 
 	for _, query := range query.CompositeQuery.BuilderQueries {
 		query.StepInterval = step
-		if req.Filters != nil && len(req.Filters.Items) > 0 {
+		if !req.Filters.IsEmpty() {
 			if query.Filters == nil {
 				query.Filters = &v3.FilterSet{Operator: "AND", Items: []v3.FilterItem{}}
 			}
-			query.Filters.Items = append(query.Filters.Items, req.Filters.Items...)
+			query.Filters.And(req.Filters.Clone())
 			// what is happening here?
 			// if the filter has host_name and we are querying for k8s host metrics,
 			// we need to replace the host_name with k8s_node_name
 			if hostNameAttrKey == "k8s_node_name" {
-				for idx, item := range query.Filters.Items {
+				query.Filters.VisitItems(func(item *v3.FilterItem) {
 					if item.Key.Key == "host_name" {
-						query.Filters.Items[idx].Key.Key = "k8s_node_name"
+						item.Key.Key = "k8s_node_name"
 					}
-				}
+				})
 			}
 		}
 	}
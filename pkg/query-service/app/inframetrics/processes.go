@@ -165,11 +165,11 @@ func (p *ProcessesRepo) GetProcessList(ctx context.Context, req model.ProcessLis
 
 	for _, query := range query.CompositeQuery.BuilderQueries {
 		query.StepInterval = step
-		if req.Filters != nil && len(req.Filters.Items) > 0 {
+		if !req.Filters.IsEmpty() {
 			if query.Filters == nil {
 				query.Filters = &v3.FilterSet{Operator: "AND", Items: []v3.FilterItem{}}
 			}
-			query.Filters.Items = append(query.Filters.Items, req.Filters.Items...)
+			query.Filters.And(req.Filters)
 		}
 	}
 
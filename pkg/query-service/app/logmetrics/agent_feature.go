@@ -0,0 +1,5 @@
+package logmetrics
+
+import "go.signoz.io/signoz/pkg/query-service/agentConf"
+
+const LogMetricsFeatureType agentConf.AgentFeatureType = "log_metrics"
@@ -0,0 +1,218 @@
+package logmetrics
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/pkg/errors"
+	"go.signoz.io/signoz/pkg/query-service/constants"
+	coreModel "go.signoz.io/signoz/pkg/query-service/model"
+	v3 "go.signoz.io/signoz/pkg/query-service/model/v3"
+	"go.uber.org/zap"
+	"gopkg.in/yaml.v3"
+)
+
+// The log metrics are produced by a signaltometrics connector. The connector
+// is an exporter of the logs pipeline, so it gets the logs processed by the
+// log pipelines, and a receiver of the metrics pipeline, so the metrics are
+// exported like the other metrics received by the collector. Only the
+// collectors declaring the connector in their description get the log
+// metrics, the others are sent the config without them.
+
+const (
+	logsPipelineName    = "logs"
+	metricsPipelineName = "metrics"
+)
+
+// connectorMetricConfig returns the config of the log metric in the connector
+func connectorMetricConfig(m LogMetric) (map[string]interface{}, error) {
+	conditions := []string{}
+	condition, err := filterToOTTLCondition(m.Filter)
+	if err != nil {
+		return nil, errors.Wrap(err, fmt.Sprintf("could not convert filter of log metric %s", m.Name))
+	}
+	if condition != "" {
+		conditions = append(conditions, condition)
+	}
+
+	// the series are always grouped by service, the connector includes all
+	// the resource attributes otherwise
+	resourceAttributes := []map[string]interface{}{{"key": "service.name"}}
+	attributes := []map[string]interface{}{}
+	for _, key := range m.GroupBy {
+		if key.Type == v3.AttributeKeyTypeResource {
+			if key.Key != "service.name" {
+				resourceAttributes = append(resourceAttributes, map[string]interface{}{"key": key.Key})
+			}
+			continue
+		}
+		attributes = append(attributes, map[string]interface{}{"key": key.Key})
+	}
+
+	metricConf := map[string]interface{}{
+		"name":                        m.Name,
+		"description":                 m.Description,
+		"include_resource_attributes": resourceAttributes,
+	}
+	if m.Unit != "" {
+		metricConf["unit"] = m.Unit
+	}
+	if len(attributes) > 0 {
+		metricConf["attributes"] = attributes
+	}
+
+	switch m.Type {
+	case LogMetricTypeCounter:
+		metricConf["sum"] = map[string]interface{}{
+			"value": "1",
+		}
+	case LogMetricTypeHistogram:
+		valuePath, err := attributeFieldPath(m.ValueField)
+		if err != nil {
+			return nil, err
+		}
+		// logs without the value aren't recorded
+		valueCondition := fmt.Sprintf("%s != nil", valuePath)
+		if len(conditions) > 0 {
+			conditions[0] = fmt.Sprintf("%s and (%s)", valueCondition, conditions[0])
+		} else {
+			conditions = append(conditions, valueCondition)
+		}
+		histogram := map[string]interface{}{
+			"value": fmt.Sprintf("Double(%s)", valuePath),
+		}
+		if len(m.Buckets) > 0 {
+			histogram["buckets"] = m.Buckets
+		}
+		metricConf["histogram"] = histogram
+	default:
+		return nil, fmt.Errorf("unsupported type %s of log metric %s", m.Type, m.Name)
+	}
+
+	if len(conditions) > 0 {
+		metricConf["conditions"] = conditions
+	}
+	return metricConf, nil
+}
+
+// GenerateCollectorConfigWithLogMetrics adds the connector producing the
+// enabled log metrics to the config, the connector is removed when there are
+// no enabled log metrics
+func GenerateCollectorConfigWithLogMetrics(
+	config []byte,
+	metrics []LogMetric,
+) ([]byte, *coreModel.ApiError) {
+	var collectorConf map[string]interface{}
+	err := yaml.Unmarshal(config, &collectorConf)
+	if err != nil {
+		return nil, coreModel.BadRequest(err)
+	}
+
+	metricConfs := []map[string]interface{}{}
+	for _, m := range metrics {
+		if !m.Enabled {
+			continue
+		}
+		metricConf, err := connectorMetricConfig(m)
+		if err != nil {
+			return nil, coreModel.BadRequest(err)
+		}
+		metricConfs = append(metricConfs, metricConf)
+	}
+
+	servicePipelines, _ := nestedMap(collectorConf, "service", "pipelines")
+	logsPipeline, _ := nestedMap(servicePipelines, logsPipelineName)
+	metricsPipeline, _ := nestedMap(servicePipelines, metricsPipelineName)
+
+	withConnector := len(metricConfs) > 0
+	if withConnector && (logsPipeline == nil || metricsPipeline == nil) {
+		zap.L().Warn(
+			"log metrics can't be produced by a collector without logs and metrics pipelines",
+			zap.Int("metrics", len(metricConfs)),
+		)
+		withConnector = false
+	}
+
+	connectors, _ := nestedMap(collectorConf, "connectors")
+	if withConnector {
+		if connectors == nil {
+			connectors = map[string]interface{}{}
+			collectorConf["connectors"] = connectors
+		}
+		connectorConf := map[string]interface{}{
+			"logs": metricConfs,
+		}
+		// Escape any `$`s as `$$` so that they aren't treated as env vars
+		// when loading the collector config.
+		escapedConf, err := escapeDollars(connectorConf)
+		if err != nil {
+			return nil, coreModel.InternalError(err)
+		}
+		connectors[constants.LogMetricsConnector] = escapedConf
+	} else if connectors != nil {
+		delete(connectors, constants.LogMetricsConnector)
+		if len(connectors) == 0 {
+			delete(collectorConf, "connectors")
+		}
+	}
+
+	updateComponentList(logsPipeline, "exporters", constants.LogMetricsConnector, withConnector)
+	updateComponentList(metricsPipeline, "receivers", constants.LogMetricsConnector, withConnector)
+
+	updatedConf, err := yaml.Marshal(collectorConf)
+	if err != nil {
+		return nil, coreModel.BadRequest(err)
+	}
+	return updatedConf, nil
+}
+
+// nestedMap returns the map at the path of keys in the config
+func nestedMap(conf map[string]interface{}, keys ...string) (map[string]interface{}, bool) {
+	current := conf
+	for _, key := range keys {
+		if current == nil {
+			return nil, false
+		}
+		next, ok := current[key].(map[string]interface{})
+		if !ok {
+			return nil, false
+		}
+		current = next
+	}
+	return current, current != nil
+}
+
+// updateComponentList adds the component to or removes it from a list of
+// component names of the pipeline, like its exporters
+func updateComponentList(pipeline map[string]interface{}, key string, component string, include bool) {
+	if pipeline == nil {
+		return
+	}
+	list, _ := pipeline[key].([]interface{})
+	result := []interface{}{}
+	for _, name := range list {
+		if name != component {
+			result = append(result, name)
+		}
+	}
+	if !include && len(result) == len(list) {
+		return
+	}
+	if include {
+		result = append(result, component)
+	}
+	pipeline[key] = result
+}
+
+func escapeDollars(conf map[string]interface{}) (map[string]interface{}, error) {
+	serializedConf, err := yaml.Marshal(conf)
+	if err != nil {
+		return nil, errors.Wrap(err, "could not marshal log metrics connector config")
+	}
+	var escapedConf map[string]interface{}
+	err = yaml.Unmarshal([]byte(strings.ReplaceAll(string(serializedConf), "$", "$$")), &escapedConf)
+	if err != nil {
+		return nil, errors.Wrap(err, "could not unmarshal dollar escaped log metrics connector config")
+	}
+	return escapedConf, nil
+}
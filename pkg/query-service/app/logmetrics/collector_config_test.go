@@ -0,0 +1,143 @@
+package logmetrics
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"go.signoz.io/signoz/pkg/query-service/constants"
+	v3 "go.signoz.io/signoz/pkg/query-service/model/v3"
+	"gopkg.in/yaml.v3"
+)
+
+func TestGenerateCollectorConfigWithLogMetrics(t *testing.T) {
+	require := require.New(t)
+
+	baseConf := []byte(`
+        receivers:
+          otlp:
+            protocols:
+              grpc:
+                endpoint: 0.0.0.0:4317
+        processors:
+          batch: {}
+        exporters:
+          clickhouselogsexporter: {}
+          clickhousemetricswrite: {}
+        service:
+          pipelines:
+            logs:
+              receivers: [otlp]
+              processors: [batch]
+              exporters: [clickhouselogsexporter]
+            metrics:
+              receivers: [otlp]
+              processors: [batch]
+              exporters: [clickhousemetricswrite]
+      `)
+
+	metrics := []LogMetric{
+		{
+			Name:    "http_errors",
+			Enabled: true,
+			Filter: &v3.FilterSet{Operator: "AND", Items: []v3.FilterItem{{
+				Key:      v3.AttributeKey{Key: "status", DataType: v3.AttributeKeyDataTypeInt64, Type: v3.AttributeKeyTypeTag},
+				Operator: v3.FilterOperatorGreaterThanOrEq,
+				Value:    float64(500),
+			}}},
+			LogMetricConfig: LogMetricConfig{
+				Type: LogMetricTypeCounter,
+				GroupBy: []v3.AttributeKey{
+					{Key: "endpoint", Type: v3.AttributeKeyTypeTag},
+					{Key: "deployment.environment", Type: v3.AttributeKeyTypeResource},
+				},
+			},
+		},
+		{
+			Name:    "request_duration",
+			Enabled: true,
+			LogMetricConfig: LogMetricConfig{
+				Type:       LogMetricTypeHistogram,
+				Unit:       "ms",
+				ValueField: "attributes.duration",
+				Buckets:    []float64{10, 100, 1000},
+			},
+		},
+		{
+			Name:    "disabled",
+			Enabled: false,
+			LogMetricConfig: LogMetricConfig{
+				Type: LogMetricTypeCounter,
+			},
+		},
+	}
+	for _, m := range metrics {
+		require.Nil(m.IsValid())
+	}
+
+	conf, apiErr := GenerateCollectorConfigWithLogMetrics(baseConf, metrics)
+	require.Nil(apiErr)
+
+	var collectorConf map[string]interface{}
+	require.Nil(yaml.Unmarshal(conf, &collectorConf))
+	pipelines := collectorConf["service"].(map[string]interface{})["pipelines"].(map[string]interface{})
+	require.Equal(
+		[]interface{}{"clickhouselogsexporter", constants.LogMetricsConnector},
+		pipelines["logs"].(map[string]interface{})["exporters"],
+	)
+	require.Equal(
+		[]interface{}{"otlp", constants.LogMetricsConnector},
+		pipelines["metrics"].(map[string]interface{})["receivers"],
+	)
+
+	connector := collectorConf["connectors"].(map[string]interface{})[constants.LogMetricsConnector]
+	connectorMetrics := connector.(map[string]interface{})["logs"].([]interface{})
+	require.Equal(2, len(connectorMetrics))
+
+	counter := connectorMetrics[0].(map[string]interface{})
+	require.Equal("http_errors", counter["name"])
+	require.Equal([]interface{}{`attributes["status"] >= 500`}, counter["conditions"])
+	require.Equal([]interface{}{map[string]interface{}{"key": "endpoint"}}, counter["attributes"])
+	require.Equal([]interface{}{
+		map[string]interface{}{"key": "service.name"},
+		map[string]interface{}{"key": "deployment.environment"},
+	}, counter["include_resource_attributes"])
+	require.Equal(map[string]interface{}{"value": "1"}, counter["sum"])
+
+	histogram := connectorMetrics[1].(map[string]interface{})
+	require.Equal("ms", histogram["unit"])
+	require.Equal([]interface{}{`attributes["duration"] != nil`}, histogram["conditions"])
+	require.Equal(map[string]interface{}{
+		"value":   `Double(attributes["duration"])`,
+		"buckets": []interface{}{10, 100, 1000},
+	}, histogram["histogram"])
+
+	// the connector is removed with the metrics
+	conf, apiErr = GenerateCollectorConfigWithLogMetrics(conf, metrics[2:])
+	require.Nil(apiErr)
+	collectorConf = nil
+	require.Nil(yaml.Unmarshal(conf, &collectorConf))
+	require.NotContains(collectorConf, "connectors")
+	pipelines = collectorConf["service"].(map[string]interface{})["pipelines"].(map[string]interface{})
+	require.Equal([]interface{}{"clickhouselogsexporter"}, pipelines["logs"].(map[string]interface{})["exporters"])
+	require.Equal([]interface{}{"otlp"}, pipelines["metrics"].(map[string]interface{})["receivers"])
+}
+
+func TestLogMetricValidation(t *testing.T) {
+	require := require.New(t)
+
+	valid := LogMetric{Name: "errors.count", LogMetricConfig: LogMetricConfig{Type: LogMetricTypeCounter}}
+	require.Nil(valid.IsValid())
+
+	invalid := []LogMetric{
+		{Name: "1errors", LogMetricConfig: LogMetricConfig{Type: LogMetricTypeCounter}},
+		{Name: "errors", LogMetricConfig: LogMetricConfig{Type: "gauge"}},
+		{Name: "errors", LogMetricConfig: LogMetricConfig{Type: LogMetricTypeCounter, ValueField: "attributes.duration"}},
+		{Name: "duration", LogMetricConfig: LogMetricConfig{Type: LogMetricTypeHistogram, ValueField: "body"}},
+		{Name: "duration", LogMetricConfig: LogMetricConfig{
+			Type: LogMetricTypeHistogram, ValueField: "attributes.duration", Buckets: []float64{10, 5},
+		}},
+	}
+	for _, m := range invalid {
+		require.NotNil(m.IsValid(), "log metric %v should be invalid", m)
+	}
+}
@@ -0,0 +1,163 @@
+package logmetrics
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/pkg/errors"
+	"go.signoz.io/signoz/pkg/query-service/agentConf"
+	"go.signoz.io/signoz/pkg/query-service/auth"
+	"go.signoz.io/signoz/pkg/query-service/constants"
+	"go.signoz.io/signoz/pkg/query-service/model"
+	"go.uber.org/zap"
+)
+
+// Controller takes care of deployment cycle of log metrics.
+type LogMetricsController struct {
+	Repo
+}
+
+func NewLogMetricsController(
+	db *sqlx.DB,
+	engine string,
+) (*LogMetricsController, error) {
+	repo := NewRepo(db)
+	err := repo.InitDB(engine)
+	return &LogMetricsController{
+		Repo: repo,
+	}, err
+}
+
+// LogMetricsResponse is used to prepare http response for log metrics config related requests
+type LogMetricsResponse struct {
+	*agentConf.ConfigVersion
+
+	Metrics []LogMetric               `json:"metrics"`
+	History []agentConf.ConfigVersion `json:"history"`
+}
+
+// ApplyLogMetrics stores the log metrics and initiates a new config update
+func (mc *LogMetricsController) ApplyLogMetrics(
+	ctx context.Context,
+	postable []LogMetric,
+) (*LogMetricsResponse, *model.ApiError) {
+	userId, authErr := auth.ExtractUserIdFromContext(ctx)
+	if authErr != nil {
+		return nil, model.UnauthorizedError(errors.Wrap(authErr, "failed to get userId from context"))
+	}
+
+	names := map[string]struct{}{}
+	for _, m := range postable {
+		if _, ok := names[m.Name]; ok {
+			return nil, model.BadRequest(fmt.Errorf("duplicate log metric name %s", m.Name))
+		}
+		names[m.Name] = struct{}{}
+	}
+
+	// log metrics get stored with unique ids each time they are saved, so
+	// that historical versions aren't altered
+	elements := []string{}
+	for idx := range postable {
+		metric, apiErr := mc.insertLogMetric(ctx, &postable[idx])
+		if apiErr != nil {
+			return nil, model.WrapApiError(apiErr, "failed to insert log metric")
+		}
+		elements = append(elements, metric.Id)
+	}
+
+	cfg, err := agentConf.StartNewVersion(ctx, userId, agentConf.ElementTypeLogMetrics, elements)
+	if err != nil || cfg == nil {
+		return nil, err
+	}
+
+	return mc.GetLogMetricsByVersion(ctx, cfg.Version)
+}
+
+// GetLogMetricsByVersion responds with version info and associated log
+// metrics, along with the config history. The latest version is used when
+// version is -1.
+func (mc *LogMetricsController) GetLogMetricsByVersion(
+	ctx context.Context, version int,
+) (*LogMetricsResponse, *model.ApiError) {
+	if version < 0 {
+		latestConfig, apiErr := agentConf.GetLatestVersion(ctx, agentConf.ElementTypeLogMetrics)
+		if apiErr != nil && apiErr.Type() != model.ErrorNotFound {
+			return nil, model.WrapApiError(apiErr, "failed to get latest agent config version")
+		}
+		if latestConfig != nil {
+			version = latestConfig.Version
+		}
+	}
+
+	response := &LogMetricsResponse{
+		Metrics: []LogMetric{},
+	}
+	if version >= 0 {
+		cv, apiErr := agentConf.GetConfigVersion(ctx, agentConf.ElementTypeLogMetrics, version)
+		if apiErr != nil {
+			return nil, model.WrapApiError(apiErr, "failed to get config for given version")
+		}
+		response.ConfigVersion = cv
+
+		metrics, errors := mc.getLogMetricsByVersion(ctx, version)
+		if errors != nil {
+			zap.L().Error("failed to get log metrics for version", zap.Int("version", version), zap.Errors("errors", errors))
+			return nil, model.InternalError(fmt.Errorf("failed to get log metrics for given version"))
+		}
+		response.Metrics = metrics
+	}
+
+	// todo: make a new API for history pagination
+	limit := 10
+	history, apiErr := agentConf.GetConfigHistory(ctx, agentConf.ElementTypeLogMetrics, limit)
+	if apiErr != nil {
+		return nil, model.WrapApiError(apiErr, "failed to get config history")
+	}
+	response.History = history
+
+	return response, nil
+}
+
+// Implements agentConf.AgentFeature interface.
+func (mc *LogMetricsController) AgentFeatureType() agentConf.AgentFeatureType {
+	return LogMetricsFeatureType
+}
+
+// Implements agentConf.AgentCapabilityFeature interface.
+func (mc *LogMetricsController) RequiredAgentCapability() string {
+	return constants.LogMetricsConnectorCapability
+}
+
+// Implements agentConf.AgentFeature interface.
+func (mc *LogMetricsController) RecommendAgentConfig(
+	currentConfYaml []byte,
+	configVersion *agentConf.ConfigVersion,
+) (
+	recommendedConfYaml []byte,
+	serializedSettingsUsed string,
+	apiErr *model.ApiError,
+) {
+	metrics := []LogMetric{}
+	if configVersion != nil {
+		var errors []error
+		metrics, errors = mc.getLogMetricsByVersion(context.Background(), configVersion.Version)
+		if errors != nil {
+			zap.L().Error("failed to get log metrics for version", zap.Int("version", configVersion.Version), zap.Errors("errors", errors))
+			return nil, "", model.InternalError(fmt.Errorf("failed to get log metrics for version %d", configVersion.Version))
+		}
+	}
+
+	updatedConf, apiErr := GenerateCollectorConfigWithLogMetrics(currentConfYaml, metrics)
+	if apiErr != nil {
+		return nil, "", model.WrapApiError(apiErr, "could not generate collector config for log metrics")
+	}
+
+	rawMetricsData, err := json.Marshal(metrics)
+	if err != nil {
+		return nil, "", model.BadRequest(errors.Wrap(err, "could not serialize log metrics to JSON"))
+	}
+
+	return updatedConf, string(rawMetricsData), nil
+}
@@ -0,0 +1,140 @@
+package logmetrics
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+	"github.com/pkg/errors"
+	"go.signoz.io/signoz/pkg/query-service/agentConf"
+	"go.signoz.io/signoz/pkg/query-service/app/logmetrics/sqlite"
+	"go.signoz.io/signoz/pkg/query-service/auth"
+	"go.signoz.io/signoz/pkg/query-service/model"
+	"go.uber.org/zap"
+)
+
+// Repo handles DDL and DML ops on log metrics
+type Repo struct {
+	db *sqlx.DB
+}
+
+// NewRepo initiates a new log metrics repo
+func NewRepo(db *sqlx.DB) Repo {
+	return Repo{
+		db: db,
+	}
+}
+
+func (r *Repo) InitDB(engine string) error {
+	switch engine {
+	case "sqlite3", "sqlite":
+		return sqlite.InitDB(r.db)
+	default:
+		return fmt.Errorf("unsupported db")
+	}
+}
+
+// insertLogMetric stores a log metric to database
+func (r *Repo) insertLogMetric(
+	ctx context.Context, postable *LogMetric,
+) (*LogMetric, *model.ApiError) {
+	if err := postable.IsValid(); err != nil {
+		return nil, model.BadRequest(errors.Wrap(err,
+			"log metric is not valid",
+		))
+	}
+
+	rawConfig, err := json.Marshal(postable.LogMetricConfig)
+	if err != nil {
+		return nil, model.BadRequest(errors.Wrap(err,
+			"failed to marshal log metric config",
+		))
+	}
+
+	jwt, ok := auth.ExtractJwtFromContext(ctx)
+	if !ok {
+		return nil, model.UnauthorizedError(fmt.Errorf("failed to get jwt from context"))
+	}
+
+	claims, err := auth.ParseJWT(jwt)
+	if err != nil {
+		return nil, model.UnauthorizedError(err)
+	}
+
+	insertRow := &LogMetric{
+		Id:              uuid.New().String(),
+		Name:            postable.Name,
+		Description:     postable.Description,
+		Enabled:         postable.Enabled,
+		Filter:          postable.Filter,
+		RawConfig:       string(rawConfig),
+		LogMetricConfig: postable.LogMetricConfig,
+		Creator: Creator{
+			CreatedBy: claims["email"].(string),
+			CreatedAt: time.Now(),
+		},
+	}
+
+	insertQuery := `INSERT INTO log_metrics 
+	(id, enabled, created_by, created_at, name, description, filter, config_json) 
+	VALUES ($1, $2, $3, $4, $5, $6, $7, $8)`
+
+	_, err = r.db.ExecContext(ctx,
+		insertQuery,
+		insertRow.Id,
+		insertRow.Enabled,
+		insertRow.Creator.CreatedBy,
+		insertRow.Creator.CreatedAt,
+		insertRow.Name,
+		insertRow.Description,
+		insertRow.Filter,
+		insertRow.RawConfig)
+
+	if err != nil {
+		zap.L().Error("error in inserting log metric data", zap.Error(err))
+		return nil, model.InternalError(errors.Wrap(err, "failed to insert log metric"))
+	}
+
+	return insertRow, nil
+}
+
+// getLogMetricsByVersion returns log metrics associated with a given version
+func (r *Repo) getLogMetricsByVersion(
+	ctx context.Context, version int,
+) ([]LogMetric, []error) {
+	var errors []error
+	metrics := []LogMetric{}
+
+	versionQuery := `SELECT r.id, 
+		r.name, 
+		r.config_json,
+		COALESCE(r.description, '') as description,
+		r.filter,
+		r.created_by,
+		r.created_at,
+		r.enabled
+		FROM log_metrics r,
+			 agent_config_elements e,
+			 agent_config_versions v
+		WHERE r.id = e.element_id
+		AND v.id = e.version_id
+		AND e.element_type = $1
+		AND v.version = $2
+		ORDER BY r.name asc`
+
+	err := r.db.SelectContext(ctx, &metrics, versionQuery, agentConf.ElementTypeLogMetrics, version)
+	if err != nil {
+		return nil, []error{fmt.Errorf("failed to get log metrics from db: %v", err)}
+	}
+
+	for i := range metrics {
+		if err := metrics[i].ParseRawConfig(); err != nil {
+			errors = append(errors, err)
+		}
+	}
+
+	return metrics, errors
+}
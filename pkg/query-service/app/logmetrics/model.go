@@ -0,0 +1,123 @@
+package logmetrics
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	v3 "go.signoz.io/signoz/pkg/query-service/model/v3"
+)
+
+type LogMetricType string
+
+const (
+	// counts the logs matching the filter
+	LogMetricTypeCounter LogMetricType = "counter"
+	// records the distribution of a numeric attribute of the logs matching the filter
+	LogMetricTypeHistogram LogMetricType = "histogram"
+)
+
+var metricNameRegex = regexp.MustCompile(`^[a-zA-Z_][a-zA-Z0-9_.]*$`)
+
+// LogMetric is a metric derived from logs by the collectors at ingest time
+type LogMetric struct {
+	Id          string        `json:"id,omitempty" db:"id"`
+	Name        string        `json:"name" db:"name"`
+	Description string        `json:"description" db:"description"`
+	Enabled     bool          `json:"enabled" db:"enabled"`
+	Filter      *v3.FilterSet `json:"filter" db:"filter"`
+
+	// configuration of the metric
+	RawConfig string `db:"config_json" json:"-"`
+
+	LogMetricConfig
+
+	// Updater not required as any change will result in new version
+	Creator
+}
+
+type LogMetricConfig struct {
+	Type LogMetricType `json:"type" db:"-"`
+	Unit string        `json:"unit,omitempty" db:"-"`
+
+	// the numeric attribute recorded by histograms, e.g. attributes.duration
+	ValueField string `json:"valueField,omitempty" db:"-"`
+	// explicit bucket boundaries of histograms, the collector defaults are
+	// used when empty
+	Buckets []float64 `json:"buckets,omitempty" db:"-"`
+
+	// the metric has a series for each value of the group by attributes
+	GroupBy []v3.AttributeKey `json:"groupBy" db:"-"`
+}
+
+type Creator struct {
+	CreatedBy string    `json:"createdBy" db:"created_by"`
+	CreatedAt time.Time `json:"createdAt" db:"created_at"`
+}
+
+// PostableLogMetrics is the list of log metrics saved by the user
+type PostableLogMetrics struct {
+	Metrics []LogMetric `json:"metrics"`
+}
+
+func (m *LogMetric) ParseRawConfig() error {
+	c := LogMetricConfig{}
+	err := json.Unmarshal([]byte(m.RawConfig), &c)
+	if err != nil {
+		return errors.Wrap(err, "failed to parse log metric config")
+	}
+	m.LogMetricConfig = c
+	return nil
+}
+
+// IsValid checks if the log metric has all the required params
+func (m *LogMetric) IsValid() error {
+	if !metricNameRegex.MatchString(m.Name) {
+		return fmt.Errorf(
+			"metric name %q should start with a letter or _ and contain only letters, digits, _ and .", m.Name,
+		)
+	}
+
+	switch m.Type {
+	case LogMetricTypeCounter:
+		if m.ValueField != "" || len(m.Buckets) > 0 {
+			return fmt.Errorf("valueField and buckets are only supported for histograms")
+		}
+	case LogMetricTypeHistogram:
+		if _, err := attributeFieldPath(m.ValueField); err != nil {
+			return errors.Wrap(err, "invalid valueField of histogram")
+		}
+		for i := 1; i < len(m.Buckets); i++ {
+			if m.Buckets[i] <= m.Buckets[i-1] {
+				return fmt.Errorf("buckets of histogram should be in increasing order")
+			}
+		}
+	default:
+		return fmt.Errorf("metric type should be %s or %s", LogMetricTypeCounter, LogMetricTypeHistogram)
+	}
+
+	for _, key := range m.GroupBy {
+		if key.Key == "" {
+			return fmt.Errorf("group by attribute key cannot be empty")
+		}
+	}
+
+	if _, err := filterToOTTLCondition(m.Filter); err != nil {
+		return errors.Wrap(err, "filter is not correct")
+	}
+
+	return nil
+}
+
+// attributeFieldPath returns the OTTL path of a field of the attributes
+// named like in the log pipelines, e.g. attributes.duration
+func attributeFieldPath(field string) (string, error) {
+	key, found := strings.CutPrefix(field, "attributes.")
+	if !found || key == "" {
+		return "", fmt.Errorf("field should be an attribute like attributes.duration, got %q", field)
+	}
+	return fmt.Sprintf(`attributes[%s]`, ottlString(key)), nil
+}
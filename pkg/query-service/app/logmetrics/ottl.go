@@ -0,0 +1,170 @@
+package logmetrics
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	v3 "go.signoz.io/signoz/pkg/query-service/model/v3"
+)
+
+// The collectors evaluate the filters of the log metrics as OTTL conditions
+// on the log records.
+
+var ottlFilterSyntax = v3.FilterSyntax{And: "and", Or: "or", Not: "not"}
+
+// filterToOTTLCondition returns the OTTL condition matching the logs of the
+// filter, empty when the filter matches all the logs
+func filterToOTTLCondition(filter *v3.FilterSet) (string, error) {
+	return filter.BuildCondition(ottlFilterSyntax, filterItemToOTTL)
+}
+
+// ottlFieldPath returns the OTTL path of the field of the log record the
+// attribute key refers to
+func ottlFieldPath(key v3.AttributeKey) string {
+	switch key.Type {
+	case v3.AttributeKeyTypeTag:
+		return fmt.Sprintf("attributes[%s]", ottlString(key.Key))
+	case v3.AttributeKeyTypeResource:
+		return fmt.Sprintf("resource.attributes[%s]", ottlString(key.Key))
+	}
+
+	switch key.Key {
+	case "body", "severity_text", "severity_number":
+		return key.Key
+	case "trace_id", "span_id":
+		return key.Key + ".string"
+	}
+	return fmt.Sprintf("attributes[%s]", ottlString(key.Key))
+}
+
+func filterItemToOTTL(item v3.FilterItem) (string, error) {
+	path := ottlFieldPath(item.Key)
+
+	switch item.Operator {
+	case v3.FilterOperatorExists:
+		return fmt.Sprintf("%s != nil", path), nil
+	case v3.FilterOperatorNotExists:
+		return fmt.Sprintf("%s == nil", path), nil
+
+	case v3.FilterOperatorIn, v3.FilterOperatorNotIn:
+		values, ok := item.Value.([]interface{})
+		if !ok {
+			values = []interface{}{item.Value}
+		}
+		if len(values) == 0 {
+			return "", fmt.Errorf("%s operator needs at least one value", item.Operator)
+		}
+		op, join := "==", " or "
+		if item.Operator == v3.FilterOperatorNotIn {
+			op, join = "!=", " and "
+		}
+		conditions := []string{}
+		for _, v := range values {
+			value, err := ottlValue(v)
+			if err != nil {
+				return "", err
+			}
+			conditions = append(conditions, fmt.Sprintf("%s %s %s", path, op, value))
+		}
+		if len(conditions) == 1 {
+			return conditions[0], nil
+		}
+		return "(" + strings.Join(conditions, join) + ")", nil
+	}
+
+	if ottlOp, ok := ottlComparisonOperators[item.Operator]; ok {
+		value, err := ottlValue(item.Value)
+		if err != nil {
+			return "", err
+		}
+		return fmt.Sprintf("%s %s %s", path, ottlOp, value), nil
+	}
+
+	value, ok := item.Value.(string)
+	if !ok {
+		return "", fmt.Errorf("%s operator needs a string value", item.Operator)
+	}
+	var pattern string
+	switch item.Operator {
+	case v3.FilterOperatorContains, v3.FilterOperatorNotContains:
+		// `contains` and `ncontains` are case insensitive like when querying logs.
+		pattern = "(?i)" + regexp.QuoteMeta(value)
+	case v3.FilterOperatorLike, v3.FilterOperatorNotLike:
+		pattern = likePatternToRegex(value)
+	case v3.FilterOperatorRegex, v3.FilterOperatorNotRegex:
+		if _, err := regexp.Compile(value); err != nil {
+			return "", fmt.Errorf("invalid regex %q: %w", value, err)
+		}
+		pattern = value
+	default:
+		return "", fmt.Errorf("operator %s is not supported", item.Operator)
+	}
+
+	condition := fmt.Sprintf("IsMatch(%s, %s)", path, ottlString(pattern))
+	switch item.Operator {
+	case v3.FilterOperatorNotContains, v3.FilterOperatorNotLike, v3.FilterOperatorNotRegex:
+		condition = "not " + condition
+	}
+	return condition, nil
+}
+
+var ottlComparisonOperators = map[v3.FilterOperator]string{
+	v3.FilterOperatorEqual:           "==",
+	v3.FilterOperatorNotEqual:        "!=",
+	v3.FilterOperatorLessThan:        "<",
+	v3.FilterOperatorLessThanOrEq:    "<=",
+	v3.FilterOperatorGreaterThan:     ">",
+	v3.FilterOperatorGreaterThanOrEq: ">=",
+}
+
+// likePatternToRegex converts a like pattern to a case insensitive regex
+// matching the whole value, % matches any characters and _ matches one
+func likePatternToRegex(pattern string) string {
+	var b strings.Builder
+	b.WriteString("(?i)^")
+	for _, r := range pattern {
+		switch r {
+		case '%':
+			b.WriteString(".*")
+		case '_':
+			b.WriteString(".")
+		default:
+			b.WriteString(regexp.QuoteMeta(string(r)))
+		}
+	}
+	b.WriteString("$")
+	return b.String()
+}
+
+func ottlValue(v interface{}) (string, error) {
+	switch x := v.(type) {
+	case string:
+		return ottlString(x), nil
+	case bool:
+		return strconv.FormatBool(x), nil
+	case int, int8, int16, int32, int64, uint8, uint16, uint32, uint64:
+		return fmt.Sprintf("%d", x), nil
+	case float32:
+		return ottlFloat(float64(x)), nil
+	case float64:
+		return ottlFloat(x), nil
+	}
+	return "", fmt.Errorf("unsupported filter value %v of type %T", v, v)
+}
+
+// ottlFloat formats the float as an OTTL int or float literal, the numbers
+// in json filters are unmarshalled as floats
+func ottlFloat(x float64) string {
+	if x == float64(int64(x)) {
+		return strconv.FormatInt(int64(x), 10)
+	}
+	return strconv.FormatFloat(x, 'f', -1, 64)
+}
+
+func ottlString(s string) string {
+	s = strings.ReplaceAll(s, `\`, `\\`)
+	s = strings.ReplaceAll(s, `"`, `\"`)
+	return `"` + s + `"`
+}
@@ -0,0 +1,88 @@
+package logmetrics
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	v3 "go.signoz.io/signoz/pkg/query-service/model/v3"
+)
+
+func TestFilterToOTTLCondition(t *testing.T) {
+	tag := func(key string) v3.AttributeKey {
+		return v3.AttributeKey{Key: key, DataType: v3.AttributeKeyDataTypeString, Type: v3.AttributeKeyTypeTag}
+	}
+	resource := func(key string) v3.AttributeKey {
+		return v3.AttributeKey{Key: key, DataType: v3.AttributeKeyDataTypeString, Type: v3.AttributeKeyTypeResource}
+	}
+
+	testCases := []struct {
+		Name      string
+		Filter    *v3.FilterSet
+		Condition string
+		IsErr     bool
+	}{
+		{
+			Name:      "no filter",
+			Filter:    nil,
+			Condition: "",
+		},
+		{
+			Name: "equal and comparison",
+			Filter: &v3.FilterSet{Operator: "AND", Items: []v3.FilterItem{
+				{Key: resource("service.name"), Operator: v3.FilterOperatorEqual, Value: "checkout"},
+				{Key: tag("status"), Operator: v3.FilterOperatorGreaterThanOrEq, Value: float64(500)},
+			}},
+			Condition: `resource.attributes["service.name"] == "checkout" and attributes["status"] >= 500`,
+		},
+		{
+			Name: "in, exists and top level fields",
+			Filter: &v3.FilterSet{Operator: "OR", Items: []v3.FilterItem{
+				{Key: v3.AttributeKey{Key: "severity_text"}, Operator: v3.FilterOperatorIn, Value: []interface{}{"ERROR", "FATAL"}},
+				{Key: tag("error"), Operator: v3.FilterOperatorExists},
+			}},
+			Condition: `((severity_text == "ERROR" or severity_text == "FATAL") or attributes["error"] != nil)`,
+		},
+		{
+			Name: "matching operators",
+			Filter: &v3.FilterSet{Operator: "AND", Items: []v3.FilterItem{
+				{Key: v3.AttributeKey{Key: "body"}, Operator: v3.FilterOperatorNotContains, Value: "health.check"},
+				{Key: tag("path"), Operator: v3.FilterOperatorLike, Value: "/api/%"},
+				{Key: tag("method"), Operator: v3.FilterOperatorRegex, Value: `^(GET|POST)$`},
+			}},
+			Condition: `not IsMatch(body, "(?i)health\\.check") and IsMatch(attributes["path"], "(?i)^/api/.*$") and IsMatch(attributes["method"], "^(GET|POST)$")`,
+		},
+		{
+			Name: "quotes are escaped",
+			Filter: &v3.FilterSet{Operator: "AND", Items: []v3.FilterItem{
+				{Key: tag("message"), Operator: v3.FilterOperatorNotEqual, Value: `say "hi"`},
+			}},
+			Condition: `attributes["message"] != "say \"hi\""`,
+		},
+		{
+			Name: "invalid regex",
+			Filter: &v3.FilterSet{Operator: "AND", Items: []v3.FilterItem{
+				{Key: tag("method"), Operator: v3.FilterOperatorRegex, Value: `(GET`},
+			}},
+			IsErr: true,
+		},
+		{
+			Name: "unsupported operator",
+			Filter: &v3.FilterSet{Operator: "AND", Items: []v3.FilterItem{
+				{Key: tag("tags"), Operator: v3.FilterOperatorHas, Value: "a"},
+			}},
+			IsErr: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.Name, func(t *testing.T) {
+			condition, err := filterToOTTLCondition(tc.Filter)
+			if tc.IsErr {
+				require.NotNil(t, err)
+				return
+			}
+			require.Nil(t, err)
+			require.Equal(t, tc.Condition, condition)
+		})
+	}
+}
@@ -165,6 +165,17 @@ func checkDuplicateString(pipeline []string) bool {
 func GenerateCollectorConfigWithPipelines(
 	config []byte,
 	pipelines []Pipeline,
+) ([]byte, *coreModel.ApiError) {
+	return generateCollectorConfig(config, pipelines, true)
+}
+
+// generateCollectorConfig adds the processors of the pipelines to the config,
+// the processors masking and dropping the logs marked by the pipelines are
+// only added if withPostProcessors is set
+func generateCollectorConfig(
+	config []byte,
+	pipelines []Pipeline,
+	withPostProcessors bool,
 ) ([]byte, *coreModel.ApiError) {
 	var collectorConf map[string]interface{}
 	err := yaml.Unmarshal([]byte(config), &collectorConf)
@@ -179,6 +190,27 @@ func GenerateCollectorConfigWithPipelines(
 		))
 	}
 
+	if withPostProcessors && hasDropOperators(pipelines) {
+		processors, names := dropProcessors()
+		for name, processor := range processors {
+			signozPipelineProcessors[name] = processor
+		}
+		signozPipelineProcNames = append(signozPipelineProcNames, names...)
+	}
+
+	if masks := pipelineMasks(pipelines); withPostProcessors && len(masks) > 0 {
+		processors, names, err := maskProcessor(masks)
+		if err != nil {
+			return nil, coreModel.BadRequest(errors.Wrap(
+				err, "could not prepare the mask processor for log pipelines",
+			))
+		}
+		for name, processor := range processors {
+			signozPipelineProcessors[name] = processor
+		}
+		signozPipelineProcNames = append(signozPipelineProcNames, names...)
+	}
+
 	// Escape any `$`s as `$$` in config generated for pipelines, to ensure any occurrences
 	// like $data do not end up being treated as env vars when loading collector config.
 	for _, procName := range signozPipelineProcNames {
@@ -233,5 +265,9 @@ func GenerateCollectorConfigWithPipelines(
 }
 
 func hasSignozPipelineProcessorPrefix(procName string) bool {
-	return strings.HasPrefix(procName, constants.LogsPPLPfx) || strings.HasPrefix(procName, constants.OldLogsPPLPfx)
+	return strings.HasPrefix(procName, constants.LogsPPLPfx) ||
+		strings.HasPrefix(procName, constants.OldLogsPPLPfx) ||
+		procName == constants.LogsPPLDropProcessor ||
+		procName == constants.LogsPPLCleanupProcessor ||
+		procName == constants.LogsPPLMaskProcessor
 }
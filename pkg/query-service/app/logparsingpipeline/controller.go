@@ -46,10 +46,12 @@ type PipelinesResponse struct {
 	History   []agentConf.ConfigVersion `json:"history"`
 }
 
-// ApplyPipelines stores new or changed pipelines and initiates a new config update
+// ApplyPipelines stores new or changed pipelines and initiates a new config update.
+// With a staged selector, the new config is deployed only to the matching agents.
 func (ic *LogParsingPipelineController) ApplyPipelines(
 	ctx context.Context,
 	postable []PostablePipeline,
+	stagedSelector agentConf.AgentLabels,
 ) (*PipelinesResponse, *model.ApiError) {
 	// get user id from context
 	userId, authErr := auth.ExtractUserIdFromContext(ctx)
@@ -57,6 +59,18 @@ func (ic *LogParsingPipelineController) ApplyPipelines(
 		return nil, model.UnauthorizedError(errors.Wrap(authErr, "failed to get userId from context"))
 	}
 
+	// the pipelines run one after another and the values are masked after
+	// all of them
+	enabledOperators := []PipelineOperator{}
+	for _, p := range postable {
+		if p.Enabled {
+			enabledOperators = append(enabledOperators, p.Config...)
+		}
+	}
+	if err := checkMaskedFieldReads(enabledOperators); err != nil {
+		return nil, model.BadRequest(err)
+	}
+
 	var pipelines []Pipeline
 
 	// scan through postable pipelines, to select the existing pipelines or insert missing ones
@@ -86,7 +100,15 @@ func (ic *LogParsingPipelineController) ApplyPipelines(
 	}
 
 	// prepare config by calling gen func
-	cfg, err := agentConf.StartNewVersion(ctx, userId, agentConf.ElementTypeLogPipelines, elements)
+	var cfg *agentConf.ConfigVersion
+	var err *model.ApiError
+	if len(stagedSelector) > 0 {
+		cfg, err = agentConf.StartNewStagedVersion(
+			ctx, userId, agentConf.ElementTypeLogPipelines, elements, stagedSelector,
+		)
+	} else {
+		cfg, err = agentConf.StartNewVersion(ctx, userId, agentConf.ElementTypeLogPipelines, elements)
+	}
 	if err != nil || cfg == nil {
 		return nil, err
 	}
@@ -178,6 +200,75 @@ func (ic *LogParsingPipelineController) GetPipelinesByVersion(
 	}, nil
 }
 
+// RollbackPipelines deploys the pipelines of an earlier version to all the agents
+func (ic *LogParsingPipelineController) RollbackPipelines(
+	ctx context.Context, version int,
+) (*PipelinesResponse, *model.ApiError) {
+	apiErr := agentConf.Redeploy(ctx, agentConf.ElementTypeLogPipelines, version)
+	if apiErr != nil {
+		return nil, model.WrapApiError(apiErr, "failed to redeploy pipelines")
+	}
+	return ic.GetPipelinesByVersion(ctx, version)
+}
+
+// StagePipelines deploys the pipelines of a version only to the agents
+// matching the selector, the other agents keep their current pipelines
+func (ic *LogParsingPipelineController) StagePipelines(
+	ctx context.Context, version int, selector agentConf.AgentLabels,
+) (*PipelinesRolloutResponse, *model.ApiError) {
+	userId, authErr := auth.ExtractUserIdFromContext(ctx)
+	if authErr != nil {
+		return nil, model.UnauthorizedError(errors.Wrap(authErr, "failed to get userId from context"))
+	}
+
+	apiErr := agentConf.StageVersion(ctx, userId, agentConf.ElementTypeLogPipelines, version, selector)
+	if apiErr != nil {
+		return nil, model.WrapApiError(apiErr, "failed to stage pipelines")
+	}
+	return ic.GetPipelinesRollout(ctx)
+}
+
+// PromoteStagedPipelines deploys the staged pipelines to all the agents
+func (ic *LogParsingPipelineController) PromoteStagedPipelines(
+	ctx context.Context,
+) (*PipelinesRolloutResponse, *model.ApiError) {
+	userId, authErr := auth.ExtractUserIdFromContext(ctx)
+	if authErr != nil {
+		return nil, model.UnauthorizedError(errors.Wrap(authErr, "failed to get userId from context"))
+	}
+
+	_, apiErr := agentConf.PromoteStagedVersion(ctx, userId, agentConf.ElementTypeLogPipelines)
+	if apiErr != nil {
+		return nil, model.WrapApiError(apiErr, "failed to promote staged pipelines")
+	}
+	return ic.GetPipelinesRollout(ctx)
+}
+
+// PipelinesRolloutResponse is the config version deployed to the agents
+// and the status of the last deployment to each agent
+type PipelinesRolloutResponse struct {
+	// nil when the latest version is deployed to all the agents
+	Rollout *agentConf.ConfigRollout    `json:"rollout"`
+	Agents  []agentConf.AgentDeployment `json:"agents"`
+}
+
+func (ic *LogParsingPipelineController) GetPipelinesRollout(
+	ctx context.Context,
+) (*PipelinesRolloutResponse, *model.ApiError) {
+	rollout, apiErr := agentConf.GetRollout(ctx, agentConf.ElementTypeLogPipelines)
+	if apiErr != nil {
+		return nil, model.WrapApiError(apiErr, "failed to get pipelines rollout")
+	}
+	agents, apiErr := agentConf.GetAgentDeployments(ctx, agentConf.ElementTypeLogPipelines)
+	if apiErr != nil {
+		return nil, model.WrapApiError(apiErr, "failed to get pipelines deployments")
+	}
+	return &PipelinesRolloutResponse{
+		Rollout: rollout,
+		Agents:  agents,
+	}, nil
+}
+
 type PipelinesPreviewRequest struct {
 	Pipelines []Pipeline        `json:"pipelines"`
 	Logs      []model.SignozLog `json:"logs"`
@@ -186,6 +277,9 @@ type PipelinesPreviewRequest struct {
 type PipelinesPreviewResponse struct {
 	OutputLogs    []model.SignozLog `json:"logs"`
 	CollectorLogs []string          `json:"collectorLogs"`
+
+	// number of input logs dropped by the drop and sample operators
+	DroppedLogsCount int `json:"droppedLogsCount"`
 }
 
 func (ic *LogParsingPipelineController) PreviewLogsPipelines(
@@ -201,11 +295,36 @@ func (ic *LogParsingPipelineController) PreviewLogsPipelines(
 	}
 
 	return &PipelinesPreviewResponse{
-		OutputLogs:    result,
-		CollectorLogs: collectorLogs,
+		OutputLogs:       result,
+		CollectorLogs:    collectorLogs,
+		DroppedLogsCount: len(request.Logs) - len(result),
 	}, nil
 }
 
+func (ic *LogParsingPipelineController) PreviewPipelineOperators(
+	ctx context.Context,
+	request *OperatorPreviewRequest,
+) (*OperatorPreviewResponse, *model.ApiError) {
+	if len(request.Pipeline.Config) == 0 {
+		return nil, model.BadRequest(fmt.Errorf("pipeline has no operators to preview"))
+	}
+	enabledOperators := 0
+	for _, op := range request.Pipeline.Config {
+		if err := isValidOperator(op); err != nil {
+			return nil, model.BadRequest(err)
+		}
+		if op.Enabled {
+			enabledOperators++
+		}
+	}
+	if enabledOperators > MaxOperatorPreviewOperators {
+		return nil, model.BadRequest(fmt.Errorf(
+			"only %d operators can be previewed at a time", MaxOperatorPreviewOperators,
+		))
+	}
+	return PreviewPipelineOperators(ctx, request.Pipeline, request.Logs)
+}
+
 // Implements agentConf.AgentFeature interface.
 func (pc *LogParsingPipelineController) AgentFeatureType() agentConf.AgentFeatureType {
 	return LogPipelinesFeatureType
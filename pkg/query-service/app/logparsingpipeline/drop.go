@@ -0,0 +1,176 @@
+package logparsingpipeline
+
+import (
+	"fmt"
+	"math"
+	"strings"
+
+	"go.signoz.io/signoz/pkg/query-service/constants"
+	"go.signoz.io/signoz/pkg/query-service/model"
+)
+
+// The logs pipeline processor of the collector can't drop logs. The drop and
+// sample operators mark the logs to be dropped with attributes and the logs
+// are dropped by a filter processor after the pipeline processors. None of
+// the processors of the collector count the logs of a key, so the rates of
+// the keys can't be capped by an operator.
+
+const (
+	// dropAttribute marks the logs dropped by the drop operators and the
+	// sample operators sampling by a field
+	dropAttribute = "__signoz_drop__"
+	// traceSampleAttribute holds the threshold of the sample operators
+	// sampling by trace id, the trace id isn't available in the expressions
+	// of the pipeline processor so the logs are sampled by the filter processor
+	traceSampleAttribute = "__signoz_sample__"
+
+	sampleByTraceID = "trace_id"
+
+	// the printable characters hashed by the sample operators sampling by a
+	// field, the hash of the characters is multiplied by a large constant to
+	// spread similar values over the buckets
+	sampleHashCharacters = " !\"#$%&'()*+,-./0123456789:;<=>?@ABCDEFGHIJKLMNOPQRSTUVWXYZ[\\]^_`abcdefghijklmnopqrstuvwxyz{|}~"
+	sampleHashBuckets    = 10000
+	// the last 4 hex digits of the trace id are compared with the threshold
+	traceSampleBuckets = 0x10000
+	traceSampleDigits  = 4
+)
+
+// dropOperators marks all the logs reaching the operator to be dropped
+func dropOperators(op PipelineOperator) ([]PipelineOperator, error) {
+	return []PipelineOperator{
+		compiledOperator(op, op.ID, PipelineOperator{
+			Type:  "add",
+			Field: "attributes." + dropAttribute,
+			Value: "true",
+		}),
+	}, nil
+}
+
+// sampleOperators keeps sampling_percentage percent of the logs reaching the
+// operator. The logs are sampled by trace id by default so that all the logs
+// of a sampled trace are kept, the logs without a trace id are kept. When
+// sampling by a field, the logs are sampled by a hash of its value and the
+// logs without the field are kept.
+func sampleOperators(op PipelineOperator) ([]PipelineOperator, error) {
+	if op.SamplingPercentage >= 100 {
+		return []PipelineOperator{
+			compiledOperator(op, op.ID, PipelineOperator{Type: NOOP}),
+		}, nil
+	}
+
+	if op.SampleBy == "" || op.SampleBy == sampleByTraceID {
+		// the lowest threshold of the sample operators of a log is kept
+		threshold := traceSampleThreshold(op.SamplingPercentage)
+		field := "attributes." + traceSampleAttribute
+		return []PipelineOperator{
+			compiledOperator(op, op.ID, PipelineOperator{
+				Type:  "add",
+				Field: field,
+				Value: threshold,
+				If:    fmt.Sprintf("%s == nil || %s > %s", field, field, exprString(threshold)),
+			}),
+		}, nil
+	}
+
+	notNilCheck, err := fieldNotNilCheck(op.SampleBy)
+	if err != nil {
+		return nil, err
+	}
+	threshold := int(math.Round(op.SamplingPercentage * sampleHashBuckets / 100))
+	return []PipelineOperator{
+		compiledOperator(op, op.ID, PipelineOperator{
+			Type:  "add",
+			Field: "attributes." + dropAttribute,
+			Value: "true",
+			If: fmt.Sprintf(
+				`%s && reduce(split(string(%s), ""), (#acc * 31 + indexOf(%s, #) + 1) %% 1000000007, 0) * 2654435761 %% 1000000007 %% %d >= %d`,
+				notNilCheck, op.SampleBy, exprString(sampleHashCharacters), sampleHashBuckets, threshold,
+			),
+		}),
+	}, nil
+}
+
+// traceSampleThreshold returns the hex threshold of the last digits of the
+// trace ids of the dropped logs
+func traceSampleThreshold(percentage float64) string {
+	threshold := int(math.Round(percentage * traceSampleBuckets / 100))
+	return fmt.Sprintf("%0*x", traceSampleDigits, threshold)
+}
+
+// traceSampleDropped returns whether a log with the trace id is dropped by
+// a sample operator with the threshold
+func traceSampleDropped(traceID string, threshold string) bool {
+	if strings.Trim(traceID, "0") == "" || len(traceID) < traceSampleDigits {
+		return false
+	}
+	return strings.ToLower(traceID[len(traceID)-traceSampleDigits:]) >= threshold
+}
+
+// hasDropOperators returns whether any of the enabled pipelines drops logs
+func hasDropOperators(pipelines []Pipeline) bool {
+	for _, p := range pipelines {
+		if !p.Enabled {
+			continue
+		}
+		for _, op := range p.Config {
+			if op.Enabled && (op.Type == "drop" || op.Type == "sample") {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// dropProcessors returns the processors dropping the marked logs and removing
+// the marks from the logs that are kept, they come after the pipeline processors
+func dropProcessors() (map[string]interface{}, []string) {
+	traceIDSuffix := fmt.Sprintf(
+		"Substring(trace_id.string, %d, %d)", 32-traceSampleDigits, traceSampleDigits,
+	)
+	processors := map[string]interface{}{
+		constants.LogsPPLDropProcessor: map[string]interface{}{
+			"error_mode": "ignore",
+			"logs": map[string]interface{}{
+				"log_record": []string{
+					fmt.Sprintf(`attributes["%s"] != nil`, dropAttribute),
+					fmt.Sprintf(
+						`attributes["%s"] != nil and trace_id != TraceID(0x00000000000000000000000000000000) and %s >= attributes["%s"]`,
+						traceSampleAttribute, traceIDSuffix, traceSampleAttribute,
+					),
+				},
+			},
+		},
+		constants.LogsPPLCleanupProcessor: map[string]interface{}{
+			"error_mode": "ignore",
+			"log_statements": []map[string]interface{}{
+				{
+					"context": "log",
+					"statements": []string{
+						fmt.Sprintf(`delete_key(attributes, "%s")`, traceSampleAttribute),
+					},
+				},
+			},
+		},
+	}
+	return processors, []string{constants.LogsPPLDropProcessor, constants.LogsPPLCleanupProcessor}
+}
+
+// applyDrops removes the logs dropped by the drop processors from the output
+// of a simulation, the simulator only runs the pipeline processors
+func applyDrops(logs []model.SignozLog) []model.SignozLog {
+	kept := []model.SignozLog{}
+	for _, log := range logs {
+		if _, ok := log.Attributes_string[dropAttribute]; ok {
+			continue
+		}
+		if threshold, ok := log.Attributes_string[traceSampleAttribute]; ok {
+			if traceSampleDropped(log.TraceID, threshold) {
+				continue
+			}
+			delete(log.Attributes_string, traceSampleAttribute)
+		}
+		kept = append(kept, log)
+	}
+	return kept
+}
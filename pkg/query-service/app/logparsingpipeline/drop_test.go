@@ -0,0 +1,188 @@
+package logparsingpipeline
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"go.signoz.io/signoz/pkg/query-service/constants"
+	"go.signoz.io/signoz/pkg/query-service/model"
+	v3 "go.signoz.io/signoz/pkg/query-service/model/v3"
+	"gopkg.in/yaml.v3"
+)
+
+func makeDropTestPipeline(op PipelineOperator) Pipeline {
+	op.OrderId = 1
+	op.Enabled = true
+	op.Name = op.ID
+	return Pipeline{
+		OrderId: 1,
+		Name:    "pipeline1",
+		Alias:   "pipeline1",
+		Enabled: true,
+		Filter: &v3.FilterSet{
+			Operator: "AND",
+			Items: []v3.FilterItem{
+				{
+					Key: v3.AttributeKey{
+						Key:      "path",
+						DataType: v3.AttributeKeyDataTypeString,
+						Type:     v3.AttributeKeyTypeTag,
+					},
+					Operator: "=",
+					Value:    "/health",
+				},
+			},
+		},
+		Config: []PipelineOperator{op},
+	}
+}
+
+func TestDropOperator(t *testing.T) {
+	require := require.New(t)
+
+	pipeline := makeDropTestPipeline(PipelineOperator{ID: "drop", Type: "drop"})
+	require.Nil(isValidOperator(pipeline.Config[0]))
+
+	logs := []model.SignozLog{
+		makeTestSignozLog("health check", map[string]interface{}{"path": "/health"}),
+		makeTestSignozLog("order placed", map[string]interface{}{"path": "/orders"}),
+		makeTestSignozLog("health check", map[string]interface{}{"path": "/health"}),
+	}
+	result, collectorWarnAndErrorLogs, err := SimulatePipelinesProcessing(
+		context.Background(), []Pipeline{pipeline}, logs,
+	)
+	require.Nil(err)
+	require.Equal(0, len(collectorWarnAndErrorLogs), strings.Join(collectorWarnAndErrorLogs, "\n"))
+	require.Equal(1, len(result))
+	require.Equal("order placed", result[0].Body)
+	require.NotContains(result[0].Attributes_string, dropAttribute)
+}
+
+func TestSampleOperator(t *testing.T) {
+	require := require.New(t)
+
+	// the trace ids of the test logs are the bytes of their string
+	pipeline := makeDropTestPipeline(PipelineOperator{ID: "sample", Type: "sample", SamplingPercentage: 50})
+	require.Nil(isValidOperator(pipeline.Config[0]))
+	keptLog := makeTestSignozLog("kept", map[string]interface{}{"path": "/health"})
+	keptLog.TraceID = "aaaaaaaaaaaaaa\x00\x01"
+	droppedLog := makeTestSignozLog("dropped", map[string]interface{}{"path": "/health"})
+	droppedLog.TraceID = "aaaaaaaaaaaaaa\xff\xff"
+	noTraceLog := makeTestSignozLog("no trace", map[string]interface{}{"path": "/health"})
+	noTraceLog.TraceID = ""
+
+	result, collectorWarnAndErrorLogs, err := SimulatePipelinesProcessing(
+		context.Background(), []Pipeline{pipeline}, []model.SignozLog{keptLog, droppedLog, noTraceLog},
+	)
+	require.Nil(err)
+	require.Equal(0, len(collectorWarnAndErrorLogs), strings.Join(collectorWarnAndErrorLogs, "\n"))
+	require.Equal(2, len(result))
+	require.Equal("kept", result[0].Body)
+	require.Equal("no trace", result[1].Body)
+	require.NotContains(result[0].Attributes_string, traceSampleAttribute)
+
+	// sampling by an attribute keeps all the logs with the same value together
+	pipeline = makeDropTestPipeline(PipelineOperator{
+		ID: "sample", Type: "sample", SamplingPercentage: 30, SampleBy: "attributes.user",
+	})
+	require.Nil(isValidOperator(pipeline.Config[0]))
+	logs := []model.SignozLog{}
+	for i := 0; i < 200; i++ {
+		logs = append(logs, makeTestSignozLog(fmt.Sprintf("user%d", i%100), map[string]interface{}{
+			"path": "/health",
+			"user": fmt.Sprintf("user%d", i%100),
+		}))
+	}
+	result, collectorWarnAndErrorLogs, err = SimulatePipelinesProcessing(
+		context.Background(), []Pipeline{pipeline}, logs,
+	)
+	require.Nil(err)
+	require.Equal(0, len(collectorWarnAndErrorLogs), strings.Join(collectorWarnAndErrorLogs, "\n"))
+	kept := map[string]int{}
+	for _, log := range result {
+		kept[log.Attributes_string["user"]]++
+	}
+	for user, count := range kept {
+		require.Equal(2, count, "logs of %s should be kept together", user)
+	}
+	require.Greater(len(kept), 10)
+	require.Less(len(kept), 50)
+
+	require.NotNil(isValidOperator(PipelineOperator{ID: "sample", Type: "sample"}))
+	require.NotNil(isValidOperator(PipelineOperator{ID: "sample", Type: "sample", SamplingPercentage: 10, SampleBy: "user"}))
+}
+
+func TestSampleOperatorsKeepLowestThreshold(t *testing.T) {
+	require := require.New(t)
+
+	pipeline := makeDropTestPipeline(PipelineOperator{ID: "sample25", Type: "sample", SamplingPercentage: 25, Output: "sample75"})
+	pipeline.Config = append(pipeline.Config, PipelineOperator{
+		ID: "sample75", Name: "sample75", Type: "sample", OrderId: 2, Enabled: true, SamplingPercentage: 75,
+	})
+	// the last digits of the trace id are between the thresholds of the operators
+	log := makeTestSignozLog("sampled", map[string]interface{}{"path": "/health"})
+	log.TraceID = "aaaaaaaaaaaaaa\x50\x00"
+
+	result, collectorWarnAndErrorLogs, err := SimulatePipelinesProcessing(
+		context.Background(), []Pipeline{pipeline}, []model.SignozLog{log},
+	)
+	require.Nil(err)
+	require.Equal(0, len(collectorWarnAndErrorLogs), strings.Join(collectorWarnAndErrorLogs, "\n"))
+	require.Equal(0, len(result))
+}
+
+func TestTraceSampleDropped(t *testing.T) {
+	threshold := traceSampleThreshold(25)
+	require.Equal(t, "4000", threshold)
+	require.False(t, traceSampleDropped("5b8efff798038103d269b633813f0fff", threshold))
+	require.True(t, traceSampleDropped("5b8efff798038103d269b633813f4000", threshold))
+	require.False(t, traceSampleDropped("00000000000000000000000000000000", threshold))
+	require.False(t, traceSampleDropped("", threshold))
+}
+
+func TestDropProcessorsInCollectorConfig(t *testing.T) {
+	require := require.New(t)
+
+	baseConf := []byte(`
+        receivers:
+          memory:
+            id: in-memory-receiver
+        exporters:
+          memory:
+            id: in-memory-exporter
+        service:
+          pipelines:
+            logs:
+              receivers:
+                - memory
+              processors:
+                - batch
+              exporters:
+                - memory
+      `)
+	logsProcessors := func(conf []byte) []any {
+		var recommendedConf map[string]interface{}
+		require.Nil(yaml.Unmarshal(conf, &recommendedConf))
+		return recommendedConf["service"].(map[string]any)["pipelines"].(map[string]any)["logs"].(map[string]any)["processors"].([]any)
+	}
+
+	pipeline := makeDropTestPipeline(PipelineOperator{ID: "drop", Type: "drop"})
+	conf, apiErr := GenerateCollectorConfigWithPipelines(baseConf, []Pipeline{pipeline})
+	require.Nil(apiErr)
+	require.Equal([]any{
+		CollectorConfProcessorName(pipeline),
+		constants.LogsPPLDropProcessor,
+		constants.LogsPPLCleanupProcessor,
+		"batch",
+	}, logsProcessors(conf))
+
+	// the drop processors are removed with the drop operators
+	pipeline.Config[0].Enabled = false
+	conf, apiErr = GenerateCollectorConfigWithPipelines(conf, []Pipeline{pipeline})
+	require.Nil(apiErr)
+	require.Equal([]any{"batch"}, logsProcessors(conf))
+	require.NotContains(string(conf), constants.LogsPPLDropProcessor)
+}
@@ -0,0 +1,299 @@
+package logparsingpipeline
+
+import (
+	"fmt"
+	"hash/fnv"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"go.signoz.io/signoz/pkg/query-service/constants"
+	"go.signoz.io/signoz/pkg/query-service/model"
+)
+
+// The expressions of the logs pipeline processor can't replace all the
+// matches of a pattern. The mask operators mark the logs with an attribute
+// per masked field and the values are masked by a transform processor after
+// the pipeline processors, so the operators after a mask operator would see
+// the values before they are masked. The operators reading a masked field
+// after its mask operator are rejected, in the pipeline and in the later
+// pipelines.
+
+const (
+	defaultMaskReplacement = "****"
+
+	// maskAttributePrefix is the prefix of the attributes marking the logs
+	// whose fields are masked by the mask processor
+	maskAttributePrefix = "__signoz_mask_"
+)
+
+// builtinMaskPatterns are the patterns of the values the mask operator can
+// mask without a regex
+var builtinMaskPatterns = map[string]string{
+	"email":        `[A-Za-z0-9._%+-]+@[A-Za-z0-9-]+(?:\.[A-Za-z0-9-]+)*\.[A-Za-z]{2,}`,
+	"card_number":  `\b(?:\d[ -]?){12,18}\d\b`,
+	"bearer_token": `(?i:bearer)\s+[A-Za-z0-9\-._~+/]+=*`,
+}
+
+// logMask replaces all the matches of the pattern in the field
+type logMask struct {
+	field       string
+	pattern     string
+	replacement string
+}
+
+// attribute returns the attribute marking the logs masked by m, the mask
+// operators with the same field, pattern and replacement share it
+func (m logMask) attribute() string {
+	h := fnv.New32a()
+	h.Write([]byte(strings.Join([]string{m.field, m.pattern, m.replacement}, "\x00")))
+	return fmt.Sprintf("%s%08x__", maskAttributePrefix, h.Sum32())
+}
+
+// maskPattern returns the pattern matching any of the values masked by op
+func maskPattern(op PipelineOperator) (string, error) {
+	patterns := []string{}
+	for _, name := range op.MaskPatterns {
+		pattern, ok := builtinMaskPatterns[name]
+		if !ok {
+			return "", fmt.Errorf("unknown mask pattern %s", name)
+		}
+		patterns = append(patterns, pattern)
+	}
+	if op.Regex != "" {
+		patterns = append(patterns, op.Regex)
+	}
+	if len(patterns) == 0 {
+		return "", fmt.Errorf("no patterns to mask")
+	}
+	return fmt.Sprintf("(?:%s)", strings.Join(patterns, "|")), nil
+}
+
+// maskFields returns the fields masked by op, the body by default
+func maskFields(op PipelineOperator) []string {
+	if len(op.Fields) == 0 {
+		return []string{"body"}
+	}
+	return op.Fields
+}
+
+// operatorMasks returns the masks of the fields of op
+func operatorMasks(op PipelineOperator) ([]logMask, error) {
+	pattern, err := maskPattern(op)
+	if err != nil {
+		return nil, err
+	}
+	replacement := op.Replacement
+	if replacement == "" {
+		replacement = defaultMaskReplacement
+	}
+
+	masks := []logMask{}
+	for _, field := range maskFields(op) {
+		if _, _, err := logFieldKeys(field); err != nil {
+			return nil, err
+		}
+		masks = append(masks, logMask{field: field, pattern: pattern, replacement: replacement})
+	}
+	return masks, nil
+}
+
+// maskOperators marks the logs reaching the operator with the attributes of
+// the masks of its fields
+func maskOperators(op PipelineOperator) ([]PipelineOperator, error) {
+	masks, err := operatorMasks(op)
+	if err != nil {
+		return nil, err
+	}
+
+	operators := []PipelineOperator{}
+	for idx, mask := range masks {
+		operators = append(operators, compiledOperator(op, compiledOperatorID(op, idx), PipelineOperator{
+			Type:  "add",
+			Field: "attributes." + mask.attribute(),
+			Value: "true",
+		}))
+	}
+	return operators, nil
+}
+
+// pipelineMasks returns the distinct masks of the enabled pipelines
+func pipelineMasks(pipelines []Pipeline) []logMask {
+	masks := []logMask{}
+	seen := map[logMask]struct{}{}
+	for _, p := range pipelines {
+		if !p.Enabled {
+			continue
+		}
+		for _, op := range p.Config {
+			if !op.Enabled || op.Type != "mask" {
+				continue
+			}
+			// the operators are validated when the pipelines are prepared
+			opMasks, err := operatorMasks(op)
+			if err != nil {
+				continue
+			}
+			for _, mask := range opMasks {
+				if _, ok := seen[mask]; !ok {
+					seen[mask] = struct{}{}
+					masks = append(masks, mask)
+				}
+			}
+		}
+	}
+	return masks
+}
+
+// checkMaskedFieldReads returns an error if an operator reads a field masked
+// by a mask operator before it. The operator would read the unmasked value
+// and could copy it to a field that is not masked, so the mask operator has
+// to come after it.
+func checkMaskedFieldReads(ops []PipelineOperator) error {
+	masked := []string{}
+	for _, op := range ops {
+		if !op.Enabled {
+			continue
+		}
+		for _, field := range masked {
+			if read := operatorFieldRead(op, field); read != "" {
+				return fmt.Errorf(
+					"operator %s reads %s before it is masked, the values are masked after the pipelines so the mask operator has to come after it",
+					op.ID, read,
+				)
+			}
+		}
+		if op.Type == "mask" {
+			masked = append(masked, maskFields(op)...)
+		}
+	}
+	return nil
+}
+
+// operatorFieldRead returns the field read by op that overlaps with the
+// field, empty if op doesn't read it
+func operatorFieldRead(op PipelineOperator, field string) string {
+	reads := []string{op.ParseFrom, op.From}
+	if op.TraceParser != nil {
+		for _, traceField := range []*ParseFrom{op.TraceId, op.SpanId, op.TraceFlags} {
+			if traceField != nil {
+				reads = append(reads, traceField.ParseFrom)
+			}
+		}
+	}
+	for _, read := range reads {
+		if read != "" && (fieldContains(read, field) || fieldContains(field, read)) {
+			return read
+		}
+	}
+
+	// the values of add operators can be expressions of the fields
+	if strings.HasPrefix(op.Value, "EXPR(") {
+		root, keys, err := logFieldKeys(field)
+		if err != nil {
+			return ""
+		}
+		if strings.Contains(op.Value, root) && (len(keys) == 0 || strings.Contains(op.Value, keys[len(keys)-1])) {
+			return op.Value
+		}
+	}
+	return ""
+}
+
+// fieldContains returns whether the field is the parent field or one of its keys
+func fieldContains(parent string, field string) bool {
+	return field == parent || strings.HasPrefix(field, parent+".") || strings.HasPrefix(field, parent+"[")
+}
+
+// ottlPath returns the path of the field in the log context of the transform processor
+func ottlPath(field string) (string, error) {
+	root, keys, err := logFieldKeys(field)
+	if err != nil {
+		return "", err
+	}
+	path := root
+	if root == "resource" {
+		path = "resource.attributes"
+	}
+	for _, key := range keys {
+		path += fmt.Sprintf("[%s]", strconv.Quote(key))
+	}
+	return path, nil
+}
+
+// maskProcessor returns the processor masking the values of the marked logs
+// and removing the marks, it comes after the pipeline processors
+func maskProcessor(masks []logMask) (map[string]interface{}, []string, error) {
+	statements := []string{}
+	for _, mask := range masks {
+		path, err := ottlPath(mask.field)
+		if err != nil {
+			return nil, nil, err
+		}
+		// the replacement is expanded by the processor, $ is escaped to keep it as is
+		statements = append(statements, fmt.Sprintf(
+			`replace_pattern(%s, %s, %s) where attributes[%s] != nil`,
+			path, strconv.Quote(mask.pattern), strconv.Quote(strings.ReplaceAll(mask.replacement, "$", "$$")),
+			strconv.Quote(mask.attribute()),
+		))
+	}
+	statements = append(statements, fmt.Sprintf(
+		`delete_matching_keys(attributes, %s)`, strconv.Quote("^"+regexp.QuoteMeta(maskAttributePrefix)),
+	))
+
+	processors := map[string]interface{}{
+		constants.LogsPPLMaskProcessor: map[string]interface{}{
+			"error_mode": "ignore",
+			"log_statements": []map[string]interface{}{
+				{
+					"context":    "log",
+					"statements": statements,
+				},
+			},
+		},
+	}
+	return processors, []string{constants.LogsPPLMaskProcessor}, nil
+}
+
+// applyMasks masks the values of the marked logs in the output of a
+// simulation like the mask processor, the simulator only runs the pipeline
+// processors. Only the body and the top level attributes and resources are
+// masked, the other fields are serialized in the output.
+func applyMasks(logs []model.SignozLog, masks []logMask) {
+	for _, mask := range masks {
+		root, keys, err := logFieldKeys(mask.field)
+		if err != nil {
+			continue
+		}
+		pattern, err := regexp.Compile(mask.pattern)
+		if err != nil {
+			continue
+		}
+		for idx := range logs {
+			log := &logs[idx]
+			if _, ok := log.Attributes_string[mask.attribute()]; !ok {
+				continue
+			}
+			switch {
+			case root == "body" && len(keys) == 0:
+				log.Body = pattern.ReplaceAllLiteralString(log.Body, mask.replacement)
+			case root == "attributes" && len(keys) == 1:
+				if value, ok := log.Attributes_string[keys[0]]; ok {
+					log.Attributes_string[keys[0]] = pattern.ReplaceAllLiteralString(value, mask.replacement)
+				}
+			case root == "resource" && len(keys) == 1:
+				if value, ok := log.Resources_string[keys[0]]; ok {
+					log.Resources_string[keys[0]] = pattern.ReplaceAllLiteralString(value, mask.replacement)
+				}
+			}
+		}
+	}
+
+	for idx := range logs {
+		for key := range logs[idx].Attributes_string {
+			if strings.HasPrefix(key, maskAttributePrefix) {
+				delete(logs[idx].Attributes_string, key)
+			}
+		}
+	}
+}
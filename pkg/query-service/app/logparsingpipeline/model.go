@@ -70,6 +70,20 @@ type PipelineOperator struct {
 	// severity parser fields
 	SeverityMapping       map[string][]string `json:"mapping,omitempty" yaml:"mapping,omitempty"`
 	OverwriteSeverityText bool                `json:"overwrite_text,omitempty" yaml:"overwrite_text,omitempty"`
+
+	// kv_parser and csv_parser fields, these operators are compiled
+	// into other operators so the fields are not in the final config
+	Delimiter     string `json:"delimiter,omitempty" yaml:"-"`
+	PairDelimiter string `json:"pair_delimiter,omitempty" yaml:"-"`
+	Header        string `json:"header,omitempty" yaml:"-"`
+
+	// mask operator fields
+	MaskPatterns []string `json:"patterns,omitempty" yaml:"-"`
+	Replacement  string   `json:"replacement,omitempty" yaml:"-"`
+
+	// sample operator fields
+	SamplingPercentage float64 `json:"sampling_percentage,omitempty" yaml:"-"`
+	SampleBy           string  `json:"sample_by,omitempty" yaml:"-"`
 }
 
 type TimestampParser struct {
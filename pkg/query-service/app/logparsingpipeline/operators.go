@@ -0,0 +1,272 @@
+package logparsingpipeline
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// The collector only runs a fixed set of operators. The kv_parser, csv_parser,
+// uri_parser, user_agent_parser, mask, drop and sample operators are compiled
+// into the regex_parser, json_parser, add, remove and noop operators of the
+// collector when the pipelines are prepared.
+
+const (
+	defaultKVDelimiter     = "="
+	defaultKVPairDelimiter = " "
+	defaultCSVDelimiter    = ","
+
+	kvTempField = "attributes.__signoz_kv__"
+)
+
+// uriRegex splits a URI into its components, see RFC 3986 appendix B
+const uriRegex = `^(?:(?P<scheme>[a-zA-Z][a-zA-Z0-9+.-]*):)?(?://(?:(?P<user>[^@/?#]*)@)?(?P<host>[^:/?#]*)(?::(?P<port>[0-9]+))?)?(?P<path>[^?#]*)(?:\?(?P<query>[^#]*))?(?:#(?P<fragment>.*))?$`
+
+var csvHeaderRegex = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// userAgentMatch maps the user agents containing a token to a value, the
+// version is read after the version token
+type userAgentMatch struct {
+	token        string
+	value        string
+	versionToken string
+}
+
+var userAgentBrowsers = []userAgentMatch{
+	{token: "Edg/", value: "Edge", versionToken: "Edg/"},
+	{token: "OPR/", value: "Opera", versionToken: "OPR/"},
+	{token: "SamsungBrowser/", value: "Samsung Internet", versionToken: "SamsungBrowser/"},
+	{token: "CriOS/", value: "Chrome", versionToken: "CriOS/"},
+	{token: "FxiOS/", value: "Firefox", versionToken: "FxiOS/"},
+	{token: "Firefox/", value: "Firefox", versionToken: "Firefox/"},
+	{token: "Chrome/", value: "Chrome", versionToken: "Chrome/"},
+	{token: "Safari/", value: "Safari", versionToken: "Version/"},
+	{token: "MSIE ", value: "Internet Explorer", versionToken: "MSIE "},
+	{token: "Trident/", value: "Internet Explorer", versionToken: "rv:"},
+	{token: "Googlebot/", value: "Googlebot", versionToken: "Googlebot/"},
+	{token: "bingbot/", value: "Bingbot", versionToken: "bingbot/"},
+	{token: "curl/", value: "curl", versionToken: "curl/"},
+	{token: "Wget/", value: "Wget", versionToken: "Wget/"},
+	{token: "python-requests/", value: "Python Requests", versionToken: "python-requests/"},
+	{token: "Go-http-client/", value: "Go HTTP Client", versionToken: "Go-http-client/"},
+	{token: "okhttp/", value: "OkHttp", versionToken: "okhttp/"},
+}
+
+var userAgentOSs = []userAgentMatch{
+	{token: "Windows Phone", value: "Windows Phone"},
+	{token: "Windows", value: "Windows"},
+	{token: "Android", value: "Android"},
+	{token: "iPhone", value: "iOS"},
+	{token: "iPad", value: "iOS"},
+	{token: "CrOS", value: "Chrome OS"},
+	{token: "Mac OS X", value: "macOS"},
+	{token: "Linux", value: "Linux"},
+}
+
+// expandOperators replaces the enabled operators that are not run by the
+// collector with the operators they are compiled into
+func expandOperators(ops []PipelineOperator) ([]PipelineOperator, error) {
+	result := []PipelineOperator{}
+	for _, op := range ops {
+		if !op.Enabled {
+			result = append(result, op)
+			continue
+		}
+
+		var expanded []PipelineOperator
+		var err error
+		switch op.Type {
+		case "kv_parser":
+			expanded, err = kvParserOperators(op)
+		case "csv_parser":
+			expanded, err = csvParserOperators(op)
+		case "uri_parser":
+			expanded = []PipelineOperator{compiledOperator(op, op.ID, PipelineOperator{
+				Type:      "regex_parser",
+				ParseFrom: op.ParseFrom,
+				ParseTo:   op.ParseTo,
+				Regex:     uriRegex,
+			})}
+		case "user_agent_parser":
+			expanded, err = userAgentParserOperators(op)
+		case "mask":
+			expanded, err = maskOperators(op)
+		case "drop":
+			expanded, err = dropOperators(op)
+		case "sample":
+			expanded, err = sampleOperators(op)
+		default:
+			result = append(result, op)
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("couldn't compile %s operator %s: %w", op.Type, op.Name, err)
+		}
+
+		// the compiled operators are chained, the last one has the output of the operator
+		for idx := 0; idx < len(expanded)-1; idx++ {
+			expanded[idx].Output = expanded[idx+1].ID
+		}
+		expanded[len(expanded)-1].Output = op.Output
+		result = append(result, expanded...)
+	}
+	return result, nil
+}
+
+// compiledOperator returns an operator compiled from op with the id
+func compiledOperator(op PipelineOperator, id string, compiled PipelineOperator) PipelineOperator {
+	compiled.ID = id
+	compiled.OrderId = op.OrderId
+	compiled.Enabled = true
+	compiled.Name = op.Name
+	compiled.OnError = op.OnError
+	return compiled
+}
+
+// compiledOperatorID returns the id of the idx-th operator compiled from op,
+// the first one has the id of op so that the operators before it are routed to it
+func compiledOperatorID(op PipelineOperator, idx int) string {
+	if idx == 0 {
+		return op.ID
+	}
+	return fmt.Sprintf("%s_%d", op.ID, idx)
+}
+
+// exprString quotes the value as a string literal of an expr expression
+func exprString(value string) string {
+	return strconv.Quote(value)
+}
+
+// stringFieldCheck returns an expression checking that the field is a string
+func stringFieldCheck(field string) (string, error) {
+	notNilCheck, err := fieldNotNilCheck(field)
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf(`%s && type(%s) == "string"`, notNilCheck, field), nil
+}
+
+// kvParserOperators parses the key value pairs of the field. The pairs are
+// split on the pair delimiter and the keys and values on the first delimiter,
+// the quotes around the values are removed. The pairs are converted to a JSON
+// object in a temporary field by an add operator and parsed by a json parser.
+func kvParserOperators(op PipelineOperator) ([]PipelineOperator, error) {
+	delimiter := op.Delimiter
+	if delimiter == "" {
+		delimiter = defaultKVDelimiter
+	}
+	pairDelimiter := op.PairDelimiter
+	if pairDelimiter == "" {
+		pairDelimiter = defaultKVPairDelimiter
+	}
+
+	check, err := stringFieldCheck(op.ParseFrom)
+	if err != nil {
+		return nil, err
+	}
+
+	pair := fmt.Sprintf("split(#, %s, 2)", exprString(delimiter))
+	value := fmt.Sprintf(
+		`EXPR("{" + join(map(filter(split(%s, %s), indexOf(#, %s) > 0), toJSON(trim(%s[0])) + ":" + toJSON(trim(trim(%s[1]), %s))), ",") + "}")`,
+		op.ParseFrom, exprString(pairDelimiter), exprString(delimiter), pair, pair, exprString(`"'`),
+	)
+	return []PipelineOperator{
+		compiledOperator(op, compiledOperatorID(op, 0), PipelineOperator{
+			Type:  "add",
+			Field: kvTempField,
+			Value: value,
+			If:    fmt.Sprintf("%s && %s contains %s", check, op.ParseFrom, exprString(delimiter)),
+		}),
+		compiledOperator(op, compiledOperatorID(op, 1), PipelineOperator{
+			Type:      "json_parser",
+			ParseFrom: kvTempField,
+			ParseTo:   op.ParseTo,
+		}),
+		compiledOperator(op, compiledOperatorID(op, 2), PipelineOperator{
+			Type:  "remove",
+			Field: kvTempField,
+		}),
+	}, nil
+}
+
+// csvParserOperators parses the delimited values of the field into the
+// fields of the header with a regex parser. The values can be quoted but
+// can't contain the delimiter.
+func csvParserOperators(op PipelineOperator) ([]PipelineOperator, error) {
+	delimiter := op.Delimiter
+	if delimiter == "" {
+		delimiter = defaultCSVDelimiter
+	}
+	valuePattern := fmt.Sprintf(`"?(?P<%%s>[^%s"]*)"?`, regexp.QuoteMeta(delimiter))
+
+	groups := []string{}
+	for _, name := range strings.Split(op.Header, delimiter) {
+		groups = append(groups, fmt.Sprintf(valuePattern, strings.TrimSpace(name)))
+	}
+	regex := fmt.Sprintf(`^%s$`, strings.Join(groups, regexp.QuoteMeta(delimiter)))
+	if _, err := regexp.Compile(regex); err != nil {
+		return nil, err
+	}
+
+	return []PipelineOperator{compiledOperator(op, op.ID, PipelineOperator{
+		Type:      "regex_parser",
+		ParseFrom: op.ParseFrom,
+		ParseTo:   op.ParseTo,
+		Regex:     regex,
+	})}, nil
+}
+
+// userAgentParserOperators adds the browser, browser_version, os and
+// device_type of the user agent in the field
+func userAgentParserOperators(op PipelineOperator) ([]PipelineOperator, error) {
+	parseTo := op.ParseTo
+	if parseTo == "" {
+		parseTo = "attributes"
+	}
+	check, err := stringFieldCheck(op.ParseFrom)
+	if err != nil {
+		return nil, err
+	}
+
+	browser := exprString("Other")
+	version := exprString("")
+	for idx := len(userAgentBrowsers) - 1; idx >= 0; idx-- {
+		match := userAgentBrowsers[idx]
+		condition := fmt.Sprintf("%s contains %s", op.ParseFrom, exprString(match.token))
+		browser = fmt.Sprintf("%s ? %s : %s", condition, exprString(match.value), browser)
+		versionValue := fmt.Sprintf(
+			`%s contains %s ? split(split(split(split(%s, %s)[1], " ")[0], ";")[0], ")")[0] : ""`,
+			op.ParseFrom, exprString(match.versionToken), op.ParseFrom, exprString(match.versionToken),
+		)
+		version = fmt.Sprintf("%s ? (%s) : %s", condition, versionValue, version)
+	}
+
+	os := exprString("Other")
+	for idx := len(userAgentOSs) - 1; idx >= 0; idx-- {
+		match := userAgentOSs[idx]
+		os = fmt.Sprintf("%s contains %s ? %s : %s", op.ParseFrom, exprString(match.token), exprString(match.value), os)
+	}
+
+	deviceType := fmt.Sprintf(
+		`lower(%s) matches "bot|crawler|spider" ? "bot" : %s matches "iPad|Tablet" || (%s contains "Android" && not (%s contains "Mobile")) ? "tablet" : %s matches "Mobile|iPhone|Android" ? "mobile" : "desktop"`,
+		op.ParseFrom, op.ParseFrom, op.ParseFrom, op.ParseFrom, op.ParseFrom,
+	)
+
+	values := []struct{ key, expression string }{
+		{"browser", browser},
+		{"browser_version", version},
+		{"os", os},
+		{"device_type", deviceType},
+	}
+	operators := []PipelineOperator{}
+	for idx, value := range values {
+		operators = append(operators, compiledOperator(op, compiledOperatorID(op, idx), PipelineOperator{
+			Type:  "add",
+			Field: fmt.Sprintf("%s.%s", parseTo, value.key),
+			Value: fmt.Sprintf("EXPR(%s)", value.expression),
+			If:    check,
+		}))
+	}
+	return operators, nil
+}
@@ -83,6 +83,11 @@ func PreparePipelineProcessor(pipelines []Pipeline) (map[string]interface{}, []s
 }
 
 func getOperators(ops []PipelineOperator) ([]PipelineOperator, error) {
+	ops, err := expandOperators(ops)
+	if err != nil {
+		return nil, err
+	}
+
 	filteredOp := []PipelineOperator{}
 	for i, operator := range ops {
 		if operator.Enabled {
@@ -137,7 +142,10 @@ func getOperators(ops []PipelineOperator) ([]PipelineOperator, error) {
 							operator.Name, err,
 						)
 					}
-					if fieldsNotNilCheck != "" {
+					if fieldsNotNilCheck != "" && operator.If != "" {
+						// the condition of the compiled operators comes after the nil checks
+						operator.If = fmt.Sprintf("%s && %s", fieldsNotNilCheck, operator.If)
+					} else if fieldsNotNilCheck != "" {
 						operator.If = fieldsNotNilCheck
 					}
 				}
@@ -384,3 +392,42 @@ func logFieldsReferencedInExpr(expr string) ([]string, error) {
 
 	return v.referencedFields, nil
 }
+
+// logFieldKeys splits a field into its root, one of body, attributes
+// and resource, and the keys of the field in the root
+func logFieldKeys(field string) (string, []string, error) {
+	tree, err := parser.Parse(field)
+	if err != nil {
+		return "", nil, fmt.Errorf("invalid field %s: %w", field, err)
+	}
+
+	keys := []string{}
+	node := tree.Node
+	for {
+		member, ok := node.(*ast.MemberNode)
+		if !ok {
+			break
+		}
+		key, ok := member.Property.(*ast.StringNode)
+		if !ok {
+			return "", nil, fmt.Errorf("field %s can only have keys of maps", field)
+		}
+		keys = append([]string{key.Value}, keys...)
+		node = member.Node
+	}
+
+	root, ok := node.(*ast.IdentifierNode)
+	if !ok {
+		return "", nil, fmt.Errorf("invalid field %s", field)
+	}
+	switch {
+	case root.Value == "body":
+	case root.Value == "attributes" || root.Value == "resource":
+		if len(keys) == 0 {
+			return "", nil, fmt.Errorf("field %s must be a key of the %s", field, root.Value)
+		}
+	default:
+		return "", nil, fmt.Errorf("field %s should have prefix of body, attributes, resource", field)
+	}
+	return root.Value, keys, nil
+}
@@ -6,6 +6,7 @@ import (
 	"regexp"
 	"strings"
 
+	"go.signoz.io/signoz/pkg/query-service/agentConf"
 	v3 "go.signoz.io/signoz/pkg/query-service/model/v3"
 	"go.signoz.io/signoz/pkg/query-service/queryBuilderToExpr"
 	"golang.org/x/exp/slices"
@@ -14,6 +15,10 @@ import (
 // PostablePipelines are a list of user defined pielines
 type PostablePipelines struct {
 	Pipelines []PostablePipeline `json:"pipelines"`
+
+	// when set, the new version is deployed only to the agents with all the
+	// labels of the selector until it's promoted to the whole fleet
+	StagedSelector agentConf.AgentLabels `json:"stagedSelector,omitempty"`
 }
 
 // PostablePipeline captures user inputs in setting the pipeline
@@ -88,7 +93,7 @@ func (p *PostablePipeline) IsValid() error {
 		idUnique[op.ID] = struct{}{}
 		outputUnique[op.Output] = struct{}{}
 	}
-	return nil
+	return checkMaskedFieldReads(p.Config)
 }
 
 func isValidOperator(op PipelineOperator) error {
@@ -210,8 +215,85 @@ func isValidOperator(op PipelineOperator) error {
 			}
 		}
 
+	case "kv_parser":
+		if op.ParseFrom == "" {
+			return fmt.Errorf("parse from of key value parsing processor %s cannot be empty", op.ID)
+		}
+		if op.ParseTo != "" && !strings.HasPrefix(op.ParseTo, "attributes") {
+			return fmt.Errorf("key value parsing processor %s can only parse to attributes", op.ID)
+		}
+		delimiter, pairDelimiter := op.Delimiter, op.PairDelimiter
+		if delimiter == "" {
+			delimiter = defaultKVDelimiter
+		}
+		if pairDelimiter == "" {
+			pairDelimiter = defaultKVPairDelimiter
+		}
+		if delimiter == pairDelimiter {
+			return fmt.Errorf("delimiter and pair delimiter of key value parsing processor %s cannot be the same", op.ID)
+		}
+
+	case "csv_parser":
+		if op.ParseFrom == "" {
+			return fmt.Errorf("parse from of csv parsing processor %s cannot be empty", op.ID)
+		}
+		if op.Header == "" {
+			return fmt.Errorf("header of csv parsing processor %s cannot be empty", op.ID)
+		}
+		delimiter := op.Delimiter
+		if delimiter == "" {
+			delimiter = defaultCSVDelimiter
+		}
+		if len([]rune(delimiter)) != 1 || delimiter == `"` {
+			return fmt.Errorf("delimiter of csv parsing processor %s must be a single character other than a quote", op.ID)
+		}
+		seen := map[string]struct{}{}
+		for _, name := range strings.Split(op.Header, delimiter) {
+			name = strings.TrimSpace(name)
+			if !csvHeaderRegex.MatchString(name) {
+				return fmt.Errorf("invalid header field '%s' of csv parsing processor %s, use letters, digits and underscores", name, op.ID)
+			}
+			if _, ok := seen[name]; ok {
+				return fmt.Errorf("duplicate header field '%s' of csv parsing processor %s", name, op.ID)
+			}
+			seen[name] = struct{}{}
+		}
+
+	case "uri_parser", "user_agent_parser":
+		if op.ParseFrom == "" {
+			return fmt.Errorf("parse from of %s processor %s cannot be empty", op.Type, op.ID)
+		}
+
+	case "mask":
+		if len(op.MaskPatterns) == 0 && op.Regex == "" {
+			return fmt.Errorf("patterns or regex of mask processor %s cannot be empty", op.ID)
+		}
+		if op.Regex != "" {
+			if _, err := regexp.Compile(op.Regex); err != nil {
+				return fmt.Errorf("error compiling regex expression of mask processor %s: %w", op.ID, err)
+			}
+		}
+		if _, err := maskPattern(op); err != nil {
+			return fmt.Errorf("invalid patterns of mask processor %s: %w", op.ID, err)
+		}
+		for _, field := range op.Fields {
+			if _, _, err := logFieldKeys(field); err != nil {
+				return fmt.Errorf("invalid field of mask processor %s: %w", op.ID, err)
+			}
+		}
+
+	case "drop":
+		// drops every log matching the pipeline filter
+	case "sample":
+		if op.SamplingPercentage <= 0 || op.SamplingPercentage > 100 {
+			return fmt.Errorf("sampling_percentage of sample processor %s should be greater than 0 and at most 100", op.ID)
+		}
+		if op.SampleBy != "" && op.SampleBy != sampleByTraceID && !isValidOtelValue(op.SampleBy) {
+			return fmt.Errorf("sample_by of sample processor %s should be trace_id or have prefix of body, attributes, resource", op.ID)
+		}
+
 	default:
-		return fmt.Errorf(fmt.Sprintf("operator type %s not supported for %s, use one of (grok_parser, regex_parser, copy, move, add, remove, trace_parser, retain)", op.Type, op.ID))
+		return fmt.Errorf(fmt.Sprintf("operator type %s not supported for %s, use one of (grok_parser, regex_parser, copy, move, add, remove, trace_parser, retain, kv_parser, csv_parser, uri_parser, user_agent_parser, mask, drop, sample)", op.Type, op.ID))
 	}
 
 	if !isValidOtelValue(op.ParseFrom) ||
@@ -360,6 +360,83 @@ var operatorTest = []struct {
 			OverwriteSeverityText: true,
 		},
 		IsValid: false,
+	}, {
+		Name: "KV Parser - valid",
+		Operator: PipelineOperator{
+			ID:        "kv",
+			Type:      "kv_parser",
+			ParseFrom: "body",
+			ParseTo:   "attributes.kv",
+		},
+		IsValid: true,
+	}, {
+		Name: "KV Parser - delimiters must differ",
+		Operator: PipelineOperator{
+			ID:            "kv",
+			Type:          "kv_parser",
+			ParseFrom:     "body",
+			Delimiter:     ",",
+			PairDelimiter: ",",
+		},
+		IsValid: false,
+	}, {
+		Name: "CSV Parser - valid",
+		Operator: PipelineOperator{
+			ID:        "csv",
+			Type:      "csv_parser",
+			ParseFrom: "body",
+			Header:    "ip,method,path,status",
+		},
+		IsValid: true,
+	}, {
+		Name: "CSV Parser - header fields must be valid names",
+		Operator: PipelineOperator{
+			ID:        "csv",
+			Type:      "csv_parser",
+			ParseFrom: "body",
+			Header:    "ip;user-agent",
+			Delimiter: ";",
+		},
+		IsValid: false,
+	}, {
+		Name: "URI Parser - parse from is required",
+		Operator: PipelineOperator{
+			ID:   "uri",
+			Type: "uri_parser",
+		},
+		IsValid: false,
+	}, {
+		Name: "User Agent Parser - valid",
+		Operator: PipelineOperator{
+			ID:        "ua",
+			Type:      "user_agent_parser",
+			ParseFrom: "attributes.user_agent",
+		},
+		IsValid: true,
+	}, {
+		Name: "Mask - valid",
+		Operator: PipelineOperator{
+			ID:           "mask",
+			Type:         "mask",
+			MaskPatterns: []string{"email", "card_number", "bearer_token"},
+			Fields:       []string{"body", "attributes.auth"},
+		},
+		IsValid: true,
+	}, {
+		Name: "Mask - unknown pattern",
+		Operator: PipelineOperator{
+			ID:           "mask",
+			Type:         "mask",
+			MaskPatterns: []string{"ssn"},
+		},
+		IsValid: false,
+	}, {
+		Name: "Mask - patterns are required",
+		Operator: PipelineOperator{
+			ID:   "mask",
+			Type: "mask",
+		},
+		IsValid: false,
 	},
 }
 
@@ -2,6 +2,7 @@ package logparsingpipeline
 
 import (
 	"context"
+	"encoding/hex"
 	"sort"
 	"strings"
 	"time"
@@ -59,7 +60,9 @@ func SimulatePipelinesProcessing(
 	timeout := time.Millisecond * time.Duration(len(pipelines)*100+100)
 
 	configGenerator := func(baseConf []byte) ([]byte, error) {
-		updatedConf, apiErr := GenerateCollectorConfigWithPipelines(baseConf, pipelines)
+		// the simulator only has the logs pipeline processor, the logs marked
+		// to be masked and dropped are processed in the simulation output
+		updatedConf, apiErr := generateCollectorConfig(baseConf, pipelines, false)
 		if apiErr != nil {
 			return nil, apiErr.ToError()
 		}
@@ -90,6 +93,8 @@ func SimulatePipelinesProcessing(
 	for _, sigLog := range outputSignozLogs {
 		delete(sigLog.Attributes_int64, inputOrderAttribute)
 	}
+	applyMasks(outputSignozLogs, pipelineMasks(pipelines))
+	outputSignozLogs = applyDrops(outputSignozLogs)
 
 	for _, log := range collectorErrs {
 		// if log is empty or log comes from featuregate.go, then remove it
@@ -126,12 +131,21 @@ func SignozLogsToPLogs(logs []model.SignozLog) []plog.Logs {
 			time.Unix(0, int64(log.Timestamp)),
 		))
 
+		// the ids of stored logs are hex encoded
 		var traceIdBuf [16]byte
-		copy(traceIdBuf[:], []byte(log.TraceID))
+		if traceId, err := hex.DecodeString(log.TraceID); err == nil && len(traceId) == len(traceIdBuf) {
+			copy(traceIdBuf[:], traceId)
+		} else {
+			copy(traceIdBuf[:], []byte(log.TraceID))
+		}
 		slRecord.SetTraceID(traceIdBuf)
 
 		var spanIdBuf [8]byte
-		copy(spanIdBuf[:], []byte(log.SpanID))
+		if spanId, err := hex.DecodeString(log.SpanID); err == nil && len(spanId) == len(spanIdBuf) {
+			copy(spanIdBuf[:], spanId)
+		} else {
+			copy(spanIdBuf[:], []byte(log.SpanID))
+		}
 		slRecord.SetSpanID(spanIdBuf)
 
 		slRecord.SetFlags(plog.LogRecordFlags(log.TraceFlags))
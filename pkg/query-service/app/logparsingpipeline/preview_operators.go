@@ -0,0 +1,393 @@
+package logparsingpipeline
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+
+	"go.signoz.io/signoz/pkg/query-service/model"
+)
+
+// Operator preview runs the logs through a pipeline one operator at a time.
+// The simulation is run for every prefix of the operators of the pipeline
+// and the state of each log after an operator is compared with its state
+// after the previous operator. The last operator of a prefix stops processing
+// the logs it fails on and an operator after it marks the logs reaching it,
+// so the errors of the collector are attributed to the logs without the mark
+// in the order they are processed.
+
+const (
+	// previewMatchAttribute marks the logs matching the filter of the
+	// pipeline, it's added by an operator before the operators of the pipeline
+	previewMatchAttribute = "__signoz_preview_match__"
+
+	// previewReachedAttribute marks the logs the last operator of a prefix
+	// didn't fail on
+	previewReachedAttribute = "__signoz_preview_reached__"
+
+	DefaultOperatorPreviewLogsLimit = 10
+	MaxOperatorPreviewLogsLimit     = 100
+
+	// MaxOperatorPreviewOperators limits the simulations run for a preview,
+	// one is run per operator
+	MaxOperatorPreviewOperators = 20
+
+	// defaultOnError is the on_error of the operators without one
+	defaultOnError = "send"
+)
+
+type OperatorPreviewRequest struct {
+	Pipeline Pipeline `json:"pipeline"`
+
+	// logs to run through the pipeline. When empty, recent logs matching
+	// the filter of the pipeline are sampled from the stored logs
+	Logs []model.SignozLog `json:"logs"`
+
+	// number of stored logs sampled, and the time range in epoch millis
+	// they are sampled from
+	Limit int   `json:"limit"`
+	Start int64 `json:"start"`
+	End   int64 `json:"end"`
+}
+
+type OperatorPreviewResponse struct {
+	Logs      []LogOperatorPreview `json:"logs"`
+	Operators []OperatorErrors     `json:"operators"`
+}
+
+// LogOperatorPreview is the state of a log after each operator of the pipeline
+type LogOperatorPreview struct {
+	Input model.SignozLog `json:"input"`
+	// whether the log matches the filter of the pipeline, the operators
+	// only process the logs matching it
+	Matched bool           `json:"matched"`
+	Steps   []OperatorStep `json:"steps"`
+}
+
+type OperatorStep struct {
+	OperatorID   string           `json:"operatorId"`
+	OperatorName string           `json:"operatorName"`
+	Changes      []LogFieldChange `json:"changes"`
+	Output       *model.SignozLog `json:"output,omitempty"`
+	Dropped      bool             `json:"dropped"`
+	// errors of the operator for the log, handled as per its on_error
+	Errors []string `json:"errors,omitempty"`
+}
+
+type LogFieldChangeType string
+
+const (
+	LogFieldAdded   LogFieldChangeType = "added"
+	LogFieldChanged LogFieldChangeType = "changed"
+	LogFieldRemoved LogFieldChangeType = "removed"
+)
+
+// LogFieldChange is a change to a field of a log, the fields are named like
+// in the operators of the pipelines, e.g. body, attributes.method. The
+// changes between versions of a pipeline are reported the same way.
+type LogFieldChange struct {
+	Field  string             `json:"field"`
+	Type   LogFieldChangeType `json:"type"`
+	Before interface{}        `json:"before,omitempty"`
+	After  interface{}        `json:"after,omitempty"`
+}
+
+// OperatorErrors are the errors reported by the collector while running an
+// operator, they are handled as per the on_error of the operator
+type OperatorErrors struct {
+	OperatorID   string   `json:"operatorId"`
+	OperatorName string   `json:"operatorName"`
+	Errors       []string `json:"errors"`
+}
+
+// collectorOperatorError is the context of the errors logged by the collector
+// when an operator fails to process an entry
+type collectorOperatorError struct {
+	OperatorID string `json:"operator_id"`
+	Error      string `json:"error"`
+	Action     string `json:"action"`
+}
+
+func PreviewPipelineOperators(
+	ctx context.Context,
+	pipeline Pipeline,
+	logs []model.SignozLog,
+) (*OperatorPreviewResponse, *model.ApiError) {
+	pipeline.Enabled = true
+	operators := []PipelineOperator{}
+	for _, op := range pipeline.Config {
+		if op.Enabled {
+			if op.OnError == "" {
+				op.OnError = defaultOnError
+			}
+			operators = append(operators, op)
+		}
+	}
+
+	// the outputs of the simulations are matched to the input logs by id
+	inputLogs := make([]model.SignozLog, len(logs))
+	for idx, log := range logs {
+		inputLogs[idx] = copySignozLog(log)
+		inputLogs[idx].ID = fmt.Sprintf("%d", idx)
+	}
+
+	result := &OperatorPreviewResponse{
+		Logs:      make([]LogOperatorPreview, len(logs)),
+		Operators: []OperatorErrors{},
+	}
+	states := make([]*model.SignozLog, len(logs))
+	// whether the processing of the log was stopped by an operator failing
+	// on it with on_error drop, the operators after it don't process it
+	stopped := make([]bool, len(logs))
+	for step := 0; step <= len(operators); step++ {
+		stepPipeline := pipeline
+		stepPipeline.Config = []PipelineOperator{previewMatchOperator()}
+		if step > 0 {
+			last := operators[step-1]
+			last.OnError = "drop"
+			stepPipeline.Config = append(stepPipeline.Config, operators[:step-1]...)
+			stepPipeline.Config = append(stepPipeline.Config, last, previewReachedOperator())
+		}
+
+		stepInput := make([]model.SignozLog, len(inputLogs))
+		for idx, log := range inputLogs {
+			stepInput[idx] = copySignozLog(log)
+		}
+		output, collectorLogs, apiErr := SimulatePipelinesProcessing(ctx, []Pipeline{stepPipeline}, stepInput)
+		if apiErr != nil {
+			return nil, model.WrapApiError(apiErr, fmt.Sprintf(
+				"could not simulate the pipeline up to operator %d", step,
+			))
+		}
+
+		outputByID := map[string]*model.SignozLog{}
+		for idx := range output {
+			outputByID[output[idx].ID] = &output[idx]
+		}
+
+		var opErrors []string
+		if step > 0 {
+			opErrors = operatorErrors(collectorLogs, operators[:step])[operators[step-1].ID]
+		}
+		for idx := range inputLogs {
+			state := outputByID[inputLogs[idx].ID]
+			reached := false
+			if state != nil {
+				_, result.Logs[idx].Matched = state.Attributes_string[previewMatchAttribute]
+				_, reached = state.Attributes_string[previewReachedAttribute]
+				delete(state.Attributes_string, previewMatchAttribute)
+				delete(state.Attributes_string, previewReachedAttribute)
+				state.ID = logs[idx].ID
+			}
+			if step == 0 {
+				result.Logs[idx].Input = logs[idx]
+				result.Logs[idx].Steps = []OperatorStep{}
+				states[idx] = state
+				continue
+			}
+			if states[idx] == nil || !result.Logs[idx].Matched {
+				continue
+			}
+
+			op := operators[step-1]
+			opStep := OperatorStep{
+				OperatorID:   op.ID,
+				OperatorName: op.Name,
+				Changes:      diffSignozLogs(states[idx], state),
+				Output:       state,
+				Dropped:      state == nil,
+			}
+			// the logs are processed in order and the operator stops at
+			// the first error for a log, so its errors are in the order
+			// of the logs it failed on
+			if state != nil && !reached && !stopped[idx] && len(opErrors) > 0 {
+				opStep.Errors = opErrors[:1]
+				opErrors = opErrors[1:]
+				if strings.HasPrefix(op.OnError, "drop") {
+					stopped[idx] = true
+				}
+			}
+			result.Logs[idx].Steps = append(result.Logs[idx].Steps, opStep)
+			states[idx] = state
+		}
+	}
+
+	for _, op := range operators {
+		opErrors := []string{}
+		for _, preview := range result.Logs {
+			for _, opStep := range preview.Steps {
+				if opStep.OperatorID == op.ID {
+					opErrors = append(opErrors, opStep.Errors...)
+				}
+			}
+		}
+		if len(opErrors) > 0 {
+			result.Operators = append(result.Operators, OperatorErrors{
+				OperatorID:   op.ID,
+				OperatorName: op.Name,
+				Errors:       opErrors,
+			})
+		}
+	}
+
+	return result, nil
+}
+
+// previewReachedOperator marks the logs reaching it, it comes after the last
+// operator of a prefix
+func previewReachedOperator() PipelineOperator {
+	return PipelineOperator{
+		ID:      "signoz_preview_reached",
+		Name:    "signoz_preview_reached",
+		Type:    "add",
+		Enabled: true,
+		Field:   "attributes." + previewReachedAttribute,
+		Value:   "true",
+	}
+}
+
+func previewMatchOperator() PipelineOperator {
+	return PipelineOperator{
+		ID:      "signoz_preview_match",
+		Name:    "signoz_preview_match",
+		Type:    "add",
+		Enabled: true,
+		Field:   "attributes." + previewMatchAttribute,
+		Value:   "true",
+	}
+}
+
+// operatorErrors returns the errors in the collector logs by the id of the
+// operator of the pipeline they were reported for. The errors of the
+// operators compiled from an operator are reported for it, with its on_error
+// when it has one.
+func operatorErrors(collectorLogs []string, operators []PipelineOperator) map[string][]string {
+	result := map[string][]string{}
+	for _, line := range collectorLogs {
+		start := strings.Index(line, "{")
+		if start < 0 {
+			continue
+		}
+		var opErr collectorOperatorError
+		if err := json.Unmarshal([]byte(line[start:]), &opErr); err != nil || opErr.OperatorID == "" {
+			continue
+		}
+		opID := reportingOperatorID(opErr.OperatorID, operators)
+		if opID == "" {
+			continue
+		}
+		action := opErr.Action
+		for _, op := range operators {
+			if op.ID == opID && op.OnError != "" {
+				action = op.OnError
+			}
+		}
+		message := opErr.Error
+		if action != "" {
+			message = fmt.Sprintf("%s (on_error: %s)", opErr.Error, action)
+		}
+		result[opID] = append(result[opID], message)
+	}
+	return result
+}
+
+// reportingOperatorID returns the id of the operator of the pipeline the
+// collector operator with the id was compiled from
+func reportingOperatorID(id string, operators []PipelineOperator) string {
+	for _, op := range operators {
+		if op.ID == id {
+			return op.ID
+		}
+	}
+	for _, op := range operators {
+		if strings.HasPrefix(id, op.ID+"_") {
+			return op.ID
+		}
+	}
+	return ""
+}
+
+// diffSignozLogs returns the changes to the fields of the log, the log is
+// dropped when after is nil
+func diffSignozLogs(before *model.SignozLog, after *model.SignozLog) []LogFieldChange {
+	if after == nil {
+		return []LogFieldChange{}
+	}
+	return diffFields(signozLogFields(before), signozLogFields(after))
+}
+
+// diffFields returns the changes between the values of the fields, sorted
+// by the name of the field
+func diffFields(before map[string]interface{}, after map[string]interface{}) []LogFieldChange {
+	changes := []LogFieldChange{}
+	for field, value := range after {
+		beforeValue, ok := before[field]
+		if !ok {
+			changes = append(changes, LogFieldChange{Field: field, Type: LogFieldAdded, After: value})
+		} else if !reflect.DeepEqual(beforeValue, value) {
+			changes = append(changes, LogFieldChange{Field: field, Type: LogFieldChanged, Before: beforeValue, After: value})
+		}
+	}
+	for field, value := range before {
+		if _, ok := after[field]; !ok {
+			changes = append(changes, LogFieldChange{Field: field, Type: LogFieldRemoved, Before: value})
+		}
+	}
+	sort.Slice(changes, func(i, j int) bool {
+		return changes[i].Field < changes[j].Field
+	})
+	return changes
+}
+
+// signozLogFields returns the values of the fields of the log by their name
+// in the operators of the pipelines
+func signozLogFields(log *model.SignozLog) map[string]interface{} {
+	fields := map[string]interface{}{}
+	if log == nil {
+		return fields
+	}
+	fields["body"] = log.Body
+	fields["severity_text"] = log.SeverityText
+	fields["severity_number"] = log.SeverityNumber
+	fields["timestamp"] = log.Timestamp
+	fields["trace_id"] = log.TraceID
+	fields["span_id"] = log.SpanID
+	fields["trace_flags"] = log.TraceFlags
+	for k, v := range log.Attributes_string {
+		fields["attributes."+k] = v
+	}
+	for k, v := range log.Attributes_int64 {
+		fields["attributes."+k] = v
+	}
+	for k, v := range log.Attributes_float64 {
+		fields["attributes."+k] = v
+	}
+	for k, v := range log.Attributes_bool {
+		fields["attributes."+k] = v
+	}
+	for k, v := range log.Resources_string {
+		fields["resource."+k] = v
+	}
+	return fields
+}
+
+func copySignozLog(log model.SignozLog) model.SignozLog {
+	result := log
+	result.Resources_string = copyMap(log.Resources_string)
+	result.Attributes_string = copyMap(log.Attributes_string)
+	result.Attributes_int64 = copyMap(log.Attributes_int64)
+	result.Attributes_float64 = copyMap(log.Attributes_float64)
+	result.Attributes_bool = copyMap(log.Attributes_bool)
+	return result
+}
+
+func copyMap[V any](m map[string]V) map[string]V {
+	result := make(map[string]V, len(m))
+	for k, v := range m {
+		result[k] = v
+	}
+	return result
+}
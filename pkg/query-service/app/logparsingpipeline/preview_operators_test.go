@@ -0,0 +1,168 @@
+package logparsingpipeline
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"go.signoz.io/signoz/pkg/query-service/model"
+)
+
+func TestPreviewPipelineOperators(t *testing.T) {
+	require := require.New(t)
+
+	pipeline := makeDropTestPipeline(PipelineOperator{
+		ID:        "regex",
+		Type:      "regex_parser",
+		ParseFrom: "body",
+		ParseTo:   "attributes",
+		Regex:     `^(?P<status>\d+) (?P<payload>.*)$`,
+	})
+	pipeline.Config = append(pipeline.Config,
+		PipelineOperator{
+			ID: "json", Name: "json", Type: "json_parser", Enabled: true,
+			ParseFrom: "attributes.payload", ParseTo: "attributes", OnError: "send",
+		},
+		PipelineOperator{
+			ID: "remove", Name: "remove", Type: "remove", Enabled: true,
+			Field: "attributes.payload",
+		},
+		PipelineOperator{
+			ID: "disabled", Name: "disabled", Type: "remove", Enabled: false,
+			Field: "attributes.path",
+		},
+		PipelineOperator{
+			ID: "drop", Name: "drop", Type: "drop", Enabled: true,
+		},
+	)
+
+	logs := []model.SignozLog{
+		makeTestSignozLog(`200 {"user": "alice"}`, map[string]interface{}{"path": "/health"}),
+		makeTestSignozLog(`500 {bad}`, map[string]interface{}{"path": "/health"}),
+		makeTestSignozLog(`200 {"user": "bob"}`, map[string]interface{}{"path": "/orders"}),
+	}
+	logs[0].ID = "log-0"
+
+	result, apiErr := PreviewPipelineOperators(context.Background(), pipeline, logs)
+	require.Nil(apiErr)
+	require.Equal(3, len(result.Logs))
+
+	preview := result.Logs[0]
+	require.True(preview.Matched)
+	require.Equal("log-0", preview.Input.ID)
+	require.Equal(4, len(preview.Steps))
+	require.Equal("regex", preview.Steps[0].OperatorID)
+	require.Equal([]LogFieldChange{
+		{Field: "attributes.payload", Type: LogFieldAdded, After: `{"user": "alice"}`},
+		{Field: "attributes.status", Type: LogFieldAdded, After: "200"},
+	}, preview.Steps[0].Changes)
+	require.Equal("log-0", preview.Steps[0].Output.ID)
+	require.Equal([]LogFieldChange{
+		{Field: "attributes.user", Type: LogFieldAdded, After: "alice"},
+	}, preview.Steps[1].Changes)
+	require.Equal([]LogFieldChange{
+		{Field: "attributes.payload", Type: LogFieldRemoved, Before: `{"user": "alice"}`},
+	}, preview.Steps[2].Changes)
+	require.Equal("drop", preview.Steps[3].OperatorID)
+	require.True(preview.Steps[3].Dropped)
+	require.Nil(preview.Steps[3].Output)
+
+	// the failing operator doesn't change the log, the error is reported
+	// for the log it failed on
+	require.Equal(0, len(result.Logs[1].Steps[1].Changes))
+	require.Equal(1, len(result.Logs[1].Steps[1].Errors))
+	require.True(strings.HasSuffix(result.Logs[1].Steps[1].Errors[0], "(on_error: send)"))
+	require.Equal(0, len(preview.Steps[1].Errors))
+	// the operators after it still process the log
+	require.Equal([]LogFieldChange{
+		{Field: "attributes.payload", Type: LogFieldRemoved, Before: "{bad}"},
+	}, result.Logs[1].Steps[2].Changes)
+	require.Equal(0, len(result.Logs[1].Steps[2].Errors))
+
+	require.False(result.Logs[2].Matched)
+	require.Equal(0, len(result.Logs[2].Steps))
+
+	require.Equal(1, len(result.Operators))
+	require.Equal("json", result.Operators[0].OperatorID)
+	require.Equal(1, len(result.Operators[0].Errors))
+	require.True(strings.HasSuffix(result.Operators[0].Errors[0], "(on_error: send)"))
+}
+
+func TestPreviewPipelineOperatorsOnErrorDrop(t *testing.T) {
+	require := require.New(t)
+
+	pipeline := makeDropTestPipeline(PipelineOperator{
+		ID:        "json",
+		Type:      "json_parser",
+		ParseFrom: "body",
+		ParseTo:   "attributes",
+		OnError:   "drop",
+	})
+	pipeline.Config = append(pipeline.Config, PipelineOperator{
+		ID: "add", Name: "add", Type: "add", Enabled: true,
+		Field: "attributes.parsed", Value: "true",
+	})
+
+	logs := []model.SignozLog{
+		makeTestSignozLog(`{bad}`, map[string]interface{}{"path": "/health"}),
+		makeTestSignozLog(`{"user": "alice"}`, map[string]interface{}{"path": "/health"}),
+		makeTestSignozLog(`{"user": }`, map[string]interface{}{"path": "/health"}),
+	}
+
+	result, apiErr := PreviewPipelineOperators(context.Background(), pipeline, logs)
+	require.Nil(apiErr)
+
+	for _, idx := range []int{0, 2} {
+		steps := result.Logs[idx].Steps
+		require.Equal(2, len(steps))
+		require.Equal(1, len(steps[0].Errors))
+		require.True(strings.HasSuffix(steps[0].Errors[0], "(on_error: drop)"))
+		// the processing of the log stops at the failing operator
+		require.Equal(0, len(steps[1].Changes))
+		require.Equal(0, len(steps[1].Errors))
+	}
+	require.Equal(0, len(result.Logs[1].Steps[0].Errors))
+	require.Equal([]LogFieldChange{
+		{Field: "attributes.parsed", Type: LogFieldAdded, After: "true"},
+	}, result.Logs[1].Steps[1].Changes)
+
+	require.Equal(1, len(result.Operators))
+	require.Equal(2, len(result.Operators[0].Errors))
+}
+
+func TestPreviewPipelineOperatorsLimit(t *testing.T) {
+	pipeline := makeDropTestPipeline(PipelineOperator{
+		ID: "add", Type: "add", Field: "attributes.added", Value: "true",
+	})
+	for idx := 0; idx < MaxOperatorPreviewOperators; idx++ {
+		pipeline.Config = append(pipeline.Config, PipelineOperator{
+			ID: fmt.Sprintf("add_%d", idx), Name: "add", Type: "add", Enabled: true,
+			Field: fmt.Sprintf("attributes.added_%d", idx), Value: "true",
+		})
+	}
+
+	controller := &LogParsingPipelineController{}
+	_, apiErr := controller.PreviewPipelineOperators(context.Background(), &OperatorPreviewRequest{
+		Pipeline: pipeline,
+		Logs:     []model.SignozLog{makeTestSignozLog("hello", map[string]interface{}{})},
+	})
+	require.NotNil(t, apiErr)
+	require.Equal(t, model.ErrorBadData, apiErr.Type())
+}
+
+func TestOperatorErrorsOfCompiledOperators(t *testing.T) {
+	operators := []PipelineOperator{{ID: "kv"}, {ID: "kv_2"}}
+	collectorLogs := []string{
+		"2024-01-01T00:00:00.000Z\terror\thelper/transformer.go:102\tFailed to process entry\t" +
+			`{"operator_id": "kv_1", "error": "invalid json", "action": "send"}`,
+		"2024-01-01T00:00:00.000Z\terror\thelper/transformer.go:102\tFailed to process entry\t" +
+			`{"operator_id": "kv_2", "error": "invalid json", "action": "drop"}`,
+		"\t/root/go/pkg/mod/helper/transformer.go:102",
+	}
+	require.Equal(t, map[string][]string{
+		"kv":   {"invalid json (on_error: send)"},
+		"kv_2": {"invalid json (on_error: drop)"},
+	}, operatorErrors(collectorLogs, operators))
+}
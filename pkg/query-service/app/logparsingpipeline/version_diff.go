@@ -0,0 +1,158 @@
+package logparsingpipeline
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"go.signoz.io/signoz/pkg/query-service/agentConf"
+	"go.signoz.io/signoz/pkg/query-service/model"
+	"go.signoz.io/signoz/pkg/query-service/queryBuilderToExpr"
+	"go.uber.org/zap"
+)
+
+// The pipelines get stored with new ids each time they are saved, so the
+// pipelines of two versions are matched by their alias.
+
+type PipelineDiffType string
+
+const (
+	PipelineAdded   PipelineDiffType = "added"
+	PipelineChanged PipelineDiffType = "changed"
+	PipelineRemoved PipelineDiffType = "removed"
+)
+
+type PipelinesDiffResponse struct {
+	FromVersion int `json:"fromVersion"`
+	ToVersion   int `json:"toVersion"`
+
+	// the pipelines differing between the versions
+	Pipelines []PipelineDiff `json:"pipelines"`
+}
+
+// PipelineDiff is a pipeline added, changed or removed between two versions.
+// The changes name the fields of the pipeline like name, enabled and filter
+// and the fields of its operators like config.<operator id>.<field>
+type PipelineDiff struct {
+	Alias   string           `json:"alias"`
+	Name    string           `json:"name"`
+	Type    PipelineDiffType `json:"type"`
+	Changes []LogFieldChange `json:"changes"`
+}
+
+func (ic *LogParsingPipelineController) DiffPipelineVersions(
+	ctx context.Context, fromVersion int, toVersion int,
+) (*PipelinesDiffResponse, *model.ApiError) {
+	from, apiErr := ic.getSavedPipelinesByVersion(ctx, fromVersion)
+	if apiErr != nil {
+		return nil, apiErr
+	}
+	to, apiErr := ic.getSavedPipelinesByVersion(ctx, toVersion)
+	if apiErr != nil {
+		return nil, apiErr
+	}
+
+	return &PipelinesDiffResponse{
+		FromVersion: fromVersion,
+		ToVersion:   toVersion,
+		Pipelines:   diffPipelines(from, to),
+	}, nil
+}
+
+// getSavedPipelinesByVersion returns the pipelines saved by the user in a
+// version, the pipelines of installed integrations aren't versioned
+func (ic *LogParsingPipelineController) getSavedPipelinesByVersion(
+	ctx context.Context, version int,
+) ([]Pipeline, *model.ApiError) {
+	_, apiErr := agentConf.GetConfigVersion(ctx, agentConf.ElementTypeLogPipelines, version)
+	if apiErr != nil {
+		return nil, model.WrapApiError(apiErr, fmt.Sprintf("failed to get config version %d", version))
+	}
+
+	pipelines, errors := ic.getPipelinesByVersion(ctx, version)
+	if errors != nil {
+		zap.L().Error("failed to get pipelines for version", zap.Int("version", version), zap.Errors("errors", errors))
+		return nil, model.InternalError(fmt.Errorf("failed to get pipelines for version %d", version))
+	}
+	return pipelines, nil
+}
+
+func diffPipelines(from []Pipeline, to []Pipeline) []PipelineDiff {
+	fromByAlias := map[string]Pipeline{}
+	for _, p := range from {
+		fromByAlias[p.Alias] = p
+	}
+	toAliases := map[string]bool{}
+
+	result := []PipelineDiff{}
+	for _, p := range to {
+		toAliases[p.Alias] = true
+		before, ok := fromByAlias[p.Alias]
+		if !ok {
+			result = append(result, PipelineDiff{
+				Alias:   p.Alias,
+				Name:    p.Name,
+				Type:    PipelineAdded,
+				Changes: diffFields(nil, pipelineFields(p)),
+			})
+			continue
+		}
+		changes := diffFields(pipelineFields(before), pipelineFields(p))
+		if len(changes) > 0 {
+			result = append(result, PipelineDiff{
+				Alias:   p.Alias,
+				Name:    p.Name,
+				Type:    PipelineChanged,
+				Changes: changes,
+			})
+		}
+	}
+
+	for _, p := range from {
+		if !toAliases[p.Alias] {
+			result = append(result, PipelineDiff{
+				Alias:   p.Alias,
+				Name:    p.Name,
+				Type:    PipelineRemoved,
+				Changes: diffFields(pipelineFields(p), map[string]interface{}{}),
+			})
+		}
+	}
+	return result
+}
+
+// pipelineFields returns the values of the fields of the pipeline and its
+// operators by their name in the diffs. The filter is compared as the
+// expression it's compiled to.
+func pipelineFields(p Pipeline) map[string]interface{} {
+	fields := map[string]interface{}{
+		"name":    p.Name,
+		"enabled": p.Enabled,
+		"orderId": p.OrderId,
+	}
+	if p.Description != nil {
+		fields["description"] = *p.Description
+	}
+	if p.Filter != nil {
+		if filterExpr, err := queryBuilderToExpr.Parse(p.Filter); err == nil {
+			fields["filter"] = filterExpr
+		} else if filterJson, err := json.Marshal(p.Filter); err == nil {
+			fields["filter"] = string(filterJson)
+		}
+	}
+
+	for _, op := range p.Config {
+		opJson, err := json.Marshal(op)
+		if err != nil {
+			continue
+		}
+		var opFields map[string]interface{}
+		if err := json.Unmarshal(opJson, &opFields); err != nil {
+			continue
+		}
+		for k, v := range opFields {
+			fields[fmt.Sprintf("config.%s.%s", op.ID, k)] = v
+		}
+	}
+	return fields
+}
@@ -0,0 +1,54 @@
+package logparsingpipeline
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestDiffPipelines(t *testing.T) {
+	require := require.New(t)
+
+	unchanged := makeDropTestPipeline(PipelineOperator{ID: "drop", Type: "drop"})
+	unchanged.Alias = "unchanged"
+
+	changedBefore := makeDropTestPipeline(PipelineOperator{
+		ID: "add", Type: "add", Field: "attributes.env", Value: "dev",
+	})
+	changedBefore.Alias = "changed"
+	changedAfter := changedBefore
+	changedAfter.Id = "new-id"
+	changedAfter.Enabled = false
+	changedAfter.Config = []PipelineOperator{changedBefore.Config[0]}
+	changedAfter.Config[0].Value = "prod"
+
+	removed := makeDropTestPipeline(PipelineOperator{ID: "drop", Type: "drop"})
+	removed.Alias = "removed"
+	added := makeDropTestPipeline(PipelineOperator{ID: "drop", Type: "drop"})
+	added.Alias = "added"
+
+	diff := diffPipelines(
+		[]Pipeline{unchanged, changedBefore, removed},
+		[]Pipeline{unchanged, changedAfter, added},
+	)
+	require.Equal(3, len(diff))
+
+	require.Equal("changed", diff[0].Alias)
+	require.Equal(PipelineChanged, diff[0].Type)
+	require.Equal([]LogFieldChange{
+		{Field: "config.add.value", Type: LogFieldChanged, Before: "dev", After: "prod"},
+		{Field: "enabled", Type: LogFieldChanged, Before: true, After: false},
+	}, diff[0].Changes)
+
+	require.Equal("added", diff[1].Alias)
+	require.Equal(PipelineAdded, diff[1].Type)
+	require.Contains(diff[1].Changes, LogFieldChange{
+		Field: "filter", Type: LogFieldAdded, After: `attributes["path"] == "/health"`,
+	})
+
+	require.Equal("removed", diff[2].Alias)
+	require.Equal(PipelineRemoved, diff[2].Type)
+	require.Contains(diff[2].Changes, LogFieldChange{
+		Field: "config.drop.type", Type: LogFieldRemoved, Before: "drop",
+	})
+}
@@ -30,12 +30,14 @@ func EnrichmentRequired(params *v3.QueryRangeParamsV3) bool {
 		}
 
 		// check filter attribute
-		if query.Filters != nil && len(query.Filters.Items) != 0 {
-			for _, item := range query.Filters.Items {
-				if !isEnriched(item.Key) {
-					return true
-				}
+		enrichmentRequired := false
+		query.Filters.VisitItems(func(item *v3.FilterItem) {
+			if !isEnriched(item.Key) {
+				enrichmentRequired = true
 			}
+		})
+		if enrichmentRequired {
+			return true
 		}
 
 		groupByLookup := map[string]struct{}{}
@@ -103,16 +105,14 @@ func EnrichLogsQuery(query *v3.BuilderQuery, fields map[string]v3.AttributeKey)
 	}
 
 	// enrich filter attribute
-	if query.Filters != nil && len(query.Filters.Items) != 0 {
-		for i := 0; i < len(query.Filters.Items); i++ {
-			query.Filters.Items[i] = jsonFilterEnrich(query.Filters.Items[i])
-			if query.Filters.Items[i].Key.IsJSON {
-				query.Filters.Items[i] = jsonReplaceField(query.Filters.Items[i], fields)
-				continue
-			}
-			query.Filters.Items[i].Key = enrichFieldWithMetadata(query.Filters.Items[i].Key, fields)
+	query.Filters.VisitItems(func(item *v3.FilterItem) {
+		*item = jsonFilterEnrich(*item)
+		if item.Key.IsJSON {
+			*item = jsonReplaceField(*item, fields)
+			return
 		}
-	}
+		item.Key = enrichFieldWithMetadata(item.Key, fields)
+	})
 
 	// enrich groupby
 	for i := 0; i < len(query.GroupBy); i++ {
@@ -159,67 +159,66 @@ func GetExistsNexistsFilter(op v3.FilterOperator, item v3.FilterItem) string {
 	return fmt.Sprintf(logOperators[op], columnType, columnDataType, item.Key.Key)
 }
 
-func buildLogsTimeSeriesFilterQuery(fs *v3.FilterSet, groupBy []v3.AttributeKey, aggregateAttribute v3.AttributeKey) (string, error) {
-	var conditions []string
+func buildLogsFilterItem(item v3.FilterItem) (string, error) {
+	if item.Key.IsJSON {
+		return GetJSONFilter(item)
+	}
 
-	if fs != nil && len(fs.Items) != 0 {
-		for _, item := range fs.Items {
-			if item.Key.IsJSON {
-				filter, err := GetJSONFilter(item)
-				if err != nil {
-					return "", err
-				}
-				conditions = append(conditions, filter)
-				continue
-			}
+	op := v3.FilterOperator(strings.ToLower(strings.TrimSpace(string(item.Operator))))
 
-			op := v3.FilterOperator(strings.ToLower(strings.TrimSpace(string(item.Operator))))
+	var value interface{}
+	var err error
+	if op != v3.FilterOperatorExists && op != v3.FilterOperatorNotExists {
+		value, err = utils.ValidateAndCastValue(item.Value, item.Key.DataType)
+		if err != nil {
+			return "", fmt.Errorf("failed to validate and cast value for %s: %v", item.Key.Key, err)
+		}
+	}
 
-			var value interface{}
-			var err error
-			if op != v3.FilterOperatorExists && op != v3.FilterOperatorNotExists {
-				value, err = utils.ValidateAndCastValue(item.Value, item.Key.DataType)
-				if err != nil {
-					return "", fmt.Errorf("failed to validate and cast value for %s: %v", item.Key.Key, err)
-				}
+	if logsOp, ok := logOperators[op]; ok {
+		switch op {
+		case v3.FilterOperatorExists, v3.FilterOperatorNotExists:
+			return GetExistsNexistsFilter(op, item), nil
+		case v3.FilterOperatorRegex, v3.FilterOperatorNotRegex:
+			columnName := getClickhouseColumnName(item.Key)
+			fmtVal := utils.ClickHouseFormattedValue(value)
+			return fmt.Sprintf(logsOp, columnName, fmtVal), nil
+		case v3.FilterOperatorContains, v3.FilterOperatorNotContains:
+			columnName := getClickhouseColumnName(item.Key)
+			val := utils.QuoteEscapedString(fmt.Sprintf("%v", item.Value))
+			if columnName == BODY {
+				logsOp = strings.Replace(logsOp, "ILIKE", "LIKE", 1) // removing i from ilike and not ilike
+				return fmt.Sprintf("lower(%s) %s lower('%%%s%%')", columnName, logsOp, val), nil
 			}
-
-			if logsOp, ok := logOperators[op]; ok {
-				switch op {
-				case v3.FilterOperatorExists, v3.FilterOperatorNotExists:
-					conditions = append(conditions, GetExistsNexistsFilter(op, item))
-				case v3.FilterOperatorRegex, v3.FilterOperatorNotRegex:
-					columnName := getClickhouseColumnName(item.Key)
-					fmtVal := utils.ClickHouseFormattedValue(value)
-					conditions = append(conditions, fmt.Sprintf(logsOp, columnName, fmtVal))
-				case v3.FilterOperatorContains, v3.FilterOperatorNotContains:
-					columnName := getClickhouseColumnName(item.Key)
-					val := utils.QuoteEscapedString(fmt.Sprintf("%v", item.Value))
-					if columnName == BODY {
-						logsOp = strings.Replace(logsOp, "ILIKE", "LIKE", 1) // removing i from ilike and not ilike
-						conditions = append(conditions, fmt.Sprintf("lower(%s) %s lower('%%%s%%')", columnName, logsOp, val))
-					} else {
-						conditions = append(conditions, fmt.Sprintf("%s %s '%%%s%%'", columnName, logsOp, val))
-					}
-				default:
-					columnName := getClickhouseColumnName(item.Key)
-					fmtVal := utils.ClickHouseFormattedValue(value)
-
-					// for use lower for like and ilike
-					if op == v3.FilterOperatorLike || op == v3.FilterOperatorNotLike {
-						if columnName == BODY {
-							logsOp = strings.Replace(logsOp, "ILIKE", "LIKE", 1) // removing i from ilike and not ilike
-							columnName = fmt.Sprintf("lower(%s)", columnName)
-							fmtVal = fmt.Sprintf("lower(%s)", fmtVal)
-						}
-					}
-					conditions = append(conditions, fmt.Sprintf("%s %s %s", columnName, logsOp, fmtVal))
+			return fmt.Sprintf("%s %s '%%%s%%'", columnName, logsOp, val), nil
+		default:
+			columnName := getClickhouseColumnName(item.Key)
+			fmtVal := utils.ClickHouseFormattedValue(value)
+
+			// for use lower for like and ilike
+			if op == v3.FilterOperatorLike || op == v3.FilterOperatorNotLike {
+				if columnName == BODY {
+					logsOp = strings.Replace(logsOp, "ILIKE", "LIKE", 1) // removing i from ilike and not ilike
+					columnName = fmt.Sprintf("lower(%s)", columnName)
+					fmtVal = fmt.Sprintf("lower(%s)", fmtVal)
 				}
-			} else {
-				return "", fmt.Errorf("unsupported operator: %s", op)
 			}
+			return fmt.Sprintf("%s %s %s", columnName, logsOp, fmtVal), nil
 		}
 	}
+	return "", fmt.Errorf("unsupported operator: %s", op)
+}
+
+func buildLogsTimeSeriesFilterQuery(fs *v3.FilterSet, groupBy []v3.AttributeKey, aggregateAttribute v3.AttributeKey) (string, error) {
+	var conditions []string
+
+	filter, err := fs.BuildCondition(v3.SQLFilterSyntax, buildLogsFilterItem)
+	if err != nil {
+		return "", err
+	}
+	if filter != "" {
+		conditions = append(conditions, filter)
+	}
 
 	// add group by conditions to filter out log lines which doesn't have the key
 	for _, attr := range groupBy {
@@ -256,6 +255,14 @@ func buildLogsQuery(panelType v3.PanelType, start, end, step int64, mq *v3.Build
 	// timerange will be sent in epoch millisecond
 	timeFilter := fmt.Sprintf("(timestamp >= %d AND timestamp <= %d)", utils.GetEpochNanoSecs(start), utils.GetEpochNanoSecs(end))
 
+	if panelType.IsDistribution() {
+		// the filter sub query holds the exists filter of the aggregate attribute
+		bucket := utils.HistogramBucketExpression(mq.Buckets, getClickhouseColumnName(mq.AggregateAttribute))
+		query := fmt.Sprintf("SELECT toStartOfInterval(fromUnixTimestamp64Nano(timestamp), INTERVAL %d SECOND) AS ts, %s as le, toFloat64(count(*)) as value "+
+			"from signoz_logs.distributed_logs where %s%s group by ts, le order by ts", step, bucket, timeFilter, filterSubQuery)
+		return query, nil
+	}
+
 	selectLabels := getSelectLabels(mq.AggregateOperator, mq.GroupBy)
 
 	having := Having(mq.Having)
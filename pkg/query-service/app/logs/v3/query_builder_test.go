@@ -311,6 +311,31 @@ var timeSeriesFilterQueryData = []struct {
 		}},
 		ExpectedFilter: "lower(body) LIKE lower('test') AND lower(body) NOT LIKE lower('test1')",
 	},
+	{
+		Name: "Test nested groups",
+		FilterSet: &v3.FilterSet{Operator: "OR", Items: []v3.FilterItem{}, Groups: []v3.FilterSet{
+			{Operator: "AND", Items: []v3.FilterItem{
+				{Key: v3.AttributeKey{Key: "user_name", DataType: v3.AttributeKeyDataTypeString, Type: v3.AttributeKeyTypeTag}, Value: "john", Operator: "="},
+				{Key: v3.AttributeKey{Key: "k8s_namespace", DataType: v3.AttributeKeyDataTypeString, Type: v3.AttributeKeyTypeResource}, Value: "my_service", Operator: "!="},
+			}},
+			{Operator: "AND", Not: true, Items: []v3.FilterItem{
+				{Key: v3.AttributeKey{Key: "user_name", DataType: v3.AttributeKeyDataTypeString, Type: v3.AttributeKeyTypeTag}, Value: "jane", Operator: "="},
+			}},
+		}},
+		GroupBy: []v3.AttributeKey{
+			{Key: "user_name", DataType: v3.AttributeKeyDataTypeString, Type: v3.AttributeKeyTypeTag},
+		},
+		ExpectedFilter: "((attributes_string_value[indexOf(attributes_string_key, 'user_name')] = 'john' AND resources_string_value[indexOf(resources_string_key, 'k8s_namespace')] != 'my_service') " +
+			"OR NOT (attributes_string_value[indexOf(attributes_string_key, 'user_name')] = 'jane')) AND has(attributes_string_key, 'user_name')",
+	},
+	{
+		Name: "Test or",
+		FilterSet: &v3.FilterSet{Operator: "OR", Items: []v3.FilterItem{
+			{Key: v3.AttributeKey{Key: "body", DataType: v3.AttributeKeyDataTypeString, IsColumn: true}, Operator: "contains", Value: "test"},
+			{Key: v3.AttributeKey{Key: "body", DataType: v3.AttributeKeyDataTypeString, IsColumn: true}, Operator: "contains", Value: "test1"},
+		}},
+		ExpectedFilter: "(lower(body) LIKE lower('%test%') OR lower(body) LIKE lower('%test1%'))",
+	},
 }
 
 func TestBuildLogsTimeSeriesFilterQuery(t *testing.T) {
@@ -1041,6 +1066,46 @@ var testBuildLogsQueryData = []struct {
 		TableName:     "logs",
 		ExpectedQuery: "SELECT now() as ts, attributes_string_value[indexOf(attributes_string_key, 'name')] as `name`, toFloat64(count(*)) as value from signoz_logs.distributed_logs where (timestamp >= 1680066360726210000 AND timestamp <= 1680066458000000000) AND lower(body) like lower('%requestor_list%') AND lower(body) like lower('%index_service%') AND has(JSONExtract(JSON_QUERY(body, '$.\"requestor_list\"[*]'), 'Array(String)'), 'index_service') AND has(attributes_string_key, 'name') group by `name` order by `name` DESC",
 	},
+	{
+		Name:      "Test heatmap of a numeric attribute",
+		PanelType: v3.PanelTypeHeatmap,
+		Start:     1680066360726210000,
+		End:       1680066458000000000,
+		BuilderQuery: &v3.BuilderQuery{
+			QueryName:          "A",
+			StepInterval:       60,
+			AggregateOperator:  v3.AggregateOperatorCount,
+			AggregateAttribute: v3.AttributeKey{Key: "bytes", DataType: v3.AttributeKeyDataTypeFloat64, Type: v3.AttributeKeyTypeTag},
+			Expression:         "A",
+			Buckets:            &v3.HistogramBuckets{Bounds: []float64{100, 1000}},
+		},
+		TableName: "logs",
+		ExpectedQuery: "SELECT toStartOfInterval(fromUnixTimestamp64Nano(timestamp), INTERVAL 60 SECOND) AS ts, " +
+			"toString(arrayFirst(x -> toFloat64(attributes_float64_value[indexOf(attributes_float64_key, 'bytes')]) <= x, [100, 1000, inf])) as le, " +
+			"toFloat64(count(*)) as value from signoz_logs.distributed_logs where (timestamp >= 1680066360726210000 AND timestamp <= 1680066458000000000) " +
+			"AND has(attributes_float64_key, 'bytes') group by ts, le order by ts",
+	},
+	{
+		Name:      "Test heatmap of a numeric attribute with filters",
+		PanelType: v3.PanelTypeHeatmap,
+		Start:     1680066360726210000,
+		End:       1680066458000000000,
+		BuilderQuery: &v3.BuilderQuery{
+			QueryName:          "A",
+			StepInterval:       60,
+			AggregateOperator:  v3.AggregateOperatorCount,
+			AggregateAttribute: v3.AttributeKey{Key: "bytes", DataType: v3.AttributeKeyDataTypeFloat64, Type: v3.AttributeKeyTypeTag},
+			Filters: &v3.FilterSet{Operator: "AND", Items: []v3.FilterItem{
+				{Key: v3.AttributeKey{Key: "method", DataType: v3.AttributeKeyDataTypeString, Type: v3.AttributeKeyTypeTag}, Value: "GET", Operator: "="},
+			}},
+			Expression: "A",
+		},
+		TableName: "logs",
+		ExpectedQuery: "SELECT toStartOfInterval(fromUnixTimestamp64Nano(timestamp), INTERVAL 60 SECOND) AS ts, " +
+			"if(toFloat64(attributes_float64_value[indexOf(attributes_float64_key, 'bytes')]) <= 0, '0', toString(pow(2, ceil(round(log(toFloat64(attributes_float64_value[indexOf(attributes_float64_key, 'bytes')])) / log(2), 9))))) as le, " +
+			"toFloat64(count(*)) as value from signoz_logs.distributed_logs where (timestamp >= 1680066360726210000 AND timestamp <= 1680066458000000000) " +
+			"AND attributes_string_value[indexOf(attributes_string_key, 'method')] = 'GET' AND has(attributes_float64_key, 'bytes') group by ts, le order by ts",
+	},
 }
 
 func TestBuildLogsQuery(t *testing.T) {
@@ -172,43 +172,55 @@ func buildAttributeFilter(item v3.FilterItem) (string, error) {
 	}
 }
 
+func buildLogsFilterItem(item v3.FilterItem) (string, error) {
+	// if the filter is json filter
+	if item.Key.IsJSON {
+		return GetJSONFilter(item)
+	}
+
+	// generate the filter
+	filter, err := buildAttributeFilter(item)
+	if err != nil {
+		return "", err
+	}
+
+	// add extra condition for map contains
+	// by default clickhouse is not able to utilize indexes for keys with all operators.
+	// mapContains forces the use of index.
+	op := v3.FilterOperator(strings.ToLower(string(item.Operator)))
+	if item.Key.IsColumn == false && op != v3.FilterOperatorExists && op != v3.FilterOperatorNotExists {
+		filter = filter + " AND " + getExistsNexistsFilter(v3.FilterOperatorExists, item)
+	}
+	return filter, nil
+}
+
 func buildLogsTimeSeriesFilterQuery(fs *v3.FilterSet, groupBy []v3.AttributeKey, aggregateAttribute v3.AttributeKey) (string, error) {
 	var conditions []string
 
-	if fs == nil || len(fs.Items) == 0 {
+	if fs.IsEmpty() {
 		return "", nil
 	}
 
-	for _, item := range fs.Items {
-		// skip if it's a resource attribute
-		if item.Key.Type == v3.AttributeKeyTypeResource {
-			continue
-		}
-
-		// if the filter is json filter
-		if item.Key.IsJSON {
-			filter, err := GetJSONFilter(item)
-			if err != nil {
-				return "", err
+	// the resource attributes are filtered in the resource sub query when
+	// all of the set has to match, otherwise they are part of the condition
+	if fs.IsConjunction() {
+		attributes := fs.Clone()
+		attributes.Items = []v3.FilterItem{}
+		for _, item := range fs.Items {
+			// skip if it's a resource attribute
+			if item.Key.Type != v3.AttributeKeyTypeResource {
+				attributes.Items = append(attributes.Items, item)
 			}
-			conditions = append(conditions, filter)
-			continue
 		}
+		fs = attributes
+	}
 
-		// generate the filter
-		filter, err := buildAttributeFilter(item)
-		if err != nil {
-			return "", err
-		}
+	filter, err := fs.BuildCondition(v3.SQLFilterSyntax, buildLogsFilterItem)
+	if err != nil {
+		return "", err
+	}
+	if filter != "" {
 		conditions = append(conditions, filter)
-
-		// add extra condition for map contains
-		// by default clickhouse is not able to utilize indexes for keys with all operators.
-		// mapContains forces the use of index.
-		op := v3.FilterOperator(strings.ToLower(string(item.Operator)))
-		if item.Key.IsColumn == false && op != v3.FilterOperatorExists && op != v3.FilterOperatorNotExists {
-			conditions = append(conditions, getExistsNexistsFilter(v3.FilterOperatorExists, item))
-		}
 	}
 
 	// add group by conditions to filter out log lines which doesn't have the key
@@ -381,6 +393,17 @@ func buildLogsQuery(panelType v3.PanelType, start, end, step int64, mq *v3.Build
 		filterSubQuery = filterSubQuery + " AND (resource_fingerprint GLOBAL IN " + resourceSubQuery + ")"
 	}
 
+	if panelType.IsDistribution() {
+		// the attribute exists filter is only added with the other filters
+		if mq.Filters.IsEmpty() && mq.AggregateAttribute.Type != v3.AttributeKeyTypeResource {
+			filterSubQuery += " AND " + getExistsNexistsFilter(v3.FilterOperatorExists, v3.FilterItem{Key: mq.AggregateAttribute})
+		}
+		bucket := utils.HistogramBucketExpression(mq.Buckets, getClickhouseKey(mq.AggregateAttribute))
+		query := fmt.Sprintf("SELECT toStartOfInterval(fromUnixTimestamp64Nano(timestamp), INTERVAL %d SECOND) AS ts, %s as le, toFloat64(count(*)) as value "+
+			"from signoz_logs.%s where %s%s group by ts, le order by ts", step, bucket, DISTRIBUTED_LOGS_V2, timeFilter, filterSubQuery)
+		return query, nil
+	}
+
 	// get the select labels
 	selectLabels := getSelectLabels(mq.AggregateOperator, mq.GroupBy)
 
@@ -433,6 +433,54 @@ func Test_buildLogsTimeSeriesFilterQuery(t *testing.T) {
 			want: "attributes_string['service.name'] = 'test' AND mapContains(attributes_string, 'service.name') " +
 				"AND mapContains(attributes_string, 'user_name') AND `attribute_string_method_exists`=true AND mapContains(attributes_string, 'test')",
 		},
+		{
+			name: "build logs time series filter query with nested groups",
+			args: args{
+				fs: &v3.FilterSet{
+					Operator: "OR",
+					Items: []v3.FilterItem{
+						{
+							Key: v3.AttributeKey{
+								Key:      "service.name",
+								DataType: v3.AttributeKeyDataTypeString,
+								Type:     v3.AttributeKeyTypeResource,
+							},
+							Operator: v3.FilterOperatorEqual,
+							Value:    "test",
+						},
+					},
+					Groups: []v3.FilterSet{
+						{
+							Operator: "AND",
+							Not:      true,
+							Items: []v3.FilterItem{
+								{
+									Key: v3.AttributeKey{
+										Key:      "method",
+										DataType: v3.AttributeKeyDataTypeString,
+										Type:     v3.AttributeKeyTypeTag,
+										IsColumn: true,
+									},
+									Operator: v3.FilterOperatorEqual,
+									Value:    "GET",
+								},
+								{
+									Key: v3.AttributeKey{
+										Key:      "body",
+										DataType: v3.AttributeKeyDataTypeString,
+										IsColumn: true,
+									},
+									Operator: v3.FilterOperatorContains,
+									Value:    "error",
+								},
+							},
+						},
+					},
+				},
+			},
+			want: "(resources_string['service.name'] = 'test' AND mapContains(resources_string, 'service.name') " +
+				"OR NOT (`attribute_string_method` = 'GET' AND lower(body) LIKE lower('%error%')))",
+		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
@@ -784,6 +832,27 @@ func Test_buildLogsQuery(t *testing.T) {
 				"AND simpleJSONExtractString(labels, 'service.name') = 'test' AND labels like '%service.name%test%' AND ( (simpleJSONHas(labels, 'host') AND labels like '%host%') ))) " +
 				"group by `host`,ts order by `host` desc",
 		},
+		{
+			name: "build heatmap query",
+			args: args{
+				panelType: v3.PanelTypeHeatmap,
+				start:     1680066360726210000,
+				end:       1680066458000000000,
+				step:      60,
+				mq: &v3.BuilderQuery{
+					AggregateOperator: v3.AggregateOperatorCount,
+					AggregateAttribute: v3.AttributeKey{
+						Key:      "duration",
+						DataType: v3.AttributeKeyDataTypeFloat64,
+						Type:     v3.AttributeKeyTypeTag,
+					},
+				},
+			},
+			want: "SELECT toStartOfInterval(fromUnixTimestamp64Nano(timestamp), INTERVAL 60 SECOND) AS ts, " +
+				"if(toFloat64(attributes_number['duration']) <= 0, '0', toString(pow(2, ceil(round(log(toFloat64(attributes_number['duration'])) / log(2), 9))))) as le, " +
+				"toFloat64(count(*)) as value from signoz_logs.distributed_logs_v2 where (timestamp >= 1680066360726210000 AND timestamp <= 1680066458000000000) " +
+				"AND (ts_bucket_start >= 1680064560 AND ts_bucket_start <= 1680066458) AND mapContains(attributes_number, 'duration') group by ts, le order by ts",
+		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
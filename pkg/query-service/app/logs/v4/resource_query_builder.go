@@ -140,9 +140,10 @@ func buildResourceIndexFilter(key string, op v3.FilterOperator, value interface{
 
 // buildResourceFiltersFromFilterItems builds a list of clickhouse filter strings for resource labels from a FilterSet.
 // It skips any filter items that are not resource attributes and checks that the operator is supported and the data type is correct.
+// Only the items of a set which has to match as a whole are used, the nested groups are filtered in the main query.
 func buildResourceFiltersFromFilterItems(fs *v3.FilterSet) ([]string, error) {
 	var conditions []string
-	if fs == nil || len(fs.Items) == 0 {
+	if fs == nil || len(fs.Items) == 0 || !fs.IsConjunction() {
 		return nil, nil
 	}
 	for _, item := range fs.Items {
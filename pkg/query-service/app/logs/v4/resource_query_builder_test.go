@@ -332,6 +332,27 @@ func Test_buildResourceFiltersFromFilterItems(t *testing.T) {
 			},
 			wantErr: false,
 		},
+		{
+			name: "ignore filter joined by or",
+			args: args{
+				fs: &v3.FilterSet{
+					Operator: "OR",
+					Items: []v3.FilterItem{
+						{
+							Key: v3.AttributeKey{
+								Key:      "service.name",
+								DataType: v3.AttributeKeyDataTypeString,
+								Type:     v3.AttributeKeyTypeResource,
+							},
+							Operator: v3.FilterOperatorEqual,
+							Value:    "test",
+						},
+					},
+				},
+			},
+			want:    nil,
+			wantErr: false,
+		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
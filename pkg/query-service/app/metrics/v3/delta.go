@@ -23,7 +23,7 @@ func buildDeltaMetricQuery(start, end, step int64, mq *v3.BuilderQuery) (string,
 		}
 
 		if !temporalityFound {
-			mq.Filters.Items = append(mq.Filters.Items, v3.FilterItem{
+			mq.Filters.AndItems(v3.FilterItem{
 				Key:      v3.AttributeKey{Key: "__temporality__"},
 				Operator: v3.FilterOperatorEqual,
 				Value:    "Delta",
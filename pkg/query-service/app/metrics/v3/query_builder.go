@@ -333,6 +333,10 @@ func reduceQuery(query string, reduceTo v3.ReduceToOperator, aggregateOperator v
 // step is in seconds
 func PrepareMetricQuery(start, end int64, queryType v3.QueryType, panelType v3.PanelType, mq *v3.BuilderQuery, options Options) (string, error) {
 
+	if panelType.IsDistribution() {
+		return "", fmt.Errorf("%s panel is only supported for the v4 metrics query builder", panelType)
+	}
+
 	start, end = common.AdjustedMetricTimeRange(start, end, mq.StepInterval, *mq)
 
 	// if the aggregate operator is a histogram quantile, and user has not forgotten
@@ -38,6 +38,52 @@ func which(start, end int64) (int64, int64, string) {
 	return start, end, tableName
 }
 
+// buildFilterItem builds the condition of the filter item on the labels of the time series
+func buildFilterItem(item v3.FilterItem) (string, error) {
+	toFormat := item.Value
+	op := v3.FilterOperator(strings.ToLower(strings.TrimSpace(string(item.Operator))))
+	if op == v3.FilterOperatorContains || op == v3.FilterOperatorNotContains {
+		toFormat = fmt.Sprintf("%%%s%%", toFormat)
+	}
+	fmtVal := utils.ClickHouseFormattedValue(toFormat)
+	switch op {
+	case v3.FilterOperatorEqual:
+		return fmt.Sprintf("JSONExtractString(labels, '%s') = %s", item.Key.Key, fmtVal), nil
+	case v3.FilterOperatorNotEqual:
+		return fmt.Sprintf("JSONExtractString(labels, '%s') != %s", item.Key.Key, fmtVal), nil
+	case v3.FilterOperatorIn:
+		return fmt.Sprintf("JSONExtractString(labels, '%s') IN %s", item.Key.Key, fmtVal), nil
+	case v3.FilterOperatorNotIn:
+		return fmt.Sprintf("JSONExtractString(labels, '%s') NOT IN %s", item.Key.Key, fmtVal), nil
+	case v3.FilterOperatorLike:
+		return fmt.Sprintf("like(JSONExtractString(labels, '%s'), %s)", item.Key.Key, fmtVal), nil
+	case v3.FilterOperatorNotLike:
+		return fmt.Sprintf("notLike(JSONExtractString(labels, '%s'), %s)", item.Key.Key, fmtVal), nil
+	case v3.FilterOperatorRegex:
+		return fmt.Sprintf("match(JSONExtractString(labels, '%s'), %s)", item.Key.Key, fmtVal), nil
+	case v3.FilterOperatorNotRegex:
+		return fmt.Sprintf("not match(JSONExtractString(labels, '%s'), %s)", item.Key.Key, fmtVal), nil
+	case v3.FilterOperatorGreaterThan:
+		return fmt.Sprintf("JSONExtractString(labels, '%s') > %s", item.Key.Key, fmtVal), nil
+	case v3.FilterOperatorGreaterThanOrEq:
+		return fmt.Sprintf("JSONExtractString(labels, '%s') >= %s", item.Key.Key, fmtVal), nil
+	case v3.FilterOperatorLessThan:
+		return fmt.Sprintf("JSONExtractString(labels, '%s') < %s", item.Key.Key, fmtVal), nil
+	case v3.FilterOperatorLessThanOrEq:
+		return fmt.Sprintf("JSONExtractString(labels, '%s') <= %s", item.Key.Key, fmtVal), nil
+	case v3.FilterOperatorContains:
+		return fmt.Sprintf("like(JSONExtractString(labels, '%s'), %s)", item.Key.Key, fmtVal), nil
+	case v3.FilterOperatorNotContains:
+		return fmt.Sprintf("notLike(JSONExtractString(labels, '%s'), %s)", item.Key.Key, fmtVal), nil
+	case v3.FilterOperatorExists:
+		return fmt.Sprintf("has(JSONExtractKeys(labels), '%s')", item.Key.Key), nil
+	case v3.FilterOperatorNotExists:
+		return fmt.Sprintf("not has(JSONExtractKeys(labels), '%s')", item.Key.Key), nil
+	default:
+		return "", fmt.Errorf("unsupported filter operator")
+	}
+}
+
 // PrepareTimeseriesFilterQuery builds the sub-query to be used for filtering timeseries based on the search criteria
 func PrepareTimeseriesFilterQuery(start, end int64, mq *v3.BuilderQuery) (string, error) {
 	var conditions []string
@@ -51,51 +97,12 @@ func PrepareTimeseriesFilterQuery(start, end int64, mq *v3.BuilderQuery) (string
 
 	conditions = append(conditions, fmt.Sprintf("unix_milli >= %d AND unix_milli < %d", start, end))
 
-	if fs != nil && len(fs.Items) != 0 {
-		for _, item := range fs.Items {
-			toFormat := item.Value
-			op := v3.FilterOperator(strings.ToLower(strings.TrimSpace(string(item.Operator))))
-			if op == v3.FilterOperatorContains || op == v3.FilterOperatorNotContains {
-				toFormat = fmt.Sprintf("%%%s%%", toFormat)
-			}
-			fmtVal := utils.ClickHouseFormattedValue(toFormat)
-			switch op {
-			case v3.FilterOperatorEqual:
-				conditions = append(conditions, fmt.Sprintf("JSONExtractString(labels, '%s') = %s", item.Key.Key, fmtVal))
-			case v3.FilterOperatorNotEqual:
-				conditions = append(conditions, fmt.Sprintf("JSONExtractString(labels, '%s') != %s", item.Key.Key, fmtVal))
-			case v3.FilterOperatorIn:
-				conditions = append(conditions, fmt.Sprintf("JSONExtractString(labels, '%s') IN %s", item.Key.Key, fmtVal))
-			case v3.FilterOperatorNotIn:
-				conditions = append(conditions, fmt.Sprintf("JSONExtractString(labels, '%s') NOT IN %s", item.Key.Key, fmtVal))
-			case v3.FilterOperatorLike:
-				conditions = append(conditions, fmt.Sprintf("like(JSONExtractString(labels, '%s'), %s)", item.Key.Key, fmtVal))
-			case v3.FilterOperatorNotLike:
-				conditions = append(conditions, fmt.Sprintf("notLike(JSONExtractString(labels, '%s'), %s)", item.Key.Key, fmtVal))
-			case v3.FilterOperatorRegex:
-				conditions = append(conditions, fmt.Sprintf("match(JSONExtractString(labels, '%s'), %s)", item.Key.Key, fmtVal))
-			case v3.FilterOperatorNotRegex:
-				conditions = append(conditions, fmt.Sprintf("not match(JSONExtractString(labels, '%s'), %s)", item.Key.Key, fmtVal))
-			case v3.FilterOperatorGreaterThan:
-				conditions = append(conditions, fmt.Sprintf("JSONExtractString(labels, '%s') > %s", item.Key.Key, fmtVal))
-			case v3.FilterOperatorGreaterThanOrEq:
-				conditions = append(conditions, fmt.Sprintf("JSONExtractString(labels, '%s') >= %s", item.Key.Key, fmtVal))
-			case v3.FilterOperatorLessThan:
-				conditions = append(conditions, fmt.Sprintf("JSONExtractString(labels, '%s') < %s", item.Key.Key, fmtVal))
-			case v3.FilterOperatorLessThanOrEq:
-				conditions = append(conditions, fmt.Sprintf("JSONExtractString(labels, '%s') <= %s", item.Key.Key, fmtVal))
-			case v3.FilterOperatorContains:
-				conditions = append(conditions, fmt.Sprintf("like(JSONExtractString(labels, '%s'), %s)", item.Key.Key, fmtVal))
-			case v3.FilterOperatorNotContains:
-				conditions = append(conditions, fmt.Sprintf("notLike(JSONExtractString(labels, '%s'), %s)", item.Key.Key, fmtVal))
-			case v3.FilterOperatorExists:
-				conditions = append(conditions, fmt.Sprintf("has(JSONExtractKeys(labels), '%s')", item.Key.Key))
-			case v3.FilterOperatorNotExists:
-				conditions = append(conditions, fmt.Sprintf("not has(JSONExtractKeys(labels), '%s')", item.Key.Key))
-			default:
-				return "", fmt.Errorf("unsupported filter operator")
-			}
-		}
+	filter, err := fs.BuildCondition(v3.SQLFilterSyntax, buildFilterItem)
+	if err != nil {
+		return "", err
+	}
+	if filter != "" {
+		conditions = append(conditions, filter)
 	}
 	whereClause := strings.Join(conditions, " AND ")
 
@@ -131,51 +138,12 @@ func PrepareTimeseriesFilterQueryV3(start, end int64, mq *v3.BuilderQuery) (stri
 
 	conditions = append(conditions, fmt.Sprintf("unix_milli >= %d AND unix_milli < %d", start, end))
 
-	if fs != nil && len(fs.Items) != 0 {
-		for _, item := range fs.Items {
-			toFormat := item.Value
-			op := v3.FilterOperator(strings.ToLower(strings.TrimSpace(string(item.Operator))))
-			if op == v3.FilterOperatorContains || op == v3.FilterOperatorNotContains {
-				toFormat = fmt.Sprintf("%%%s%%", toFormat)
-			}
-			fmtVal := utils.ClickHouseFormattedValue(toFormat)
-			switch op {
-			case v3.FilterOperatorEqual:
-				conditions = append(conditions, fmt.Sprintf("JSONExtractString(labels, '%s') = %s", item.Key.Key, fmtVal))
-			case v3.FilterOperatorNotEqual:
-				conditions = append(conditions, fmt.Sprintf("JSONExtractString(labels, '%s') != %s", item.Key.Key, fmtVal))
-			case v3.FilterOperatorIn:
-				conditions = append(conditions, fmt.Sprintf("JSONExtractString(labels, '%s') IN %s", item.Key.Key, fmtVal))
-			case v3.FilterOperatorNotIn:
-				conditions = append(conditions, fmt.Sprintf("JSONExtractString(labels, '%s') NOT IN %s", item.Key.Key, fmtVal))
-			case v3.FilterOperatorLike:
-				conditions = append(conditions, fmt.Sprintf("like(JSONExtractString(labels, '%s'), %s)", item.Key.Key, fmtVal))
-			case v3.FilterOperatorNotLike:
-				conditions = append(conditions, fmt.Sprintf("notLike(JSONExtractString(labels, '%s'), %s)", item.Key.Key, fmtVal))
-			case v3.FilterOperatorRegex:
-				conditions = append(conditions, fmt.Sprintf("match(JSONExtractString(labels, '%s'), %s)", item.Key.Key, fmtVal))
-			case v3.FilterOperatorNotRegex:
-				conditions = append(conditions, fmt.Sprintf("not match(JSONExtractString(labels, '%s'), %s)", item.Key.Key, fmtVal))
-			case v3.FilterOperatorGreaterThan:
-				conditions = append(conditions, fmt.Sprintf("JSONExtractString(labels, '%s') > %s", item.Key.Key, fmtVal))
-			case v3.FilterOperatorGreaterThanOrEq:
-				conditions = append(conditions, fmt.Sprintf("JSONExtractString(labels, '%s') >= %s", item.Key.Key, fmtVal))
-			case v3.FilterOperatorLessThan:
-				conditions = append(conditions, fmt.Sprintf("JSONExtractString(labels, '%s') < %s", item.Key.Key, fmtVal))
-			case v3.FilterOperatorLessThanOrEq:
-				conditions = append(conditions, fmt.Sprintf("JSONExtractString(labels, '%s') <= %s", item.Key.Key, fmtVal))
-			case v3.FilterOperatorContains:
-				conditions = append(conditions, fmt.Sprintf("like(JSONExtractString(labels, '%s'), %s)", item.Key.Key, fmtVal))
-			case v3.FilterOperatorNotContains:
-				conditions = append(conditions, fmt.Sprintf("notLike(JSONExtractString(labels, '%s'), %s)", item.Key.Key, fmtVal))
-			case v3.FilterOperatorExists:
-				conditions = append(conditions, fmt.Sprintf("has(JSONExtractKeys(labels), '%s')", item.Key.Key))
-			case v3.FilterOperatorNotExists:
-				conditions = append(conditions, fmt.Sprintf("not has(JSONExtractKeys(labels), '%s')", item.Key.Key))
-			default:
-				return "", fmt.Errorf("unsupported filter operator")
-			}
-		}
+	filter, err := fs.BuildCondition(v3.SQLFilterSyntax, buildFilterItem)
+	if err != nil {
+		return "", err
+	}
+	if filter != "" {
+		conditions = append(conditions, filter)
 	}
 	whereClause := strings.Join(conditions, " AND ")
 
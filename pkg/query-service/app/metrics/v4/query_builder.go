@@ -21,6 +21,20 @@ func PrepareMetricQuery(start, end int64, queryType v3.QueryType, panelType v3.P
 
 	start, end = common.AdjustedMetricTimeRange(start, end, mq.StepInterval, *mq)
 
+	// the distribution panels show the number of observations in each bucket
+	// of the histogram in the step, the query of the caller is left as is
+	if panelType.IsDistribution() {
+		distribution := *mq
+		distribution.TimeAggregation = v3.TimeAggregationIncrease
+		distribution.SpaceAggregation = v3.SpaceAggregationSum
+		distribution.GroupBy = []v3.AttributeKey{{
+			Key:      "le",
+			Type:     v3.AttributeKeyTypeTag,
+			DataType: v3.AttributeKeyDataTypeString,
+		}}
+		mq = &distribution
+	}
+
 	var quantile float64
 
 	percentileOperator := mq.SpaceAggregation
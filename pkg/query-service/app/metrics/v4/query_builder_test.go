@@ -532,3 +532,34 @@ func TestPrepareMetricQueryGauge(t *testing.T) {
 		})
 	}
 }
+
+func TestPrepareMetricQueryHeatmap(t *testing.T) {
+	builderQuery := &v3.BuilderQuery{
+		QueryName:    "A",
+		StepInterval: 60,
+		DataSource:   v3.DataSourceMetrics,
+		AggregateAttribute: v3.AttributeKey{
+			Key:  "signoz_latency_bucket",
+			Type: v3.AttributeKeyType(v3.MetricTypeHistogram),
+		},
+		Temporality: v3.Cumulative,
+		GroupBy: []v3.AttributeKey{{
+			Key:      "service_name",
+			DataType: v3.AttributeKeyDataTypeString,
+			Type:     v3.AttributeKeyTypeTag,
+		}},
+		Expression:       "A",
+		TimeAggregation:  v3.TimeAggregationRate,
+		SpaceAggregation: v3.SpaceAggregationPercentile99,
+	}
+
+	query, err := PrepareMetricQuery(1650991982000, 1651078382000, v3.QueryTypeBuilder, v3.PanelTypeHeatmap, builderQuery, metricsV3.Options{})
+	assert.Nil(t, err)
+	// the buckets are counted with the increase of each of them over the step
+	assert.Contains(t, query, "SELECT le, ts, sum(per_series_value) as value")
+	assert.NotContains(t, query, "service_name")
+	// the query of the caller is not changed
+	assert.Equal(t, v3.TimeAggregationRate, builderQuery.TimeAggregation)
+	assert.Equal(t, v3.SpaceAggregationPercentile99, builderQuery.SpaceAggregation)
+	assert.Equal(t, []v3.AttributeKey{{Key: "service_name", DataType: v3.AttributeKeyDataTypeString, Type: v3.AttributeKeyTypeTag}}, builderQuery.GroupBy)
+}
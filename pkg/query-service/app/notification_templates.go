@@ -0,0 +1,95 @@
+package app
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"github.com/gorilla/mux"
+	"go.signoz.io/signoz/pkg/query-service/model"
+	"go.signoz.io/signoz/pkg/query-service/rules"
+)
+
+func (aH *APIHandler) listNotificationTemplates(w http.ResponseWriter, r *http.Request) {
+	templates, apiErr := aH.ruleManager.ListNotificationTemplates(r.Context())
+	if apiErr != nil {
+		RespondError(w, apiErr, nil)
+		return
+	}
+	aH.Respond(w, templates)
+}
+
+func (aH *APIHandler) getNotificationTemplate(w http.ResponseWriter, r *http.Request) {
+	tmpl, apiErr := aH.ruleManager.GetNotificationTemplate(r.Context(), mux.Vars(r)["id"])
+	if apiErr != nil {
+		RespondError(w, apiErr, nil)
+		return
+	}
+	aH.Respond(w, tmpl)
+}
+
+func (aH *APIHandler) createNotificationTemplate(w http.ResponseWriter, r *http.Request) {
+	var tmpl rules.NotificationTemplate
+	if err := json.NewDecoder(r.Body).Decode(&tmpl); err != nil {
+		RespondError(w, &model.ApiError{Typ: model.ErrorBadData, Err: err}, nil)
+		return
+	}
+
+	created, apiErr := aH.ruleManager.CreateNotificationTemplate(r.Context(), &tmpl)
+	if apiErr != nil {
+		RespondError(w, apiErr, nil)
+		return
+	}
+	aH.Respond(w, created)
+}
+
+func (aH *APIHandler) editNotificationTemplate(w http.ResponseWriter, r *http.Request) {
+	var tmpl rules.NotificationTemplate
+	if err := json.NewDecoder(r.Body).Decode(&tmpl); err != nil {
+		RespondError(w, &model.ApiError{Typ: model.ErrorBadData, Err: err}, nil)
+		return
+	}
+
+	edited, apiErr := aH.ruleManager.EditNotificationTemplate(r.Context(), mux.Vars(r)["id"], &tmpl)
+	if apiErr != nil {
+		RespondError(w, apiErr, nil)
+		return
+	}
+	aH.Respond(w, edited)
+}
+
+func (aH *APIHandler) deleteNotificationTemplate(w http.ResponseWriter, r *http.Request) {
+	if apiErr := aH.ruleManager.DeleteNotificationTemplate(r.Context(), mux.Vars(r)["id"]); apiErr != nil {
+		RespondError(w, apiErr, nil)
+		return
+	}
+	aH.Respond(w, "notification template successfully deleted")
+}
+
+// previewNotificationTemplateRequest carries the template to preview and
+// the rule whose test alert it is rendered against
+type previewNotificationTemplateRequest struct {
+	Template rules.NotificationTemplate `json:"template"`
+	Rule     json.RawMessage            `json:"rule"`
+}
+
+// previewNotificationTemplate renders the template against a sample alert
+// of the rule, the rule is evaluated as it is for a test notification
+func (aH *APIHandler) previewNotificationTemplate(w http.ResponseWriter, r *http.Request) {
+	var req previewNotificationTemplateRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		RespondError(w, &model.ApiError{Typ: model.ErrorBadData, Err: err}, nil)
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), 1*time.Minute)
+	defer cancel()
+
+	preview, apiErr := aH.ruleManager.PreviewNotificationTemplate(ctx, &req.Template, string(req.Rule))
+	if apiErr != nil {
+		RespondError(w, apiErr, nil)
+		return
+	}
+	aH.Respond(w, preview)
+}
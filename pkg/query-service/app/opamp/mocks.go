@@ -67,7 +67,9 @@ func (ta *MockAgentConfigProvider) HasRecommendations() bool {
 }
 
 // AgentConfigProvider interface
-func (ta *MockAgentConfigProvider) RecommendAgentConfig(baseConfYaml []byte) (
+func (ta *MockAgentConfigProvider) RecommendAgentConfig(
+	agentId string, agentLabels map[string]string, baseConfYaml []byte,
+) (
 	[]byte, string, error,
 ) {
 	if len(ta.ZPagesEndpoint) < 1 {
@@ -8,6 +8,7 @@ import (
 	"time"
 
 	"go.uber.org/zap"
+	"golang.org/x/exp/slices"
 	"google.golang.org/protobuf/proto"
 
 	"github.com/open-telemetry/opamp-go/protobufs"
@@ -96,6 +97,28 @@ func ExtractLbFlag(agentDescr *protobufs.AgentDescription) bool {
 	return false
 }
 
+// Labels returns the string valued attributes in the description of the agent,
+// they are matched against the selectors of staged config rollouts.
+func (agent *Agent) Labels() map[string]string {
+	labels := map[string]string{}
+	if agent.Status == nil || agent.Status.AgentDescription == nil {
+		return labels
+	}
+	agentDescr := agent.Status.AgentDescription
+	attributes := append(
+		slices.Clone(agentDescr.IdentifyingAttributes), agentDescr.NonIdentifyingAttributes...,
+	)
+	for _, kv := range attributes {
+		if kv.Value == nil {
+			continue
+		}
+		if anyvalue, ok := kv.Value.Value.(*protobufs.AnyValue_StringValue); ok {
+			labels[kv.Key] = anyvalue.StringValue
+		}
+	}
+	return labels
+}
+
 func (agent *Agent) updateAgentDescription(newStatus *protobufs.AgentToServer) (agentDescrChanged bool) {
 	prevStatus := agent.Status
 
@@ -274,7 +297,9 @@ func (agent *Agent) processStatusUpdate(
 }
 
 func (agent *Agent) updateRemoteConfig(configProvider AgentConfigProvider) bool {
-	recommendedConfig, confId, err := configProvider.RecommendAgentConfig([]byte(agent.EffectiveConfig))
+	recommendedConfig, confId, err := configProvider.RecommendAgentConfig(
+		agent.ID, agent.Labels(), []byte(agent.EffectiveConfig),
+	)
 	if err != nil {
 		zap.L().Error("could not generate config recommendation for agent", zap.String("agentID", agent.ID), zap.Error(err))
 		return false
@@ -4,7 +4,9 @@ package model
 type AgentConfigProvider interface {
 	// Generate recommended config for an agent based on its `currentConfYaml`
 	// and current state of user facing settings for agent based features.
-	RecommendAgentConfig(currentConfYaml []byte) (
+	// The id and labels of the agent select the config versions deployed to it
+	// during staged rollouts.
+	RecommendAgentConfig(agentId string, agentLabels map[string]string, currentConfYaml []byte) (
 		recommendedConfYaml []byte,
 		// Opaque id of the recommended config, used for reporting deployment status updates
 		configId string,
@@ -1079,13 +1079,16 @@ func ParseQueryRangeParams(r *http.Request) (*v3.QueryRangeParamsV3, *model.ApiE
 					params[k] = v
 				}
 
-				can, _, err := expression.CanJoin(params)
-				if err != nil {
-					return nil, &model.ApiError{Typ: model.ErrorBadData, Err: err}
-				}
+				// the explicit vector matching decides how the series are joined
+				if query.VectorMatching == nil {
+					can, _, err := expression.CanJoin(params)
+					if err != nil {
+						return nil, &model.ApiError{Typ: model.ErrorBadData, Err: err}
+					}
 
-				if !can {
-					return nil, &model.ApiError{Typ: model.ErrorBadData, Err: fmt.Errorf("cannot join the given group keys")}
+					if !can {
+						return nil, &model.ApiError{Typ: model.ErrorBadData, Err: fmt.Errorf("cannot join the given group keys, use on or ignoring to match the series explicitly")}
+					}
 				}
 			}
 
@@ -1114,12 +1117,12 @@ func ParseQueryRangeParams(r *http.Request) (*v3.QueryRangeParamsV3, *model.ApiE
 			}
 			query.ShiftBy = timeShiftBy
 
-			if query.Filters == nil || len(query.Filters.Items) == 0 {
+			if query.Filters.IsEmpty() {
 				continue
 			}
 
-			for idx := range query.Filters.Items {
-				item := &query.Filters.Items[idx]
+			var apiErr *model.ApiError
+			query.Filters.VisitItems(func(item *v3.FilterItem) {
 				value := item.Value
 				if value != nil {
 					switch x := value.(type) {
@@ -1144,9 +1147,14 @@ func ParseQueryRangeParams(r *http.Request) (*v3.QueryRangeParamsV3, *model.ApiE
 				if v3.FilterOperator(strings.ToLower((string(item.Operator)))) != v3.FilterOperatorIn && v3.FilterOperator(strings.ToLower((string(item.Operator)))) != v3.FilterOperatorNotIn {
 					// the value type should not be multiple values
 					if _, ok := item.Value.([]interface{}); ok {
-						return nil, &model.ApiError{Typ: model.ErrorBadData, Err: fmt.Errorf("multiple values %s are not allowed for operator `%s` for key `%s`", item.Value, item.Operator, item.Key.Key)}
+						if apiErr == nil {
+							apiErr = &model.ApiError{Typ: model.ErrorBadData, Err: fmt.Errorf("multiple values %s are not allowed for operator `%s` for key `%s`", item.Value, item.Operator, item.Key.Key)}
+						}
 					}
 				}
+			})
+			if apiErr != nil {
+				return nil, apiErr
 			}
 		}
 	}
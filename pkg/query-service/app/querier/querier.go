@@ -14,6 +14,7 @@ import (
 	"go.signoz.io/signoz/pkg/query-service/common"
 	chErrors "go.signoz.io/signoz/pkg/query-service/errors"
 	"go.signoz.io/signoz/pkg/query-service/querycache"
+	"go.signoz.io/signoz/pkg/query-service/querylimits"
 	"go.signoz.io/signoz/pkg/query-service/utils"
 
 	"go.signoz.io/signoz/pkg/query-service/cache"
@@ -43,9 +44,14 @@ type querier struct {
 	builder       *queryBuilder.QueryBuilder
 	featureLookUp interfaces.FeatureLookup
 
+	limits *querylimits.Guard
+
 	// used for testing
 	// TODO(srikanthccv): remove this once we have a proper mock
-	testingMode     bool
+	testingMode bool
+	// testingMtx guards the queries and the time ranges, the queries are
+	// run in parallel
+	testingMtx      sync.Mutex
 	queriesExecuted []string
 	// tuple of start and end time in milliseconds
 	timeRanges     [][]int
@@ -61,6 +67,8 @@ type QuerierOptions struct {
 	KeyGenerator  cache.KeyGenerator
 	FluxInterval  time.Duration
 	FeatureLookup interfaces.FeatureLookup
+	// QueryLimits are the query limits of the roles, nil disables them
+	QueryLimits *querylimits.Config
 
 	// used for testing
 	TestingMode      bool
@@ -90,6 +98,7 @@ func NewQuerier(opts QuerierOptions) interfaces.Querier {
 			BuildMetricQuery: metricsV3.PrepareMetricQuery,
 		}, opts.FeatureLookup),
 		featureLookUp: opts.FeatureLookup,
+		limits:        querylimits.NewGuard(opts.QueryLimits, opts.Reader),
 
 		testingMode:      opts.TestingMode,
 		returnedSeries:   opts.ReturnedSeries,
@@ -99,7 +108,12 @@ func NewQuerier(opts QuerierOptions) interfaces.Querier {
 }
 
 func (q *querier) execClickHouseQuery(ctx context.Context, query string) ([]*v3.Series, error) {
+	if err := q.limits.CheckRowsScanned(ctx, query); err != nil {
+		return nil, err
+	}
+	q.testingMtx.Lock()
 	q.queriesExecuted = append(q.queriesExecuted, query)
+	q.testingMtx.Unlock()
 	if q.testingMode && q.reader == nil {
 		return q.returnedSeries, q.returnedErr
 	}
@@ -122,15 +136,36 @@ func (q *querier) execClickHouseQuery(ctx context.Context, query string) ([]*v3.
 	if pointsWithNegativeTimestamps > 0 {
 		zap.L().Error("found points with negative timestamps for query", zap.String("query", query))
 	}
-	return result, err
+	if err != nil {
+		return result, q.limits.ExecutionError(ctx, err)
+	}
+	if err := q.limits.CheckSeries(ctx, len(result)); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// execListQuery checks the list query against the query limits and runs it
+func (q *querier) execListQuery(ctx context.Context, query string) ([]*v3.Row, error) {
+	if err := q.limits.CheckRowsScanned(ctx, query); err != nil {
+		return nil, err
+	}
+	rowList, err := q.reader.GetListResultV3(ctx, query)
+	if err != nil {
+		return nil, q.limits.ExecutionError(ctx, err)
+	}
+	return rowList, nil
 }
 
 func (q *querier) execPromQuery(ctx context.Context, params *model.QueryRangeParams) ([]*v3.Series, error) {
+	q.testingMtx.Lock()
 	q.queriesExecuted = append(q.queriesExecuted, params.Query)
 	if q.testingMode && q.reader == nil {
 		q.timeRanges = append(q.timeRanges, []int{int(params.Start.UnixMilli()), int(params.End.UnixMilli())})
+		q.testingMtx.Unlock()
 		return q.returnedSeries, q.returnedErr
 	}
+	q.testingMtx.Unlock()
 	promResult, _, err := q.reader.GetQueryRangeResult(ctx, params)
 	if err != nil {
 		return nil, err
@@ -332,7 +367,7 @@ func (q *querier) runLogsListQuery(ctx context.Context, params *v3.QueryRangePar
 
 		// this will to run only once
 		for name, query := range queries {
-			rowList, err := q.reader.GetListResultV3(ctx, query)
+			rowList, err := q.execListQuery(ctx, query)
 			if err != nil {
 				errs := []error{err}
 				errQuriesByName := map[string]error{
@@ -345,7 +380,7 @@ func (q *querier) runLogsListQuery(ctx context.Context, params *v3.QueryRangePar
 
 		// append a filter to the params
 		if len(data) > 0 {
-			params.CompositeQuery.BuilderQueries[qName].Filters.Items = append(params.CompositeQuery.BuilderQueries[qName].Filters.Items, v3.FilterItem{
+			params.CompositeQuery.BuilderQueries[qName].Filters.AndItems(v3.FilterItem{
 				Key: v3.AttributeKey{
 					Key:      "id",
 					IsColumn: true,
@@ -394,7 +429,7 @@ func (q *querier) runBuilderListQueries(ctx context.Context, params *v3.QueryRan
 		wg.Add(1)
 		go func(name, query string) {
 			defer wg.Done()
-			rowList, err := q.reader.GetListResultV3(ctx, query)
+			rowList, err := q.execListQuery(ctx, query)
 
 			if err != nil {
 				ch <- channelResult{Err: err, Name: name, Query: query}
@@ -432,6 +467,13 @@ func (q *querier) QueryRange(ctx context.Context, params *v3.QueryRangeParamsV3)
 	var results []*v3.Result
 	var err error
 	var errQueriesByName map[string]error
+
+	if err := q.limits.CheckTimeRange(ctx, params.Start, params.End); err != nil {
+		return nil, nil, err
+	}
+	ctx, cancel := q.limits.WithExecutionTimeout(ctx)
+	defer cancel()
+
 	if params.CompositeQuery != nil {
 		switch params.CompositeQuery.QueryType {
 		case v3.QueryTypeBuilder:
@@ -440,10 +482,10 @@ func (q *querier) QueryRange(ctx context.Context, params *v3.QueryRangeParamsV3)
 			} else {
 				results, errQueriesByName, err = q.runBuilderQueries(ctx, params)
 			}
-			// in builder query, the only errors we expose are the ones that exceed the resource limits
+			// in builder query, the only errors we expose are the ones that exceed the resource or query limits
 			// everything else is internal error as they are not actionable by the user
 			for name, err := range errQueriesByName {
-				if !chErrors.IsResourceLimitError(err) {
+				if !chErrors.IsResourceLimitError(err) && !chErrors.IsQueryLimitError(err) {
 					delete(errQueriesByName, name)
 				}
 			}
@@ -469,9 +511,13 @@ func (q *querier) QueryRange(ctx context.Context, params *v3.QueryRangeParamsV3)
 }
 
 func (q *querier) QueriesExecuted() []string {
+	q.testingMtx.Lock()
+	defer q.testingMtx.Unlock()
 	return q.queriesExecuted
 }
 
 func (q *querier) TimeRanges() [][]int {
+	q.testingMtx.Lock()
+	defer q.testingMtx.Unlock()
 	return q.timeRanges
 }
@@ -12,8 +12,12 @@ import (
 	"go.signoz.io/signoz/pkg/query-service/app/queryBuilder"
 	tracesV3 "go.signoz.io/signoz/pkg/query-service/app/traces/v3"
 	"go.signoz.io/signoz/pkg/query-service/cache/inmemory"
+	"go.signoz.io/signoz/pkg/query-service/constants"
+	chErrors "go.signoz.io/signoz/pkg/query-service/errors"
+	"go.signoz.io/signoz/pkg/query-service/model"
 	v3 "go.signoz.io/signoz/pkg/query-service/model/v3"
 	"go.signoz.io/signoz/pkg/query-service/querycache"
+	"go.signoz.io/signoz/pkg/query-service/querylimits"
 )
 
 func minTimestamp(series []*v3.Series) int64 {
@@ -1124,3 +1128,55 @@ func TestQueryRangeValueTypePromQL(t *testing.T) {
 		}
 	}
 }
+
+func TestQueryRangeQueryLimits(t *testing.T) {
+	params := &v3.QueryRangeParamsV3{
+		Start: 1675115596722,
+		End:   1675115596722 + 48*60*60*1000,
+		Step:  5 * time.Minute.Milliseconds(),
+		CompositeQuery: &v3.CompositeQuery{
+			QueryType: v3.QueryTypeClickHouseSQL,
+			PanelType: v3.PanelTypeGraph,
+			ClickHouseQueries: map[string]*v3.ClickHouseQuery{
+				"A": {
+					Query: "SELECT now() as ts, 1 as value",
+				},
+			},
+		},
+	}
+	opts := QuerierOptions{
+		Reader:       nil,
+		FluxInterval: 5 * time.Minute,
+		KeyGenerator: queryBuilder.NewKeyGenerator(),
+		QueryLimits: &querylimits.Config{
+			Default: querylimits.Limits{MaxTimeRange: 24 * time.Hour},
+		},
+
+		TestingMode:    true,
+		ReturnedSeries: []*v3.Series{},
+	}
+	q := NewQuerier(opts)
+
+	viewer := context.WithValue(context.Background(), constants.ContextUserKey, &model.UserPayload{Role: constants.ViewerGroup})
+	_, _, err := q.QueryRange(viewer, params)
+	limitErr := chErrors.AsQueryLimitError(err)
+	if limitErr == nil {
+		t.Fatalf("expected query limit error, got %v", err)
+	}
+	if limitErr.Limit != chErrors.QueryLimitTimeRange {
+		t.Errorf("expected %s limit to be exceeded, got %s", chErrors.QueryLimitTimeRange, limitErr.Limit)
+	}
+	if len(q.QueriesExecuted()) != 0 {
+		t.Errorf("expected no query to be executed, got %v", q.QueriesExecuted())
+	}
+
+	// an admin can override the limits
+	admin := context.WithValue(context.Background(), constants.ContextUserKey, &model.UserPayload{Role: constants.AdminGroup})
+	_, _, err = q.QueryRange(querylimits.WithOverride(admin), params)
+	if err != nil {
+		t.Errorf("expected no error, got %s", err)
+	}
+	if len(q.QueriesExecuted()) != 1 {
+		t.Errorf("expected one query to be executed, got %v", q.QueriesExecuted())
+	}
+}
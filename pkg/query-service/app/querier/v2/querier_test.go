@@ -12,8 +12,12 @@ import (
 	"go.signoz.io/signoz/pkg/query-service/app/queryBuilder"
 	tracesV3 "go.signoz.io/signoz/pkg/query-service/app/traces/v3"
 	"go.signoz.io/signoz/pkg/query-service/cache/inmemory"
+	"go.signoz.io/signoz/pkg/query-service/constants"
+	chErrors "go.signoz.io/signoz/pkg/query-service/errors"
+	"go.signoz.io/signoz/pkg/query-service/model"
 	v3 "go.signoz.io/signoz/pkg/query-service/model/v3"
 	"go.signoz.io/signoz/pkg/query-service/querycache"
+	"go.signoz.io/signoz/pkg/query-service/querylimits"
 )
 
 func minTimestamp(series []*v3.Series) int64 {
@@ -873,6 +877,75 @@ func TestV2QueryRangeTimeShift(t *testing.T) {
 	}
 }
 
+// the previous period of a comparison is queried with the offset
+func TestV2QueryRangeComparison(t *testing.T) {
+	param := &v3.QueryRangeParamsV3{
+		Start:   1675115596722,               //31, 3:23
+		End:     1675115596722 + 120*60*1000, //31, 5:23
+		Step:    5 * time.Minute.Milliseconds(),
+		Version: "v4",
+		CompositeQuery: &v3.CompositeQuery{
+			QueryType: v3.QueryTypeBuilder,
+			PanelType: v3.PanelTypeGraph,
+			BuilderQueries: map[string]*v3.BuilderQuery{
+				"A": {
+					QueryName:          "A",
+					StepInterval:       60,
+					DataSource:         v3.DataSourceLogs,
+					AggregateAttribute: v3.AttributeKey{},
+					Filters: &v3.FilterSet{
+						Operator: "AND",
+						Items:    []v3.FilterItem{},
+					},
+					AggregateOperator: v3.AggregateOperatorCount,
+					Expression:        "A",
+					Comparison:        &v3.Comparison{Offset: 86400},
+				},
+			},
+		},
+	}
+	opts := QuerierOptions{
+		Reader:       nil,
+		FluxInterval: 5 * time.Minute,
+		KeyGenerator: queryBuilder.NewKeyGenerator(),
+		TestingMode:  true,
+	}
+	q := NewQuerier(opts)
+
+	tracesV3.Enrich(param, map[string]v3.AttributeKey{})
+	// the handlers add the previous period to a copy of the params
+	_, errByName, err := q.QueryRange(context.Background(), param.WithComparisonQueries())
+	if err != nil {
+		t.Errorf("expected no error, got %s", err)
+	}
+	if len(errByName) > 0 {
+		t.Errorf("expected no error, got %v", errByName)
+	}
+	if len(param.CompositeQuery.BuilderQueries) != 1 {
+		t.Errorf("expected the params to be unchanged, got %d queries", len(param.CompositeQuery.BuilderQueries))
+	}
+
+	// logs queries are generates in ns
+	expectedTimeRanges := []string{
+		fmt.Sprintf("timestamp >= %d AND timestamp <= %d", 1675115596722*1000000, (1675115596722+120*60*1000)*1000000),
+		fmt.Sprintf("timestamp >= %d AND timestamp <= %d", (1675115596722-86400*1000)*1000000, ((1675115596722+120*60*1000)-86400*1000)*1000000),
+	}
+	if len(q.QueriesExecuted()) != len(expectedTimeRanges) {
+		t.Fatalf("expected %d queries to be executed, got %v", len(expectedTimeRanges), q.QueriesExecuted())
+	}
+	for _, expected := range expectedTimeRanges {
+		found := false
+		for _, query := range q.QueriesExecuted() {
+			if strings.Contains(query, expected) {
+				found = true
+			}
+		}
+		if !found {
+			t.Errorf("expected a query to contain %s, got %v", expected, q.QueriesExecuted())
+		}
+	}
+}
+
 // timeshift works with caching
 func TestV2QueryRangeTimeShiftWithCache(t *testing.T) {
 	params := []*v3.QueryRangeParamsV3{
@@ -1178,3 +1251,55 @@ func TestV2QueryRangeValueTypePromQL(t *testing.T) {
 		}
 	}
 }
+
+func TestV2QueryRangeQueryLimits(t *testing.T) {
+	params := &v3.QueryRangeParamsV3{
+		Start: 1675115596722,
+		End:   1675115596722 + 48*60*60*1000,
+		Step:  5 * time.Minute.Milliseconds(),
+		CompositeQuery: &v3.CompositeQuery{
+			QueryType: v3.QueryTypeClickHouseSQL,
+			PanelType: v3.PanelTypeGraph,
+			ClickHouseQueries: map[string]*v3.ClickHouseQuery{
+				"A": {
+					Query: "SELECT now() as ts, 1 as value",
+				},
+			},
+		},
+	}
+	opts := QuerierOptions{
+		Reader:       nil,
+		FluxInterval: 5 * time.Minute,
+		KeyGenerator: queryBuilder.NewKeyGenerator(),
+		QueryLimits: &querylimits.Config{
+			Default: querylimits.Limits{MaxTimeRange: 24 * time.Hour},
+		},
+
+		TestingMode:    true,
+		ReturnedSeries: []*v3.Series{},
+	}
+	q := NewQuerier(opts)
+
+	viewer := context.WithValue(context.Background(), constants.ContextUserKey, &model.UserPayload{Role: constants.ViewerGroup})
+	_, _, err := q.QueryRange(viewer, params)
+	limitErr := chErrors.AsQueryLimitError(err)
+	if limitErr == nil {
+		t.Fatalf("expected query limit error, got %v", err)
+	}
+	if limitErr.Limit != chErrors.QueryLimitTimeRange {
+		t.Errorf("expected %s limit to be exceeded, got %s", chErrors.QueryLimitTimeRange, limitErr.Limit)
+	}
+	if len(q.QueriesExecuted()) != 0 {
+		t.Errorf("expected no query to be executed, got %v", q.QueriesExecuted())
+	}
+
+	// an admin can override the limits
+	admin := context.WithValue(context.Background(), constants.ContextUserKey, &model.UserPayload{Role: constants.AdminGroup})
+	_, _, err = q.QueryRange(querylimits.WithOverride(admin), params)
+	if err != nil {
+		t.Errorf("expected no error, got %s", err)
+	}
+	if len(q.QueriesExecuted()) != 1 {
+		t.Errorf("expected one query to be executed, got %v", q.QueriesExecuted())
+	}
+}
@@ -243,6 +243,148 @@ func funcMedian7(result *v3.Result) *v3.Result {
 	return result
 }
 
+// funcHoltWinters smooths each point with double exponential smoothing, the
+// smoothing factor weighs the new values and the trend factor the new trend
+func funcHoltWinters(result *v3.Result, smoothingFactor, trendFactor float64) *v3.Result {
+	for _, series := range result.Series {
+		var level, trend float64
+		var count int
+
+		for i, point := range series.Points {
+			if math.IsNaN(point.Value) {
+				// keep the smoothed value over the gaps once initialized
+				if count > 0 {
+					series.Points[i].Value = level
+				}
+				continue
+			}
+			count++
+			if count == 1 {
+				// Initialize the level with the first non-NaN value
+				level = point.Value
+				continue
+			}
+			if count == 2 {
+				// and the trend with the difference of the first two
+				trend = point.Value - level
+			}
+			previous := level
+			level = smoothingFactor*point.Value + (1-smoothingFactor)*(level+trend)
+			trend = trendFactor*(level-previous) + (1-trendFactor)*trend
+			series.Points[i].Value = level
+		}
+	}
+	return result
+}
+
+// linearFit is the least squares fit of the points of a series, x is the
+// time in seconds since the origin
+type linearFit struct {
+	origin              int64
+	n, sx, sy, sxx, sxy float64
+}
+
+func (f *linearFit) add(point v3.Point) {
+	x := float64(point.Timestamp-f.origin) / 1000
+	f.n++
+	f.sx += x
+	f.sy += point.Value
+	f.sxx += x * x
+	f.sxy += x * point.Value
+}
+
+// at returns the value of the fit at the timestamp, NaN with less than two points
+func (f *linearFit) at(timestamp int64) float64 {
+	denominator := f.n*f.sxx - f.sx*f.sx
+	if f.n < 2 || denominator == 0 {
+		return math.NaN()
+	}
+	slope := (f.n*f.sxy - f.sx*f.sy) / denominator
+	intercept := (f.sy - slope*f.sx) / f.n
+	return intercept + slope*float64(timestamp-f.origin)/1000
+}
+
+// funcLinearRegression replaces each point with the least squares trend line
+// of the series
+func funcLinearRegression(result *v3.Result) *v3.Result {
+	for _, series := range result.Series {
+		if len(series.Points) == 0 {
+			continue
+		}
+		fit := &linearFit{origin: series.Points[0].Timestamp}
+		for _, point := range series.Points {
+			if !math.IsNaN(point.Value) {
+				fit.add(point)
+			}
+		}
+		if fit.n < 2 {
+			continue
+		}
+		for i, point := range series.Points {
+			series.Points[i].Value = fit.at(point.Timestamp)
+		}
+	}
+	return result
+}
+
+// funcPredictLinear returns for each point the value predicted horizon seconds
+// later by the least squares fit of the points up to it
+func funcPredictLinear(result *v3.Result, horizon float64) *v3.Result {
+	for _, series := range result.Series {
+		if len(series.Points) == 0 {
+			continue
+		}
+		fit := &linearFit{origin: series.Points[0].Timestamp}
+		for i, point := range series.Points {
+			if !math.IsNaN(point.Value) {
+				fit.add(point)
+			}
+			series.Points[i].Value = fit.at(point.Timestamp + int64(horizon*1000))
+		}
+	}
+	return result
+}
+
+// funcDerivative returns the per second rate of change between each point and
+// the previous one
+func funcDerivative(result *v3.Result) *v3.Result {
+	for _, series := range result.Series {
+		if len(series.Points) == 0 {
+			continue
+		}
+		// iterate over the point in reverse order
+		for idx := len(series.Points) - 1; idx > 0; idx-- {
+			seconds := float64(series.Points[idx].Timestamp-series.Points[idx-1].Timestamp) / 1000
+			if seconds <= 0 {
+				series.Points[idx].Value = math.NaN()
+				continue
+			}
+			series.Points[idx].Value = (series.Points[idx].Value - series.Points[idx-1].Value) / seconds
+		}
+		// remove the first point, it has no previous point
+		series.Points = series.Points[1:]
+	}
+	return result
+}
+
+// funcIntegral returns the cumulative area under the series since the first
+// point, with the values taken as per second rates
+func funcIntegral(result *v3.Result) *v3.Result {
+	for _, series := range result.Series {
+		var area float64
+		previous := v3.Point{Value: math.NaN()}
+		for i, point := range series.Points {
+			// the intervals with a missing value are not counted
+			if i > 0 && !math.IsNaN(previous.Value) && !math.IsNaN(point.Value) {
+				area += (previous.Value + point.Value) / 2 * float64(point.Timestamp-previous.Timestamp) / 1000
+			}
+			previous = point
+			series.Points[i].Value = area
+		}
+	}
+	return result
+}
+
 func median(values []float64) float64 {
 	sort.Float64s(values)
 	medianIndex := len(values) / 2
@@ -305,6 +447,34 @@ func ApplyFunction(fn v3.Function, result *v3.Result) *v3.Result {
 			return result
 		}
 		return funcTimeShift(result, shift)
+	case v3.FunctionNameHoltWinters:
+		if len(fn.Args) < 2 {
+			return result
+		}
+		smoothingFactor, ok := fn.Args[0].(float64)
+		if !ok {
+			return result
+		}
+		trendFactor, ok := fn.Args[1].(float64)
+		if !ok {
+			return result
+		}
+		return funcHoltWinters(result, smoothingFactor, trendFactor)
+	case v3.FunctionNameLinearRegression:
+		return funcLinearRegression(result)
+	case v3.FunctionNamePredictLinear:
+		if len(fn.Args) == 0 {
+			return result
+		}
+		horizon, ok := fn.Args[0].(float64)
+		if !ok {
+			return result
+		}
+		return funcPredictLinear(result, horizon)
+	case v3.FunctionNameDerivative:
+		return funcDerivative(result)
+	case v3.FunctionNameIntegral:
+		return funcIntegral(result)
 	}
 	return result
 }
@@ -669,3 +669,73 @@ func TestFuncRunningDiff(t *testing.T) {
 		})
 	}
 }
+
+func TestForecastFunctions(t *testing.T) {
+	// the points are a minute apart
+	points := func(values ...float64) []v3.Point {
+		result := make([]v3.Point, 0, len(values))
+		for idx, value := range values {
+			result = append(result, v3.Point{Timestamp: int64(idx) * 60000, Value: value})
+		}
+		return result
+	}
+
+	tests := []struct {
+		name   string
+		fn     v3.Function
+		points []v3.Point
+		want   []v3.Point
+	}{
+		{
+			name:   "linear regression",
+			fn:     v3.Function{Name: v3.FunctionNameLinearRegression},
+			points: points(0, 70, 110, 180),
+			want:   points(3, 61, 119, 177),
+		},
+		{
+			name:   "predict linear an hour later",
+			fn:     v3.Function{Name: v3.FunctionNamePredictLinear, Args: []interface{}{float64(3600)}},
+			points: points(100, 160, math.NaN(), 280),
+			want:   points(math.NaN(), 3760, 3820, 3880),
+		},
+		{
+			name:   "derivative",
+			fn:     v3.Function{Name: v3.FunctionNameDerivative},
+			points: points(0, 60, 60, 240),
+			want:   points(0, 1, 0, 3)[1:],
+		},
+		{
+			name:   "integral",
+			fn:     v3.Function{Name: v3.FunctionNameIntegral},
+			points: points(1, 3, math.NaN(), 2, 2),
+			want:   points(0, 120, 120, 120, 240),
+		},
+		{
+			name:   "holt winters follows the trend",
+			fn:     v3.Function{Name: v3.FunctionNameHoltWinters, Args: []interface{}{0.5, 0.5}},
+			points: points(10, 20, 30, math.NaN(), 50),
+			want:   points(10, 20, 30, 30, 45),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := &v3.Result{Series: []*v3.Series{{Points: tt.points}}}
+			got := ApplyFunction(tt.fn, result).Series[0].Points
+			if len(got) != len(tt.want) {
+				t.Fatalf("ApplyFunction(%s) = %v points, want %v", tt.fn.Name, len(got), len(tt.want))
+			}
+			for idx := range got {
+				if math.IsNaN(tt.want[idx].Value) {
+					if !math.IsNaN(got[idx].Value) {
+						t.Errorf("ApplyFunction(%s) point %d = %v, want NaN", tt.fn.Name, idx, got[idx].Value)
+					}
+					continue
+				}
+				if math.Abs(got[idx].Value-tt.want[idx].Value) > 1e-9 {
+					t.Errorf("ApplyFunction(%s) point %d = %v, want %v", tt.fn.Name, idx, got[idx].Value, tt.want[idx].Value)
+				}
+			}
+		})
+	}
+}
@@ -249,6 +249,10 @@ func (qb *QueryBuilder) PrepareQueries(params *v3.QueryRangeParamsV3) (map[strin
 		// Build queries for each expression
 		for _, query := range compositeQuery.BuilderQueries {
 			if query.Expression != query.QueryName {
+				// the formulas are joined on all of the group by labels in clickhouse
+				if query.VectorMatching != nil {
+					return nil, fmt.Errorf("vector matching of formula %s is only supported in the v4 query range API", query.QueryName)
+				}
 				expression, err := govaluate.NewEvaluableExpressionWithFunctions(query.Expression, EvalFuncs)
 
 				if err != nil {
@@ -353,10 +357,12 @@ func (c *cacheKeyGenerator) GenerateKeys(params *v3.QueryRangeParamsV3) map[stri
 				parts = append(parts, fmt.Sprintf("aggregateAttribute=%s", query.AggregateAttribute.CacheKey()))
 			}
 
-			if query.Filters != nil && len(query.Filters.Items) > 0 {
-				for idx, filter := range query.Filters.Items {
-					parts = append(parts, fmt.Sprintf("filter-%d=%s", idx, filter.CacheKey()))
-				}
+			if !query.Filters.IsEmpty() {
+				parts = append(parts, query.Filters.CacheKey())
+			}
+
+			if query.TraceStructure != nil {
+				parts = append(parts, fmt.Sprintf("traceStructure=%s", query.TraceStructure.CacheKey()))
 			}
 
 			if len(query.GroupBy) > 0 {
@@ -406,6 +412,12 @@ func (c *cacheKeyGenerator) GenerateKeys(params *v3.QueryRangeParamsV3) map[stri
 			parts = append(parts, fmt.Sprintf("timeAggregation=%s", query.TimeAggregation))
 			parts = append(parts, fmt.Sprintf("spaceAggregation=%s", query.SpaceAggregation))
 
+			// the distribution panels query the increase of the buckets instead
+			// of the aggregations of the query
+			if params.CompositeQuery.PanelType.IsDistribution() {
+				parts = append(parts, fmt.Sprintf("panelType=%s", v3.PanelTypeHeatmap))
+			}
+
 			if query.ShiftBy != 0 {
 				parts = append(parts, fmt.Sprintf("shiftBy=%d", query.ShiftBy))
 			}
@@ -414,10 +426,8 @@ func (c *cacheKeyGenerator) GenerateKeys(params *v3.QueryRangeParamsV3) map[stri
 				parts = append(parts, fmt.Sprintf("aggregateAttribute=%s", query.AggregateAttribute.CacheKey()))
 			}
 
-			if query.Filters != nil && len(query.Filters.Items) > 0 {
-				for idx, filter := range query.Filters.Items {
-					parts = append(parts, fmt.Sprintf("filter-%d=%s", idx, filter.CacheKey()))
-				}
+			if !query.Filters.IsEmpty() {
+				parts = append(parts, query.Filters.CacheKey())
 			}
 
 			if len(query.GroupBy) > 0 {
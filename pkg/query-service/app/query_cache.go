@@ -0,0 +1,291 @@
+package app
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"github.com/gorilla/mux"
+	"go.signoz.io/signoz/pkg/query-service/app/dashboards"
+	"go.signoz.io/signoz/pkg/query-service/cache"
+	cacheStatus "go.signoz.io/signoz/pkg/query-service/cache/status"
+	"go.signoz.io/signoz/pkg/query-service/cache/tiered"
+	"go.signoz.io/signoz/pkg/query-service/model"
+	v3 "go.signoz.io/signoz/pkg/query-service/model/v3"
+	"go.signoz.io/signoz/pkg/query-service/querycache"
+)
+
+// cachedRange is a time range of the series cached for a query
+type cachedRange struct {
+	Start  int64 `json:"start"`
+	End    int64 `json:"end"`
+	Series int   `json:"series"`
+}
+
+type cacheEntryDetails struct {
+	tiered.Entry
+	Ranges []cachedRange `json:"ranges"`
+}
+
+// dashboardCacheKeys returns the cache keys of the queries of the panels of
+// the dashboard. The keys are generated from the saved queries so the entries
+// of the queries with dashboard variables, or run with a step other than the
+// saved one, are not found.
+func dashboardCacheKeys(dashboard *dashboards.Dashboard, keyGenerator cache.KeyGenerator) []string {
+	widgets, ok := dashboard.Data["widgets"].([]interface{})
+	if !ok {
+		return nil
+	}
+
+	seen := make(map[string]struct{})
+	keys := make([]string, 0)
+	for _, widget := range widgets {
+		widgetData, ok := widget.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		query, ok := widgetData["query"].(map[string]interface{})
+		if !ok {
+			continue
+		}
+		panelType, _ := widgetData["panelTypes"].(string)
+		compositeQuery := &v3.CompositeQuery{PanelType: v3.PanelType(panelType)}
+
+		switch query["queryType"] {
+		case string(v3.QueryTypeBuilder):
+			builder, ok := query["builder"].(map[string]interface{})
+			if !ok {
+				continue
+			}
+			compositeQuery.QueryType = v3.QueryTypeBuilder
+			// the results of the formulas are not cached
+			var builderQueries []*v3.BuilderQuery
+			if err := remarshal(builder["queryData"], &builderQueries); err != nil {
+				continue
+			}
+			compositeQuery.BuilderQueries = make(map[string]*v3.BuilderQuery)
+			for _, builderQuery := range builderQueries {
+				if builderQuery.QueryName == builderQuery.Expression {
+					compositeQuery.BuilderQueries[builderQuery.QueryName] = builderQuery
+				}
+			}
+		case string(v3.QueryTypePromQL):
+			var promQueries []struct {
+				Name string `json:"name"`
+				v3.PromQuery
+			}
+			if err := remarshal(query["promql"], &promQueries); err != nil {
+				continue
+			}
+			compositeQuery.QueryType = v3.QueryTypePromQL
+			compositeQuery.PromQueries = make(map[string]*v3.PromQuery)
+			for idx := range promQueries {
+				compositeQuery.PromQueries[promQueries[idx].Name] = &promQueries[idx].PromQuery
+			}
+		default:
+			continue
+		}
+
+		for _, key := range keyGenerator.GenerateKeys(&v3.QueryRangeParamsV3{Version: "v4", CompositeQuery: compositeQuery}) {
+			if _, ok := seen[key]; !ok && key != "" {
+				seen[key] = struct{}{}
+				keys = append(keys, key)
+			}
+		}
+	}
+	return keys
+}
+
+func remarshal(from interface{}, to interface{}) error {
+	data, err := json.Marshal(from)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, to)
+}
+
+func (aH *APIHandler) inspectableCache() (cache.Inspectable, *model.ApiError) {
+	if aH.cache == nil {
+		return nil, &model.ApiError{Typ: model.ErrorNotFound, Err: fmt.Errorf("query cache is not configured")}
+	}
+	inspectable, ok := aH.cache.(cache.Inspectable)
+	if !ok {
+		return nil, &model.ApiError{Typ: model.ErrorNotImplemented, Err: fmt.Errorf("query cache entries can't be inspected, use the tiered cache provider")}
+	}
+	return inspectable, nil
+}
+
+// cacheEntries returns the entries of the cache, only the entries with the
+// keys are returned if they are set
+func (aH *APIHandler) cacheEntries(keys []string) ([]tiered.Entry, *model.ApiError) {
+	inspectable, apiErr := aH.inspectableCache()
+	if apiErr != nil {
+		return nil, apiErr
+	}
+	if keys == nil {
+		entries, err := inspectable.Entries()
+		if err != nil {
+			return nil, &model.ApiError{Typ: model.ErrorInternal, Err: err}
+		}
+		return entries, nil
+	}
+	entries := make([]tiered.Entry, 0)
+	for _, key := range keys {
+		entry, err := inspectable.Entry(tiered.KeyHash(key))
+		if err != nil {
+			return nil, &model.ApiError{Typ: model.ErrorInternal, Err: err}
+		}
+		if entry != nil {
+			entries = append(entries, *entry)
+		}
+	}
+	return entries, nil
+}
+
+func (aH *APIHandler) cacheEntryByHash(hash string) (cache.Inspectable, *tiered.Entry, *model.ApiError) {
+	inspectable, apiErr := aH.inspectableCache()
+	if apiErr != nil {
+		return nil, nil, apiErr
+	}
+	entry, err := inspectable.Entry(hash)
+	if err != nil {
+		return nil, nil, &model.ApiError{Typ: model.ErrorInternal, Err: err}
+	}
+	if entry == nil {
+		return nil, nil, &model.ApiError{Typ: model.ErrorNotFound, Err: fmt.Errorf("no cache entry found with hash %s", hash)}
+	}
+	return inspectable, entry, nil
+}
+
+func (aH *APIHandler) getQueryCacheStats(w http.ResponseWriter, r *http.Request) {
+	inspectable, apiErr := aH.inspectableCache()
+	if apiErr != nil {
+		RespondError(w, apiErr, nil)
+		return
+	}
+	aH.Respond(w, inspectable.Stats())
+}
+
+func (aH *APIHandler) listQueryCacheEntries(w http.ResponseWriter, r *http.Request) {
+	entries, apiErr := aH.cacheEntries(nil)
+	if apiErr != nil {
+		RespondError(w, apiErr, nil)
+		return
+	}
+	if prefix := r.URL.Query().Get("prefix"); prefix != "" {
+		filtered := make([]tiered.Entry, 0)
+		for _, entry := range entries {
+			if entry.Prefix == prefix {
+				filtered = append(filtered, entry)
+			}
+		}
+		entries = filtered
+	}
+	aH.Respond(w, entries)
+}
+
+func (aH *APIHandler) getQueryCacheEntry(w http.ResponseWriter, r *http.Request) {
+	inspectable, entry, apiErr := aH.cacheEntryByHash(mux.Vars(r)["hash"])
+	if apiErr != nil {
+		RespondError(w, apiErr, nil)
+		return
+	}
+
+	details := cacheEntryDetails{Entry: *entry, Ranges: make([]cachedRange, 0)}
+	// inspecting the entry doesn't count as a hit or make it recently used
+	data, _, err := inspectable.Peek(entry.Key)
+	if err != nil {
+		RespondError(w, &model.ApiError{Typ: model.ErrorInternal, Err: err}, nil)
+		return
+	}
+	var cachedSeriesData []querycache.CachedSeriesData
+	// the entries of the PromQL queries are cached series data too
+	if err := json.Unmarshal(data, &cachedSeriesData); err == nil {
+		for _, cached := range cachedSeriesData {
+			details.Ranges = append(details.Ranges, cachedRange{Start: cached.Start, End: cached.End, Series: len(cached.Data)})
+		}
+	}
+	aH.Respond(w, details)
+}
+
+// deleteQueryCacheEntry removes the entry from the remote cache and from the
+// local cache of the instance serving the request, the local caches of the
+// other instances keep it until their local TTL expires
+func (aH *APIHandler) deleteQueryCacheEntry(w http.ResponseWriter, r *http.Request) {
+	_, entry, apiErr := aH.cacheEntryByHash(mux.Vars(r)["hash"])
+	if apiErr != nil {
+		RespondError(w, apiErr, nil)
+		return
+	}
+	aH.cache.Remove(entry.Key)
+	aH.Respond(w, entry)
+}
+
+func (aH *APIHandler) listDashboardQueryCacheEntries(w http.ResponseWriter, r *http.Request) {
+	dashboard, apiErr := dashboards.GetDashboard(r.Context(), mux.Vars(r)["uuid"])
+	if apiErr != nil {
+		RespondError(w, apiErr, nil)
+		return
+	}
+	entries, apiErr := aH.cacheEntries(dashboardCacheKeys(dashboard, aH.keyGenerator))
+	if apiErr != nil {
+		RespondError(w, apiErr, nil)
+		return
+	}
+	aH.Respond(w, entries)
+}
+
+// existingCacheKeys returns the keys with an entry in the cache
+func existingCacheKeys(c cache.Cache, keys []string) ([]string, *model.ApiError) {
+	existing := make([]string, 0, len(keys))
+	inspectable, isInspectable := c.(cache.Inspectable)
+	for _, key := range keys {
+		if isInspectable {
+			entry, err := inspectable.Entry(tiered.KeyHash(key))
+			if err != nil {
+				return nil, &model.ApiError{Typ: model.ErrorInternal, Err: err}
+			}
+			if entry != nil {
+				existing = append(existing, key)
+			}
+			continue
+		}
+		_, retrieveStatus, err := c.Retrieve(key, true)
+		if err != nil {
+			return nil, &model.ApiError{Typ: model.ErrorInternal, Err: err}
+		}
+		if retrieveStatus != cacheStatus.RetrieveStatusKeyMiss {
+			existing = append(existing, key)
+		}
+	}
+	return existing, nil
+}
+
+// purgeDashboardQueryCache removes the cache entries of the queries of the
+// dashboard, it works with any cache provider since the keys are known. Only
+// the hashes of the entries found in the cache are reported as removed. Like
+// the removal of a single entry, the local caches of the other instances of
+// the tiered cache keep the entries until their local TTL expires.
+func (aH *APIHandler) purgeDashboardQueryCache(w http.ResponseWriter, r *http.Request) {
+	if aH.cache == nil {
+		RespondError(w, &model.ApiError{Typ: model.ErrorNotFound, Err: fmt.Errorf("query cache is not configured")}, nil)
+		return
+	}
+	dashboard, apiErr := dashboards.GetDashboard(r.Context(), mux.Vars(r)["uuid"])
+	if apiErr != nil {
+		RespondError(w, apiErr, nil)
+		return
+	}
+	keys, apiErr := existingCacheKeys(aH.cache, dashboardCacheKeys(dashboard, aH.keyGenerator))
+	if apiErr != nil {
+		RespondError(w, apiErr, nil)
+		return
+	}
+	aH.cache.BulkRemove(keys)
+
+	hashes := make([]string, 0, len(keys))
+	for _, key := range keys {
+		hashes = append(hashes, tiered.KeyHash(key))
+	}
+	aH.Respond(w, map[string]interface{}{"removed": hashes})
+}
@@ -0,0 +1,72 @@
+package app
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.signoz.io/signoz/pkg/query-service/app/dashboards"
+	"go.signoz.io/signoz/pkg/query-service/app/queryBuilder"
+	"go.signoz.io/signoz/pkg/query-service/cache/inmemory"
+)
+
+func TestDashboardCacheKeys(t *testing.T) {
+	var data dashboards.Data
+	require.NoError(t, json.Unmarshal([]byte(`{
+		"widgets": [
+			{
+				"panelTypes": "graph",
+				"query": {
+					"queryType": "builder",
+					"builder": {
+						"queryData": [
+							{
+								"queryName": "A",
+								"expression": "A",
+								"dataSource": "metrics",
+								"aggregateOperator": "sum_rate",
+								"aggregateAttribute": {"key": "signoz_calls_total", "dataType": "float64", "type": "Sum"},
+								"timeAggregation": "rate",
+								"spaceAggregation": "sum",
+								"stepInterval": 60
+							}
+						],
+						"queryFormulas": [{"queryName": "F1", "expression": "A * 2"}]
+					}
+				}
+			},
+			{
+				"panelTypes": "graph",
+				"query": {
+					"queryType": "promql",
+					"promql": [{"name": "A", "query": "up"}]
+				}
+			},
+			{
+				"panelTypes": "list",
+				"query": {"queryType": "builder", "builder": {"queryData": [{"queryName": "A", "expression": "A", "dataSource": "logs"}]}}
+			}
+		]
+	}`), &data))
+
+	keys := dashboardCacheKeys(&dashboards.Dashboard{Data: data}, queryBuilder.NewKeyGenerator())
+	require.Len(t, keys, 2)
+	assert.Contains(t, keys, "up")
+	for _, key := range keys {
+		if key != "up" {
+			assert.Contains(t, key, "source=metrics&step=60&aggregate=sum_rate")
+		}
+	}
+}
+
+func TestExistingCacheKeys(t *testing.T) {
+	c := inmemory.New(&inmemory.Options{TTL: time.Minute, CleanupInterval: time.Minute})
+	require.NoError(t, c.Store("stored", []byte("data"), time.Minute))
+
+	// only the keys with an entry are reported as removed
+	keys, apiErr := existingCacheKeys(c, []string{"stored", "missing"})
+	require.Nil(t, apiErr)
+	assert.Equal(t, []string{"stored"}, keys)
+}
@@ -26,6 +26,7 @@ import (
 	"go.signoz.io/signoz/pkg/query-service/app/clickhouseReader"
 	"go.signoz.io/signoz/pkg/query-service/app/dashboards"
 	"go.signoz.io/signoz/pkg/query-service/app/integrations"
+	"go.signoz.io/signoz/pkg/query-service/app/logmetrics"
 	"go.signoz.io/signoz/pkg/query-service/app/logparsingpipeline"
 	"go.signoz.io/signoz/pkg/query-service/app/opamp"
 	opAmpModel "go.signoz.io/signoz/pkg/query-service/app/opamp/model"
@@ -45,6 +46,7 @@ import (
 	"go.signoz.io/signoz/pkg/query-service/interfaces"
 	"go.signoz.io/signoz/pkg/query-service/model"
 	pqle "go.signoz.io/signoz/pkg/query-service/pqlEngine"
+	"go.signoz.io/signoz/pkg/query-service/querylimits"
 	"go.signoz.io/signoz/pkg/query-service/rules"
 	"go.signoz.io/signoz/pkg/query-service/telemetry"
 	"go.signoz.io/signoz/pkg/query-service/utils"
@@ -64,6 +66,7 @@ type ServerOptions struct {
 	MaxOpenConns      int
 	DialTimeout       time.Duration
 	CacheConfigPath   string
+	QueryLimitsPath   string
 	FluxInterval      string
 	Cluster           string
 	UseLogsNewSchema  bool
@@ -151,6 +154,11 @@ func NewServer(serverOptions *ServerOptions) (*Server, error) {
 			return nil, err
 		}
 		c = cache.NewCache(cacheOpts)
+		if c != nil {
+			if err := c.Connect(); err != nil {
+				return nil, err
+			}
+		}
 	}
 
 	<-readerReady
@@ -174,6 +182,14 @@ func NewServer(serverOptions *ServerOptions) (*Server, error) {
 		return nil, err
 	}
 
+	var queryLimits *querylimits.Config
+	if serverOptions.QueryLimitsPath != "" {
+		queryLimits, err = querylimits.LoadFromYAMLConfigFile(serverOptions.QueryLimitsPath)
+		if err != nil {
+			return nil, err
+		}
+	}
+
 	integrationsController, err := integrations.NewController(localDB)
 	if err != nil {
 		return nil, fmt.Errorf("couldn't create integrations controller: %w", err)
@@ -186,6 +202,11 @@ func NewServer(serverOptions *ServerOptions) (*Server, error) {
 		return nil, err
 	}
 
+	logMetricsController, err := logmetrics.NewLogMetricsController(localDB, "sqlite")
+	if err != nil {
+		return nil, err
+	}
+
 	telemetry.GetInstance().SetReader(reader)
 	apiHandler, err := NewAPIHandler(APIHandlerOpts{
 		Reader:                        reader,
@@ -199,9 +220,11 @@ func NewServer(serverOptions *ServerOptions) (*Server, error) {
 		FeatureFlags:                  fm,
 		IntegrationsController:        integrationsController,
 		LogsParsingPipelineController: logParsingPipelineController,
+		LogMetricsController:          logMetricsController,
 		Cache:                         c,
 		FluxInterval:                  fluxInterval,
 		UseLogsNewSchema:              serverOptions.UseLogsNewSchema,
+		QueryLimits:                   queryLimits,
 	})
 	if err != nil {
 		return nil, err
@@ -240,6 +263,7 @@ func NewServer(serverOptions *ServerOptions) (*Server, error) {
 		DBEngine: "sqlite",
 		AgentFeatures: []agentConf.AgentFeature{
 			logParsingPipelineController,
+			logMetricsController,
 		},
 	})
 	if err != nil {
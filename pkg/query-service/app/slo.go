@@ -0,0 +1,101 @@
+package app
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/gorilla/mux"
+	"go.signoz.io/signoz/pkg/query-service/dao"
+	"go.signoz.io/signoz/pkg/query-service/model"
+	"go.signoz.io/signoz/pkg/query-service/rules"
+)
+
+// parseSLO reads the SLO from the request body. A latency SLO of a service
+// gets its queries from the apdex threshold of the service.
+func parseSLO(r *http.Request) (*rules.SLO, *model.ApiError) {
+	var slo rules.SLO
+	if err := json.NewDecoder(r.Body).Decode(&slo); err != nil {
+		return nil, &model.ApiError{Typ: model.ErrorBadData, Err: err}
+	}
+
+	if slo.Service != "" && slo.Good == nil && slo.Total == nil {
+		apdexSettings, apiErr := dao.DB().GetApdexSettings(r.Context(), []string{slo.Service})
+		if apiErr != nil {
+			return nil, &model.ApiError{Typ: model.ErrorInternal, Err: apiErr.Err}
+		}
+		if len(apdexSettings) == 0 {
+			return nil, &model.ApiError{Typ: model.ErrorBadData, Err: fmt.Errorf("no apdex settings found for service %s", slo.Service)}
+		}
+		slo.Good, slo.Total = rules.LatencySLOQueries(slo.Service, apdexSettings[0].Threshold)
+	}
+	return &slo, nil
+}
+
+func (aH *APIHandler) listSLOs(w http.ResponseWriter, r *http.Request) {
+	slos, apiErr := aH.ruleManager.ListSLOs(r.Context())
+	if apiErr != nil {
+		RespondError(w, apiErr, nil)
+		return
+	}
+	aH.Respond(w, slos)
+}
+
+func (aH *APIHandler) getSLO(w http.ResponseWriter, r *http.Request) {
+	slo, apiErr := aH.ruleManager.GetSLO(r.Context(), mux.Vars(r)["id"])
+	if apiErr != nil {
+		RespondError(w, apiErr, nil)
+		return
+	}
+	aH.Respond(w, slo)
+}
+
+func (aH *APIHandler) createSLO(w http.ResponseWriter, r *http.Request) {
+	slo, apiErr := parseSLO(r)
+	if apiErr != nil {
+		RespondError(w, apiErr, nil)
+		return
+	}
+
+	slo, apiErr = aH.ruleManager.CreateSLO(r.Context(), slo)
+	if apiErr != nil {
+		RespondError(w, apiErr, nil)
+		return
+	}
+	aH.Respond(w, slo)
+}
+
+func (aH *APIHandler) editSLO(w http.ResponseWriter, r *http.Request) {
+	slo, apiErr := parseSLO(r)
+	if apiErr != nil {
+		RespondError(w, apiErr, nil)
+		return
+	}
+
+	slo, apiErr = aH.ruleManager.EditSLO(r.Context(), mux.Vars(r)["id"], slo)
+	if apiErr != nil {
+		RespondError(w, apiErr, nil)
+		return
+	}
+	aH.Respond(w, slo)
+}
+
+func (aH *APIHandler) deleteSLO(w http.ResponseWriter, r *http.Request) {
+	if apiErr := aH.ruleManager.DeleteSLO(r.Context(), mux.Vars(r)["id"]); apiErr != nil {
+		RespondError(w, apiErr, nil)
+		return
+	}
+	aH.Respond(w, "slo successfully deleted")
+}
+
+// getSLOStatus returns the attainment, the remaining error budget and the
+// budget history of the SLO over its window
+func (aH *APIHandler) getSLOStatus(w http.ResponseWriter, r *http.Request) {
+	status, apiErr := aH.ruleManager.SLOStatus(r.Context(), mux.Vars(r)["id"], time.Now())
+	if apiErr != nil {
+		RespondError(w, apiErr, nil)
+		return
+	}
+	aH.Respond(w, status)
+}
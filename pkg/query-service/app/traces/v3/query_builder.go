@@ -149,54 +149,47 @@ func getZerosForEpochNano(epoch int64) int64 {
 	return int64(math.Pow(10, float64(19-count)))
 }
 
-func buildTracesFilterQuery(fs *v3.FilterSet) (string, error) {
-	var conditions []string
-
-	if fs != nil && len(fs.Items) != 0 {
-		for _, item := range fs.Items {
-			val := item.Value
-			// generate the key
-			columnName := getColumnName(item.Key)
-			var fmtVal string
-			item.Operator = v3.FilterOperator(strings.ToLower(strings.TrimSpace(string(item.Operator))))
-			if item.Operator != v3.FilterOperatorExists && item.Operator != v3.FilterOperatorNotExists {
-				var err error
-				val, err = utils.ValidateAndCastValue(val, item.Key.DataType)
-				if err != nil {
-					return "", fmt.Errorf("invalid value for key %s: %v", item.Key.Key, err)
-				}
-			}
-			if val != nil {
-				fmtVal = utils.ClickHouseFormattedValue(val)
-			}
-			if operator, ok := tracesOperatorMappingV3[item.Operator]; ok {
-				switch item.Operator {
-				case v3.FilterOperatorContains, v3.FilterOperatorNotContains:
-					val = utils.QuoteEscapedString(fmt.Sprintf("%v", item.Value))
-					conditions = append(conditions, fmt.Sprintf("%s %s '%%%s%%'", columnName, operator, val))
-				case v3.FilterOperatorRegex, v3.FilterOperatorNotRegex:
-					conditions = append(conditions, fmt.Sprintf(operator, columnName, fmtVal))
-				case v3.FilterOperatorExists, v3.FilterOperatorNotExists:
-					if item.Key.IsColumn {
-						subQuery, err := existsSubQueryForFixedColumn(item.Key, item.Operator)
-						if err != nil {
-							return "", err
-						}
-						conditions = append(conditions, subQuery)
-					} else {
-						columnType, columnDataType := getClickhouseTracesColumnDataTypeAndType(item.Key)
-						conditions = append(conditions, fmt.Sprintf(operator, columnDataType, columnType, item.Key.Key))
-					}
-
-				default:
-					conditions = append(conditions, fmt.Sprintf("%s %s %s", columnName, operator, fmtVal))
-				}
-			} else {
-				return "", fmt.Errorf("unsupported operator %s", item.Operator)
+func buildTracesFilterItem(item v3.FilterItem) (string, error) {
+	val := item.Value
+	// generate the key
+	columnName := getColumnName(item.Key)
+	var fmtVal string
+	item.Operator = v3.FilterOperator(strings.ToLower(strings.TrimSpace(string(item.Operator))))
+	if item.Operator != v3.FilterOperatorExists && item.Operator != v3.FilterOperatorNotExists {
+		var err error
+		val, err = utils.ValidateAndCastValue(val, item.Key.DataType)
+		if err != nil {
+			return "", fmt.Errorf("invalid value for key %s: %v", item.Key.Key, err)
+		}
+	}
+	if val != nil {
+		fmtVal = utils.ClickHouseFormattedValue(val)
+	}
+	if operator, ok := tracesOperatorMappingV3[item.Operator]; ok {
+		switch item.Operator {
+		case v3.FilterOperatorContains, v3.FilterOperatorNotContains:
+			val = utils.QuoteEscapedString(fmt.Sprintf("%v", item.Value))
+			return fmt.Sprintf("%s %s '%%%s%%'", columnName, operator, val), nil
+		case v3.FilterOperatorRegex, v3.FilterOperatorNotRegex:
+			return fmt.Sprintf(operator, columnName, fmtVal), nil
+		case v3.FilterOperatorExists, v3.FilterOperatorNotExists:
+			if item.Key.IsColumn {
+				return existsSubQueryForFixedColumn(item.Key, item.Operator)
 			}
+			columnType, columnDataType := getClickhouseTracesColumnDataTypeAndType(item.Key)
+			return fmt.Sprintf(operator, columnDataType, columnType, item.Key.Key), nil
+		default:
+			return fmt.Sprintf("%s %s %s", columnName, operator, fmtVal), nil
 		}
 	}
-	queryString := strings.Join(conditions, " AND ")
+	return "", fmt.Errorf("unsupported operator %s", item.Operator)
+}
+
+func buildTracesFilterQuery(fs *v3.FilterSet) (string, error) {
+	queryString, err := fs.BuildCondition(v3.SQLFilterSyntax, buildTracesFilterItem)
+	if err != nil {
+		return "", err
+	}
 
 	if len(queryString) > 0 {
 		queryString = " AND " + queryString
@@ -239,6 +232,9 @@ func handleEmptyValuesInGroupBy(groupBy []v3.AttributeKey) (string, error) {
 }
 
 func buildTracesQuery(start, end, step int64, mq *v3.BuilderQuery, _ string, panelType v3.PanelType, options Options) (string, error) {
+	if mq.TraceStructure != nil {
+		return buildTraceStructureQuery(start, end, step, mq, panelType)
+	}
 
 	filterSubQuery, err := buildTracesFilterQuery(mq.Filters)
 	if err != nil {
@@ -247,6 +243,10 @@ func buildTracesQuery(start, end, step int64, mq *v3.BuilderQuery, _ string, pan
 	// timerange will be sent in epoch millisecond
 	spanIndexTableTimeFilter := fmt.Sprintf("(timestamp >= '%d' AND timestamp <= '%d')", start*getZerosForEpochNano(start), end*getZerosForEpochNano(end))
 
+	if panelType.IsDistribution() {
+		return buildTracesHeatmapQuery(step, mq, spanIndexTableTimeFilter, filterSubQuery), nil
+	}
+
 	selectLabels := getSelectLabels(mq.AggregateOperator, mq.GroupBy)
 
 	having := having(mq.Having)
@@ -381,6 +381,25 @@ func buildTracesQuery(start, end, step int64, mq *v3.BuilderQuery, _ string, pan
 	}
 }
 
+// buildTracesHeatmapQuery counts the spans per step in the buckets of the value
+// of the aggregate attribute, the bucket is selected as the le label
+func buildTracesHeatmapQuery(step int64, mq *v3.BuilderQuery, timeFilter, filterSubQuery string) string {
+	if mq.AggregateAttribute.IsColumn {
+		subQuery, err := existsSubQueryForFixedColumn(mq.AggregateAttribute, v3.FilterOperatorExists)
+		if err == nil {
+			filterSubQuery = fmt.Sprintf("%s AND %s", filterSubQuery, subQuery)
+		}
+	} else {
+		columnType, columnDataType := getClickhouseTracesColumnDataTypeAndType(mq.AggregateAttribute)
+		filterSubQuery = fmt.Sprintf("%s AND has(%s%s, '%s')", filterSubQuery, columnDataType, columnType, mq.AggregateAttribute.Key)
+	}
+
+	bucket := utils.HistogramBucketExpression(mq.Buckets, getColumnName(mq.AggregateAttribute))
+	return fmt.Sprintf("SELECT toStartOfInterval(timestamp, INTERVAL %d SECOND) AS ts, %s as le, toFloat64(count()) as value "+
+		"from %s.%s where %s%s group by ts, le order by ts", step, bucket,
+		constants.SIGNOZ_TRACE_DBNAME, constants.SIGNOZ_SPAN_INDEX_TABLENAME, timeFilter, filterSubQuery)
+}
+
 func enrichOrderBy(items []v3.OrderBy, keys map[string]v3.AttributeKey) []v3.OrderBy {
 	enrichedItems := []v3.OrderBy{}
 	for i := 0; i < len(items); i++ {
@@ -556,11 +575,12 @@ func EnrichTracesQuery(query *v3.BuilderQuery, keys map[string]v3.AttributeKey)
 	// enrich aggregate attribute
 	query.AggregateAttribute = enrichKeyWithMetadata(query.AggregateAttribute, keys)
 	// enrich filter items
-	if query.Filters != nil && len(query.Filters.Items) > 0 {
-		for idx, filter := range query.Filters.Items {
-			query.Filters.Items[idx].Key = enrichKeyWithMetadata(filter.Key, keys)
-		}
-	}
+	query.Filters.VisitItems(func(item *v3.FilterItem) {
+		item.Key = enrichKeyWithMetadata(item.Key, keys)
+	})
+	query.TraceStructure.VisitItems(func(item *v3.FilterItem) {
+		item.Key = enrichKeyWithMetadata(item.Key, keys)
+	})
 	// enrich group by
 	for idx, groupBy := range query.GroupBy {
 		query.GroupBy[idx] = enrichKeyWithMetadata(groupBy, keys)
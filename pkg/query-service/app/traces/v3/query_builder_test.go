@@ -128,6 +128,23 @@ var buildFilterQueryData = []struct {
 		}},
 		ExpectedFilter: " AND NOT match(stringTagMap['name'], '102.')",
 	},
+	{
+		Name: "Test nested groups",
+		FilterSet: &v3.FilterSet{Operator: "AND", Items: []v3.FilterItem{
+			{Key: v3.AttributeKey{Key: "host", DataType: v3.AttributeKeyDataTypeString, Type: v3.AttributeKeyTypeTag}, Value: "102.", Operator: "="},
+		}, Groups: []v3.FilterSet{
+			{Operator: "OR", Items: []v3.FilterItem{
+				{Key: v3.AttributeKey{Key: "bytes", DataType: v3.AttributeKeyDataTypeInt64, Type: v3.AttributeKeyTypeTag}, Value: 100, Operator: ">"},
+				{Key: v3.AttributeKey{Key: "count", DataType: v3.AttributeKeyDataTypeFloat64, Type: v3.AttributeKeyTypeTag}, Operator: "nexists"},
+			}, Groups: []v3.FilterSet{
+				{Operator: "AND", Items: []v3.FilterItem{
+					{Key: v3.AttributeKey{Key: "name", DataType: v3.AttributeKeyDataTypeString, Type: v3.AttributeKeyTypeTag, IsColumn: true}, Value: "GET", Operator: "="},
+					{Key: v3.AttributeKey{Key: "host", DataType: v3.AttributeKeyDataTypeString, Type: v3.AttributeKeyTypeTag}, Value: "103.", Operator: "!="},
+				}},
+			}},
+		}},
+		ExpectedFilter: " AND stringTagMap['host'] = '102.' AND (numberTagMap['bytes'] > 100 OR NOT has(numberTagMap, 'count') OR (name = 'GET' AND stringTagMap['host'] != '103.'))",
+	},
 }
 
 func TestBuildTracesFilterQuery(t *testing.T) {
@@ -1172,6 +1189,45 @@ var testBuildTracesQueryData = []struct {
 			"GROUP BY subQuery.traceID, subQuery.durationNano, subQuery.name, subQuery.serviceName ORDER BY subQuery.durationNano desc LIMIT 1 BY subQuery.traceID;",
 		PanelType: v3.PanelTypeTrace,
 	},
+	{
+		Name:  "Test heatmap of duration with explicit buckets",
+		Start: 1680066360726210000,
+		End:   1680066458000000000,
+		BuilderQuery: &v3.BuilderQuery{
+			QueryName:          "A",
+			StepInterval:       60,
+			AggregateOperator:  v3.AggregateOperatorCount,
+			Expression:         "A",
+			AggregateAttribute: v3.AttributeKey{Key: "durationNano", DataType: v3.AttributeKeyDataTypeFloat64, Type: v3.AttributeKeyTypeTag, IsColumn: true},
+			Buckets:            &v3.HistogramBuckets{Bounds: []float64{1000000, 10000000}},
+			Filters: &v3.FilterSet{Operator: "AND", Items: []v3.FilterItem{
+				{Key: v3.AttributeKey{Key: "method", DataType: v3.AttributeKeyDataTypeString, Type: v3.AttributeKeyTypeTag}, Value: "GET", Operator: "="},
+			}},
+		},
+		ExpectedQuery: "SELECT toStartOfInterval(timestamp, INTERVAL 60 SECOND) AS ts, " +
+			"toString(arrayFirst(x -> toFloat64(durationNano) <= x, [1000000, 10000000, inf])) as le, toFloat64(count()) as value" +
+			" from signoz_traces.distributed_signoz_index_v2 where (timestamp >= '1680066360726210000' AND timestamp <= '1680066458000000000')" +
+			" AND stringTagMap['method'] = 'GET' group by ts, le order by ts",
+		PanelType: v3.PanelTypeHeatmap,
+	},
+	{
+		Name:  "Test histogram of attribute with log-scale buckets",
+		Start: 1680066360726210000,
+		End:   1680066458000000000,
+		BuilderQuery: &v3.BuilderQuery{
+			QueryName:          "A",
+			StepInterval:       60,
+			AggregateOperator:  v3.AggregateOperatorCount,
+			Expression:         "A",
+			AggregateAttribute: v3.AttributeKey{Key: "bytes", DataType: v3.AttributeKeyDataTypeFloat64, Type: v3.AttributeKeyTypeTag},
+			Buckets:            &v3.HistogramBuckets{Base: 10},
+		},
+		ExpectedQuery: "SELECT toStartOfInterval(timestamp, INTERVAL 60 SECOND) AS ts, " +
+			"if(toFloat64(numberTagMap['bytes']) <= 0, '0', toString(pow(10, ceil(round(log(toFloat64(numberTagMap['bytes'])) / log(10), 9))))) as le, toFloat64(count()) as value" +
+			" from signoz_traces.distributed_signoz_index_v2 where (timestamp >= '1680066360726210000' AND timestamp <= '1680066458000000000')" +
+			" AND has(numberTagMap, 'bytes') group by ts, le order by ts",
+		PanelType: v3.PanelTypeHistogram,
+	},
 }
 
 func TestBuildTracesQuery(t *testing.T) {
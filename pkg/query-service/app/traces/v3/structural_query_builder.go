@@ -0,0 +1,119 @@
+package v3
+
+import (
+	"fmt"
+	"strings"
+
+	"go.signoz.io/signoz/pkg/query-service/constants"
+	v3 "go.signoz.io/signoz/pkg/query-service/model/v3"
+)
+
+// traceStructureMaxDepth is the number of levels of ancestors searched for
+// an indirect parent of a span
+const traceStructureMaxDepth = 16
+
+// buildTraceStructureSubQuery builds the query selecting the traces which match
+// the structure, it groups the spans by trace and returns the traceID, the start
+// of the trace, the number of spans and the duration of the trace.
+//
+// The spans of the selectors used in relations are collected into arrays of span
+// ids, the other selectors are counted. The parents of a span are looked up in the
+// span and parent span ids of the whole trace, level by level.
+func buildTraceStructureSubQuery(start, end int64, structure *v3.TraceStructure) (string, error) {
+	spanIndexTableTimeFilter := fmt.Sprintf("(timestamp >= '%d' AND timestamp <= '%d')", start*getZerosForEpochNano(start), end*getZerosForEpochNano(end))
+
+	related := map[string]struct{}{}
+	for _, relation := range structure.Relations {
+		related[relation.Parent] = struct{}{}
+		related[relation.Child] = struct{}{}
+	}
+
+	selectColumns := []string{
+		"traceID",
+		"min(timestamp) as trace_start",
+		"count() as span_count",
+		"max(toUnixTimestamp64Nano(timestamp) + durationNano) - toUnixTimestamp64Nano(trace_start) as duration_nano",
+	}
+	if len(related) > 0 {
+		selectColumns = append(selectColumns, "groupArray(spanID) as span_ids", "groupArray(parentSpanID) as parent_ids")
+	}
+
+	var conditions []string
+	selectorIdx := map[string]int{}
+	for idx, span := range structure.Spans {
+		selectorIdx[span.Name] = idx
+		filter, err := span.Filters.BuildCondition(v3.SQLFilterSyntax, buildTracesFilterItem)
+		if err != nil {
+			return "", fmt.Errorf("failed to build the filter of span selector %s: %w", span.Name, err)
+		}
+		if filter == "" {
+			filter = "true"
+		}
+
+		if _, ok := related[span.Name]; ok {
+			selectColumns = append(selectColumns, fmt.Sprintf("groupArrayIf(spanID, %s) as span_%d_ids", filter, idx))
+			conditions = append(conditions, fmt.Sprintf("notEmpty(span_%d_ids)", idx))
+			continue
+		}
+
+		selectColumns = append(selectColumns, fmt.Sprintf("countIf(%s) as span_%d_count", filter, idx))
+		if span.Absent {
+			conditions = append(conditions, fmt.Sprintf("span_%d_count = 0", idx))
+		} else {
+			conditions = append(conditions, fmt.Sprintf("span_%d_count > 0", idx))
+		}
+	}
+
+	for idx, relation := range structure.Relations {
+		depth := traceStructureMaxDepth
+		if relation.Direct {
+			depth = 1
+		}
+
+		// the parents of the child spans at every level up to the depth
+		var levels []string
+		previous := fmt.Sprintf("span_%d_ids", selectorIdx[relation.Child])
+		for level := 1; level <= depth; level++ {
+			name := fmt.Sprintf("relation_%d_level_%d", idx, level)
+			selectColumns = append(selectColumns, fmt.Sprintf("arrayMap(x -> parent_ids[indexOf(span_ids, x)], %s) as %s", previous, name))
+			levels = append(levels, name)
+			previous = name
+		}
+		conditions = append(conditions, fmt.Sprintf("hasAny(span_%d_ids, arrayConcat(%s))", selectorIdx[relation.Parent], strings.Join(levels, ", ")))
+	}
+
+	query := "SELECT " + strings.Join(selectColumns, ", ") +
+		" from " + constants.SIGNOZ_TRACE_DBNAME + "." + constants.SIGNOZ_SPAN_INDEX_TABLENAME +
+		" where " + spanIndexTableTimeFilter +
+		" group by traceID" +
+		" having " + strings.Join(conditions, " AND ")
+	return query, nil
+}
+
+// buildTraceStructureQuery builds the query of a builder query selecting traces
+// by their structure, the list and trace panels return the matching traces and
+// the other panels the number of matching traces.
+func buildTraceStructureQuery(start, end, step int64, mq *v3.BuilderQuery, panelType v3.PanelType) (string, error) {
+	subQuery, err := buildTraceStructureSubQuery(start, end, mq.TraceStructure)
+	if err != nil {
+		return "", err
+	}
+
+	switch panelType {
+	case v3.PanelTypeList, v3.PanelTypeTrace:
+		query := "SELECT trace_start as timestamp_datetime, traceID, span_count, duration_nano from (" + subQuery + ") order by timestamp_datetime DESC"
+		if panelType == v3.PanelTypeTrace {
+			query = addLimitToQuery(query, mq.Limit)
+			if mq.Offset != 0 {
+				query = addOffsetToQuery(query, mq.Offset)
+			}
+		}
+		return query, nil
+	case v3.PanelTypeTable:
+		return "SELECT now() as ts, toFloat64(count()) as value from (" + subQuery + ")", nil
+	case v3.PanelTypeGraph, v3.PanelTypeValue:
+		return fmt.Sprintf("SELECT toStartOfInterval(trace_start, INTERVAL %d SECOND) AS ts, toFloat64(count()) as value from (%s) group by ts order by ts", step, subQuery), nil
+	default:
+		return "", fmt.Errorf("unsupported panel type %s for trace structure", panelType)
+	}
+}
@@ -0,0 +1,82 @@
+package v3
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	v3 "go.signoz.io/signoz/pkg/query-service/model/v3"
+)
+
+func traceStructureTestQuery(relations ...v3.SpanRelation) *v3.BuilderQuery {
+	return &v3.BuilderQuery{
+		QueryName:         "A",
+		Expression:        "A",
+		DataSource:        v3.DataSourceTraces,
+		AggregateOperator: v3.AggregateOperatorCount,
+		StepInterval:      60,
+		TraceStructure: &v3.TraceStructure{
+			Spans: []v3.SpanSelector{
+				{Name: "frontend", Filters: &v3.FilterSet{Operator: "AND", Items: []v3.FilterItem{
+					{Key: v3.AttributeKey{Key: "serviceName", DataType: v3.AttributeKeyDataTypeString, IsColumn: true}, Value: "frontend", Operator: "="},
+				}}},
+				{Name: "failing", Filters: &v3.FilterSet{Operator: "AND", Items: []v3.FilterItem{
+					{Key: v3.AttributeKey{Key: "serviceName", DataType: v3.AttributeKeyDataTypeString, IsColumn: true}, Value: "redis", Operator: "="},
+					{Key: v3.AttributeKey{Key: "hasError", DataType: v3.AttributeKeyDataTypeBool, IsColumn: true}, Value: true, Operator: "="},
+				}}},
+				{Name: "retry", Absent: true, Filters: &v3.FilterSet{Operator: "AND", Items: []v3.FilterItem{
+					{Key: v3.AttributeKey{Key: "name", DataType: v3.AttributeKeyDataTypeString, IsColumn: true}, Value: "retry", Operator: "="},
+				}}},
+			},
+			Relations: relations,
+		},
+	}
+}
+
+func TestBuildTraceStructureQuery(t *testing.T) {
+	start, end := int64(1680066360726), int64(1680066458000)
+	subQuery := "SELECT traceID, min(timestamp) as trace_start, count() as span_count, " +
+		"max(toUnixTimestamp64Nano(timestamp) + durationNano) - toUnixTimestamp64Nano(trace_start) as duration_nano, " +
+		"groupArray(spanID) as span_ids, groupArray(parentSpanID) as parent_ids, " +
+		"groupArrayIf(spanID, serviceName = 'frontend') as span_0_ids, " +
+		"groupArrayIf(spanID, serviceName = 'redis' AND hasError = true) as span_1_ids, " +
+		"countIf(name = 'retry') as span_2_count, " +
+		"arrayMap(x -> parent_ids[indexOf(span_ids, x)], span_1_ids) as relation_0_level_1 " +
+		"from signoz_traces.distributed_signoz_index_v2 where (timestamp >= '1680066360000000000' AND timestamp <= '1680066420000000000') " +
+		"group by traceID having notEmpty(span_0_ids) AND notEmpty(span_1_ids) AND span_2_count = 0 " +
+		"AND hasAny(span_0_ids, arrayConcat(relation_0_level_1))"
+
+	mq := traceStructureTestQuery(v3.SpanRelation{Parent: "frontend", Child: "failing", Direct: true})
+	require.NoError(t, mq.Validate(v3.PanelTypeGraph))
+
+	query, err := PrepareTracesQuery(start, end, v3.PanelTypeGraph, mq, Options{})
+	require.NoError(t, err)
+	assert.Equal(t, "SELECT toStartOfInterval(trace_start, INTERVAL 60 SECOND) AS ts, toFloat64(count()) as value from ("+subQuery+") group by ts order by ts", query)
+
+	query, err = PrepareTracesQuery(start, end, v3.PanelTypeList, mq, Options{})
+	require.NoError(t, err)
+	assert.Equal(t, "SELECT trace_start as timestamp_datetime, traceID, span_count, duration_nano from ("+subQuery+") order by timestamp_datetime DESC LIMIT 100", query)
+
+	query, err = PrepareTracesQuery(start, end, v3.PanelTypeTable, mq, Options{})
+	require.NoError(t, err)
+	assert.Equal(t, "SELECT now() as ts, toFloat64(count()) as value from ("+subQuery+") LIMIT 100", query)
+}
+
+func TestBuildTraceStructureQueryAncestors(t *testing.T) {
+	mq := traceStructureTestQuery(v3.SpanRelation{Parent: "frontend", Child: "failing"})
+	query, err := buildTraceStructureSubQuery(1680066360000, 1680066420000, mq.TraceStructure)
+	require.NoError(t, err)
+
+	// the ancestors are looked up level by level up to the max depth
+	assert.Contains(t, query, "arrayMap(x -> parent_ids[indexOf(span_ids, x)], span_1_ids) as relation_0_level_1")
+	assert.Contains(t, query, "arrayMap(x -> parent_ids[indexOf(span_ids, x)], relation_0_level_15) as relation_0_level_16")
+	assert.Equal(t, traceStructureMaxDepth, strings.Count(query, "arrayMap("))
+
+	// without relations the selectors are only counted
+	mq = traceStructureTestQuery()
+	query, err = buildTraceStructureSubQuery(1680066360000, 1680066420000, mq.TraceStructure)
+	require.NoError(t, err)
+	assert.NotContains(t, query, "groupArray")
+	assert.Contains(t, query, "having span_0_count > 0 AND span_1_count > 0 AND span_2_count = 0")
+}
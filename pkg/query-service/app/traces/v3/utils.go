@@ -56,8 +56,8 @@ func TraceIdFilterUsedWithEqual(params *v3.QueryRangeParamsV3) (bool, []string)
 			continue
 		}
 
-		// check filter attribute
-		if query.Filters != nil && len(query.Filters.Items) != 0 {
+		// check filter attribute, the trace ids only restrict the spans when all of the set has to match
+		if query.Filters != nil && len(query.Filters.Items) != 0 && query.Filters.IsConjunction() {
 			for _, item := range query.Filters.Items {
 
 				if item.Key.Key == "traceID" && (item.Operator == v3.FilterOperatorIn ||
@@ -168,8 +168,8 @@ func AddTimestampFilters(minTime int64, maxTime int64, params *v3.QueryRangePara
 
 		addTimeStampFilter := false
 
-		// check filter attribute
-		if query.Filters != nil && len(query.Filters.Items) != 0 {
+		// check filter attribute, the trace ids only restrict the spans when all of the set has to match
+		if query.Filters != nil && len(query.Filters.Items) != 0 && query.Filters.IsConjunction() {
 			for _, item := range query.Filters.Items {
 				if item.Key.Key == "traceID" && (item.Operator == v3.FilterOperatorIn ||
 					item.Operator == v3.FilterOperatorEqual) {
@@ -209,7 +209,7 @@ func AddTimestampFilters(minTime int64, maxTime int64, params *v3.QueryRangePara
 					Items: timeFilters,
 				}
 			} else {
-				query.Filters.Items = append(query.Filters.Items, timeFilters...)
+				query.Filters.AndItems(timeFilters...)
 			}
 		}
 	}
@@ -7,6 +7,7 @@ import (
 	inmemory "go.signoz.io/signoz/pkg/query-service/cache/inmemory"
 	redis "go.signoz.io/signoz/pkg/query-service/cache/redis"
 	"go.signoz.io/signoz/pkg/query-service/cache/status"
+	"go.signoz.io/signoz/pkg/query-service/cache/tiered"
 	v3 "go.signoz.io/signoz/pkg/query-service/model/v3"
 	"gopkg.in/yaml.v2"
 )
@@ -16,6 +17,7 @@ type Options struct {
 	Provider string            `yaml:"provider"`
 	Redis    *redis.Options    `yaml:"redis,omitempty"`
 	InMemory *inmemory.Options `yaml:"inmemory,omitempty"`
+	Tiered   *tiered.Options   `yaml:"tiered,omitempty"`
 }
 
 // Cache is the interface for the storage backend
@@ -29,6 +31,16 @@ type Cache interface {
 	Close() error
 }
 
+// Inspectable is implemented by the caches whose entries and stats can be inspected
+type Inspectable interface {
+	Stats() tiered.Stats
+	Entries() ([]tiered.Entry, error)
+	// Entry returns the entry with the hash of the key, nil if there is none
+	Entry(hash string) (*tiered.Entry, error)
+	// Peek retrieves the data of the entry without changing the cache
+	Peek(cacheKey string) ([]byte, status.RetrieveStatus, error)
+}
+
 // KeyGenerator is the interface for the key generator
 // The key generator is used to generate the cache keys for the cache entries
 type KeyGenerator interface {
@@ -63,6 +75,9 @@ func NewCache(options *Options) Cache {
 		return redis.New(options.Redis)
 	case "inmemory":
 		return inmemory.New(options.InMemory)
+	case "tiered":
+		// the local cache is in front of redis
+		return tiered.New(options.Tiered, redis.New(options.Redis))
 	default:
 		return nil
 	}
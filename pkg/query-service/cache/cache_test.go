@@ -50,3 +50,14 @@ func TestLoadFromYAMLCacheConfigFile(t *testing.T) {
 		t.Fatalf("unexpected error: %s", err)
 	}
 }
+
+func TestNewCacheTiered(t *testing.T) {
+	c := NewCache(&Options{
+		Name:     "test",
+		Provider: "tiered",
+	})
+
+	if _, ok := c.(Inspectable); !ok {
+		t.Fatalf("expected an inspectable cache, got %T", c)
+	}
+}
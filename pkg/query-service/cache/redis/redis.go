@@ -11,6 +11,9 @@ import (
 	"go.uber.org/zap"
 )
 
+// scanCount is the number of keys scanned by each SCAN call
+const scanCount = 1000
+
 type cache struct {
 	client *redis.Client
 	opts   *Options
@@ -110,6 +113,18 @@ func (c *cache) GetKeys(pattern string) ([]string, error) {
 	return c.client.Keys(context.Background(), pattern).Result()
 }
 
+// ScanKeys returns the keys matching the pattern, unlike GetKeys the keys
+// are scanned in batches so the server isn't blocked
+func (c *cache) ScanKeys(pattern string) ([]string, error) {
+	ctx := context.Background()
+	keys := []string{}
+	iter := c.client.Scan(ctx, 0, pattern, scanCount).Iterator()
+	for iter.Next(ctx) {
+		keys = append(keys, iter.Val())
+	}
+	return keys, iter.Err()
+}
+
 // GetKeysWithTTL returns the keys matching the pattern with their TTL
 func (c *cache) GetKeysWithTTL(pattern string) (map[string]time.Duration, error) {
 	keys, err := c.GetKeys(pattern)
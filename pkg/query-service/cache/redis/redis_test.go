@@ -89,3 +89,22 @@ func TestBulkRemove(t *testing.T) {
 		t.Errorf("there were unfulfilled expectations: %s", err)
 	}
 }
+
+func TestScanKeys(t *testing.T) {
+	db, mock := redismock.NewClientMock()
+	c := WithClient(db)
+
+	mock.ExpectScan(0, "query_cache:*", scanCount).SetVal([]string{"query_cache:a"}, 5)
+	mock.ExpectScan(5, "query_cache:*", scanCount).SetVal([]string{"query_cache:b"}, 0)
+	keys, err := c.ScanKeys("query_cache:*")
+	if err != nil {
+		t.Errorf("unexpected error: %s", err)
+	}
+	if len(keys) != 2 || keys[0] != "query_cache:a" || keys[1] != "query_cache:b" {
+		t.Errorf("expected the keys of both scans, got %v", keys)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %s", err)
+	}
+}
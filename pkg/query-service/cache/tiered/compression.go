@@ -0,0 +1,38 @@
+package tiered
+
+import (
+	"bytes"
+	"compress/gzip"
+	"io"
+)
+
+// the gzip payloads start with the gzip magic number, the JSON payloads of the
+// uncompressed entries never do so both can be read from the same cache
+var gzipMagic = []byte{0x1f, 0x8b}
+
+func compress(data []byte, compression string, minSize int) ([]byte, error) {
+	if compression != CompressionGzip || len(data) < minSize {
+		return data, nil
+	}
+	var buf bytes.Buffer
+	writer := gzip.NewWriter(&buf)
+	if _, err := writer.Write(data); err != nil {
+		return nil, err
+	}
+	if err := writer.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func decompress(data []byte) ([]byte, error) {
+	if !bytes.HasPrefix(data, gzipMagic) {
+		return data, nil
+	}
+	reader, err := gzip.NewReader(bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+	defer reader.Close()
+	return io.ReadAll(reader)
+}
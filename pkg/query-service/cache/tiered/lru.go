@@ -0,0 +1,131 @@
+package tiered
+
+import (
+	"container/list"
+	"sync"
+	"time"
+)
+
+type lruEntry struct {
+	key       string
+	data      []byte
+	expiresAt time.Time
+}
+
+// lru is a least recently used cache bounded by the size of its entries
+type lru struct {
+	mu      sync.Mutex
+	maxSize int64
+	size    int64
+	order   *list.List
+	entries map[string]*list.Element
+	onEvict func(key string)
+}
+
+func newLRU(maxSize int64, onEvict func(key string)) *lru {
+	return &lru{
+		maxSize: maxSize,
+		order:   list.New(),
+		entries: make(map[string]*list.Element),
+		onEvict: onEvict,
+	}
+}
+
+func (l *lru) get(key string) ([]byte, bool) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	element, ok := l.entries[key]
+	if !ok {
+		return nil, false
+	}
+	entry := element.Value.(*lruEntry)
+	if !entry.expiresAt.IsZero() && time.Now().After(entry.expiresAt) {
+		l.removeElement(element)
+		return nil, false
+	}
+	l.order.MoveToFront(element)
+	return entry.data, true
+}
+
+// peek returns the data of the entry without marking it as recently used
+func (l *lru) peek(key string) ([]byte, bool) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	element, ok := l.entries[key]
+	if !ok {
+		return nil, false
+	}
+	entry := element.Value.(*lruEntry)
+	if !entry.expiresAt.IsZero() && time.Now().After(entry.expiresAt) {
+		return nil, false
+	}
+	return entry.data, true
+}
+
+// set adds the entry and evicts the least recently used entries until the
+// size of the entries is within the max size. The entries larger than the
+// max size are not added.
+func (l *lru) set(key string, data []byte, ttl time.Duration) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if element, ok := l.entries[key]; ok {
+		l.removeElement(element)
+	}
+	if int64(len(data)) > l.maxSize {
+		return
+	}
+	var expiresAt time.Time
+	if ttl > 0 {
+		expiresAt = time.Now().Add(ttl)
+	}
+	l.entries[key] = l.order.PushFront(&lruEntry{key: key, data: data, expiresAt: expiresAt})
+	l.size += int64(len(data))
+
+	for l.size > l.maxSize {
+		oldest := l.order.Back()
+		l.removeElement(oldest)
+		if l.onEvict != nil {
+			l.onEvict(oldest.Value.(*lruEntry).key)
+		}
+	}
+}
+
+// setTTL shortens the time to live of the entry if the ttl is shorter
+func (l *lru) setTTL(key string, ttl time.Duration) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	element, ok := l.entries[key]
+	if !ok || ttl <= 0 {
+		return
+	}
+	entry := element.Value.(*lruEntry)
+	if expiresAt := time.Now().Add(ttl); entry.expiresAt.IsZero() || expiresAt.Before(entry.expiresAt) {
+		entry.expiresAt = expiresAt
+	}
+}
+
+func (l *lru) remove(key string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if element, ok := l.entries[key]; ok {
+		l.removeElement(element)
+	}
+}
+
+func (l *lru) removeElement(element *list.Element) {
+	entry := element.Value.(*lruEntry)
+	l.order.Remove(element)
+	delete(l.entries, entry.key)
+	l.size -= int64(len(entry.data))
+}
+
+// snapshot returns the size of the entries and the size of each entry
+func (l *lru) snapshot() (int64, map[string]int) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	sizes := make(map[string]int, len(l.entries))
+	for key, element := range l.entries {
+		sizes[key] = len(element.Value.(*lruEntry).data)
+	}
+	return l.size, sizes
+}
@@ -0,0 +1,75 @@
+package tiered
+
+import (
+	"time"
+
+	"go.opentelemetry.io/otel/metric"
+)
+
+const (
+	defaultMaxSize            = 256 << 20
+	defaultTTL                = 5 * time.Minute
+	defaultCompression        = CompressionGzip
+	defaultMinCompressionSize = 1024
+	defaultKeyPrefix          = "query_cache:"
+)
+
+const (
+	CompressionNone = "none"
+	CompressionGzip = "gzip"
+)
+
+// Options holds the options for the tiered cache
+type Options struct {
+	// MaxSize is the maximum size in bytes of the entries of the local cache
+	MaxSize int64 `yaml:"maxSize,omitempty"`
+	// TTL is the maximum time to live of the entries of the local cache, the
+	// entries are read again from the remote cache after it
+	TTL time.Duration `yaml:"ttl,omitempty"`
+	// Compression is the compression of the stored payloads, gzip or none
+	Compression string `yaml:"compression,omitempty"`
+	// MinCompressionSize is the minimum size in bytes of a compressed payload
+	MinCompressionSize int `yaml:"minCompressionSize,omitempty"`
+	// KeyPrefix is the prefix of the keys of the remote cache, the entries
+	// are listed by scanning the keys with the prefix
+	KeyPrefix string `yaml:"keyPrefix,omitempty"`
+
+	// MeterProvider provides the meter of the cache metrics,
+	// the global meter provider is used if it is not set
+	MeterProvider metric.MeterProvider `yaml:"-"`
+}
+
+func defaultOptions() *Options {
+	return &Options{
+		MaxSize:            defaultMaxSize,
+		TTL:                defaultTTL,
+		Compression:        defaultCompression,
+		MinCompressionSize: defaultMinCompressionSize,
+		KeyPrefix:          defaultKeyPrefix,
+	}
+}
+
+// withDefaults returns a copy of the options with the defaults of the unset options
+func (o *Options) withDefaults() *Options {
+	opts := defaultOptions()
+	if o == nil {
+		return opts
+	}
+	if o.MaxSize > 0 {
+		opts.MaxSize = o.MaxSize
+	}
+	if o.TTL > 0 {
+		opts.TTL = o.TTL
+	}
+	if o.Compression != "" {
+		opts.Compression = o.Compression
+	}
+	if o.MinCompressionSize > 0 {
+		opts.MinCompressionSize = o.MinCompressionSize
+	}
+	if o.KeyPrefix != "" {
+		opts.KeyPrefix = o.KeyPrefix
+	}
+	opts.MeterProvider = o.MeterProvider
+	return opts
+}
@@ -0,0 +1,141 @@
+package tiered
+
+import (
+	"context"
+	"sort"
+	"strings"
+	"sync"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+	"go.uber.org/zap"
+)
+
+const meterName = "go.signoz.io/signoz/pkg/query-service/cache/tiered"
+
+const otherPrefix = "other"
+
+// PrefixStats are the counts of the cache requests of the keys with a prefix
+type PrefixStats struct {
+	Prefix     string `json:"prefix"`
+	LocalHits  int64  `json:"localHits"`
+	RemoteHits int64  `json:"remoteHits"`
+	Misses     int64  `json:"misses"`
+	Evictions  int64  `json:"evictions"`
+	Stores     int64  `json:"stores"`
+}
+
+// Stats are the stats of the cache since it was created
+type Stats struct {
+	// Entries and Size are the number and the size in bytes of the entries of the local cache
+	Entries int   `json:"entries"`
+	Size    int64 `json:"size"`
+	MaxSize int64 `json:"maxSize"`
+	// StoredBytes and CompressedBytes are the sizes of the stored payloads before and after the compression
+	StoredBytes     int64         `json:"storedBytes"`
+	CompressedBytes int64         `json:"compressedBytes"`
+	Prefixes        []PrefixStats `json:"prefixes"`
+}
+
+// KeyPrefix returns the prefix of the key the stats are grouped by. The keys
+// of the builder queries start with their data source, e.g. source=metrics,
+// the other keys like the PromQL queries have the prefix other.
+func KeyPrefix(key string) string {
+	prefix, _, found := strings.Cut(key, "&")
+	if !found || !strings.Contains(prefix, "=") || strings.ContainsAny(prefix, " {}") {
+		return otherPrefix
+	}
+	return prefix
+}
+
+type stats struct {
+	mu              sync.Mutex
+	prefixes        map[string]*PrefixStats
+	storedBytes     int64
+	compressedBytes int64
+
+	requests  metric.Int64Counter
+	evictions metric.Int64Counter
+}
+
+func newStats(meter metric.Meter) *stats {
+	s := &stats{prefixes: make(map[string]*PrefixStats)}
+	var err error
+	s.requests, err = meter.Int64Counter(
+		"signoz_query_cache_requests",
+		metric.WithDescription("The number of query cache requests by key prefix and result"),
+	)
+	if err != nil {
+		zap.L().Error("error creating query cache requests counter", zap.Error(err))
+	}
+	s.evictions, err = meter.Int64Counter(
+		"signoz_query_cache_evictions",
+		metric.WithDescription("The number of entries evicted from the local query cache by key prefix"),
+	)
+	if err != nil {
+		zap.L().Error("error creating query cache evictions counter", zap.Error(err))
+	}
+	return s
+}
+
+func (s *stats) prefixStats(key string) *PrefixStats {
+	prefix := KeyPrefix(key)
+	if _, ok := s.prefixes[prefix]; !ok {
+		s.prefixes[prefix] = &PrefixStats{Prefix: prefix}
+	}
+	return s.prefixes[prefix]
+}
+
+func (s *stats) recordRequest(key string, result string) {
+	s.mu.Lock()
+	prefixStats := s.prefixStats(key)
+	switch result {
+	case resultLocalHit:
+		prefixStats.LocalHits++
+	case resultRemoteHit:
+		prefixStats.RemoteHits++
+	default:
+		prefixStats.Misses++
+	}
+	s.mu.Unlock()
+
+	if s.requests != nil {
+		s.requests.Add(context.Background(), 1, metric.WithAttributes(
+			attribute.String("prefix", KeyPrefix(key)),
+			attribute.String("result", result),
+		))
+	}
+}
+
+func (s *stats) recordEviction(key string) {
+	s.mu.Lock()
+	s.prefixStats(key).Evictions++
+	s.mu.Unlock()
+
+	if s.evictions != nil {
+		s.evictions.Add(context.Background(), 1, metric.WithAttributes(attribute.String("prefix", KeyPrefix(key))))
+	}
+}
+
+func (s *stats) recordStore(key string, size, compressedSize int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.prefixStats(key).Stores++
+	s.storedBytes += int64(size)
+	s.compressedBytes += int64(compressedSize)
+}
+
+func (s *stats) snapshot() Stats {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	result := Stats{
+		StoredBytes:     s.storedBytes,
+		CompressedBytes: s.compressedBytes,
+		Prefixes:        make([]PrefixStats, 0, len(s.prefixes)),
+	}
+	for _, prefixStats := range s.prefixes {
+		result.Prefixes = append(result.Prefixes, *prefixStats)
+	}
+	sort.Slice(result.Prefixes, func(i, j int) bool { return result.Prefixes[i].Prefix < result.Prefixes[j].Prefix })
+	return result
+}
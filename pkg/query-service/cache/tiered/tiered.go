@@ -0,0 +1,306 @@
+package tiered
+
+import (
+	"context"
+	"fmt"
+	"hash/fnv"
+	"sort"
+	"strings"
+	"time"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/metric"
+	"go.signoz.io/signoz/pkg/query-service/cache/status"
+	"go.uber.org/zap"
+)
+
+const (
+	resultLocalHit  = "local_hit"
+	resultRemoteHit = "remote_hit"
+	resultMiss      = "miss"
+)
+
+// Remote is the shared cache behind the local cache, e.g. redis
+type Remote interface {
+	Connect() error
+	Store(cacheKey string, data []byte, ttl time.Duration) error
+	Retrieve(cacheKey string, allowExpired bool) ([]byte, status.RetrieveStatus, error)
+	SetTTL(cacheKey string, ttl time.Duration)
+	Remove(cacheKey string)
+	BulkRemove(cacheKeys []string)
+	Close() error
+}
+
+// keyLister is implemented by the remote caches that can list their keys
+type keyLister interface {
+	ScanKeys(pattern string) ([]string, error)
+}
+
+// Entry describes a cache entry. The size is known for the entries of the
+// local cache only.
+type Entry struct {
+	Hash   string `json:"hash"`
+	Key    string `json:"key"`
+	Prefix string `json:"prefix"`
+	Size   int    `json:"size,omitempty"`
+	Local  bool   `json:"local"`
+	Remote bool   `json:"remote"`
+}
+
+// cache is a size bounded local LRU cache in front of a remote cache. The
+// payloads are compressed before they are stored in both tiers. The remote
+// keys are prefixed with the key prefix of the options, along with each
+// entry the key is stored under its hash so the entries are found by hash.
+type cache struct {
+	opts   *Options
+	local  *lru
+	remote Remote
+	stats  *stats
+}
+
+// New creates a new tiered cache, without a remote cache it is only the local cache
+func New(opts *Options, remote Remote) *cache {
+	opts = opts.withDefaults()
+	if opts.Compression != CompressionGzip && opts.Compression != CompressionNone {
+		zap.L().Warn("unknown cache compression, the payloads are not compressed", zap.String("compression", opts.Compression))
+		opts.Compression = CompressionNone
+	}
+	meterProvider := opts.MeterProvider
+	if meterProvider == nil {
+		meterProvider = otel.GetMeterProvider()
+	}
+	meter := meterProvider.Meter(meterName)
+
+	c := &cache{opts: opts, remote: remote, stats: newStats(meter)}
+	c.local = newLRU(opts.MaxSize, c.stats.recordEviction)
+
+	_, err := meter.Int64ObservableGauge(
+		"signoz_query_cache_local_size",
+		metric.WithDescription("The size in bytes of the entries of the local query cache"),
+		metric.WithUnit("By"),
+		metric.WithInt64Callback(func(_ context.Context, observer metric.Int64Observer) error {
+			size, _ := c.local.snapshot()
+			observer.Observe(size)
+			return nil
+		}),
+	)
+	if err != nil {
+		zap.L().Error("error creating query cache size gauge", zap.Error(err))
+	}
+	return c
+}
+
+// Connect connects to the remote cache
+func (c *cache) Connect() error {
+	if c.remote == nil {
+		return nil
+	}
+	return c.remote.Connect()
+}
+
+// Store stores the compressed data in the local and the remote cache
+func (c *cache) Store(cacheKey string, data []byte, ttl time.Duration) error {
+	compressed, err := compress(data, c.opts.Compression, c.opts.MinCompressionSize)
+	if err != nil {
+		return fmt.Errorf("error compressing cache entry: %w", err)
+	}
+	c.stats.recordStore(cacheKey, len(data), len(compressed))
+	c.local.set(cacheKey, compressed, c.localTTL(ttl))
+	if c.remote == nil {
+		return nil
+	}
+	if err := c.remote.Store(c.entryKey(cacheKey), compressed, ttl); err != nil {
+		return err
+	}
+	return c.remote.Store(c.hashKey(KeyHash(cacheKey)), []byte(cacheKey), ttl)
+}
+
+// Retrieve retrieves the data from the local cache, or from the remote cache
+// and adds it to the local cache
+func (c *cache) Retrieve(cacheKey string, allowExpired bool) ([]byte, status.RetrieveStatus, error) {
+	data, ok := c.local.get(cacheKey)
+	result := resultLocalHit
+	if !ok {
+		if c.remote == nil {
+			c.stats.recordRequest(cacheKey, resultMiss)
+			return nil, status.RetrieveStatusKeyMiss, nil
+		}
+		var retrieveStatus status.RetrieveStatus
+		var err error
+		data, retrieveStatus, err = c.remote.Retrieve(c.entryKey(cacheKey), allowExpired)
+		if err != nil || retrieveStatus != status.RetrieveStatusHit {
+			c.stats.recordRequest(cacheKey, resultMiss)
+			return nil, retrieveStatus, err
+		}
+		result = resultRemoteHit
+		c.local.set(cacheKey, data, c.opts.TTL)
+	}
+	c.stats.recordRequest(cacheKey, result)
+
+	decompressed, err := decompress(data)
+	if err != nil {
+		return nil, status.RetrieveStatusError, fmt.Errorf("error decompressing cache entry: %w", err)
+	}
+	return decompressed, status.RetrieveStatusHit, nil
+}
+
+// Peek retrieves the data like Retrieve without counting the request and
+// without adding the entry of the remote cache to the local cache
+func (c *cache) Peek(cacheKey string) ([]byte, status.RetrieveStatus, error) {
+	data, ok := c.local.peek(cacheKey)
+	if !ok {
+		if c.remote == nil {
+			return nil, status.RetrieveStatusKeyMiss, nil
+		}
+		var retrieveStatus status.RetrieveStatus
+		var err error
+		data, retrieveStatus, err = c.remote.Retrieve(c.entryKey(cacheKey), true)
+		if err != nil || retrieveStatus != status.RetrieveStatusHit {
+			return nil, retrieveStatus, err
+		}
+	}
+	decompressed, err := decompress(data)
+	if err != nil {
+		return nil, status.RetrieveStatusError, fmt.Errorf("error decompressing cache entry: %w", err)
+	}
+	return decompressed, status.RetrieveStatusHit, nil
+}
+
+// SetTTL sets the TTL for the cache entry
+func (c *cache) SetTTL(cacheKey string, ttl time.Duration) {
+	c.local.setTTL(cacheKey, ttl)
+	if c.remote != nil {
+		c.remote.SetTTL(c.entryKey(cacheKey), ttl)
+		c.remote.SetTTL(c.hashKey(KeyHash(cacheKey)), ttl)
+	}
+}
+
+// Remove removes the cache entry
+func (c *cache) Remove(cacheKey string) {
+	c.BulkRemove([]string{cacheKey})
+}
+
+// BulkRemove removes the cache entries from the local cache of this instance
+// and from the remote cache. The local caches of the other instances keep
+// their copies until the TTL of the local cache expires.
+func (c *cache) BulkRemove(cacheKeys []string) {
+	if len(cacheKeys) == 0 {
+		return
+	}
+	remoteKeys := make([]string, 0, 2*len(cacheKeys))
+	for _, cacheKey := range cacheKeys {
+		c.local.remove(cacheKey)
+		remoteKeys = append(remoteKeys, c.entryKey(cacheKey), c.hashKey(KeyHash(cacheKey)))
+	}
+	if c.remote != nil {
+		c.remote.BulkRemove(remoteKeys)
+	}
+}
+
+// Close closes the remote cache
+func (c *cache) Close() error {
+	if c.remote == nil {
+		return nil
+	}
+	return c.remote.Close()
+}
+
+// Stats returns the stats of the cache
+func (c *cache) Stats() Stats {
+	stats := c.stats.snapshot()
+	size, sizes := c.local.snapshot()
+	stats.Entries = len(sizes)
+	stats.Size = size
+	stats.MaxSize = c.opts.MaxSize
+	return stats
+}
+
+// Entries returns the entries of the local cache and the keys of the remote
+// cache if it can list them
+func (c *cache) Entries() ([]Entry, error) {
+	entries := make(map[string]*Entry)
+	_, sizes := c.local.snapshot()
+	for key, size := range sizes {
+		entries[key] = &Entry{Hash: KeyHash(key), Key: key, Prefix: KeyPrefix(key), Size: size, Local: true}
+	}
+	if lister, ok := c.remote.(keyLister); ok {
+		prefix := c.entryKey("")
+		keys, err := lister.ScanKeys(prefix + "*")
+		if err != nil {
+			return nil, err
+		}
+		for _, key := range keys {
+			key = strings.TrimPrefix(key, prefix)
+			if _, ok := entries[key]; !ok {
+				entries[key] = &Entry{Hash: KeyHash(key), Key: key, Prefix: KeyPrefix(key)}
+			}
+			entries[key].Remote = true
+		}
+	}
+
+	result := make([]Entry, 0, len(entries))
+	for _, entry := range entries {
+		result = append(result, *entry)
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].Key < result[j].Key })
+	return result, nil
+}
+
+// Entry returns the entry with the hash, nil if there is none. The key of
+// the hash is looked up in the remote cache, the local cache is searched
+// without a remote cache.
+func (c *cache) Entry(hash string) (*Entry, error) {
+	var entry *Entry
+	if c.remote == nil {
+		_, sizes := c.local.snapshot()
+		for key := range sizes {
+			if KeyHash(key) == hash {
+				entry = &Entry{Hash: hash, Key: key, Prefix: KeyPrefix(key)}
+				break
+			}
+		}
+	} else {
+		key, retrieveStatus, err := c.remote.Retrieve(c.hashKey(hash), true)
+		if err != nil {
+			return nil, err
+		}
+		if retrieveStatus == status.RetrieveStatusHit {
+			entry = &Entry{Hash: hash, Key: string(key), Prefix: KeyPrefix(string(key)), Remote: true}
+		}
+	}
+	if entry == nil {
+		return nil, nil
+	}
+	if data, ok := c.local.peek(entry.Key); ok {
+		entry.Local = true
+		entry.Size = len(data)
+	}
+	return entry, nil
+}
+
+// entryKey is the key of the entry in the remote cache
+func (c *cache) entryKey(cacheKey string) string {
+	return c.opts.KeyPrefix + "entry:" + cacheKey
+}
+
+// hashKey is the key in the remote cache of the key of the entry with the hash
+func (c *cache) hashKey(hash string) string {
+	return c.opts.KeyPrefix + "hash:" + hash
+}
+
+// localTTL returns the TTL of the local cache entries, which is never longer
+// than the TTL of the options so that the changes to the remote entries are seen
+func (c *cache) localTTL(ttl time.Duration) time.Duration {
+	if ttl <= 0 || ttl > c.opts.TTL {
+		return c.opts.TTL
+	}
+	return ttl
+}
+
+// KeyHash returns the hash of a cache key, the entries are identified by it in
+// the admin APIs since the keys of the builder queries are long
+func KeyHash(key string) string {
+	h := fnv.New64a()
+	h.Write([]byte(key))
+	return fmt.Sprintf("%016x", h.Sum64())
+}
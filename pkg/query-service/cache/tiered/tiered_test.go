@@ -0,0 +1,210 @@
+package tiered
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.signoz.io/signoz/pkg/query-service/cache/inmemory"
+	"go.signoz.io/signoz/pkg/query-service/cache/status"
+)
+
+const metricsKey = "source=metrics&step=60&aggregate=sum"
+
+// remoteKey is the key of the entry in the remote cache
+func remoteKey(key string) string {
+	return defaultKeyPrefix + "entry:" + key
+}
+
+// listingRemote is a remote cache that lists its keys
+type listingRemote struct {
+	Remote
+	keys map[string]struct{}
+}
+
+func newListingRemote() *listingRemote {
+	return &listingRemote{Remote: inmemory.New(nil), keys: map[string]struct{}{}}
+}
+
+func (r *listingRemote) Store(cacheKey string, data []byte, ttl time.Duration) error {
+	r.keys[cacheKey] = struct{}{}
+	return r.Remote.Store(cacheKey, data, ttl)
+}
+
+func (r *listingRemote) BulkRemove(cacheKeys []string) {
+	for _, key := range cacheKeys {
+		delete(r.keys, key)
+	}
+	r.Remote.BulkRemove(cacheKeys)
+}
+
+func (r *listingRemote) ScanKeys(pattern string) ([]string, error) {
+	keys := []string{}
+	for key := range r.keys {
+		if strings.HasPrefix(key, strings.TrimSuffix(pattern, "*")) {
+			keys = append(keys, key)
+		}
+	}
+	return keys, nil
+}
+
+// TestStoreRetrieve tests that the payloads are compressed in both tiers
+func TestStoreRetrieve(t *testing.T) {
+	remote := inmemory.New(nil)
+	c := New(&Options{MinCompressionSize: 10}, remote)
+	payload := []byte(strings.Repeat(`{"start":1,"end":2,"data":[]}`, 100))
+
+	require.NoError(t, c.Store(metricsKey, payload, 0))
+
+	stored, retrieveStatus, err := remote.Retrieve(remoteKey(metricsKey), false)
+	require.NoError(t, err)
+	assert.Equal(t, status.RetrieveStatusHit, retrieveStatus)
+	assert.True(t, bytes.HasPrefix(stored, gzipMagic))
+	assert.Less(t, len(stored), len(payload))
+
+	data, retrieveStatus, err := c.Retrieve(metricsKey, false)
+	require.NoError(t, err)
+	assert.Equal(t, status.RetrieveStatusHit, retrieveStatus)
+	assert.Equal(t, payload, data)
+
+	stats := c.Stats()
+	assert.Equal(t, 1, stats.Entries)
+	assert.Equal(t, int64(len(payload)), stats.StoredBytes)
+	assert.Equal(t, int64(len(stored)), stats.CompressedBytes)
+}
+
+// TestRetrieveUncompressed tests that the entries stored without compression can be read
+func TestRetrieveUncompressed(t *testing.T) {
+	remote := inmemory.New(nil)
+	require.NoError(t, remote.Store(remoteKey("key"), []byte(`[{"start":1}]`), 0))
+	c := New(nil, remote)
+
+	data, retrieveStatus, err := c.Retrieve("key", false)
+	require.NoError(t, err)
+	assert.Equal(t, status.RetrieveStatusHit, retrieveStatus)
+	assert.Equal(t, []byte(`[{"start":1}]`), data)
+}
+
+// TestRetrieveTiers tests the hits of the local and the remote cache
+func TestRetrieveTiers(t *testing.T) {
+	remote := inmemory.New(nil)
+	require.NoError(t, New(nil, remote).Store(metricsKey, []byte("value"), 0))
+
+	// another instance shares the remote cache only
+	c := New(nil, remote)
+	for i := 0; i < 2; i++ {
+		data, retrieveStatus, err := c.Retrieve(metricsKey, false)
+		require.NoError(t, err)
+		assert.Equal(t, status.RetrieveStatusHit, retrieveStatus)
+		assert.Equal(t, []byte("value"), data)
+	}
+	_, retrieveStatus, err := c.Retrieve("source=logs&step=60", false)
+	require.NoError(t, err)
+	assert.Equal(t, status.RetrieveStatusKeyMiss, retrieveStatus)
+
+	assert.Equal(t, []PrefixStats{
+		{Prefix: "source=logs", Misses: 1},
+		{Prefix: "source=metrics", LocalHits: 1, RemoteHits: 1},
+	}, c.Stats().Prefixes)
+
+	c.Remove(metricsKey)
+	_, retrieveStatus, err = remote.Retrieve(remoteKey(metricsKey), false)
+	require.NoError(t, err)
+	assert.Equal(t, status.RetrieveStatusKeyMiss, retrieveStatus)
+}
+
+// TestEviction tests that the local cache is bounded by the max size
+func TestEviction(t *testing.T) {
+	c := New(&Options{MaxSize: 10, Compression: CompressionNone}, nil)
+	require.NoError(t, c.Store("source=logs&a", []byte("12345"), 0))
+	require.NoError(t, c.Store("source=logs&b", []byte("12345"), 0))
+	require.NoError(t, c.Store("source=logs&c", []byte("12345"), 0))
+	// larger than the max size
+	require.NoError(t, c.Store("source=logs&d", []byte("12345678901"), 0))
+
+	_, retrieveStatus, _ := c.Retrieve("source=logs&a", false)
+	assert.Equal(t, status.RetrieveStatusKeyMiss, retrieveStatus)
+	_, retrieveStatus, _ = c.Retrieve("source=logs&c", false)
+	assert.Equal(t, status.RetrieveStatusHit, retrieveStatus)
+	_, retrieveStatus, _ = c.Retrieve("source=logs&d", false)
+	assert.Equal(t, status.RetrieveStatusKeyMiss, retrieveStatus)
+
+	stats := c.Stats()
+	assert.Equal(t, 2, stats.Entries)
+	assert.Equal(t, int64(10), stats.Size)
+	assert.Equal(t, int64(1), stats.Prefixes[0].Evictions)
+
+	entries, err := c.Entries()
+	require.NoError(t, err)
+	require.Len(t, entries, 2)
+	assert.Equal(t, Entry{Hash: KeyHash("source=logs&b"), Key: "source=logs&b", Prefix: "source=logs", Size: 5, Local: true}, entries[0])
+}
+
+// TestLocalTTL tests that the local entries expire after the TTL of the options
+func TestLocalTTL(t *testing.T) {
+	remote := inmemory.New(nil)
+	c := New(&Options{TTL: 100 * time.Millisecond}, remote)
+	require.NoError(t, c.Store(metricsKey, []byte("value"), 0))
+	require.NoError(t, remote.Store(remoteKey(metricsKey), []byte("updated"), 0))
+
+	time.Sleep(200 * time.Millisecond)
+	data, _, err := c.Retrieve(metricsKey, false)
+	require.NoError(t, err)
+	assert.Equal(t, []byte("updated"), data)
+}
+
+// TestEntries tests that the entries are listed and found by hash without
+// changing the cache
+func TestEntries(t *testing.T) {
+	remote := newListingRemote()
+	require.NoError(t, New(nil, remote).Store(metricsKey, []byte("value"), 0))
+	// keys of others sharing the remote cache are not listed
+	require.NoError(t, remote.Store("session", []byte("value"), 0))
+
+	c := New(&Options{Compression: CompressionNone}, remote)
+	require.NoError(t, c.Store("source=logs&step=60", []byte("logs"), 0))
+
+	entries, err := c.Entries()
+	require.NoError(t, err)
+	assert.Equal(t, []Entry{
+		{Hash: KeyHash("source=logs&step=60"), Key: "source=logs&step=60", Prefix: "source=logs", Size: 4, Local: true, Remote: true},
+		{Hash: KeyHash(metricsKey), Key: metricsKey, Prefix: "source=metrics", Remote: true},
+	}, entries)
+
+	entry, err := c.Entry(KeyHash(metricsKey))
+	require.NoError(t, err)
+	assert.Equal(t, &Entry{Hash: KeyHash(metricsKey), Key: metricsKey, Prefix: "source=metrics", Remote: true}, entry)
+
+	data, retrieveStatus, err := c.Peek(metricsKey)
+	require.NoError(t, err)
+	assert.Equal(t, status.RetrieveStatusHit, retrieveStatus)
+	assert.Equal(t, []byte("value"), data)
+	// peeking doesn't count or add the entry to the local cache
+	for _, prefix := range c.Stats().Prefixes {
+		assert.NotEqual(t, "source=metrics", prefix.Prefix)
+	}
+	assert.Equal(t, 1, c.Stats().Entries)
+
+	c.Remove(metricsKey)
+	entry, err = c.Entry(KeyHash(metricsKey))
+	require.NoError(t, err)
+	assert.Nil(t, entry)
+
+	// without a remote cache the local cache is searched
+	local := New(nil, nil)
+	require.NoError(t, local.Store(metricsKey, []byte("value"), 0))
+	entry, err = local.Entry(KeyHash(metricsKey))
+	require.NoError(t, err)
+	require.NotNil(t, entry)
+	assert.True(t, entry.Local)
+	assert.Equal(t, metricsKey, entry.Key)
+}
+
+func TestKeyPrefix(t *testing.T) {
+	assert.Equal(t, "source=metrics", KeyPrefix(metricsKey))
+	assert.Equal(t, "other", KeyPrefix(`sum(rate(signoz_calls_total{service_name="frontend"}[5m]))`))
+	assert.Equal(t, "other", KeyPrefix(`up{job="a&b"}`))
+}
@@ -19,7 +19,7 @@ func AdjustedMetricTimeRange(start, end, step int64, mq v3.BuilderQuery) (int64,
 	// so that we can calculate the rate for the first data point
 	hasRunningDiff := false
 	for _, fn := range mq.Functions {
-		if fn.Name == v3.FunctionNameRunningDiff {
+		if fn.Name == v3.FunctionNameRunningDiff || fn.Name == v3.FunctionNameDerivative {
 			hasRunningDiff = true
 			break
 		}
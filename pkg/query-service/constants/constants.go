@@ -69,6 +69,18 @@ var InviteEmailTemplate = GetOrDefaultEnv("INVITE_EMAIL_TEMPLATE", "/root/templa
 // Alert manager channel subpath
 var AmChannelApiPath = GetOrDefaultEnv("ALERTMANAGER_API_CHANNEL_PATH", "v1/routes")
 
+// Alert manager mode, "external" sends alerts to the alertmanager at
+// ALERTMANAGER_API_PREFIX and "native" dispatches notifications from
+// the query service itself
+var AlertManagerMode = GetOrDefaultEnv("ALERTMANAGER_MODE", "external")
+
+// Optional yaml file with the route and inhibition config of the native alert manager
+var NativeAlertManagerConfigPath = GetOrDefaultEnv("ALERTMANAGER_NATIVE_CONFIG_PATH", "")
+
+func IsNativeAlertManagerEnabled() bool {
+	return AlertManagerMode == "native"
+}
+
 var OTLPTarget = GetOrDefaultEnv("OTEL_EXPORTER_OTLP_ENDPOINT", "")
 var LogExportBatchSize = GetOrDefaultEnv("OTEL_BLRP_MAX_EXPORT_BATCH_SIZE", "512")
 
@@ -350,6 +362,21 @@ var ReservedColumnTargetAliases = map[string]struct{}{
 const LogsPPLPfx = "signozlogspipeline/pipeline_"
 const OldLogsPPLPfx = "logstransform/pipeline_"
 
+// processors dropping the logs marked by the drop and sample operators of log pipelines
+const LogsPPLDropProcessor = "filter/signoz_logs_pipelines_drop"
+const LogsPPLCleanupProcessor = "transform/signoz_logs_pipelines_cleanup"
+
+// processor masking the values in the logs marked by the mask operators of log pipelines
+const LogsPPLMaskProcessor = "transform/signoz_logs_pipelines_mask"
+
+// connector producing the metrics derived from logs, it's an exporter of the
+// logs pipeline and a receiver of the metrics pipeline
+const LogMetricsConnector = "signaltometrics/signoz_log_metrics"
+
+// set in the agent description when the collector has the connector producing
+// the log metrics. values: 1 (true) or 0 (false)
+const LogMetricsConnectorCapability = "capabilities.signaltometrics"
+
 const IntegrationPipelineIdPrefix = "integration"
 
 // The datatype present here doesn't represent the actual datatype of column in the logs table.
@@ -0,0 +1,59 @@
+package errors
+
+import (
+	"errors"
+	"fmt"
+)
+
+const (
+	QueryLimitRowsScanned   = "rowsScanned"
+	QueryLimitTimeRange     = "timeRange"
+	QueryLimitSeries        = "series"
+	QueryLimitExecutionTime = "executionTime"
+)
+
+// QueryLimitError is returned when a query exceeds one of the configured
+// query limits of the role of the user, the durations are in milliseconds
+type QueryLimitError struct {
+	Limit  string `json:"limit"`
+	Max    uint64 `json:"max"`
+	Actual uint64 `json:"actual"`
+	Role   string `json:"role,omitempty"`
+	// OverrideHeader is the request header an admin can set to skip the limits
+	OverrideHeader string `json:"overrideHeader,omitempty"`
+}
+
+func (e *QueryLimitError) Error() string {
+	var msg string
+	switch e.Limit {
+	case QueryLimitRowsScanned:
+		msg = fmt.Sprintf("query would scan about %d rows, the limit is %d rows, try applying filters such as service.name, etc. or a shorter time range", e.Actual, e.Max)
+	case QueryLimitTimeRange:
+		msg = fmt.Sprintf("query time range of %dms exceeds the limit of %dms", e.Actual, e.Max)
+	case QueryLimitSeries:
+		msg = fmt.Sprintf("query returned %d series, the limit is %d series, try adding a limit or fewer group by attributes", e.Actual, e.Max)
+	case QueryLimitExecutionTime:
+		msg = fmt.Sprintf("query exceeded the execution time limit of %dms", e.Max)
+	default:
+		msg = fmt.Sprintf("query exceeded the %s limit of %d", e.Limit, e.Max)
+	}
+	if e.Role != "" {
+		msg += fmt.Sprintf(" for role %s", e.Role)
+	}
+	return msg
+}
+
+func IsQueryLimitError(err error) bool {
+	return AsQueryLimitError(err) != nil
+}
+
+// AsQueryLimitError returns the first query limit error found in errs
+func AsQueryLimitError(errs ...error) *QueryLimitError {
+	for _, err := range errs {
+		var target *QueryLimitError
+		if err != nil && errors.As(err, &target) {
+			return target
+		}
+	}
+	return nil
+}
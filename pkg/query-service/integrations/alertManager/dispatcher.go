@@ -0,0 +1,340 @@
+package alertManager
+
+import (
+	"context"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// RouteConfig controls how alerts are grouped into notifications
+type RouteConfig struct {
+	// labels to group alerts by, "..." groups by all labels
+	GroupBy []string `yaml:"group_by" json:"group_by"`
+	// how long to wait to buffer alerts of a new group before the first notification
+	GroupWait time.Duration `yaml:"group_wait" json:"group_wait"`
+	// how long to wait before notifying about changes in a group
+	GroupInterval time.Duration `yaml:"group_interval" json:"group_interval"`
+	// how long to wait before re-sending a notification that has not changed
+	RepeatInterval time.Duration `yaml:"repeat_interval" json:"repeat_interval"`
+}
+
+const groupByAll = "..."
+
+func defaultRouteConfig() RouteConfig {
+	return RouteConfig{
+		GroupBy:        []string{"alertname"},
+		GroupWait:      30 * time.Second,
+		GroupInterval:  5 * time.Minute,
+		RepeatInterval: 4 * time.Hour,
+	}
+}
+
+// dispatcher sorts the incoming alerts into aggregation groups, one per
+// receiver and group labels, and flushes the groups to the receiver
+// integrations on the route intervals
+type dispatcher struct {
+	mtx         sync.Mutex
+	route       RouteConfig
+	externalURL string
+	inhibitor   *inhibitor
+
+	// the latest state of all alerts, used for inhibition
+	alerts map[string]*Alert
+	groups map[string]*aggrGroup
+
+	// receivers returns the names of all the registered receivers
+	receivers func() []string
+	// integrations returns the integrations of a receiver
+	integrations func(receiver string) ([]integration, bool)
+
+	ctx    context.Context
+	cancel func()
+}
+
+func newDispatcher(route RouteConfig, ih *inhibitor, externalURL string, receivers func() []string, integrations func(string) ([]integration, bool)) *dispatcher {
+	ctx, cancel := context.WithCancel(context.Background())
+	return &dispatcher{
+		route:        route,
+		externalURL:  externalURL,
+		inhibitor:    ih,
+		alerts:       map[string]*Alert{},
+		groups:       map[string]*aggrGroup{},
+		receivers:    receivers,
+		integrations: integrations,
+		ctx:          ctx,
+		cancel:       cancel,
+	}
+}
+
+// put adds the alerts to their aggregation groups. Alerts without
+// receivers are sent to all the registered receivers.
+func (d *dispatcher) put(alerts ...*Alert) {
+	d.mtx.Lock()
+	defer d.mtx.Unlock()
+
+	now := time.Now()
+	for fp, a := range d.alerts {
+		if a.ResolvedAt(now) {
+			delete(d.alerts, fp)
+		}
+	}
+
+	for _, a := range alerts {
+		if a.ResolvedAt(now) {
+			delete(d.alerts, a.Fingerprint())
+		} else {
+			d.alerts[a.Fingerprint()] = a
+		}
+
+		receivers := a.Receivers
+		if len(receivers) == 0 {
+			receivers = d.receivers()
+		}
+
+		groupLabels := d.groupLabels(a)
+		for _, receiver := range receivers {
+			key := groupKey(receiver, groupLabels)
+			ag, ok := d.groups[key]
+			if !ok {
+				ag = newAggrGroup(d, key, receiver, groupLabels)
+				d.groups[key] = ag
+				go ag.run()
+			}
+			ag.insert(a)
+		}
+	}
+}
+
+// muted tells if the alert is inhibited by another alert at ts
+func (d *dispatcher) muted(a *Alert, ts time.Time) bool {
+	d.mtx.Lock()
+	defer d.mtx.Unlock()
+	return d.inhibitor.mutes(a, d.alerts, ts)
+}
+
+// removeIfEmpty drops the group once it has no alerts left, and
+// reports whether the group was dropped
+func (d *dispatcher) removeIfEmpty(ag *aggrGroup) bool {
+	d.mtx.Lock()
+	defer d.mtx.Unlock()
+
+	if !ag.empty() {
+		return false
+	}
+	delete(d.groups, ag.key)
+	return true
+}
+
+func (d *dispatcher) stop() {
+	d.cancel()
+}
+
+func (d *dispatcher) groupLabels(a *Alert) map[string]string {
+	lbls := kvFromLabels(a.Labels)
+	res := map[string]string{}
+	for _, name := range d.route.GroupBy {
+		if name == groupByAll {
+			return lbls
+		}
+		if v, ok := lbls[name]; ok {
+			res[name] = v
+		}
+	}
+	return res
+}
+
+func groupKey(receiver string, groupLabels map[string]string) string {
+	pairs := KV(groupLabels).SortedPairs()
+	parts := make([]string, 0, len(pairs))
+	for _, p := range pairs {
+		parts = append(parts, p.Name+"="+p.Value)
+	}
+	return receiver + ":{" + strings.Join(parts, ",") + "}"
+}
+
+// notifyEntry records what was last sent to an integration of the group
+type notifyEntry struct {
+	firing   map[string]struct{}
+	resolved map[string]struct{}
+	ts       time.Time
+}
+
+func isSubset(sub, set map[string]struct{}) bool {
+	for k := range sub {
+		if _, ok := set[k]; !ok {
+			return false
+		}
+	}
+	return true
+}
+
+// aggrGroup batches the alerts of a receiver that share the group labels
+type aggrGroup struct {
+	d        *dispatcher
+	key      string
+	receiver string
+	labels   map[string]string
+
+	mtx        sync.Mutex
+	alerts     map[string]*Alert
+	log        map[string]*notifyEntry
+	hasFlushed bool
+
+	flushNow chan struct{}
+}
+
+func newAggrGroup(d *dispatcher, key, receiver string, groupLabels map[string]string) *aggrGroup {
+	return &aggrGroup{
+		d:        d,
+		key:      key,
+		receiver: receiver,
+		labels:   groupLabels,
+		alerts:   map[string]*Alert{},
+		log:      map[string]*notifyEntry{},
+		flushNow: make(chan struct{}, 1),
+	}
+}
+
+func (ag *aggrGroup) insert(a *Alert) {
+	ag.mtx.Lock()
+	defer ag.mtx.Unlock()
+
+	ag.alerts[a.Fingerprint()] = a
+
+	// alerts that have been firing for longer than group wait
+	// are notified immediately on the first flush
+	if !ag.hasFlushed && a.StartsAt.Add(ag.d.route.GroupWait).Before(time.Now()) {
+		select {
+		case ag.flushNow <- struct{}{}:
+		default:
+		}
+	}
+}
+
+func (ag *aggrGroup) empty() bool {
+	ag.mtx.Lock()
+	defer ag.mtx.Unlock()
+	return len(ag.alerts) == 0
+}
+
+func (ag *aggrGroup) run() {
+	timer := time.NewTimer(ag.d.route.GroupWait)
+	defer timer.Stop()
+
+	for {
+		select {
+		case <-ag.d.ctx.Done():
+			return
+		case <-ag.flushNow:
+			if !timer.Stop() {
+				<-timer.C
+			}
+		case <-timer.C:
+		}
+
+		ag.flush(time.Now())
+		if ag.d.removeIfEmpty(ag) {
+			return
+		}
+		timer.Reset(ag.d.route.GroupInterval)
+	}
+}
+
+// flush notifies the integrations of the receiver about the group
+// alerts that changed since the last notification, or when the repeat
+// interval has passed
+func (ag *aggrGroup) flush(now time.Time) {
+	ag.mtx.Lock()
+	ag.hasFlushed = true
+	alerts := make([]*Alert, 0, len(ag.alerts))
+	for _, a := range ag.alerts {
+		alerts = append(alerts, a)
+	}
+	ag.mtx.Unlock()
+
+	sort.Slice(alerts, func(i, j int) bool {
+		return alerts[i].Fingerprint() < alerts[j].Fingerprint()
+	})
+
+	integrations, ok := ag.d.integrations(ag.receiver)
+	if !ok {
+		zap.L().Warn("dropping alerts for unknown receiver", zap.String("receiver", ag.receiver), zap.Int("count", len(alerts)))
+		ag.mtx.Lock()
+		ag.alerts = map[string]*Alert{}
+		ag.mtx.Unlock()
+		return
+	}
+
+	var firing, resolved []*Alert
+	firingSet, resolvedSet := map[string]struct{}{}, map[string]struct{}{}
+	for _, a := range alerts {
+		if a.ResolvedAt(now) {
+			resolved = append(resolved, a)
+			resolvedSet[a.Fingerprint()] = struct{}{}
+		} else if !ag.d.muted(a, now) {
+			firing = append(firing, a)
+			firingSet[a.Fingerprint()] = struct{}{}
+		}
+	}
+
+	success := true
+	for _, i := range integrations {
+		entry := ag.log[i.Name()]
+		if !ag.needsUpdate(entry, firingSet, resolvedSet, i.SendResolved(), now) {
+			continue
+		}
+
+		toSend := firing
+		if i.SendResolved() {
+			toSend = append(append([]*Alert{}, firing...), resolved...)
+		}
+		if len(toSend) > 0 {
+			data := newTemplateData(ag.receiver, ag.labels, ag.d.externalURL, now, toSend...)
+			ctx, cancel := context.WithTimeout(ag.d.ctx, 30*time.Second)
+			err := i.Notify(ctx, ag.key, data)
+			cancel()
+			if err != nil {
+				zap.L().Error("failed to send notification", zap.String("receiver", ag.receiver), zap.String("integration", i.Name()), zap.Error(err))
+				success = false
+				continue
+			}
+		}
+		ag.log[i.Name()] = &notifyEntry{firing: firingSet, resolved: resolvedSet, ts: now}
+	}
+
+	if !success {
+		return
+	}
+
+	// resolved alerts have been notified, drop them unless
+	// they were updated while the notifications were sent
+	ag.mtx.Lock()
+	defer ag.mtx.Unlock()
+	for _, a := range resolved {
+		fp := a.Fingerprint()
+		if cur, ok := ag.alerts[fp]; ok && cur == a {
+			delete(ag.alerts, fp)
+		}
+	}
+}
+
+func (ag *aggrGroup) needsUpdate(entry *notifyEntry, firing, resolved map[string]struct{}, sendResolved bool, now time.Time) bool {
+	if entry == nil {
+		return len(firing) > 0
+	}
+	if !isSubset(firing, entry.firing) {
+		return true
+	}
+	if len(firing) == 0 {
+		// notify once that everything has resolved
+		return len(entry.firing) > 0
+	}
+	if sendResolved && !isSubset(resolved, entry.resolved) {
+		return true
+	}
+	return !entry.ts.After(now.Add(-ag.d.route.RepeatInterval))
+}
@@ -0,0 +1,114 @@
+package alertManager
+
+import (
+	"fmt"
+	"regexp"
+	"time"
+)
+
+// InhibitRule mutes the alerts matching the target matchers while an alert
+// matching the source matchers is firing. Labels listed in Equal must have
+// the same value on both the source and the target alert.
+type InhibitRule struct {
+	SourceMatch   map[string]string `yaml:"source_match" json:"source_match"`
+	SourceMatchRE map[string]string `yaml:"source_match_re" json:"source_match_re"`
+	TargetMatch   map[string]string `yaml:"target_match" json:"target_match"`
+	TargetMatchRE map[string]string `yaml:"target_match_re" json:"target_match_re"`
+	Equal         []string          `yaml:"equal" json:"equal"`
+}
+
+type matchers struct {
+	equal map[string]string
+	regex map[string]*regexp.Regexp
+}
+
+func newMatchers(equal, re map[string]string) (*matchers, error) {
+	m := &matchers{equal: equal, regex: map[string]*regexp.Regexp{}}
+	for name, expr := range re {
+		// anchored the same way as the alertmanager matchers
+		r, err := regexp.Compile("^(?:" + expr + ")$")
+		if err != nil {
+			return nil, fmt.Errorf("invalid regex for label %s: %v", name, err)
+		}
+		m.regex[name] = r
+	}
+	return m, nil
+}
+
+func (m *matchers) matches(lbls KV) bool {
+	for name, value := range m.equal {
+		if lbls[name] != value {
+			return false
+		}
+	}
+	for name, r := range m.regex {
+		if !r.MatchString(lbls[name]) {
+			return false
+		}
+	}
+	return true
+}
+
+type inhibitRule struct {
+	source *matchers
+	target *matchers
+	equal  []string
+}
+
+// inhibitor tells if an alert is muted by another firing alert
+type inhibitor struct {
+	rules []*inhibitRule
+}
+
+func newInhibitor(rules []InhibitRule) (*inhibitor, error) {
+	ih := &inhibitor{}
+	for _, r := range rules {
+		source, err := newMatchers(r.SourceMatch, r.SourceMatchRE)
+		if err != nil {
+			return nil, err
+		}
+		target, err := newMatchers(r.TargetMatch, r.TargetMatchRE)
+		if err != nil {
+			return nil, err
+		}
+		ih.rules = append(ih.rules, &inhibitRule{source: source, target: target, equal: r.Equal})
+	}
+	return ih, nil
+}
+
+// mutes returns true if the target alert is inhibited by any of the
+// alerts firing at ts
+func (ih *inhibitor) mutes(target *Alert, alerts map[string]*Alert, ts time.Time) bool {
+	if len(ih.rules) == 0 {
+		return false
+	}
+
+	targetLbls := kvFromLabels(target.Labels)
+	targetFp := target.Fingerprint()
+
+	for _, r := range ih.rules {
+		if !r.target.matches(targetLbls) {
+			continue
+		}
+		for fp, source := range alerts {
+			if fp == targetFp || source.ResolvedAt(ts) {
+				continue
+			}
+			sourceLbls := kvFromLabels(source.Labels)
+			if !r.source.matches(sourceLbls) {
+				continue
+			}
+			equal := true
+			for _, name := range r.equal {
+				if sourceLbls[name] != targetLbls[name] {
+					equal = false
+					break
+				}
+			}
+			if equal {
+				return true
+			}
+		}
+	}
+	return false
+}
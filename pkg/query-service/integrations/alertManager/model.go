@@ -56,6 +56,11 @@ func (a *Alert) Hash() uint64 {
 	return a.Labels.Hash()
 }
 
+// Fingerprint returns the hex encoded hash of the alert labels.
+func (a *Alert) Fingerprint() string {
+	return fmt.Sprintf("%016x", a.Hash())
+}
+
 func (a *Alert) String() string {
 	s := fmt.Sprintf("%s[%s][%s]", a.Name(), fmt.Sprintf("%016x", a.Hash())[:7], a.Receivers)
 	if a.Resolved() {
@@ -0,0 +1,235 @@
+package alertManager
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	neturl "net/url"
+	"os"
+	"sort"
+	"sync"
+	"time"
+
+	"go.signoz.io/signoz/pkg/query-service/constants"
+	"go.signoz.io/signoz/pkg/query-service/model"
+	"go.signoz.io/signoz/pkg/query-service/utils/labels"
+	"go.uber.org/zap"
+	"gopkg.in/yaml.v2"
+)
+
+// NativeConfig is the config of the native alert manager, which
+// dispatches notifications from the query service instead of
+// forwarding the alerts to an external alertmanager
+type NativeConfig struct {
+	// url of the signoz frontend, used in notifications
+	ExternalURL  string        `yaml:"external_url" json:"external_url"`
+	Route        RouteConfig   `yaml:"route" json:"route"`
+	InhibitRules []InhibitRule `yaml:"inhibit_rules" json:"inhibit_rules"`
+}
+
+// LoadNativeConfig reads the native alert manager config from a yaml
+// file. Missing route settings are filled in with the defaults.
+func LoadNativeConfig(path string) (*NativeConfig, error) {
+	conf := &NativeConfig{}
+	if path != "" {
+		b, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read alert manager config: %v", err)
+		}
+		if err := yaml.Unmarshal(b, conf); err != nil {
+			return nil, fmt.Errorf("failed to parse alert manager config: %v", err)
+		}
+	}
+
+	defaults := defaultRouteConfig()
+	if len(conf.Route.GroupBy) == 0 {
+		conf.Route.GroupBy = defaults.GroupBy
+	}
+	if conf.Route.GroupWait == 0 {
+		conf.Route.GroupWait = defaults.GroupWait
+	}
+	if conf.Route.GroupInterval == 0 {
+		conf.Route.GroupInterval = defaults.GroupInterval
+	}
+	if conf.Route.RepeatInterval == 0 {
+		conf.Route.RepeatInterval = defaults.RepeatInterval
+	}
+	return conf, nil
+}
+
+var (
+	nativeOnce     sync.Once
+	nativeInstance *NativeManager
+	nativeErr      error
+)
+
+// GetManager returns the alert manager selected by the ALERTMANAGER_MODE
+// env. The native alert manager is shared by all callers so that the
+// channels and the alerts end up in the same place.
+func GetManager() (Manager, error) {
+	if !constants.IsNativeAlertManagerEnabled() {
+		return New()
+	}
+	nm, err := getNativeManager()
+	if err != nil {
+		return nil, err
+	}
+	return nm, nil
+}
+
+func getNativeManager() (*NativeManager, error) {
+	nativeOnce.Do(func() {
+		var conf *NativeConfig
+		conf, nativeErr = LoadNativeConfig(constants.NativeAlertManagerConfigPath)
+		if nativeErr != nil {
+			return
+		}
+		nativeInstance, nativeErr = NewNativeManager(conf)
+	})
+	return nativeInstance, nativeErr
+}
+
+type nativeReceiver struct {
+	receiver     *Receiver
+	integrations []integration
+}
+
+// NativeManager implements Manager by sending the notifications to the
+// channels directly
+type NativeManager struct {
+	mtx       sync.RWMutex
+	receivers map[string]*nativeReceiver
+
+	client     *http.Client
+	dispatcher *dispatcher
+}
+
+func NewNativeManager(conf *NativeConfig) (*NativeManager, error) {
+	ih, err := newInhibitor(conf.InhibitRules)
+	if err != nil {
+		return nil, err
+	}
+
+	m := &NativeManager{
+		receivers: map[string]*nativeReceiver{},
+		client:    &http.Client{Timeout: 30 * time.Second},
+	}
+	m.dispatcher = newDispatcher(conf.Route, ih, conf.ExternalURL, m.receiverNames, m.integrations)
+
+	zap.L().Info("Starting native alert manager",
+		zap.Strings("group_by", conf.Route.GroupBy),
+		zap.Duration("group_wait", conf.Route.GroupWait),
+		zap.Duration("group_interval", conf.Route.GroupInterval),
+		zap.Duration("repeat_interval", conf.Route.RepeatInterval),
+		zap.Int("inhibit_rules", len(conf.InhibitRules)))
+	return m, nil
+}
+
+// URL is empty as there is no external alertmanager in native mode
+func (m *NativeManager) URL() *neturl.URL {
+	return &neturl.URL{}
+}
+
+func (m *NativeManager) URLPath(path string) *neturl.URL {
+	upath, err := neturl.Parse(path)
+	if err != nil {
+		return nil
+	}
+	return upath
+}
+
+func (m *NativeManager) receiverNames() []string {
+	m.mtx.RLock()
+	defer m.mtx.RUnlock()
+
+	names := make([]string, 0, len(m.receivers))
+	for name := range m.receivers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func (m *NativeManager) integrations(name string) ([]integration, bool) {
+	m.mtx.RLock()
+	defer m.mtx.RUnlock()
+
+	r, ok := m.receivers[name]
+	if !ok {
+		return nil, false
+	}
+	return r.integrations, true
+}
+
+func (m *NativeManager) AddRoute(receiver *Receiver) *model.ApiError {
+	integrations, err := buildIntegrations(receiver, m.client)
+	if err != nil {
+		zap.L().Error("Invalid receiver config", zap.String("receiver", receiver.Name), zap.Error(err))
+		return &model.ApiError{Typ: model.ErrorBadData, Err: err}
+	}
+
+	m.mtx.Lock()
+	defer m.mtx.Unlock()
+	m.receivers[receiver.Name] = &nativeReceiver{receiver: receiver, integrations: integrations}
+	return nil
+}
+
+func (m *NativeManager) EditRoute(receiver *Receiver) *model.ApiError {
+	m.mtx.RLock()
+	_, ok := m.receivers[receiver.Name]
+	m.mtx.RUnlock()
+	if !ok {
+		return &model.ApiError{Typ: model.ErrorNotFound, Err: fmt.Errorf("receiver %s not found", receiver.Name)}
+	}
+	return m.AddRoute(receiver)
+}
+
+func (m *NativeManager) DeleteRoute(name string) *model.ApiError {
+	m.mtx.Lock()
+	defer m.mtx.Unlock()
+	delete(m.receivers, name)
+	return nil
+}
+
+// TestReceiver sends a test alert to all the configs of the receiver
+// right away, bypassing grouping
+func (m *NativeManager) TestReceiver(receiver *Receiver) *model.ApiError {
+	integrations, err := buildIntegrations(receiver, m.client)
+	if err != nil {
+		return &model.ApiError{Typ: model.ErrorBadData, Err: err}
+	}
+
+	now := time.Now()
+	alert := &Alert{
+		Labels: labels.FromMap(map[string]string{
+			labels.AlertNameLabel: fmt.Sprintf("Test Alert (%s)", receiver.Name),
+			"severity":            "none",
+		}),
+		Annotations: labels.FromMap(map[string]string{
+			"description": "This is a test alert to verify the notification channel",
+		}),
+		StartsAt: now,
+	}
+	data := newTemplateData(receiver.Name, map[string]string{labels.AlertNameLabel: alert.Name()}, m.dispatcher.externalURL, now, alert)
+
+	for _, i := range integrations {
+		ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+		err := i.Notify(ctx, groupKey(receiver.Name, data.GroupLabels), data)
+		cancel()
+		if err != nil {
+			zap.L().Error("Failed to send test notification", zap.String("receiver", receiver.Name), zap.String("integration", i.Name()), zap.Error(err))
+			return &model.ApiError{Typ: model.ErrorInternal, Err: fmt.Errorf("%s: %v", i.Name(), err)}
+		}
+	}
+	return nil
+}
+
+// Put hands the alerts over to the dispatcher
+func (m *NativeManager) Put(alerts ...*Alert) {
+	m.dispatcher.put(alerts...)
+}
+
+// Stop stops all the pending notifications
+func (m *NativeManager) Stop() {
+	m.dispatcher.stop()
+}
@@ -0,0 +1,366 @@
+package alertManager
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.signoz.io/signoz/pkg/query-service/utils/labels"
+)
+
+type request struct {
+	header http.Header
+	body   map[string]interface{}
+}
+
+// newStandIn starts a local http server that records the json pushed to it
+func newStandIn(t *testing.T) (*httptest.Server, chan request) {
+	reqs := make(chan request, 16)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		b, _ := io.ReadAll(r.Body)
+		body := map[string]interface{}{}
+		_ = json.Unmarshal(b, &body)
+		reqs <- request{header: r.Header, body: body}
+		w.WriteHeader(http.StatusOK)
+	}))
+	t.Cleanup(srv.Close)
+	return srv, reqs
+}
+
+func receive(t *testing.T, reqs chan request) request {
+	select {
+	case r := <-reqs:
+		return r
+	case <-time.After(2 * time.Second):
+		t.Fatal("timed out waiting for notification")
+	}
+	return request{}
+}
+
+func assertNoRequest(t *testing.T, reqs chan request, wait time.Duration) {
+	select {
+	case r := <-reqs:
+		t.Fatalf("unexpected notification: %v", r.body)
+	case <-time.After(wait):
+	}
+}
+
+type fakeEmailSender struct {
+	to, subject, body string
+}
+
+func (f *fakeEmailSender) SendEmail(to, subject, body string) error {
+	f.to, f.subject, f.body = to, subject, body
+	return nil
+}
+
+func testRoute() RouteConfig {
+	return RouteConfig{
+		GroupBy:        []string{"alertname"},
+		GroupWait:      20 * time.Millisecond,
+		GroupInterval:  50 * time.Millisecond,
+		RepeatInterval: time.Hour,
+	}
+}
+
+func newTestManager(t *testing.T, conf *NativeConfig) *NativeManager {
+	m, err := NewNativeManager(conf)
+	require.NoError(t, err)
+	t.Cleanup(m.Stop)
+	return m
+}
+
+func TestNativeManagerTestReceiver(t *testing.T) {
+	m := newTestManager(t, &NativeConfig{Route: testRoute(), ExternalURL: "http://signoz.local"})
+
+	slack, slackReqs := newStandIn(t)
+	webhook, webhookReqs := newStandIn(t)
+	pagerduty, pagerdutyReqs := newStandIn(t)
+	msteams, msteamsReqs := newStandIn(t)
+
+	sender := &fakeEmailSender{}
+	origSender := getEmailSender
+	getEmailSender = func() emailSender { return sender }
+	defer func() { getEmailSender = origSender }()
+
+	cases := []struct {
+		name     string
+		receiver *Receiver
+		check    func(t *testing.T)
+	}{
+		{
+			name: "slack",
+			receiver: &Receiver{Name: "slack", SlackConfigs: []interface{}{map[string]interface{}{
+				"send_resolved": true,
+				"api_url":       slack.URL,
+				"channel":       "#alerts",
+				"title":         `[{{ .Status | toUpper }}] {{ .CommonLabels.alertname }}`,
+				"text":          `{{ range .Alerts }}{{ .Annotations.description }}{{ end }}`,
+			}}},
+			check: func(t *testing.T) {
+				r := receive(t, slackReqs)
+				assert.Equal(t, "#alerts", r.body["channel"])
+				attachment := r.body["attachments"].([]interface{})[0].(map[string]interface{})
+				assert.Equal(t, "[FIRING] Test Alert (slack)", attachment["title"])
+				assert.Equal(t, "This is a test alert to verify the notification channel", attachment["text"])
+				assert.Equal(t, "danger", attachment["color"])
+			},
+		},
+		{
+			name: "webhook",
+			receiver: &Receiver{Name: "webhook", WebhookConfigs: []interface{}{map[string]interface{}{
+				"send_resolved": true,
+				"url":           webhook.URL,
+				"http_config": map[string]interface{}{
+					"authorization": map[string]interface{}{"type": "bearer", "credentials": "token"},
+				},
+			}}},
+			check: func(t *testing.T) {
+				r := receive(t, webhookReqs)
+				assert.Equal(t, "bearer token", r.header.Get("Authorization"))
+				assert.Equal(t, "4", r.body["version"])
+				assert.Equal(t, "firing", r.body["status"])
+				assert.Equal(t, "webhook", r.body["receiver"])
+				assert.Equal(t, "http://signoz.local", r.body["externalURL"])
+				assert.Len(t, r.body["alerts"], 1)
+			},
+		},
+		{
+			name: "pagerduty",
+			receiver: &Receiver{Name: "pagerduty", PagerdutyConfigs: []interface{}{map[string]interface{}{
+				"send_resolved": true,
+				"url":           pagerduty.URL,
+				"routing_key":   "key",
+				"description":   `{{ template "__subject" . }}`,
+				"severity":      `{{ .CommonLabels.severity }}`,
+				"details":       map[string]interface{}{"firing": `{{ template "pagerduty.default.instances" .Alerts.Firing }}`},
+			}}},
+			check: func(t *testing.T) {
+				r := receive(t, pagerdutyReqs)
+				assert.Equal(t, "key", r.body["routing_key"])
+				assert.Equal(t, "trigger", r.body["event_action"])
+				assert.Equal(t, "http://signoz.local", r.body["client_url"])
+				payload := r.body["payload"].(map[string]interface{})
+				assert.Equal(t, "[FIRING:1] Test Alert (pagerduty) (none)", payload["summary"])
+				assert.Equal(t, "none", payload["severity"])
+				details := payload["custom_details"].(map[string]interface{})
+				assert.Contains(t, details["firing"], "alertname = Test Alert (pagerduty)")
+			},
+		},
+		{
+			name: "msteams",
+			receiver: &Receiver{Name: "msteams", MSTeamsConfigs: []interface{}{map[string]interface{}{
+				"send_resolved": true,
+				"webhook_url":   msteams.URL,
+			}}},
+			check: func(t *testing.T) {
+				r := receive(t, msteamsReqs)
+				assert.Equal(t, "MessageCard", r.body["type"])
+				assert.Equal(t, "[FIRING:1] Test Alert (msteams) (none)", r.body["title"])
+				assert.Contains(t, r.body["text"], "Alerts Firing:")
+			},
+		},
+		{
+			name: "email",
+			receiver: &Receiver{Name: "email", EmailConfigs: []interface{}{map[string]interface{}{
+				"send_resolved": true,
+				"to":            "oncall@signoz.local",
+				"html":          `{{ range .Alerts }}<p>{{ .Labels.alertname }}</p>{{ end }}`,
+			}}},
+			check: func(t *testing.T) {
+				assert.Equal(t, "oncall@signoz.local", sender.to)
+				assert.Equal(t, "[FIRING:1] Test Alert (email) (none)", sender.subject)
+				assert.Equal(t, "<p>Test Alert (email)</p>", sender.body)
+			},
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			apiErr := m.TestReceiver(c.receiver)
+			require.Nil(t, apiErr)
+			c.check(t)
+		})
+	}
+}
+
+func TestNativeManagerInvalidReceiver(t *testing.T) {
+	m := newTestManager(t, &NativeConfig{Route: testRoute()})
+
+	apiErr := m.AddRoute(&Receiver{Name: "slack", SlackConfigs: []interface{}{map[string]interface{}{"channel": "#alerts"}}})
+	require.NotNil(t, apiErr)
+
+	apiErr = m.AddRoute(&Receiver{Name: "opsgenie", OpsGenieConfigs: []interface{}{map[string]interface{}{"api_key": "key"}}})
+	require.NotNil(t, apiErr)
+
+	apiErr = m.EditRoute(&Receiver{Name: "unknown"})
+	require.NotNil(t, apiErr)
+}
+
+func TestNativeManagerGrouping(t *testing.T) {
+	m := newTestManager(t, &NativeConfig{Route: testRoute()})
+	webhook, reqs := newStandIn(t)
+
+	require.Nil(t, m.AddRoute(&Receiver{Name: "webhook", WebhookConfigs: []interface{}{map[string]interface{}{
+		"send_resolved": true,
+		"url":           webhook.URL,
+	}}}))
+
+	now := time.Now()
+	alert := func(name, instance string, endsAt time.Time) *Alert {
+		return &Alert{
+			Labels:    labels.FromStrings(labels.AlertNameLabel, name, "instance", instance),
+			StartsAt:  now,
+			EndsAt:    endsAt,
+			Receivers: []string{"webhook"},
+		}
+	}
+
+	// both alerts share the alertname and end up in the same notification
+	m.Put(alert("HighLatency", "a", now.Add(time.Hour)), alert("HighLatency", "b", now.Add(time.Hour)))
+	r := receive(t, reqs)
+	assert.Equal(t, "firing", r.body["status"])
+	assert.Len(t, r.body["alerts"], 2)
+	assert.Equal(t, map[string]interface{}{"alertname": "HighLatency"}, r.body["groupLabels"])
+
+	// the same alerts are not re-sent before the repeat interval
+	m.Put(alert("HighLatency", "a", now.Add(time.Hour)), alert("HighLatency", "b", now.Add(time.Hour)))
+	assertNoRequest(t, reqs, 150*time.Millisecond)
+
+	// resolving an alert notifies on the next group interval
+	m.Put(alert("HighLatency", "b", now.Add(-time.Second)))
+	r = receive(t, reqs)
+	assert.Equal(t, "firing", r.body["status"])
+	statuses := []string{}
+	for _, a := range r.body["alerts"].([]interface{}) {
+		statuses = append(statuses, a.(map[string]interface{})["status"].(string))
+	}
+	assert.ElementsMatch(t, []string{"firing", "resolved"}, statuses)
+
+	// the resolved alert has been dropped from the group
+	m.Put(alert("HighLatency", "a", now.Add(-time.Second)))
+	r = receive(t, reqs)
+	assert.Equal(t, "resolved", r.body["status"])
+	assert.Len(t, r.body["alerts"], 1)
+}
+
+func TestNativeManagerRenderedNotification(t *testing.T) {
+	m := newTestManager(t, &NativeConfig{Route: testRoute()})
+	slack, reqs := newStandIn(t)
+
+	require.Nil(t, m.AddRoute(&Receiver{Name: "slack", SlackConfigs: []interface{}{map[string]interface{}{
+		"api_url": slack.URL,
+		"title":   `{{ .CommonLabels.alertname }}`,
+		"text":    `{{ range .Alerts }}{{ .Annotations.SortedPairs.Names }}{{ end }}`,
+	}}}))
+
+	now := time.Now()
+	alert := func(instance string, annotations ...string) *Alert {
+		return &Alert{
+			Labels:      labels.FromStrings(labels.AlertNameLabel, "HighLatency", "instance", instance),
+			Annotations: labels.FromStrings(annotations...),
+			StartsAt:    now,
+			EndsAt:      now.Add(time.Hour),
+			Receivers:   []string{"slack"},
+		}
+	}
+
+	// the bodies rendered by the rule replace the channel text
+	m.Put(
+		alert("a", "summary", "a is slow", RenderedTitleAnnotation("slack"), "Latency is high", RenderedBodyAnnotation("slack"), "*a* is slow"),
+		alert("b", "summary", "b is slow", RenderedTitleAnnotation("slack"), "Latency is high", RenderedBodyAnnotation("slack"), "*b* is slow"),
+	)
+	r := receive(t, reqs)
+	attachment := r.body["attachments"].([]interface{})[0].(map[string]interface{})
+	assert.Equal(t, "Latency is high", attachment["title"])
+	assert.ElementsMatch(t, []string{"*a* is slow", "*b* is slow"}, strings.Split(attachment["text"].(string), "\n\n"))
+
+	// an alert without a rendered body falls back to the channel templates,
+	// which don't see the rendered annotations
+	m.Put(
+		alert("c", "summary", "c is slow", RenderedBodyAnnotation("slack"), "*c* is slow"),
+		alert("d", "summary", "d is slow"),
+	)
+	r = receive(t, reqs)
+	attachment = r.body["attachments"].([]interface{})[0].(map[string]interface{})
+	assert.Equal(t, "HighLatency", attachment["title"])
+	assert.NotContains(t, attachment["text"], "notification_")
+	assert.Contains(t, attachment["text"], "summary")
+}
+
+func TestNativeManagerInhibition(t *testing.T) {
+	m := newTestManager(t, &NativeConfig{
+		Route: testRoute(),
+		InhibitRules: []InhibitRule{{
+			SourceMatch: map[string]string{"severity": "critical"},
+			TargetMatch: map[string]string{"severity": "warning"},
+			Equal:       []string{"service"},
+		}},
+	})
+	webhook, reqs := newStandIn(t)
+
+	require.Nil(t, m.AddRoute(&Receiver{Name: "webhook", WebhookConfigs: []interface{}{map[string]interface{}{
+		"url": webhook.URL,
+	}}}))
+
+	now := time.Now()
+	alert := func(name, severity, service string) *Alert {
+		return &Alert{
+			Labels:   labels.FromStrings(labels.AlertNameLabel, name, "severity", severity, "service", service),
+			StartsAt: now,
+			EndsAt:   now.Add(time.Hour),
+		}
+	}
+
+	m.Put(
+		alert("ServiceDown", "critical", "frontend"),
+		alert("HighLatency", "warning", "frontend"),
+		alert("HighErrorRate", "warning", "cart"),
+	)
+
+	received := map[string]bool{}
+	for i := 0; i < 2; i++ {
+		r := receive(t, reqs)
+		received[r.body["groupLabels"].(map[string]interface{})["alertname"].(string)] = true
+	}
+	assertNoRequest(t, reqs, 100*time.Millisecond)
+
+	assert.Equal(t, map[string]bool{"ServiceDown": true, "HighErrorRate": true}, received)
+}
+
+func TestLoadNativeConfig(t *testing.T) {
+	conf, err := LoadNativeConfig("")
+	require.NoError(t, err)
+	assert.Equal(t, defaultRouteConfig(), conf.Route)
+
+	f, err := os.CreateTemp(t.TempDir(), "alertmanager-*.yaml")
+	require.NoError(t, err)
+	_, err = f.WriteString(`
+route:
+  group_by: [alertname, service]
+  group_wait: 10s
+inhibit_rules:
+  - source_match:
+      severity: critical
+    target_match:
+      severity: warning
+    equal: [service]
+`)
+	require.NoError(t, err)
+	require.NoError(t, f.Close())
+
+	conf, err = LoadNativeConfig(f.Name())
+	require.NoError(t, err)
+	assert.Equal(t, []string{"alertname", "service"}, conf.Route.GroupBy)
+	assert.Equal(t, 10*time.Second, conf.Route.GroupWait)
+	assert.Equal(t, 5*time.Minute, conf.Route.GroupInterval)
+	assert.Len(t, conf.InhibitRules, 1)
+	assert.Equal(t, []string{"service"}, conf.InhibitRules[0].Equal)
+}
@@ -14,6 +14,8 @@ import (
 
 	old_ctx "golang.org/x/net/context"
 
+	"go.signoz.io/signoz/pkg/query-service/constants"
+
 	"github.com/go-kit/log"
 	"github.com/go-kit/log/level"
 
@@ -38,7 +40,9 @@ type Notifier struct {
 	cancel func()
 
 	alertmanagers *alertmanagerSet
-	logger        log.Logger
+	// set instead of alertmanagers when notifications are dispatched natively
+	native *NativeManager
+	logger log.Logger
 }
 
 // NotifierOptions are the configurable parameters of a Handler.
@@ -85,6 +89,17 @@ func NewNotifier(o *NotifierOptions, logger log.Logger) (*Notifier, error) {
 		timeout = time.Duration(30 * time.Second)
 	}
 
+	if constants.IsNativeAlertManagerEnabled() {
+		native, err := getNativeManager()
+		if err != nil {
+			zap.L().Error("failed to start native alert manager", zap.Error(err))
+			return n, err
+		}
+		n.native = native
+		zap.L().Info("Starting notifier with native alert manager")
+		return n, nil
+	}
+
 	amset, err := newAlertmanagerSet(o.AlertManagerURLs, timeout, logger)
 	if err != nil {
 		zap.L().Error("failed to parse alert manager urls")
@@ -189,6 +204,9 @@ func (n *Notifier) Alertmanagers() []*url.URL {
 	n.mtx.RUnlock()
 
 	var res []*url.URL
+	if amset == nil {
+		return res
+	}
 
 	amset.mtx.RLock()
 	for _, am := range amset.ams {
@@ -203,6 +221,11 @@ func (n *Notifier) Alertmanagers() []*url.URL {
 // It returns true if the alerts could be sent successfully to at least one Alertmanager.
 func (n *Notifier) sendAll(alerts ...*Alert) bool {
 
+	if n.native != nil {
+		n.native.Put(alerts...)
+		return true
+	}
+
 	b, err := json.Marshal(alerts)
 	if err != nil {
 		zap.L().Error("Encoding alerts failed", zap.Error(err))
@@ -0,0 +1,527 @@
+package alertManager
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	smtpservice "go.signoz.io/signoz/pkg/query-service/utils/smtpService"
+)
+
+const defaultPagerdutyURL = "https://events.pagerduty.com/v2/enqueue"
+
+// integration delivers a group of alerts to one of the configs
+// of a receiver.
+type integration interface {
+	// Name identifies the integration in logs, e.g. slack[0]
+	Name() string
+	// SendResolved tells if resolved alerts should be delivered
+	SendResolved() bool
+	// Notify sends the notification for the group identified by key
+	Notify(ctx context.Context, key string, data *TemplateData) error
+}
+
+// emailSender sends html emails, it is satisfied by the smtp service
+type emailSender interface {
+	SendEmail(to, subject, body string) error
+}
+
+var getEmailSender = func() emailSender {
+	return smtpservice.GetInstance()
+}
+
+type basicAuth struct {
+	Username string `json:"username"`
+	Password string `json:"password"`
+}
+
+type authorization struct {
+	Type        string `json:"type"`
+	Credentials string `json:"credentials"`
+}
+
+type httpConfig struct {
+	BasicAuth     *basicAuth     `json:"basic_auth,omitempty"`
+	Authorization *authorization `json:"authorization,omitempty"`
+}
+
+func (c *httpConfig) apply(req *http.Request) {
+	if c == nil {
+		return
+	}
+	if c.BasicAuth != nil {
+		req.SetBasicAuth(c.BasicAuth.Username, c.BasicAuth.Password)
+	}
+	if c.Authorization != nil && c.Authorization.Credentials != "" {
+		typ := c.Authorization.Type
+		if typ == "" {
+			typ = "Bearer"
+		}
+		req.Header.Set("Authorization", fmt.Sprintf("%s %s", typ, c.Authorization.Credentials))
+	}
+}
+
+type slackConfig struct {
+	SendResolved *bool       `json:"send_resolved,omitempty"`
+	HTTPConfig   *httpConfig `json:"http_config,omitempty"`
+	APIURL       string      `json:"api_url"`
+	Channel      string      `json:"channel"`
+	Username     string      `json:"username"`
+	Title        string      `json:"title"`
+	TitleLink    string      `json:"title_link"`
+	Text         string      `json:"text"`
+}
+
+type webhookConfig struct {
+	SendResolved *bool       `json:"send_resolved,omitempty"`
+	HTTPConfig   *httpConfig `json:"http_config,omitempty"`
+	URL          string      `json:"url"`
+	MaxAlerts    int         `json:"max_alerts"`
+}
+
+type pagerdutyConfig struct {
+	SendResolved *bool             `json:"send_resolved,omitempty"`
+	HTTPConfig   *httpConfig       `json:"http_config,omitempty"`
+	URL          string            `json:"url"`
+	RoutingKey   string            `json:"routing_key"`
+	Client       string            `json:"client"`
+	ClientURL    string            `json:"client_url"`
+	Description  string            `json:"description"`
+	Severity     string            `json:"severity"`
+	Class        string            `json:"class"`
+	Component    string            `json:"component"`
+	Group        string            `json:"group"`
+	Details      map[string]string `json:"details"`
+}
+
+type msteamsConfig struct {
+	SendResolved *bool       `json:"send_resolved,omitempty"`
+	HTTPConfig   *httpConfig `json:"http_config,omitempty"`
+	WebhookURL   string      `json:"webhook_url"`
+	Title        string      `json:"title"`
+	Text         string      `json:"text"`
+}
+
+type emailConfig struct {
+	SendResolved *bool             `json:"send_resolved,omitempty"`
+	To           string            `json:"to"`
+	HTML         string            `json:"html"`
+	Headers      map[string]string `json:"headers"`
+}
+
+// decodeConfigs converts the loosely typed receiver configs
+// into the typed config slice pointed to by out
+func decodeConfigs(raw interface{}, out interface{}) error {
+	if raw == nil {
+		return nil
+	}
+	b, err := json.Marshal(raw)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(b, out)
+}
+
+func boolOrDefault(b *bool, def bool) bool {
+	if b == nil {
+		return def
+	}
+	return *b
+}
+
+// buildIntegrations prepares the integrations for all the configs
+// of a receiver. Configs for integrations that can not be dispatched
+// natively result in an error.
+func buildIntegrations(receiver *Receiver, client *http.Client) ([]integration, error) {
+	var integrations []integration
+
+	unsupported := map[string]interface{}{
+		"opsgenie":  receiver.OpsGenieConfigs,
+		"wechat":    receiver.WechatConfigs,
+		"pushover":  receiver.PushoverConfigs,
+		"victorops": receiver.VictorOpsConfigs,
+		"sns":       receiver.SNSConfigs,
+	}
+	for name, raw := range unsupported {
+		if raw != nil {
+			return nil, fmt.Errorf("%s channels are not supported by the native alert manager", name)
+		}
+	}
+
+	var slackConfigs []slackConfig
+	if err := decodeConfigs(receiver.SlackConfigs, &slackConfigs); err != nil {
+		return nil, fmt.Errorf("invalid slack config: %v", err)
+	}
+	for i, c := range slackConfigs {
+		if c.APIURL == "" {
+			return nil, fmt.Errorf("slack config is missing the api url")
+		}
+		integrations = append(integrations, &slackIntegration{idx: i, conf: c, client: client})
+	}
+
+	var webhookConfigs []webhookConfig
+	if err := decodeConfigs(receiver.WebhookConfigs, &webhookConfigs); err != nil {
+		return nil, fmt.Errorf("invalid webhook config: %v", err)
+	}
+	for i, c := range webhookConfigs {
+		if c.URL == "" {
+			return nil, fmt.Errorf("webhook config is missing the url")
+		}
+		integrations = append(integrations, &webhookIntegration{idx: i, conf: c, client: client})
+	}
+
+	var pagerdutyConfigs []pagerdutyConfig
+	if err := decodeConfigs(receiver.PagerdutyConfigs, &pagerdutyConfigs); err != nil {
+		return nil, fmt.Errorf("invalid pagerduty config: %v", err)
+	}
+	for i, c := range pagerdutyConfigs {
+		if c.RoutingKey == "" {
+			return nil, fmt.Errorf("pagerduty config is missing the routing key")
+		}
+		if c.URL == "" {
+			c.URL = defaultPagerdutyURL
+		}
+		integrations = append(integrations, &pagerdutyIntegration{idx: i, conf: c, client: client})
+	}
+
+	var msteamsConfigs []msteamsConfig
+	if err := decodeConfigs(receiver.MSTeamsConfigs, &msteamsConfigs); err != nil {
+		return nil, fmt.Errorf("invalid msteams config: %v", err)
+	}
+	for i, c := range msteamsConfigs {
+		if c.WebhookURL == "" {
+			return nil, fmt.Errorf("msteams config is missing the webhook url")
+		}
+		integrations = append(integrations, &msteamsIntegration{idx: i, conf: c, client: client})
+	}
+
+	var emailConfigs []emailConfig
+	if err := decodeConfigs(receiver.EmailConfigs, &emailConfigs); err != nil {
+		return nil, fmt.Errorf("invalid email config: %v", err)
+	}
+	for i, c := range emailConfigs {
+		if c.To == "" {
+			return nil, fmt.Errorf("email config is missing the recipients")
+		}
+		integrations = append(integrations, &emailIntegration{idx: i, conf: c, sender: getEmailSender()})
+	}
+
+	return integrations, nil
+}
+
+// expandMessage returns the title and the body of the notification for the
+// channel type. The notification rendered by the rules takes precedence over
+// the templates of the channel config, the title falls back to the channel
+// title when the rules rendered none.
+func expandMessage(data *TemplateData, channelType, sep, titleTmpl, bodyTmpl, defaultBody string) (string, string, error) {
+	title, body, ok := data.renderedNotification(channelType, sep)
+	if !ok {
+		var err error
+		body, err = expandTemplate(bodyTmpl, defaultBody, data)
+		if err != nil {
+			return "", "", err
+		}
+	}
+	if title == "" {
+		var err error
+		title, err = expandTemplate(titleTmpl, "__subject", data)
+		if err != nil {
+			return "", "", err
+		}
+	}
+	return title, body, nil
+}
+
+// postJSON sends the payload to url and fails on a non 2xx response
+func postJSON(ctx context.Context, client *http.Client, url string, conf *httpConfig, payload interface{}) error {
+	b, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(b))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", contentTypeJSON)
+	conf.apply(req)
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(io.Discard, resp.Body)
+
+	if resp.StatusCode/100 != 2 {
+		return fmt.Errorf("unexpected status code %v", resp.StatusCode)
+	}
+	return nil
+}
+
+type slackIntegration struct {
+	idx    int
+	conf   slackConfig
+	client *http.Client
+}
+
+type slackAttachment struct {
+	Title     string   `json:"title,omitempty"`
+	TitleLink string   `json:"title_link,omitempty"`
+	Text      string   `json:"text"`
+	Fallback  string   `json:"fallback"`
+	Color     string   `json:"color,omitempty"`
+	MrkdwnIn  []string `json:"mrkdwn_in,omitempty"`
+}
+
+type slackMessage struct {
+	Channel     string            `json:"channel,omitempty"`
+	Username    string            `json:"username,omitempty"`
+	Attachments []slackAttachment `json:"attachments"`
+}
+
+func (s *slackIntegration) Name() string { return fmt.Sprintf("slack[%d]", s.idx) }
+
+func (s *slackIntegration) SendResolved() bool { return boolOrDefault(s.conf.SendResolved, false) }
+
+func (s *slackIntegration) Notify(ctx context.Context, key string, data *TemplateData) error {
+	title, text, err := expandMessage(data, "slack", "\n\n", s.conf.Title, s.conf.Text, "__text")
+	if err != nil {
+		return err
+	}
+	titleLink, err := expandTemplate(s.conf.TitleLink, "", data)
+	if err != nil {
+		return err
+	}
+
+	color := "danger"
+	if data.Status == alertStatusResolved {
+		color = "good"
+	}
+
+	msg := slackMessage{
+		Channel:  s.conf.Channel,
+		Username: s.conf.Username,
+		Attachments: []slackAttachment{{
+			Title:     title,
+			TitleLink: titleLink,
+			Text:      text,
+			Fallback:  title,
+			Color:     color,
+			MrkdwnIn:  []string{"fallback", "pretext", "text"},
+		}},
+	}
+	return postJSON(ctx, s.client, s.conf.APIURL, s.conf.HTTPConfig, msg)
+}
+
+type webhookIntegration struct {
+	idx    int
+	conf   webhookConfig
+	client *http.Client
+}
+
+// webhookMessage is the payload pushed to webhooks, compatible with
+// the one pushed by the Alertmanager
+type webhookMessage struct {
+	*TemplateData
+	Version         string `json:"version"`
+	GroupKey        string `json:"groupKey"`
+	TruncatedAlerts int    `json:"truncatedAlerts"`
+}
+
+func (w *webhookIntegration) Name() string { return fmt.Sprintf("webhook[%d]", w.idx) }
+
+func (w *webhookIntegration) SendResolved() bool { return boolOrDefault(w.conf.SendResolved, true) }
+
+func (w *webhookIntegration) Notify(ctx context.Context, key string, data *TemplateData) error {
+	msg := webhookMessage{Version: "4", GroupKey: key}
+
+	if w.conf.MaxAlerts > 0 && len(data.Alerts) > w.conf.MaxAlerts {
+		truncated := *data
+		truncated.Alerts = data.Alerts[:w.conf.MaxAlerts]
+		msg.TemplateData = &truncated
+		msg.TruncatedAlerts = len(data.Alerts) - w.conf.MaxAlerts
+	} else {
+		msg.TemplateData = data
+	}
+
+	return postJSON(ctx, w.client, w.conf.URL, w.conf.HTTPConfig, msg)
+}
+
+type pagerdutyIntegration struct {
+	idx    int
+	conf   pagerdutyConfig
+	client *http.Client
+}
+
+type pagerdutyPayload struct {
+	Summary       string            `json:"summary"`
+	Source        string            `json:"source"`
+	Severity      string            `json:"severity"`
+	Class         string            `json:"class,omitempty"`
+	Component     string            `json:"component,omitempty"`
+	Group         string            `json:"group,omitempty"`
+	CustomDetails map[string]string `json:"custom_details,omitempty"`
+}
+
+type pagerdutyMessage struct {
+	RoutingKey  string            `json:"routing_key"`
+	EventAction string            `json:"event_action"`
+	DedupKey    string            `json:"dedup_key"`
+	Client      string            `json:"client,omitempty"`
+	ClientURL   string            `json:"client_url,omitempty"`
+	Payload     *pagerdutyPayload `json:"payload,omitempty"`
+}
+
+// pagerduty limits the summary to 1024 characters
+const pagerdutyMaxSummaryLen = 1024
+
+func (p *pagerdutyIntegration) Name() string { return fmt.Sprintf("pagerduty[%d]", p.idx) }
+
+func (p *pagerdutyIntegration) SendResolved() bool { return boolOrDefault(p.conf.SendResolved, true) }
+
+func (p *pagerdutyIntegration) Notify(ctx context.Context, key string, data *TemplateData) error {
+	msg := pagerdutyMessage{
+		RoutingKey:  p.conf.RoutingKey,
+		EventAction: "trigger",
+		DedupKey:    fmt.Sprintf("%x", sha256.Sum256([]byte(key))),
+	}
+
+	if data.Status == alertStatusResolved {
+		msg.EventAction = "resolve"
+		return postJSON(ctx, p.client, p.conf.URL, p.conf.HTTPConfig, msg)
+	}
+
+	var err error
+	expand := func(text, def string) string {
+		if err != nil {
+			return ""
+		}
+		var res string
+		res, err = expandTemplate(text, def, data)
+		return res
+	}
+
+	summary := expand(p.conf.Description, "__subject")
+	title, body, rendered := data.renderedNotification("pagerduty", "\n\n")
+	if rendered && title != "" {
+		summary = title
+	}
+	if len(summary) > pagerdutyMaxSummaryLen {
+		summary = summary[:pagerdutyMaxSummaryLen-1] + "…"
+	}
+
+	client := p.conf.Client
+	if client == "" {
+		client = "SigNoz Alert Manager"
+	}
+
+	payload := &pagerdutyPayload{
+		Summary:   summary,
+		Source:    expand(client, ""),
+		Severity:  strings.ToLower(expand(p.conf.Severity, "")),
+		Class:     expand(p.conf.Class, ""),
+		Component: expand(p.conf.Component, ""),
+		Group:     expand(p.conf.Group, ""),
+	}
+	if payload.Severity == "" {
+		payload.Severity = "error"
+	}
+
+	payload.CustomDetails = map[string]string{}
+	for k, v := range p.conf.Details {
+		payload.CustomDetails[k] = expand(v, "")
+	}
+	if rendered {
+		payload.CustomDetails["description"] = body
+	} else if len(p.conf.Details) == 0 {
+		payload.CustomDetails["firing"] = expand(`{{ template "pagerduty.default.instances" .Alerts.Firing }}`, "")
+		payload.CustomDetails["resolved"] = expand(`{{ template "pagerduty.default.instances" .Alerts.Resolved }}`, "")
+		payload.CustomDetails["num_firing"] = expand(`{{ .Alerts.Firing | len }}`, "")
+		payload.CustomDetails["num_resolved"] = expand(`{{ .Alerts.Resolved | len }}`, "")
+	}
+
+	msg.Client = payload.Source
+	msg.ClientURL = expand(p.conf.ClientURL, "")
+	if msg.ClientURL == "" {
+		msg.ClientURL = data.ExternalURL
+	}
+	msg.Payload = payload
+
+	if err != nil {
+		return err
+	}
+	return postJSON(ctx, p.client, p.conf.URL, p.conf.HTTPConfig, msg)
+}
+
+type msteamsIntegration struct {
+	idx    int
+	conf   msteamsConfig
+	client *http.Client
+}
+
+type msteamsMessage struct {
+	Context    string `json:"@context"`
+	Type       string `json:"type"`
+	Title      string `json:"title"`
+	Summary    string `json:"summary"`
+	Text       string `json:"text"`
+	ThemeColor string `json:"themeColor"`
+}
+
+func (m *msteamsIntegration) Name() string { return fmt.Sprintf("msteams[%d]", m.idx) }
+
+func (m *msteamsIntegration) SendResolved() bool { return boolOrDefault(m.conf.SendResolved, true) }
+
+func (m *msteamsIntegration) Notify(ctx context.Context, key string, data *TemplateData) error {
+	title, text, err := expandMessage(data, "msteams", "\n\n", m.conf.Title, m.conf.Text, "__text")
+	if err != nil {
+		return err
+	}
+
+	color := "8C1A1A"
+	if data.Status == alertStatusResolved {
+		color = "2DC72D"
+	}
+
+	msg := msteamsMessage{
+		Context:    "http://schema.org/extensions",
+		Type:       "MessageCard",
+		Title:      title,
+		Summary:    title,
+		Text:       text,
+		ThemeColor: color,
+	}
+	return postJSON(ctx, m.client, m.conf.WebhookURL, m.conf.HTTPConfig, msg)
+}
+
+type emailIntegration struct {
+	idx    int
+	conf   emailConfig
+	sender emailSender
+}
+
+func (e *emailIntegration) Name() string { return fmt.Sprintf("email[%d]", e.idx) }
+
+func (e *emailIntegration) SendResolved() bool { return boolOrDefault(e.conf.SendResolved, false) }
+
+func (e *emailIntegration) Notify(ctx context.Context, key string, data *TemplateData) error {
+	var subjectTmpl string
+	for k, v := range e.conf.Headers {
+		if strings.EqualFold(k, "subject") {
+			subjectTmpl = v
+		}
+	}
+
+	subject, body, err := expandMessage(data, "email", "<hr/>", subjectTmpl, e.conf.HTML, "email.default.html")
+	if err != nil {
+		return err
+	}
+
+	return e.sender.SendEmail(e.conf.To, subject, body)
+}
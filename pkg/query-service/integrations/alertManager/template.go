@@ -0,0 +1,332 @@
+package alertManager
+
+import (
+	"bytes"
+	"regexp"
+	"sort"
+	"strings"
+	"text/template"
+	"time"
+
+	"go.signoz.io/signoz/pkg/query-service/utils/labels"
+	"golang.org/x/text/cases"
+	"golang.org/x/text/language"
+)
+
+// The types in this file mirror the data model of the Alertmanager
+// notification templates, so that the channel templates created from
+// the frontend render the same with the native alert manager.
+
+const (
+	alertStatusFiring   = "firing"
+	alertStatusResolved = "resolved"
+)
+
+// Pair is a key/value string pair.
+type Pair struct {
+	Name, Value string
+}
+
+// Pairs is a list of key/value string pairs.
+type Pairs []Pair
+
+// Names returns a list of names of the pairs.
+func (ps Pairs) Names() []string {
+	ns := make([]string, 0, len(ps))
+	for _, p := range ps {
+		ns = append(ns, p.Name)
+	}
+	return ns
+}
+
+// Values returns a list of values of the pairs.
+func (ps Pairs) Values() []string {
+	vs := make([]string, 0, len(ps))
+	for _, p := range ps {
+		vs = append(vs, p.Value)
+	}
+	return vs
+}
+
+// KV is a set of key/value string pairs.
+type KV map[string]string
+
+// SortedPairs returns a sorted list of key/value pairs.
+func (kv KV) SortedPairs() Pairs {
+	pairs := make(Pairs, 0, len(kv))
+	for k, v := range kv {
+		pairs = append(pairs, Pair{Name: k, Value: v})
+	}
+	sort.Slice(pairs, func(i, j int) bool {
+		return pairs[i].Name < pairs[j].Name
+	})
+	return pairs
+}
+
+// Remove returns a copy of the key/value set without the given keys.
+func (kv KV) Remove(keys []string) KV {
+	keySet := make(map[string]struct{}, len(keys))
+	for _, k := range keys {
+		keySet[k] = struct{}{}
+	}
+
+	res := KV{}
+	for k, v := range kv {
+		if _, ok := keySet[k]; !ok {
+			res[k] = v
+		}
+	}
+	return res
+}
+
+// Names returns the names of the label names in the KV.
+func (kv KV) Names() []string {
+	return kv.SortedPairs().Names()
+}
+
+// Values returns a list of the values in the KV.
+func (kv KV) Values() []string {
+	return kv.SortedPairs().Values()
+}
+
+func kvFromLabels(l labels.BaseLabels) KV {
+	if l == nil {
+		return KV{}
+	}
+	return KV(l.Map())
+}
+
+// TemplateAlert holds one alert for notification templates.
+type TemplateAlert struct {
+	Status       string    `json:"status"`
+	Labels       KV        `json:"labels"`
+	Annotations  KV        `json:"annotations"`
+	StartsAt     time.Time `json:"startsAt"`
+	EndsAt       time.Time `json:"endsAt"`
+	GeneratorURL string    `json:"generatorURL"`
+	Fingerprint  string    `json:"fingerprint"`
+
+	// rendered holds the notification rendered by the rule for each
+	// channel type, it is taken out of the annotations
+	rendered KV
+}
+
+// TemplateAlerts is a list of TemplateAlert objects.
+type TemplateAlerts []TemplateAlert
+
+// Firing returns the subset of alerts that are firing.
+func (as TemplateAlerts) Firing() []TemplateAlert {
+	res := []TemplateAlert{}
+	for _, a := range as {
+		if a.Status == alertStatusFiring {
+			res = append(res, a)
+		}
+	}
+	return res
+}
+
+// Resolved returns the subset of alerts that are resolved.
+func (as TemplateAlerts) Resolved() []TemplateAlert {
+	res := []TemplateAlert{}
+	for _, a := range as {
+		if a.Status == alertStatusResolved {
+			res = append(res, a)
+		}
+	}
+	return res
+}
+
+// TemplateData is the data passed to notification templates and webhook pushes.
+type TemplateData struct {
+	Receiver string         `json:"receiver"`
+	Status   string         `json:"status"`
+	Alerts   TemplateAlerts `json:"alerts"`
+
+	GroupLabels       KV `json:"groupLabels"`
+	CommonLabels      KV `json:"commonLabels"`
+	CommonAnnotations KV `json:"commonAnnotations"`
+
+	ExternalURL string `json:"externalURL"`
+}
+
+// newTemplateData prepares the template data for a group of alerts
+// sent to the given receiver at ts.
+func newTemplateData(receiver string, groupLabels map[string]string, externalURL string, ts time.Time, alerts ...*Alert) *TemplateData {
+	data := &TemplateData{
+		Receiver:          receiver,
+		Status:            alertStatusResolved,
+		Alerts:            make(TemplateAlerts, 0, len(alerts)),
+		GroupLabels:       KV{},
+		CommonLabels:      KV{},
+		CommonAnnotations: KV{},
+		ExternalURL:       externalURL,
+	}
+
+	for k, v := range groupLabels {
+		data.GroupLabels[k] = v
+	}
+
+	for _, a := range alerts {
+		status := alertStatusFiring
+		if a.ResolvedAt(ts) {
+			status = alertStatusResolved
+		} else {
+			data.Status = alertStatusFiring
+		}
+		annotations, rendered := splitRenderedAnnotations(kvFromLabels(a.Annotations))
+		data.Alerts = append(data.Alerts, TemplateAlert{
+			Status:       status,
+			Labels:       kvFromLabels(a.Labels),
+			Annotations:  annotations,
+			StartsAt:     a.StartsAt,
+			EndsAt:       a.EndsAt,
+			GeneratorURL: a.GeneratorURL,
+			Fingerprint:  a.Fingerprint(),
+			rendered:     rendered,
+		})
+	}
+
+	if len(data.Alerts) > 0 {
+		for k, v := range data.Alerts[0].Labels {
+			data.CommonLabels[k] = v
+		}
+		for k, v := range data.Alerts[0].Annotations {
+			data.CommonAnnotations[k] = v
+		}
+		for _, a := range data.Alerts[1:] {
+			for k, v := range data.CommonLabels {
+				if a.Labels[k] != v {
+					delete(data.CommonLabels, k)
+				}
+			}
+			for k, v := range data.CommonAnnotations {
+				if a.Annotations[k] != v {
+					delete(data.CommonAnnotations, k)
+				}
+			}
+		}
+	}
+
+	return data
+}
+
+// renderedAnnotationPrefix starts the names of the annotations that carry
+// the notifications rendered by the rules from their notification templates
+const renderedAnnotationPrefix = "notification_"
+
+// RenderedTitleAnnotation is the name of the annotation holding the title
+// rendered by a rule for the channel type, e.g. slack
+func RenderedTitleAnnotation(channelType string) string {
+	return renderedAnnotationPrefix + channelType + "_title"
+}
+
+// RenderedBodyAnnotation is the name of the annotation holding the body
+// rendered by a rule for the channel type, e.g. slack
+func RenderedBodyAnnotation(channelType string) string {
+	return renderedAnnotationPrefix + channelType + "_body"
+}
+
+// splitRenderedAnnotations separates the rendered notifications from the
+// other annotations so that they don't show up in the channel templates
+func splitRenderedAnnotations(kv KV) (KV, KV) {
+	annotations, rendered := KV{}, KV{}
+	for k, v := range kv {
+		if strings.HasPrefix(k, renderedAnnotationPrefix) {
+			rendered[k] = v
+		} else {
+			annotations[k] = v
+		}
+	}
+	return annotations, rendered
+}
+
+// renderedNotification returns the notification the rules rendered for the
+// channel type. The title is the one of the first alert with the status of
+// the group, the bodies of the alerts are joined with sep. It returns false
+// if any alert of the group was sent without a rendered body, in which case
+// the channel templates are used.
+func (data *TemplateData) renderedNotification(channelType, sep string) (string, string, bool) {
+	if len(data.Alerts) == 0 {
+		return "", "", false
+	}
+
+	var title string
+	bodies := make([]string, 0, len(data.Alerts))
+	for _, a := range data.Alerts {
+		body, ok := a.rendered[RenderedBodyAnnotation(channelType)]
+		if !ok {
+			return "", "", false
+		}
+		bodies = append(bodies, body)
+		if title == "" && a.Status == data.Status {
+			title = a.rendered[RenderedTitleAnnotation(channelType)]
+		}
+	}
+	return title, strings.Join(bodies, sep), true
+}
+
+var templateFuncs = template.FuncMap{
+	"toUpper": strings.ToUpper,
+	"toLower": strings.ToLower,
+	"title":   cases.Title(language.AmericanEnglish).String,
+	"join": func(sep string, s []string) string {
+		return strings.Join(s, sep)
+	},
+	"match": regexp.MatchString,
+	"reReplaceAll": func(pattern, repl, text string) string {
+		re := regexp.MustCompile(pattern)
+		return re.ReplaceAllString(text, repl)
+	},
+	"stringSlice": func(s ...string) []string {
+		return s
+	},
+}
+
+// defaultTemplates are the named templates available to channel templates,
+// a subset of the ones shipped with the Alertmanager.
+const defaultTemplates = `
+{{ define "__alertmanager" }}SigNoz Alertmanager{{ end }}
+
+{{ define "__subject" }}[{{ .Status | toUpper }}{{ if eq .Status "firing" }}:{{ .Alerts.Firing | len }}{{ end }}] {{ .GroupLabels.SortedPairs.Values | join " " }} {{ if gt (len .CommonLabels) (len .GroupLabels) }}({{ with .CommonLabels.Remove .GroupLabels.Names }}{{ .Values | join " " }}{{ end }}){{ end }}{{ end }}
+
+{{ define "__text_alert_list" }}{{ range . }}Labels:
+{{ range .Labels.SortedPairs }} - {{ .Name }} = {{ .Value }}
+{{ end }}Annotations:
+{{ range .Annotations.SortedPairs }} - {{ .Name }} = {{ .Value }}
+{{ end }}Source: {{ .GeneratorURL }}
+{{ end }}{{ end }}
+
+{{ define "__text" }}{{ if gt (len .Alerts.Firing) 0 }}Alerts Firing:
+{{ template "__text_alert_list" .Alerts.Firing }}{{ end }}{{ if gt (len .Alerts.Resolved) 0 }}Alerts Resolved:
+{{ template "__text_alert_list" .Alerts.Resolved }}{{ end }}{{ end }}
+
+{{ define "email.default.html" }}<html><body><h3>{{ template "__subject" . }}</h3><pre>{{ template "__text" . }}</pre></body></html>{{ end }}
+
+{{ define "pagerduty.default.instances" }}{{ template "__text_alert_list" . }}{{ end }}
+`
+
+// expandTemplate renders the text against the template data. Empty
+// text renders to the named default template if one is given.
+func expandTemplate(text string, defaultName string, data *TemplateData) (string, error) {
+	if text == "" {
+		if defaultName == "" {
+			return "", nil
+		}
+		text = `{{ template "` + defaultName + `" . }}`
+	}
+
+	tmpl, err := template.New("").Option("missingkey=zero").Funcs(templateFuncs).Parse(defaultTemplates)
+	if err != nil {
+		return "", err
+	}
+	tmpl, err = tmpl.New("text").Parse(text)
+	if err != nil {
+		return "", err
+	}
+
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, data); err != nil {
+		return "", err
+	}
+	return buf.String(), nil
+}
@@ -41,6 +41,7 @@ func main() {
 	var useLogsNewSchema bool
 	// the url used to build link in the alert messages in slack and other systems
 	var ruleRepoURL, cacheConfigPath, fluxInterval string
+	var queryLimitsPath string
 	var cluster string
 
 	var preferSpanMetrics bool
@@ -56,6 +57,7 @@ func main() {
 	flag.BoolVar(&preferSpanMetrics, "prefer-span-metrics", false, "(prefer span metrics for service level metrics)")
 	flag.StringVar(&ruleRepoURL, "rules.repo-url", constants.AlertHelpPage, "(host address used to build rule link in alert messages)")
 	flag.StringVar(&cacheConfigPath, "experimental.cache-config", "", "(cache config to use)")
+	flag.StringVar(&queryLimitsPath, "query-limits-config", "", "(config file with the query limits of the roles)")
 	flag.StringVar(&fluxInterval, "flux-interval", "5m", "(the interval to exclude data from being cached to avoid incorrect cache for data in motion)")
 	flag.StringVar(&cluster, "cluster", "cluster", "(cluster name - defaults to 'cluster')")
 	// Allow using the consistent naming with the signoz collector
@@ -84,6 +86,7 @@ func main() {
 		MaxOpenConns:      maxOpenConns,
 		DialTimeout:       dialTimeout,
 		CacheConfigPath:   cacheConfigPath,
+		QueryLimitsPath:   queryLimitsPath,
 		FluxInterval:      fluxInterval,
 		Cluster:           cluster,
 		UseLogsNewSchema:  useLogsNewSchema,
@@ -73,6 +73,7 @@ func ClickHouseMigrate(conn driver.Conn, cluster string) error {
     fingerprint UInt64 CODEC(ZSTD(1)),
     value Float64 CODEC(Gorilla, ZSTD(1)),
     labels String CODEC(ZSTD(5)),
+    severity LowCardinality(String) DEFAULT '',
 )
 ENGINE = MergeTree
 PARTITION BY toDate(unix_milli / 1000)
@@ -92,9 +93,14 @@ SETTINGS ttl_only_drop_parts = 1, index_granularity = 8192`
     fingerprint UInt64 CODEC(ZSTD(1)),
     value Float64 CODEC(Gorilla, ZSTD(1)),
     labels String CODEC(ZSTD(5)),
+    severity LowCardinality(String) DEFAULT '',
 )
 ENGINE = Distributed(%s, signoz_analytics, rule_state_history_v0, cityHash64(rule_id, rule_name, fingerprint))`
 
+	// severity was added after the first release of the tables, add it
+	// to the tables created before that
+	addSeverityColumn := `ALTER TABLE signoz_analytics.%s ON CLUSTER %s ADD COLUMN IF NOT EXISTS severity LowCardinality(String) DEFAULT ''`
+
 	// check if db exists
 	dbExists := `SELECT count(*) FROM system.databases WHERE name = 'signoz_analytics'`
 	var count uint64
@@ -140,5 +146,12 @@ ENGINE = Distributed(%s, signoz_analytics, rule_state_history_v0, cityHash64(rul
 		}
 	}
 
+	for _, table := range []string{"rule_state_history_v0", "distributed_rule_state_history_v0"} {
+		err = conn.Exec(context.Background(), fmt.Sprintf(addSeverityColumn, table, cluster))
+		if err != nil {
+			return err
+		}
+	}
+
 	return nil
 }
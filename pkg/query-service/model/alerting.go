@@ -18,6 +18,7 @@ const (
 	StateFiring
 	StateNoData
 	StateDisabled
+	StateSuppressed
 )
 
 func (s AlertState) String() string {
@@ -32,6 +33,8 @@ func (s AlertState) String() string {
 		return "nodata"
 	case StateDisabled:
 		return "disabled"
+	case StateSuppressed:
+		return "suppressed"
 	}
 	panic(errors.Errorf("unknown alert state: %d", s))
 }
@@ -58,6 +61,8 @@ func (s *AlertState) UnmarshalJSON(b []byte) error {
 			*s = StateNoData
 		case "disabled":
 			*s = StateDisabled
+		case "suppressed":
+			*s = StateSuppressed
 		default:
 			*s = StateInactive
 		}
@@ -83,6 +88,8 @@ func (s *AlertState) Scan(value interface{}) error {
 		*s = StateNoData
 	case "disabled":
 		*s = StateDisabled
+	case "suppressed":
+		*s = StateSuppressed
 	}
 	return nil
 }
@@ -132,6 +139,8 @@ type RuleStateHistory struct {
 	Labels       LabelsString `json:"labels" ch:"labels"`
 	Fingerprint  uint64       `json:"fingerprint" ch:"fingerprint"`
 	Value        float64      `json:"value" ch:"value"`
+	// Severity of the threshold level breached, for rules with threshold levels
+	Severity string `json:"severity,omitempty" ch:"severity"`
 
 	RelatedTracesLink string `json:"relatedTracesLink"`
 	RelatedLogsLink   string `json:"relatedLogsLink"`
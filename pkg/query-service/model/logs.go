@@ -27,6 +27,9 @@ type QueryProgress struct {
 	ReadBytes uint64 `json:"read_bytes"`
 
 	ElapsedMs uint64 `json:"elapsed_ms"`
+
+	// Cancelled is set on the last update of a cancelled query
+	Cancelled bool `json:"cancelled,omitempty"`
 }
 
 func GetLogFieldsV3(ctx context.Context, queryRangeParams *v3.QueryRangeParamsV3, fields *GetFieldsResponse) map[string]v3.AttributeKey {
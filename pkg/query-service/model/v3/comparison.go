@@ -0,0 +1,88 @@
+package v3
+
+import "fmt"
+
+// ComparisonQuerySuffix is appended to the name of a query with a comparison
+// to name the query of the previous period
+const ComparisonQuerySuffix = "_previous"
+
+// Comparison compares the results of a query with the results of the same
+// query Offset seconds earlier, e.g. 604800 for the same time last week
+type Comparison struct {
+	Offset int64 `json:"offset"`
+}
+
+func (c *Comparison) Validate() error {
+	if c == nil {
+		return nil
+	}
+	if c.Offset <= 0 {
+		return fmt.Errorf("comparison offset must be a positive number of seconds")
+	}
+	return nil
+}
+
+// ComparisonResult has the series of the previous period of a query with a
+// comparison and their difference with the series of the current period.
+// The previous series are shifted to the time of the current period, the
+// delta and the percent change series are calculated for the series with
+// the same labels in both periods.
+type ComparisonResult struct {
+	Offset        int64     `json:"offset"`
+	Previous      []*Series `json:"previous"`
+	Delta         []*Series `json:"delta"`
+	PercentChange []*Series `json:"percentChange"`
+}
+
+// WithComparisonQueries returns a copy of the params with the query of the
+// previous period added for each of the builder queries with a comparison.
+// The previous period is queried with a time shift of the offset so that its
+// points line up with the current one. It is disabled so only the comparison
+// of the current query is returned. The params are not changed.
+func (p *QueryRangeParamsV3) WithComparisonQueries() *QueryRangeParamsV3 {
+	if p == nil || p.CompositeQuery == nil || p.CompositeQuery.QueryType != QueryTypeBuilder {
+		return p
+	}
+	// only the builder queries of the copy are added to
+	params := *p
+	compositeQuery := *p.CompositeQuery
+	compositeQuery.BuilderQueries = make(map[string]*BuilderQuery, len(p.CompositeQuery.BuilderQueries))
+	for name, query := range p.CompositeQuery.BuilderQueries {
+		compositeQuery.BuilderQueries[name] = query
+	}
+	params.CompositeQuery = &compositeQuery
+	compositeQuery.addComparisonQueries()
+	return &params
+}
+
+func (c *CompositeQuery) addComparisonQueries() {
+	names := make([]string, 0, len(c.BuilderQueries))
+	for name := range c.BuilderQueries {
+		names = append(names, name)
+	}
+	for _, name := range names {
+		query := c.BuilderQueries[name]
+		if query.Comparison == nil || query.QueryName != query.Expression {
+			continue
+		}
+		previousName := name + ComparisonQuerySuffix
+		if _, ok := c.BuilderQueries[previousName]; ok {
+			continue
+		}
+
+		previous := query.Clone()
+		previous.QueryName = previousName
+		previous.Expression = previousName
+		previous.Disabled = true
+		previous.Comparison = nil
+		previous.ShiftBy = query.ShiftBy + query.Comparison.Offset
+		// the time shift is the first function so that the others use the shifted time
+		previous.Functions = []Function{{Name: FunctionNameTimeShift, Args: []interface{}{float64(previous.ShiftBy)}}}
+		for _, function := range query.Functions {
+			if function.Name != FunctionNameTimeShift {
+				previous.Functions = append(previous.Functions, function)
+			}
+		}
+		c.BuilderQueries[previousName] = previous
+	}
+}
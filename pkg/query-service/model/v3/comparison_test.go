@@ -0,0 +1,84 @@
+package v3
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWithComparisonQueries(t *testing.T) {
+	compositeQuery := &CompositeQuery{
+		QueryType: QueryTypeBuilder,
+		PanelType: PanelTypeGraph,
+		BuilderQueries: map[string]*BuilderQuery{
+			"A": {
+				QueryName:          "A",
+				Expression:         "A",
+				StepInterval:       60,
+				DataSource:         DataSourceMetrics,
+				AggregateOperator:  AggregateOperatorSum,
+				AggregateAttribute: AttributeKey{Key: "signoz_calls_total"},
+				ShiftBy:            3600,
+				Functions: []Function{
+					{Name: FunctionNameTimeShift, Args: []interface{}{float64(3600)}},
+					{Name: FunctionNameEWMA5},
+				},
+				Comparison: &Comparison{Offset: 86400},
+			},
+			"B": {
+				QueryName:          "B",
+				Expression:         "B",
+				StepInterval:       60,
+				DataSource:         DataSourceMetrics,
+				AggregateOperator:  AggregateOperatorSum,
+				AggregateAttribute: AttributeKey{Key: "signoz_calls_total"},
+			},
+		},
+	}
+
+	params := &QueryRangeParamsV3{CompositeQuery: compositeQuery}
+	withComparison := params.WithComparisonQueries()
+	require.Len(t, withComparison.CompositeQuery.BuilderQueries, 3)
+	// the params of the caller are not changed
+	assert.Len(t, compositeQuery.BuilderQueries, 2)
+
+	previous := withComparison.CompositeQuery.BuilderQueries["A"+ComparisonQuerySuffix]
+	require.NotNil(t, previous)
+	assert.Equal(t, "A_previous", previous.QueryName)
+	assert.Equal(t, "A_previous", previous.Expression)
+	assert.True(t, previous.Disabled)
+	assert.Nil(t, previous.Comparison)
+	assert.Equal(t, int64(90000), previous.ShiftBy)
+	assert.Equal(t, []Function{
+		{Name: FunctionNameTimeShift, Args: []interface{}{float64(90000)}},
+		{Name: FunctionNameEWMA5},
+	}, previous.Functions)
+
+	// the query with the comparison is unchanged
+	assert.Equal(t, int64(3600), compositeQuery.BuilderQueries["A"].ShiftBy)
+	assert.Len(t, compositeQuery.BuilderQueries["A"].Functions, 2)
+
+	assert.Len(t, withComparison.WithComparisonQueries().CompositeQuery.BuilderQueries, 3)
+}
+
+func TestBuilderQueryValidateComparison(t *testing.T) {
+	query := func(comparison *Comparison) *BuilderQuery {
+		return &BuilderQuery{
+			QueryName:          "A",
+			Expression:         "A",
+			StepInterval:       60,
+			DataSource:         DataSourceMetrics,
+			AggregateOperator:  AggregateOperatorSum,
+			AggregateAttribute: AttributeKey{Key: "signoz_calls_total"},
+			Comparison:         comparison,
+		}
+	}
+	assert.NoError(t, query(&Comparison{Offset: 604800}).Validate(PanelTypeGraph))
+	assert.NoError(t, query(&Comparison{Offset: 604800}).Validate(PanelTypeTable))
+	assert.Error(t, query(&Comparison{}).Validate(PanelTypeGraph))
+	assert.Error(t, query(&Comparison{Offset: 604800}).Validate(PanelTypeList))
+
+	formula := &BuilderQuery{QueryName: "F1", Expression: "A * 2", Comparison: &Comparison{Offset: 3600}}
+	assert.Error(t, formula.Validate(PanelTypeGraph))
+}
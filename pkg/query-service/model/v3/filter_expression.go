@@ -0,0 +1,739 @@
+package v3
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// This file implements a textual form of filter sets, e.g.
+//
+//	service.name = "api" AND http.status_code >= 500 AND body CONTAINS "timeout"
+//
+// Conditions are `key op value`, where op is one of =, !=, >, >=, <, <=,
+// IN, CONTAINS, REGEXP (or =~), LIKE, optionally preceded by NOT, or
+// `key EXISTS`, `key NOT EXISTS` and `has(key, value)`. NOT in front of a
+// condition or a parenthesised group negates it as a whole. Values are
+// double or single quoted strings, numbers, true and false; IN takes a list
+// in parentheses or brackets. Keys can be prefixed with their type,
+// `resource:service.name`, and suffixed with their data type,
+// `http.status_code::int64`, followed by `::column` for columns and
+// `::json` for json attributes. Keys with other characters are quoted with
+// backticks.
+
+// FilterExpressionError is a syntax error in a filter expression
+type FilterExpressionError struct {
+	// Pos is the byte offset of the error in the expression
+	Pos    int    `json:"pos"`
+	Line   int    `json:"line"`
+	Column int    `json:"column"`
+	Msg    string `json:"msg"`
+}
+
+func (e *FilterExpressionError) Error() string {
+	return fmt.Sprintf("invalid filter expression at %d:%d: %s", e.Line, e.Column, e.Msg)
+}
+
+type filterTokenKind int
+
+const (
+	filterTokenEOF filterTokenKind = iota
+	filterTokenKey
+	filterTokenString
+	filterTokenNumber
+	filterTokenOp
+	filterTokenLParen
+	filterTokenRParen
+	filterTokenLBracket
+	filterTokenRBracket
+	filterTokenComma
+)
+
+type filterToken struct {
+	kind filterTokenKind
+	pos  int
+	// text is the source of the token, the unquoted value for strings
+	text string
+	// key is set for key tokens
+	key *AttributeKey
+}
+
+// keyword tells if the token is the bare keyword kw, ignoring case
+func (t filterToken) keyword(kw string) bool {
+	return t.kind == filterTokenKey && t.text == t.key.Key && strings.EqualFold(t.text, kw)
+}
+
+func (t filterToken) describe() string {
+	switch t.kind {
+	case filterTokenEOF:
+		return "end of expression"
+	case filterTokenString:
+		return strconv.Quote(t.text)
+	default:
+		return fmt.Sprintf("%q", t.text)
+	}
+}
+
+type filterLexer struct {
+	input  string
+	pos    int
+	tokens []filterToken
+}
+
+func isKeyChar(c byte) bool {
+	return c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9' ||
+		c == '_' || c == '.' || c == '-' || c == '/' || c == '@' || c == '$' || c == ':' || c >= utf8.RuneSelf
+}
+
+func isDigit(c byte) bool {
+	return c >= '0' && c <= '9'
+}
+
+func (l *filterLexer) errorf(pos int, format string, args ...interface{}) *FilterExpressionError {
+	return newFilterExpressionError(l.input, pos, fmt.Sprintf(format, args...))
+}
+
+func newFilterExpressionError(input string, pos int, msg string) *FilterExpressionError {
+	line, col := 1, 1
+	for _, r := range input[:pos] {
+		if r == '\n' {
+			line++
+			col = 1
+		} else {
+			col++
+		}
+	}
+	return &FilterExpressionError{Pos: pos, Line: line, Column: col, Msg: msg}
+}
+
+func (l *filterLexer) lex() error {
+	for {
+		for l.pos < len(l.input) && strings.ContainsRune(" \t\r\n", rune(l.input[l.pos])) {
+			l.pos++
+		}
+		if l.pos >= len(l.input) {
+			l.tokens = append(l.tokens, filterToken{kind: filterTokenEOF, pos: l.pos})
+			return nil
+		}
+
+		start := l.pos
+		c := l.input[l.pos]
+		switch {
+		case c == '(':
+			l.emit(filterTokenLParen, start, 1)
+		case c == ')':
+			l.emit(filterTokenRParen, start, 1)
+		case c == '[':
+			l.emit(filterTokenLBracket, start, 1)
+		case c == ']':
+			l.emit(filterTokenRBracket, start, 1)
+		case c == ',':
+			l.emit(filterTokenComma, start, 1)
+		case c == '"' || c == '\'':
+			s, err := l.quoted(c)
+			if err != nil {
+				return err
+			}
+			l.tokens = append(l.tokens, filterToken{kind: filterTokenString, pos: start, text: s})
+		case isDigit(c) || (c == '-' || c == '+') && l.pos+1 < len(l.input) && isDigit(l.input[l.pos+1]):
+			l.pos++
+			for l.pos < len(l.input) && (isDigit(l.input[l.pos]) || strings.ContainsRune(".eE", rune(l.input[l.pos])) ||
+				strings.ContainsRune("+-", rune(l.input[l.pos])) && strings.ContainsRune("eE", rune(l.input[l.pos-1]))) {
+				l.pos++
+			}
+			text := l.input[start:l.pos]
+			if _, err := strconv.ParseFloat(text, 64); err != nil {
+				return l.errorf(start, "invalid number %q", text)
+			}
+			l.tokens = append(l.tokens, filterToken{kind: filterTokenNumber, pos: start, text: text})
+		case strings.ContainsRune("=!<>", rune(c)):
+			op := ""
+			for _, candidate := range []string{"==", "!=", "<>", ">=", "<=", "=~", "!~", "=", ">", "<"} {
+				if strings.HasPrefix(l.input[l.pos:], candidate) {
+					op = candidate
+					break
+				}
+			}
+			if op == "" {
+				return l.errorf(start, "unexpected character %q", c)
+			}
+			l.emit(filterTokenOp, start, len(op))
+		case isKeyChar(c) || c == '`':
+			if err := l.key(); err != nil {
+				return err
+			}
+		default:
+			r, _ := utf8.DecodeRuneInString(l.input[l.pos:])
+			return l.errorf(start, "unexpected character %q", r)
+		}
+	}
+}
+
+func (l *filterLexer) emit(kind filterTokenKind, start, n int) {
+	l.pos = start + n
+	l.tokens = append(l.tokens, filterToken{kind: kind, pos: start, text: l.input[start:l.pos]})
+}
+
+// quoted scans a string quoted with q, backslash escapes the next character
+func (l *filterLexer) quoted(q byte) (string, error) {
+	start := l.pos
+	l.pos++
+	var b strings.Builder
+	for l.pos < len(l.input) {
+		c := l.input[l.pos]
+		switch {
+		case c == q:
+			l.pos++
+			return b.String(), nil
+		case c == '\\' && l.pos+1 < len(l.input):
+			l.pos++
+			switch e := l.input[l.pos]; e {
+			case 'n':
+				b.WriteByte('\n')
+			case 't':
+				b.WriteByte('\t')
+			case 'r':
+				b.WriteByte('\r')
+			default:
+				b.WriteByte(e)
+			}
+		default:
+			b.WriteByte(c)
+		}
+		l.pos++
+	}
+	return "", l.errorf(start, "unterminated string")
+}
+
+// key scans an attribute key made of bare and backtick quoted parts.
+// The type prefix and the data type suffix are only recognised in
+// bare parts.
+func (l *filterLexer) key() error {
+	start := l.pos
+	var name strings.Builder
+	firstBare, lastBare := "", ""
+	parts := 0
+	for l.pos < len(l.input) {
+		c := l.input[l.pos]
+		if c == '`' {
+			end := strings.IndexByte(l.input[l.pos+1:], '`')
+			if end < 0 {
+				return l.errorf(l.pos, "unterminated quoted key")
+			}
+			name.WriteString(l.input[l.pos+1 : l.pos+1+end])
+			l.pos += end + 2
+			lastBare = ""
+			parts++
+			continue
+		}
+		if !isKeyChar(c) {
+			break
+		}
+		partStart := l.pos
+		for l.pos < len(l.input) && isKeyChar(l.input[l.pos]) {
+			l.pos++
+			// array data types carry parentheses
+			if strings.HasSuffix(l.input[partStart:l.pos], "::array") && l.pos < len(l.input) && l.input[l.pos] == '(' {
+				if end := strings.IndexByte(l.input[l.pos:], ')'); end >= 0 {
+					l.pos += end + 1
+				}
+			}
+		}
+		part := l.input[partStart:l.pos]
+		if parts == 0 {
+			firstBare = part
+		}
+		lastBare = part
+		name.WriteString(part)
+		parts++
+	}
+
+	key := &AttributeKey{Key: name.String()}
+	for {
+		idx := strings.LastIndex(lastBare, "::")
+		if idx < 0 {
+			break
+		}
+		switch lastBare[idx+2:] {
+		case "column":
+			key.IsColumn = true
+		case "json":
+			key.IsJSON = true
+		default:
+			idx = -1
+		}
+		if idx < 0 {
+			break
+		}
+		key.Key = key.Key[:len(key.Key)-len(lastBare)+idx]
+		lastBare = lastBare[:idx]
+	}
+	if idx := strings.LastIndex(lastBare, "::"); idx >= 0 {
+		key.DataType = AttributeKeyDataType(lastBare[idx+2:])
+		if err := key.Validate(); err != nil || key.DataType == "" {
+			return l.errorf(start+len(key.Key)-len(lastBare)+idx+2, "invalid data type %q", key.DataType)
+		}
+		key.Key = key.Key[:len(key.Key)-len(lastBare)+idx]
+	}
+	for _, typ := range []AttributeKeyType{AttributeKeyTypeTag, AttributeKeyTypeResource} {
+		prefix := string(typ) + ":"
+		if strings.HasPrefix(firstBare, prefix) && len(key.Key) > len(prefix) {
+			key.Type = typ
+			key.Key = key.Key[len(prefix):]
+			break
+		}
+	}
+	if key.Key == "" {
+		return l.errorf(start, "empty key")
+	}
+
+	l.tokens = append(l.tokens, filterToken{kind: filterTokenKey, pos: start, text: l.input[start:l.pos], key: key})
+	return nil
+}
+
+// filterNode is a node of the parsed expression, either a condition or
+// a group of nodes joined by AND or OR
+type filterNode struct {
+	item     *FilterItem
+	op       string
+	children []*filterNode
+	not      bool
+}
+
+type filterParser struct {
+	input  string
+	tokens []filterToken
+	idx    int
+}
+
+func (p *filterParser) peek() filterToken {
+	return p.tokens[p.idx]
+}
+
+func (p *filterParser) next() filterToken {
+	t := p.tokens[p.idx]
+	if t.kind != filterTokenEOF {
+		p.idx++
+	}
+	return t
+}
+
+func (p *filterParser) errorf(t filterToken, format string, args ...interface{}) *FilterExpressionError {
+	return newFilterExpressionError(p.input, t.pos, fmt.Sprintf(format, args...))
+}
+
+func (p *filterParser) expect(kind filterTokenKind, what string) (filterToken, error) {
+	t := p.next()
+	if t.kind != kind {
+		return t, p.errorf(t, "expected %s, found %s", what, t.describe())
+	}
+	return t, nil
+}
+
+// parseGroup parses the operands joined by the boolean operator op, the
+// operands are parsed by operand
+func (p *filterParser) parseGroup(op string, operand func() (*filterNode, error)) (*filterNode, error) {
+	first, err := operand()
+	if err != nil {
+		return nil, err
+	}
+	node := &filterNode{op: op, children: []*filterNode{first}}
+	for p.peek().keyword(op) {
+		p.next()
+		n, err := operand()
+		if err != nil {
+			return nil, err
+		}
+		node.children = append(node.children, n)
+	}
+	if len(node.children) == 1 {
+		return first, nil
+	}
+	return node, nil
+}
+
+func (p *filterParser) parseOr() (*filterNode, error) {
+	return p.parseGroup("OR", p.parseAnd)
+}
+
+func (p *filterParser) parseAnd() (*filterNode, error) {
+	return p.parseGroup("AND", p.parseUnary)
+}
+
+func (p *filterParser) parseUnary() (*filterNode, error) {
+	if p.peek().keyword("NOT") {
+		p.next()
+		n, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		// the negation is kept as a group, negating the operators of the
+		// conditions would change the result for the records that don't
+		// have the attributes
+		if n.item != nil {
+			return &filterNode{op: "AND", children: []*filterNode{n}, not: true}, nil
+		}
+		n.not = !n.not
+		return n, nil
+	}
+	return p.parsePrimary()
+}
+
+func (p *filterParser) parsePrimary() (*filterNode, error) {
+	t := p.peek()
+	switch {
+	case t.kind == filterTokenLParen:
+		p.next()
+		n, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		if _, err := p.expect(filterTokenRParen, "')'"); err != nil {
+			return nil, err
+		}
+		return n, nil
+	case t.keyword("has") && p.tokens[p.idx+1].kind == filterTokenLParen:
+		p.next()
+		p.next()
+		key, err := p.expect(filterTokenKey, "key")
+		if err != nil {
+			return nil, err
+		}
+		if _, err := p.expect(filterTokenComma, "','"); err != nil {
+			return nil, err
+		}
+		value, err := p.parseValue()
+		if err != nil {
+			return nil, err
+		}
+		if _, err := p.expect(filterTokenRParen, "')'"); err != nil {
+			return nil, err
+		}
+		return &filterNode{item: &FilterItem{Key: *key.key, Operator: FilterOperatorHas, Value: value}}, nil
+	case t.kind == filterTokenKey:
+		return p.parseCondition()
+	default:
+		return nil, p.errorf(t, "expected condition, found %s", t.describe())
+	}
+}
+
+var filterComparisonOps = map[string]FilterOperator{
+	"=":  FilterOperatorEqual,
+	"==": FilterOperatorEqual,
+	"!=": FilterOperatorNotEqual,
+	"<>": FilterOperatorNotEqual,
+	">":  FilterOperatorGreaterThan,
+	">=": FilterOperatorGreaterThanOrEq,
+	"<":  FilterOperatorLessThan,
+	"<=": FilterOperatorLessThanOrEq,
+	"=~": FilterOperatorRegex,
+	"!~": FilterOperatorNotRegex,
+}
+
+var filterKeywordOps = map[string]FilterOperator{
+	"IN":       FilterOperatorIn,
+	"CONTAINS": FilterOperatorContains,
+	"REGEXP":   FilterOperatorRegex,
+	"LIKE":     FilterOperatorLike,
+	"EXISTS":   FilterOperatorExists,
+	"HAS":      FilterOperatorHas,
+}
+
+func (p *filterParser) parseCondition() (*filterNode, error) {
+	keyToken := p.next()
+	item := &FilterItem{Key: *keyToken.key}
+
+	t := p.next()
+	negated := false
+	if t.keyword("NOT") {
+		negated = true
+		t = p.next()
+	}
+
+	switch {
+	case t.kind == filterTokenOp && !negated:
+		item.Operator = filterComparisonOps[t.text]
+	case t.kind == filterTokenKey && filterKeywordOps[strings.ToUpper(t.text)] != "" && t.keyword(t.text):
+		item.Operator = filterKeywordOps[strings.ToUpper(t.text)]
+	default:
+		return nil, p.errorf(t, "expected operator after %s, found %s", keyToken.describe(), t.describe())
+	}
+
+	var err error
+	switch item.Operator {
+	case FilterOperatorExists:
+	case FilterOperatorIn:
+		item.Value, err = p.parseList()
+	default:
+		item.Value, err = p.parseValue()
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if negated {
+		item.Operator = negatedFilterOperators[item.Operator]
+	}
+	return &filterNode{item: item}, nil
+}
+
+func (p *filterParser) parseValue() (interface{}, error) {
+	t := p.next()
+	switch {
+	case t.kind == filterTokenString:
+		return t.text, nil
+	case t.kind == filterTokenNumber:
+		// numbers are float64 as they are when the filter is decoded from json
+		return strconv.ParseFloat(t.text, 64)
+	case t.keyword("true"):
+		return true, nil
+	case t.keyword("false"):
+		return false, nil
+	default:
+		return nil, p.errorf(t, "expected value, found %s", t.describe())
+	}
+}
+
+// parseList parses the values of IN, a single value is accepted as well
+func (p *filterParser) parseList() (interface{}, error) {
+	open := p.peek()
+	var closing filterTokenKind
+	switch open.kind {
+	case filterTokenLParen:
+		closing = filterTokenRParen
+	case filterTokenLBracket:
+		closing = filterTokenRBracket
+	default:
+		v, err := p.parseValue()
+		if err != nil {
+			return nil, err
+		}
+		return []interface{}{v}, nil
+	}
+	p.next()
+
+	values := []interface{}{}
+	for {
+		v, err := p.parseValue()
+		if err != nil {
+			return nil, err
+		}
+		values = append(values, v)
+
+		t := p.next()
+		if t.kind == closing {
+			return values, nil
+		}
+		if t.kind != filterTokenComma {
+			return nil, p.errorf(t, "expected ',' or the end of the list, found %s", t.describe())
+		}
+	}
+}
+
+// negatedFilterOperators are the operators of the `key NOT op value`
+// conditions
+var negatedFilterOperators = map[FilterOperator]FilterOperator{
+	FilterOperatorIn:       FilterOperatorNotIn,
+	FilterOperatorContains: FilterOperatorNotContains,
+	FilterOperatorRegex:    FilterOperatorNotRegex,
+	FilterOperatorLike:     FilterOperatorNotLike,
+	FilterOperatorExists:   FilterOperatorNotExists,
+	FilterOperatorHas:      FilterOperatorNotHas,
+}
+
+// filterSetOf converts the node to a filter set, the children joined by the
+// other operator and the negated children become nested groups
+func filterSetOf(n *filterNode) FilterSet {
+	if n.item != nil {
+		return FilterSet{Operator: "AND", Items: []FilterItem{*n.item}}
+	}
+	fs := FilterSet{Operator: n.op, Items: []FilterItem{}, Not: n.not}
+	for _, c := range n.children {
+		switch {
+		case c.item != nil:
+			fs.Items = append(fs.Items, *c.item)
+		case !c.not && (c.op == n.op || len(c.children) == 1):
+			child := filterSetOf(c)
+			fs.Items = append(fs.Items, child.Items...)
+			fs.Groups = append(fs.Groups, child.Groups...)
+		default:
+			fs.Groups = append(fs.Groups, filterSetOf(c))
+		}
+	}
+	return fs
+}
+
+// ParseFilterExpression compiles the textual filter expression into a
+// filter set. An empty expression results in an empty filter set.
+func ParseFilterExpression(expr string) (*FilterSet, error) {
+	lexer := &filterLexer{input: expr}
+	if err := lexer.lex(); err != nil {
+		return nil, err
+	}
+	p := &filterParser{input: expr, tokens: lexer.tokens}
+	if p.peek().kind == filterTokenEOF {
+		return &FilterSet{Operator: "AND", Items: []FilterItem{}}, nil
+	}
+
+	root, err := p.parseOr()
+	if err != nil {
+		return nil, err
+	}
+	if t := p.peek(); t.kind != filterTokenEOF {
+		return nil, p.errorf(t, "expected AND, OR or the end of the expression, found %s", t.describe())
+	}
+
+	fs := filterSetOf(root)
+	return &fs, nil
+}
+
+var bareFilterKey = regexp.MustCompile(`^[A-Za-z_@$][A-Za-z0-9_.\-/@$]*$`)
+
+var filterKeywords = map[string]struct{}{
+	"AND": {}, "OR": {}, "NOT": {}, "IN": {}, "CONTAINS": {}, "REGEXP": {},
+	"LIKE": {}, "EXISTS": {}, "HAS": {}, "TRUE": {}, "FALSE": {},
+}
+
+func formatFilterKey(key AttributeKey) string {
+	var b strings.Builder
+	if key.Type != AttributeKeyTypeUnspecified {
+		b.WriteString(string(key.Type) + ":")
+	}
+	if _, ok := filterKeywords[strings.ToUpper(key.Key)]; bareFilterKey.MatchString(key.Key) && !ok {
+		b.WriteString(key.Key)
+	} else {
+		b.WriteString("`" + key.Key + "`")
+	}
+	if key.DataType != AttributeKeyDataTypeUnspecified {
+		b.WriteString("::" + string(key.DataType))
+	}
+	if key.IsColumn {
+		b.WriteString("::column")
+	}
+	if key.IsJSON {
+		b.WriteString("::json")
+	}
+	return b.String()
+}
+
+var filterStringEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`, "\t", `\t`, "\r", `\r`)
+
+func formatFilterValue(v interface{}) string {
+	switch v := v.(type) {
+	case string:
+		return `"` + filterStringEscaper.Replace(v) + `"`
+	case float64:
+		return strconv.FormatFloat(v, 'g', -1, 64)
+	case float32:
+		return strconv.FormatFloat(float64(v), 'g', -1, 32)
+	case []interface{}:
+		values := make([]string, 0, len(v))
+		for _, e := range v {
+			values = append(values, formatFilterValue(e))
+		}
+		return "(" + strings.Join(values, ", ") + ")"
+	case []string:
+		values := make([]string, 0, len(v))
+		for _, e := range v {
+			values = append(values, formatFilterValue(e))
+		}
+		return "(" + strings.Join(values, ", ") + ")"
+	case nil:
+		return `""`
+	default:
+		return fmt.Sprintf("%v", v)
+	}
+}
+
+var filterOperatorKeywords = map[FilterOperator]string{
+	FilterOperatorIn:          "IN",
+	FilterOperatorNotIn:       "NOT IN",
+	FilterOperatorContains:    "CONTAINS",
+	FilterOperatorNotContains: "NOT CONTAINS",
+	FilterOperatorRegex:       "REGEXP",
+	FilterOperatorNotRegex:    "NOT REGEXP",
+	FilterOperatorLike:        "LIKE",
+	FilterOperatorNotLike:     "NOT LIKE",
+}
+
+// FormatFilterItem prints the condition of the filter item in the
+// filter expression syntax
+func FormatFilterItem(item FilterItem) string {
+	key := formatFilterKey(item.Key)
+	op := FilterOperator(strings.ToLower(string(item.Operator)))
+	switch op {
+	case FilterOperatorExists:
+		return key + " EXISTS"
+	case FilterOperatorNotExists:
+		return key + " NOT EXISTS"
+	case FilterOperatorHas:
+		return fmt.Sprintf("has(%s, %s)", key, formatFilterValue(item.Value))
+	case FilterOperatorNotHas:
+		return fmt.Sprintf("%s NOT HAS %s", key, formatFilterValue(item.Value))
+	case FilterOperatorIn, FilterOperatorNotIn:
+		value := formatFilterValue(item.Value)
+		if !strings.HasPrefix(value, "(") {
+			value = "(" + value + ")"
+		}
+		return fmt.Sprintf("%s %s %s", key, filterOperatorKeywords[op], value)
+	}
+	if kw, ok := filterOperatorKeywords[op]; ok {
+		return fmt.Sprintf("%s %s %s", key, kw, formatFilterValue(item.Value))
+	}
+	return fmt.Sprintf("%s %s %s", key, op, formatFilterValue(item.Value))
+}
+
+// FormatFilterExpression prints the filter set in the filter expression
+// syntax, parsing the result gives back an equivalent filter set. Nested
+// groups are printed in parentheses.
+func FormatFilterExpression(f *FilterSet) string {
+	if f == nil {
+		return ""
+	}
+	op := strings.ToUpper(f.Operator)
+	if op == "" {
+		op = "AND"
+	}
+	conditions := make([]string, 0, len(f.Items)+len(f.Groups))
+	for _, item := range f.Items {
+		conditions = append(conditions, FormatFilterItem(item))
+	}
+	for idx := range f.Groups {
+		group := FormatFilterExpression(&f.Groups[idx])
+		if group == "" {
+			continue
+		}
+		if !f.Groups[idx].Not {
+			group = "(" + group + ")"
+		}
+		conditions = append(conditions, group)
+	}
+	expr := strings.Join(conditions, " "+op+" ")
+	if f.Not && expr != "" {
+		expr = "NOT (" + expr + ")"
+	}
+	return expr
+}
+
+// UnmarshalJSON accepts a filter expression string in place of the
+// filter set object
+func (f *FilterSet) UnmarshalJSON(data []byte) error {
+	if trimmed := strings.TrimSpace(string(data)); strings.HasPrefix(trimmed, `"`) {
+		var expr string
+		if err := json.Unmarshal(data, &expr); err != nil {
+			return err
+		}
+		parsed, err := ParseFilterExpression(expr)
+		if err != nil {
+			return err
+		}
+		*f = *parsed
+		return nil
+	}
+
+	type filterSet FilterSet
+	return json.Unmarshal(data, (*filterSet)(f))
+}
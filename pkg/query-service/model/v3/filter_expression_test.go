@@ -0,0 +1,204 @@
+package v3
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseFilterExpression(t *testing.T) {
+	cases := []struct {
+		name     string
+		expr     string
+		expected *FilterSet
+	}{
+		{
+			name:     "empty",
+			expr:     "  ",
+			expected: &FilterSet{Operator: "AND", Items: []FilterItem{}},
+		},
+		{
+			name: "and",
+			expr: `service.name = "api" AND http.status_code >= 500 and body CONTAINS 'time\'out'`,
+			expected: &FilterSet{Operator: "AND", Items: []FilterItem{
+				{Key: AttributeKey{Key: "service.name"}, Operator: FilterOperatorEqual, Value: "api"},
+				{Key: AttributeKey{Key: "http.status_code"}, Operator: FilterOperatorGreaterThanOrEq, Value: float64(500)},
+				{Key: AttributeKey{Key: "body"}, Operator: FilterOperatorContains, Value: "time'out"},
+			}},
+		},
+		{
+			name: "or with parentheses",
+			expr: `(level IN ("error", "fatal") OR has(tags, "retry")) OR duration > -1.5e3`,
+			expected: &FilterSet{Operator: "OR", Items: []FilterItem{
+				{Key: AttributeKey{Key: "level"}, Operator: FilterOperatorIn, Value: []interface{}{"error", "fatal"}},
+				{Key: AttributeKey{Key: "tags"}, Operator: FilterOperatorHas, Value: "retry"},
+				{Key: AttributeKey{Key: "duration"}, Operator: FilterOperatorGreaterThan, Value: float64(-1500)},
+			}},
+		},
+		{
+			name: "typed keys",
+			expr: "resource:service.name::string != 'api' AND `weird key`::bool = true AND tag:ids::array(int64) NOT IN [1, 2]",
+			expected: &FilterSet{Operator: "AND", Items: []FilterItem{
+				{Key: AttributeKey{Key: "service.name", Type: AttributeKeyTypeResource, DataType: AttributeKeyDataTypeString}, Operator: FilterOperatorNotEqual, Value: "api"},
+				{Key: AttributeKey{Key: "weird key", DataType: AttributeKeyDataTypeBool}, Operator: FilterOperatorEqual, Value: true},
+				{Key: AttributeKey{Key: "ids", Type: AttributeKeyTypeTag, DataType: AttributeKeyDataTypeArrayInt64}, Operator: FilterOperatorNotIn, Value: []interface{}{float64(1), float64(2)}},
+			}},
+		},
+		{
+			name: "column and json keys",
+			expr: "body::string::column CONTAINS 'x' AND body.user.id::int64::json = 5 AND `a::json`::json EXISTS",
+			expected: &FilterSet{Operator: "AND", Items: []FilterItem{
+				{Key: AttributeKey{Key: "body", DataType: AttributeKeyDataTypeString, IsColumn: true}, Operator: FilterOperatorContains, Value: "x"},
+				{Key: AttributeKey{Key: "body.user.id", DataType: AttributeKeyDataTypeInt64, IsJSON: true}, Operator: FilterOperatorEqual, Value: float64(5)},
+				{Key: AttributeKey{Key: "a::json", IsJSON: true}, Operator: FilterOperatorExists},
+			}},
+		},
+		{
+			name: "or nested in and",
+			expr: `service.name = "api" AND (http.status_code >= 500 OR has(tags, "retry")) AND body CONTAINS "timeout"`,
+			expected: &FilterSet{Operator: "AND", Items: []FilterItem{
+				{Key: AttributeKey{Key: "service.name"}, Operator: FilterOperatorEqual, Value: "api"},
+				{Key: AttributeKey{Key: "body"}, Operator: FilterOperatorContains, Value: "timeout"},
+			}, Groups: []FilterSet{
+				{Operator: "OR", Items: []FilterItem{
+					{Key: AttributeKey{Key: "http.status_code"}, Operator: FilterOperatorGreaterThanOrEq, Value: float64(500)},
+					{Key: AttributeKey{Key: "tags"}, Operator: FilterOperatorHas, Value: "retry"},
+				}},
+			}},
+		},
+		{
+			name: "not",
+			expr: `trace_id EXISTS AND NOT (span.kind = "client" OR name NOT REGEXP "^GET") AND NOT has(tags, "a") AND msg NOT LIKE "%x%" AND tags NOT HAS "b"`,
+			expected: &FilterSet{Operator: "AND", Items: []FilterItem{
+				{Key: AttributeKey{Key: "trace_id"}, Operator: FilterOperatorExists},
+				{Key: AttributeKey{Key: "msg"}, Operator: FilterOperatorNotLike, Value: "%x%"},
+				{Key: AttributeKey{Key: "tags"}, Operator: FilterOperatorNotHas, Value: "b"},
+			}, Groups: []FilterSet{
+				{Operator: "OR", Not: true, Items: []FilterItem{
+					{Key: AttributeKey{Key: "span.kind"}, Operator: FilterOperatorEqual, Value: "client"},
+					{Key: AttributeKey{Key: "name"}, Operator: FilterOperatorNotRegex, Value: "^GET"},
+				}},
+				{Operator: "AND", Not: true, Items: []FilterItem{
+					{Key: AttributeKey{Key: "tags"}, Operator: FilterOperatorHas, Value: "a"},
+				}},
+			}},
+		},
+		{
+			name: "negated condition",
+			expr: `NOT a > 5`,
+			expected: &FilterSet{Operator: "AND", Not: true, Items: []FilterItem{
+				{Key: AttributeKey{Key: "a"}, Operator: FilterOperatorGreaterThan, Value: float64(5)},
+			}},
+		},
+		{
+			name: "double negation",
+			expr: `NOT NOT a > 5 AND NOT NOT (b = 1 AND c = 2)`,
+			expected: &FilterSet{Operator: "AND", Items: []FilterItem{
+				{Key: AttributeKey{Key: "a"}, Operator: FilterOperatorGreaterThan, Value: float64(5)},
+				{Key: AttributeKey{Key: "b"}, Operator: FilterOperatorEqual, Value: float64(1)},
+				{Key: AttributeKey{Key: "c"}, Operator: FilterOperatorEqual, Value: float64(2)},
+			}},
+		},
+		{
+			name: "nested groups",
+			expr: `(a = 1 AND b = 2) OR c = 3 OR NOT (d = 4 OR (e = 5 AND f = 6))`,
+			expected: &FilterSet{Operator: "OR", Items: []FilterItem{
+				{Key: AttributeKey{Key: "c"}, Operator: FilterOperatorEqual, Value: float64(3)},
+			}, Groups: []FilterSet{
+				{Operator: "AND", Items: []FilterItem{
+					{Key: AttributeKey{Key: "a"}, Operator: FilterOperatorEqual, Value: float64(1)},
+					{Key: AttributeKey{Key: "b"}, Operator: FilterOperatorEqual, Value: float64(2)},
+				}},
+				{Operator: "OR", Not: true, Items: []FilterItem{
+					{Key: AttributeKey{Key: "d"}, Operator: FilterOperatorEqual, Value: float64(4)},
+				}, Groups: []FilterSet{
+					{Operator: "AND", Items: []FilterItem{
+						{Key: AttributeKey{Key: "e"}, Operator: FilterOperatorEqual, Value: float64(5)},
+						{Key: AttributeKey{Key: "f"}, Operator: FilterOperatorEqual, Value: float64(6)},
+					}},
+				}},
+			}},
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			fs, err := ParseFilterExpression(c.expr)
+			require.NoError(t, err)
+			assert.Equal(t, c.expected, fs)
+
+			// the printed expression parses back to the same filter set
+			printed := FormatFilterExpression(fs)
+			reparsed, err := ParseFilterExpression(printed)
+			require.NoError(t, err, printed)
+			assert.Equal(t, fs, reparsed, printed)
+		})
+	}
+}
+
+func TestParseFilterExpressionErrors(t *testing.T) {
+	cases := []struct {
+		expr   string
+		line   int
+		column int
+		msg    string
+	}{
+		{expr: `service.name = `, line: 1, column: 16, msg: "expected value, found end of expression"},
+		{expr: `service.name "api"`, line: 1, column: 14, msg: `expected operator after "service.name", found "api"`},
+		{expr: "a = 1 AND\n  (b = 2 OR c = 'x)", line: 2, column: 17, msg: "unterminated string"},
+		{expr: `a = 1 AND (b = 2 OR )`, line: 1, column: 21, msg: `expected condition, found ")"`},
+		{expr: `a = 1 b = 2`, line: 1, column: 7, msg: `expected AND, OR or the end of the expression, found "b"`},
+		{expr: `a::text = 1`, line: 1, column: 4, msg: `invalid data type "text"`},
+		{expr: `a IN (1, 2`, line: 1, column: 11, msg: "expected ',' or the end of the list, found end of expression"},
+	}
+
+	for _, c := range cases {
+		_, err := ParseFilterExpression(c.expr)
+		require.Error(t, err, c.expr)
+		exprErr, ok := err.(*FilterExpressionError)
+		require.True(t, ok, c.expr)
+		assert.Equal(t, c.line, exprErr.Line, c.expr)
+		assert.Equal(t, c.column, exprErr.Column, c.expr)
+		assert.Equal(t, c.msg, exprErr.Msg, c.expr)
+	}
+}
+
+func TestFormatFilterExpression(t *testing.T) {
+	fs := &FilterSet{Operator: "or", Items: []FilterItem{
+		{Key: AttributeKey{Key: "and"}, Operator: "IN", Value: "x"},
+		{Key: AttributeKey{Key: "body", IsColumn: true}, Operator: FilterOperatorNotContains, Value: "a\"b\n"},
+		{Key: AttributeKey{Key: "count"}, Operator: FilterOperatorLessThan, Value: 10},
+		{Key: AttributeKey{Key: "host"}, Operator: FilterOperatorNotExists},
+		{Key: AttributeKey{Key: "tags"}, Operator: FilterOperatorNotHas, Value: "x"},
+	}}
+	assert.Equal(t, "`and` IN (\"x\") OR body::column NOT CONTAINS \"a\\\"b\\n\" OR count < 10 OR host NOT EXISTS OR tags NOT HAS \"x\"", FormatFilterExpression(fs))
+
+	fs = &FilterSet{Operator: "AND", Items: []FilterItem{
+		{Key: AttributeKey{Key: "a"}, Operator: FilterOperatorEqual, Value: "x"},
+	}, Groups: []FilterSet{
+		{Operator: "OR", Not: true, Items: []FilterItem{
+			{Key: AttributeKey{Key: "b"}, Operator: FilterOperatorExists},
+			{Key: AttributeKey{Key: "c"}, Operator: FilterOperatorGreaterThan, Value: 1},
+		}},
+	}}
+	assert.Equal(t, `a = "x" AND NOT (b EXISTS OR c > 1)`, FormatFilterExpression(fs))
+}
+
+func TestFilterSetUnmarshalExpression(t *testing.T) {
+	var q BuilderQuery
+	err := json.Unmarshal([]byte(`{"queryName": "A", "filters": "service.name = \"api\" AND status >= 500"}`), &q)
+	require.NoError(t, err)
+	require.NotNil(t, q.Filters)
+	assert.Equal(t, "AND", q.Filters.Operator)
+	assert.Len(t, q.Filters.Items, 2)
+
+	err = json.Unmarshal([]byte(`{"filters": {"op": "OR", "items": [{"key": {"key": "a"}, "op": "=", "value": "b"}]}}`), &q)
+	require.NoError(t, err)
+	assert.Equal(t, "OR", q.Filters.Operator)
+	assert.Equal(t, "b", q.Filters.Items[0].Value)
+
+	err = json.Unmarshal([]byte(`{"filters": "service.name ="}`), &q)
+	assert.Error(t, err)
+}
@@ -0,0 +1,111 @@
+package v3
+
+import (
+	"fmt"
+	"strings"
+)
+
+// TraceStructure selects traces by the spans they contain and the
+// relationships between them, e.g. a span of service A which is a parent
+// of a failing span of service B. A trace matches when it has a span for
+// every selector that isn't absent, no span for an absent selector and
+// every relation holds for some of the selected spans.
+type TraceStructure struct {
+	Spans     []SpanSelector `json:"spans"`
+	Relations []SpanRelation `json:"relations,omitempty"`
+}
+
+// SpanSelector is a named filter on the spans of a trace
+type SpanSelector struct {
+	Name    string     `json:"name"`
+	Filters *FilterSet `json:"filters"`
+	// Absent selects the traces without a matching span
+	Absent bool `json:"absent,omitempty"`
+}
+
+// SpanRelation requires a span of the selector Parent to be the parent of a
+// span of the selector Child. Direct only matches the immediate parent,
+// otherwise any ancestor matches.
+type SpanRelation struct {
+	Parent string `json:"parent"`
+	Child  string `json:"child"`
+	Direct bool   `json:"direct,omitempty"`
+}
+
+func (t *TraceStructure) Clone() *TraceStructure {
+	if t == nil {
+		return nil
+	}
+	clone := &TraceStructure{
+		Spans:     make([]SpanSelector, 0, len(t.Spans)),
+		Relations: t.Relations,
+	}
+	for _, span := range t.Spans {
+		clone.Spans = append(clone.Spans, SpanSelector{
+			Name:    span.Name,
+			Filters: span.Filters.Clone(),
+			Absent:  span.Absent,
+		})
+	}
+	return clone
+}
+
+func (t *TraceStructure) Validate() error {
+	if t == nil {
+		return nil
+	}
+	if len(t.Spans) == 0 {
+		return fmt.Errorf("at least one span selector is required")
+	}
+
+	selectors := map[string]SpanSelector{}
+	for _, span := range t.Spans {
+		if span.Name == "" {
+			return fmt.Errorf("span selector name is required")
+		}
+		if _, ok := selectors[span.Name]; ok {
+			return fmt.Errorf("duplicate span selector %s", span.Name)
+		}
+		if err := span.Filters.Validate(); err != nil {
+			return fmt.Errorf("filters of span selector %s are invalid: %w", span.Name, err)
+		}
+		selectors[span.Name] = span
+	}
+
+	for _, relation := range t.Relations {
+		for _, name := range []string{relation.Parent, relation.Child} {
+			span, ok := selectors[name]
+			if !ok {
+				return fmt.Errorf("unknown span selector %s in relation", name)
+			}
+			if span.Absent {
+				return fmt.Errorf("absent span selector %s can not be used in a relation", name)
+			}
+		}
+	}
+	return nil
+}
+
+// VisitItems calls fn with the filter items of all the span selectors
+func (t *TraceStructure) VisitItems(fn func(item *FilterItem)) {
+	if t == nil {
+		return
+	}
+	for _, span := range t.Spans {
+		span.Filters.VisitItems(fn)
+	}
+}
+
+func (t *TraceStructure) CacheKey() string {
+	if t == nil {
+		return ""
+	}
+	var parts []string
+	for idx, span := range t.Spans {
+		parts = append(parts, fmt.Sprintf("span-%d=%s,absent:%t,(%s)", idx, span.Name, span.Absent, span.Filters.CacheKey()))
+	}
+	for idx, relation := range t.Relations {
+		parts = append(parts, fmt.Sprintf("relation-%d=%s>%s,direct:%t", idx, relation.Parent, relation.Child, relation.Direct))
+	}
+	return strings.Join(parts, "&")
+}
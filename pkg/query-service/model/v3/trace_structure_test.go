@@ -0,0 +1,56 @@
+package v3
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTraceStructureValidate(t *testing.T) {
+	structure := func() *TraceStructure {
+		return &TraceStructure{
+			Spans: []SpanSelector{
+				{Name: "a", Filters: &FilterSet{Operator: "AND", Items: []FilterItem{{Key: AttributeKey{Key: "serviceName"}, Value: "a", Operator: "="}}}},
+				{Name: "b", Filters: &FilterSet{Operator: "AND", Items: []FilterItem{{Key: AttributeKey{Key: "serviceName"}, Value: "b", Operator: "="}}}},
+				{Name: "c", Absent: true},
+			},
+			Relations: []SpanRelation{{Parent: "a", Child: "b"}},
+		}
+	}
+	assert.NoError(t, structure().Validate())
+
+	ts := structure()
+	ts.Spans[1].Name = "a"
+	assert.Error(t, ts.Validate())
+
+	ts = structure()
+	ts.Relations = append(ts.Relations, SpanRelation{Parent: "a", Child: "d"})
+	assert.Error(t, ts.Validate())
+
+	ts = structure()
+	ts.Relations = append(ts.Relations, SpanRelation{Parent: "c", Child: "b"})
+	assert.Error(t, ts.Validate())
+
+	query := &BuilderQuery{
+		QueryName:         "A",
+		Expression:        "A",
+		DataSource:        DataSourceTraces,
+		AggregateOperator: AggregateOperatorCount,
+		TraceStructure:    structure(),
+	}
+	assert.NoError(t, query.Validate(PanelTypeGraph))
+
+	query.GroupBy = []AttributeKey{{Key: "serviceName"}}
+	assert.Error(t, query.Validate(PanelTypeGraph))
+
+	query.GroupBy = nil
+	query.AggregateOperator = AggregateOperatorP99
+	query.AggregateAttribute = AttributeKey{Key: "durationNano"}
+	assert.Error(t, query.Validate(PanelTypeGraph))
+
+	// the structure is part of the clone and of the cache key
+	clone := query.Clone()
+	clone.TraceStructure.Spans[0].Filters.Items[0].Value = "x"
+	assert.Equal(t, "a", query.TraceStructure.Spans[0].Filters.Items[0].Value)
+	assert.NotEqual(t, query.TraceStructure.CacheKey(), clone.TraceStructure.CacheKey())
+}
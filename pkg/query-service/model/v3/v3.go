@@ -203,15 +203,51 @@ const (
 	PanelTypeTrace PanelType = "trace"
 )
 
+// The heatmap and histogram panels show the distribution of the values of the
+// aggregate attribute in buckets, per step or over the whole time range
+const (
+	PanelTypeHeatmap   PanelType = "heatmap"
+	PanelTypeHistogram PanelType = "histogram"
+)
+
 func (p PanelType) Validate() error {
 	switch p {
-	case PanelTypeValue, PanelTypeGraph, PanelTypeTable, PanelTypeList, PanelTypeTrace:
+	case PanelTypeValue, PanelTypeGraph, PanelTypeTable, PanelTypeList, PanelTypeTrace, PanelTypeHeatmap, PanelTypeHistogram:
 		return nil
 	default:
 		return fmt.Errorf("invalid panel type: %s", p)
 	}
 }
 
+// IsDistribution returns true for the panel types with bucketed results
+func (p PanelType) IsDistribution() bool {
+	return p == PanelTypeHeatmap || p == PanelTypeHistogram
+}
+
+// HistogramBuckets are the buckets of the heatmap and histogram panels, a value
+// is counted in the bucket with the smallest upper bound not less than it. The
+// buckets are log-scale with powers of Base as upper bounds unless explicit
+// bounds are given, values above the largest explicit bound go to +Inf.
+type HistogramBuckets struct {
+	Bounds []float64 `json:"bounds,omitempty"`
+	Base   float64   `json:"base,omitempty"`
+}
+
+func (h *HistogramBuckets) Validate() error {
+	if h == nil {
+		return nil
+	}
+	for idx := 1; idx < len(h.Bounds); idx++ {
+		if h.Bounds[idx] <= h.Bounds[idx-1] {
+			return fmt.Errorf("bucket bounds must be in increasing order")
+		}
+	}
+	if h.Base != 0 && h.Base <= 1 {
+		return fmt.Errorf("bucket base must be greater than 1")
+	}
+	return nil
+}
+
 // AggregateAttributeRequest is a request to fetch possible attribute keys
 // for a selected aggregate operator and search text.
 // The context of the selected aggregate operator is used as the
@@ -731,6 +767,15 @@ const (
 	FunctionNameAnomaly     FunctionName = "anomaly"
 )
 
+// forecasting and trend functions
+const (
+	FunctionNameHoltWinters      FunctionName = "holtWinters"
+	FunctionNameLinearRegression FunctionName = "linearRegression"
+	FunctionNamePredictLinear    FunctionName = "predictLinear"
+	FunctionNameDerivative       FunctionName = "derivative"
+	FunctionNameIntegral         FunctionName = "integral"
+)
+
 func (f FunctionName) Validate() error {
 	switch f {
 	case FunctionNameCutOffMin,
@@ -749,7 +794,12 @@ func (f FunctionName) Validate() error {
 		FunctionNameMedian5,
 		FunctionNameMedian7,
 		FunctionNameTimeShift,
-		FunctionNameAnomaly:
+		FunctionNameAnomaly,
+		FunctionNameHoltWinters,
+		FunctionNameLinearRegression,
+		FunctionNamePredictLinear,
+		FunctionNameDerivative,
+		FunctionNameIntegral:
 		return nil
 	default:
 		return fmt.Errorf("invalid function name: %s", f)
@@ -784,6 +834,10 @@ type BuilderQuery struct {
 	TimeAggregation      TimeAggregation   `json:"timeAggregation,omitempty"`
 	SpaceAggregation     SpaceAggregation  `json:"spaceAggregation,omitempty"`
 	Functions            []Function        `json:"functions,omitempty"`
+	TraceStructure       *TraceStructure   `json:"traceStructure,omitempty"`
+	Buckets              *HistogramBuckets `json:"buckets,omitempty"`
+	VectorMatching       *VectorMatching   `json:"vectorMatching,omitempty"`
+	Comparison           *Comparison       `json:"comparison,omitempty"`
 	ShiftBy              int64
 	IsAnomaly            bool
 	QueriesUsedInFormula []string
@@ -815,6 +869,10 @@ func (b *BuilderQuery) Clone() *BuilderQuery {
 		TimeAggregation:      b.TimeAggregation,
 		SpaceAggregation:     b.SpaceAggregation,
 		Functions:            b.Functions,
+		TraceStructure:       b.TraceStructure.Clone(),
+		Buckets:              b.Buckets,
+		VectorMatching:       b.VectorMatching,
+		Comparison:           b.Comparison,
 		ShiftBy:              b.ShiftBy,
 		IsAnomaly:            b.IsAnomaly,
 		QueriesUsedInFormula: b.QueriesUsedInFormula,
@@ -929,10 +987,67 @@ func (b *BuilderQuery) Validate(panelType PanelType) error {
 		}
 	}
 
+	if b.TraceStructure != nil {
+		if b.DataSource != DataSourceTraces {
+			return fmt.Errorf("trace structure is only supported for traces")
+		}
+		if b.AggregateOperator != AggregateOperatorCount && b.AggregateOperator != AggregateOperatorNoOp {
+			return fmt.Errorf("trace structure only supports the count and noop aggregate operators")
+		}
+		if !b.Filters.IsEmpty() {
+			return fmt.Errorf("filters are not supported with trace structure, use the filters of the span selectors")
+		}
+		if len(b.GroupBy) > 0 {
+			return fmt.Errorf("group by is not supported with trace structure")
+		}
+		if err := b.TraceStructure.Validate(); err != nil {
+			return fmt.Errorf("trace structure is invalid: %w", err)
+		}
+	}
+
 	if b.Expression == "" {
 		return fmt.Errorf("expression is required")
 	}
 
+	if b.VectorMatching != nil {
+		if b.QueryName == b.Expression {
+			return fmt.Errorf("vector matching is only supported for formulas")
+		}
+		if err := b.VectorMatching.Validate(); err != nil {
+			return fmt.Errorf("vector matching is invalid: %w", err)
+		}
+	}
+
+	if b.Comparison != nil {
+		if b.QueryName != b.Expression {
+			return fmt.Errorf("comparison is not supported for formulas")
+		}
+		if panelType == PanelTypeList || panelType == PanelTypeTrace || panelType.IsDistribution() {
+			return fmt.Errorf("comparison is not supported for %s panel type", panelType)
+		}
+		if err := b.Comparison.Validate(); err != nil {
+			return fmt.Errorf("comparison is invalid: %w", err)
+		}
+	}
+
+	if panelType.IsDistribution() {
+		if b.QueryName != b.Expression {
+			return fmt.Errorf("formulas are not supported for %s panel type", panelType)
+		}
+		if len(b.GroupBy) > 0 {
+			return fmt.Errorf("group by is not supported for %s panel type", panelType)
+		}
+		if b.AggregateAttribute.Key == "" {
+			return fmt.Errorf("aggregate attribute is required for %s panel type", panelType)
+		}
+		if b.DataSource == DataSourceMetrics && b.AggregateAttribute.Type != AttributeKeyType(MetricTypeHistogram) {
+			return fmt.Errorf("%s panel type requires a histogram metric", panelType)
+		}
+		if err := b.Buckets.Validate(); err != nil {
+			return fmt.Errorf("buckets are invalid: %w", err)
+		}
+	}
+
 	if len(b.Functions) > 0 {
 		for _, function := range b.Functions {
 			if err := function.Name.Validate(); err != nil {
@@ -985,6 +1100,26 @@ func (b *BuilderQuery) Validate(panelType PanelType) error {
 					}
 					function.Args[0] = threshold
 				}
+			} else if function.Name == FunctionNameHoltWinters {
+				if len(function.Args) < 2 {
+					return fmt.Errorf("smoothing factor and trend factor params missing in query")
+				}
+				for idx, name := range []string{"smoothing factor", "trend factor"} {
+					factor, err := floatFunctionArg(function.Args, idx)
+					if err != nil {
+						return fmt.Errorf("%s param should be a float", name)
+					}
+					if factor <= 0 || factor >= 1 {
+						return fmt.Errorf("%s param should be between 0 and 1", name)
+					}
+				}
+			} else if function.Name == FunctionNamePredictLinear {
+				if len(function.Args) == 0 {
+					return fmt.Errorf("horizon param missing in query")
+				}
+				if _, err := floatFunctionArg(function.Args, 0); err != nil {
+					return fmt.Errorf("horizon param should be a number of seconds")
+				}
 			}
 		}
 	}
@@ -992,19 +1127,54 @@ func (b *BuilderQuery) Validate(panelType PanelType) error {
 	return nil
 }
 
+// floatFunctionArg returns the float value of the argument of a function, a
+// string argument is converted to float in place
+func floatFunctionArg(args []interface{}, idx int) (float64, error) {
+	switch arg := args[idx].(type) {
+	case float64:
+		return arg, nil
+	case string:
+		value, err := strconv.ParseFloat(arg, 64)
+		if err != nil {
+			return 0, err
+		}
+		args[idx] = value
+		return value, nil
+	default:
+		return 0, fmt.Errorf("invalid argument type %T", arg)
+	}
+}
+
 type FilterSet struct {
 	Operator string       `json:"op,omitempty"`
 	Items    []FilterItem `json:"items"`
+
+	// Groups are nested filter sets, they are joined with the items by
+	// the operator of the set
+	Groups []FilterSet `json:"groups,omitempty"`
+	// Not negates the filter set
+	Not bool `json:"not,omitempty"`
 }
 
 func (f *FilterSet) Clone() *FilterSet {
 	if f == nil {
 		return nil
 	}
-	return &FilterSet{
+	clone := &FilterSet{
 		Operator: f.Operator,
-		Items:    f.Items,
+		Not:      f.Not,
+	}
+	if f.Items != nil {
+		clone.Items = make([]FilterItem, len(f.Items))
+		copy(clone.Items, f.Items)
+	}
+	if f.Groups != nil {
+		clone.Groups = make([]FilterSet, 0, len(f.Groups))
+		for _, group := range f.Groups {
+			clone.Groups = append(clone.Groups, *group.Clone())
+		}
 	}
+	return clone
 }
 
 func (f *FilterSet) Validate() error {
@@ -1019,9 +1189,164 @@ func (f *FilterSet) Validate() error {
 			return fmt.Errorf("filter item key is invalid: %w", err)
 		}
 	}
+	for idx := range f.Groups {
+		if err := f.Groups[idx].Validate(); err != nil {
+			return err
+		}
+	}
 	return nil
 }
 
+// IsConjunction returns true if all the items and groups of the set have
+// to match, i.e. the set is neither joined by OR nor negated
+func (f *FilterSet) IsConjunction() bool {
+	return f == nil || (!f.Not && !strings.EqualFold(f.Operator, "OR"))
+}
+
+// IsEmpty returns true if the set has no items in it or its groups
+func (f *FilterSet) IsEmpty() bool {
+	if f == nil {
+		return true
+	}
+	if len(f.Items) != 0 {
+		return false
+	}
+	for idx := range f.Groups {
+		if !f.Groups[idx].IsEmpty() {
+			return false
+		}
+	}
+	return true
+}
+
+// AndItems adds items which have to match in addition to the set. If the
+// set isn't a conjunction it's moved to a group of a new AND set.
+func (f *FilterSet) AndItems(items ...FilterItem) {
+	if !f.IsConjunction() {
+		*f = FilterSet{Operator: "AND", Items: []FilterItem{}, Groups: []FilterSet{*f}}
+	}
+	f.Items = append(f.Items, items...)
+}
+
+// And adds the filter set other which has to match in addition to the set
+func (f *FilterSet) And(other *FilterSet) {
+	if other == nil {
+		return
+	}
+	if other.IsConjunction() {
+		f.AndItems(other.Items...)
+		f.Groups = append(f.Groups, other.Groups...)
+		return
+	}
+	f.AndItems()
+	f.Groups = append(f.Groups, *other)
+}
+
+// VisitItems calls fn with the items of the set and of its nested groups,
+// the items can be modified in place
+func (f *FilterSet) VisitItems(fn func(item *FilterItem)) {
+	if f == nil {
+		return
+	}
+	for idx := range f.Items {
+		fn(&f.Items[idx])
+	}
+	for idx := range f.Groups {
+		f.Groups[idx].VisitItems(fn)
+	}
+}
+
+// FilterSyntax is the spelling of the boolean operators of the language a
+// filter set is rendered to
+type FilterSyntax struct {
+	And string
+	Or  string
+	Not string
+}
+
+var SQLFilterSyntax = FilterSyntax{And: "AND", Or: "OR", Not: "NOT"}
+
+// BuildCondition renders the filter set to a boolean condition, the items
+// are rendered by buildItem and items rendered to an empty string are left
+// out. Groups, negated sets and a set joined by OR are parenthesised so the
+// condition can be joined with others by AND. It returns an empty string if
+// nothing is rendered.
+func (f *FilterSet) BuildCondition(syntax FilterSyntax, buildItem func(item FilterItem) (string, error)) (string, error) {
+	if f == nil {
+		return "", nil
+	}
+	condition, n, err := f.buildCondition(syntax, buildItem)
+	if err != nil || n == 0 {
+		return "", err
+	}
+	if !f.Not && n > 1 && strings.EqualFold(f.Operator, "OR") {
+		condition = "(" + condition + ")"
+	}
+	return condition, nil
+}
+
+// buildCondition returns the condition of the set and the number of the
+// conditions joined in it
+func (f *FilterSet) buildCondition(syntax FilterSyntax, buildItem func(item FilterItem) (string, error)) (string, int, error) {
+	var conditions []string
+	for _, item := range f.Items {
+		condition, err := buildItem(item)
+		if err != nil {
+			return "", 0, err
+		}
+		if condition != "" {
+			conditions = append(conditions, condition)
+		}
+	}
+	for idx := range f.Groups {
+		condition, n, err := f.Groups[idx].buildCondition(syntax, buildItem)
+		if err != nil {
+			return "", 0, err
+		}
+		if n > 1 && !f.Groups[idx].Not {
+			condition = "(" + condition + ")"
+		}
+		if n > 0 {
+			conditions = append(conditions, condition)
+		}
+	}
+	if len(conditions) == 0 {
+		return "", 0, nil
+	}
+
+	op := syntax.And
+	if strings.EqualFold(f.Operator, "OR") {
+		op = syntax.Or
+	}
+	condition := strings.Join(conditions, " "+op+" ")
+	if f.Not {
+		condition = syntax.Not + " (" + condition + ")"
+	}
+	return condition, len(conditions), nil
+}
+
+// CacheKey returns the key of the filter set for caching query results, it
+// includes the operator and the nested groups when they affect the result
+func (f *FilterSet) CacheKey() string {
+	if f == nil {
+		return ""
+	}
+	var parts []string
+	if strings.EqualFold(f.Operator, "OR") {
+		parts = append(parts, "filterOp=OR")
+	}
+	if f.Not {
+		parts = append(parts, "filterNot=true")
+	}
+	for idx, filter := range f.Items {
+		parts = append(parts, fmt.Sprintf("filter-%d=%s", idx, filter.CacheKey()))
+	}
+	for idx := range f.Groups {
+		parts = append(parts, fmt.Sprintf("filterGroup-%d=(%s)", idx, f.Groups[idx].CacheKey()))
+	}
+	return strings.Join(parts, "&")
+}
+
 // For serializing to and from db
 func (f *FilterSet) Scan(src interface{}) error {
 	if data, ok := src.([]byte); ok {
@@ -1166,6 +1491,21 @@ type Result struct {
 	AnomalyScores    []*Series `json:"anomalyScores,omitempty"`
 	List             []*Row    `json:"list,omitempty"`
 	Table            *Table    `json:"table,omitempty"`
+	Heatmap          *Heatmap  `json:"heatmap,omitempty"`
+
+	// Comparison is set for the queries with a comparison with the previous period
+	Comparison *ComparisonResult `json:"comparison,omitempty"`
+}
+
+// Heatmap is the result of the heatmap and histogram panels
+type Heatmap struct {
+	// Bounds are the upper bounds of the buckets in increasing order,
+	// formatted like the values of the points
+	Bounds []string `json:"bounds"`
+	// Timestamps are the starts of the steps, the histogram panel has a single one
+	Timestamps []int64 `json:"timestamps"`
+	// Counts has the counts of the buckets for each of the timestamps
+	Counts [][]float64 `json:"counts"`
 }
 
 type Series struct {
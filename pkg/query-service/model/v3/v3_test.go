@@ -0,0 +1,161 @@
+package v3
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func nestedTestFilterSet() *FilterSet {
+	return &FilterSet{Operator: "OR", Items: []FilterItem{
+		{Key: AttributeKey{Key: "a"}, Operator: FilterOperatorEqual, Value: 1},
+	}, Groups: []FilterSet{
+		{Operator: "AND", Items: []FilterItem{
+			{Key: AttributeKey{Key: "b"}, Operator: FilterOperatorEqual, Value: 2},
+			{Key: AttributeKey{Key: "c"}, Operator: FilterOperatorEqual, Value: 3},
+		}},
+		{Operator: "AND", Not: true, Items: []FilterItem{
+			{Key: AttributeKey{Key: "d"}, Operator: FilterOperatorEqual, Value: 4},
+		}},
+	}}
+}
+
+func TestFilterSetBuildCondition(t *testing.T) {
+	buildItem := func(item FilterItem) (string, error) {
+		if item.Key.Key == "skip" {
+			return "", nil
+		}
+		return fmt.Sprintf("%s = %v", item.Key.Key, item.Value), nil
+	}
+
+	condition, err := nestedTestFilterSet().BuildCondition(SQLFilterSyntax, buildItem)
+	require.NoError(t, err)
+	assert.Equal(t, "(a = 1 OR (b = 2 AND c = 3) OR NOT (d = 4))", condition)
+
+	condition, err = nestedTestFilterSet().BuildCondition(FilterSyntax{And: "and", Or: "or", Not: "not"}, buildItem)
+	require.NoError(t, err)
+	assert.Equal(t, "(a = 1 or (b = 2 and c = 3) or not (d = 4))", condition)
+
+	// the items left out don't need parentheses
+	fs := &FilterSet{Operator: "OR", Items: []FilterItem{{Key: AttributeKey{Key: "a"}, Value: 1}, {Key: AttributeKey{Key: "skip"}}}}
+	condition, err = fs.BuildCondition(SQLFilterSyntax, buildItem)
+	require.NoError(t, err)
+	assert.Equal(t, "a = 1", condition)
+
+	var empty *FilterSet
+	condition, err = empty.BuildCondition(SQLFilterSyntax, buildItem)
+	require.NoError(t, err)
+	assert.Equal(t, "", condition)
+
+	_, err = nestedTestFilterSet().BuildCondition(SQLFilterSyntax, func(item FilterItem) (string, error) {
+		return "", fmt.Errorf("unsupported operator")
+	})
+	assert.Error(t, err)
+}
+
+func TestFilterSetCacheKey(t *testing.T) {
+	flat := &FilterSet{Operator: "AND", Items: []FilterItem{
+		{Key: AttributeKey{Key: "a"}, Operator: FilterOperatorEqual, Value: 1},
+	}}
+	assert.Equal(t, "filter-0=key:a---false,op:=,value:1", flat.CacheKey())
+
+	or := flat.Clone()
+	or.Operator = "OR"
+	assert.NotEqual(t, flat.CacheKey(), or.CacheKey())
+
+	nested := nestedTestFilterSet()
+	negated := nestedTestFilterSet()
+	negated.Groups[1].Not = false
+	assert.NotEqual(t, nested.CacheKey(), negated.CacheKey())
+}
+
+func TestFilterSetAndItems(t *testing.T) {
+	item := FilterItem{Key: AttributeKey{Key: "id"}, Operator: FilterOperatorLessThan, Value: "x"}
+
+	fs := &FilterSet{Operator: "AND", Items: []FilterItem{}}
+	fs.AndItems(item)
+	assert.Equal(t, &FilterSet{Operator: "AND", Items: []FilterItem{item}}, fs)
+
+	// a set joined by OR is nested in a group
+	fs = nestedTestFilterSet()
+	fs.AndItems(item)
+	assert.Equal(t, &FilterSet{Operator: "AND", Items: []FilterItem{item}, Groups: []FilterSet{*nestedTestFilterSet()}}, fs)
+
+	count := 0
+	fs.VisitItems(func(item *FilterItem) {
+		count++
+	})
+	assert.Equal(t, 5, count)
+}
+
+func TestFilterSetClone(t *testing.T) {
+	fs := nestedTestFilterSet()
+	clone := fs.Clone()
+	assert.Equal(t, fs, clone)
+
+	clone.VisitItems(func(item *FilterItem) {
+		item.Value = 0
+	})
+	assert.Equal(t, nestedTestFilterSet(), fs)
+}
+
+func TestBuilderQueryValidateDistribution(t *testing.T) {
+	query := func() *BuilderQuery {
+		return &BuilderQuery{
+			QueryName:          "A",
+			Expression:         "A",
+			StepInterval:       60,
+			DataSource:         DataSourceTraces,
+			AggregateOperator:  AggregateOperatorCount,
+			AggregateAttribute: AttributeKey{Key: "durationNano", DataType: AttributeKeyDataTypeFloat64, IsColumn: true},
+		}
+	}
+	assert.NoError(t, query().Validate(PanelTypeHeatmap))
+
+	grouped := query()
+	grouped.GroupBy = []AttributeKey{{Key: "serviceName", DataType: AttributeKeyDataTypeString, IsColumn: true}}
+	assert.Error(t, grouped.Validate(PanelTypeHistogram))
+
+	buckets := query()
+	buckets.Buckets = &HistogramBuckets{Bounds: []float64{10, 5}}
+	assert.Error(t, buckets.Validate(PanelTypeHeatmap))
+	buckets.Buckets = &HistogramBuckets{Base: 1}
+	assert.Error(t, buckets.Validate(PanelTypeHeatmap))
+
+	metric := query()
+	metric.DataSource = DataSourceMetrics
+	metric.AggregateOperator = AggregateOperatorRate
+	metric.AggregateAttribute = AttributeKey{Key: "signoz_calls_total", Type: AttributeKeyType(MetricTypeSum)}
+	assert.Error(t, metric.Validate(PanelTypeHeatmap))
+	metric.AggregateAttribute.Type = AttributeKeyType(MetricTypeHistogram)
+	assert.NoError(t, metric.Validate(PanelTypeHeatmap))
+}
+
+func TestBuilderQueryValidateForecastFunctions(t *testing.T) {
+	query := func(functions ...Function) *BuilderQuery {
+		return &BuilderQuery{
+			QueryName:          "A",
+			Expression:         "A",
+			StepInterval:       60,
+			DataSource:         DataSourceMetrics,
+			AggregateOperator:  AggregateOperatorSum,
+			AggregateAttribute: AttributeKey{Key: "system_filesystem_usage"},
+			Functions:          functions,
+		}
+	}
+
+	holtWinters := query(Function{Name: FunctionNameHoltWinters, Args: []interface{}{"0.3", 0.1}})
+	require.NoError(t, holtWinters.Validate(PanelTypeGraph))
+	assert.Equal(t, 0.3, holtWinters.Functions[0].Args[0])
+
+	assert.Error(t, query(Function{Name: FunctionNameHoltWinters, Args: []interface{}{0.3}}).Validate(PanelTypeGraph))
+	assert.Error(t, query(Function{Name: FunctionNameHoltWinters, Args: []interface{}{0.3, 1.0}}).Validate(PanelTypeGraph))
+
+	assert.NoError(t, query(Function{Name: FunctionNamePredictLinear, Args: []interface{}{float64(14400)}}).Validate(PanelTypeGraph))
+	assert.Error(t, query(Function{Name: FunctionNamePredictLinear}).Validate(PanelTypeGraph))
+	assert.Error(t, query(Function{Name: FunctionNamePredictLinear, Args: []interface{}{"4h"}}).Validate(PanelTypeGraph))
+
+	assert.NoError(t, query(Function{Name: FunctionNameDerivative}, Function{Name: FunctionNameIntegral}, Function{Name: FunctionNameLinearRegression}).Validate(PanelTypeGraph))
+}
@@ -0,0 +1,178 @@
+package v3
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// VectorMatchingGroup selects the side of a formula with many series for
+// each match group, like group_left and group_right of PromQL
+type VectorMatchingGroup string
+
+const (
+	VectorMatchingGroupNone  VectorMatchingGroup = ""
+	VectorMatchingGroupLeft  VectorMatchingGroup = "left"
+	VectorMatchingGroupRight VectorMatchingGroup = "right"
+)
+
+// VectorMatching is the explicit matching of the series of the queries of a
+// formula. The series are matched on the Labels when On is set, otherwise
+// on all of their labels but the Labels. Without a Group each match group has
+// at most one series of every query. With a Group the first (left) or the last
+// (right) query of the formula can have many series in a match group, the
+// result series have its labels and the Include labels of the other queries.
+//
+// It can also be written in the expression like in PromQL, e.g.
+//
+//	A / on(service_name) group_left(team) B
+//	A * ignoring(status_code) B
+type VectorMatching struct {
+	On      bool                `json:"on,omitempty"`
+	Labels  []string            `json:"labels,omitempty"`
+	Group   VectorMatchingGroup `json:"group,omitempty"`
+	Include []string            `json:"include,omitempty"`
+}
+
+func (m *VectorMatching) Validate() error {
+	if m == nil {
+		return nil
+	}
+	switch m.Group {
+	case VectorMatchingGroupNone:
+		if len(m.Include) > 0 {
+			return fmt.Errorf("include labels can only be used with group left or right")
+		}
+	case VectorMatchingGroupLeft, VectorMatchingGroupRight:
+	default:
+		return fmt.Errorf("invalid vector matching group: %s", m.Group)
+	}
+	if m.On {
+		for _, label := range m.Include {
+			for _, matching := range m.Labels {
+				if label == matching {
+					return fmt.Errorf("label %s must not occur in both on and group_%s", label, m.Group)
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// Signature returns the key of the match group of the labels
+func (m *VectorMatching) Signature(labels map[string]string) string {
+	parts := []string{}
+	if m.On {
+		for _, label := range m.Labels {
+			parts = append(parts, fmt.Sprintf("%s=%s", label, labels[label]))
+		}
+	} else {
+		ignored := map[string]struct{}{}
+		for _, label := range m.Labels {
+			ignored[label] = struct{}{}
+		}
+		for key, value := range labels {
+			if _, ok := ignored[key]; !ok {
+				parts = append(parts, fmt.Sprintf("%s=%s", key, value))
+			}
+		}
+	}
+	sort.Strings(parts)
+	return strings.Join(parts, ",")
+}
+
+func (m *VectorMatching) CacheKey() string {
+	if m == nil {
+		return ""
+	}
+	return fmt.Sprintf("on=%t,labels=%s,group=%s,include=%s", m.On, strings.Join(m.Labels, ","), m.Group, strings.Join(m.Include, ","))
+}
+
+// String formats the matching like the modifiers of a PromQL binary operator
+func (m *VectorMatching) String() string {
+	if m == nil {
+		return ""
+	}
+	modifier := "ignoring"
+	if m.On {
+		modifier = "on"
+	}
+	str := fmt.Sprintf("%s(%s)", modifier, strings.Join(m.Labels, ", "))
+	if m.Group != VectorMatchingGroupNone {
+		str += fmt.Sprintf(" group_%s(%s)", m.Group, strings.Join(m.Include, ", "))
+	}
+	return str
+}
+
+var vectorMatchingRegexp = regexp.MustCompile(`\b(on|ignoring)\s*\(([^()]*)\)(?:\s*(group_left|group_right)\b(?:\s*\(([^()]*)\))?)?`)
+
+var vectorMatchingGroupRegexp = regexp.MustCompile(`\b(group_left|group_right)\b`)
+
+func splitLabels(list string) []string {
+	labels := []string{}
+	for _, label := range strings.Split(list, ",") {
+		if label = strings.TrimSpace(label); label != "" {
+			labels = append(labels, label)
+		}
+	}
+	return labels
+}
+
+// ParseVectorMatching removes the vector matching modifiers from the formula
+// expression. The modifiers of all the operators of the formula must be the
+// same since the matching applies to the whole formula.
+func ParseVectorMatching(expression string) (string, *VectorMatching, error) {
+	var matching *VectorMatching
+	var err error
+	stripped := vectorMatchingRegexp.ReplaceAllStringFunc(expression, func(modifiers string) string {
+		groups := vectorMatchingRegexp.FindStringSubmatch(modifiers)
+		current := &VectorMatching{
+			On:     groups[1] == "on",
+			Labels: splitLabels(groups[2]),
+		}
+		if groups[3] != "" {
+			current.Group = VectorMatchingGroup(strings.TrimPrefix(groups[3], "group_"))
+			current.Include = splitLabels(groups[4])
+		}
+		if matching != nil && matching.CacheKey() != current.CacheKey() && err == nil {
+			err = fmt.Errorf("all the operators of a formula must use the same vector matching, found %s and %s", matching, current)
+		}
+		if matching == nil {
+			matching = current
+		}
+		return " "
+	})
+	if err != nil {
+		return "", nil, err
+	}
+	if vectorMatchingGroupRegexp.MatchString(stripped) {
+		return "", nil, fmt.Errorf("group_left and group_right must follow on or ignoring in formula %s", expression)
+	}
+	if matching == nil {
+		return expression, nil, nil
+	}
+	return strings.Join(strings.Fields(stripped), " "), matching, matching.Validate()
+}
+
+// UnmarshalJSON moves the vector matching modifiers of the expression of a
+// formula to VectorMatching
+func (b *BuilderQuery) UnmarshalJSON(data []byte) error {
+	type builderQuery BuilderQuery
+	if err := json.Unmarshal(data, (*builderQuery)(b)); err != nil {
+		return err
+	}
+	expression, matching, err := ParseVectorMatching(b.Expression)
+	if err != nil {
+		return err
+	}
+	if matching != nil {
+		if b.VectorMatching != nil {
+			return fmt.Errorf("vector matching of formula %s is set in both the expression and vectorMatching", b.QueryName)
+		}
+		b.Expression = expression
+		b.VectorMatching = matching
+	}
+	return nil
+}
@@ -0,0 +1,98 @@
+package v3
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseVectorMatching(t *testing.T) {
+	tests := []struct {
+		name       string
+		expression string
+		stripped   string
+		matching   *VectorMatching
+		wantErr    bool
+	}{
+		{
+			name:       "no modifiers",
+			expression: "A / B",
+			stripped:   "A / B",
+		},
+		{
+			name:       "on with group left",
+			expression: "A / on(service_name) group_left(team, tier) B",
+			stripped:   "A / B",
+			matching:   &VectorMatching{On: true, Labels: []string{"service_name"}, Group: VectorMatchingGroupLeft, Include: []string{"team", "tier"}},
+		},
+		{
+			name:       "ignoring",
+			expression: "(A*100) / ignoring (status_code, method) B",
+			stripped:   "(A*100) / B",
+			matching:   &VectorMatching{Labels: []string{"status_code", "method"}},
+		},
+		{
+			name:       "group right without labels",
+			expression: "A * on() group_right B",
+			stripped:   "A * B",
+			matching:   &VectorMatching{On: true, Labels: []string{}, Group: VectorMatchingGroupRight, Include: []string{}},
+		},
+		{
+			name:       "same modifiers for all operators",
+			expression: "A / on(service_name) B + on(service_name) C",
+			stripped:   "A / B + C",
+			matching:   &VectorMatching{On: true, Labels: []string{"service_name"}},
+		},
+		{
+			name:       "different modifiers",
+			expression: "A / on(service_name) B + ignoring(host) C",
+			wantErr:    true,
+		},
+		{
+			name:       "group without matching",
+			expression: "A / group_left B",
+			wantErr:    true,
+		},
+		{
+			name:       "include label in matching labels",
+			expression: "A / on(service_name) group_left(service_name) B",
+			wantErr:    true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			stripped, matching, err := ParseVectorMatching(tt.expression)
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.stripped, stripped)
+			assert.Equal(t, tt.matching, matching)
+		})
+	}
+}
+
+func TestBuilderQueryUnmarshalVectorMatching(t *testing.T) {
+	var query BuilderQuery
+	err := json.Unmarshal([]byte(`{"queryName": "C", "expression": "A / on(service_name) B", "disabled": false}`), &query)
+	require.NoError(t, err)
+	assert.Equal(t, "A / B", query.Expression)
+	assert.Equal(t, &VectorMatching{On: true, Labels: []string{"service_name"}}, query.VectorMatching)
+	assert.Equal(t, "on(service_name)", query.VectorMatching.String())
+
+	err = json.Unmarshal([]byte(`{"queryName": "C", "expression": "A / on(service_name) B", "vectorMatching": {"labels": ["host"]}}`), &BuilderQuery{})
+	assert.Error(t, err)
+}
+
+func TestVectorMatchingSignature(t *testing.T) {
+	labels := map[string]string{"service_name": "api", "host": "h1", "status_code": "500"}
+	on := &VectorMatching{On: true, Labels: []string{"service_name", "env"}}
+	assert.Equal(t, "env=,service_name=api", on.Signature(labels))
+
+	ignoring := &VectorMatching{Labels: []string{"status_code"}}
+	assert.Equal(t, "host=h1,service_name=api", ignoring.Signature(labels))
+}
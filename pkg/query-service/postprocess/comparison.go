@@ -0,0 +1,88 @@
+package postprocess
+
+import (
+	"math"
+	"strings"
+
+	v3 "go.signoz.io/signoz/pkg/query-service/model/v3"
+)
+
+// ApplyComparison moves the results of the previous period of the queries with a
+// comparison into the comparison of the results of the queries, along with the
+// delta and the percent change of the series found in both periods
+func ApplyComparison(results []*v3.Result, params *v3.QueryRangeParamsV3) []*v3.Result {
+	builderQueries := params.CompositeQuery.BuilderQueries
+	previousResults := make(map[string]*v3.Result)
+	newResults := make([]*v3.Result, 0, len(results))
+	for _, result := range results {
+		queryName := strings.TrimSuffix(result.QueryName, v3.ComparisonQuerySuffix)
+		if queryName != result.QueryName && builderQueries[queryName] != nil && builderQueries[queryName].Comparison != nil {
+			previousResults[queryName] = result
+			continue
+		}
+		newResults = append(newResults, result)
+	}
+	if len(previousResults) == 0 {
+		return results
+	}
+
+	// the table and value panels have a single point per series
+	singlePoint := params.CompositeQuery.PanelType == v3.PanelTypeTable || params.CompositeQuery.PanelType == v3.PanelTypeValue
+	for _, result := range newResults {
+		previous, ok := previousResults[result.QueryName]
+		if !ok {
+			continue
+		}
+		result.Comparison = compareSeries(result.Series, previous.Series, singlePoint)
+		result.Comparison.Offset = builderQueries[result.QueryName].Comparison.Offset
+	}
+	return newResults
+}
+
+func compareSeries(current, previous []*v3.Series, singlePoint bool) *v3.ComparisonResult {
+	comparison := &v3.ComparisonResult{
+		Previous:      previous,
+		Delta:         make([]*v3.Series, 0),
+		PercentChange: make([]*v3.Series, 0),
+	}
+
+	previousByLabels := make(map[string]*v3.Series)
+	for _, series := range previous {
+		previousByLabels[labelsKey(series.Labels)] = series
+	}
+
+	for _, series := range current {
+		previousSeries, ok := previousByLabels[labelsKey(series.Labels)]
+		if !ok {
+			continue
+		}
+		previousValues := make(map[int64]float64)
+		for _, point := range previousSeries.Points {
+			previousValues[point.Timestamp] = point.Value
+		}
+
+		delta := &v3.Series{Labels: series.Labels, LabelsArray: series.LabelsArray, Points: make([]v3.Point, 0)}
+		percentChange := &v3.Series{Labels: series.Labels, LabelsArray: series.LabelsArray, Points: make([]v3.Point, 0)}
+		for idx, point := range series.Points {
+			previousValue, ok := previousValues[point.Timestamp]
+			// the reduced points of the periods don't have the same timestamp
+			if singlePoint && idx == 0 && len(previousSeries.Points) > 0 {
+				previousValue, ok = previousSeries.Points[0].Value, true
+			}
+			if !ok || math.IsNaN(point.Value) || math.IsNaN(previousValue) {
+				continue
+			}
+			delta.Points = append(delta.Points, v3.Point{Timestamp: point.Timestamp, Value: point.Value - previousValue})
+			// there is no percent change from zero
+			if previousValue != 0 {
+				percentChange.Points = append(percentChange.Points, v3.Point{
+					Timestamp: point.Timestamp,
+					Value:     (point.Value - previousValue) / math.Abs(previousValue) * 100,
+				})
+			}
+		}
+		comparison.Delta = append(comparison.Delta, delta)
+		comparison.PercentChange = append(comparison.PercentChange, percentChange)
+	}
+	return comparison
+}
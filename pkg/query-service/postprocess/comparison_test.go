@@ -0,0 +1,116 @@
+package postprocess
+
+import (
+	"reflect"
+	"testing"
+
+	v3 "go.signoz.io/signoz/pkg/query-service/model/v3"
+)
+
+func TestApplyComparison(t *testing.T) {
+	series := func(service string, points ...v3.Point) *v3.Series {
+		return &v3.Series{
+			Labels:      map[string]string{"service_name": service},
+			LabelsArray: []map[string]string{{"service_name": service}},
+			Points:      points,
+		}
+	}
+	params := func(panelType v3.PanelType) *v3.QueryRangeParamsV3 {
+		return &v3.QueryRangeParamsV3{
+			CompositeQuery: &v3.CompositeQuery{
+				QueryType: v3.QueryTypeBuilder,
+				PanelType: panelType,
+				BuilderQueries: map[string]*v3.BuilderQuery{
+					"A":          {QueryName: "A", Expression: "A", Comparison: &v3.Comparison{Offset: 3600}},
+					"A_previous": {QueryName: "A_previous", Expression: "A_previous", Disabled: true},
+				},
+			},
+		}
+	}
+
+	t.Run("graph", func(t *testing.T) {
+		results := []*v3.Result{
+			{
+				QueryName: "A",
+				Series: []*v3.Series{
+					series("frontend", v3.Point{Timestamp: 1, Value: 15}, v3.Point{Timestamp: 2, Value: 10}, v3.Point{Timestamp: 3, Value: 4}),
+					series("redis", v3.Point{Timestamp: 1, Value: 1}),
+				},
+			},
+			{
+				QueryName: "A_previous",
+				Series: []*v3.Series{
+					series("frontend", v3.Point{Timestamp: 1, Value: 10}, v3.Point{Timestamp: 2, Value: 0}),
+				},
+			},
+		}
+
+		results = ApplyComparison(results, params(v3.PanelTypeGraph))
+		if len(results) != 1 {
+			t.Fatalf("expected the previous result to be removed, got %d results", len(results))
+		}
+		comparison := results[0].Comparison
+		if comparison == nil {
+			t.Fatalf("expected a comparison")
+		}
+		if comparison.Offset != 3600 {
+			t.Errorf("expected offset 3600, got %d", comparison.Offset)
+		}
+		if len(comparison.Previous) != 1 {
+			t.Errorf("expected one previous series, got %d", len(comparison.Previous))
+		}
+		if len(comparison.Delta) != 1 || len(comparison.PercentChange) != 1 {
+			t.Fatalf("expected the delta and percent change of the frontend series only, got %d and %d",
+				len(comparison.Delta), len(comparison.PercentChange))
+		}
+		expectedDelta := []v3.Point{{Timestamp: 1, Value: 5}, {Timestamp: 2, Value: 10}}
+		if !reflect.DeepEqual(comparison.Delta[0].Points, expectedDelta) {
+			t.Errorf("expected delta %v, got %v", expectedDelta, comparison.Delta[0].Points)
+		}
+		// no percent change from zero
+		expectedPercent := []v3.Point{{Timestamp: 1, Value: 50}}
+		if !reflect.DeepEqual(comparison.PercentChange[0].Points, expectedPercent) {
+			t.Errorf("expected percent change %v, got %v", expectedPercent, comparison.PercentChange[0].Points)
+		}
+	})
+
+	t.Run("table", func(t *testing.T) {
+		results := []*v3.Result{
+			{QueryName: "A", Series: []*v3.Series{series("frontend", v3.Point{Timestamp: 7200, Value: 8})}},
+			{QueryName: "A_previous", Series: []*v3.Series{series("frontend", v3.Point{Timestamp: 3600, Value: 10})}},
+		}
+
+		results = ApplyComparison(results, params(v3.PanelTypeTable))
+		if len(results) != 1 || results[0].Comparison == nil {
+			t.Fatalf("expected one result with a comparison, got %v", results)
+		}
+		if value := results[0].Comparison.Delta[0].Points[0].Value; value != -2 {
+			t.Errorf("expected delta -2, got %v", value)
+		}
+		if value := results[0].Comparison.PercentChange[0].Points[0].Value; value != -20 {
+			t.Errorf("expected percent change -20, got %v", value)
+		}
+
+		params := params(v3.PanelTypeTable)
+		params.CompositeQuery.BuilderQueries["A"].AggregateOperator = v3.AggregateOperatorSum
+		table := TransformToTableForBuilderQueries(results, params)
+		if len(table) != 1 || table[0].Table == nil {
+			t.Fatalf("expected a table")
+		}
+		columns := make([]string, 0)
+		for _, column := range table[0].Table.Columns {
+			columns = append(columns, column.Name)
+		}
+		expectedColumns := []string{"service_name", "A", "A_previous", "A_delta", "A_percentChange"}
+		if !reflect.DeepEqual(columns, expectedColumns) {
+			t.Errorf("expected columns %v, got %v", expectedColumns, columns)
+		}
+		if len(table[0].Table.Rows) != 1 {
+			t.Fatalf("expected one row, got %d", len(table[0].Table.Rows))
+		}
+		row := table[0].Table.Rows[0].Data
+		if row["A_previous"] != 10.0 || row["A_delta"] != -2.0 || row["A_percentChange"] != -20.0 {
+			t.Errorf("unexpected comparison values in row %v", row)
+		}
+	})
+}
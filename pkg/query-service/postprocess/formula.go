@@ -70,34 +70,45 @@ func joinAndCalculate(
 	canDefaultZero map[string]bool,
 ) (*v3.Series, error) {
 
-	uniqueTimestamps := make(map[int64]struct{})
-	// map[queryName]map[timestamp]value
-	seriesMap := make(map[string]map[int64]float64)
+	matchingSeries := make(map[string]*v3.Series)
 	for _, result := range results {
-		var matchingSeries *v3.Series
 		// We try to find a series that matches the label set from the current query result
 		for _, series := range result.Series {
 			if isSubset(uniqueLabelSet, series.Labels) {
-				matchingSeries = series
+				matchingSeries[result.QueryName] = series
 				break
 			}
 		}
+	}
+	return calculate(matchingSeries, uniqueLabelSet, expression, canDefaultZero)
+}
+
+// calculate evaluates the expression at the timestamps of the series joined
+// for the label set, matchingSeries has the series of each query
+func calculate(
+	matchingSeries map[string]*v3.Series,
+	labels map[string]string,
+	expression *govaluate.EvaluableExpression,
+	canDefaultZero map[string]bool,
+) (*v3.Series, error) {
 
-		// Prepare the seriesMap for quick lookup during evaluation
-		// seriesMap[queryName][timestamp]value contains the value of the series with the given queryName at the given timestamp
-		if matchingSeries != nil {
-			for _, point := range matchingSeries.Points {
-				if _, ok := seriesMap[result.QueryName]; !ok {
-					seriesMap[result.QueryName] = make(map[int64]float64)
-				}
-				seriesMap[result.QueryName][point.Timestamp] = point.Value
-				uniqueTimestamps[point.Timestamp] = struct{}{}
+	uniqueTimestamps := make(map[int64]struct{})
+	// map[queryName]map[timestamp]value
+	seriesMap := make(map[string]map[int64]float64)
+	// Prepare the seriesMap for quick lookup during evaluation
+	// seriesMap[queryName][timestamp]value contains the value of the series with the given queryName at the given timestamp
+	for queryName, series := range matchingSeries {
+		for _, point := range series.Points {
+			if _, ok := seriesMap[queryName]; !ok {
+				seriesMap[queryName] = make(map[int64]float64)
 			}
+			seriesMap[queryName][point.Timestamp] = point.Value
+			uniqueTimestamps[point.Timestamp] = struct{}{}
 		}
 	}
 
 	resultSeries := &v3.Series{
-		Labels: uniqueLabelSet,
+		Labels: labels,
 		Points: make([]v3.Point, 0),
 	}
 	timestamps := make([]int64, 0)
@@ -183,11 +194,7 @@ func processResults(
 			return nil, err
 		}
 		if series != nil && len(series.Points) != 0 {
-			labelsArray := make([]map[string]string, 0)
-			for k, v := range series.Labels {
-				labelsArray = append(labelsArray, map[string]string{k: v})
-			}
-			series.LabelsArray = labelsArray
+			setLabelsArray(series)
 			newSeries = append(newSeries, series)
 		}
 	}
@@ -197,6 +204,14 @@ func processResults(
 	}, nil
 }
 
+func setLabelsArray(series *v3.Series) {
+	labelsArray := make([]map[string]string, 0)
+	for k, v := range series.Labels {
+		labelsArray = append(labelsArray, map[string]string{k: v})
+	}
+	series.LabelsArray = labelsArray
+}
+
 var SupportedFunctions = []string{"exp", "log", "ln", "exp2", "log2", "exp10", "log10", "sqrt", "cbrt", "erf", "erfc", "lgamma", "tgamma", "sin", "cos", "tan", "asin", "acos", "atan", "degrees", "radians", "now", "toUnixTimestamp"}
 
 func EvalFuncs() map[string]govaluate.ExpressionFunction {
@@ -0,0 +1,93 @@
+package postprocess
+
+import (
+	"math"
+	"sort"
+	"strconv"
+
+	v3 "go.signoz.io/signoz/pkg/query-service/model/v3"
+)
+
+// TransformToHeatmap converts the series of the buckets returned for the heatmap
+// and histogram panels into a heatmap. Each series is the count of one bucket,
+// its upper bound is the le label. The buckets of the histogram metrics are
+// cumulative so they are converted to the count of the bucket alone. The
+// histogram panel has the counts of the whole time range at the start.
+func TransformToHeatmap(results []*v3.Result, params *v3.QueryRangeParamsV3) {
+	if !params.CompositeQuery.PanelType.IsDistribution() {
+		return
+	}
+	for _, result := range results {
+		query := params.CompositeQuery.BuilderQueries[result.QueryName]
+		if query == nil {
+			continue
+		}
+		cumulative := query.DataSource == v3.DataSourceMetrics
+		result.Heatmap = buildHeatmap(result.Series, cumulative, params.CompositeQuery.PanelType == v3.PanelTypeHistogram, params.Start)
+		result.Series = nil
+	}
+}
+
+func buildHeatmap(series []*v3.Series, cumulative, single bool, start int64) *v3.Heatmap {
+	counts := map[float64]map[int64]float64{}
+	timestamps := map[int64]struct{}{}
+	for _, s := range series {
+		bound, err := strconv.ParseFloat(s.Labels["le"], 64)
+		if err != nil || math.IsNaN(bound) {
+			continue
+		}
+		if _, ok := counts[bound]; !ok {
+			counts[bound] = map[int64]float64{}
+		}
+		for _, point := range s.Points {
+			counts[bound][point.Timestamp] += point.Value
+			timestamps[point.Timestamp] = struct{}{}
+		}
+	}
+
+	bounds := make([]float64, 0, len(counts))
+	for bound := range counts {
+		bounds = append(bounds, bound)
+	}
+	sort.Float64s(bounds)
+
+	heatmap := &v3.Heatmap{
+		Bounds:     make([]string, 0, len(bounds)),
+		Timestamps: make([]int64, 0, len(timestamps)),
+		Counts:     [][]float64{},
+	}
+	for _, bound := range bounds {
+		heatmap.Bounds = append(heatmap.Bounds, strconv.FormatFloat(bound, 'f', -1, 64))
+	}
+	for ts := range timestamps {
+		heatmap.Timestamps = append(heatmap.Timestamps, ts)
+	}
+	sort.Slice(heatmap.Timestamps, func(i, j int) bool { return heatmap.Timestamps[i] < heatmap.Timestamps[j] })
+
+	for _, ts := range heatmap.Timestamps {
+		row := make([]float64, len(bounds))
+		previous := 0.0
+		for idx, bound := range bounds {
+			count := counts[bound][ts]
+			if cumulative {
+				// the counts can decrease with the buckets missing in some of
+				// the series, there are no negative counts
+				count, previous = math.Max(count-previous, 0), math.Max(count, previous)
+			}
+			row[idx] = count
+		}
+		heatmap.Counts = append(heatmap.Counts, row)
+	}
+
+	if single {
+		total := make([]float64, len(bounds))
+		for _, row := range heatmap.Counts {
+			for idx, count := range row {
+				total[idx] += count
+			}
+		}
+		heatmap.Timestamps = []int64{start}
+		heatmap.Counts = [][]float64{total}
+	}
+	return heatmap
+}
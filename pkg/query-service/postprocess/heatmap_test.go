@@ -0,0 +1,83 @@
+package postprocess
+
+import (
+	"reflect"
+	"testing"
+
+	v3 "go.signoz.io/signoz/pkg/query-service/model/v3"
+)
+
+func TestTransformToHeatmap(t *testing.T) {
+	bucket := func(le string, values ...float64) *v3.Series {
+		series := &v3.Series{Labels: map[string]string{"le": le}}
+		for idx, value := range values {
+			series.Points = append(series.Points, v3.Point{Timestamp: int64(idx+1) * 60000, Value: value})
+		}
+		return series
+	}
+
+	tests := []struct {
+		name       string
+		panelType  v3.PanelType
+		dataSource v3.DataSource
+		series     []*v3.Series
+		expected   *v3.Heatmap
+	}{
+		{
+			name:       "traces heatmap",
+			panelType:  v3.PanelTypeHeatmap,
+			dataSource: v3.DataSourceTraces,
+			series:     []*v3.Series{bucket("inf", 1, 0), bucket("4", 2, 3), bucket("0.5", 5, 1)},
+			expected: &v3.Heatmap{
+				Bounds:     []string{"0.5", "4", "+Inf"},
+				Timestamps: []int64{60000, 120000},
+				Counts:     [][]float64{{5, 2, 1}, {1, 3, 0}},
+			},
+		},
+		{
+			name:       "cumulative metric buckets",
+			panelType:  v3.PanelTypeHeatmap,
+			dataSource: v3.DataSourceMetrics,
+			series:     []*v3.Series{bucket("+Inf", 10, 4), bucket("0.1", 3, 1), bucket("1", 8, 0)},
+			expected: &v3.Heatmap{
+				Bounds:     []string{"0.1", "1", "+Inf"},
+				Timestamps: []int64{60000, 120000},
+				Counts:     [][]float64{{3, 5, 2}, {1, 0, 3}},
+			},
+		},
+		{
+			name:       "histogram sums the steps",
+			panelType:  v3.PanelTypeHistogram,
+			dataSource: v3.DataSourceLogs,
+			series:     []*v3.Series{bucket("2", 1, 2), bucket("1", 3, 4), {Labels: map[string]string{}}},
+			expected: &v3.Heatmap{
+				Bounds:     []string{"1", "2"},
+				Timestamps: []int64{1000},
+				Counts:     [][]float64{{7, 3}},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			params := &v3.QueryRangeParamsV3{
+				Start: 1000,
+				CompositeQuery: &v3.CompositeQuery{
+					PanelType: tt.panelType,
+					BuilderQueries: map[string]*v3.BuilderQuery{
+						"A": {QueryName: "A", Expression: "A", DataSource: tt.dataSource},
+					},
+				},
+			}
+			results := []*v3.Result{{QueryName: "A", Series: tt.series}}
+			TransformToHeatmap(results, params)
+
+			if results[0].Series != nil {
+				t.Errorf("expected the series to be replaced by the heatmap")
+			}
+			if !reflect.DeepEqual(results[0].Heatmap, tt.expected) {
+				t.Errorf("expected heatmap %+v, got %+v", tt.expected, results[0].Heatmap)
+			}
+		})
+	}
+}
@@ -34,6 +34,9 @@ func PostProcessResult(result []*v3.Result, queryRangeParams *v3.QueryRangeParam
 	applyReduceTo(result, queryRangeParams)
 	// We apply the functions here it's easier to add new functions
 	ApplyFunctions(result, queryRangeParams)
+	// The previous period of a comparison is queried like any other query,
+	// the functions are applied to it before it is compared with the current one
+	result = ApplyComparison(result, queryRangeParams)
 
 	// expressions are executed at query serivce so the value of time.now in the invdividual
 	// queries will be different so for table panel we are making it same.
@@ -58,7 +61,12 @@ func PostProcessResult(result []*v3.Result, queryRangeParams *v3.QueryRangeParam
 				zap.L().Error("error in expression", zap.Error(err))
 				return nil, err
 			}
-			formulaResult, err := processResults(result, expression, canDefaultZero)
+			var formulaResult *v3.Result
+			if query.VectorMatching != nil {
+				formulaResult, err = processResultsWithMatching(result, expression, query.VectorMatching, canDefaultZero)
+			} else {
+				formulaResult, err = processResults(result, expression, canDefaultZero)
+			}
 			if err != nil {
 				zap.L().Error("error in expression", zap.Error(err))
 				return nil, err
@@ -87,6 +95,9 @@ func PostProcessResult(result []*v3.Result, queryRangeParams *v3.QueryRangeParam
 		FillGaps(result, queryRangeParams)
 	}
 
+	// the heatmap and histogram panels plot the buckets of the results
+	TransformToHeatmap(result, queryRangeParams)
+
 	if queryRangeParams.FormatForWeb &&
 		queryRangeParams.CompositeQuery.QueryType == v3.QueryTypeBuilder &&
 		queryRangeParams.CompositeQuery.PanelType == v3.PanelTypeTable {
@@ -41,7 +41,7 @@ func TransformToTableForBuilderQueries(results []*v3.Result, params *v3.QueryRan
 	seen := make(map[string]struct{})
 	labelKeys := []string{}
 	for _, result := range results {
-		for _, series := range result.Series {
+		for _, series := range valueColumnsSeries(result) {
 			for _, labels := range series.LabelsArray {
 				for key := range labels {
 					if _, ok := seen[key]; !ok {
@@ -61,13 +61,19 @@ func TransformToTableForBuilderQueries(results []*v3.Result, params *v3.QueryRan
 	}
 	for _, result := range results {
 		columns = append(columns, &v3.TableColumn{Name: result.QueryName, QueryName: result.QueryName, IsValueColumn: true})
+		// The comparison with the previous period has a column for each of its values
+		if result.Comparison != nil {
+			for _, name := range comparisonColumnNames(result.QueryName) {
+				columns = append(columns, &v3.TableColumn{Name: name, QueryName: result.QueryName, IsValueColumn: true})
+			}
+		}
 	}
 
 	// Create a map to store unique rows
 	rowMap := make(map[string]*v3.TableRow)
 
 	for _, result := range results {
-		for _, series := range result.Series {
+		for _, series := range valueColumnsSeries(result) {
 			if len(series.Points) == 0 {
 				continue
 			}
@@ -97,7 +103,7 @@ func TransformToTableForBuilderQueries(results []*v3.Result, params *v3.QueryRan
 
 			// Add the value for this query
 			for _, col := range columns {
-				if col.Name == result.QueryName {
+				if col.Name == series.column {
 					row.Data[col.Name] = roundToTwoDecimal(series.Points[0].Value)
 					break
 				}
@@ -142,6 +148,35 @@ func TransformToTableForBuilderQueries(results []*v3.Result, params *v3.QueryRan
 	return []*v3.Result{&tableResult}
 }
 
+// columnSeries is a series of the value column of a table
+type columnSeries struct {
+	*v3.Series
+	column string
+}
+
+// comparisonColumnNames returns the names of the previous, delta and percent
+// change columns of a query with a comparison
+func comparisonColumnNames(queryName string) []string {
+	return []string{queryName + v3.ComparisonQuerySuffix, queryName + "_delta", queryName + "_percentChange"}
+}
+
+// valueColumnsSeries returns the series of the result with their value column
+func valueColumnsSeries(result *v3.Result) []columnSeries {
+	series := make([]columnSeries, 0, len(result.Series))
+	for _, s := range result.Series {
+		series = append(series, columnSeries{Series: s, column: result.QueryName})
+	}
+	if result.Comparison != nil {
+		names := comparisonColumnNames(result.QueryName)
+		for idx, comparisonSeries := range [][]*v3.Series{result.Comparison.Previous, result.Comparison.Delta, result.Comparison.PercentChange} {
+			for _, s := range comparisonSeries {
+				series = append(series, columnSeries{Series: s, column: names[idx]})
+			}
+		}
+	}
+	return series
+}
+
 func sortRows(rows []*v3.TableRow, builderQueries map[string]*v3.BuilderQuery, queryNames []string) {
 	// use reverse order of queryNames
 	for i := len(queryNames) - 1; i >= 0; i-- {
@@ -0,0 +1,189 @@
+package postprocess
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/SigNoz/govaluate"
+	v3 "go.signoz.io/signoz/pkg/query-service/model/v3"
+)
+
+// processResultsWithMatching evaluates the formula on the series joined with the
+// explicit vector matching of the formula instead of the label subsets.
+// 1. Group the series of the queries on the one side by their match group
+// 2. Join the series of the many side, or of every match group for one-to-one
+// matching, with the series of the same match group of the other queries
+// 3. Calculate the new values of every join
+func processResultsWithMatching(
+	results []*v3.Result,
+	expression *govaluate.EvaluableExpression,
+	matching *v3.VectorMatching,
+	canDefaultZero map[string]bool,
+) (*v3.Result, error) {
+
+	resultsByQuery := make(map[string]*v3.Result)
+	for _, result := range results {
+		resultsByQuery[result.QueryName] = result
+	}
+	queryNames := make([]string, 0)
+	for _, v := range expression.Vars() {
+		if _, ok := resultsByQuery[v]; !ok {
+			continue
+		}
+		if !contains(queryNames, v) {
+			queryNames = append(queryNames, v)
+		}
+	}
+	if len(queryNames) == 0 {
+		return &v3.Result{Series: make([]*v3.Series, 0)}, nil
+	}
+
+	// the query with many series in a match group
+	var manyQuery string
+	switch matching.Group {
+	case v3.VectorMatchingGroupLeft:
+		manyQuery = queryNames[0]
+	case v3.VectorMatchingGroupRight:
+		manyQuery = queryNames[len(queryNames)-1]
+	}
+
+	// map[signature]map[queryName]series for the queries of the one side
+	groups := make(map[string]map[string]*v3.Series)
+	signatures := make([]string, 0)
+	for _, queryName := range queryNames {
+		if queryName == manyQuery {
+			continue
+		}
+		for _, series := range resultsByQuery[queryName].Series {
+			signature := matching.Signature(series.Labels)
+			if _, ok := groups[signature]; !ok {
+				groups[signature] = make(map[string]*v3.Series)
+				signatures = append(signatures, signature)
+			}
+			if _, ok := groups[signature][queryName]; ok {
+				return nil, manyToManyError(matching, queryName, signature)
+			}
+			groups[signature][queryName] = series
+		}
+	}
+
+	type join struct {
+		labels map[string]string
+		series map[string]*v3.Series
+	}
+	joins := make([]join, 0)
+	if manyQuery == "" {
+		for _, signature := range signatures {
+			// the result has the labels of the first query with a series
+			var labels map[string]string
+			for _, queryName := range queryNames {
+				if series, ok := groups[signature][queryName]; ok {
+					labels = resultLabels(matching, series.Labels, nil)
+					break
+				}
+			}
+			joins = append(joins, join{labels: labels, series: groups[signature]})
+		}
+	} else {
+		for _, series := range resultsByQuery[manyQuery].Series {
+			signature := matching.Signature(series.Labels)
+			joined := map[string]*v3.Series{manyQuery: series}
+			others := make([]*v3.Series, 0)
+			for _, queryName := range queryNames {
+				if other, ok := groups[signature][queryName]; ok {
+					joined[queryName] = other
+					others = append(others, other)
+				}
+			}
+			joins = append(joins, join{labels: resultLabels(matching, series.Labels, others), series: joined})
+		}
+	}
+
+	newSeries := make([]*v3.Series, 0)
+	seen := make(map[string]struct{})
+	for _, join := range joins {
+		key := labelsKey(join.labels)
+		if _, ok := seen[key]; ok {
+			return nil, fmt.Errorf("multiple matches for labels {%s}, the grouping labels must make the matches unique", key)
+		}
+		seen[key] = struct{}{}
+
+		series, err := calculate(join.series, join.labels, expression, canDefaultZero)
+		if err != nil {
+			return nil, err
+		}
+		if series != nil && len(series.Points) != 0 {
+			setLabelsArray(series)
+			newSeries = append(newSeries, series)
+		}
+	}
+
+	return &v3.Result{
+		Series: newSeries,
+	}, nil
+}
+
+// resultLabels returns the labels of the series calculated for a join. With
+// one-to-one matching they are the matching labels, otherwise the labels of
+// the series of the many side and the included labels of the others.
+func resultLabels(matching *v3.VectorMatching, labels map[string]string, others []*v3.Series) map[string]string {
+	result := make(map[string]string)
+	if matching.Group == v3.VectorMatchingGroupNone {
+		if matching.On {
+			for _, label := range matching.Labels {
+				if value, ok := labels[label]; ok {
+					result[label] = value
+				}
+			}
+			return result
+		}
+		for key, value := range labels {
+			if !contains(matching.Labels, key) {
+				result[key] = value
+			}
+		}
+		return result
+	}
+
+	for key, value := range labels {
+		result[key] = value
+	}
+	for _, label := range matching.Include {
+		delete(result, label)
+		for _, other := range others {
+			if value, ok := other.Labels[label]; ok {
+				result[label] = value
+				break
+			}
+		}
+	}
+	return result
+}
+
+func manyToManyError(matching *v3.VectorMatching, queryName, signature string) error {
+	if matching.Group == v3.VectorMatchingGroupNone {
+		return fmt.Errorf("many-to-many matching not allowed: query %s has more than one series for the match group {%s}, "+
+			"use group_left or group_right or match on more labels", queryName, signature)
+	}
+	return fmt.Errorf("many-to-many matching not allowed: query %s has more than one series for the match group {%s}, "+
+		"only the query on the %s side can have many series with group_%s", queryName, signature, matching.Group, matching.Group)
+}
+
+func labelsKey(labels map[string]string) string {
+	parts := make([]string, 0, len(labels))
+	for key, value := range labels {
+		parts = append(parts, fmt.Sprintf("%s=%s", key, value))
+	}
+	sort.Strings(parts)
+	return strings.Join(parts, ",")
+}
+
+func contains(list []string, value string) bool {
+	for _, item := range list {
+		if item == value {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,121 @@
+package postprocess
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/SigNoz/govaluate"
+	v3 "go.signoz.io/signoz/pkg/query-service/model/v3"
+)
+
+func TestProcessResultsWithMatching(t *testing.T) {
+	series := func(labels map[string]string, values ...float64) *v3.Series {
+		s := &v3.Series{Labels: labels}
+		for idx, value := range values {
+			s.Points = append(s.Points, v3.Point{Timestamp: int64(idx + 1), Value: value})
+		}
+		return s
+	}
+	errorsByEndpoint := &v3.Result{
+		QueryName: "A",
+		Series: []*v3.Series{
+			series(map[string]string{"service_name": "api", "endpoint": "/users"}, 2, 4),
+			series(map[string]string{"service_name": "api", "endpoint": "/orders"}, 1, 1),
+			series(map[string]string{"service_name": "web", "endpoint": "/"}, 5, 0),
+		},
+	}
+	requestsByService := &v3.Result{
+		QueryName: "B",
+		Series: []*v3.Series{
+			series(map[string]string{"service_name": "api", "team": "core"}, 10, 20),
+			series(map[string]string{"service_name": "web", "team": "frontend"}, 50, 50),
+		},
+	}
+
+	tests := []struct {
+		name       string
+		results    []*v3.Result
+		expression string
+		want       []*v3.Series
+		wantErr    string
+	}{
+		{
+			name:       "many-to-one with group left",
+			results:    []*v3.Result{errorsByEndpoint, requestsByService},
+			expression: "A / on(service_name) group_left(team) B",
+			want: []*v3.Series{
+				series(map[string]string{"service_name": "api", "endpoint": "/users", "team": "core"}, 0.2, 0.2),
+				series(map[string]string{"service_name": "api", "endpoint": "/orders", "team": "core"}, 0.1, 0.05),
+				series(map[string]string{"service_name": "web", "endpoint": "/", "team": "frontend"}, 0.1, 0),
+			},
+		},
+		{
+			name:       "one-to-many with group right",
+			results:    []*v3.Result{errorsByEndpoint, requestsByService},
+			expression: "B - on(service_name) group_right A",
+			want: []*v3.Series{
+				series(map[string]string{"service_name": "api", "endpoint": "/users"}, 8, 16),
+				series(map[string]string{"service_name": "api", "endpoint": "/orders"}, 9, 19),
+				series(map[string]string{"service_name": "web", "endpoint": "/"}, 45, 50),
+			},
+		},
+		{
+			name: "one-to-one ignoring labels",
+			results: []*v3.Result{
+				{QueryName: "A", Series: []*v3.Series{series(map[string]string{"service_name": "api", "status_code": "500"}, 3)}},
+				{QueryName: "B", Series: []*v3.Series{series(map[string]string{"service_name": "api"}, 6)}},
+			},
+			expression: "A / ignoring(status_code) B",
+			want: []*v3.Series{
+				series(map[string]string{"service_name": "api"}, 0.5),
+			},
+		},
+		{
+			name:       "many-to-many without group",
+			results:    []*v3.Result{errorsByEndpoint, requestsByService},
+			expression: "A / on(service_name) B",
+			wantErr:    "many-to-many matching not allowed: query A",
+		},
+		{
+			name:       "many series on the one side",
+			results:    []*v3.Result{requestsByService, errorsByEndpoint},
+			expression: "B / on(service_name) group_left A",
+			wantErr:    "many-to-many matching not allowed: query A",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			stripped, matching, err := v3.ParseVectorMatching(tt.expression)
+			if err != nil {
+				t.Fatalf("Error parsing vector matching: %v", err)
+			}
+			expression, err := govaluate.NewEvaluableExpression(stripped)
+			if err != nil {
+				t.Fatalf("Error parsing expression: %v", err)
+			}
+			got, err := processResultsWithMatching(tt.results, expression, matching, map[string]bool{})
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Errorf("processResultsWithMatching(): error = %v, want %v", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Error processing results: %v", err)
+			}
+			if len(got.Series) != len(tt.want) {
+				t.Fatalf("processResultsWithMatching(): number of series - got = %v, want %v", len(got.Series), len(tt.want))
+			}
+			for i := range got.Series {
+				if !reflect.DeepEqual(got.Series[i].Labels, tt.want[i].Labels) {
+					t.Errorf("processResultsWithMatching(): labels - got = %v, want %v", got.Series[i].Labels, tt.want[i].Labels)
+				}
+				if !reflect.DeepEqual(got.Series[i].Points, tt.want[i].Points) {
+					t.Errorf("processResultsWithMatching(): points - got = %v, want %v", got.Series[i].Points, tt.want[i].Points)
+				}
+			}
+		})
+	}
+}
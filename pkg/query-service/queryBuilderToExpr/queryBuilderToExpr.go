@@ -46,53 +46,58 @@ func getTypeName(v v3.AttributeKeyType) string {
 	return ""
 }
 
-func Parse(filters *v3.FilterSet) (string, error) {
-	var res []string
-	for _, v := range filters.Items {
-		if _, ok := logOperatorsToExpr[v.Operator]; !ok {
-			return "", fmt.Errorf("operator not supported")
-		}
+var exprFilterSyntax = v3.FilterSyntax{And: "and", Or: "or", Not: "not"}
 
-		name := getName(v.Key)
+func parseFilterItem(v v3.FilterItem) (string, error) {
+	if _, ok := logOperatorsToExpr[v.Operator]; !ok {
+		return "", fmt.Errorf("operator not supported")
+	}
 
-		var filter string
+	name := getName(v.Key)
 
-		switch v.Operator {
-		// uncomment following lines when new version of expr is used
-		// case v3.FilterOperatorIn, v3.FilterOperatorNotIn:
-		// 	filter = fmt.Sprintf("%s %s list%s", name, logOperatorsToExpr[v.Operator], exprFormattedValue(v.Value))
+	var filter string
 
-		case v3.FilterOperatorExists, v3.FilterOperatorNotExists:
-			filter = fmt.Sprintf("%s %s %s", exprFormattedValue(v.Key.Key), logOperatorsToExpr[v.Operator], getTypeName(v.Key.Type))
+	switch v.Operator {
+	// uncomment following lines when new version of expr is used
+	// case v3.FilterOperatorIn, v3.FilterOperatorNotIn:
+	// 	filter = fmt.Sprintf("%s %s list%s", name, logOperatorsToExpr[v.Operator], exprFormattedValue(v.Value))
 
-		default:
-			filter = fmt.Sprintf("%s %s %s", name, logOperatorsToExpr[v.Operator], exprFormattedValue(v.Value))
-
-			if v.Operator == v3.FilterOperatorContains || v.Operator == v3.FilterOperatorNotContains {
-				// `contains` and `ncontains` should be case insensitive to match how they work when querying logs.
-				filter = fmt.Sprintf(
-					"lower(%s) %s lower(%s)",
-					name, logOperatorsToExpr[v.Operator], exprFormattedValue(v.Value),
-				)
-			}
+	case v3.FilterOperatorExists, v3.FilterOperatorNotExists:
+		filter = fmt.Sprintf("%s %s %s", exprFormattedValue(v.Key.Key), logOperatorsToExpr[v.Operator], getTypeName(v.Key.Type))
 
-			// Avoid running operators on nil values
-			if v.Operator != v3.FilterOperatorEqual && v.Operator != v3.FilterOperatorNotEqual {
-				filter = fmt.Sprintf("%s != nil && %s", name, filter)
-			}
+	default:
+		filter = fmt.Sprintf("%s %s %s", name, logOperatorsToExpr[v.Operator], exprFormattedValue(v.Value))
+
+		if v.Operator == v3.FilterOperatorContains || v.Operator == v3.FilterOperatorNotContains {
+			// `contains` and `ncontains` should be case insensitive to match how they work when querying logs.
+			filter = fmt.Sprintf(
+				"lower(%s) %s lower(%s)",
+				name, logOperatorsToExpr[v.Operator], exprFormattedValue(v.Value),
+			)
 		}
 
-		// check if the filter is a correct expression language
-		_, err := expr.Compile(filter)
-		if err != nil {
-			return "", err
+		// Avoid running operators on nil values
+		if v.Operator != v3.FilterOperatorEqual && v.Operator != v3.FilterOperatorNotEqual {
+			filter = fmt.Sprintf("%s != nil && %s", name, filter)
 		}
-		res = append(res, filter)
+	}
+
+	// check if the filter is a correct expression language
+	_, err := expr.Compile(filter)
+	if err != nil {
+		return "", err
+	}
+	return filter, nil
+}
+
+func Parse(filters *v3.FilterSet) (string, error) {
+	q, err := filters.BuildCondition(exprFilterSyntax, parseFilterItem)
+	if err != nil {
+		return "", err
 	}
 
 	// check the final filter
-	q := strings.Join(res, " "+strings.ToLower(filters.Operator)+" ")
-	_, err := expr.Compile(q)
+	_, err = expr.Compile(q)
 	if err != nil {
 		return "", err
 	}
@@ -136,6 +136,21 @@ var testCases = []struct {
 		}},
 		Expr: `attributes.key <= 10 and body not matches "[0-1]+regex$" and "key" not in attributes`,
 	},
+	{
+		Name: "nested groups",
+		Query: &v3.FilterSet{Operator: "OR", Items: []v3.FilterItem{
+			{Key: v3.AttributeKey{Key: "key", DataType: v3.AttributeKeyDataTypeString, Type: v3.AttributeKeyTypeTag}, Value: "checkbody", Operator: "="},
+		}, Groups: []v3.FilterSet{
+			{Operator: "AND", Items: []v3.FilterItem{
+				{Key: v3.AttributeKey{Key: "service", DataType: v3.AttributeKeyDataTypeString, Type: v3.AttributeKeyTypeResource}, Value: "api", Operator: "="},
+				{Key: v3.AttributeKey{Key: "code", DataType: v3.AttributeKeyDataTypeString, Type: v3.AttributeKeyTypeTag}, Operator: "exists"},
+			}},
+			{Operator: "OR", Not: true, Items: []v3.FilterItem{
+				{Key: v3.AttributeKey{Key: "body", DataType: v3.AttributeKeyDataTypeString, IsColumn: true}, Value: "debug", Operator: "="},
+			}},
+		}},
+		Expr: `(attributes["key"] == "checkbody" or (resource["service"] == "api" and "code" in attributes) or not (body == "debug"))`,
+	},
 	{
 		Name: "incorrect multi filter",
 		Query: &v3.FilterSet{Operator: "AND", Items: []v3.FilterItem{
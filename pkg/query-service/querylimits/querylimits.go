@@ -0,0 +1,178 @@
+package querylimits
+
+import (
+	"context"
+	"errors"
+	"os"
+	"time"
+
+	"go.signoz.io/signoz/pkg/query-service/common"
+	"go.signoz.io/signoz/pkg/query-service/constants"
+	chErrors "go.signoz.io/signoz/pkg/query-service/errors"
+	"go.uber.org/zap"
+	"gopkg.in/yaml.v2"
+)
+
+// OverrideHeader is the request header an admin sets to run a query
+// without the query limits
+const OverrideHeader = "X-SIGNOZ-QUERY-LIMITS-OVERRIDE"
+
+// Limits bounds the cost of a query, a zero value disables the limit
+type Limits struct {
+	// MaxRowsScanned is the number of rows clickhouse estimates to read for a statement
+	MaxRowsScanned uint64 `yaml:"maxRowsScanned"`
+	// MaxTimeRange is the length of the time range of a request
+	MaxTimeRange time.Duration `yaml:"maxTimeRange"`
+	// MaxSeries is the number of series returned by a statement
+	MaxSeries int `yaml:"maxSeries"`
+	// MaxExecutionTime is the time all the statements of a request can take
+	MaxExecutionTime time.Duration `yaml:"maxExecutionTime"`
+}
+
+// Config holds the query limits of the roles
+type Config struct {
+	// Default applies to the roles without limits of their own
+	Default Limits            `yaml:"default"`
+	Roles   map[string]Limits `yaml:"roles,omitempty"`
+}
+
+// LoadFromYAMLConfig loads the query limits from the given YAML config bytes
+func LoadFromYAMLConfig(yamlConfig []byte) (*Config, error) {
+	var config Config
+	err := yaml.Unmarshal(yamlConfig, &config)
+	if err != nil {
+		return nil, err
+	}
+	return &config, nil
+}
+
+// LoadFromYAMLConfigFile loads the query limits from the given YAML config file
+func LoadFromYAMLConfigFile(configFile string) (*Config, error) {
+	bytes, err := os.ReadFile(configFile)
+	if err != nil {
+		return nil, err
+	}
+	return LoadFromYAMLConfig(bytes)
+}
+
+// Estimator estimates the number of rows clickhouse reads for a query
+type Estimator interface {
+	EstimateRowsScanned(ctx context.Context, query string) (uint64, error)
+}
+
+type overrideKey struct{}
+
+type executionTimeoutKey struct{}
+
+// WithOverride marks the queries run with the context to skip the limits,
+// it is only honoured for admins
+func WithOverride(ctx context.Context) context.Context {
+	return context.WithValue(ctx, overrideKey{}, true)
+}
+
+// Guard checks the queries of a user against the limits of their role
+type Guard struct {
+	config    *Config
+	estimator Estimator
+}
+
+// NewGuard returns a guard enforcing config, a nil config doesn't limit any query
+func NewGuard(config *Config, estimator Estimator) *Guard {
+	return &Guard{config: config, estimator: estimator}
+}
+
+// limitsFor returns the limits of the role of the user of the context. The
+// queries without a user e.g. the rule evaluations and the queries of an
+// admin overriding the limits are not limited.
+func (g *Guard) limitsFor(ctx context.Context) (Limits, string, bool) {
+	if g == nil || g.config == nil {
+		return Limits{}, "", false
+	}
+	user := common.GetUserFromContext(ctx)
+	if user == nil {
+		return Limits{}, "", false
+	}
+	if override, _ := ctx.Value(overrideKey{}).(bool); override && user.Role == constants.AdminGroup {
+		return Limits{}, "", false
+	}
+	if limits, ok := g.config.Roles[user.Role]; ok {
+		return limits, user.Role, true
+	}
+	return g.config.Default, user.Role, true
+}
+
+func newLimitError(limit, role string, max, actual uint64) error {
+	return &chErrors.QueryLimitError{
+		Limit:          limit,
+		Max:            max,
+		Actual:         actual,
+		Role:           role,
+		OverrideHeader: OverrideHeader,
+	}
+}
+
+// CheckTimeRange checks the time range of a request, start and end are in milliseconds
+func (g *Guard) CheckTimeRange(ctx context.Context, start, end int64) error {
+	limits, role, ok := g.limitsFor(ctx)
+	if !ok || limits.MaxTimeRange == 0 || end <= start {
+		return nil
+	}
+	if end-start > limits.MaxTimeRange.Milliseconds() {
+		return newLimitError(chErrors.QueryLimitTimeRange, role, uint64(limits.MaxTimeRange.Milliseconds()), uint64(end-start))
+	}
+	return nil
+}
+
+// CheckRowsScanned estimates the rows read by the query before it is run, the
+// query is allowed when the estimate isn't available
+func (g *Guard) CheckRowsScanned(ctx context.Context, query string) error {
+	limits, role, ok := g.limitsFor(ctx)
+	if !ok || limits.MaxRowsScanned == 0 || g.estimator == nil {
+		return nil
+	}
+	rows, err := g.estimator.EstimateRowsScanned(ctx, query)
+	if err != nil {
+		zap.L().Warn("failed to estimate the rows scanned by query", zap.String("query", query), zap.Error(err))
+		return nil
+	}
+	if rows > limits.MaxRowsScanned {
+		return newLimitError(chErrors.QueryLimitRowsScanned, role, limits.MaxRowsScanned, rows)
+	}
+	return nil
+}
+
+// CheckSeries checks the number of series returned by a statement
+func (g *Guard) CheckSeries(ctx context.Context, count int) error {
+	limits, role, ok := g.limitsFor(ctx)
+	if !ok || limits.MaxSeries == 0 {
+		return nil
+	}
+	if count > limits.MaxSeries {
+		return newLimitError(chErrors.QueryLimitSeries, role, uint64(limits.MaxSeries), uint64(count))
+	}
+	return nil
+}
+
+// WithExecutionTimeout returns a context cancelled after the execution time limit
+func (g *Guard) WithExecutionTimeout(ctx context.Context) (context.Context, context.CancelFunc) {
+	limits, _, ok := g.limitsFor(ctx)
+	if !ok || limits.MaxExecutionTime == 0 {
+		return ctx, func() {}
+	}
+	ctx = context.WithValue(ctx, executionTimeoutKey{}, limits.MaxExecutionTime)
+	return context.WithTimeout(ctx, limits.MaxExecutionTime)
+}
+
+// ExecutionError returns the execution time limit error when err is caused by
+// the timeout of WithExecutionTimeout, otherwise err
+func (g *Guard) ExecutionError(ctx context.Context, err error) error {
+	if err == nil {
+		return nil
+	}
+	timeout, ok := ctx.Value(executionTimeoutKey{}).(time.Duration)
+	if !ok || !errors.Is(ctx.Err(), context.DeadlineExceeded) {
+		return err
+	}
+	_, role, _ := g.limitsFor(ctx)
+	return newLimitError(chErrors.QueryLimitExecutionTime, role, uint64(timeout.Milliseconds()), uint64(timeout.Milliseconds()))
+}
@@ -0,0 +1,111 @@
+package querylimits
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.signoz.io/signoz/pkg/query-service/constants"
+	chErrors "go.signoz.io/signoz/pkg/query-service/errors"
+	"go.signoz.io/signoz/pkg/query-service/model"
+)
+
+type fakeEstimator struct {
+	rows uint64
+	err  error
+}
+
+func (f *fakeEstimator) EstimateRowsScanned(ctx context.Context, query string) (uint64, error) {
+	return f.rows, f.err
+}
+
+func userContext(role string) context.Context {
+	return context.WithValue(context.Background(), constants.ContextUserKey, &model.UserPayload{Role: role})
+}
+
+const testConfig = `
+default:
+  maxRowsScanned: 1000
+  maxTimeRange: 24h
+  maxSeries: 10
+roles:
+  ADMIN:
+    maxTimeRange: 720h
+`
+
+func TestLoadFromYAMLConfig(t *testing.T) {
+	config, err := LoadFromYAMLConfig([]byte(testConfig))
+	require.NoError(t, err)
+	assert.Equal(t, Limits{MaxRowsScanned: 1000, MaxTimeRange: 24 * time.Hour, MaxSeries: 10}, config.Default)
+	assert.Equal(t, Limits{MaxTimeRange: 720 * time.Hour}, config.Roles[constants.AdminGroup])
+}
+
+func TestGuard(t *testing.T) {
+	config, err := LoadFromYAMLConfig([]byte(testConfig))
+	require.NoError(t, err)
+	estimator := &fakeEstimator{rows: 5000}
+	guard := NewGuard(config, estimator)
+
+	viewer := userContext(constants.ViewerGroup)
+	admin := userContext(constants.AdminGroup)
+	day := (24 * time.Hour).Milliseconds()
+
+	// the viewer gets the default limits
+	err = guard.CheckTimeRange(viewer, 0, 2*day)
+	limitErr := chErrors.AsQueryLimitError(err)
+	require.NotNil(t, limitErr)
+	assert.Equal(t, chErrors.QueryLimitTimeRange, limitErr.Limit)
+	assert.Equal(t, uint64(day), limitErr.Max)
+	assert.Equal(t, uint64(2*day), limitErr.Actual)
+	assert.Equal(t, constants.ViewerGroup, limitErr.Role)
+	assert.NoError(t, guard.CheckTimeRange(viewer, 0, day))
+
+	err = guard.CheckRowsScanned(viewer, "SELECT 1")
+	limitErr = chErrors.AsQueryLimitError(err)
+	require.NotNil(t, limitErr)
+	assert.Equal(t, chErrors.QueryLimitRowsScanned, limitErr.Limit)
+	assert.Equal(t, uint64(5000), limitErr.Actual)
+
+	assert.True(t, chErrors.IsQueryLimitError(guard.CheckSeries(viewer, 11)))
+	assert.NoError(t, guard.CheckSeries(viewer, 10))
+
+	// the admin role has limits of its own
+	assert.NoError(t, guard.CheckTimeRange(admin, 0, 2*day))
+	assert.NoError(t, guard.CheckRowsScanned(admin, "SELECT 1"))
+	assert.Error(t, guard.CheckTimeRange(admin, 0, 31*day))
+
+	// only the admins can override the limits
+	assert.NoError(t, guard.CheckTimeRange(WithOverride(admin), 0, 31*day))
+	assert.Error(t, guard.CheckTimeRange(WithOverride(viewer), 0, 2*day))
+
+	// the queries without a user aren't limited
+	assert.NoError(t, guard.CheckTimeRange(context.Background(), 0, 31*day))
+
+	// the query runs when the estimate fails
+	estimator.err = errors.New("estimate failed")
+	assert.NoError(t, guard.CheckRowsScanned(viewer, "SELECT 1"))
+
+	// a nil config doesn't limit the queries
+	assert.NoError(t, NewGuard(nil, estimator).CheckTimeRange(viewer, 0, 31*day))
+}
+
+func TestGuardExecutionTime(t *testing.T) {
+	guard := NewGuard(&Config{Default: Limits{MaxExecutionTime: time.Millisecond}}, nil)
+
+	ctx, cancel := guard.WithExecutionTimeout(userContext(constants.EditorGroup))
+	defer cancel()
+	<-ctx.Done()
+
+	err := guard.ExecutionError(ctx, errors.New("context deadline exceeded"))
+	limitErr := chErrors.AsQueryLimitError(err)
+	require.NotNil(t, limitErr)
+	assert.Equal(t, chErrors.QueryLimitExecutionTime, limitErr.Limit)
+	assert.Equal(t, uint64(1), limitErr.Max)
+
+	// the errors of the queries without the limit are returned as is
+	queryErr := errors.New("syntax error")
+	assert.Equal(t, queryErr, guard.ExecutionError(context.Background(), queryErr))
+}
@@ -30,6 +30,8 @@ const (
 	RuleTypeThreshold = "threshold_rule"
 	RuleTypeProm      = "promql_rule"
 	RuleTypeAnomaly   = "anomaly_rule"
+	RuleTypeRecording = "recording_rule"
+	RuleTypeBurnRate  = "burn_rate_rule"
 )
 
 type RuleHealth string
@@ -60,6 +62,18 @@ type Alert struct {
 	LastSentAt time.Time
 	ValidUntil time.Time
 
+	// Severity is the severity of the threshold level breached by the alert.
+	// It is empty for rules without threshold levels
+	Severity string
+
+	// SuppressedBy is the id of the rule that inhibits the alert,
+	// empty if the alert is not suppressed
+	SuppressedBy string
+
+	// Notified is set once the alert was notified as firing, alerts
+	// suppressed for as long as they fired don't notify their resolution
+	Notified bool
+
 	Missing bool
 }
 
@@ -68,6 +82,16 @@ func (a *Alert) needsSending(ts time.Time, resendDelay time.Duration) bool {
 		return false
 	}
 
+	// suppressed alerts are handed over on every evaluation so that
+	// they are notified as soon as the inhibition is lifted
+	if a.SuppressedBy != "" && a.ResolvedAt.IsZero() {
+		return true
+	}
+
+	if !a.ResolvedAt.IsZero() && !a.Notified {
+		return false
+	}
+
 	// if an alert has been resolved since the last send, resend it
 	if a.ResolvedAt.After(a.LastSentAt) {
 		return true
@@ -76,6 +100,21 @@ func (a *Alert) needsSending(ts time.Time, resendDelay time.Duration) bool {
 	return a.LastSentAt.Add(resendDelay).Before(ts)
 }
 
+// UpdateSeverity moves the alert to the severity (and the labels carrying it)
+// of a newer evaluation of the same series. It returns true if the severity
+// of the alert changed.
+func (a *Alert) UpdateSeverity(from *Alert) bool {
+	if a.Severity == from.Severity {
+		return false
+	}
+	a.Severity = from.Severity
+	a.Labels = from.Labels
+	// make sure the new severity goes out with the next notification
+	// instead of waiting for the resend delay
+	a.LastSentAt = time.Time{}
+	return true
+}
+
 type NamedAlert struct {
 	Name string
 	*Alert
@@ -105,12 +144,55 @@ const (
 	Last          MatchType = "5"
 )
 
+// AbsentMode decides how missing data is alerted on when AlertOnAbsent is set
+type AbsentMode string
+
+const (
+	// AbsentModeQuery raises a single alert when the query returns no data at all
+	AbsentModeQuery AbsentMode = "query"
+	// AbsentModeSeries raises an alert for every previously seen label set
+	// that stops reporting, and resolves it when the data returns
+	AbsentModeSeries AbsentMode = "series"
+)
+
+// RuleInhibition suppresses the notifications of a rule while the
+// rule RuleID is firing with the same values for the Equal labels,
+// e.g. service and env
+type RuleInhibition struct {
+	RuleID string   `yaml:"ruleId" json:"ruleId"`
+	Equal  []string `yaml:"equal,omitempty" json:"equal,omitempty"`
+}
+
+// BurnRateCondition is the condition of a burn rate rule generated for an
+// SLO. The good and total events are counted by the builder queries A and B
+// of the composite query, the rule target is the burn rate that raises the
+// alert when it is exceeded over both windows.
+type BurnRateCondition struct {
+	SLOID int64 `yaml:"sloId,omitempty" json:"sloId,omitempty"`
+	// Objective is the expected ratio of good to total events, e.g. 0.999
+	Objective   float64  `yaml:"objective" json:"objective"`
+	LongWindow  Duration `yaml:"longWindow" json:"longWindow"`
+	ShortWindow Duration `yaml:"shortWindow" json:"shortWindow"`
+}
+
+// RuleThreshold is a single level of a rule with multiple thresholds. Each level
+// has its own target, comparison and match type, and the severity that is
+// attached to the alert when the level is breached.
+type RuleThreshold struct {
+	Severity   string    `yaml:"severity" json:"severity"`
+	Target     *float64  `yaml:"target,omitempty" json:"target,omitempty"`
+	TargetUnit string    `yaml:"targetUnit,omitempty" json:"targetUnit,omitempty"`
+	CompareOp  CompareOp `yaml:"op,omitempty" json:"op,omitempty"`
+	MatchType  MatchType `yaml:"matchType,omitempty" json:"matchType,omitempty"`
+}
+
 type RuleCondition struct {
 	CompositeQuery    *v3.CompositeQuery `json:"compositeQuery,omitempty" yaml:"compositeQuery,omitempty"`
 	CompareOp         CompareOp          `yaml:"op,omitempty" json:"op,omitempty"`
 	Target            *float64           `yaml:"target,omitempty" json:"target,omitempty"`
 	AlertOnAbsent     bool               `yaml:"alertOnAbsent,omitempty" json:"alertOnAbsent,omitempty"`
 	AbsentFor         uint64             `yaml:"absentFor,omitempty" json:"absentFor,omitempty"`
+	AbsentMode        AbsentMode         `yaml:"absentMode,omitempty" json:"absentMode,omitempty"`
 	MatchType         MatchType          `json:"matchType,omitempty"`
 	TargetUnit        string             `json:"targetUnit,omitempty"`
 	Algorithm         string             `json:"algorithm,omitempty"`
@@ -118,6 +200,41 @@ type RuleCondition struct {
 	SelectedQuery     string             `json:"selectedQueryName,omitempty"`
 	RequireMinPoints  bool               `yaml:"requireMinPoints,omitempty" json:"requireMinPoints,omitempty"`
 	RequiredNumPoints int                `yaml:"requiredNumPoints,omitempty" json:"requiredNumPoints,omitempty"`
+	// Thresholds are ordered from the most severe to the least severe level.
+	// When set, they take precedence over Target, CompareOp and MatchType
+	Thresholds []RuleThreshold `yaml:"thresholds,omitempty" json:"thresholds,omitempty"`
+	// BurnRate is set for burn rate rules only
+	BurnRate *BurnRateCondition `yaml:"burnRate,omitempty" json:"burnRate,omitempty"`
+}
+
+// thresholds returns the threshold levels of the condition, most severe first.
+// Rules without levels are treated as a single level built from the
+// condition's target. Levels inherit the unit, compare op and match type of
+// the condition when they don't set their own.
+func (rc *RuleCondition) thresholds() []RuleThreshold {
+	if len(rc.Thresholds) == 0 {
+		return []RuleThreshold{{
+			Target:     rc.Target,
+			TargetUnit: rc.TargetUnit,
+			CompareOp:  rc.CompareOp,
+			MatchType:  rc.MatchType,
+		}}
+	}
+
+	levels := make([]RuleThreshold, 0, len(rc.Thresholds))
+	for _, th := range rc.Thresholds {
+		if th.TargetUnit == "" {
+			th.TargetUnit = rc.TargetUnit
+		}
+		if th.CompareOp == "" {
+			th.CompareOp = rc.CompareOp
+		}
+		if th.MatchType == "" {
+			th.MatchType = rc.MatchType
+		}
+		levels = append(levels, th)
+	}
+	return levels
 }
 
 func (rc *RuleCondition) GetSelectedQueryName() string {
@@ -168,11 +285,13 @@ func (rc *RuleCondition) IsValid() bool {
 	}
 
 	if rc.QueryType() == v3.QueryTypeBuilder {
-		if rc.Target == nil {
-			return false
-		}
-		if rc.CompareOp == "" {
-			return false
+		for _, th := range rc.thresholds() {
+			if th.Target == nil {
+				return false
+			}
+			if th.CompareOp == "" {
+				return false
+			}
 		}
 	}
 	if rc.QueryType() == v3.QueryTypePromQL {
@@ -184,6 +303,11 @@ func (rc *RuleCondition) IsValid() bool {
 	return true
 }
 
+// alertOnAbsentSeries returns true if missing data is alerted on per series
+func (rc *RuleCondition) alertOnAbsentSeries() bool {
+	return rc.AlertOnAbsent && rc.AbsentMode == AbsentModeSeries
+}
+
 // QueryType is a short hand method to get query type
 func (rc *RuleCondition) QueryType() v3.QueryType {
 	if rc.CompositeQuery != nil {
@@ -72,6 +72,18 @@ type PostableRule struct {
 
 	PreferredChannels []string `json:"preferredChannels,omitempty"`
 
+	// Record is the name of the metric the results of a recording
+	// rule are written to
+	Record string `yaml:"record,omitempty" json:"record,omitempty"`
+
+	// InhibitedBy lists the rules whose firing alerts suppress
+	// the notifications of this rule
+	InhibitedBy []RuleInhibition `yaml:"inhibitedBy,omitempty" json:"inhibitedBy,omitempty"`
+
+	// NotificationTemplates maps channel types, e.g. slack, to the names
+	// of the notification templates used for the notifications of the rule
+	NotificationTemplates map[string]string `yaml:"notificationTemplates,omitempty" json:"notificationTemplates,omitempty"`
+
 	Version string `json:"version,omitempty"`
 
 	// legacy
@@ -136,7 +148,7 @@ func parseIntoRule(initRule PostableRule, content []byte, kind RuleDataKind) (*P
 			if rule.RuleType == "" {
 				rule.RuleType = RuleTypeThreshold
 			}
-		} else if rule.RuleCondition.CompositeQuery.QueryType == v3.QueryTypePromQL {
+		} else if rule.RuleCondition.CompositeQuery.QueryType == v3.QueryTypePromQL && rule.RuleType != RuleTypeRecording {
 			rule.RuleType = RuleTypeProm
 		}
 
@@ -166,6 +178,18 @@ func isValidLabelName(ln string) bool {
 	return true
 }
 
+func isValidMetricName(mn string) bool {
+	if len(mn) == 0 {
+		return false
+	}
+	for i, b := range mn {
+		if !((b >= 'a' && b <= 'z') || (b >= 'A' && b <= 'Z') || b == '_' || b == ':' || (b >= '0' && b <= '9' && i > 0)) {
+			return false
+		}
+	}
+	return true
+}
+
 func isValidLabelValue(v string) bool {
 	return utf8.ValidString(v)
 }
@@ -226,7 +250,9 @@ func (r *PostableRule) Validate() error {
 		errs = append(errs, errors.Errorf("all queries are disabled in rule condition"))
 	}
 
-	if r.RuleType == RuleTypeThreshold {
+	if len(r.RuleCondition.Thresholds) > 0 {
+		errs = append(errs, validateThresholds(r.RuleCondition)...)
+	} else if r.RuleType == RuleTypeThreshold {
 		if r.RuleCondition.Target == nil {
 			errs = append(errs, errors.Errorf("rule condition missing the threshold"))
 		}
@@ -238,6 +264,25 @@ func (r *PostableRule) Validate() error {
 		}
 	}
 
+	switch r.RuleCondition.AbsentMode {
+	case "", AbsentModeQuery, AbsentModeSeries:
+	default:
+		errs = append(errs, errors.Errorf("invalid absent mode: %s", r.RuleCondition.AbsentMode))
+	}
+
+	if r.RuleType == RuleTypeRecording {
+		if !isValidMetricName(r.Record) {
+			errs = append(errs, errors.Errorf("invalid recording rule metric name: %q", r.Record))
+		}
+		if r.RuleCondition.QueryType() == v3.QueryTypePromQL {
+			errs = append(errs, errors.Errorf("recording rules support builder and clickhouse queries only"))
+		}
+	}
+
+	if r.RuleType == RuleTypeBurnRate {
+		errs = append(errs, validateBurnRate(r.RuleCondition)...)
+	}
+
 	for k, v := range r.Labels {
 		if !isValidLabelName(k) {
 			errs = append(errs, errors.Errorf("invalid label name: %s", k))
@@ -254,10 +299,57 @@ func (r *PostableRule) Validate() error {
 		}
 	}
 
+	for idx, inh := range r.InhibitedBy {
+		if inh.RuleID == "" {
+			errs = append(errs, errors.Errorf("inhibition %d is missing the rule id", idx))
+		}
+		for _, name := range inh.Equal {
+			if !isValidLabelName(name) {
+				errs = append(errs, errors.Errorf("invalid label name in inhibition %d: %s", idx, name))
+			}
+		}
+	}
+
+	for channelType, name := range r.NotificationTemplates {
+		if !isNotificationChannelType(channelType) {
+			errs = append(errs, errors.Errorf("invalid notification template channel type: %s", channelType))
+		}
+		if name == "" {
+			errs = append(errs, errors.Errorf("notification template for %s is missing the name", channelType))
+		}
+	}
+
 	errs = append(errs, testTemplateParsing(r)...)
 	return multierr.Combine(errs...)
 }
 
+// validateThresholds checks that every threshold level of the condition
+// can be evaluated on its own and carries a unique severity
+func validateThresholds(rc *RuleCondition) (errs []error) {
+	seen := map[string]struct{}{}
+	for idx, th := range rc.thresholds() {
+		if th.Severity == "" {
+			errs = append(errs, errors.Errorf("threshold %d is missing the severity", idx))
+		} else if _, ok := seen[th.Severity]; ok {
+			errs = append(errs, errors.Errorf("duplicate threshold severity: %s", th.Severity))
+		} else if !isValidLabelValue(th.Severity) {
+			errs = append(errs, errors.Errorf("invalid threshold severity: %s", th.Severity))
+		}
+		seen[th.Severity] = struct{}{}
+
+		if th.Target == nil {
+			errs = append(errs, errors.Errorf("threshold %s is missing the target", th.Severity))
+		}
+		if th.CompareOp == "" {
+			errs = append(errs, errors.Errorf("threshold %s is missing the compare op", th.Severity))
+		}
+		if th.MatchType == "" {
+			errs = append(errs, errors.Errorf("threshold %s is missing the match option", th.Severity))
+		}
+	}
+	return errs
+}
+
 func testTemplateParsing(rl *PostableRule) (errs []error) {
 	if rl.AlertName == "" {
 		// Not an alerting rule.
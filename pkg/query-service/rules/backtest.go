@@ -0,0 +1,193 @@
+package rules
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"go.uber.org/zap"
+
+	"go.signoz.io/signoz/pkg/query-service/interfaces"
+	"go.signoz.io/signoz/pkg/query-service/model"
+)
+
+// maxBacktestEvaluations limits the number of evaluations of a single
+// backtest, one week at the default frequency of one minute
+const maxBacktestEvaluations = 7 * 24 * 60
+
+// BacktestBreach is a period in which an alert series was firing
+type BacktestBreach struct {
+	StartsAt int64 `json:"startsAt"`
+	// EndsAt is zero if the series was still firing at the end of the window
+	EndsAt   int64            `json:"endsAt,omitempty"`
+	State    model.AlertState `json:"state"`
+	Value    float64          `json:"value"`
+	Severity string           `json:"severity,omitempty"`
+}
+
+// BacktestSeries lists the breaches of one alert series
+type BacktestSeries struct {
+	Labels      model.LabelsString `json:"labels"`
+	Fingerprint uint64             `json:"fingerprint"`
+	Breaches    []BacktestBreach   `json:"breaches"`
+	// FiringDuration is the time in milliseconds the series was firing
+	FiringDuration int64 `json:"firingDuration"`
+}
+
+// BacktestResult is the outcome of replaying a rule over a past window
+type BacktestResult struct {
+	Start       int64 `json:"start"`
+	End         int64 `json:"end"`
+	Evaluations int   `json:"evaluations"`
+	// Notifications is the number of notifications that would have been
+	// sent, a notification can carry several alerts
+	Notifications int `json:"notifications"`
+	// Timeline holds the state changes in the order they happened
+	Timeline []model.RuleStateHistory `json:"timeline"`
+	Series   []BacktestSeries         `json:"series"`
+}
+
+// backtestReader keeps the state history of a backtest in memory,
+// every other call is passed on to the reader
+type backtestReader struct {
+	interfaces.Reader
+	history []model.RuleStateHistory
+}
+
+func (r *backtestReader) AddRuleStateHistory(ctx context.Context, ruleStateHistory []model.RuleStateHistory) error {
+	r.history = append(r.history, ruleStateHistory...)
+	return nil
+}
+
+func (r *backtestReader) GetLastSavedRuleStateHistory(ctx context.Context, ruleID string) ([]model.RuleStateHistory, error) {
+	return nil, nil
+}
+
+// Backtest replays the rule at its frequency over the window between start
+// and end. The rule is evaluated at the simulated timestamps the same way
+// it is evaluated by its task, but the state history is kept in memory and
+// no notifications are sent.
+func (m *Manager) Backtest(ctx context.Context, ruleStr string, start, end time.Time) (*BacktestResult, *model.ApiError) {
+
+	parsedRule, err := ParsePostableRule([]byte(ruleStr))
+	if err != nil {
+		return nil, newApiErrorBadData(err)
+	}
+
+	if parsedRule.RuleType == RuleTypeRecording {
+		return nil, newApiErrorBadData(fmt.Errorf("recording rules can not be backtested"))
+	}
+
+	if !start.Before(end) {
+		return nil, newApiErrorBadData(fmt.Errorf("start must be before end"))
+	}
+	frequency := time.Duration(parsedRule.Frequency)
+	if frequency <= 0 {
+		return nil, newApiErrorBadData(fmt.Errorf("frequency must be positive"))
+	}
+	if evaluations := end.Sub(start) / frequency; evaluations > maxBacktestEvaluations {
+		return nil, newApiErrorBadData(fmt.Errorf("the window needs %d evaluations, at most %d are allowed", evaluations, maxBacktestEvaluations))
+	}
+
+	reader := &backtestReader{Reader: m.reader}
+
+	notifications := 0
+	notifyFunc := func(ctx context.Context, expr string, alerts ...*Alert) {
+		if len(alerts) > 0 {
+			notifications++
+		}
+	}
+
+	// the task is only used to build the rule, it is never run
+	task, err := m.prepareTaskFunc(PrepareTaskOptions{
+		Rule:        parsedRule,
+		TaskName:    prepareTaskName("backtest"),
+		RuleDB:      m.ruleDB,
+		Logger:      m.logger,
+		Reader:      reader,
+		Cache:       m.cache,
+		FF:          m.featureFlags,
+		ManagerOpts: m.opts,
+		NotifyFunc:  notifyFunc,
+
+		UseLogsNewSchema: m.opts.UseLogsNewSchema,
+	})
+	if err != nil {
+		return nil, newApiErrorBadData(err)
+	}
+
+	result := &BacktestResult{
+		Start: start.UnixMilli(),
+		End:   end.UnixMilli(),
+	}
+
+	for ts := start; !ts.After(end); ts = ts.Add(frequency) {
+		if ctx.Err() != nil {
+			return nil, newApiErrorInternal(ctx.Err())
+		}
+		for _, rule := range task.Rules() {
+			if _, err := rule.Eval(ctx, ts); err != nil {
+				zap.L().Error("backtest evaluation failed", zap.String("rule", rule.Name()), zap.Time("ts", ts), zap.Error(err))
+				return nil, newApiErrorInternal(fmt.Errorf("rule evaluation at %s failed: %w", ts.Format(time.RFC3339), err))
+			}
+			rule.SendAlerts(ctx, ts, m.opts.ResendDelay, frequency, notifyFunc)
+		}
+		result.Evaluations++
+	}
+
+	result.Notifications = notifications
+	result.Timeline = reader.history
+	result.Series = backtestSeries(reader.history, end)
+
+	return result, nil
+}
+
+// backtestSeries groups the state changes by series into breaches, series
+// that are still firing at the end of the window are counted as firing
+// until the end
+func backtestSeries(history []model.RuleStateHistory, end time.Time) []BacktestSeries {
+	series := map[uint64]*BacktestSeries{}
+	order := []uint64{}
+
+	for _, item := range history {
+		s, ok := series[item.Fingerprint]
+		if !ok {
+			s = &BacktestSeries{Labels: item.Labels, Fingerprint: item.Fingerprint, Breaches: []BacktestBreach{}}
+			series[item.Fingerprint] = s
+			order = append(order, item.Fingerprint)
+		}
+
+		var open *BacktestBreach
+		if n := len(s.Breaches); n > 0 && s.Breaches[n-1].EndsAt == 0 {
+			open = &s.Breaches[n-1]
+		}
+
+		switch {
+		case item.State == model.StateInactive && open != nil:
+			open.EndsAt = item.UnixMilli
+			s.FiringDuration += open.EndsAt - open.StartsAt
+		case item.State != model.StateInactive && open == nil:
+			s.Breaches = append(s.Breaches, BacktestBreach{
+				StartsAt: item.UnixMilli,
+				State:    item.State,
+				Value:    item.Value,
+				Severity: item.Severity,
+			})
+		case item.State != model.StateInactive && open != nil:
+			// moving between threshold levels keeps the breach open,
+			// it reports the most recent level
+			open.Value = item.Value
+			open.Severity = item.Severity
+		}
+	}
+
+	result := make([]BacktestSeries, 0, len(series))
+	for _, fp := range order {
+		s := series[fp]
+		if n := len(s.Breaches); n > 0 && s.Breaches[n-1].EndsAt == 0 {
+			s.FiringDuration += end.UnixMilli() - s.Breaches[n-1].StartsAt
+		}
+		result = append(result, *s)
+	}
+	return result
+}
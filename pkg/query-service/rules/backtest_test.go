@@ -0,0 +1,184 @@
+package rules
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	cmock "github.com/srikanthccv/ClickHouse-go-mock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.signoz.io/signoz/pkg/query-service/app/clickhouseReader"
+	"go.signoz.io/signoz/pkg/query-service/featureManager"
+	"go.signoz.io/signoz/pkg/query-service/model"
+	v3 "go.signoz.io/signoz/pkg/query-service/model/v3"
+)
+
+func TestManagerBacktest(t *testing.T) {
+	target := 5.0
+	postableRule := PostableRule{
+		AlertName:  "Backtest",
+		AlertType:  AlertTypeMetric,
+		RuleType:   RuleTypeThreshold,
+		EvalWindow: Duration(5 * time.Minute),
+		Frequency:  Duration(1 * time.Minute),
+		RuleCondition: &RuleCondition{
+			CompositeQuery: &v3.CompositeQuery{
+				QueryType: v3.QueryTypeBuilder,
+				BuilderQueries: map[string]*v3.BuilderQuery{
+					"A": {
+						QueryName:    "A",
+						StepInterval: 60,
+						AggregateAttribute: v3.AttributeKey{
+							Key: "signoz_calls_total",
+						},
+						AggregateOperator: v3.AggregateOperatorSumRate,
+						DataSource:        v3.DataSourceMetrics,
+						Temporality:       v3.Delta,
+						Expression:        "A",
+					},
+				},
+			},
+			Target:    &target,
+			CompareOp: ValueIsAbove,
+			MatchType: AtleastOnce,
+		},
+	}
+	ruleStr, err := json.Marshal(postableRule)
+	require.NoError(t, err)
+
+	fm := featureManager.StartManager()
+	mock, err := cmock.NewClickHouseWithQueryMatcher(nil, &queryMatcherAny{})
+	require.NoError(t, err)
+
+	cols := []cmock.ColumnType{
+		{Name: "value", Type: "Float64"},
+		{Name: "service_name", Type: "String"},
+	}
+	// the value breaches the threshold in the first two evaluations
+	for _, value := range []float64{10, 12, 1} {
+		mock.ExpectQuery("SELECT any").WillReturnRows(cmock.NewRows(cols, [][]interface{}{
+			{value, "frontend"},
+		}))
+	}
+
+	options := clickhouseReader.NewOptions("", 0, 0, 0, "", "archiveNamespace")
+	reader := clickhouseReader.NewReaderFromClickhouseConnection(mock, options, nil, "", fm, "", true)
+
+	m := &Manager{
+		opts:            defaultOptions(&ManagerOptions{}),
+		reader:          reader,
+		featureFlags:    fm,
+		prepareTaskFunc: defaultPrepareTaskFunc,
+	}
+
+	start := time.Date(2024, 1, 1, 10, 0, 0, 0, time.UTC)
+	end := start.Add(2 * time.Minute)
+	res, apiErr := m.Backtest(context.Background(), string(ruleStr), start, end)
+	require.Nil(t, apiErr)
+	assert.NoError(t, mock.ExpectationsWereMet())
+
+	assert.Equal(t, 3, res.Evaluations)
+	// the alert is sent when it fires and when it resolves
+	assert.Equal(t, 2, res.Notifications)
+
+	require.Len(t, res.Timeline, 2)
+	assert.Equal(t, model.StateFiring, res.Timeline[0].State)
+	assert.Equal(t, model.StateInactive, res.Timeline[1].State)
+
+	require.Len(t, res.Series, 1)
+	assert.Equal(t, []BacktestBreach{{
+		StartsAt: start.UnixMilli(),
+		EndsAt:   end.UnixMilli(),
+		State:    model.StateFiring,
+		Value:    10,
+	}}, res.Series[0].Breaches)
+	assert.Equal(t, (2 * time.Minute).Milliseconds(), res.Series[0].FiringDuration)
+
+	// the window is limited to a week of evaluations
+	_, apiErr = m.Backtest(context.Background(), string(ruleStr), end.Add(-8*24*time.Hour), end)
+	require.NotNil(t, apiErr)
+}
+
+func TestManagerBacktestAbsent(t *testing.T) {
+	target := 5.0
+	postableRule := PostableRule{
+		AlertName:  "Backtest absent",
+		AlertType:  AlertTypeMetric,
+		RuleType:   RuleTypeThreshold,
+		EvalWindow: Duration(5 * time.Minute),
+		Frequency:  Duration(1 * time.Minute),
+		RuleCondition: &RuleCondition{
+			CompositeQuery: &v3.CompositeQuery{
+				QueryType: v3.QueryTypeBuilder,
+				BuilderQueries: map[string]*v3.BuilderQuery{
+					"A": {
+						QueryName:    "A",
+						StepInterval: 60,
+						AggregateAttribute: v3.AttributeKey{
+							Key: "signoz_calls_total",
+						},
+						AggregateOperator: v3.AggregateOperatorSumRate,
+						DataSource:        v3.DataSourceMetrics,
+						Temporality:       v3.Delta,
+						Expression:        "A",
+					},
+				},
+			},
+			Target:        &target,
+			CompareOp:     ValueIsAbove,
+			MatchType:     AtleastOnce,
+			AlertOnAbsent: true,
+			AbsentFor:     1,
+		},
+	}
+	ruleStr, err := json.Marshal(postableRule)
+	require.NoError(t, err)
+
+	fm := featureManager.StartManager()
+	mock, err := cmock.NewClickHouseWithQueryMatcher(nil, &queryMatcherAny{})
+	require.NoError(t, err)
+
+	cols := []cmock.ColumnType{
+		{Name: "value", Type: "Float64"},
+		{Name: "service_name", Type: "String"},
+	}
+	// the data stops after the first evaluation
+	mock.ExpectQuery("SELECT any").WillReturnRows(cmock.NewRows(cols, [][]interface{}{
+		{float64(1), "frontend"},
+	}))
+	for i := 0; i < 2; i++ {
+		mock.ExpectQuery("SELECT any").WillReturnRows(cmock.NewRows(cols, [][]interface{}{}))
+	}
+
+	options := clickhouseReader.NewOptions("", 0, 0, 0, "", "archiveNamespace")
+	reader := clickhouseReader.NewReaderFromClickhouseConnection(mock, options, nil, "", fm, "", true)
+
+	m := &Manager{
+		opts:            defaultOptions(&ManagerOptions{}),
+		reader:          reader,
+		featureFlags:    fm,
+		prepareTaskFunc: defaultPrepareTaskFunc,
+	}
+
+	// the absence is measured against the evaluation time of the window
+	start := time.Date(2024, 1, 1, 10, 0, 0, 0, time.UTC)
+	end := start.Add(2 * time.Minute)
+	res, apiErr := m.Backtest(context.Background(), string(ruleStr), start, end)
+	require.Nil(t, apiErr)
+	assert.NoError(t, mock.ExpectationsWereMet())
+
+	require.Len(t, res.Series, 1)
+	require.Len(t, res.Series[0].Breaches, 1)
+	assert.Equal(t, end.UnixMilli(), res.Series[0].Breaches[0].StartsAt)
+	assert.Equal(t, model.StateNoData, res.Series[0].Breaches[0].State)
+	assert.Equal(t, 1, res.Notifications)
+
+	// the frequency must be positive
+	postableRule.Frequency = Duration(-time.Minute)
+	ruleStr, err = json.Marshal(postableRule)
+	require.NoError(t, err)
+	_, apiErr = m.Backtest(context.Background(), string(ruleStr), start, end)
+	require.NotNil(t, apiErr)
+}
@@ -2,12 +2,14 @@ package rules
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
 	"math"
 	"net/url"
 	"sync"
 	"time"
 
+	"go.signoz.io/signoz/pkg/query-service/constants"
 	"go.signoz.io/signoz/pkg/query-service/converter"
 	"go.signoz.io/signoz/pkg/query-service/interfaces"
 	"go.signoz.io/signoz/pkg/query-service/model"
@@ -45,7 +47,9 @@ type BaseRule struct {
 	// preferredChannels is the list of channels to send the alert to
 	// if the rule is triggered
 	preferredChannels []string
-	mtx               sync.Mutex
+	// inhibitedBy is the list of rules that suppress the alerts of this rule
+	inhibitedBy []RuleInhibition
+	mtx         sync.Mutex
 	// the time it took to evaluate the rule (most recent evaluation)
 	evaluationDuration time.Duration
 	// the timestamp of the last evaluation
@@ -60,6 +64,14 @@ type BaseRule struct {
 	// this is used for missing data alerts
 	lastTimestampWithDatapoints time.Time
 
+	// seenSeries holds the label sets returned by the rule query and when
+	// they were last seen, used for missing data alerts per series
+	seenSeries map[uint64]*seenSeries
+
+	// notifyTemplates maps channel types to the names of the notification
+	// templates that render the notifications of the rule
+	notifyTemplates map[string]string
+
 	reader interfaces.Reader
 
 	logger *zap.Logger
@@ -107,7 +119,11 @@ func WithLogger(logger *zap.Logger) RuleOption {
 }
 
 func NewBaseRule(id string, p *PostableRule, reader interfaces.Reader, opts ...RuleOption) (*BaseRule, error) {
-	if p.RuleCondition == nil || !p.RuleCondition.IsValid() {
+	if p.RuleCondition == nil || p.RuleCondition.CompositeQuery == nil {
+		return nil, fmt.Errorf("invalid rule condition")
+	}
+	// recording rules don't compare the result against a threshold
+	if p.RuleType != RuleTypeRecording && !p.RuleCondition.IsValid() {
 		return nil, fmt.Errorf("invalid rule condition")
 	}
 
@@ -121,8 +137,11 @@ func NewBaseRule(id string, p *PostableRule, reader interfaces.Reader, opts ...R
 		labels:            qslabels.FromMap(p.Labels),
 		annotations:       qslabels.FromMap(p.Annotations),
 		preferredChannels: p.PreferredChannels,
+		inhibitedBy:       p.InhibitedBy,
+		notifyTemplates:   p.NotificationTemplates,
 		health:            HealthUnknown,
 		Active:            map[uint64]*Alert{},
+		seenSeries:        map[uint64]*seenSeries{},
 		reader:            reader,
 		TemporalityMap:    make(map[string]map[v3.Temporality]bool),
 	}
@@ -139,33 +158,44 @@ func NewBaseRule(id string, p *PostableRule, reader interfaces.Reader, opts ...R
 }
 
 func (r *BaseRule) targetVal() float64 {
-	if r.ruleCondition == nil || r.ruleCondition.Target == nil {
+	if r.ruleCondition == nil {
+		return 0
+	}
+	return r.thresholdTarget(r.ruleCondition.thresholds()[0])
+}
+
+// thresholdTarget returns the target of the threshold level converted to
+// the y-axis unit of the rule
+func (r *BaseRule) thresholdTarget(th RuleThreshold) float64 {
+	return thresholdTarget(th, r.Unit())
+}
+
+// thresholdTarget returns the target of the threshold level converted to unit
+func thresholdTarget(th RuleThreshold, unit string) float64 {
+	if th.Target == nil {
 		return 0
 	}
 
 	// get the converter for the target unit
-	unitConverter := converter.FromUnit(converter.Unit(r.ruleCondition.TargetUnit))
+	unitConverter := converter.FromUnit(converter.Unit(th.TargetUnit))
 	// convert the target value to the y-axis unit
 	value := unitConverter.Convert(converter.Value{
-		F: *r.ruleCondition.Target,
-		U: converter.Unit(r.ruleCondition.TargetUnit),
-	}, converter.Unit(r.Unit()))
+		F: *th.Target,
+		U: converter.Unit(th.TargetUnit),
+	}, converter.Unit(unit))
 
 	return value.F
 }
 
-func (r *BaseRule) matchType() MatchType {
-	if r.ruleCondition == nil {
-		return AtleastOnce
-	}
-	return r.ruleCondition.MatchType
-}
-
-func (r *BaseRule) compareOp() CompareOp {
-	if r.ruleCondition == nil {
-		return ValueIsEq
+// AlertFingerprint returns the hash that identifies an alert across evaluations.
+// For rules with threshold levels the severity is left out, so that moving
+// between levels updates the active alert instead of resolving it and
+// creating a new one.
+func (r *BaseRule) AlertFingerprint(lbs qslabels.Labels) uint64 {
+	if r.ruleCondition != nil && len(r.ruleCondition.Thresholds) > 0 {
+		return lbs.HashWithoutLabels(qslabels.AlertSeverityLabel)
 	}
-	return r.ruleCondition.CompareOp
+	return lbs.Hash()
 }
 
 func (r *BaseRule) currentAlerts() []*Alert {
@@ -213,6 +243,9 @@ func (r *BaseRule) Condition() *RuleCondition        { return r.ruleCondition }
 func (r *BaseRule) Labels() qslabels.BaseLabels      { return r.labels }
 func (r *BaseRule) Annotations() qslabels.BaseLabels { return r.annotations }
 func (r *BaseRule) PreferredChannels() []string      { return r.preferredChannels }
+func (r *BaseRule) InhibitedBy() []RuleInhibition    { return r.inhibitedBy }
+
+func (r *BaseRule) NotificationTemplates() map[string]string { return r.notifyTemplates }
 
 func (r *BaseRule) GeneratorURL() string {
 	return prepareRuleGeneratorURL(r.ID(), r.source)
@@ -310,6 +343,7 @@ func (r *BaseRule) ActiveAlerts() []*Alert {
 
 func (r *BaseRule) SendAlerts(ctx context.Context, ts time.Time, resendDelay time.Duration, interval time.Duration, notifyFunc NotifyFunc) {
 	alerts := []*Alert{}
+	sent := []*Alert{}
 	r.ForEachActiveAlert(func(alert *Alert) {
 		if alert.needsSending(ts, resendDelay) {
 			alert.LastSentAt = ts
@@ -320,9 +354,66 @@ func (r *BaseRule) SendAlerts(ctx context.Context, ts time.Time, resendDelay tim
 			alert.ValidUntil = ts.Add(4 * delta)
 			anew := *alert
 			alerts = append(alerts, &anew)
+			sent = append(sent, alert)
 		}
 	})
 	notifyFunc(ctx, "", alerts...)
+
+	// the suppressed alerts are dropped by the notify func, the others
+	// went out as firing
+	r.mtx.Lock()
+	defer r.mtx.Unlock()
+	for _, alert := range sent {
+		if alert.ResolvedAt.IsZero() && alert.SuppressedBy == "" {
+			alert.Notified = true
+		}
+	}
+}
+
+// UpdateSuppression marks the active alert with the given labels as suppressed
+// by the rule sourceID, or clears the mark when sourceID is empty. Changes are
+// recorded in the rule state history.
+func (r *BaseRule) UpdateSuppression(ctx context.Context, ts time.Time, lbls qslabels.BaseLabels, sourceID string) {
+	r.mtx.Lock()
+	a, ok := r.Active[r.AlertFingerprint(qslabels.FromMap(lbls.Map()))]
+	if !ok || a.SuppressedBy == sourceID || a.State != model.StateFiring {
+		r.mtx.Unlock()
+		return
+	}
+	a.SuppressedBy = sourceID
+
+	labelsJSON, err := json.Marshal(a.QueryResultLables)
+	if err != nil {
+		zap.L().Error("error marshaling labels", zap.Error(err), zap.Any("labels", a.Labels))
+	}
+
+	state := model.StateFiring
+	if sourceID != "" {
+		state = model.StateSuppressed
+	} else if a.Missing {
+		state = model.StateNoData
+	}
+	item := model.RuleStateHistory{
+		RuleID:       r.ID(),
+		RuleName:     r.Name(),
+		State:        state,
+		StateChanged: true,
+		UnixMilli:    ts.UnixMilli(),
+		Labels:       model.LabelsString(labelsJSON),
+		Fingerprint:  a.QueryResultLables.Hash(),
+		Value:        a.Value,
+		Severity:     a.Severity,
+		OverallState: model.StateFiring,
+	}
+	r.mtx.Unlock()
+
+	zap.L().Info("alert suppression changed", zap.String("ruleid", r.ID()), zap.String("suppressedBy", sourceID), zap.Any("labels", lbls))
+
+	if r.reader != nil {
+		if err := r.reader.AddRuleStateHistory(ctx, []model.RuleStateHistory{item}); err != nil {
+			zap.L().Error("error while inserting rule state history", zap.Error(err), zap.Any("item", item))
+		}
+	}
 }
 
 func (r *BaseRule) ForEachActiveAlert(f func(*Alert)) {
@@ -334,9 +425,54 @@ func (r *BaseRule) ForEachActiveAlert(f func(*Alert)) {
 	}
 }
 
+// absentSeriesRetention is how long a series that stopped reporting is
+// tracked, after that its missing data alert is resolved
+const absentSeriesRetention = 24 * time.Hour
+
+type seenSeries struct {
+	labels   qslabels.Labels
+	lastSeen time.Time
+}
+
+// absentSeries records the series returned by the evaluation at ts and returns
+// a missing data sample for every tracked series that has not been seen for
+// the absent duration of the rule condition.
+func (r *BaseRule) absentSeries(ts time.Time, series []*v3.Series) Vector {
+	for _, s := range series {
+		if len(s.Points) == 0 {
+			continue
+		}
+		lbls := qslabels.FromMap(s.Labels)
+		r.seenSeries[lbls.Hash()] = &seenSeries{labels: lbls, lastSeen: ts}
+	}
+
+	absentFor := time.Duration(r.ruleCondition.AbsentFor) * time.Minute
+
+	var result Vector
+	for fp, s := range r.seenSeries {
+		missingFor := ts.Sub(s.lastSeen)
+		if missingFor > absentFor+absentSeriesRetention {
+			delete(r.seenSeries, fp)
+			continue
+		}
+		if missingFor == 0 || missingFor < absentFor {
+			continue
+		}
+		lbls := qslabels.NewBuilder(s.labels)
+		lbls.Set("lastSeen", s.lastSeen.Format(constants.AlertTimeFormat))
+		result = append(result, Sample{
+			Metric:    lbls.Labels(),
+			IsMissing: true,
+			Threshold: r.targetVal(),
+		})
+	}
+	return result
+}
+
+// ShouldAlert evaluates the series against the threshold levels of the rule,
+// most severe first, and returns the sample of the first level that is breached.
 func (r *BaseRule) ShouldAlert(series v3.Series) (Sample, bool) {
 	var alertSmpl Sample
-	var shouldAlert bool
 	var lbls qslabels.Labels
 
 	for name, value := range series.Labels {
@@ -357,44 +493,62 @@ func (r *BaseRule) ShouldAlert(series v3.Series) (Sample, bool) {
 		}
 	}
 
-	switch r.matchType() {
+	for _, th := range r.ruleCondition.thresholds() {
+		smpl, shouldAlert := r.shouldAlertForThreshold(series, lbls, th)
+		if shouldAlert {
+			return smpl, true
+		}
+		alertSmpl = smpl
+	}
+	return alertSmpl, false
+}
+
+// shouldAlertForThreshold evaluates the points of the series against
+// a single threshold level
+func (r *BaseRule) shouldAlertForThreshold(series v3.Series, lbls qslabels.Labels, th RuleThreshold) (Sample, bool) {
+	var alertSmpl Sample
+	var shouldAlert bool
+
+	target := r.thresholdTarget(th)
+
+	switch th.MatchType {
 	case AtleastOnce:
 		// If any sample matches the condition, the rule is firing.
-		if r.compareOp() == ValueIsAbove {
+		if th.CompareOp == ValueIsAbove {
 			for _, smpl := range series.Points {
-				if smpl.Value > r.targetVal() {
+				if smpl.Value > target {
 					alertSmpl = Sample{Point: Point{V: smpl.Value}, Metric: lbls}
 					shouldAlert = true
 					break
 				}
 			}
-		} else if r.compareOp() == ValueIsBelow {
+		} else if th.CompareOp == ValueIsBelow {
 			for _, smpl := range series.Points {
-				if smpl.Value < r.targetVal() {
+				if smpl.Value < target {
 					alertSmpl = Sample{Point: Point{V: smpl.Value}, Metric: lbls}
 					shouldAlert = true
 					break
 				}
 			}
-		} else if r.compareOp() == ValueIsEq {
+		} else if th.CompareOp == ValueIsEq {
 			for _, smpl := range series.Points {
-				if smpl.Value == r.targetVal() {
+				if smpl.Value == target {
 					alertSmpl = Sample{Point: Point{V: smpl.Value}, Metric: lbls}
 					shouldAlert = true
 					break
 				}
 			}
-		} else if r.compareOp() == ValueIsNotEq {
+		} else if th.CompareOp == ValueIsNotEq {
 			for _, smpl := range series.Points {
-				if smpl.Value != r.targetVal() {
+				if smpl.Value != target {
 					alertSmpl = Sample{Point: Point{V: smpl.Value}, Metric: lbls}
 					shouldAlert = true
 					break
 				}
 			}
-		} else if r.compareOp() == ValueOutsideBounds {
+		} else if th.CompareOp == ValueOutsideBounds {
 			for _, smpl := range series.Points {
-				if math.Abs(smpl.Value) >= r.targetVal() {
+				if math.Abs(smpl.Value) >= target {
 					alertSmpl = Sample{Point: Point{V: smpl.Value}, Metric: lbls}
 					shouldAlert = true
 					break
@@ -404,10 +558,10 @@ func (r *BaseRule) ShouldAlert(series v3.Series) (Sample, bool) {
 	case AllTheTimes:
 		// If all samples match the condition, the rule is firing.
 		shouldAlert = true
-		alertSmpl = Sample{Point: Point{V: r.targetVal()}, Metric: lbls}
-		if r.compareOp() == ValueIsAbove {
+		alertSmpl = Sample{Point: Point{V: target}, Metric: lbls}
+		if th.CompareOp == ValueIsAbove {
 			for _, smpl := range series.Points {
-				if smpl.Value <= r.targetVal() {
+				if smpl.Value <= target {
 					shouldAlert = false
 					break
 				}
@@ -422,9 +576,9 @@ func (r *BaseRule) ShouldAlert(series v3.Series) (Sample, bool) {
 				}
 				alertSmpl = Sample{Point: Point{V: minValue}, Metric: lbls}
 			}
-		} else if r.compareOp() == ValueIsBelow {
+		} else if th.CompareOp == ValueIsBelow {
 			for _, smpl := range series.Points {
-				if smpl.Value >= r.targetVal() {
+				if smpl.Value >= target {
 					shouldAlert = false
 					break
 				}
@@ -438,16 +592,16 @@ func (r *BaseRule) ShouldAlert(series v3.Series) (Sample, bool) {
 				}
 				alertSmpl = Sample{Point: Point{V: maxValue}, Metric: lbls}
 			}
-		} else if r.compareOp() == ValueIsEq {
+		} else if th.CompareOp == ValueIsEq {
 			for _, smpl := range series.Points {
-				if smpl.Value != r.targetVal() {
+				if smpl.Value != target {
 					shouldAlert = false
 					break
 				}
 			}
-		} else if r.compareOp() == ValueIsNotEq {
+		} else if th.CompareOp == ValueIsNotEq {
 			for _, smpl := range series.Points {
-				if smpl.Value == r.targetVal() {
+				if smpl.Value == target {
 					shouldAlert = false
 					break
 				}
@@ -461,9 +615,9 @@ func (r *BaseRule) ShouldAlert(series v3.Series) (Sample, bool) {
 					}
 				}
 			}
-		} else if r.compareOp() == ValueOutsideBounds {
+		} else if th.CompareOp == ValueOutsideBounds {
 			for _, smpl := range series.Points {
-				if math.Abs(smpl.Value) >= r.targetVal() {
+				if math.Abs(smpl.Value) >= target {
 					alertSmpl = Sample{Point: Point{V: smpl.Value}, Metric: lbls}
 					shouldAlert = true
 					break
@@ -482,24 +636,24 @@ func (r *BaseRule) ShouldAlert(series v3.Series) (Sample, bool) {
 		}
 		avg := sum / count
 		alertSmpl = Sample{Point: Point{V: avg}, Metric: lbls}
-		if r.compareOp() == ValueIsAbove {
-			if avg > r.targetVal() {
+		if th.CompareOp == ValueIsAbove {
+			if avg > target {
 				shouldAlert = true
 			}
-		} else if r.compareOp() == ValueIsBelow {
-			if avg < r.targetVal() {
+		} else if th.CompareOp == ValueIsBelow {
+			if avg < target {
 				shouldAlert = true
 			}
-		} else if r.compareOp() == ValueIsEq {
-			if avg == r.targetVal() {
+		} else if th.CompareOp == ValueIsEq {
+			if avg == target {
 				shouldAlert = true
 			}
-		} else if r.compareOp() == ValueIsNotEq {
-			if avg != r.targetVal() {
+		} else if th.CompareOp == ValueIsNotEq {
+			if avg != target {
 				shouldAlert = true
 			}
-		} else if r.compareOp() == ValueOutsideBounds {
-			if math.Abs(avg) >= r.targetVal() {
+		} else if th.CompareOp == ValueOutsideBounds {
+			if math.Abs(avg) >= target {
 				shouldAlert = true
 			}
 		}
@@ -514,24 +668,24 @@ func (r *BaseRule) ShouldAlert(series v3.Series) (Sample, bool) {
 			sum += smpl.Value
 		}
 		alertSmpl = Sample{Point: Point{V: sum}, Metric: lbls}
-		if r.compareOp() == ValueIsAbove {
-			if sum > r.targetVal() {
+		if th.CompareOp == ValueIsAbove {
+			if sum > target {
 				shouldAlert = true
 			}
-		} else if r.compareOp() == ValueIsBelow {
-			if sum < r.targetVal() {
+		} else if th.CompareOp == ValueIsBelow {
+			if sum < target {
 				shouldAlert = true
 			}
-		} else if r.compareOp() == ValueIsEq {
-			if sum == r.targetVal() {
+		} else if th.CompareOp == ValueIsEq {
+			if sum == target {
 				shouldAlert = true
 			}
-		} else if r.compareOp() == ValueIsNotEq {
-			if sum != r.targetVal() {
+		} else if th.CompareOp == ValueIsNotEq {
+			if sum != target {
 				shouldAlert = true
 			}
-		} else if r.compareOp() == ValueOutsideBounds {
-			if math.Abs(sum) >= r.targetVal() {
+		} else if th.CompareOp == ValueOutsideBounds {
+			if math.Abs(sum) >= target {
 				shouldAlert = true
 			}
 		}
@@ -539,24 +693,26 @@ func (r *BaseRule) ShouldAlert(series v3.Series) (Sample, bool) {
 		// If the last sample matches the condition, the rule is firing.
 		shouldAlert = false
 		alertSmpl = Sample{Point: Point{V: series.Points[len(series.Points)-1].Value}, Metric: lbls}
-		if r.compareOp() == ValueIsAbove {
-			if series.Points[len(series.Points)-1].Value > r.targetVal() {
+		if th.CompareOp == ValueIsAbove {
+			if series.Points[len(series.Points)-1].Value > target {
 				shouldAlert = true
 			}
-		} else if r.compareOp() == ValueIsBelow {
-			if series.Points[len(series.Points)-1].Value < r.targetVal() {
+		} else if th.CompareOp == ValueIsBelow {
+			if series.Points[len(series.Points)-1].Value < target {
 				shouldAlert = true
 			}
-		} else if r.compareOp() == ValueIsEq {
-			if series.Points[len(series.Points)-1].Value == r.targetVal() {
+		} else if th.CompareOp == ValueIsEq {
+			if series.Points[len(series.Points)-1].Value == target {
 				shouldAlert = true
 			}
-		} else if r.compareOp() == ValueIsNotEq {
-			if series.Points[len(series.Points)-1].Value != r.targetVal() {
+		} else if th.CompareOp == ValueIsNotEq {
+			if series.Points[len(series.Points)-1].Value != target {
 				shouldAlert = true
 			}
 		}
 	}
+	alertSmpl.Threshold = target
+	alertSmpl.Severity = th.Severity
 	return alertSmpl, shouldAlert
 }
 
@@ -585,7 +741,7 @@ func (r *BaseRule) RecordRuleStateHistory(ctx context.Context, prevState, curren
 			if !ok {
 				// there was a state change in the past, but not in the current state
 				// if the state was firing, then we should add a resolved state change
-				if item.State == model.StateFiring || item.State == model.StateNoData {
+				if item.State == model.StateFiring || item.State == model.StateNoData || item.State == model.StateSuppressed {
 					item.State = model.StateInactive
 					item.StateChanged = true
 					item.UnixMilli = time.Now().UnixMilli()
@@ -595,9 +751,17 @@ func (r *BaseRule) RecordRuleStateHistory(ctx context.Context, prevState, curren
 			} else {
 				if item.State != currentState.State {
 					item.State = currentState.State
+					item.Severity = currentState.Severity
 					item.StateChanged = true
 					item.UnixMilli = time.Now().UnixMilli()
 					revisedItemsToAdd[item.Fingerprint] = item
+				} else if item.Severity != currentState.Severity {
+					// the alert is still in the same state but moved to another
+					// threshold level while the query-service was down
+					item.Severity = currentState.Severity
+					item.StateChanged = false
+					item.UnixMilli = time.Now().UnixMilli()
+					revisedItemsToAdd[item.Fingerprint] = item
 				}
 			}
 			// do not add this item to revisedItemsToAdd as it is already processed
@@ -615,7 +779,7 @@ func (r *BaseRule) RecordRuleStateHistory(ctx context.Context, prevState, curren
 
 		newState := model.StateInactive
 		for _, item := range revisedItemsToAdd {
-			if item.State == model.StateFiring || item.State == model.StateNoData {
+			if item.State == model.StateFiring || item.State == model.StateNoData || item.State == model.StateSuppressed {
 				newState = model.StateFiring
 				break
 			}
@@ -0,0 +1,504 @@
+package rules
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+	"go.uber.org/zap"
+	"gopkg.in/yaml.v3"
+)
+
+// A bundle is a set of rules and planned maintenances kept outside of
+// SigNoz, e.g. in git, as a multi-document yaml file or a json array.
+// Every document has a kind, an optional id and the spec, which is the
+// same payload accepted by the rules and downtime schedules apis:
+//
+//	kind: rule
+//	id: "12"
+//	spec:
+//	  alert: High latency
+//	  ...
+//
+// Documents are matched with the stored rules and maintenances by id,
+// or by name when no id is given.
+
+type BundleKind string
+
+const (
+	BundleKindRule        BundleKind = "rule"
+	BundleKindMaintenance BundleKind = "maintenance"
+)
+
+type BundleAction string
+
+const (
+	BundleActionCreate    BundleAction = "create"
+	BundleActionUpdate    BundleAction = "update"
+	BundleActionDelete    BundleAction = "delete"
+	BundleActionUnchanged BundleAction = "unchanged"
+)
+
+// BundleDoc is a single document of a bundle
+type BundleDoc struct {
+	Kind BundleKind      `json:"kind" yaml:"kind"`
+	ID   string          `json:"id,omitempty" yaml:"id,omitempty"`
+	Spec json.RawMessage `json:"spec" yaml:"-"`
+}
+
+// maintenanceSpec is the part of a planned maintenance that is
+// defined by the user
+type maintenanceSpec struct {
+	Name           string          `json:"name"`
+	Description    string          `json:"description"`
+	Schedule       *Schedule       `json:"schedule"`
+	AlertIds       *AlertIds       `json:"alertIds"`
+	LabelSelectors *LabelSelectors `json:"labelSelectors,omitempty"`
+}
+
+// BundleChange is a single change required to sync the stored
+// rules and maintenances with a bundle
+type BundleChange struct {
+	Kind   BundleKind   `json:"kind"`
+	Action BundleAction `json:"action"`
+	ID     string       `json:"id,omitempty"`
+	Name   string       `json:"name"`
+
+	rule        *PostableRule
+	ruleData    string
+	maintenance *PlannedMaintenance
+}
+
+// BundleDiff is the result of applying, or dry running, a bundle
+type BundleDiff struct {
+	DryRun    bool           `json:"dryRun"`
+	Created   int            `json:"created"`
+	Updated   int            `json:"updated"`
+	Deleted   int            `json:"deleted"`
+	Unchanged int            `json:"unchanged"`
+	Changes   []BundleChange `json:"changes"`
+}
+
+func (d *BundleDiff) add(c BundleChange) {
+	switch c.Action {
+	case BundleActionCreate:
+		d.Created++
+	case BundleActionUpdate:
+		d.Updated++
+	case BundleActionDelete:
+		d.Deleted++
+	case BundleActionUnchanged:
+		d.Unchanged++
+	}
+	d.Changes = append(d.Changes, c)
+}
+
+// ParseBundle reads the documents of a yaml or json bundle
+func ParseBundle(content []byte) ([]BundleDoc, error) {
+	docs := []BundleDoc{}
+
+	dec := yaml.NewDecoder(bytes.NewReader(content))
+	for {
+		var raw interface{}
+		err := dec.Decode(&raw)
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to parse bundle")
+		}
+		if raw == nil {
+			continue
+		}
+
+		// a json bundle is a list of documents
+		items, ok := raw.([]interface{})
+		if !ok {
+			items = []interface{}{raw}
+		}
+
+		for _, item := range items {
+			doc, err := parseBundleDoc(item)
+			if err != nil {
+				return nil, errors.Wrapf(err, "invalid bundle document %d", len(docs))
+			}
+			docs = append(docs, doc)
+		}
+	}
+	return docs, nil
+}
+
+func parseBundleDoc(item interface{}) (BundleDoc, error) {
+	var doc BundleDoc
+
+	m, ok := item.(map[string]interface{})
+	if !ok {
+		return doc, fmt.Errorf("document must be an object")
+	}
+
+	kind, _ := m["kind"].(string)
+	doc.Kind = BundleKind(kind)
+	if doc.Kind != BundleKindRule && doc.Kind != BundleKindMaintenance {
+		return doc, fmt.Errorf("unknown kind %q", kind)
+	}
+
+	switch id := m["id"].(type) {
+	case nil:
+	case string:
+		doc.ID = id
+	case int:
+		doc.ID = strconv.Itoa(id)
+	default:
+		return doc, fmt.Errorf("invalid id %v", id)
+	}
+
+	if m["spec"] == nil {
+		return doc, fmt.Errorf("missing spec")
+	}
+	spec, err := json.Marshal(m["spec"])
+	if err != nil {
+		return doc, err
+	}
+	doc.Spec = spec
+	return doc, nil
+}
+
+// FormatBundle writes the documents as a multi-document yaml or a json array
+func FormatBundle(docs []BundleDoc, kind RuleDataKind) ([]byte, error) {
+	if kind == RuleDataKindJson {
+		return json.MarshalIndent(docs, "", "  ")
+	}
+
+	var buf bytes.Buffer
+	enc := yaml.NewEncoder(&buf)
+	enc.SetIndent(2)
+	for _, doc := range docs {
+		var spec interface{}
+		if err := json.Unmarshal(doc.Spec, &spec); err != nil {
+			return nil, err
+		}
+		err := enc.Encode(struct {
+			Kind BundleKind  `yaml:"kind"`
+			ID   string      `yaml:"id,omitempty"`
+			Spec interface{} `yaml:"spec"`
+		}{doc.Kind, doc.ID, spec})
+		if err != nil {
+			return nil, err
+		}
+	}
+	if err := enc.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// normalizedRule returns the rule in a form that can be compared with other rules
+func normalizedRule(rule *PostableRule) string {
+	b, _ := json.Marshal(rule)
+	return string(b)
+}
+
+func normalizedMaintenance(m *PlannedMaintenance) string {
+	b, _ := json.Marshal(maintenanceSpec{m.Name, m.Description, m.Schedule, m.AlertIds, m.LabelSelectors})
+	return string(b)
+}
+
+// diffBundle computes the changes needed to sync the stored rules and
+// maintenances with the documents. Stored items missing in the bundle
+// are deleted only when prune is set.
+func (m *Manager) diffBundle(ctx context.Context, docs []BundleDoc, prune bool) (*BundleDiff, error) {
+	storedRules, err := m.ruleDB.GetStoredRules(ctx)
+	if err != nil {
+		return nil, err
+	}
+	storedMaintenances, err := m.ruleDB.GetAllPlannedMaintenance(ctx)
+	if err != nil {
+		return nil, err
+	}
+	slos, err := m.ruleDB.GetAllSLOs(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	// the burn rate rules of the SLOs are managed with the SLOs, they are
+	// never pruned
+	sloRuleIDs := map[string]bool{}
+	for _, s := range slos {
+		for _, id := range s.RuleIds {
+			sloRuleIDs[id] = true
+		}
+	}
+
+	rulesByID := map[string]*PostableRule{}
+	ruleIDsByName := map[string][]string{}
+	for _, s := range storedRules {
+		id := strconv.Itoa(s.Id)
+		rule, err := ParsePostableRule([]byte(s.Data))
+		if err != nil {
+			// stored rules that no longer parse are always updated
+			zap.L().Warn("failed to parse stored rule", zap.String("id", id), zap.Error(err))
+			rule = &PostableRule{}
+		}
+		rulesByID[id] = rule
+		ruleIDsByName[rule.AlertName] = append(ruleIDsByName[rule.AlertName], id)
+	}
+
+	maintenancesByID := map[string]*PlannedMaintenance{}
+	maintenanceIDsByName := map[string][]string{}
+	for idx := range storedMaintenances {
+		mt := &storedMaintenances[idx]
+		id := strconv.FormatInt(mt.Id, 10)
+		maintenancesByID[id] = mt
+		maintenanceIDsByName[mt.Name] = append(maintenanceIDsByName[mt.Name], id)
+	}
+
+	// resolve finds the stored item a document refers to
+	resolve := func(kind BundleKind, id, name string, byID map[string]bool, idsByName map[string][]string) (string, error) {
+		if id != "" {
+			if !byID[id] {
+				return "", fmt.Errorf("%s %s not found", kind, id)
+			}
+			return id, nil
+		}
+		ids := idsByName[name]
+		if len(ids) > 1 {
+			return "", fmt.Errorf("more than one %s is named %q, set the id to pick one", kind, name)
+		}
+		if len(ids) == 1 {
+			return ids[0], nil
+		}
+		return "", nil
+	}
+
+	ruleIDs, maintenanceIDs := map[string]bool{}, map[string]bool{}
+	for id := range rulesByID {
+		ruleIDs[id] = true
+	}
+	for id := range maintenancesByID {
+		maintenanceIDs[id] = true
+	}
+
+	diff := &BundleDiff{Changes: []BundleChange{}}
+	seen := map[BundleKind]map[string]bool{BundleKindRule: {}, BundleKindMaintenance: {}}
+	newNames := map[BundleKind]map[string]bool{BundleKindRule: {}, BundleKindMaintenance: {}}
+
+	for idx, doc := range docs {
+		change := BundleChange{Kind: doc.Kind}
+
+		var id string
+		switch doc.Kind {
+		case BundleKindRule:
+			rule, err := ParsePostableRule(doc.Spec)
+			if err != nil {
+				return nil, errors.Wrapf(err, "invalid rule in document %d", idx)
+			}
+			change.rule = rule
+			change.ruleData = string(doc.Spec)
+			change.Name = rule.AlertName
+
+			id, err = resolve(doc.Kind, doc.ID, rule.AlertName, ruleIDs, ruleIDsByName)
+			if err != nil {
+				return nil, err
+			}
+			if id != "" && normalizedRule(rulesByID[id]) == normalizedRule(rule) {
+				change.Action = BundleActionUnchanged
+			}
+		case BundleKindMaintenance:
+			var spec maintenanceSpec
+			if err := json.Unmarshal(doc.Spec, &spec); err != nil {
+				return nil, errors.Wrapf(err, "invalid maintenance in document %d", idx)
+			}
+			mt := &PlannedMaintenance{Name: spec.Name, Description: spec.Description, Schedule: spec.Schedule, AlertIds: spec.AlertIds, LabelSelectors: spec.LabelSelectors}
+			if err := mt.Validate(); err != nil {
+				return nil, errors.Wrapf(err, "invalid maintenance in document %d", idx)
+			}
+			change.maintenance = mt
+			change.Name = mt.Name
+
+			id, err = resolve(doc.Kind, doc.ID, mt.Name, maintenanceIDs, maintenanceIDsByName)
+			if err != nil {
+				return nil, err
+			}
+			if id != "" && normalizedMaintenance(maintenancesByID[id]) == normalizedMaintenance(mt) {
+				change.Action = BundleActionUnchanged
+			}
+		}
+
+		if id == "" {
+			if newNames[doc.Kind][change.Name] {
+				return nil, fmt.Errorf("more than one new %s is named %q", doc.Kind, change.Name)
+			}
+			newNames[doc.Kind][change.Name] = true
+			change.Action = BundleActionCreate
+		} else {
+			if seen[doc.Kind][id] {
+				return nil, fmt.Errorf("%s %s is defined more than once", doc.Kind, id)
+			}
+			seen[doc.Kind][id] = true
+			change.ID = id
+			if change.Action == "" {
+				change.Action = BundleActionUpdate
+			}
+		}
+		diff.add(change)
+	}
+
+	if prune {
+		deleted := []BundleChange{}
+		for id, rule := range rulesByID {
+			if !seen[BundleKindRule][id] && !sloRuleIDs[id] {
+				deleted = append(deleted, BundleChange{Kind: BundleKindRule, Action: BundleActionDelete, ID: id, Name: rule.AlertName})
+			}
+		}
+		for id, mt := range maintenancesByID {
+			if !seen[BundleKindMaintenance][id] {
+				deleted = append(deleted, BundleChange{Kind: BundleKindMaintenance, Action: BundleActionDelete, ID: id, Name: mt.Name})
+			}
+		}
+		sort.Slice(deleted, func(i, j int) bool {
+			if deleted[i].Kind != deleted[j].Kind {
+				return deleted[i].Kind > deleted[j].Kind
+			}
+			a, _ := strconv.Atoi(deleted[i].ID)
+			b, _ := strconv.Atoi(deleted[j].ID)
+			return a < b
+		})
+		for _, c := range deleted {
+			diff.add(c)
+		}
+	}
+
+	return diff, nil
+}
+
+// ApplyBundle syncs the stored rules and planned maintenances with the
+// bundle. All the changes are written in a single transaction, nothing
+// is written when dryRun is set.
+func (m *Manager) ApplyBundle(ctx context.Context, content []byte, dryRun bool, prune bool) (*BundleDiff, error) {
+	docs, err := ParseBundle(content)
+	if err != nil {
+		return nil, newApiErrorBadData(err)
+	}
+
+	diff, err := m.diffBundle(ctx, docs, prune)
+	if err != nil {
+		return nil, newApiErrorBadData(err)
+	}
+	diff.DryRun = dryRun
+
+	for _, c := range diff.Changes {
+		if c.rule == nil || c.Action == BundleActionUnchanged {
+			continue
+		}
+		if err := m.checkNotificationTemplates(ctx, c.rule); err != nil {
+			return nil, newApiErrorBadData(errors.Wrapf(err, "invalid rule %q", c.Name))
+		}
+	}
+
+	// make sure every rule can be loaded before anything is written
+	if !m.opts.DisableRules {
+		for _, c := range diff.Changes {
+			if c.rule == nil || c.rule.Disabled || c.Action == BundleActionUnchanged {
+				continue
+			}
+			if _, err := m.prepareTaskFunc(PrepareTaskOptions{
+				Rule:             c.rule,
+				TaskName:         prepareTaskName(0),
+				RuleDB:           m.ruleDB,
+				Logger:           m.logger,
+				Reader:           m.reader,
+				Cache:            m.cache,
+				FF:               m.featureFlags,
+				ManagerOpts:      m.opts,
+				NotifyFunc:       m.prepareNotifyFunc(),
+				UseLogsNewSchema: m.opts.UseLogsNewSchema,
+			}); err != nil {
+				return nil, newApiErrorBadData(errors.Wrapf(err, "failed to load rule %q", c.Name))
+			}
+		}
+	}
+
+	if dryRun {
+		return diff, nil
+	}
+
+	tx, err := m.ruleDB.ApplyBundleTx(ctx, diff.Changes)
+	if err != nil {
+		return nil, newApiErrorInternal(err)
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, newApiErrorInternal(err)
+	}
+
+	if m.opts.DisableRules {
+		return diff, nil
+	}
+
+	var errs []error
+	for _, c := range diff.Changes {
+		if c.Kind != BundleKindRule || c.Action == BundleActionUnchanged {
+			continue
+		}
+		id, _ := strconv.Atoi(c.ID)
+		taskName := prepareTaskName(int64(id))
+		if c.Action == BundleActionDelete {
+			m.deleteTask(taskName)
+			continue
+		}
+		if err := m.syncRuleStateWithTask(taskName, c.rule); err != nil {
+			zap.L().Error("failed to sync rule task with the bundle", zap.String("id", c.ID), zap.Error(err))
+			errs = append(errs, err)
+		}
+	}
+	if len(errs) > 0 {
+		return diff, newApiErrorInternal(fmt.Errorf("bundle was saved but %d rules failed to load", len(errs)))
+	}
+
+	return diff, nil
+}
+
+// ExportBundle writes all the stored rules and planned maintenances
+// as a bundle that can be applied with ApplyBundle
+func (m *Manager) ExportBundle(ctx context.Context, kind RuleDataKind) ([]byte, error) {
+	storedRules, err := m.ruleDB.GetStoredRules(ctx)
+	if err != nil {
+		return nil, err
+	}
+	maintenances, err := m.ruleDB.GetAllPlannedMaintenance(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	sort.Slice(storedRules, func(i, j int) bool { return storedRules[i].Id < storedRules[j].Id })
+	sort.Slice(maintenances, func(i, j int) bool { return maintenances[i].Id < maintenances[j].Id })
+
+	// a rule left out of the bundle would be deleted when the bundle is
+	// applied with prune, so the export fails instead
+	invalidIds := []string{}
+	docs := make([]BundleDoc, 0, len(storedRules)+len(maintenances))
+	for _, s := range storedRules {
+		if !json.Valid([]byte(s.Data)) {
+			invalidIds = append(invalidIds, strconv.Itoa(s.Id))
+			continue
+		}
+		docs = append(docs, BundleDoc{Kind: BundleKindRule, ID: strconv.Itoa(s.Id), Spec: json.RawMessage(s.Data)})
+	}
+	if len(invalidIds) > 0 {
+		return nil, fmt.Errorf("stored rules %s have invalid data and can't be exported", strings.Join(invalidIds, ", "))
+	}
+	for _, mt := range maintenances {
+		spec, err := json.Marshal(maintenanceSpec{mt.Name, mt.Description, mt.Schedule, mt.AlertIds, mt.LabelSelectors})
+		if err != nil {
+			return nil, err
+		}
+		docs = append(docs, BundleDoc{Kind: BundleKindMaintenance, ID: strconv.FormatInt(mt.Id, 10), Spec: spec})
+	}
+
+	return FormatBundle(docs, kind)
+}
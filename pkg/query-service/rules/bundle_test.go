@@ -0,0 +1,163 @@
+package rules
+
+import (
+	"context"
+	"strconv"
+	"strings"
+	"testing"
+
+	_ "github.com/mattn/go-sqlite3"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.signoz.io/signoz/pkg/query-service/utils"
+)
+
+const testBundle = `
+kind: rule
+spec:
+  alert: Target down
+  ruleType: promql_rule
+  evalWindow: 5m0s
+  frequency: 1m0s
+  condition:
+    compositeQuery:
+      queryType: promql
+      panelType: graph
+      promQueries:
+        A:
+          query: up == 0
+    op: "1"
+    target: 0
+    matchType: "1"
+  labels:
+    severity: critical
+---
+kind: rule
+spec:
+  alert: Too many restarts
+  ruleType: promql_rule
+  condition:
+    compositeQuery:
+      queryType: promql
+      panelType: graph
+      promQueries:
+        A:
+          query: increase(restarts_total[5m])
+    op: "1"
+    target: 3
+    matchType: "1"
+---
+kind: maintenance
+spec:
+  name: Weekly db upgrade
+  schedule:
+    timezone: UTC
+    startTime: "2024-01-01T00:00:00Z"
+    endTime: "2024-01-01T02:00:00Z"
+  alertIds: []
+`
+
+func TestParseBundle(t *testing.T) {
+	docs, err := ParseBundle([]byte(testBundle))
+	require.NoError(t, err)
+	require.Len(t, docs, 3)
+	assert.Equal(t, BundleKindRule, docs[0].Kind)
+	assert.Equal(t, BundleKindMaintenance, docs[2].Kind)
+
+	rule, err := ParsePostableRule(docs[0].Spec)
+	require.NoError(t, err)
+	assert.Equal(t, "Target down", rule.AlertName)
+	assert.Equal(t, "critical", rule.Labels["severity"])
+
+	// json bundles are a list of documents
+	out, err := FormatBundle(docs, RuleDataKindJson)
+	require.NoError(t, err)
+	jsonDocs, err := ParseBundle(out)
+	require.NoError(t, err)
+	require.Len(t, jsonDocs, 3)
+	assert.JSONEq(t, string(docs[1].Spec), string(jsonDocs[1].Spec))
+
+	_, err = ParseBundle([]byte("kind: dashboard\nspec: {}"))
+	assert.Error(t, err)
+
+	_, err = ParseBundle([]byte("kind: rule"))
+	assert.Error(t, err)
+}
+
+func TestApplyBundle(t *testing.T) {
+	ctx := context.Background()
+	db := utils.NewQueryServiceDBForTests(t)
+	m := &Manager{
+		ruleDB: NewRuleDB(db, nil),
+		opts:   &ManagerOptions{DisableRules: true},
+	}
+
+	// nothing is written on a dry run
+	diff, err := m.ApplyBundle(ctx, []byte(testBundle), true, false)
+	require.NoError(t, err)
+	assert.True(t, diff.DryRun)
+	assert.Equal(t, 3, diff.Created)
+	stored, err := m.ruleDB.GetStoredRules(ctx)
+	require.NoError(t, err)
+	assert.Len(t, stored, 0)
+
+	diff, err = m.ApplyBundle(ctx, []byte(testBundle), false, false)
+	require.NoError(t, err)
+	assert.Equal(t, 3, diff.Created)
+	stored, err = m.ruleDB.GetStoredRules(ctx)
+	require.NoError(t, err)
+	assert.Len(t, stored, 2)
+	maintenances, err := m.ruleDB.GetAllPlannedMaintenance(ctx)
+	require.NoError(t, err)
+	assert.Len(t, maintenances, 1)
+
+	// an exported bundle applies without changes
+	exported, err := m.ExportBundle(ctx, RuleDataKindYaml)
+	require.NoError(t, err)
+	diff, err = m.ApplyBundle(ctx, exported, false, true)
+	require.NoError(t, err)
+	assert.Equal(t, 3, diff.Unchanged)
+	assert.Equal(t, 0, diff.Created+diff.Updated+diff.Deleted)
+
+	// rules are matched by name, missing items are deleted when pruning
+	updated := strings.SplitN(testBundle, "---", 3)
+	updated[0] = strings.Replace(updated[0], "severity: critical", "severity: warning", 1)
+	diff, err = m.ApplyBundle(ctx, []byte(updated[0]+"---"+updated[1]), false, true)
+	require.NoError(t, err)
+	assert.Equal(t, 1, diff.Updated)
+	assert.Equal(t, 1, diff.Unchanged)
+	assert.Equal(t, 1, diff.Deleted)
+
+	stored, err = m.ruleDB.GetStoredRules(ctx)
+	require.NoError(t, err)
+	require.Len(t, stored, 2)
+	rule, err := ParsePostableRule([]byte(stored[0].Data))
+	require.NoError(t, err)
+	assert.Equal(t, "warning", rule.Labels["severity"])
+	maintenances, err = m.ruleDB.GetAllPlannedMaintenance(ctx)
+	require.NoError(t, err)
+	assert.Len(t, maintenances, 0)
+
+	// the rules generated for SLOs are not pruned
+	_, err = m.ruleDB.CreateSLO(ctx, &SLO{Name: "Restarts", RuleIds: []string{strconv.Itoa(stored[1].Id)}})
+	require.NoError(t, err)
+	diff, err = m.ApplyBundle(ctx, []byte(updated[0]), false, true)
+	require.NoError(t, err)
+	assert.Equal(t, 0, diff.Deleted)
+	stored, err = m.ruleDB.GetStoredRules(ctx)
+	require.NoError(t, err)
+	assert.Len(t, stored, 2)
+
+	// unknown ids are rejected and nothing is written
+	_, err = m.ApplyBundle(ctx, []byte(`{"kind": "rule", "id": "100", "spec": {"alert": "x"}}`), false, false)
+	assert.Error(t, err)
+
+	// rules with invalid data fail the export, pruning with the bundle
+	// would delete them
+	id, tx, err := m.ruleDB.CreateRuleTx(ctx, "{invalid")
+	require.NoError(t, err)
+	require.NoError(t, tx.Commit())
+	_, err = m.ExportBundle(ctx, RuleDataKindYaml)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), strconv.FormatInt(id, 10))
+}
@@ -0,0 +1,258 @@
+package rules
+
+import (
+	"context"
+	"math"
+	"time"
+
+	"github.com/pkg/errors"
+	"go.uber.org/zap"
+
+	"go.signoz.io/signoz/pkg/query-service/common"
+	"go.signoz.io/signoz/pkg/query-service/interfaces"
+	v3 "go.signoz.io/signoz/pkg/query-service/model/v3"
+	"go.signoz.io/signoz/pkg/query-service/utils/labels"
+)
+
+const (
+	// sloGoodQuery and sloTotalQuery are the names of the builder queries
+	// that count the good and the total events of an SLO
+	sloGoodQuery  = "A"
+	sloTotalQuery = "B"
+)
+
+// BurnRateRule alerts when the error budget of an SLO is spent faster than
+// the rule target over both the long and the short window of the rule. The
+// long window makes sure a significant part of the budget was spent, the
+// short window resolves the alert soon after the burn stops.
+type BurnRateRule struct {
+	*ThresholdRule
+
+	burnRate BurnRateCondition
+}
+
+func NewBurnRateRule(
+	id string,
+	p *PostableRule,
+	featureFlags interfaces.FeatureLookup,
+	reader interfaces.Reader,
+	useLogsNewSchema bool,
+	opts ...RuleOption,
+) (*BurnRateRule, error) {
+
+	zap.L().Info("creating new BurnRateRule", zap.String("id", id), zap.Any("opts", opts))
+
+	if errs := validateBurnRate(p.RuleCondition); len(errs) > 0 {
+		return nil, errs[0]
+	}
+
+	tr, err := NewThresholdRule(id, p, featureFlags, reader, useLogsNewSchema, opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	return &BurnRateRule{
+		ThresholdRule: tr,
+		burnRate:      *p.RuleCondition.BurnRate,
+	}, nil
+}
+
+func validateBurnRate(rc *RuleCondition) []error {
+	if rc == nil || rc.BurnRate == nil {
+		return []error{errors.Errorf("burn rate rule is missing the burn rate condition")}
+	}
+
+	var errs []error
+	br := rc.BurnRate
+	if br.Objective <= 0 || br.Objective >= 1 {
+		errs = append(errs, errors.Errorf("burn rate objective must be between 0 and 1"))
+	}
+	if br.ShortWindow <= 0 || br.LongWindow <= br.ShortWindow {
+		errs = append(errs, errors.Errorf("burn rate long window must be longer than the short window"))
+	}
+	if rc.Target == nil {
+		errs = append(errs, errors.Errorf("burn rate rule is missing the burn rate target"))
+	}
+	if rc.CompositeQuery == nil || rc.CompositeQuery.QueryType != v3.QueryTypeBuilder {
+		errs = append(errs, errors.Errorf("burn rate rules support builder queries only"))
+	} else {
+		for _, name := range []string{sloGoodQuery, sloTotalQuery} {
+			if _, ok := rc.CompositeQuery.BuilderQueries[name]; !ok {
+				errs = append(errs, errors.Errorf("burn rate rule is missing the query %s", name))
+			}
+		}
+	}
+	return errs
+}
+
+func (r *BurnRateRule) Type() RuleType {
+	return RuleTypeBurnRate
+}
+
+// sloEvents are the good and total events of a time bucket
+type sloEvents struct {
+	good  float64
+	total float64
+}
+
+// sloSeries holds the events of a series of the SLO queries by timestamp
+type sloSeries struct {
+	labels map[string]string
+	points map[int64]*sloEvents
+}
+
+// events returns the sum of the events of the series
+func (s *sloSeries) events() sloEvents {
+	var sum sloEvents
+	for _, e := range s.points {
+		sum.good += e.good
+		sum.total += e.total
+	}
+	return sum
+}
+
+// queryEvents runs the good and total queries between start and end with a
+// step of at least minStep seconds, and returns the events of every series
+// by the hash of its labels. The series of the two queries are matched by
+// their labels.
+func (r *BurnRateRule) queryEvents(ctx context.Context, start, end time.Time, minStep int64) (map[uint64]*sloSeries, error) {
+
+	cq := r.ruleCondition.CompositeQuery.Clone()
+	cq.PanelType = v3.PanelTypeGraph
+
+	step := int64(math.Max(float64(common.MinAllowedStepInterval(start.UnixMilli(), end.UnixMilli())), 60))
+	if minStep > step {
+		step = minStep
+	}
+	for _, q := range cq.BuilderQueries {
+		if q.StepInterval < step {
+			q.StepInterval = step
+		}
+	}
+
+	params := &v3.QueryRangeParamsV3{
+		Start:          start.UnixMilli(),
+		End:            end.UnixMilli(),
+		Step:           step,
+		CompositeQuery: cq,
+		Variables:      make(map[string]interface{}, 0),
+		NoCache:        true,
+	}
+
+	results, err := r.queryRange(ctx, params)
+	if err != nil {
+		return nil, err
+	}
+
+	series := map[uint64]*sloSeries{}
+	for _, res := range results {
+		q, ok := cq.BuilderQueries[res.QueryName]
+		if !ok || (res.QueryName != sloGoodQuery && res.QueryName != sloTotalQuery) {
+			continue
+		}
+		addSLOEvents(series, res, q)
+	}
+	return series, nil
+}
+
+// addSLOEvents adds the points of the result of the good or the total query
+// to the series. The values of rate aggregations are per second, they are
+// converted to the number of events of the step.
+func addSLOEvents(series map[uint64]*sloSeries, res *v3.Result, q *v3.BuilderQuery) {
+	scale := 1.0
+	if q.AggregateOperator.IsRateOperator() || q.TimeAggregation.IsRateOperator() {
+		scale = float64(q.StepInterval)
+	}
+
+	for _, s := range res.Series {
+		h := labels.FromMap(s.Labels).Hash()
+		ss, ok := series[h]
+		if !ok {
+			ss = &sloSeries{labels: s.Labels, points: map[int64]*sloEvents{}}
+			series[h] = ss
+		}
+		for _, p := range s.Points {
+			if math.IsNaN(p.Value) || math.IsInf(p.Value, 0) {
+				continue
+			}
+			e, ok := ss.points[p.Timestamp]
+			if !ok {
+				e = &sloEvents{}
+				ss.points[p.Timestamp] = e
+			}
+			if res.QueryName == sloGoodQuery {
+				e.good += p.Value * scale
+			} else {
+				e.total += p.Value * scale
+			}
+		}
+	}
+}
+
+// burnRate returns how many times faster than allowed by the objective the
+// error budget was spent, false if there were no events
+func burnRate(events sloEvents, objective float64) (float64, bool) {
+	if events.total <= 0 {
+		return 0, false
+	}
+	errorRatio := 1 - events.good/events.total
+	if errorRatio < 0 {
+		errorRatio = 0
+	}
+	return errorRatio / (1 - objective), true
+}
+
+// burnRates returns the burn rate of every series over the window ending at end
+func (r *BurnRateRule) burnRates(ctx context.Context, end time.Time, window time.Duration) (map[uint64]Sample, error) {
+	series, err := r.queryEvents(ctx, end.Add(-window), end, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	samples := make(map[uint64]Sample, len(series))
+	for h, s := range series {
+		rate, ok := burnRate(s.events(), r.burnRate.Objective)
+		if !ok {
+			continue
+		}
+		samples[h] = Sample{
+			Point:     Point{T: end.UnixMilli(), V: rate},
+			Metric:    labels.FromMap(s.labels),
+			Threshold: r.targetVal(),
+		}
+	}
+	return samples, nil
+}
+
+// Eval raises an alert for every series whose burn rate exceeds the target
+// over both windows, the value of the alert is the burn rate of the long window
+func (r *BurnRateRule) Eval(ctx context.Context, ts time.Time) (interface{}, error) {
+
+	_, end := r.Timestamps(ts)
+
+	long, err := r.burnRates(ctx, end, time.Duration(r.burnRate.LongWindow))
+	if err != nil {
+		return nil, err
+	}
+	short, err := r.burnRates(ctx, end, time.Duration(r.burnRate.ShortWindow))
+	if err != nil {
+		return nil, err
+	}
+
+	target := r.targetVal()
+
+	res := Vector{}
+	for h, smpl := range long {
+		if smpl.V <= target {
+			continue
+		}
+		if s, ok := short[h]; !ok || s.V <= target {
+			continue
+		}
+		res = append(res, smpl)
+	}
+
+	zap.L().Debug("burn rate evaluated", zap.String("rule", r.Name()), zap.Int("series", len(long)), zap.Int("breaching", len(res)))
+
+	return r.evalVector(ctx, ts, res)
+}
@@ -2,6 +2,7 @@ package rules
 
 import (
 	"context"
+	"database/sql"
 	"encoding/json"
 	"fmt"
 	"slices"
@@ -56,6 +57,43 @@ type RuleDB interface {
 	// GetAllPlannedMaintenance fetches the maintenance definitions from db
 	GetAllPlannedMaintenance(ctx context.Context) ([]PlannedMaintenance, error)
 
+	// CreateSLO stores the given SLO in the db and returns its id
+	CreateSLO(ctx context.Context, slo *SLO) (int64, error)
+
+	// EditSLO updates the given SLO in the db
+	EditSLO(ctx context.Context, slo *SLO) error
+
+	// DeleteSLO deletes the SLO with the given id from the db
+	DeleteSLO(ctx context.Context, id string) error
+
+	// GetSLO fetches the SLO from db by id, it returns nil if there is none
+	GetSLO(ctx context.Context, id string) (*SLO, error)
+
+	// GetAllSLOs fetches the SLO definitions from db
+	GetAllSLOs(ctx context.Context) ([]*SLO, error)
+
+	// CreateNotificationTemplate stores the given template in the db and returns its id
+	CreateNotificationTemplate(ctx context.Context, t *NotificationTemplate) (int64, error)
+
+	// EditNotificationTemplate updates the given template in the db
+	EditNotificationTemplate(ctx context.Context, t *NotificationTemplate) error
+
+	// DeleteNotificationTemplate deletes the template with the given id from the db
+	DeleteNotificationTemplate(ctx context.Context, id string) error
+
+	// GetNotificationTemplate fetches the template from db by id, it returns nil if there is none
+	GetNotificationTemplate(ctx context.Context, id string) (*NotificationTemplate, error)
+
+	// GetNotificationTemplateByName fetches the template from db by name, it returns nil if there is none
+	GetNotificationTemplateByName(ctx context.Context, name string) (*NotificationTemplate, error)
+
+	// GetAllNotificationTemplates fetches the notification templates from db
+	GetAllNotificationTemplates(ctx context.Context) ([]*NotificationTemplate, error)
+
+	// ApplyBundleTx writes the changes of a rules bundle in a single transaction
+	// and sets the ids of the created items. The tx is returned uncommitted.
+	ApplyBundleTx(ctx context.Context, changes []BundleChange) (Tx, error)
+
 	// used for internal telemetry
 	GetAlertsInfo(ctx context.Context) (*model.AlertsInfo, error)
 }
@@ -239,7 +277,7 @@ func (r *ruleDB) GetStoredRule(ctx context.Context, id string) (*StoredRule, err
 func (r *ruleDB) GetAllPlannedMaintenance(ctx context.Context) ([]PlannedMaintenance, error) {
 	maintenances := []PlannedMaintenance{}
 
-	query := "SELECT id, name, description, schedule, alert_ids, created_at, created_by, updated_at, updated_by FROM planned_maintenance"
+	query := "SELECT id, name, description, schedule, alert_ids, label_selectors, created_at, created_by, updated_at, updated_by FROM planned_maintenance"
 
 	err := r.Select(&maintenances, query)
 
@@ -254,7 +292,7 @@ func (r *ruleDB) GetAllPlannedMaintenance(ctx context.Context) ([]PlannedMainten
 func (r *ruleDB) GetPlannedMaintenanceByID(ctx context.Context, id string) (*PlannedMaintenance, error) {
 	maintenance := &PlannedMaintenance{}
 
-	query := "SELECT id, name, description, schedule, alert_ids, created_at, created_by, updated_at, updated_by FROM planned_maintenance WHERE id=$1"
+	query := "SELECT id, name, description, schedule, alert_ids, label_selectors, created_at, created_by, updated_at, updated_by FROM planned_maintenance WHERE id=$1"
 	err := r.Get(maintenance, query, id)
 
 	if err != nil {
@@ -273,9 +311,9 @@ func (r *ruleDB) CreatePlannedMaintenance(ctx context.Context, maintenance Plann
 	maintenance.UpdatedBy = email
 	maintenance.UpdatedAt = time.Now()
 
-	query := "INSERT INTO planned_maintenance (name, description, schedule, alert_ids, created_at, created_by, updated_at, updated_by) VALUES ($1, $2, $3, $4, $5, $6, $7, $8)"
+	query := "INSERT INTO planned_maintenance (name, description, schedule, alert_ids, label_selectors, created_at, created_by, updated_at, updated_by) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)"
 
-	result, err := r.Exec(query, maintenance.Name, maintenance.Description, maintenance.Schedule, maintenance.AlertIds, maintenance.CreatedAt, maintenance.CreatedBy, maintenance.UpdatedAt, maintenance.UpdatedBy)
+	result, err := r.Exec(query, maintenance.Name, maintenance.Description, maintenance.Schedule, maintenance.AlertIds, maintenance.LabelSelectors, maintenance.CreatedAt, maintenance.CreatedBy, maintenance.UpdatedAt, maintenance.UpdatedBy)
 
 	if err != nil {
 		zap.L().Error("Error in processing sql query", zap.Error(err))
@@ -302,8 +340,8 @@ func (r *ruleDB) EditPlannedMaintenance(ctx context.Context, maintenance Planned
 	maintenance.UpdatedBy = email
 	maintenance.UpdatedAt = time.Now()
 
-	query := "UPDATE planned_maintenance SET name=$1, description=$2, schedule=$3, alert_ids=$4, updated_at=$5, updated_by=$6 WHERE id=$7"
-	_, err := r.Exec(query, maintenance.Name, maintenance.Description, maintenance.Schedule, maintenance.AlertIds, maintenance.UpdatedAt, maintenance.UpdatedBy, id)
+	query := "UPDATE planned_maintenance SET name=$1, description=$2, schedule=$3, alert_ids=$4, label_selectors=$5, updated_at=$6, updated_by=$7 WHERE id=$8"
+	_, err := r.Exec(query, maintenance.Name, maintenance.Description, maintenance.Schedule, maintenance.AlertIds, maintenance.LabelSelectors, maintenance.UpdatedAt, maintenance.UpdatedBy, id)
 
 	if err != nil {
 		zap.L().Error("Error in processing sql query", zap.Error(err))
@@ -313,6 +351,238 @@ func (r *ruleDB) EditPlannedMaintenance(ctx context.Context, maintenance Planned
 	return "", nil
 }
 
+// storedSLO is a row of the slos table, the definition is kept as json
+type storedSLO struct {
+	Id        int64     `db:"id"`
+	CreatedAt time.Time `db:"created_at"`
+	CreatedBy string    `db:"created_by"`
+	UpdatedAt time.Time `db:"updated_at"`
+	UpdatedBy string    `db:"updated_by"`
+	Data      string    `db:"data"`
+}
+
+func (s *storedSLO) slo() (*SLO, error) {
+	slo := &SLO{}
+	if err := json.Unmarshal([]byte(s.Data), slo); err != nil {
+		return nil, err
+	}
+	slo.Id = s.Id
+	slo.CreatedAt, slo.CreatedBy = s.CreatedAt, s.CreatedBy
+	slo.UpdatedAt, slo.UpdatedBy = s.UpdatedAt, s.UpdatedBy
+	return slo, nil
+}
+
+func (r *ruleDB) CreateSLO(ctx context.Context, slo *SLO) (int64, error) {
+	email, _ := auth.GetEmailFromJwt(ctx)
+	slo.CreatedBy = email
+	slo.CreatedAt = time.Now()
+	slo.UpdatedBy = email
+	slo.UpdatedAt = slo.CreatedAt
+
+	data, err := json.Marshal(slo)
+	if err != nil {
+		return 0, err
+	}
+
+	query := "INSERT INTO slos (created_at, created_by, updated_at, updated_by, data) VALUES ($1, $2, $3, $4, $5)"
+	result, err := r.Exec(query, slo.CreatedAt, slo.CreatedBy, slo.UpdatedAt, slo.UpdatedBy, string(data))
+	if err != nil {
+		zap.L().Error("Error in processing sql query", zap.Error(err))
+		return 0, err
+	}
+
+	return result.LastInsertId()
+}
+
+func (r *ruleDB) EditSLO(ctx context.Context, slo *SLO) error {
+	email, _ := auth.GetEmailFromJwt(ctx)
+	slo.UpdatedBy = email
+	slo.UpdatedAt = time.Now()
+
+	data, err := json.Marshal(slo)
+	if err != nil {
+		return err
+	}
+
+	query := "UPDATE slos SET updated_at=$1, updated_by=$2, data=$3 WHERE id=$4"
+	_, err = r.Exec(query, slo.UpdatedAt, slo.UpdatedBy, string(data), slo.Id)
+	if err != nil {
+		zap.L().Error("Error in processing sql query", zap.Error(err))
+		return err
+	}
+	return nil
+}
+
+func (r *ruleDB) DeleteSLO(ctx context.Context, id string) error {
+	_, err := r.Exec("DELETE FROM slos WHERE id=$1", id)
+	if err != nil {
+		zap.L().Error("Error in processing sql query", zap.Error(err))
+		return err
+	}
+	return nil
+}
+
+func (r *ruleDB) GetSLO(ctx context.Context, id string) (*SLO, error) {
+	stored := storedSLO{}
+	err := r.Get(&stored, "SELECT id, created_at, created_by, updated_at, updated_by, data FROM slos WHERE id=$1", id)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		zap.L().Error("Error in processing sql query", zap.Error(err))
+		return nil, err
+	}
+	return stored.slo()
+}
+
+func (r *ruleDB) GetAllSLOs(ctx context.Context) ([]*SLO, error) {
+	stored := []storedSLO{}
+	err := r.Select(&stored, "SELECT id, created_at, created_by, updated_at, updated_by, data FROM slos ORDER BY id")
+	if err != nil {
+		zap.L().Error("Error in processing sql query", zap.Error(err))
+		return nil, err
+	}
+
+	slos := make([]*SLO, 0, len(stored))
+	for idx := range stored {
+		slo, err := stored[idx].slo()
+		if err != nil {
+			zap.L().Error("skipping stored slo with invalid data", zap.Int64("id", stored[idx].Id), zap.Error(err))
+			continue
+		}
+		slos = append(slos, slo)
+	}
+	return slos, nil
+}
+
+const notificationTemplateColumns = "id, name, channel_type, title, body, created_at, created_by, updated_at, updated_by"
+
+func (r *ruleDB) CreateNotificationTemplate(ctx context.Context, t *NotificationTemplate) (int64, error) {
+	email, _ := auth.GetEmailFromJwt(ctx)
+	t.CreatedBy = email
+	t.CreatedAt = time.Now()
+	t.UpdatedBy = email
+	t.UpdatedAt = t.CreatedAt
+
+	query := "INSERT INTO notification_templates (name, channel_type, title, body, created_at, created_by, updated_at, updated_by) VALUES ($1, $2, $3, $4, $5, $6, $7, $8)"
+	result, err := r.Exec(query, t.Name, t.ChannelType, t.Title, t.Body, t.CreatedAt, t.CreatedBy, t.UpdatedAt, t.UpdatedBy)
+	if err != nil {
+		zap.L().Error("Error in processing sql query", zap.Error(err))
+		return 0, err
+	}
+
+	return result.LastInsertId()
+}
+
+func (r *ruleDB) EditNotificationTemplate(ctx context.Context, t *NotificationTemplate) error {
+	email, _ := auth.GetEmailFromJwt(ctx)
+	t.UpdatedBy = email
+	t.UpdatedAt = time.Now()
+
+	query := "UPDATE notification_templates SET name=$1, channel_type=$2, title=$3, body=$4, updated_at=$5, updated_by=$6 WHERE id=$7"
+	_, err := r.Exec(query, t.Name, t.ChannelType, t.Title, t.Body, t.UpdatedAt, t.UpdatedBy, t.Id)
+	if err != nil {
+		zap.L().Error("Error in processing sql query", zap.Error(err))
+		return err
+	}
+	return nil
+}
+
+func (r *ruleDB) DeleteNotificationTemplate(ctx context.Context, id string) error {
+	_, err := r.Exec("DELETE FROM notification_templates WHERE id=$1", id)
+	if err != nil {
+		zap.L().Error("Error in processing sql query", zap.Error(err))
+		return err
+	}
+	return nil
+}
+
+func (r *ruleDB) GetNotificationTemplate(ctx context.Context, id string) (*NotificationTemplate, error) {
+	return r.getNotificationTemplate("SELECT "+notificationTemplateColumns+" FROM notification_templates WHERE id=$1", id)
+}
+
+func (r *ruleDB) GetNotificationTemplateByName(ctx context.Context, name string) (*NotificationTemplate, error) {
+	return r.getNotificationTemplate("SELECT "+notificationTemplateColumns+" FROM notification_templates WHERE name=$1", name)
+}
+
+func (r *ruleDB) getNotificationTemplate(query string, arg string) (*NotificationTemplate, error) {
+	t := &NotificationTemplate{}
+	err := r.Get(t, query, arg)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		zap.L().Error("Error in processing sql query", zap.Error(err))
+		return nil, err
+	}
+	return t, nil
+}
+
+func (r *ruleDB) GetAllNotificationTemplates(ctx context.Context) ([]*NotificationTemplate, error) {
+	templates := []*NotificationTemplate{}
+	err := r.Select(&templates, "SELECT "+notificationTemplateColumns+" FROM notification_templates ORDER BY name")
+	if err != nil {
+		zap.L().Error("Error in processing sql query", zap.Error(err))
+		return nil, err
+	}
+	return templates, nil
+}
+
+// ApplyBundleTx creates, updates and deletes the rules and planned
+// maintenances of a bundle in a single transaction
+func (r *ruleDB) ApplyBundleTx(ctx context.Context, changes []BundleChange) (Tx, error) {
+	var userEmail string
+	if user := common.GetUserFromContext(ctx); user != nil {
+		userEmail = user.Email
+	}
+	now := time.Now()
+
+	tx, err := r.Beginx()
+	if err != nil {
+		return nil, err
+	}
+
+	for idx := range changes {
+		c := &changes[idx]
+
+		var result sql.Result
+		switch {
+		case c.Action == BundleActionUnchanged:
+			continue
+		case c.Kind == BundleKindRule && c.Action == BundleActionCreate:
+			result, err = tx.Exec(`INSERT into rules (created_at, created_by, updated_at, updated_by, data) VALUES($1,$2,$3,$4,$5);`,
+				now, userEmail, now, userEmail, c.ruleData)
+		case c.Kind == BundleKindRule && c.Action == BundleActionUpdate:
+			_, err = tx.Exec(`UPDATE rules SET updated_by=$1, updated_at=$2, data=$3 WHERE id=$4;`, userEmail, now, c.ruleData, c.ID)
+		case c.Kind == BundleKindRule && c.Action == BundleActionDelete:
+			_, err = tx.Exec(`DELETE FROM rules WHERE id=$1;`, c.ID)
+		case c.Kind == BundleKindMaintenance && c.Action == BundleActionCreate:
+			mt := c.maintenance
+			result, err = tx.Exec("INSERT INTO planned_maintenance (name, description, schedule, alert_ids, label_selectors, created_at, created_by, updated_at, updated_by) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)",
+				mt.Name, mt.Description, mt.Schedule, mt.AlertIds, mt.LabelSelectors, now, userEmail, now, userEmail)
+		case c.Kind == BundleKindMaintenance && c.Action == BundleActionUpdate:
+			mt := c.maintenance
+			_, err = tx.Exec("UPDATE planned_maintenance SET name=$1, description=$2, schedule=$3, alert_ids=$4, label_selectors=$5, updated_at=$6, updated_by=$7 WHERE id=$8",
+				mt.Name, mt.Description, mt.Schedule, mt.AlertIds, mt.LabelSelectors, now, userEmail, c.ID)
+		case c.Kind == BundleKindMaintenance && c.Action == BundleActionDelete:
+			_, err = tx.Exec("DELETE FROM planned_maintenance WHERE id=$1", c.ID)
+		}
+
+		if err == nil && result != nil {
+			var id int64
+			id, err = result.LastInsertId()
+			c.ID = strconv.FormatInt(id, 10)
+		}
+		if err != nil {
+			zap.L().Error("Error in applying bundle change", zap.String("kind", string(c.Kind)), zap.String("action", string(c.Action)), zap.String("name", c.Name), zap.Error(err))
+			tx.Rollback()
+			return nil, err
+		}
+	}
+
+	return tx, nil
+}
+
 func getChannelType(receiver *am.Receiver) string {
 
 	if receiver.EmailConfigs != nil {
@@ -3,11 +3,14 @@ package rules
 import (
 	"database/sql/driver"
 	"encoding/json"
+	"fmt"
+	"regexp"
 	"slices"
 	"strings"
 	"time"
 
 	"github.com/pkg/errors"
+	"github.com/robfig/cron/v3"
 	"go.uber.org/zap"
 )
 
@@ -17,6 +20,7 @@ var (
 	ErrMissingTimezone   = errors.New("missing timezone")
 	ErrMissingRepeatType = errors.New("missing repeat type")
 	ErrMissingDuration   = errors.New("missing duration")
+	ErrMissingCron       = errors.New("missing cron expression")
 )
 
 type PlannedMaintenance struct {
@@ -25,12 +29,15 @@ type PlannedMaintenance struct {
 	Description string    `json:"description" db:"description"`
 	Schedule    *Schedule `json:"schedule" db:"schedule"`
 	AlertIds    *AlertIds `json:"alertIds" db:"alert_ids"`
-	CreatedAt   time.Time `json:"createdAt" db:"created_at"`
-	CreatedBy   string    `json:"createdBy" db:"created_by"`
-	UpdatedAt   time.Time `json:"updatedAt" db:"updated_at"`
-	UpdatedBy   string    `json:"updatedBy" db:"updated_by"`
-	Status      string    `json:"status"`
-	Kind        string    `json:"kind"`
+	// LabelSelectors scope the maintenance to the rules with matching
+	// labels, in addition to the rules listed in AlertIds
+	LabelSelectors *LabelSelectors `json:"labelSelectors,omitempty" db:"label_selectors"`
+	CreatedAt      time.Time       `json:"createdAt" db:"created_at"`
+	CreatedBy      string          `json:"createdBy" db:"created_by"`
+	UpdatedAt      time.Time       `json:"updatedAt" db:"updated_at"`
+	UpdatedBy      string          `json:"updatedBy" db:"updated_by"`
+	Status         string          `json:"status"`
+	Kind           string          `json:"kind"`
 }
 
 type AlertIds []string
@@ -46,6 +53,109 @@ func (a *AlertIds) Value() (driver.Value, error) {
 	return json.Marshal(a)
 }
 
+type LabelSelectorOp string
+
+const (
+	LabelSelectorOpEqual    LabelSelectorOp = "="
+	LabelSelectorOpNotEqual LabelSelectorOp = "!="
+	LabelSelectorOpRegex    LabelSelectorOp = "=~"
+	LabelSelectorOpNotRegex LabelSelectorOp = "!~"
+)
+
+// LabelSelector matches the value of a rule label, a label the rule
+// doesn't have is matched as an empty value. Regular expressions are
+// anchored at both ends.
+type LabelSelector struct {
+	Key   string          `json:"key"`
+	Op    LabelSelectorOp `json:"op"`
+	Value string          `json:"value"`
+
+	// re is the compiled expression of the regex operators, it is set by
+	// Validate
+	re *regexp.Regexp
+}
+
+func (s *LabelSelector) Validate() error {
+	if s.Key == "" {
+		return errors.New("label selector is missing the key")
+	}
+	switch s.Op {
+	case LabelSelectorOpEqual, LabelSelectorOpNotEqual:
+	case LabelSelectorOpRegex, LabelSelectorOpNotRegex:
+		re, err := regexp.Compile("^(?:" + s.Value + ")$")
+		if err != nil {
+			return errors.Wrapf(err, "invalid regular expression for label %s", s.Key)
+		}
+		s.re = re
+	default:
+		return fmt.Errorf("invalid label selector operator %q for label %s", s.Op, s.Key)
+	}
+	return nil
+}
+
+func (s LabelSelector) Matches(labels map[string]string) bool {
+	value := labels[s.Key]
+	switch s.Op {
+	case LabelSelectorOpEqual:
+		return value == s.Value
+	case LabelSelectorOpNotEqual:
+		return value != s.Value
+	case LabelSelectorOpRegex, LabelSelectorOpNotRegex:
+		re := s.re
+		if re == nil {
+			// the selector wasn't validated
+			var err error
+			if re, err = regexp.Compile("^(?:" + s.Value + ")$"); err != nil {
+				zap.L().Error("invalid label selector", zap.String("key", s.Key), zap.String("value", s.Value), zap.Error(err))
+				return false
+			}
+		}
+		return re.MatchString(value) == (s.Op == LabelSelectorOpRegex)
+	}
+	return false
+}
+
+// LabelSelectors match the labels that match all of the selectors
+type LabelSelectors []LabelSelector
+
+func (s LabelSelectors) Matches(labels map[string]string) bool {
+	for _, selector := range s {
+		if !selector.Matches(labels) {
+			return false
+		}
+	}
+	return true
+}
+
+func (s *LabelSelectors) Scan(src interface{}) error {
+	var data []byte
+	switch src := src.(type) {
+	case []byte:
+		data = src
+	case string:
+		data = []byte(src)
+	default:
+		return nil
+	}
+	if err := json.Unmarshal(data, s); err != nil {
+		return err
+	}
+	// compile the regular expressions once for all the rules matched
+	for idx := range *s {
+		if err := (*s)[idx].Validate(); err != nil {
+			zap.L().Error("invalid label selector", zap.String("key", (*s)[idx].Key), zap.Error(err))
+		}
+	}
+	return nil
+}
+
+func (s *LabelSelectors) Value() (driver.Value, error) {
+	if s == nil {
+		return nil, nil
+	}
+	return json.Marshal(s)
+}
+
 type Schedule struct {
 	Timezone   string      `json:"timezone"`
 	StartTime  time.Time   `json:"startTime,omitempty"`
@@ -70,6 +180,9 @@ const (
 	RepeatTypeDaily   RepeatType = "daily"
 	RepeatTypeWeekly  RepeatType = "weekly"
 	RepeatTypeMonthly RepeatType = "monthly"
+	// RepeatTypeCron repeats the maintenance at the times of a standard
+	// five field cron expression in the timezone of the schedule
+	RepeatTypeCron RepeatType = "cron"
 )
 
 type RepeatOn string
@@ -90,6 +203,7 @@ type Recurrence struct {
 	Duration   Duration   `json:"duration"`
 	RepeatType RepeatType `json:"repeatType"`
 	RepeatOn   []RepeatOn `json:"repeatOn"`
+	Cron       string     `json:"cron,omitempty"`
 }
 
 func (r *Recurrence) Scan(src interface{}) error {
@@ -131,6 +245,7 @@ func (s Schedule) MarshalJSON() ([]byte, error) {
 			Duration:   s.Recurrence.Duration,
 			RepeatType: s.Recurrence.RepeatType,
 			RepeatOn:   s.Recurrence.RepeatOn,
+			Cron:       s.Recurrence.Cron,
 		}
 	}
 
@@ -205,27 +320,31 @@ func (s *Schedule) UnmarshalJSON(data []byte) error {
 			Duration:   aux.Recurrence.Duration,
 			RepeatType: aux.Recurrence.RepeatType,
 			RepeatOn:   aux.Recurrence.RepeatOn,
+			Cron:       aux.Recurrence.Cron,
 		}
 	}
 	return nil
 }
 
-func (m *PlannedMaintenance) shouldSkip(ruleID string, now time.Time) bool {
+// appliesTo reports whether the rule is in scope of the maintenance. The
+// rule is in scope if its id is listed in the alert ids or its labels match
+// the label selectors. A maintenance with neither applies to all rules.
+func (m *PlannedMaintenance) appliesTo(ruleID string, ruleLabels map[string]string) bool {
+	hasAlertIds := m.AlertIds != nil && len(*m.AlertIds) > 0
+	hasSelectors := m.LabelSelectors != nil && len(*m.LabelSelectors) > 0
 
-	found := false
-	if m.AlertIds != nil {
-		for _, alertID := range *m.AlertIds {
-			if alertID == ruleID {
-				found = true
-				break
-			}
-		}
+	if !hasAlertIds && !hasSelectors {
+		return true
 	}
-
-	// If no alert ids, then skip all alerts
-	if m.AlertIds == nil || len(*m.AlertIds) == 0 {
-		found = true
+	if hasAlertIds && slices.Contains(*m.AlertIds, ruleID) {
+		return true
 	}
+	return hasSelectors && m.LabelSelectors.Matches(ruleLabels)
+}
+
+func (m *PlannedMaintenance) shouldSkip(ruleID string, ruleLabels map[string]string, now time.Time) bool {
+
+	found := m.appliesTo(ruleID, ruleLabels)
 
 	if found {
 
@@ -281,36 +400,17 @@ func (m *PlannedMaintenance) shouldSkip(ruleID string, now time.Time) bool {
 				return false
 			}
 
-			switch m.Schedule.Recurrence.RepeatType {
-			case RepeatTypeDaily:
-				// take the hours and minutes from the start time and add them to the current time
-				startTime := time.Date(currentTime.Year(), currentTime.Month(), currentTime.Day(), start.Hour(), start.Minute(), 0, 0, loc)
-				endTime := time.Date(currentTime.Year(), currentTime.Month(), currentTime.Day(), end.Hour(), end.Minute(), 0, 0, loc)
-				zap.L().Info("checking daily schedule", zap.Any("rule", ruleID), zap.String("maintenance", m.Name), zap.Time("currentTime", currentTime), zap.Time("startTime", startTime), zap.Time("endTime", endTime))
-
-				if currentTime.After(startTime) && currentTime.Before(endTime) {
-					return true
-				}
-			case RepeatTypeWeekly:
-				// if the current time in the timezone is between the start and end time on the RepeatOn day
-				startTime := time.Date(currentTime.Year(), currentTime.Month(), currentTime.Day(), start.Hour(), start.Minute(), 0, 0, loc)
-				endTime := time.Date(currentTime.Year(), currentTime.Month(), currentTime.Day(), end.Hour(), end.Minute(), 0, 0, loc)
-				zap.L().Info("checking weekly schedule", zap.Any("rule", ruleID), zap.String("maintenance", m.Name), zap.Time("currentTime", currentTime), zap.Time("startTime", startTime), zap.Time("endTime", endTime))
-				if currentTime.After(startTime) && currentTime.Before(endTime) {
-					if len(m.Schedule.Recurrence.RepeatOn) == 0 {
-						return true
-					} else if slices.Contains(m.Schedule.Recurrence.RepeatOn, RepeatOn(strings.ToLower(currentTime.Weekday().String()))) {
-						return true
-					}
-				}
-			case RepeatTypeMonthly:
-				// if the current time in the timezone is between the start and end time on the day of the current month
-				startTime := time.Date(currentTime.Year(), currentTime.Month(), start.Day(), start.Hour(), start.Minute(), 0, 0, loc)
-				endTime := time.Date(currentTime.Year(), currentTime.Month(), end.Day(), end.Hour(), end.Minute(), 0, 0, loc)
-				zap.L().Info("checking monthly schedule", zap.Any("rule", ruleID), zap.String("maintenance", m.Name), zap.Time("currentTime", currentTime), zap.Time("startTime", startTime), zap.Time("endTime", endTime))
-				if currentTime.After(startTime) && currentTime.Before(endTime) && currentTime.Day() == start.Day() {
-					return true
-				}
+			// the windows of every repeat type come from the same schedule
+			// as the upcoming occurrences, see nextOccurrence
+			sched, err := m.Schedule.Recurrence.schedule()
+			if err != nil {
+				zap.L().Error("Error parsing recurrence schedule", zap.Any("rule", ruleID), zap.String("maintenance", m.Name), zap.Error(err))
+				return false
+			}
+			window, ok := m.Schedule.Recurrence.windowAfter(sched, currentTime, loc)
+			zap.L().Info("checking recurrence window", zap.Any("rule", ruleID), zap.String("maintenance", m.Name), zap.Time("currentTime", currentTime), zap.Time("startTime", window.StartTime), zap.Time("endTime", window.EndTime))
+			if ok && !window.StartTime.After(currentTime) {
+				return true
 			}
 		}
 	}
@@ -318,12 +418,12 @@ func (m *PlannedMaintenance) shouldSkip(ruleID string, now time.Time) bool {
 	return false
 }
 
+// IsActive reports whether a window of the maintenance is open at now,
+// regardless of the rules it applies to
 func (m *PlannedMaintenance) IsActive(now time.Time) bool {
-	ruleID := "maintenance"
-	if m.AlertIds != nil && len(*m.AlertIds) > 0 {
-		ruleID = (*m.AlertIds)[0]
-	}
-	return m.shouldSkip(ruleID, now)
+	unscoped := *m
+	unscoped.AlertIds, unscoped.LabelSelectors = nil, nil
+	return unscoped.shouldSkip("maintenance", nil, now)
 }
 
 func (m *PlannedMaintenance) IsUpcoming() bool {
@@ -341,6 +441,147 @@ func (m *PlannedMaintenance) IsRecurring() bool {
 	return m.Schedule.Recurrence != nil
 }
 
+// Occurrence is a single window of a planned maintenance
+type Occurrence struct {
+	StartTime time.Time `json:"startTime"`
+	EndTime   time.Time `json:"endTime"`
+}
+
+// Occurrences returns up to limit windows of the maintenance that end after
+// from, in the order they start. The windows of a recurrence are computed on
+// the wall clock of the schedule timezone, see nextOccurrence.
+func (m *PlannedMaintenance) Occurrences(from time.Time, limit int) ([]Occurrence, error) {
+	loc, err := time.LoadLocation(m.Schedule.Timezone)
+	if err != nil {
+		return nil, errors.Wrap(err, "invalid timezone")
+	}
+
+	occurrences := []Occurrence{}
+	if !m.Schedule.StartTime.IsZero() && !m.Schedule.EndTime.IsZero() && m.Schedule.EndTime.After(from) {
+		occurrences = append(occurrences, Occurrence{StartTime: m.Schedule.StartTime.In(loc), EndTime: m.Schedule.EndTime.In(loc)})
+	}
+
+	if m.Schedule.Recurrence == nil {
+		if len(occurrences) > limit {
+			occurrences = occurrences[:limit]
+		}
+		return occurrences, nil
+	}
+
+	sched, err := m.Schedule.Recurrence.schedule()
+	if err != nil {
+		return nil, err
+	}
+
+	after := from.In(loc)
+	for len(occurrences) < limit {
+		window, ok := m.Schedule.Recurrence.windowAfter(sched, after, loc)
+		if !ok {
+			break
+		}
+		occurrences = append(occurrences, window)
+		// the next window starts after the start of this one
+		after = window.EndTime
+	}
+	return occurrences, nil
+}
+
+// schedule returns the cron schedule of the recurrence, the daily, weekly
+// and monthly repeat types are converted to the equivalent cron expression
+func (r *Recurrence) schedule() (cron.Schedule, error) {
+	start := r.StartTime
+	switch r.RepeatType {
+	case RepeatTypeCron:
+		return parseCron(r.Cron)
+	case RepeatTypeDaily:
+		return parseCron(fmt.Sprintf("%d %d * * *", start.Minute(), start.Hour()))
+	case RepeatTypeWeekly:
+		days := "*"
+		if len(r.RepeatOn) > 0 {
+			names := make([]string, 0, len(r.RepeatOn))
+			for _, day := range r.RepeatOn {
+				if len(day) < 3 {
+					return nil, fmt.Errorf("invalid repeat on day %q", day)
+				}
+				names = append(names, string(day[:3]))
+			}
+			days = strings.Join(names, ",")
+		}
+		return parseCron(fmt.Sprintf("%d %d * * %s", start.Minute(), start.Hour(), days))
+	case RepeatTypeMonthly:
+		return parseCron(fmt.Sprintf("%d %d %d * *", start.Minute(), start.Hour(), start.Day()))
+	}
+	return nil, fmt.Errorf("invalid repeat type %q", r.RepeatType)
+}
+
+// windowAfter returns the first window of the recurrence that ends after t,
+// it returns false if the recurrence has no more windows
+func (r *Recurrence) windowAfter(sched cron.Schedule, t time.Time, loc *time.Location) (Occurrence, bool) {
+	duration := time.Duration(r.Duration)
+
+	// a window skipped by a DST change starts up to an hour after its
+	// scheduled time, so the search starts early enough to find it
+	after := t.Add(-duration - time.Hour)
+	if !r.StartTime.IsZero() && after.Before(r.StartTime) {
+		// a window may start at the start time of the recurrence
+		after = r.StartTime.Add(-time.Nanosecond)
+	}
+
+	for {
+		start := nextOccurrence(sched, after, loc)
+		if start.IsZero() || (r.EndTime != nil && start.After(*r.EndTime)) {
+			return Occurrence{}, false
+		}
+		if end := start.Add(duration); end.After(t) {
+			return Occurrence{StartTime: start, EndTime: end}, true
+		}
+		after = start
+	}
+}
+
+// parseCron parses a standard five field cron expression. The expression
+// is evaluated in the timezone of the schedule, so it can't set its own.
+func parseCron(spec string) (cron.Schedule, error) {
+	if strings.HasPrefix(spec, "TZ=") || strings.HasPrefix(spec, "CRON_TZ=") {
+		return nil, errors.New("cron expression can not set a timezone, the timezone of the schedule is used")
+	}
+	sched, err := cron.ParseStandard(spec)
+	if err != nil {
+		return nil, errors.Wrap(err, "invalid cron expression")
+	}
+	return sched, nil
+}
+
+// nextOccurrence returns the first time of the schedule after t. The
+// schedule runs on the wall clock of loc, so every scheduled local time
+// starts a window once across DST changes: a time skipped when the clocks
+// move forward starts the window after the gap, a time repeated when the
+// clocks move back starts the window at its first instance.
+func nextOccurrence(sched cron.Schedule, t time.Time, loc *time.Location) time.Time {
+	local := t.In(loc)
+	wall := time.Date(local.Year(), local.Month(), local.Day(), local.Hour(), local.Minute(), local.Second(), local.Nanosecond(), time.UTC)
+
+	for {
+		next := sched.Next(wall)
+		if next.IsZero() {
+			return next
+		}
+
+		occ := time.Date(next.Year(), next.Month(), next.Day(), next.Hour(), next.Minute(), next.Second(), 0, loc)
+		if occ.Hour() != next.Hour() || occ.Minute() != next.Minute() {
+			// the local time doesn't exist, it is moved forward by the
+			// length of the gap using the offset from before the gap
+			_, offset := occ.Zone()
+			occ = time.Unix(next.Unix()-int64(offset), 0).In(loc)
+		}
+		if occ.After(t) {
+			return occ
+		}
+		// the first instance of a repeated local time is before t
+		wall = next
+	}
+}
+
 func (m *PlannedMaintenance) Validate() error {
 	if m.Name == "" {
 		return ErrMissingName
@@ -373,6 +614,22 @@ func (m *PlannedMaintenance) Validate() error {
 		if m.Schedule.Recurrence.EndTime != nil && m.Schedule.Recurrence.EndTime.Before(m.Schedule.Recurrence.StartTime) {
 			return errors.New("end time cannot be before start time")
 		}
+		if m.Schedule.Recurrence.RepeatType == RepeatTypeCron {
+			if m.Schedule.Recurrence.Cron == "" {
+				return ErrMissingCron
+			}
+			if _, err := parseCron(m.Schedule.Recurrence.Cron); err != nil {
+				return err
+			}
+		}
+	}
+
+	if m.LabelSelectors != nil {
+		for idx := range *m.LabelSelectors {
+			if err := (*m.LabelSelectors)[idx].Validate(); err != nil {
+				return err
+			}
+		}
 	}
 	return nil
 }
@@ -396,28 +653,30 @@ func (m PlannedMaintenance) MarshalJSON() ([]byte, error) {
 	}
 
 	return json.Marshal(struct {
-		Id          int64     `json:"id" db:"id"`
-		Name        string    `json:"name" db:"name"`
-		Description string    `json:"description" db:"description"`
-		Schedule    *Schedule `json:"schedule" db:"schedule"`
-		AlertIds    *AlertIds `json:"alertIds" db:"alert_ids"`
-		CreatedAt   time.Time `json:"createdAt" db:"created_at"`
-		CreatedBy   string    `json:"createdBy" db:"created_by"`
-		UpdatedAt   time.Time `json:"updatedAt" db:"updated_at"`
-		UpdatedBy   string    `json:"updatedBy" db:"updated_by"`
-		Status      string    `json:"status"`
-		Kind        string    `json:"kind"`
+		Id             int64           `json:"id" db:"id"`
+		Name           string          `json:"name" db:"name"`
+		Description    string          `json:"description" db:"description"`
+		Schedule       *Schedule       `json:"schedule" db:"schedule"`
+		AlertIds       *AlertIds       `json:"alertIds" db:"alert_ids"`
+		LabelSelectors *LabelSelectors `json:"labelSelectors,omitempty" db:"label_selectors"`
+		CreatedAt      time.Time       `json:"createdAt" db:"created_at"`
+		CreatedBy      string          `json:"createdBy" db:"created_by"`
+		UpdatedAt      time.Time       `json:"updatedAt" db:"updated_at"`
+		UpdatedBy      string          `json:"updatedBy" db:"updated_by"`
+		Status         string          `json:"status"`
+		Kind           string          `json:"kind"`
 	}{
-		Id:          m.Id,
-		Name:        m.Name,
-		Description: m.Description,
-		Schedule:    m.Schedule,
-		AlertIds:    m.AlertIds,
-		CreatedAt:   m.CreatedAt,
-		CreatedBy:   m.CreatedBy,
-		UpdatedAt:   m.UpdatedAt,
-		UpdatedBy:   m.UpdatedBy,
-		Status:      status,
-		Kind:        kind,
+		Id:             m.Id,
+		Name:           m.Name,
+		Description:    m.Description,
+		Schedule:       m.Schedule,
+		AlertIds:       m.AlertIds,
+		LabelSelectors: m.LabelSelectors,
+		CreatedAt:      m.CreatedAt,
+		CreatedBy:      m.CreatedBy,
+		UpdatedAt:      m.UpdatedAt,
+		UpdatedBy:      m.UpdatedBy,
+		Status:         status,
+		Kind:           kind,
 	})
 }
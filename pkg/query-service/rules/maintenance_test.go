@@ -222,9 +222,332 @@ func TestShouldSkipMaintenance(t *testing.T) {
 	}
 
 	for _, c := range cases {
-		result := c.maintenance.shouldSkip(c.name, c.ts)
+		result := c.maintenance.shouldSkip(c.name, nil, c.ts)
 		if result != c.expected {
 			t.Errorf("expected %v, got %v", c.expected, result)
 		}
 	}
 }
+
+func TestShouldSkipMaintenanceLabelSelectors(t *testing.T) {
+
+	schedule := &Schedule{
+		Timezone:  "UTC",
+		StartTime: time.Now().UTC().Add(-time.Hour),
+		EndTime:   time.Now().UTC().Add(time.Hour),
+	}
+
+	cases := []struct {
+		name        string
+		maintenance *PlannedMaintenance
+		labels      map[string]string
+		expected    bool
+	}{
+		{
+			name: "equal selector matches",
+			maintenance: &PlannedMaintenance{
+				Schedule:       schedule,
+				LabelSelectors: &LabelSelectors{{Key: "env", Op: LabelSelectorOpEqual, Value: "staging"}},
+			},
+			labels:   map[string]string{"env": "staging", "team": "payments"},
+			expected: true,
+		},
+		{
+			name: "equal selector doesn't match",
+			maintenance: &PlannedMaintenance{
+				Schedule:       schedule,
+				LabelSelectors: &LabelSelectors{{Key: "env", Op: LabelSelectorOpEqual, Value: "staging"}},
+			},
+			labels:   map[string]string{"env": "production"},
+			expected: false,
+		},
+		{
+			name: "all selectors must match",
+			maintenance: &PlannedMaintenance{
+				Schedule: schedule,
+				LabelSelectors: &LabelSelectors{
+					{Key: "env", Op: LabelSelectorOpEqual, Value: "staging"},
+					{Key: "team", Op: LabelSelectorOpNotEqual, Value: "payments"},
+				},
+			},
+			labels:   map[string]string{"env": "staging", "team": "payments"},
+			expected: false,
+		},
+		{
+			name: "regex selector is anchored",
+			maintenance: &PlannedMaintenance{
+				Schedule:       schedule,
+				LabelSelectors: &LabelSelectors{{Key: "team", Op: LabelSelectorOpRegex, Value: "pay.*|billing"}},
+			},
+			labels:   map[string]string{"team": "billing-eu"},
+			expected: false,
+		},
+		{
+			name: "negative regex selector matches missing label",
+			maintenance: &PlannedMaintenance{
+				Schedule:       schedule,
+				LabelSelectors: &LabelSelectors{{Key: "env", Op: LabelSelectorOpNotRegex, Value: "prod.*"}},
+			},
+			labels:   map[string]string{"team": "payments"},
+			expected: true,
+		},
+		{
+			name: "listed alert id matches without labels",
+			maintenance: &PlannedMaintenance{
+				Schedule:       schedule,
+				AlertIds:       &AlertIds{"rule-1"},
+				LabelSelectors: &LabelSelectors{{Key: "env", Op: LabelSelectorOpEqual, Value: "staging"}},
+			},
+			labels:   map[string]string{"env": "production"},
+			expected: true,
+		},
+	}
+
+	for _, c := range cases {
+		result := c.maintenance.shouldSkip("rule-1", c.labels, time.Now())
+		if result != c.expected {
+			t.Errorf("%s: expected %v, got %v", c.name, c.expected, result)
+		}
+	}
+}
+
+func TestShouldSkipMaintenanceCron(t *testing.T) {
+
+	cases := []struct {
+		name     string
+		cron     string
+		duration time.Duration
+		ts       time.Time
+		expected bool
+	}{
+		{
+			name:     "window on a regular day",
+			cron:     "30 2 * * *",
+			duration: time.Hour,
+			ts:       time.Date(2024, 3, 11, 6, 45, 0, 0, time.UTC),
+			expected: true,
+		},
+		{
+			name:     "window skipped by DST starts after the gap",
+			cron:     "30 2 * * *",
+			duration: time.Hour,
+			ts:       time.Date(2024, 3, 10, 7, 45, 0, 0, time.UTC),
+			expected: true,
+		},
+		{
+			name:     "window skipped by DST doesn't start before the gap",
+			cron:     "30 2 * * *",
+			duration: time.Hour,
+			ts:       time.Date(2024, 3, 10, 6, 45, 0, 0, time.UTC),
+			expected: false,
+		},
+		{
+			name:     "window repeated by DST starts at the first instance",
+			cron:     "30 1 * * *",
+			duration: 30 * time.Minute,
+			ts:       time.Date(2024, 11, 3, 5, 45, 0, 0, time.UTC),
+			expected: true,
+		},
+		{
+			name:     "window repeated by DST doesn't start again",
+			cron:     "30 1 * * *",
+			duration: 30 * time.Minute,
+			ts:       time.Date(2024, 11, 3, 6, 45, 0, 0, time.UTC),
+			expected: false,
+		},
+		{
+			name:     "weekdays only",
+			cron:     "0 9 * * 1-5",
+			duration: time.Hour,
+			ts:       time.Date(2024, 3, 9, 14, 30, 0, 0, time.UTC),
+			expected: false,
+		},
+	}
+
+	loc, _ := time.LoadLocation("America/New_York")
+	for _, c := range cases {
+		maintenance := &PlannedMaintenance{
+			Name: c.name,
+			Schedule: &Schedule{
+				Timezone: "America/New_York",
+				Recurrence: &Recurrence{
+					StartTime:  time.Date(2024, 1, 1, 0, 0, 0, 0, loc),
+					Duration:   Duration(c.duration),
+					RepeatType: RepeatTypeCron,
+					Cron:       c.cron,
+				},
+			},
+		}
+		if err := maintenance.Validate(); err != nil {
+			t.Fatalf("%s: unexpected error %v", c.name, err)
+		}
+		result := maintenance.shouldSkip("rule-1", nil, c.ts)
+		if result != c.expected {
+			t.Errorf("%s: expected %v, got %v", c.name, c.expected, result)
+		}
+	}
+}
+
+func TestMaintenanceValidateCron(t *testing.T) {
+	for _, spec := range []string{"", "61 * * * *", "CRON_TZ=UTC 0 1 * * *"} {
+		maintenance := &PlannedMaintenance{
+			Name: "invalid cron",
+			Schedule: &Schedule{
+				Timezone: "UTC",
+				Recurrence: &Recurrence{
+					Duration:   Duration(time.Hour),
+					RepeatType: RepeatTypeCron,
+					Cron:       spec,
+				},
+			},
+		}
+		if err := maintenance.Validate(); err == nil {
+			t.Errorf("expected an error for cron expression %q", spec)
+		}
+	}
+
+	maintenance := &PlannedMaintenance{
+		Name:           "invalid selector",
+		Schedule:       &Schedule{Timezone: "UTC"},
+		LabelSelectors: &LabelSelectors{{Key: "team", Op: LabelSelectorOpRegex, Value: "pay("}},
+	}
+	if err := maintenance.Validate(); err == nil {
+		t.Errorf("expected an error for invalid regex selector")
+	}
+}
+
+func TestMaintenanceOccurrences(t *testing.T) {
+
+	loc, _ := time.LoadLocation("America/New_York")
+	endTime := time.Date(2024, 1, 20, 0, 0, 0, 0, time.UTC)
+
+	cases := []struct {
+		name        string
+		maintenance *PlannedMaintenance
+		from        time.Time
+		limit       int
+		expected    []time.Time
+	}{
+		{
+			name: "cron across the DST change",
+			maintenance: &PlannedMaintenance{
+				Schedule: &Schedule{
+					Timezone: "America/New_York",
+					Recurrence: &Recurrence{
+						StartTime:  time.Date(2024, 1, 1, 0, 0, 0, 0, loc),
+						Duration:   Duration(time.Hour),
+						RepeatType: RepeatTypeCron,
+						Cron:       "30 2 * * *",
+					},
+				},
+			},
+			from:  time.Date(2024, 3, 9, 0, 0, 0, 0, loc),
+			limit: 3,
+			expected: []time.Time{
+				time.Date(2024, 3, 9, 7, 30, 0, 0, time.UTC),
+				time.Date(2024, 3, 10, 7, 30, 0, 0, time.UTC),
+				time.Date(2024, 3, 11, 6, 30, 0, 0, time.UTC),
+			},
+		},
+		{
+			name: "weekly until the end time, including the window in progress",
+			maintenance: &PlannedMaintenance{
+				Schedule: &Schedule{
+					Timezone: "UTC",
+					Recurrence: &Recurrence{
+						StartTime:  time.Date(2024, 1, 1, 10, 0, 0, 0, time.UTC),
+						EndTime:    &endTime,
+						Duration:   Duration(2 * time.Hour),
+						RepeatType: RepeatTypeWeekly,
+						RepeatOn:   []RepeatOn{RepeatOnMonday},
+					},
+				},
+			},
+			from:  time.Date(2024, 1, 8, 11, 0, 0, 0, time.UTC),
+			limit: 10,
+			expected: []time.Time{
+				time.Date(2024, 1, 8, 10, 0, 0, 0, time.UTC),
+				time.Date(2024, 1, 15, 10, 0, 0, 0, time.UTC),
+			},
+		},
+		{
+			name: "fixed schedule",
+			maintenance: &PlannedMaintenance{
+				Schedule: &Schedule{
+					Timezone:  "UTC",
+					StartTime: time.Date(2024, 1, 1, 10, 0, 0, 0, time.UTC),
+					EndTime:   time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC),
+				},
+			},
+			from:     time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC),
+			limit:    10,
+			expected: []time.Time{time.Date(2024, 1, 1, 10, 0, 0, 0, time.UTC)},
+		},
+	}
+
+	for _, c := range cases {
+		occurrences, err := c.maintenance.Occurrences(c.from, c.limit)
+		if err != nil {
+			t.Fatalf("%s: unexpected error %v", c.name, err)
+		}
+		if len(occurrences) != len(c.expected) {
+			t.Fatalf("%s: expected %d occurrences, got %d", c.name, len(c.expected), len(occurrences))
+		}
+		for idx, occurrence := range occurrences {
+			if !occurrence.StartTime.Equal(c.expected[idx]) {
+				t.Errorf("%s: expected occurrence %d to start at %v, got %v", c.name, idx, c.expected[idx], occurrence.StartTime)
+			}
+		}
+	}
+}
+
+func TestMaintenanceIsActiveMatchesOccurrences(t *testing.T) {
+
+	loc, _ := time.LoadLocation("America/New_York")
+	maintenance := &PlannedMaintenance{
+		Name: "daily across the DST change",
+		Schedule: &Schedule{
+			Timezone: "America/New_York",
+			Recurrence: &Recurrence{
+				StartTime:  time.Date(2024, 1, 1, 2, 30, 0, 0, loc),
+				Duration:   Duration(time.Hour),
+				RepeatType: RepeatTypeDaily,
+			},
+		},
+	}
+
+	// the window skipped by the DST change starts after the gap, as listed
+	// by the occurrences
+	occurrences, err := maintenance.Occurrences(time.Date(2024, 3, 10, 0, 0, 0, 0, loc), 1)
+	if err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+	window := occurrences[0]
+	for ts := window.StartTime.Add(-time.Hour); ts.Before(window.EndTime.Add(time.Hour)); ts = ts.Add(15 * time.Minute) {
+		expected := !ts.Before(window.StartTime) && ts.Before(window.EndTime)
+		if active := maintenance.IsActive(ts); active != expected {
+			t.Errorf("at %v: expected %v, got %v", ts, expected, active)
+		}
+	}
+}
+
+func TestLabelSelectorValidateCompiles(t *testing.T) {
+	selectors := LabelSelectors{{Key: "team", Op: LabelSelectorOpRegex, Value: "pay.*"}}
+	if err := selectors[0].Validate(); err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+	if selectors[0].re == nil {
+		t.Fatalf("expected the regular expression to be compiled")
+	}
+	if !selectors.Matches(map[string]string{"team": "payments"}) {
+		t.Errorf("expected the selector to match")
+	}
+
+	var scanned LabelSelectors
+	if err := scanned.Scan([]byte(`[{"key": "team", "op": "!~", "value": "pay.*"}]`)); err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+	if scanned[0].re == nil || scanned.Matches(map[string]string{"team": "payments"}) {
+		t.Errorf("expected the scanned selector to be compiled and not match")
+	}
+}
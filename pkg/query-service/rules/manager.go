@@ -19,6 +19,7 @@ import (
 	"github.com/jmoiron/sqlx"
 
 	"go.signoz.io/signoz/pkg/query-service/cache"
+	"go.signoz.io/signoz/pkg/query-service/constants"
 	am "go.signoz.io/signoz/pkg/query-service/integrations/alertManager"
 	"go.signoz.io/signoz/pkg/query-service/interfaces"
 	"go.signoz.io/signoz/pkg/query-service/model"
@@ -37,6 +38,7 @@ type PrepareTaskOptions struct {
 	FF          interfaces.FeatureLookup
 	ManagerOpts *ManagerOptions
 	NotifyFunc  NotifyFunc
+	InhibitFunc InhibitFunc
 
 	UseLogsNewSchema bool
 }
@@ -90,7 +92,10 @@ type Manager struct {
 	tasks map[string]Task
 	rules map[string]Rule
 	mtx   sync.RWMutex
-	block chan struct{}
+	// rulesMtx guards the rules map for readers running inside rule
+	// tasks, which can not take mtx as it is held while tasks are stopped
+	rulesMtx sync.RWMutex
+	block    chan struct{}
 	// Notifier sends messages through alert manager
 	notifier *am.Notifier
 
@@ -150,7 +155,7 @@ func defaultPrepareTaskFunc(opts PrepareTaskOptions) (Task, error) {
 		rules = append(rules, tr)
 
 		// create ch rule task for evalution
-		task = newTask(TaskTypeCh, opts.TaskName, taskNamesuffix, time.Duration(opts.Rule.Frequency), rules, opts.ManagerOpts, opts.NotifyFunc, opts.RuleDB)
+		task = newTask(TaskTypeCh, opts.TaskName, taskNamesuffix, time.Duration(opts.Rule.Frequency), rules, opts.ManagerOpts, opts.NotifyFunc, opts.InhibitFunc, opts.RuleDB)
 
 	} else if opts.Rule.RuleType == RuleTypeProm {
 
@@ -170,10 +175,49 @@ func defaultPrepareTaskFunc(opts PrepareTaskOptions) (Task, error) {
 		rules = append(rules, pr)
 
 		// create promql rule task for evalution
-		task = newTask(TaskTypeProm, opts.TaskName, taskNamesuffix, time.Duration(opts.Rule.Frequency), rules, opts.ManagerOpts, opts.NotifyFunc, opts.RuleDB)
+		task = newTask(TaskTypeProm, opts.TaskName, taskNamesuffix, time.Duration(opts.Rule.Frequency), rules, opts.ManagerOpts, opts.NotifyFunc, opts.InhibitFunc, opts.RuleDB)
+
+	} else if opts.Rule.RuleType == RuleTypeRecording {
+		// create a recording rule
+		rr, err := NewRecordingRule(
+			ruleId,
+			opts.Rule,
+			opts.FF,
+			opts.Reader,
+			opts.UseLogsNewSchema,
+			WithEvalDelay(opts.ManagerOpts.EvalDelay),
+		)
+
+		if err != nil {
+			return task, err
+		}
+
+		rules = append(rules, rr)
+
+		// create ch rule task for evalution
+		task = newTask(TaskTypeCh, opts.TaskName, taskNamesuffix, time.Duration(opts.Rule.Frequency), rules, opts.ManagerOpts, opts.NotifyFunc, opts.InhibitFunc, opts.RuleDB)
+
+	} else if opts.Rule.RuleType == RuleTypeBurnRate {
+		// create a burn rate rule of an slo
+		br, err := NewBurnRateRule(
+			ruleId,
+			opts.Rule,
+			opts.FF,
+			opts.Reader,
+			opts.UseLogsNewSchema,
+			WithEvalDelay(opts.ManagerOpts.EvalDelay),
+		)
+		if err != nil {
+			return task, err
+		}
+
+		rules = append(rules, br)
+
+		// create ch rule task for evalution
+		task = newTask(TaskTypeCh, opts.TaskName, taskNamesuffix, time.Duration(opts.Rule.Frequency), rules, opts.ManagerOpts, opts.NotifyFunc, opts.InhibitFunc, opts.RuleDB)
 
 	} else {
-		return nil, fmt.Errorf("unsupported rule type %s. Supported types: %s, %s", opts.Rule.RuleType, RuleTypeProm, RuleTypeThreshold)
+		return nil, fmt.Errorf("unsupported rule type %s. Supported types: %s, %s, %s, %s", opts.Rule.RuleType, RuleTypeProm, RuleTypeThreshold, RuleTypeRecording, RuleTypeBurnRate)
 	}
 
 	return task, nil
@@ -193,13 +237,21 @@ func NewManager(o *ManagerOptions) (*Manager, error) {
 		return nil, err
 	}
 
-	amManager, err := am.New()
+	amManager, err := am.GetManager()
 	if err != nil {
 		return nil, err
 	}
 
 	db := NewRuleDB(o.DBConn, amManager)
 
+	if constants.IsNativeAlertManagerEnabled() {
+		// the native alert manager keeps the channels in memory,
+		// load the stored ones before any alert is dispatched
+		if err := loadChannels(db, amManager); err != nil {
+			return nil, err
+		}
+	}
+
 	telemetry.GetInstance().SetAlertsInfoCallback(db.GetAlertsInfo)
 
 	m := &Manager{
@@ -218,6 +270,26 @@ func NewManager(o *ManagerOptions) (*Manager, error) {
 	return m, nil
 }
 
+// loadChannels registers the stored notification channels with the alert manager
+func loadChannels(db RuleDB, alertManager am.Manager) error {
+	channels, apiErr := db.GetChannels()
+	if apiErr != nil {
+		return apiErr.Err
+	}
+
+	for _, channel := range *channels {
+		receiver := &am.Receiver{}
+		if err := json.Unmarshal([]byte(channel.Data), receiver); err != nil {
+			zap.L().Error("failed to parse stored channel", zap.String("channel", channel.Name), zap.Error(err))
+			continue
+		}
+		if apiErr := alertManager.AddRoute(receiver); apiErr != nil {
+			zap.L().Error("failed to register stored channel", zap.String("channel", channel.Name), zap.Error(apiErr.Err))
+		}
+	}
+	return nil
+}
+
 func (m *Manager) Start() {
 	if err := m.initiate(); err != nil {
 		zap.L().Error("failed to initialize alerting rules manager", zap.Error(err))
@@ -319,6 +391,10 @@ func (m *Manager) EditRule(ctx context.Context, ruleStr string, id string) error
 		return err
 	}
 
+	if err := m.checkNotificationTemplates(ctx, parsedRule); err != nil {
+		return err
+	}
+
 	taskName, _, err := m.ruleDB.EditRuleTx(ctx, ruleStr, id)
 	if err != nil {
 		return err
@@ -350,6 +426,7 @@ func (m *Manager) editTask(rule *PostableRule, taskName string) error {
 		FF:          m.featureFlags,
 		ManagerOpts: m.opts,
 		NotifyFunc:  m.prepareNotifyFunc(),
+		InhibitFunc: m.inhibit,
 
 		UseLogsNewSchema: m.opts.UseLogsNewSchema,
 	})
@@ -359,9 +436,11 @@ func (m *Manager) editTask(rule *PostableRule, taskName string) error {
 		return errors.New("error preparing rule with given parameters, previous rule set restored")
 	}
 
+	m.rulesMtx.Lock()
 	for _, r := range newTask.Rules() {
 		m.rules[r.ID()] = r
 	}
+	m.rulesMtx.Unlock()
 
 	// If there is an old task with the same identifier, stop it and wait for
 	// it to finish the current iteration. Then copy it into the new group.
@@ -418,7 +497,9 @@ func (m *Manager) deleteTask(taskName string) {
 	if ok {
 		oldg.Stop()
 		delete(m.tasks, taskName)
+		m.rulesMtx.Lock()
 		delete(m.rules, RuleIdFromTaskName(taskName))
+		m.rulesMtx.Unlock()
 		zap.L().Debug("rule task deleted", zap.String("name", taskName))
 	} else {
 		zap.L().Info("rule not found for deletion", zap.String("name", taskName))
@@ -434,6 +515,10 @@ func (m *Manager) CreateRule(ctx context.Context, ruleStr string) (*GettableRule
 		return nil, err
 	}
 
+	if err := m.checkNotificationTemplates(ctx, parsedRule); err != nil {
+		return nil, err
+	}
+
 	lastInsertId, tx, err := m.ruleDB.CreateRuleTx(ctx, ruleStr)
 	taskName := prepareTaskName(lastInsertId)
 	if err != nil {
@@ -472,6 +557,7 @@ func (m *Manager) addTask(rule *PostableRule, taskName string) error {
 		FF:          m.featureFlags,
 		ManagerOpts: m.opts,
 		NotifyFunc:  m.prepareNotifyFunc(),
+		InhibitFunc: m.inhibit,
 
 		UseLogsNewSchema: m.opts.UseLogsNewSchema,
 	})
@@ -481,9 +567,11 @@ func (m *Manager) addTask(rule *PostableRule, taskName string) error {
 		return errors.New("error loading rules, previous rule set restored")
 	}
 
+	m.rulesMtx.Lock()
 	for _, r := range newTask.Rules() {
 		m.rules[r.ID()] = r
 	}
+	m.rulesMtx.Unlock()
 
 	// If there is an another task with the same identifier, raise an error
 	_, ok := m.tasks[taskName]
@@ -573,21 +661,40 @@ func (m *Manager) TriggeredAlerts() []*NamedAlert {
 // NotifyFunc sends notifications about a set of alerts generated by the given expression.
 type NotifyFunc func(ctx context.Context, expr string, alerts ...*Alert)
 
+// InhibitFunc updates the suppression of the alerts of a rule after it is
+// evaluated and before its alerts are sent.
+type InhibitFunc func(ctx context.Context, ts time.Time, rule Rule)
+
 // prepareNotifyFunc implements the NotifyFunc for a Notifier.
 func (m *Manager) prepareNotifyFunc() NotifyFunc {
 	return func(ctx context.Context, expr string, alerts ...*Alert) {
 		var res []*am.Alert
 
+		alerts = withoutSuppressed(alerts)
+
+		// the alerts of an evaluation share the templates of their rule
+		templates := map[string]map[string]*NotificationTemplate{}
+
 		for _, alert := range alerts {
 			generatorURL := alert.GeneratorURL
 			if generatorURL == "" {
 				generatorURL = m.opts.RepoURL
 			}
 
+			annotations := alert.Annotations
+			if rule, ok := m.ruleByID(alert.Labels.Get(labels.AlertRuleIdLabel)); ok {
+				ruleTemplates, ok := templates[rule.ID()]
+				if !ok {
+					ruleTemplates = m.notificationTemplates(ctx, rule)
+					templates[rule.ID()] = ruleTemplates
+				}
+				annotations = withNotificationTemplates(rule, alert, ruleTemplates)
+			}
+
 			a := &am.Alert{
 				StartsAt:     alert.FiredAt,
 				Labels:       alert.Labels,
-				Annotations:  alert.Annotations,
+				Annotations:  annotations,
 				GeneratorURL: generatorURL,
 				Receivers:    alert.Receivers,
 			}
@@ -605,6 +712,90 @@ func (m *Manager) prepareNotifyFunc() NotifyFunc {
 	}
 }
 
+// inhibit marks the firing alerts of the rule that are inhibited by the
+// rules in its inhibitedBy as suppressed, and clears the mark of the alerts
+// that no longer are. It runs once per evaluation of the rule, before its
+// alerts are sent.
+func (m *Manager) inhibit(ctx context.Context, ts time.Time, rule Rule) {
+	if len(rule.InhibitedBy()) == 0 {
+		return
+	}
+	for _, alert := range rule.ActiveAlerts() {
+		if alert.State != model.StateFiring {
+			continue
+		}
+		// the suppression is only recorded when it changes
+		rule.UpdateSuppression(ctx, ts, alert.Labels, m.inhibitingRule(rule, alert))
+	}
+}
+
+// withoutSuppressed drops the firing alerts suppressed by an inhibition
+func withoutSuppressed(alerts []*Alert) []*Alert {
+	res := make([]*Alert, 0, len(alerts))
+	for _, alert := range alerts {
+		if alert.SuppressedBy != "" && alert.ResolvedAt.IsZero() {
+			zap.L().Debug("alert suppressed", zap.String("ruleid", alert.Labels.Get(labels.AlertRuleIdLabel)), zap.String("suppressedBy", alert.SuppressedBy))
+			continue
+		}
+		res = append(res, alert)
+	}
+	return res
+}
+
+// inhibitingRule returns the id of the first rule that inhibits the alert,
+// that is a source rule firing an alert with the same values for the
+// labels to be matched. It returns an empty string if the alert is not
+// inhibited.
+func (m *Manager) inhibitingRule(rule Rule, alert *Alert) string {
+	for _, inh := range rule.InhibitedBy() {
+		if inh.RuleID == rule.ID() {
+			continue
+		}
+		source, ok := m.ruleByID(inh.RuleID)
+		if !ok {
+			continue
+		}
+		for _, sa := range source.ActiveAlerts() {
+			if sa.State != model.StateFiring {
+				continue
+			}
+			matched := true
+			for _, name := range inh.Equal {
+				if sa.Labels.Get(name) != alert.Labels.Get(name) {
+					matched = false
+					break
+				}
+			}
+			if matched {
+				return source.ID()
+			}
+		}
+	}
+	return ""
+}
+
+func (m *Manager) ruleByID(id string) (Rule, bool) {
+	m.rulesMtx.RLock()
+	defer m.rulesMtx.RUnlock()
+	r, ok := m.rules[id]
+	return r, ok
+}
+
+// ruleState is the state of the rule as reported by the api, a firing
+// rule whose firing alerts are all inhibited is reported as suppressed
+func ruleState(rule Rule) model.AlertState {
+	state := rule.State()
+	if state != model.StateFiring {
+		return state
+	}
+	for _, a := range rule.ActiveAlerts() {
+		if a.State == model.StateFiring && a.SuppressedBy == "" {
+			return state
+		}
+	}
+	return model.StateSuppressed
+}
+
 func (m *Manager) ListActiveRules() ([]Rule, error) {
 	ruleList := []Rule{}
 
@@ -641,7 +832,7 @@ func (m *Manager) ListRuleStates(ctx context.Context) (*GettableRules, error) {
 			ruleResponse.State = model.StateDisabled
 			ruleResponse.Disabled = true
 		} else {
-			ruleResponse.State = rm.State()
+			ruleResponse.State = ruleState(rm)
 		}
 		ruleResponse.CreatedAt = s.CreatedAt
 		ruleResponse.CreatedBy = s.CreatedBy
@@ -668,7 +859,7 @@ func (m *Manager) GetRule(ctx context.Context, id string) (*GettableRule, error)
 		r.State = model.StateDisabled
 		r.Disabled = true
 	} else {
-		r.State = rm.State()
+		r.State = ruleState(rm)
 	}
 	r.CreatedAt = s.CreatedAt
 	r.CreatedBy = s.CreatedBy
@@ -740,6 +931,10 @@ func (m *Manager) PatchRule(ctx context.Context, ruleStr string, ruleId string)
 		return nil, err
 	}
 
+	if err := m.checkNotificationTemplates(ctx, patchedRule); err != nil {
+		return nil, err
+	}
+
 	// deploy or un-deploy task according to patched (new) rule state
 	if err := m.syncRuleStateWithTask(taskName, patchedRule); err != nil {
 		zap.L().Error("failed to sync stored rule state with the task", zap.String("taskName", taskName), zap.Error(err))
@@ -775,7 +970,7 @@ func (m *Manager) PatchRule(ctx context.Context, ruleStr string, ruleId string)
 		response.State = model.StateDisabled
 		response.Disabled = true
 	} else {
-		response.State = rm.State()
+		response.State = ruleState(rm)
 	}
 
 	return &response, nil
@@ -785,10 +980,47 @@ func (m *Manager) PatchRule(ctx context.Context, ruleStr string, ruleId string)
 // sends a test notification. returns alert count and error (if any)
 func (m *Manager) TestNotification(ctx context.Context, ruleStr string) (int, *model.ApiError) {
 
+	rule, apiErr := m.prepareTestRule(ruleStr)
+	if apiErr != nil {
+		return 0, apiErr
+	}
+
+	// set timestamp to current utc time
+	ts := time.Now().UTC()
+
+	count, err := rule.Eval(ctx, ts)
+	if err != nil {
+		zap.L().Error("evaluating rule failed", zap.String("rule", rule.Name()), zap.Error(err))
+		return 0, newApiErrorInternal(fmt.Errorf("rule evaluation failed"))
+	}
+	alertsFound, ok := count.(int)
+	if !ok {
+		return 0, newApiErrorInternal(fmt.Errorf("something went wrong"))
+	}
+
+	// the test rule is not registered with the manager, its notification
+	// templates are applied here
+	notify := m.prepareNotifyFunc()
+	templates := m.notificationTemplates(ctx, rule)
+	rule.SendAlerts(ctx, ts, 0, time.Duration(1*time.Minute), func(ctx context.Context, expr string, alerts ...*Alert) {
+		for _, alert := range alerts {
+			alert.Annotations = withNotificationTemplates(rule, alert, templates)
+		}
+		notify(ctx, expr, alerts...)
+	})
+
+	return alertsFound, nil
+}
+
+// prepareTestRule builds the rule used to test the notifications of the
+// given rule, it sends the observed values even if they don't match the
+// rule condition
+func (m *Manager) prepareTestRule(ruleStr string) (Rule, *model.ApiError) {
+
 	parsedRule, err := ParsePostableRule([]byte(ruleStr))
 
 	if err != nil {
-		return 0, newApiErrorBadData(err)
+		return nil, newApiErrorBadData(err)
 	}
 
 	var alertname = parsedRule.AlertName
@@ -801,12 +1033,24 @@ func (m *Manager) TestNotification(ctx context.Context, ruleStr string) (int, *m
 	// append name to indicate this is test alert
 	parsedRule.AlertName = fmt.Sprintf("%s%s", alertname, TestAlertPostFix)
 
+	if parsedRule.Labels == nil {
+		parsedRule.Labels = map[string]string{}
+	}
+	if parsedRule.Annotations == nil {
+		parsedRule.Annotations = map[string]string{}
+	}
+
 	var rule Rule
 
 	if parsedRule.RuleType == RuleTypeThreshold {
 
 		// add special labels for test alerts
-		parsedRule.Annotations[labels.AlertSummaryLabel] = fmt.Sprintf("The rule threshold is set to %.4f, and the observed metric value is {{$value}}.", *parsedRule.RuleCondition.Target)
+		var target float64
+		// the most severe threshold level is the one shown in the test alert
+		if th := parsedRule.RuleCondition.thresholds()[0]; th.Target != nil {
+			target = *th.Target
+		}
+		parsedRule.Annotations[labels.AlertSummaryLabel] = fmt.Sprintf("The rule threshold is set to %.4f, and the observed metric value is {{$value}}.", target)
 		parsedRule.Labels[labels.RuleSourceLabel] = ""
 		parsedRule.Labels[labels.AlertRuleIdLabel] = ""
 
@@ -823,7 +1067,7 @@ func (m *Manager) TestNotification(ctx context.Context, ruleStr string) (int, *m
 
 		if err != nil {
 			zap.L().Error("failed to prepare a new threshold rule for test", zap.String("name", rule.Name()), zap.Error(err))
-			return 0, newApiErrorBadData(err)
+			return nil, newApiErrorBadData(err)
 		}
 
 	} else if parsedRule.RuleType == RuleTypeProm {
@@ -841,25 +1085,11 @@ func (m *Manager) TestNotification(ctx context.Context, ruleStr string) (int, *m
 
 		if err != nil {
 			zap.L().Error("failed to prepare a new promql rule for test", zap.String("name", rule.Name()), zap.Error(err))
-			return 0, newApiErrorBadData(err)
+			return nil, newApiErrorBadData(err)
 		}
 	} else {
-		return 0, newApiErrorBadData(fmt.Errorf("failed to derive ruletype with given information"))
-	}
-
-	// set timestamp to current utc time
-	ts := time.Now().UTC()
-
-	count, err := rule.Eval(ctx, ts)
-	if err != nil {
-		zap.L().Error("evaluating rule failed", zap.String("rule", rule.Name()), zap.Error(err))
-		return 0, newApiErrorInternal(fmt.Errorf("rule evaluation failed"))
-	}
-	alertsFound, ok := count.(int)
-	if !ok {
-		return 0, newApiErrorInternal(fmt.Errorf("something went wrong"))
+		return nil, newApiErrorBadData(fmt.Errorf("failed to derive ruletype with given information"))
 	}
-	rule.SendAlerts(ctx, ts, 0, time.Duration(1*time.Minute), m.prepareNotifyFunc())
 
-	return alertsFound, nil
+	return rule, nil
 }
@@ -0,0 +1,169 @@
+package rules
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.signoz.io/signoz/pkg/query-service/featureManager"
+	"go.signoz.io/signoz/pkg/query-service/model"
+	v3 "go.signoz.io/signoz/pkg/query-service/model/v3"
+	"go.signoz.io/signoz/pkg/query-service/utils/labels"
+)
+
+func TestManagerInhibit(t *testing.T) {
+	target := 1.0
+	postableRule := func(name string, inhibitedBy []RuleInhibition) *PostableRule {
+		return &PostableRule{
+			AlertName:  name,
+			AlertType:  AlertTypeMetric,
+			RuleType:   RuleTypeThreshold,
+			EvalWindow: Duration(5 * time.Minute),
+			Frequency:  Duration(1 * time.Minute),
+			RuleCondition: &RuleCondition{
+				CompositeQuery: &v3.CompositeQuery{
+					QueryType: v3.QueryTypeBuilder,
+					BuilderQueries: map[string]*v3.BuilderQuery{
+						"A": {
+							QueryName:          "A",
+							StepInterval:       60,
+							AggregateAttribute: v3.AttributeKey{Key: "signoz_calls_total"},
+							AggregateOperator:  v3.AggregateOperatorNoOp,
+							DataSource:         v3.DataSourceMetrics,
+							Expression:         "A",
+						},
+					},
+				},
+				Target:    &target,
+				CompareOp: ValueIsAbove,
+				MatchType: AtleastOnce,
+			},
+			InhibitedBy: inhibitedBy,
+		}
+	}
+
+	fm := featureManager.StartManager()
+	dbRule, err := NewThresholdRule("1", postableRule("Database down", nil), fm, nil, true)
+	require.NoError(t, err)
+	latencyRule, err := NewThresholdRule("2", postableRule("High latency", []RuleInhibition{
+		{RuleID: "1", Equal: []string{"service", "env"}},
+	}), fm, nil, true)
+	require.NoError(t, err)
+
+	addAlert := func(rule *ThresholdRule, service string) *Alert {
+		lbls := labels.FromStrings(labels.AlertRuleIdLabel, rule.ID(), "env", "prod", "service", service)
+		a := &Alert{
+			State:             model.StateFiring,
+			Labels:            lbls,
+			QueryResultLables: lbls,
+			ActiveAt:          time.Now(),
+		}
+		rule.Active[rule.AlertFingerprint(lbls)] = a
+		return a
+	}
+
+	dbAlert := addAlert(dbRule, "db")
+	suppressed := addAlert(latencyRule, "db")
+	notified := addAlert(latencyRule, "cart")
+
+	m := &Manager{rules: map[string]Rule{"1": dbRule, "2": latencyRule}}
+
+	copies := func(alerts ...*Alert) []*Alert {
+		res := []*Alert{}
+		for _, a := range alerts {
+			anew := *a
+			res = append(res, &anew)
+		}
+		return res
+	}
+
+	// the latency alert of the db service is suppressed while the db rule
+	// fires, before the alerts are sent
+	ts := time.Now()
+	m.inhibit(context.Background(), ts, latencyRule)
+	assert.Equal(t, "1", suppressed.SuppressedBy)
+	assert.Equal(t, "", notified.SuppressedBy)
+	assert.Equal(t, model.StateFiring, ruleState(latencyRule))
+	res := withoutSuppressed(copies(suppressed, notified))
+	require.Len(t, res, 1)
+	assert.Equal(t, "cart", res[0].Labels.Get("service"))
+
+	// suppressed alerts are handed over on every evaluation
+	suppressed.LastSentAt = ts
+	assert.True(t, suppressed.needsSending(ts, time.Hour))
+
+	// once every firing alert is inhibited, the rule is reported as suppressed
+	notified.State = model.StateInactive
+	notified.ResolvedAt = ts
+	assert.Equal(t, model.StateSuppressed, ruleState(latencyRule))
+
+	// alerts of the source rule are not inhibited
+	m.inhibit(context.Background(), ts, dbRule)
+	assert.Equal(t, "", dbAlert.SuppressedBy)
+
+	// the suppression is lifted when the source rule resolves
+	dbAlert.State = model.StateInactive
+	dbAlert.ResolvedAt = ts
+	m.inhibit(context.Background(), ts.Add(time.Minute), latencyRule)
+	assert.Equal(t, "", suppressed.SuppressedBy)
+	assert.Equal(t, model.StateFiring, ruleState(latencyRule))
+	require.Len(t, withoutSuppressed(copies(suppressed)), 1)
+}
+
+func TestSendAlertsResolvedAfterInhibition(t *testing.T) {
+	rule := &BaseRule{id: "2", Active: map[uint64]*Alert{}}
+	inhibited := &Alert{State: model.StateFiring, Labels: labels.FromStrings("service", "db")}
+	notified := &Alert{State: model.StateFiring, Labels: labels.FromStrings("service", "cart")}
+	rule.Active[1] = inhibited
+	rule.Active[2] = notified
+
+	// the alert of the db service is suppressed when the alerts are sent
+	inhibited.SuppressedBy = "1"
+	var sent []*Alert
+	notifyFunc := func(ctx context.Context, expr string, alerts ...*Alert) {
+		sent = alerts
+	}
+	ts := time.Now()
+	rule.SendAlerts(context.Background(), ts, time.Hour, time.Minute, notifyFunc)
+	require.Len(t, sent, 2)
+	assert.False(t, inhibited.Notified)
+	assert.True(t, notified.Notified)
+
+	// only the alert that went out as firing notifies its resolution
+	for _, a := range []*Alert{inhibited, notified} {
+		a.State = model.StateInactive
+		a.ResolvedAt = ts.Add(time.Minute)
+	}
+	rule.SendAlerts(context.Background(), ts.Add(time.Minute), time.Hour, time.Minute, notifyFunc)
+	require.Len(t, sent, 1)
+	assert.Equal(t, "cart", sent[0].Labels.Get("service"))
+}
+
+func TestPostableRuleValidateInhibitions(t *testing.T) {
+	target := 1.0
+	rule := PostableRule{
+		AlertName: "High latency",
+		RuleType:  RuleTypeThreshold,
+		RuleCondition: &RuleCondition{
+			CompositeQuery: &v3.CompositeQuery{
+				QueryType: v3.QueryTypePromQL,
+				PromQueries: map[string]*v3.PromQuery{
+					"A": {Query: "up"},
+				},
+			},
+			Target:    &target,
+			CompareOp: ValueIsAbove,
+			MatchType: AtleastOnce,
+		},
+		InhibitedBy: []RuleInhibition{{RuleID: "1", Equal: []string{"service"}}},
+	}
+	assert.NoError(t, rule.Validate())
+
+	rule.InhibitedBy = []RuleInhibition{{Equal: []string{"service name"}}}
+	err := rule.Validate()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "missing the rule id")
+	assert.Contains(t, err.Error(), "invalid label name")
+}
@@ -0,0 +1,523 @@
+package rules
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"html"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/pkg/errors"
+	"go.uber.org/zap"
+
+	"go.signoz.io/signoz/pkg/query-service/contextlinks"
+	"go.signoz.io/signoz/pkg/query-service/formatter"
+	am "go.signoz.io/signoz/pkg/query-service/integrations/alertManager"
+	"go.signoz.io/signoz/pkg/query-service/model"
+	"go.signoz.io/signoz/pkg/query-service/utils/labels"
+)
+
+// NotificationChannelTypes are the channel types a notification template
+// can be rendered for
+var NotificationChannelTypes = []string{"slack", "msteams", "pagerduty", "email"}
+
+func isNotificationChannelType(channelType string) bool {
+	for _, t := range NotificationChannelTypes {
+		if t == channelType {
+			return true
+		}
+	}
+	return false
+}
+
+// NotificationTemplate is a named go text/template that renders the title
+// and the body of the notifications of a channel type. Rules pick the
+// template to use for each channel type by name.
+type NotificationTemplate struct {
+	Id          int64  `json:"id" db:"id"`
+	Name        string `json:"name" db:"name"`
+	ChannelType string `json:"channelType" db:"channel_type"`
+	Title       string `json:"title" db:"title"`
+	Body        string `json:"body" db:"body"`
+
+	CreatedAt time.Time `json:"createdAt" db:"created_at"`
+	CreatedBy string    `json:"createdBy" db:"created_by"`
+	UpdatedAt time.Time `json:"updatedAt" db:"updated_at"`
+	UpdatedBy string    `json:"updatedBy" db:"updated_by"`
+}
+
+func (t *NotificationTemplate) Validate() error {
+	if t.Name == "" {
+		return errors.New("notification template is missing the name")
+	}
+	if !isNotificationChannelType(t.ChannelType) {
+		return errors.Errorf("invalid channel type %q, must be one of %s", t.ChannelType, strings.Join(NotificationChannelTypes, ", "))
+	}
+	if t.Body == "" {
+		return errors.New("notification template is missing the body")
+	}
+	if _, err := parseNotificationTemplate("title", t.Title); err != nil {
+		return errors.Wrap(err, "invalid title template")
+	}
+	if _, err := parseNotificationTemplate("body", t.Body); err != nil {
+		return errors.Wrap(err, "invalid body template")
+	}
+	return nil
+}
+
+// Render expands the title and the body of the template against the data
+func (t *NotificationTemplate) Render(data *NotificationTemplateData) (string, string, error) {
+	title, err := expandNotificationTemplate("title", t.Title, data)
+	if err != nil {
+		return "", "", errors.Wrap(err, "failed to render the title")
+	}
+	body, err := expandNotificationTemplate("body", t.Body, data)
+	if err != nil {
+		return "", "", errors.Wrap(err, "failed to render the body")
+	}
+	return title, body, nil
+}
+
+// NotificationTemplateData is the alert passed to notification templates
+type NotificationTemplateData struct {
+	RuleID   string `json:"ruleId"`
+	RuleName string `json:"ruleName"`
+	// Status is either firing or resolved
+	Status    string  `json:"status"`
+	Severity  string  `json:"severity,omitempty"`
+	Value     float64 `json:"value"`
+	Threshold float64 `json:"threshold"`
+	// Unit is the y-axis unit of the rule, the value and the threshold
+	// are in this unit
+	Unit string `json:"unit,omitempty"`
+
+	Labels      map[string]string `json:"labels"`
+	Annotations map[string]string `json:"annotations"`
+
+	StartsAt     time.Time `json:"startsAt"`
+	EndsAt       time.Time `json:"endsAt,omitempty"`
+	GeneratorURL string    `json:"generatorURL"`
+
+	// queryLabels are the labels of the query result, they become the
+	// filters of the related logs and traces links
+	queryLabels map[string]string
+	evalWindow  time.Duration
+}
+
+// newNotificationTemplateData prepares the template data of an alert of the rule
+func newNotificationTemplateData(rule Rule, alert *Alert) *NotificationTemplateData {
+	data := &NotificationTemplateData{
+		RuleID:       rule.ID(),
+		RuleName:     rule.Name(),
+		Status:       "firing",
+		Severity:     alert.Severity,
+		Value:        alert.Value,
+		Labels:       alert.Labels.Map(),
+		Annotations:  alert.Annotations.Map(),
+		StartsAt:     alert.FiredAt,
+		EndsAt:       alert.ResolvedAt,
+		GeneratorURL: alert.GeneratorURL,
+		evalWindow:   rule.EvalWindow(),
+	}
+	if alert.QueryResultLables != nil {
+		data.queryLabels = alert.QueryResultLables.Map()
+	}
+	if !alert.ResolvedAt.IsZero() {
+		data.Status = "resolved"
+	}
+	if data.StartsAt.IsZero() {
+		data.StartsAt = alert.ActiveAt
+	}
+	if data.Severity == "" {
+		data.Severity = data.Labels[labels.AlertSeverityLabel]
+	}
+
+	if rc := rule.Condition(); rc != nil {
+		if rc.CompositeQuery != nil {
+			data.Unit = rc.CompositeQuery.Unit
+		}
+		levels := rc.thresholds()
+		th := levels[0]
+		for _, level := range levels {
+			if level.Severity != "" && level.Severity == alert.Severity {
+				th = level
+			}
+		}
+		data.Threshold = thresholdTarget(th, data.Unit)
+	}
+	return data
+}
+
+// FormattedValue is the value of the alert humanised for the rule unit
+func (d *NotificationTemplateData) FormattedValue() string {
+	return formatValue(d.Unit, d.Value)
+}
+
+// FormattedThreshold is the threshold of the alert humanised for the rule unit
+func (d *NotificationTemplateData) FormattedThreshold() string {
+	return formatValue(d.Unit, d.Threshold)
+}
+
+// host returns the address of SigNoz taken from the generator url
+func (d *NotificationTemplateData) host() string {
+	u, err := url.Parse(d.GeneratorURL)
+	if err != nil || u.Scheme == "" || u.Host == "" {
+		return ""
+	}
+	return fmt.Sprintf("%s://%s", u.Scheme, u.Host)
+}
+
+// linkWindow is the time range of the related logs and traces links
+func (d *NotificationTemplateData) linkWindow() (time.Time, time.Time) {
+	end := d.EndsAt
+	if end.IsZero() {
+		end = d.StartsAt
+	}
+	return d.StartsAt.Add(-d.evalWindow), end
+}
+
+// LogsLink links to the logs of the alert series, the one of the rule is
+// used for logs based rules
+func (d *NotificationTemplateData) LogsLink() string {
+	if link, ok := d.Annotations["related_logs"]; ok {
+		return link
+	}
+	host := d.host()
+	if host == "" {
+		return ""
+	}
+	start, end := d.linkWindow()
+	filters := contextlinks.PrepareFilters(d.queryLabels, nil, nil, nil)
+	return fmt.Sprintf("%s/logs/logs-explorer?%s", host, contextlinks.PrepareLinksToLogs(start, end, filters))
+}
+
+// TracesLink links to the traces of the alert series, the one of the rule
+// is used for traces based rules
+func (d *NotificationTemplateData) TracesLink() string {
+	if link, ok := d.Annotations["related_traces"]; ok {
+		return link
+	}
+	host := d.host()
+	if host == "" {
+		return ""
+	}
+	start, end := d.linkWindow()
+	filters := contextlinks.PrepareFilters(d.queryLabels, nil, nil, nil)
+	return fmt.Sprintf("%s/traces-explorer?%s", host, contextlinks.PrepareLinksToTraces(start, end, filters))
+}
+
+// formatValue humanises the value for the unit, e.g. 1.5 s for 1500 ms
+func formatValue(unit string, value float64) string {
+	return formatter.FromUnit(unit).Format(value, unit)
+}
+
+// sortedLabelNames returns the names of the labels that are not internal
+// to the rules, sorted
+func sortedLabelNames(lbls map[string]string) []string {
+	names := make([]string, 0, len(lbls))
+	for name := range lbls {
+		if name == labels.AlertRuleIdLabel || name == labels.RuleSourceLabel || strings.HasPrefix(name, "__") {
+			continue
+		}
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// labelTable renders the labels as a markdown table
+func labelTable(lbls map[string]string) string {
+	var b strings.Builder
+	b.WriteString("| Label | Value |\n| --- | --- |\n")
+	for _, name := range sortedLabelNames(lbls) {
+		fmt.Fprintf(&b, "| %s | %s |\n", name, strings.ReplaceAll(lbls[name], "|", "\\|"))
+	}
+	return b.String()
+}
+
+// labelTableHTML renders the labels as an html table for emails
+func labelTableHTML(lbls map[string]string) string {
+	var b strings.Builder
+	b.WriteString("<table><tr><th>Label</th><th>Value</th></tr>")
+	for _, name := range sortedLabelNames(lbls) {
+		fmt.Fprintf(&b, "<tr><td>%s</td><td>%s</td></tr>", html.EscapeString(name), html.EscapeString(lbls[name]))
+	}
+	b.WriteString("</table>")
+	return b.String()
+}
+
+var notificationTemplateFuncs = template.FuncMap{
+	"toUpper":        strings.ToUpper,
+	"toLower":        strings.ToLower,
+	"join":           func(sep string, s []string) string { return strings.Join(s, sep) },
+	"formatValue":    formatValue,
+	"labelTable":     labelTable,
+	"labelTableHTML": labelTableHTML,
+	"humanizeDuration": func(d time.Duration) string {
+		return formatValue("s", d.Seconds())
+	},
+	"since": func(t time.Time) time.Duration {
+		return time.Since(t).Truncate(time.Second)
+	},
+	"formatTime": func(layout string, t time.Time) string {
+		return t.UTC().Format(layout)
+	},
+}
+
+func parseNotificationTemplate(name, text string) (*template.Template, error) {
+	return template.New(name).Option("missingkey=zero").Funcs(notificationTemplateFuncs).Parse(text)
+}
+
+func expandNotificationTemplate(name, text string, data *NotificationTemplateData) (string, error) {
+	tmpl, err := parseNotificationTemplate(name, text)
+	if err != nil {
+		return "", err
+	}
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, data); err != nil {
+		return "", err
+	}
+	return buf.String(), nil
+}
+
+// notificationTemplates fetches the notification templates of the rule by
+// channel type. Templates that are missing or made for another channel type
+// are left out, the channel then falls back to its own templates.
+func (m *Manager) notificationTemplates(ctx context.Context, rule Rule) map[string]*NotificationTemplate {
+	names := rule.NotificationTemplates()
+	if len(names) == 0 {
+		return nil
+	}
+
+	templates := make(map[string]*NotificationTemplate, len(names))
+	for channelType, name := range names {
+		tmpl, err := m.ruleDB.GetNotificationTemplateByName(ctx, name)
+		if err != nil || tmpl == nil {
+			zap.L().Error("failed to get the notification template", zap.String("ruleid", rule.ID()), zap.String("template", name), zap.Error(err))
+			continue
+		}
+		if tmpl.ChannelType != channelType {
+			zap.L().Error("notification template is made for another channel type", zap.String("ruleid", rule.ID()), zap.String("template", name), zap.String("channelType", channelType), zap.String("templateChannelType", tmpl.ChannelType))
+			continue
+		}
+		templates[channelType] = tmpl
+	}
+	return templates
+}
+
+// withNotificationTemplates returns the annotations of the alert with the
+// notifications rendered from the given templates of the rule. Templates
+// that can not be rendered are left out.
+func withNotificationTemplates(rule Rule, alert *Alert, templates map[string]*NotificationTemplate) labels.BaseLabels {
+	if len(templates) == 0 {
+		return alert.Annotations
+	}
+
+	lb := labels.NewBuilder(labels.FromMap(alert.Annotations.Map()))
+	data := newNotificationTemplateData(rule, alert)
+	for channelType, tmpl := range templates {
+		title, body, err := tmpl.Render(data)
+		if err != nil {
+			zap.L().Error("failed to render the notification template", zap.String("ruleid", rule.ID()), zap.String("template", tmpl.Name), zap.Error(err))
+			continue
+		}
+		lb.Set(am.RenderedTitleAnnotation(channelType), title)
+		lb.Set(am.RenderedBodyAnnotation(channelType), body)
+	}
+	return lb.Labels()
+}
+
+// checkNotificationTemplates fails if a notification template of the rule
+// doesn't exist or is made for another channel type
+func (m *Manager) checkNotificationTemplates(ctx context.Context, rule *PostableRule) error {
+	for channelType, name := range rule.NotificationTemplates {
+		tmpl, err := m.ruleDB.GetNotificationTemplateByName(ctx, name)
+		if err != nil {
+			return err
+		}
+		if tmpl == nil {
+			return fmt.Errorf("notification template %s for %s not found", name, channelType)
+		}
+		if tmpl.ChannelType != channelType {
+			return fmt.Errorf("notification template %s is made for %s, not %s", name, tmpl.ChannelType, channelType)
+		}
+	}
+	return nil
+}
+
+// rulesUsingNotificationTemplate returns the ids of the stored rules that
+// reference the notification template
+func (m *Manager) rulesUsingNotificationTemplate(ctx context.Context, name string) ([]string, error) {
+	storedRules, err := m.ruleDB.GetStoredRules(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var ids []string
+	for _, storedRule := range storedRules {
+		rule := PostableRule{}
+		if err := json.Unmarshal([]byte(storedRule.Data), &rule); err != nil {
+			zap.L().Error("failed to unmarshal stored rule", zap.Int("id", storedRule.Id), zap.Error(err))
+			continue
+		}
+		for _, n := range rule.NotificationTemplates {
+			if n == name {
+				ids = append(ids, strconv.Itoa(storedRule.Id))
+				break
+			}
+		}
+	}
+	return ids, nil
+}
+
+// checkNotificationTemplateUnused fails if rules reference the template
+func (m *Manager) checkNotificationTemplateUnused(ctx context.Context, name string) *model.ApiError {
+	ids, err := m.rulesUsingNotificationTemplate(ctx, name)
+	if err != nil {
+		return newApiErrorInternal(err)
+	}
+	if len(ids) > 0 {
+		return &model.ApiError{Typ: model.ErrorConflict, Err: fmt.Errorf("notification template %s is used by the rules %s", name, strings.Join(ids, ", "))}
+	}
+	return nil
+}
+
+func (m *Manager) ListNotificationTemplates(ctx context.Context) ([]*NotificationTemplate, *model.ApiError) {
+	templates, err := m.ruleDB.GetAllNotificationTemplates(ctx)
+	if err != nil {
+		return nil, newApiErrorInternal(err)
+	}
+	return templates, nil
+}
+
+func (m *Manager) GetNotificationTemplate(ctx context.Context, id string) (*NotificationTemplate, *model.ApiError) {
+	t, err := m.ruleDB.GetNotificationTemplate(ctx, id)
+	if err != nil {
+		return nil, newApiErrorInternal(err)
+	}
+	if t == nil {
+		return nil, &model.ApiError{Typ: model.ErrorNotFound, Err: fmt.Errorf("notification template %s not found", id)}
+	}
+	return t, nil
+}
+
+func (m *Manager) CreateNotificationTemplate(ctx context.Context, t *NotificationTemplate) (*NotificationTemplate, *model.ApiError) {
+	if err := t.Validate(); err != nil {
+		return nil, newApiErrorBadData(err)
+	}
+	if apiErr := m.checkNotificationTemplateName(ctx, t.Name, 0); apiErr != nil {
+		return nil, apiErr
+	}
+
+	id, err := m.ruleDB.CreateNotificationTemplate(ctx, t)
+	if err != nil {
+		return nil, newApiErrorInternal(err)
+	}
+	t.Id = id
+	return t, nil
+}
+
+func (m *Manager) EditNotificationTemplate(ctx context.Context, id string, t *NotificationTemplate) (*NotificationTemplate, *model.ApiError) {
+	stored, apiErr := m.GetNotificationTemplate(ctx, id)
+	if apiErr != nil {
+		return nil, apiErr
+	}
+	if err := t.Validate(); err != nil {
+		return nil, newApiErrorBadData(err)
+	}
+	if apiErr := m.checkNotificationTemplateName(ctx, t.Name, stored.Id); apiErr != nil {
+		return nil, apiErr
+	}
+	// the rules reference the template by name for a channel type
+	if t.Name != stored.Name || t.ChannelType != stored.ChannelType {
+		if apiErr := m.checkNotificationTemplateUnused(ctx, stored.Name); apiErr != nil {
+			return nil, apiErr
+		}
+	}
+
+	t.Id = stored.Id
+	t.CreatedAt, t.CreatedBy = stored.CreatedAt, stored.CreatedBy
+	if err := m.ruleDB.EditNotificationTemplate(ctx, t); err != nil {
+		return nil, newApiErrorInternal(err)
+	}
+	return t, nil
+}
+
+func (m *Manager) DeleteNotificationTemplate(ctx context.Context, id string) *model.ApiError {
+	stored, apiErr := m.GetNotificationTemplate(ctx, id)
+	if apiErr != nil {
+		return apiErr
+	}
+	if apiErr := m.checkNotificationTemplateUnused(ctx, stored.Name); apiErr != nil {
+		return apiErr
+	}
+	if err := m.ruleDB.DeleteNotificationTemplate(ctx, id); err != nil {
+		return newApiErrorInternal(err)
+	}
+	return nil
+}
+
+// checkNotificationTemplateName fails if another template has the name
+func (m *Manager) checkNotificationTemplateName(ctx context.Context, name string, id int64) *model.ApiError {
+	existing, err := m.ruleDB.GetNotificationTemplateByName(ctx, name)
+	if err != nil {
+		return newApiErrorInternal(err)
+	}
+	if existing != nil && existing.Id != id {
+		return &model.ApiError{Typ: model.ErrorConflict, Err: fmt.Errorf("notification template %s already exists", name)}
+	}
+	return nil
+}
+
+// NotificationPreview is a notification template rendered against an alert
+type NotificationPreview struct {
+	Title string                    `json:"title"`
+	Body  string                    `json:"body"`
+	Alert *NotificationTemplateData `json:"alert"`
+}
+
+// PreviewNotificationTemplate evaluates the rule the same way a test
+// notification does and renders the template against the first alert
+// the rule produces. Nothing is sent.
+func (m *Manager) PreviewNotificationTemplate(ctx context.Context, t *NotificationTemplate, ruleStr string) (*NotificationPreview, *model.ApiError) {
+	if err := t.Validate(); err != nil {
+		return nil, newApiErrorBadData(err)
+	}
+
+	rule, apiErr := m.prepareTestRule(ruleStr)
+	if apiErr != nil {
+		return nil, apiErr
+	}
+
+	ts := time.Now().UTC()
+	if _, err := rule.Eval(ctx, ts); err != nil {
+		zap.L().Error("evaluating rule failed", zap.String("rule", rule.Name()), zap.Error(err))
+		return nil, newApiErrorInternal(fmt.Errorf("rule evaluation failed"))
+	}
+
+	var alerts []*Alert
+	rule.SendAlerts(ctx, ts, 0, time.Duration(1*time.Minute), func(ctx context.Context, expr string, a ...*Alert) {
+		alerts = append(alerts, a...)
+	})
+	if len(alerts) == 0 {
+		return nil, newApiErrorBadData(fmt.Errorf("the rule did not produce an alert to render the template against"))
+	}
+
+	// the order of the alerts of a rule is not stable
+	sort.Slice(alerts, func(i, j int) bool {
+		return alerts[i].Labels.String() < alerts[j].Labels.String()
+	})
+
+	data := newNotificationTemplateData(rule, alerts[0])
+	title, body, err := t.Render(data)
+	if err != nil {
+		return nil, newApiErrorBadData(err)
+	}
+	return &NotificationPreview{Title: title, Body: body, Alert: data}, nil
+}
@@ -0,0 +1,249 @@
+package rules
+
+import (
+	"context"
+	"encoding/json"
+	"strconv"
+	"testing"
+	"time"
+
+	_ "github.com/mattn/go-sqlite3"
+	cmock "github.com/srikanthccv/ClickHouse-go-mock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.signoz.io/signoz/pkg/query-service/app/clickhouseReader"
+	"go.signoz.io/signoz/pkg/query-service/featureManager"
+	am "go.signoz.io/signoz/pkg/query-service/integrations/alertManager"
+	"go.signoz.io/signoz/pkg/query-service/model"
+	v3 "go.signoz.io/signoz/pkg/query-service/model/v3"
+	"go.signoz.io/signoz/pkg/query-service/utils"
+	"go.signoz.io/signoz/pkg/query-service/utils/labels"
+)
+
+func notificationTestRule(t *testing.T) *PostableRule {
+	target := 500.0
+	return &PostableRule{
+		AlertName:  "High latency",
+		AlertType:  AlertTypeMetric,
+		RuleType:   RuleTypeThreshold,
+		EvalWindow: Duration(5 * time.Minute),
+		Frequency:  Duration(1 * time.Minute),
+		Source:     "http://signoz.local/alerts/new",
+		RuleCondition: &RuleCondition{
+			CompositeQuery: &v3.CompositeQuery{
+				QueryType: v3.QueryTypeBuilder,
+				Unit:      "ms",
+				BuilderQueries: map[string]*v3.BuilderQuery{
+					"A": {
+						QueryName:          "A",
+						StepInterval:       60,
+						AggregateAttribute: v3.AttributeKey{Key: "signoz_latency_max"},
+						AggregateOperator:  v3.AggregateOperatorMax,
+						DataSource:         v3.DataSourceMetrics,
+						Temporality:        v3.Unspecified,
+						Expression:         "A",
+					},
+				},
+			},
+			Target:     &target,
+			TargetUnit: "ms",
+			CompareOp:  ValueIsAbove,
+			MatchType:  AtleastOnce,
+		},
+		Labels:                map[string]string{"severity": "critical"},
+		Annotations:           map[string]string{},
+		NotificationTemplates: map[string]string{"slack": "latency-slack"},
+	}
+}
+
+func testNotificationTemplate() *NotificationTemplate {
+	return &NotificationTemplate{
+		Name:        "latency-slack",
+		ChannelType: "slack",
+		Title:       `[{{ .Status | toUpper }}] {{ .RuleName }}`,
+		Body:        `{{ .Labels.service_name }} latency is {{ .FormattedValue }} (threshold {{ .FormattedThreshold }})`,
+	}
+}
+
+func TestNotificationTemplateValidate(t *testing.T) {
+	assert.NoError(t, testNotificationTemplate().Validate())
+
+	tmpl := testNotificationTemplate()
+	tmpl.ChannelType = "webhook"
+	assert.Error(t, tmpl.Validate())
+
+	tmpl = testNotificationTemplate()
+	tmpl.Body = `{{ .Labels.service_name `
+	assert.Error(t, tmpl.Validate())
+
+	tmpl = testNotificationTemplate()
+	tmpl.Title = `{{ unknownFunc .RuleName }}`
+	assert.Error(t, tmpl.Validate())
+
+	rule := notificationTestRule(t)
+	rule.NotificationTemplates = map[string]string{"sms": "latency-sms"}
+	assert.Error(t, rule.Validate())
+}
+
+func TestNotificationTemplateRender(t *testing.T) {
+	rule, err := NewThresholdRule("5", notificationTestRule(t), nil, nil, true)
+	require.NoError(t, err)
+
+	firedAt := time.Date(2024, 1, 1, 10, 0, 0, 0, time.UTC)
+	alert := &Alert{
+		Labels:            labels.FromStrings(labels.AlertNameLabel, "High latency", labels.AlertRuleIdLabel, "5", "service_name", "frontend", "severity", "critical"),
+		Annotations:       labels.FromStrings("summary", "frontend is slow"),
+		QueryResultLables: labels.FromStrings("service_name", "frontend"),
+		GeneratorURL:      rule.GeneratorURL(),
+		Value:             1500,
+		FiredAt:           firedAt,
+	}
+
+	data := newNotificationTemplateData(rule, alert)
+	assert.Equal(t, "firing", data.Status)
+	assert.Equal(t, "critical", data.Severity)
+	assert.Equal(t, float64(500), data.Threshold)
+
+	title, body, err := testNotificationTemplate().Render(data)
+	require.NoError(t, err)
+	assert.Equal(t, "[FIRING] High latency", title)
+	assert.Equal(t, "frontend latency is 1.50 s (threshold 500 ms)", body)
+
+	tmpl := &NotificationTemplate{
+		Name:        "labels",
+		ChannelType: "email",
+		Body:        `{{ labelTable .Labels }}{{ formatValue "bytes" 2048 }}`,
+	}
+	_, body, err = tmpl.Render(data)
+	require.NoError(t, err)
+	assert.Equal(t, "| Label | Value |\n| --- | --- |\n| alertname | High latency |\n| service_name | frontend |\n| severity | critical |\n2.0 KiB", body)
+
+	// the links are built from the query labels over the evaluation window
+	link := data.TracesLink()
+	assert.Contains(t, link, "http://signoz.local/traces-explorer?")
+	assert.Contains(t, link, "frontend")
+	assert.Contains(t, link, "startTime="+strconv.FormatInt(firedAt.Add(-5*time.Minute).UnixNano(), 10))
+}
+
+func TestManagerNotificationTemplates(t *testing.T) {
+	ctx := context.Background()
+	db := utils.NewQueryServiceDBForTests(t)
+	m := &Manager{
+		ruleDB: NewRuleDB(db, nil),
+		opts:   &ManagerOptions{DisableRules: true},
+	}
+
+	created, apiErr := m.CreateNotificationTemplate(ctx, testNotificationTemplate())
+	require.Nil(t, apiErr)
+	id := strconv.FormatInt(created.Id, 10)
+
+	_, apiErr = m.CreateNotificationTemplate(ctx, testNotificationTemplate())
+	require.NotNil(t, apiErr, "the names are unique")
+
+	edited := testNotificationTemplate()
+	edited.Body = `{{ .RuleName }} is {{ .Status }}`
+	_, apiErr = m.EditNotificationTemplate(ctx, id, edited)
+	require.Nil(t, apiErr)
+
+	got, apiErr := m.GetNotificationTemplate(ctx, id)
+	require.Nil(t, apiErr)
+	assert.Equal(t, edited.Body, got.Body)
+
+	// the rule renders the templates it references into the annotations
+	rule, err := NewThresholdRule("5", notificationTestRule(t), nil, nil, true)
+	require.NoError(t, err)
+	alert := &Alert{
+		Labels:      labels.FromStrings(labels.AlertRuleIdLabel, "5"),
+		Annotations: labels.FromStrings("summary", "slow"),
+		ResolvedAt:  time.Now(),
+	}
+	annotations := withNotificationTemplates(rule, alert, m.notificationTemplates(ctx, rule))
+	assert.Equal(t, "slow", annotations.Get("summary"))
+	assert.Equal(t, "[RESOLVED] High latency", annotations.Get(am.RenderedTitleAnnotation("slack")))
+	assert.Equal(t, "High latency is resolved", annotations.Get(am.RenderedBodyAnnotation("slack")))
+	assert.False(t, alert.Annotations.Has(am.RenderedBodyAnnotation("slack")))
+
+	// a template made for another channel type is left out
+	emailTmpl := testNotificationTemplate()
+	emailTmpl.Name, emailTmpl.ChannelType = "latency-email", "email"
+	_, apiErr = m.CreateNotificationTemplate(ctx, emailTmpl)
+	require.Nil(t, apiErr)
+	mismatched := notificationTestRule(t)
+	mismatched.NotificationTemplates = map[string]string{"slack": "latency-email"}
+	mismatchedRule, err := NewThresholdRule("6", mismatched, nil, nil, true)
+	require.NoError(t, err)
+	assert.Empty(t, m.notificationTemplates(ctx, mismatchedRule))
+
+	templates, apiErr := m.ListNotificationTemplates(ctx)
+	require.Nil(t, apiErr)
+	assert.Len(t, templates, 2)
+
+	// rules can only reference existing templates of their channel type
+	ruleStr, err := json.Marshal(mismatched)
+	require.NoError(t, err)
+	_, err = m.CreateRule(ctx, string(ruleStr))
+	assert.Error(t, err)
+
+	missing := notificationTestRule(t)
+	missing.NotificationTemplates = map[string]string{"slack": "missing"}
+	ruleStr, err = json.Marshal(missing)
+	require.NoError(t, err)
+	_, err = m.CreateRule(ctx, string(ruleStr))
+	assert.Error(t, err)
+
+	ruleStr, err = json.Marshal(notificationTestRule(t))
+	require.NoError(t, err)
+	stored, err := m.CreateRule(ctx, string(ruleStr))
+	require.NoError(t, err)
+
+	// the template can't be deleted or renamed while the rule uses it
+	apiErr = m.DeleteNotificationTemplate(ctx, id)
+	require.NotNil(t, apiErr)
+	assert.Equal(t, model.ErrorConflict, apiErr.Type())
+	renamed := testNotificationTemplate()
+	renamed.Name = "latency-slack-v2"
+	_, apiErr = m.EditNotificationTemplate(ctx, id, renamed)
+	require.NotNil(t, apiErr)
+	assert.Equal(t, model.ErrorConflict, apiErr.Type())
+
+	require.NoError(t, m.DeleteRule(ctx, stored.Id))
+	require.Nil(t, m.DeleteNotificationTemplate(ctx, id))
+	_, apiErr = m.GetNotificationTemplate(ctx, id)
+	assert.NotNil(t, apiErr)
+
+	// a missing template is left out
+	annotations = withNotificationTemplates(rule, alert, m.notificationTemplates(ctx, rule))
+	assert.False(t, annotations.Has(am.RenderedBodyAnnotation("slack")))
+}
+
+func TestManagerPreviewNotificationTemplate(t *testing.T) {
+	ruleStr, err := json.Marshal(notificationTestRule(t))
+	require.NoError(t, err)
+
+	fm := featureManager.StartManager()
+	mock, err := cmock.NewClickHouseWithQueryMatcher(nil, &queryMatcherAny{})
+	require.NoError(t, err)
+
+	cols := []cmock.ColumnType{
+		{Name: "value", Type: "Float64"},
+		{Name: "service_name", Type: "String"},
+	}
+	mock.ExpectQuery("SELECT").WillReturnRows(cmock.NewRows(cols, [][]interface{}{
+		{float64(750), "frontend"},
+	}))
+
+	options := clickhouseReader.NewOptions("", 0, 0, 0, "", "archiveNamespace")
+	reader := clickhouseReader.NewReaderFromClickhouseConnection(mock, options, nil, "", fm, "", true)
+
+	m := &Manager{
+		opts:         defaultOptions(&ManagerOptions{}),
+		reader:       reader,
+		featureFlags: fm,
+	}
+
+	preview, apiErr := m.PreviewNotificationTemplate(context.Background(), testNotificationTemplate(), string(ruleStr))
+	require.Nil(t, apiErr)
+	assert.Equal(t, "[FIRING] High latency"+TestAlertPostFix, preview.Title)
+	assert.Equal(t, "frontend latency is 750 ms (threshold 500 ms)", preview.Body)
+	assert.Equal(t, "frontend", preview.Alert.Labels["service_name"])
+}
@@ -115,30 +115,43 @@ func (r *PromRule) Eval(ctx context.Context, ts time.Time) (interface{}, error)
 		return nil, err
 	}
 
-	r.mtx.Lock()
-	defer r.mtx.Unlock()
-
-	resultFPs := map[uint64]struct{}{}
-
-	var alerts = make(map[uint64]*Alert, len(res))
-
+	var vec Vector
+	seen := make([]*v3.Series, 0, len(res))
 	for _, series := range res {
-		l := make(map[string]string, len(series.Metric))
-		for _, lbl := range series.Metric {
-			l[lbl.Name] = lbl.Value
-		}
-
 		if len(series.Floats) == 0 {
 			continue
 		}
 
-		alertSmpl, shouldAlert := r.ShouldAlert(toCommonSeries(series))
+		commonSeries := toCommonSeries(series)
+		seen = append(seen, &commonSeries)
+
+		alertSmpl, shouldAlert := r.ShouldAlert(commonSeries)
 		if !shouldAlert {
 			continue
 		}
 		zap.L().Debug("alerting for series", zap.String("name", r.Name()), zap.Any("series", series))
+		vec = append(vec, alertSmpl)
+	}
+
+	if r.ruleCondition.alertOnAbsentSeries() {
+		// every series that stopped reporting is alerted on separately
+		vec = append(vec, r.absentSeries(ts, seen)...)
+	}
+
+	r.mtx.Lock()
+	defer r.mtx.Unlock()
+
+	resultFPs := map[uint64]struct{}{}
+
+	var alerts = make(map[uint64]*Alert, len(vec))
+
+	for _, alertSmpl := range vec {
+		l := make(map[string]string, len(alertSmpl.Metric))
+		for _, lbl := range alertSmpl.Metric {
+			l[lbl.Name] = lbl.Value
+		}
 
-		threshold := valueFormatter.Format(r.targetVal(), r.Unit())
+		threshold := valueFormatter.Format(alertSmpl.Threshold, r.Unit())
 
 		tmplData := AlertTemplateData(l, valueFormatter.Format(alertSmpl.V, r.Unit()), threshold)
 		// Inject some convenience variables that are easier to remember for users
@@ -169,6 +182,9 @@ func (r *PromRule) Eval(ctx context.Context, ts time.Time) (interface{}, error)
 		for name, value := range r.labels.Map() {
 			lb.Set(name, expand(value))
 		}
+		if alertSmpl.Severity != "" {
+			lb.Set(qslabels.AlertSeverityLabel, alertSmpl.Severity)
+		}
 
 		lb.Set(qslabels.AlertNameLabel, r.Name())
 		lb.Set(qslabels.AlertRuleIdLabel, r.ID())
@@ -178,9 +194,12 @@ func (r *PromRule) Eval(ctx context.Context, ts time.Time) (interface{}, error)
 		for name, value := range r.annotations.Map() {
 			annotations = append(annotations, qslabels.Label{Name: name, Value: expand(value)})
 		}
+		if alertSmpl.IsMissing {
+			lb.Set(qslabels.AlertNameLabel, "[No data] "+r.Name())
+		}
 
 		lbs := lb.Labels()
-		h := lbs.Hash()
+		h := r.AlertFingerprint(lbs)
 		resultFPs[h] = struct{}{}
 
 		if _, ok := alerts[h]; ok {
@@ -201,10 +220,15 @@ func (r *PromRule) Eval(ctx context.Context, ts time.Time) (interface{}, error)
 			Value:             alertSmpl.V,
 			GeneratorURL:      r.GeneratorURL(),
 			Receivers:         r.preferredChannels,
+			Missing:           alertSmpl.IsMissing,
+			Severity:          alertSmpl.Severity,
 		}
 	}
 
 	zap.L().Debug("found alerts for rule", zap.Int("count", len(alerts)), zap.String("name", r.Name()))
+
+	severityChanged := map[uint64]bool{}
+
 	// alerts[h] is ready, add or update active list now
 	for h, a := range alerts {
 		// Check whether we already have alerting state for the identifying label set.
@@ -213,6 +237,8 @@ func (r *PromRule) Eval(ctx context.Context, ts time.Time) (interface{}, error)
 			alert.Value = a.Value
 			alert.Annotations = a.Annotations
 			alert.Receivers = r.preferredChannels
+			// moving between threshold levels keeps the alert active
+			severityChanged[h] = alert.UpdateSeverity(a)
 			continue
 		}
 
@@ -245,6 +271,7 @@ func (r *PromRule) Eval(ctx context.Context, ts time.Time) (interface{}, error)
 					UnixMilli:    ts.UnixMilli(),
 					Labels:       model.LabelsString(labelsJSON),
 					Fingerprint:  a.QueryResultLables.Hash(),
+					Severity:     a.Severity,
 				})
 			}
 			continue
@@ -266,6 +293,19 @@ func (r *PromRule) Eval(ctx context.Context, ts time.Time) (interface{}, error)
 				Labels:       model.LabelsString(labelsJSON),
 				Fingerprint:  a.QueryResultLables.Hash(),
 				Value:        a.Value,
+				Severity:     a.Severity,
+			})
+		} else if a.State == model.StateFiring && severityChanged[fp] {
+			itemsToAdd = append(itemsToAdd, model.RuleStateHistory{
+				RuleID:       r.ID(),
+				RuleName:     r.Name(),
+				State:        model.StateFiring,
+				StateChanged: false,
+				UnixMilli:    ts.UnixMilli(),
+				Labels:       model.LabelsString(labelsJSON),
+				Fingerprint:  a.QueryResultLables.Hash(),
+				Value:        a.Value,
+				Severity:     a.Severity,
 			})
 		}
 
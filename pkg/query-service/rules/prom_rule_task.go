@@ -34,13 +34,15 @@ type PromRuleTask struct {
 	pause  bool
 	logger *zap.Logger
 	notify NotifyFunc
+	// inhibit updates the suppression of the alerts of a rule before they are sent
+	inhibit InhibitFunc
 
 	ruleDB RuleDB
 }
 
 // newPromRuleTask holds rules that have promql condition
 // and evalutes the rule at a given frequency
-func NewPromRuleTask(name, file string, frequency time.Duration, rules []Rule, opts *ManagerOptions, notify NotifyFunc, ruleDB RuleDB) *PromRuleTask {
+func NewPromRuleTask(name, file string, frequency time.Duration, rules []Rule, opts *ManagerOptions, notify NotifyFunc, inhibit InhibitFunc, ruleDB RuleDB) *PromRuleTask {
 	zap.L().Info("Initiating a new rule group", zap.String("name", name), zap.Duration("frequency", frequency))
 
 	if time.Now() == time.Now().Add(frequency) {
@@ -58,6 +60,7 @@ func NewPromRuleTask(name, file string, frequency time.Duration, rules []Rule, o
 		done:                 make(chan struct{}),
 		terminated:           make(chan struct{}),
 		notify:               notify,
+		inhibit:              inhibit,
 		ruleDB:               ruleDB,
 		logger:               opts.Logger,
 	}
@@ -331,7 +334,7 @@ func (g *PromRuleTask) Eval(ctx context.Context, ts time.Time) {
 		shouldSkip := false
 		for _, m := range maintenance {
 			zap.L().Info("checking if rule should be skipped", zap.String("rule", rule.ID()), zap.Any("maintenance", m))
-			if m.shouldSkip(rule.ID(), ts) {
+			if m.shouldSkip(rule.ID(), rule.Labels().Map(), ts) {
 				shouldSkip = true
 				break
 			}
@@ -381,6 +384,9 @@ func (g *PromRuleTask) Eval(ctx context.Context, ts time.Time) {
 				//}
 				return
 			}
+			if g.inhibit != nil {
+				g.inhibit(ctx, ts, rule)
+			}
 			rule.SendAlerts(ctx, ts, g.opts.ResendDelay, g.frequency, g.notify)
 
 		}(i, rule)
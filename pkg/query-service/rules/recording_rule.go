@@ -0,0 +1,168 @@
+package rules
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"go.uber.org/zap"
+
+	"go.signoz.io/signoz/pkg/query-service/interfaces"
+	v3 "go.signoz.io/signoz/pkg/query-service/model/v3"
+
+	yaml "gopkg.in/yaml.v2"
+)
+
+// RecordingRule evaluates the rule condition query at every frequency and
+// writes the result back to the metrics tables as a new metric, so that
+// expensive queries can be precomputed for dashboards and alerts. The
+// query is run the same way as for threshold rules, no alerts are raised.
+type RecordingRule struct {
+	*ThresholdRule
+
+	// record is the name of the metric the result is written to
+	record    string
+	frequency time.Duration
+}
+
+func NewRecordingRule(
+	id string,
+	p *PostableRule,
+	featureFlags interfaces.FeatureLookup,
+	reader interfaces.Reader,
+	useLogsNewSchema bool,
+	opts ...RuleOption,
+) (*RecordingRule, error) {
+
+	zap.L().Info("creating new RecordingRule", zap.String("id", id), zap.Any("opts", opts))
+
+	if !isValidMetricName(p.Record) {
+		return nil, fmt.Errorf("invalid recording rule metric name: %q", p.Record)
+	}
+
+	tr, err := NewThresholdRule(id, p, featureFlags, reader, useLogsNewSchema, opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	r := RecordingRule{
+		ThresholdRule: tr,
+		record:        p.Record,
+		frequency:     time.Duration(p.Frequency),
+	}
+	if r.frequency == 0 {
+		r.frequency = 1 * time.Minute
+	}
+	return &r, nil
+}
+
+func (r *RecordingRule) Type() RuleType {
+	return RuleTypeRecording
+}
+
+// Record returns the name of the metric the rule writes to
+func (r *RecordingRule) Record() string {
+	return r.record
+}
+
+// stepInterval returns the step of the selected query in milliseconds
+func (r *RecordingRule) stepInterval(params *v3.QueryRangeParamsV3) int64 {
+	if q, ok := params.CompositeQuery.BuilderQueries[r.GetSelectedQuery()]; ok && q.StepInterval > 0 {
+		return q.StepInterval * 1000
+	}
+	return params.Step * 1000
+}
+
+// newPoints returns the series with the points that were completed since the
+// previous evaluation. A point covers the step interval starting at its
+// timestamp, the last one is usually still filling up and is written by the
+// next evaluation instead.
+func (r *RecordingRule) newPoints(series []*v3.Series, end, step int64) []*v3.Series {
+	since := end - r.frequency.Milliseconds()
+
+	ruleLabels := r.labels.Map()
+
+	result := make([]*v3.Series, 0, len(series))
+	for _, s := range series {
+		lbls := make(map[string]string, len(s.Labels)+len(ruleLabels))
+		for k, v := range s.Labels {
+			lbls[k] = v
+		}
+		// rule labels are attached to every series, the same as for alerts
+		for k, v := range ruleLabels {
+			lbls[k] = v
+		}
+
+		points := []v3.Point{}
+		for _, p := range s.Points {
+			if p.Timestamp+step <= end && p.Timestamp+step > since {
+				points = append(points, p)
+			}
+		}
+		if len(points) == 0 {
+			continue
+		}
+		result = append(result, &v3.Series{Labels: lbls, Points: points})
+	}
+	return result
+}
+
+// Eval runs the rule query and writes the new points of the result, it
+// returns the number of series written
+func (r *RecordingRule) Eval(ctx context.Context, ts time.Time) (interface{}, error) {
+
+	params, err := r.prepareQueryRange(ts)
+	if err != nil {
+		return nil, err
+	}
+	// the query range is changed by the querier, keep the end for later
+	end := params.End
+	step := r.stepInterval(params)
+
+	res, err := r.runQuery(ctx, params)
+	if err != nil {
+		return nil, err
+	}
+
+	var series []*v3.Series
+	if res != nil {
+		series = r.newPoints(res.Series, end, step)
+	}
+
+	if len(series) > 0 {
+		err = r.reader.AddMetricSamples(ctx, r.record, r.ruleCondition.CompositeQuery.Unit, series)
+		if err != nil {
+			zap.L().Error("failed to write recorded series", zap.String("rule", r.Name()), zap.String("record", r.record), zap.Error(err))
+			return nil, fmt.Errorf("internal error while writing recorded series")
+		}
+	}
+
+	r.health = HealthGood
+	r.lastError = err
+
+	return len(series), nil
+}
+
+// SendAlerts is a no-op, recording rules don't raise alerts
+func (r *RecordingRule) SendAlerts(ctx context.Context, ts time.Time, resendDelay time.Duration, interval time.Duration, notifyFunc NotifyFunc) {
+}
+
+func (r *RecordingRule) String() string {
+
+	ar := PostableRule{
+		AlertName:     r.name,
+		RuleType:      RuleTypeRecording,
+		RuleCondition: r.ruleCondition,
+		EvalWindow:    Duration(r.evalWindow),
+		Frequency:     Duration(r.frequency),
+		Labels:        r.labels.Map(),
+		Record:        r.record,
+	}
+
+	byt, err := yaml.Marshal(ar)
+	if err != nil {
+		return fmt.Sprintf("error marshaling recording rule: %s", err.Error())
+	}
+
+	return string(byt)
+}
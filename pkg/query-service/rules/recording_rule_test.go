@@ -0,0 +1,139 @@
+package rules
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	cmock "github.com/srikanthccv/ClickHouse-go-mock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.signoz.io/signoz/pkg/query-service/app/clickhouseReader"
+	"go.signoz.io/signoz/pkg/query-service/featureManager"
+	v3 "go.signoz.io/signoz/pkg/query-service/model/v3"
+)
+
+func recordingPostableRule() *PostableRule {
+	return &PostableRule{
+		AlertName:  "Calls per service",
+		RuleType:   RuleTypeRecording,
+		Record:     "service:signoz_calls:rate",
+		EvalWindow: Duration(5 * time.Minute),
+		Frequency:  Duration(1 * time.Minute),
+		RuleCondition: &RuleCondition{
+			CompositeQuery: &v3.CompositeQuery{
+				QueryType: v3.QueryTypeBuilder,
+				BuilderQueries: map[string]*v3.BuilderQuery{
+					"A": {
+						QueryName:    "A",
+						StepInterval: 60,
+						AggregateAttribute: v3.AttributeKey{
+							Key: "signoz_calls_total",
+						},
+						AggregateOperator: v3.AggregateOperatorSumRate,
+						DataSource:        v3.DataSourceMetrics,
+						Expression:        "A",
+					},
+				},
+			},
+		},
+		Labels: map[string]string{"team": "platform"},
+	}
+}
+
+func TestRecordingRuleValidate(t *testing.T) {
+	rule := recordingPostableRule()
+	assert.NoError(t, rule.Validate())
+
+	rule.Record = "calls-per-service"
+	assert.Error(t, rule.Validate())
+
+	rule.Record = ""
+	_, err := NewRecordingRule("1", rule, featureManager.StartManager(), nil, true)
+	assert.Error(t, err)
+}
+
+func TestRecordingRuleNewPoints(t *testing.T) {
+	rule, err := NewRecordingRule("1", recordingPostableRule(), featureManager.StartManager(), nil, true)
+	require.NoError(t, err)
+
+	end := time.Date(2024, 1, 1, 10, 5, 0, 0, time.UTC).UnixMilli()
+	minute := time.Minute.Milliseconds()
+	series := []*v3.Series{
+		{
+			Labels: map[string]string{"service_name": "frontend"},
+			Points: []v3.Point{
+				{Timestamp: end - 3*minute, Value: 1},
+				{Timestamp: end - 2*minute, Value: 2},
+				{Timestamp: end - minute, Value: 3},
+				{Timestamp: end, Value: 4},
+			},
+		},
+		{
+			Labels: map[string]string{"service_name": "cart"},
+			Points: []v3.Point{
+				{Timestamp: end - 3*minute, Value: 1},
+			},
+		},
+	}
+
+	// only the point completed since the previous evaluation is written
+	res := rule.newPoints(series, end, minute)
+	require.Len(t, res, 1)
+	assert.Equal(t, map[string]string{"service_name": "frontend", "team": "platform"}, res[0].Labels)
+	assert.Equal(t, []v3.Point{{Timestamp: end - minute, Value: 3}}, res[0].Points)
+}
+
+func TestRecordingRuleEval(t *testing.T) {
+	fm := featureManager.StartManager()
+	mock, err := cmock.NewClickHouseWithQueryMatcher(nil, &queryMatcherAny{})
+	require.NoError(t, err)
+
+	cols := []cmock.ColumnType{
+		{Name: "value", Type: "Float64"},
+		{Name: "attr", Type: "String"},
+		{Name: "timestamp", Type: "String"},
+	}
+	rows := cmock.NewRows(cols, [][]interface{}{
+		{float64(10), "attr", time.Now()},
+	})
+	mock.ExpectQuery("SELECT any").WillReturnRows(rows)
+
+	options := clickhouseReader.NewOptions("", 0, 0, 0, "", "archiveNamespace")
+	reader := clickhouseReader.NewReaderFromClickhouseConnection(mock, options, nil, "", fm, "", true)
+
+	rule, err := NewRecordingRule("1", recordingPostableRule(), fm, reader, true)
+	require.NoError(t, err)
+	rule.TemporalityMap = map[string]map[v3.Temporality]bool{
+		"signoz_calls_total": {
+			v3.Delta: true,
+		},
+	}
+
+	// the point falls outside of the evaluation window, nothing is written
+	retVal, err := rule.Eval(context.Background(), time.Now())
+	require.NoError(t, err)
+	assert.Equal(t, 0, retVal.(int))
+	assert.Equal(t, HealthGood, rule.Health())
+	assert.Empty(t, rule.ActiveAlerts())
+
+	// recorded series are written to the time series and samples tables
+	tsBatch := mock.ExpectPrepareBatch("INSERT INTO signoz_metrics.distributed_time_series_v4")
+	samplesBatch := mock.ExpectPrepareBatch("INSERT INTO signoz_metrics.distributed_samples_v4")
+	tsBatch.ExpectAppend()
+	samplesBatch.ExpectAppend()
+	samplesBatch.ExpectAppend()
+	tsBatch.ExpectSend()
+	samplesBatch.ExpectSend()
+
+	now := time.Now().Truncate(time.Hour)
+	err = reader.AddMetricSamples(context.Background(), rule.Record(), "", []*v3.Series{{
+		Labels: map[string]string{"service_name": "frontend"},
+		Points: []v3.Point{
+			{Timestamp: now.UnixMilli(), Value: 1},
+			{Timestamp: now.Add(time.Minute).UnixMilli(), Value: 2},
+		},
+	}})
+	require.NoError(t, err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
@@ -18,6 +18,12 @@ type Sample struct {
 	Metric labels.Labels
 
 	IsMissing bool
+
+	// Threshold is the target (in the y-axis unit) of the threshold level
+	// the sample was evaluated against, and Severity is the severity of
+	// that level
+	Threshold float64
+	Severity  string
 }
 
 func (s Sample) String() string {
@@ -25,6 +25,10 @@ type Rule interface {
 	ActiveAlerts() []*Alert
 
 	PreferredChannels() []string
+	InhibitedBy() []RuleInhibition
+	// NotificationTemplates maps channel types to the names of the
+	// notification templates that render the notifications of the rule
+	NotificationTemplates() map[string]string
 
 	Eval(context.Context, time.Time) (interface{}, error)
 	String() string
@@ -39,5 +43,7 @@ type Rule interface {
 
 	RecordRuleStateHistory(ctx context.Context, prevState, currentState model.AlertState, itemsToAdd []model.RuleStateHistory) error
 
+	UpdateSuppression(ctx context.Context, ts time.Time, lbls labels.BaseLabels, sourceID string)
+
 	SendAlerts(ctx context.Context, ts time.Time, resendDelay time.Duration, interval time.Duration, notifyFunc NotifyFunc)
 }
@@ -31,6 +31,8 @@ type RuleTask struct {
 
 	pause  bool
 	notify NotifyFunc
+	// inhibit updates the suppression of the alerts of a rule before they are sent
+	inhibit InhibitFunc
 
 	ruleDB RuleDB
 }
@@ -38,7 +40,7 @@ type RuleTask struct {
 const DefaultFrequency = 1 * time.Minute
 
 // NewRuleTask makes a new RuleTask with the given name, options, and rules.
-func NewRuleTask(name, file string, frequency time.Duration, rules []Rule, opts *ManagerOptions, notify NotifyFunc, ruleDB RuleDB) *RuleTask {
+func NewRuleTask(name, file string, frequency time.Duration, rules []Rule, opts *ManagerOptions, notify NotifyFunc, inhibit InhibitFunc, ruleDB RuleDB) *RuleTask {
 
 	if time.Now() == time.Now().Add(frequency) {
 		frequency = DefaultFrequency
@@ -55,6 +57,7 @@ func NewRuleTask(name, file string, frequency time.Duration, rules []Rule, opts
 		done:       make(chan struct{}),
 		terminated: make(chan struct{}),
 		notify:     notify,
+		inhibit:    inhibit,
 		ruleDB:     ruleDB,
 	}
 }
@@ -311,11 +314,15 @@ func (g *RuleTask) Eval(ctx context.Context, ts time.Time) {
 		}
 
 		shouldSkip := false
-		for _, m := range maintenance {
-			zap.L().Info("checking if rule should be skipped", zap.String("rule", rule.ID()), zap.Any("maintenance", m))
-			if m.shouldSkip(rule.ID(), ts) {
-				shouldSkip = true
-				break
+		// maintenance only silences alerts, recording rules keep writing
+		// their series
+		if rule.Type() != RuleTypeRecording {
+			for _, m := range maintenance {
+				zap.L().Info("checking if rule should be skipped", zap.String("rule", rule.ID()), zap.Any("maintenance", m))
+				if m.shouldSkip(rule.ID(), rule.Labels().Map(), ts) {
+					shouldSkip = true
+					break
+				}
 			}
 		}
 
@@ -364,6 +371,9 @@ func (g *RuleTask) Eval(ctx context.Context, ts time.Time) {
 				return
 			}
 
+			if g.inhibit != nil {
+				g.inhibit(ctx, ts, rule)
+			}
 			rule.SendAlerts(ctx, ts, g.opts.ResendDelay, g.frequency, g.notify)
 
 		}(i, rule)
@@ -0,0 +1,531 @@
+package rules
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	"go.uber.org/zap"
+
+	"go.signoz.io/signoz/pkg/query-service/model"
+	v3 "go.signoz.io/signoz/pkg/query-service/model/v3"
+)
+
+const (
+	defaultSLOWindow = 30 * 24 * time.Hour
+	maxSLOWindow     = 90 * 24 * time.Hour
+	// sloHistoryPoints is the number of points of the budget history
+	sloHistoryPoints = 100
+)
+
+// SLO is a service level objective: the share of good events out of all
+// events that is expected over a rolling window. The events are counted by
+// builder queries over traces, logs or metrics.
+type SLO struct {
+	Id          int64  `json:"id"`
+	Name        string `json:"name"`
+	Description string `json:"description,omitempty"`
+
+	// Service sets up a latency SLO of a service when Good and Total are
+	// not given, the good events are the spans of the service that are
+	// faster than the apdex threshold of the service
+	Service string `json:"service,omitempty"`
+
+	Good  *v3.BuilderQuery `json:"good"`
+	Total *v3.BuilderQuery `json:"total"`
+
+	// Target is the objective in percent, e.g. 99.9
+	Target float64 `json:"target"`
+	// Window is the rolling window of the objective, 30 days by default
+	Window Duration `json:"window"`
+
+	// Labels are added to the generated burn rate rules
+	Labels            map[string]string `json:"labels,omitempty"`
+	PreferredChannels []string          `json:"preferredChannels,omitempty"`
+	// DisableAlerts skips generating the burn rate rules
+	DisableAlerts bool `json:"disableAlerts,omitempty"`
+
+	// RuleIds are the ids of the generated burn rate rules
+	RuleIds []string `json:"ruleIds"`
+
+	CreatedAt time.Time `json:"createdAt"`
+	CreatedBy string    `json:"createdBy"`
+	UpdatedAt time.Time `json:"updatedAt"`
+	UpdatedBy string    `json:"updatedBy"`
+}
+
+// objective returns the target as the expected ratio of good events
+func (s *SLO) objective() float64 {
+	return s.Target / 100
+}
+
+func (s *SLO) window() time.Duration {
+	if s.Window == 0 {
+		return defaultSLOWindow
+	}
+	return time.Duration(s.Window)
+}
+
+func (s *SLO) Validate() error {
+	if s.Name == "" {
+		return errors.New("missing name")
+	}
+	if s.Good == nil || s.Total == nil {
+		return errors.New("the good and total queries are required")
+	}
+	if s.Target <= 0 || s.Target >= 100 {
+		return errors.New("target must be between 0 and 100")
+	}
+	if w := s.window(); w < time.Hour || w > maxSLOWindow {
+		return fmt.Errorf("window must be between 1h and %s", maxSLOWindow)
+	}
+
+	if err := s.compositeQuery().Validate(); err != nil {
+		return err
+	}
+
+	// the events of the two queries are matched by their labels
+	goodKeys, totalKeys := groupByKeys(s.Good), groupByKeys(s.Total)
+	if !slices.Equal(goodKeys, totalKeys) {
+		return errors.New("the good and total queries must be grouped by the same attributes")
+	}
+
+	for k, v := range s.Labels {
+		if !isValidLabelName(k) {
+			return fmt.Errorf("invalid label name: %s", k)
+		}
+		if !isValidLabelValue(v) {
+			return fmt.Errorf("invalid label value: %s", v)
+		}
+	}
+	return nil
+}
+
+func groupByKeys(q *v3.BuilderQuery) []string {
+	keys := make([]string, 0, len(q.GroupBy))
+	for _, key := range q.GroupBy {
+		keys = append(keys, key.Key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// compositeQuery returns the good and total queries as the builder
+// queries A and B
+func (s *SLO) compositeQuery() *v3.CompositeQuery {
+	good, total := s.Good.Clone(), s.Total.Clone()
+	good.QueryName, good.Expression = sloGoodQuery, sloGoodQuery
+	total.QueryName, total.Expression = sloTotalQuery, sloTotalQuery
+
+	return &v3.CompositeQuery{
+		QueryType: v3.QueryTypeBuilder,
+		PanelType: v3.PanelTypeGraph,
+		BuilderQueries: map[string]*v3.BuilderQuery{
+			sloGoodQuery:  good,
+			sloTotalQuery: total,
+		},
+	}
+}
+
+// burnRateWindow is a pair of windows of the generated burn rate rules.
+// The rule fires when the share of the error budget is spent over the long
+// window, and the short window is burning at the same rate.
+type burnRateWindow struct {
+	long      time.Duration
+	short     time.Duration
+	budget    float64
+	frequency time.Duration
+	severity  string
+}
+
+// burnRateWindows are the multiwindow, multi-burn-rate alerts recommended
+// by the Google SRE workbook for a 30 day window. The first two page, the
+// others are for slower burns that can wait.
+var burnRateWindows = []burnRateWindow{
+	{long: time.Hour, short: 5 * time.Minute, budget: 0.02, frequency: time.Minute, severity: "critical"},
+	{long: 6 * time.Hour, short: 30 * time.Minute, budget: 0.05, frequency: time.Minute, severity: "critical"},
+	{long: 24 * time.Hour, short: 2 * time.Hour, budget: 0.1, frequency: 5 * time.Minute, severity: "warning"},
+	{long: 72 * time.Hour, short: 6 * time.Hour, budget: 0.1, frequency: 5 * time.Minute, severity: "warning"},
+}
+
+// alertType returns the alert type for the data source of the SLO queries
+func (s *SLO) alertType() AlertType {
+	switch s.Total.DataSource {
+	case v3.DataSourceTraces:
+		return AlertTypeTraces
+	case v3.DataSourceLogs:
+		return AlertTypeLogs
+	}
+	return AlertTypeMetric
+}
+
+// burnRateRules returns the burn rate rules of the SLO. The burn rate that
+// spends the budget share of a window is scaled to the SLO window, windows
+// longer than the SLO window are left out.
+func (s *SLO) burnRateRules() []PostableRule {
+	window := s.window()
+
+	rules := []PostableRule{}
+	for _, w := range burnRateWindows {
+		if w.long >= window {
+			continue
+		}
+		rules = append(rules, s.burnRateRule(w))
+	}
+	return rules
+}
+
+func (s *SLO) burnRateRule(w burnRateWindow) PostableRule {
+	rate := w.budget * float64(s.window()) / float64(w.long)
+
+	lbls := make(map[string]string, len(s.Labels)+2)
+	for k, v := range s.Labels {
+		lbls[k] = v
+	}
+	lbls["severity"] = w.severity
+	lbls["slo_id"] = strconv.FormatInt(s.Id, 10)
+
+	return PostableRule{
+		AlertName:  fmt.Sprintf("%s burn rate over %s", s.Name, shortDuration(w.long)),
+		AlertType:  s.alertType(),
+		RuleType:   RuleTypeBurnRate,
+		EvalWindow: Duration(w.long),
+		Frequency:  Duration(w.frequency),
+		RuleCondition: &RuleCondition{
+			CompositeQuery: s.compositeQuery(),
+			CompareOp:      ValueIsAbove,
+			Target:         &rate,
+			MatchType:      AtleastOnce,
+			BurnRate: &BurnRateCondition{
+				SLOID:       s.Id,
+				Objective:   s.objective(),
+				LongWindow:  Duration(w.long),
+				ShortWindow: Duration(w.short),
+			},
+		},
+		Labels: lbls,
+		Annotations: map[string]string{
+			"summary":     fmt.Sprintf("The error budget of the SLO %s is burning fast", s.Name),
+			"description": fmt.Sprintf("The error budget of the SLO %s is being spent {{$value}} times faster than the target of %g%% allows over the last %s and %s.", s.Name, s.Target, shortDuration(w.long), shortDuration(w.short)),
+		},
+		PreferredChannels: s.PreferredChannels,
+	}
+}
+
+// shortDuration formats whole hours and minutes without the zero units,
+// e.g. 1h instead of 1h0m0s
+func shortDuration(d time.Duration) string {
+	str := d.String()
+	if strings.HasSuffix(str, "m0s") {
+		str = strings.TrimSuffix(str, "0s")
+	}
+	if strings.HasSuffix(str, "h0m") {
+		str = strings.TrimSuffix(str, "0m")
+	}
+	return str
+}
+
+// LatencySLOQueries returns the good and total queries of a latency SLO of
+// the service, the good events are the spans faster than the threshold in
+// seconds, usually the apdex threshold of the service
+func LatencySLOQueries(service string, threshold float64) (*v3.BuilderQuery, *v3.BuilderQuery) {
+	serviceFilter := v3.FilterItem{
+		Key:      v3.AttributeKey{Key: "serviceName", DataType: v3.AttributeKeyDataTypeString, Type: v3.AttributeKeyTypeTag, IsColumn: true},
+		Operator: v3.FilterOperatorEqual,
+		Value:    service,
+	}
+	latencyFilter := v3.FilterItem{
+		Key:      v3.AttributeKey{Key: "durationNano", DataType: v3.AttributeKeyDataTypeFloat64, Type: v3.AttributeKeyTypeTag, IsColumn: true},
+		Operator: v3.FilterOperatorLessThanOrEq,
+		Value:    threshold * float64(time.Second),
+	}
+
+	query := func(items ...v3.FilterItem) *v3.BuilderQuery {
+		return &v3.BuilderQuery{
+			DataSource:        v3.DataSourceTraces,
+			AggregateOperator: v3.AggregateOperatorCount,
+			Filters:           &v3.FilterSet{Operator: "AND", Items: items},
+			StepInterval:      60,
+		}
+	}
+	return query(serviceFilter, latencyFilter), query(serviceFilter)
+}
+
+// SLOBudgetPoint is the state of the error budget at a point of the window
+type SLOBudgetPoint struct {
+	Timestamp  int64   `json:"timestamp"`
+	Attainment float64 `json:"attainment"`
+	// RemainingBudget is the share of the error budget of the window that
+	// is left at the timestamp
+	RemainingBudget float64 `json:"remainingBudget"`
+}
+
+// SLOStatus is the attainment of an SLO over its current window
+type SLOStatus struct {
+	Start  int64   `json:"start"`
+	End    int64   `json:"end"`
+	Target float64 `json:"target"`
+	Good   float64 `json:"good"`
+	Total  float64 `json:"total"`
+	// Attainment is the percentage of good events, 100 without events
+	Attainment float64 `json:"attainment"`
+	// ErrorBudget is the number of bad events the target allows
+	ErrorBudget float64 `json:"errorBudget"`
+	// RemainingBudget is the share of the error budget that is left,
+	// it is negative once the budget is exhausted
+	RemainingBudget float64 `json:"remainingBudget"`
+	// History is the budget burndown over the window
+	History []SLOBudgetPoint `json:"history"`
+}
+
+// newSLOStatus sums the events of all series into the status of the SLO
+func newSLOStatus(s *SLO, series map[uint64]*sloSeries, start, end time.Time) *SLOStatus {
+	byTs := map[int64]*sloEvents{}
+	for _, ss := range series {
+		for ts, e := range ss.points {
+			sum, ok := byTs[ts]
+			if !ok {
+				sum = &sloEvents{}
+				byTs[ts] = sum
+			}
+			sum.good += e.good
+			sum.total += e.total
+		}
+	}
+
+	timestamps := make([]int64, 0, len(byTs))
+	for ts := range byTs {
+		timestamps = append(timestamps, ts)
+	}
+	sort.Slice(timestamps, func(i, j int) bool { return timestamps[i] < timestamps[j] })
+
+	status := &SLOStatus{
+		Start:   start.UnixMilli(),
+		End:     end.UnixMilli(),
+		Target:  s.Target,
+		History: make([]SLOBudgetPoint, 0, len(timestamps)),
+	}
+	for _, ts := range timestamps {
+		status.Good += byTs[ts].good
+		status.Total += byTs[ts].total
+	}
+	status.ErrorBudget = status.Total * (1 - s.objective())
+
+	budgetLeft := func(good, total float64) (float64, float64) {
+		attainment := 100.0
+		if total > 0 {
+			attainment = good / total * 100
+		}
+		if status.ErrorBudget <= 0 {
+			return attainment, 1
+		}
+		return attainment, 1 - (total-good)/status.ErrorBudget
+	}
+
+	status.Attainment, status.RemainingBudget = budgetLeft(status.Good, status.Total)
+
+	var good, total float64
+	for _, ts := range timestamps {
+		good += byTs[ts].good
+		total += byTs[ts].total
+		attainment, remaining := budgetLeft(good, total)
+		status.History = append(status.History, SLOBudgetPoint{
+			Timestamp:       ts,
+			Attainment:      attainment,
+			RemainingBudget: remaining,
+		})
+	}
+	return status
+}
+
+// sloQueryRule returns a burn rate rule for the SLO that is only used to
+// run its queries
+func (m *Manager) sloQueryRule(s *SLO) (*BurnRateRule, error) {
+	p := s.burnRateRule(burnRateWindows[0])
+	return NewBurnRateRule(fmt.Sprintf("slo-%d", s.Id), &p, m.featureFlags, m.reader, m.opts.UseLogsNewSchema)
+}
+
+// SLOStatus returns the attainment, the error budget and the budget
+// history of the SLO over the window ending at now
+func (m *Manager) SLOStatus(ctx context.Context, id string, now time.Time) (*SLOStatus, *model.ApiError) {
+	s, err := m.ruleDB.GetSLO(ctx, id)
+	if err != nil {
+		return nil, newApiErrorInternal(err)
+	}
+	if s == nil {
+		return nil, &model.ApiError{Typ: model.ErrorNotFound, Err: fmt.Errorf("slo %s not found", id)}
+	}
+
+	r, err := m.sloQueryRule(s)
+	if err != nil {
+		return nil, newApiErrorBadData(err)
+	}
+
+	start := now.Add(-s.window())
+	// the step keeps the history at about sloHistoryPoints points
+	step := int64(s.window().Seconds()) / sloHistoryPoints
+	step -= step % 60
+
+	series, err := r.queryEvents(ctx, start, now, step)
+	if err != nil {
+		return nil, newApiErrorInternal(err)
+	}
+	return newSLOStatus(s, series, start, now), nil
+}
+
+func (m *Manager) ListSLOs(ctx context.Context) ([]*SLO, *model.ApiError) {
+	slos, err := m.ruleDB.GetAllSLOs(ctx)
+	if err != nil {
+		return nil, newApiErrorInternal(err)
+	}
+	return slos, nil
+}
+
+func (m *Manager) GetSLO(ctx context.Context, id string) (*SLO, *model.ApiError) {
+	s, err := m.ruleDB.GetSLO(ctx, id)
+	if err != nil {
+		return nil, newApiErrorInternal(err)
+	}
+	if s == nil {
+		return nil, &model.ApiError{Typ: model.ErrorNotFound, Err: fmt.Errorf("slo %s not found", id)}
+	}
+	return s, nil
+}
+
+// CreateSLO stores the SLO and creates its burn rate rules
+func (m *Manager) CreateSLO(ctx context.Context, s *SLO) (*SLO, *model.ApiError) {
+	if s.Window == 0 {
+		s.Window = Duration(defaultSLOWindow)
+	}
+	if err := s.Validate(); err != nil {
+		return nil, newApiErrorBadData(err)
+	}
+
+	id, err := m.ruleDB.CreateSLO(ctx, s)
+	if err != nil {
+		return nil, newApiErrorInternal(err)
+	}
+	s.Id = id
+
+	s.RuleIds, err = m.syncSLORules(ctx, s, nil)
+	if err == nil {
+		err = m.ruleDB.EditSLO(ctx, s)
+	}
+	if err != nil {
+		m.deleteSLORules(ctx, s.RuleIds)
+		if err := m.ruleDB.DeleteSLO(ctx, strconv.FormatInt(id, 10)); err != nil {
+			zap.L().Error("failed to delete the slo after its rules failed", zap.Int64("id", id), zap.Error(err))
+		}
+		return nil, newApiErrorInternal(err)
+	}
+	return s, nil
+}
+
+// EditSLO updates the SLO and its burn rate rules, the existing rules are
+// updated in place so they keep their state
+func (m *Manager) EditSLO(ctx context.Context, id string, s *SLO) (*SLO, *model.ApiError) {
+	stored, apiErr := m.GetSLO(ctx, id)
+	if apiErr != nil {
+		return nil, apiErr
+	}
+
+	if s.Window == 0 {
+		s.Window = Duration(defaultSLOWindow)
+	}
+	if err := s.Validate(); err != nil {
+		return nil, newApiErrorBadData(err)
+	}
+
+	s.Id = stored.Id
+	s.CreatedAt, s.CreatedBy = stored.CreatedAt, stored.CreatedBy
+
+	ruleIds, err := m.syncSLORules(ctx, s, stored.RuleIds)
+	s.RuleIds = ruleIds
+	if err == nil {
+		err = m.ruleDB.EditSLO(ctx, s)
+	}
+	if err != nil {
+		// the stored SLO keeps its rules, the ones created by the edit
+		// are not referenced by it
+		created := []string{}
+		for _, ruleId := range ruleIds {
+			if !slices.Contains(stored.RuleIds, ruleId) {
+				created = append(created, ruleId)
+			}
+		}
+		m.deleteSLORules(ctx, created)
+		return nil, newApiErrorInternal(err)
+	}
+	return s, nil
+}
+
+// DeleteSLO deletes the SLO and its burn rate rules
+func (m *Manager) DeleteSLO(ctx context.Context, id string) *model.ApiError {
+	stored, apiErr := m.GetSLO(ctx, id)
+	if apiErr != nil {
+		return apiErr
+	}
+
+	if _, err := m.syncSLORules(ctx, &SLO{DisableAlerts: true}, stored.RuleIds); err != nil {
+		return newApiErrorInternal(err)
+	}
+	if err := m.ruleDB.DeleteSLO(ctx, id); err != nil {
+		return newApiErrorInternal(err)
+	}
+	return nil
+}
+
+// deleteSLORules deletes the burn rate rules of an SLO that failed to be
+// saved, so that they don't keep running without an SLO
+func (m *Manager) deleteSLORules(ctx context.Context, ruleIds []string) {
+	for _, id := range ruleIds {
+		if err := m.DeleteRule(ctx, id); err != nil {
+			zap.L().Error("failed to delete the burn rate rule of a failed slo", zap.String("id", id), zap.Error(err))
+		}
+	}
+}
+
+// syncSLORules makes the given rules match the burn rate rules of the SLO.
+// Rules are updated in order, missing rules are created and the extra
+// ones deleted. It returns the ids of the rules of the SLO.
+func (m *Manager) syncSLORules(ctx context.Context, s *SLO, ruleIds []string) ([]string, error) {
+	var rules []PostableRule
+	if !s.DisableAlerts {
+		rules = s.burnRateRules()
+	}
+
+	ids := make([]string, 0, len(rules))
+	for idx, rule := range rules {
+		ruleStr, err := json.Marshal(rule)
+		if err != nil {
+			return ids, err
+		}
+
+		if idx < len(ruleIds) {
+			if err := m.EditRule(ctx, string(ruleStr), ruleIds[idx]); err != nil {
+				return ids, errors.Wrapf(err, "failed to update the burn rate rule %s", ruleIds[idx])
+			}
+			ids = append(ids, ruleIds[idx])
+			continue
+		}
+
+		created, err := m.CreateRule(ctx, string(ruleStr))
+		if err != nil {
+			return ids, errors.Wrap(err, "failed to create the burn rate rule")
+		}
+		ids = append(ids, created.Id)
+	}
+
+	for idx := len(rules); idx < len(ruleIds); idx++ {
+		if err := m.DeleteRule(ctx, ruleIds[idx]); err != nil {
+			return ids, errors.Wrapf(err, "failed to delete the burn rate rule %s", ruleIds[idx])
+		}
+	}
+	return ids, nil
+}
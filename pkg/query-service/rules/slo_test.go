@@ -0,0 +1,228 @@
+package rules
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"strconv"
+	"testing"
+	"time"
+
+	_ "github.com/mattn/go-sqlite3"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	v3 "go.signoz.io/signoz/pkg/query-service/model/v3"
+	"go.signoz.io/signoz/pkg/query-service/utils"
+)
+
+func testSLO() *SLO {
+	good, total := LatencySLOQueries("frontend", 0.5)
+	return &SLO{
+		Name:   "Frontend latency",
+		Good:   good,
+		Total:  total,
+		Target: 99.9,
+		Window: Duration(30 * 24 * time.Hour),
+		Labels: map[string]string{"team": "web"},
+	}
+}
+
+func TestSLOValidate(t *testing.T) {
+	slo := testSLO()
+	assert.NoError(t, slo.Validate())
+
+	slo.Target = 100
+	assert.Error(t, slo.Validate())
+
+	slo = testSLO()
+	slo.Window = Duration(180 * 24 * time.Hour)
+	assert.Error(t, slo.Validate())
+
+	slo = testSLO()
+	slo.Good.GroupBy = []v3.AttributeKey{{Key: "operation"}}
+	assert.Error(t, slo.Validate())
+}
+
+func TestSLOBurnRateRules(t *testing.T) {
+	slo := testSLO()
+	slo.Id = 7
+
+	rules := slo.burnRateRules()
+	require.Len(t, rules, 4)
+
+	expected := []float64{14.4, 6, 3, 1}
+	for idx, rule := range rules {
+		assert.NoError(t, rule.Validate())
+		assert.Equal(t, RuleType(RuleTypeBurnRate), rule.RuleType)
+		assert.InDelta(t, expected[idx], *rule.RuleCondition.Target, 1e-9)
+		assert.Equal(t, int64(7), rule.RuleCondition.BurnRate.SLOID)
+		assert.InDelta(t, 0.999, rule.RuleCondition.BurnRate.Objective, 1e-9)
+		assert.Equal(t, "7", rule.Labels["slo_id"])
+		assert.Equal(t, "web", rule.Labels["team"])
+	}
+	assert.Equal(t, "Frontend latency burn rate over 1h", rules[0].AlertName)
+	assert.Equal(t, "critical", rules[0].Labels["severity"])
+	assert.Equal(t, "warning", rules[3].Labels["severity"])
+
+	// the burn rates are scaled to the window, longer windows are left out
+	slo.Window = Duration(2 * 24 * time.Hour)
+	rules = slo.burnRateRules()
+	require.Len(t, rules, 3)
+	assert.InDelta(t, 0.96, *rules[0].RuleCondition.Target, 1e-9)
+}
+
+func TestSLOEvents(t *testing.T) {
+	good := &v3.BuilderQuery{QueryName: sloGoodQuery, StepInterval: 60, AggregateOperator: v3.AggregateOperatorCount}
+	total := &v3.BuilderQuery{QueryName: sloTotalQuery, StepInterval: 60, AggregateOperator: v3.AggregateOperatorSumRate}
+
+	series := map[uint64]*sloSeries{}
+	addSLOEvents(series, &v3.Result{
+		QueryName: sloGoodQuery,
+		Series: []*v3.Series{{
+			Labels: map[string]string{"service": "frontend"},
+			Points: []v3.Point{{Timestamp: 0, Value: 90}, {Timestamp: 60000, Value: 95}},
+		}},
+	}, good)
+	// rates are converted to the events of the step
+	addSLOEvents(series, &v3.Result{
+		QueryName: sloTotalQuery,
+		Series: []*v3.Series{{
+			Labels: map[string]string{"service": "frontend"},
+			Points: []v3.Point{{Timestamp: 0, Value: 1.5}, {Timestamp: 60000, Value: 5.0 / 3}},
+		}},
+	}, total)
+
+	require.Len(t, series, 1)
+	for _, s := range series {
+		events := s.events()
+		assert.InDelta(t, 185, events.good, 1e-9)
+		assert.InDelta(t, 190, events.total, 1e-9)
+
+		rate, ok := burnRate(events, 0.99)
+		require.True(t, ok)
+		assert.InDelta(t, (5.0/190)/0.01, rate, 1e-9)
+	}
+
+	_, ok := burnRate(sloEvents{}, 0.99)
+	assert.False(t, ok)
+}
+
+func TestNewSLOStatus(t *testing.T) {
+	slo := testSLO()
+	slo.Target = 99
+
+	series := map[uint64]*sloSeries{
+		1: {points: map[int64]*sloEvents{
+			0:     {good: 500, total: 500},
+			60000: {good: 495, total: 500},
+		}},
+		2: {points: map[int64]*sloEvents{
+			60000: {good: 0, total: 0},
+		}},
+	}
+
+	start := time.UnixMilli(0)
+	status := newSLOStatus(slo, series, start, start.Add(2*time.Minute))
+	assert.Equal(t, float64(995), status.Good)
+	assert.Equal(t, float64(1000), status.Total)
+	assert.InDelta(t, 99.5, status.Attainment, 1e-9)
+	assert.InDelta(t, 10, status.ErrorBudget, 1e-9)
+	assert.InDelta(t, 0.5, status.RemainingBudget, 1e-9)
+
+	require.Len(t, status.History, 2)
+	assert.InDelta(t, 1, status.History[0].RemainingBudget, 1e-9)
+	assert.InDelta(t, 0.5, status.History[1].RemainingBudget, 1e-9)
+}
+
+func TestManagerSLO(t *testing.T) {
+	ctx := context.Background()
+	db := utils.NewQueryServiceDBForTests(t)
+	m := &Manager{
+		ruleDB: NewRuleDB(db, nil),
+		opts:   &ManagerOptions{DisableRules: true},
+	}
+
+	slo, apiErr := m.CreateSLO(ctx, testSLO())
+	require.Nil(t, apiErr)
+	require.Len(t, slo.RuleIds, 4)
+
+	stored, err := m.ruleDB.GetStoredRules(ctx)
+	require.NoError(t, err)
+	assert.Len(t, stored, 4)
+
+	// the rules are updated in place, the rule of the longest window is deleted
+	id := strconv.FormatInt(slo.Id, 10)
+	edited := testSLO()
+	edited.Window = Duration(2 * 24 * time.Hour)
+	edited, apiErr = m.EditSLO(ctx, id, edited)
+	require.Nil(t, apiErr)
+	assert.Equal(t, slo.RuleIds[:3], edited.RuleIds)
+
+	stored, err = m.ruleDB.GetStoredRules(ctx)
+	require.NoError(t, err)
+	require.Len(t, stored, 3)
+	rule, err := ParsePostableRule([]byte(stored[0].Data))
+	require.NoError(t, err)
+	assert.InDelta(t, 0.96, *rule.RuleCondition.Target, 1e-9)
+
+	got, apiErr := m.GetSLO(ctx, id)
+	require.Nil(t, apiErr)
+	gotJSON, err := json.Marshal(got.Window)
+	require.NoError(t, err)
+	assert.Equal(t, `"48h0m0s"`, string(gotJSON))
+
+	require.Nil(t, m.DeleteSLO(ctx, id))
+	stored, err = m.ruleDB.GetStoredRules(ctx)
+	require.NoError(t, err)
+	assert.Len(t, stored, 0)
+	_, apiErr = m.GetSLO(ctx, id)
+	assert.NotNil(t, apiErr)
+}
+
+// failingRuleDB fails to create rules once the given number of rules
+// were created
+type failingRuleDB struct {
+	RuleDB
+	creates int
+}
+
+func (db *failingRuleDB) CreateRuleTx(ctx context.Context, rule string) (int64, Tx, error) {
+	if db.creates == 0 {
+		return 0, nil, errors.New("failed to create the rule")
+	}
+	db.creates--
+	return db.RuleDB.CreateRuleTx(ctx, rule)
+}
+
+func TestManagerSLORulesFailure(t *testing.T) {
+	ctx := context.Background()
+	db := utils.NewQueryServiceDBForTests(t)
+	ruleDB := &failingRuleDB{RuleDB: NewRuleDB(db, nil), creates: 2}
+	m := &Manager{
+		ruleDB: ruleDB,
+		opts:   &ManagerOptions{DisableRules: true},
+	}
+
+	// the rules created before the failure are deleted with the SLO
+	_, apiErr := m.CreateSLO(ctx, testSLO())
+	require.NotNil(t, apiErr)
+	stored, err := m.ruleDB.GetStoredRules(ctx)
+	require.NoError(t, err)
+	assert.Len(t, stored, 0)
+	slos, err := m.ruleDB.GetAllSLOs(ctx)
+	require.NoError(t, err)
+	assert.Len(t, slos, 0)
+
+	// an edit that fails deletes the rules it created, the SLO keeps its
+	// own rules
+	noAlerts := testSLO()
+	noAlerts.DisableAlerts = true
+	slo, apiErr := m.CreateSLO(ctx, noAlerts)
+	require.Nil(t, apiErr)
+	ruleDB.creates = 2
+	_, apiErr = m.EditSLO(ctx, strconv.FormatInt(slo.Id, 10), testSLO())
+	require.NotNil(t, apiErr)
+	stored, err = m.ruleDB.GetStoredRules(ctx)
+	require.NoError(t, err)
+	assert.Len(t, stored, 0)
+}
@@ -194,8 +194,9 @@ func (r *ThresholdRule) prepareLinksToLogs(ts time.Time, lbls labels.Labels) str
 		return ""
 	}
 
+	// the explorer links take a list of conditions which all have to match
 	queryFilter := []v3.FilterItem{}
-	if q.Filters != nil {
+	if q.Filters != nil && q.Filters.IsConjunction() {
 		queryFilter = q.Filters.Items
 	}
 
@@ -228,8 +229,9 @@ func (r *ThresholdRule) prepareLinksToTraces(ts time.Time, lbls labels.Labels) s
 		return ""
 	}
 
+	// the explorer links take a list of conditions which all have to match
 	queryFilter := []v3.FilterItem{}
-	if q.Filters != nil {
+	if q.Filters != nil && q.Filters.IsConjunction() {
 		queryFilter = q.Filters.Items
 	}
 
@@ -248,7 +250,77 @@ func (r *ThresholdRule) buildAndRunQuery(ctx context.Context, ts time.Time) (Vec
 	if err != nil {
 		return nil, err
 	}
-	err = r.PopulateTemporality(ctx, params)
+	queryResult, err := r.runQuery(ctx, params)
+	if err != nil {
+		return nil, err
+	}
+
+	if queryResult != nil && len(queryResult.Series) > 0 {
+		r.lastTimestampWithDatapoints = ts
+	}
+
+	var resultVector Vector
+
+	if r.ruleCondition.alertOnAbsentSeries() {
+		// every series that stopped reporting is alerted on separately
+		var series []*v3.Series
+		if queryResult != nil {
+			series = queryResult.Series
+		}
+		resultVector = append(resultVector, r.absentSeries(ts, series)...)
+	} else if r.ruleCondition.AlertOnAbsent && r.lastTimestampWithDatapoints.Add(time.Duration(r.Condition().AbsentFor)*time.Minute).Before(ts) {
+		zap.L().Info("no data found for rule condition", zap.String("ruleid", r.ID()))
+		lbls := labels.NewBuilder(labels.Labels{})
+		if !r.lastTimestampWithDatapoints.IsZero() {
+			lbls.Set("lastSeen", r.lastTimestampWithDatapoints.Format(constants.AlertTimeFormat))
+		}
+		resultVector = append(resultVector, Sample{
+			Metric:    lbls.Labels(),
+			IsMissing: true,
+			Threshold: r.targetVal(),
+		})
+		return resultVector, nil
+	}
+
+	if queryResult == nil {
+		return resultVector, nil
+	}
+
+	for _, series := range queryResult.Series {
+		smpl, shouldAlert := r.ShouldAlert(*series)
+		if shouldAlert {
+			resultVector = append(resultVector, smpl)
+		}
+	}
+	return resultVector, nil
+}
+
+// runQuery runs the prepared query range params and returns the result
+// of the selected query, nil if the selected query returned no result
+func (r *ThresholdRule) runQuery(ctx context.Context, params *v3.QueryRangeParamsV3) (*v3.Result, error) {
+
+	results, err := r.queryRange(ctx, params)
+	if err != nil {
+		return nil, err
+	}
+
+	selectedQuery := r.GetSelectedQuery()
+
+	var queryResult *v3.Result
+	for _, res := range results {
+		if res.QueryName == selectedQuery {
+			queryResult = res
+			break
+		}
+	}
+	return queryResult, nil
+}
+
+// queryRange runs the prepared query range params and returns the
+// results of all queries
+func (r *ThresholdRule) queryRange(ctx context.Context, params *v3.QueryRangeParamsV3) ([]*v3.Result, error) {
+
+	err := r.PopulateTemporality(ctx, params)
 	if err != nil {
 		return nil, fmt.Errorf("internal error while setting temporality")
 	}
@@ -310,55 +382,29 @@ func (r *ThresholdRule) buildAndRunQuery(ctx context.Context, ts time.Time) (Vec
 		}
 	}
 
-	selectedQuery := r.GetSelectedQuery()
-
-	var queryResult *v3.Result
-	for _, res := range results {
-		if res.QueryName == selectedQuery {
-			queryResult = res
-			break
-		}
-	}
+	return results, nil
+}
 
-	if queryResult != nil && len(queryResult.Series) > 0 {
-		r.lastTimestampWithDatapoints = time.Now()
-	}
+func (r *ThresholdRule) Eval(ctx context.Context, ts time.Time) (interface{}, error) {
 
-	var resultVector Vector
+	res, err := r.buildAndRunQuery(ctx, ts)
 
-	// if the data is missing for `For` duration then we should send alert
-	if r.ruleCondition.AlertOnAbsent && r.lastTimestampWithDatapoints.Add(time.Duration(r.Condition().AbsentFor)*time.Minute).Before(time.Now()) {
-		zap.L().Info("no data found for rule condition", zap.String("ruleid", r.ID()))
-		lbls := labels.NewBuilder(labels.Labels{})
-		if !r.lastTimestampWithDatapoints.IsZero() {
-			lbls.Set("lastSeen", r.lastTimestampWithDatapoints.Format(constants.AlertTimeFormat))
-		}
-		resultVector = append(resultVector, Sample{
-			Metric:    lbls.Labels(),
-			IsMissing: true,
-		})
-		return resultVector, nil
+	if err != nil {
+		return nil, err
 	}
 
-	for _, series := range queryResult.Series {
-		smpl, shouldAlert := r.ShouldAlert(*series)
-		if shouldAlert {
-			resultVector = append(resultVector, smpl)
-		}
-	}
-	return resultVector, nil
+	return r.evalVector(ctx, ts, res)
 }
 
-func (r *ThresholdRule) Eval(ctx context.Context, ts time.Time) (interface{}, error) {
+// evalVector updates the active alerts with the samples that satisfied
+// the rule condition at ts and records the state changes
+func (r *ThresholdRule) evalVector(ctx context.Context, ts time.Time, res Vector) (interface{}, error) {
 
 	prevState := r.State()
 
 	valueFormatter := formatter.FromUnit(r.Unit())
-	res, err := r.buildAndRunQuery(ctx, ts)
 
-	if err != nil {
-		return nil, err
-	}
+	var err error
 
 	r.mtx.Lock()
 	defer r.mtx.Unlock()
@@ -373,7 +419,7 @@ func (r *ThresholdRule) Eval(ctx context.Context, ts time.Time) (interface{}, er
 		}
 
 		value := valueFormatter.Format(smpl.V, r.Unit())
-		threshold := valueFormatter.Format(r.targetVal(), r.Unit())
+		threshold := valueFormatter.Format(smpl.Threshold, r.Unit())
 		zap.L().Debug("Alert template data for rule", zap.String("name", r.Name()), zap.String("formatter", valueFormatter.Name()), zap.String("value", value), zap.String("threshold", threshold))
 
 		tmplData := AlertTemplateData(l, value, threshold)
@@ -406,6 +452,9 @@ func (r *ThresholdRule) Eval(ctx context.Context, ts time.Time) (interface{}, er
 		for name, value := range r.labels.Map() {
 			lb.Set(name, expand(value))
 		}
+		if smpl.Severity != "" {
+			lb.Set(labels.AlertSeverityLabel, smpl.Severity)
+		}
 
 		lb.Set(labels.AlertNameLabel, r.Name())
 		lb.Set(labels.AlertRuleIdLabel, r.ID())
@@ -437,7 +486,7 @@ func (r *ThresholdRule) Eval(ctx context.Context, ts time.Time) (interface{}, er
 		}
 
 		lbs := lb.Labels()
-		h := lbs.Hash()
+		h := r.AlertFingerprint(lbs)
 		resultFPs[h] = struct{}{}
 
 		if _, ok := alerts[h]; ok {
@@ -456,11 +505,14 @@ func (r *ThresholdRule) Eval(ctx context.Context, ts time.Time) (interface{}, er
 			GeneratorURL:      r.GeneratorURL(),
 			Receivers:         r.preferredChannels,
 			Missing:           smpl.IsMissing,
+			Severity:          smpl.Severity,
 		}
 	}
 
 	zap.L().Info("number of alerts found", zap.String("name", r.Name()), zap.Int("count", len(alerts)))
 
+	severityChanged := map[uint64]bool{}
+
 	// alerts[h] is ready, add or update active list now
 	for h, a := range alerts {
 		// Check whether we already have alerting state for the identifying label set.
@@ -470,6 +522,8 @@ func (r *ThresholdRule) Eval(ctx context.Context, ts time.Time) (interface{}, er
 			alert.Value = a.Value
 			alert.Annotations = a.Annotations
 			alert.Receivers = r.preferredChannels
+			// moving between threshold levels keeps the alert active
+			severityChanged[h] = alert.UpdateSeverity(a)
 			continue
 		}
 
@@ -502,6 +556,7 @@ func (r *ThresholdRule) Eval(ctx context.Context, ts time.Time) (interface{}, er
 					Labels:       model.LabelsString(labelsJSON),
 					Fingerprint:  a.QueryResultLables.Hash(),
 					Value:        a.Value,
+					Severity:     a.Severity,
 				})
 			}
 			continue
@@ -523,6 +578,19 @@ func (r *ThresholdRule) Eval(ctx context.Context, ts time.Time) (interface{}, er
 				Labels:       model.LabelsString(labelsJSON),
 				Fingerprint:  a.QueryResultLables.Hash(),
 				Value:        a.Value,
+				Severity:     a.Severity,
+			})
+		} else if a.State == model.StateFiring && severityChanged[fp] {
+			itemsToAdd = append(itemsToAdd, model.RuleStateHistory{
+				RuleID:       r.ID(),
+				RuleName:     r.Name(),
+				State:        model.StateFiring,
+				StateChanged: false,
+				UnixMilli:    ts.UnixMilli(),
+				Labels:       model.LabelsString(labelsJSON),
+				Fingerprint:  a.QueryResultLables.Hash(),
+				Value:        a.Value,
+				Severity:     a.Severity,
 			})
 		}
 	}
@@ -7,9 +7,11 @@ import (
 	"time"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 	"go.signoz.io/signoz/pkg/query-service/app/clickhouseReader"
 	"go.signoz.io/signoz/pkg/query-service/common"
 	"go.signoz.io/signoz/pkg/query-service/featureManager"
+	"go.signoz.io/signoz/pkg/query-service/model"
 	v3 "go.signoz.io/signoz/pkg/query-service/model/v3"
 	"go.signoz.io/signoz/pkg/query-service/utils/labels"
 
@@ -1602,3 +1604,281 @@ func TestThresholdRuleLogsLink(t *testing.T) {
 		}
 	}
 }
+
+func TestThresholdRuleShouldAlertWithThresholdLevels(t *testing.T) {
+	critical := 10.0
+	warning := 5.0
+	postableRule := PostableRule{
+		AlertName:  "Threshold levels test",
+		AlertType:  AlertTypeMetric,
+		RuleType:   RuleTypeThreshold,
+		EvalWindow: Duration(5 * time.Minute),
+		Frequency:  Duration(1 * time.Minute),
+		RuleCondition: &RuleCondition{
+			CompositeQuery: &v3.CompositeQuery{
+				QueryType: v3.QueryTypeBuilder,
+				BuilderQueries: map[string]*v3.BuilderQuery{
+					"A": {
+						QueryName:    "A",
+						StepInterval: 60,
+						AggregateAttribute: v3.AttributeKey{
+							Key: "probe_success",
+						},
+						AggregateOperator: v3.AggregateOperatorNoOp,
+						DataSource:        v3.DataSourceMetrics,
+						Expression:        "A",
+					},
+				},
+			},
+			Thresholds: []RuleThreshold{
+				{Severity: "critical", Target: &critical, CompareOp: ValueIsAbove, MatchType: AtleastOnce},
+				{Severity: "warning", Target: &warning, CompareOp: ValueIsAbove, MatchType: AtleastOnce},
+			},
+		},
+	}
+
+	cases := []struct {
+		values           v3.Series
+		expectAlert      bool
+		expectedSeverity string
+		expectedTarget   float64
+	}{
+		{
+			values: v3.Series{
+				Points: []v3.Point{
+					{Value: 1.0},
+					{Value: 12.0},
+				},
+			},
+			expectAlert:      true,
+			expectedSeverity: "critical",
+			expectedTarget:   critical,
+		},
+		{
+			values: v3.Series{
+				Points: []v3.Point{
+					{Value: 1.0},
+					{Value: 7.0},
+				},
+			},
+			expectAlert:      true,
+			expectedSeverity: "warning",
+			expectedTarget:   warning,
+		},
+		{
+			values: v3.Series{
+				Points: []v3.Point{
+					{Value: 1.0},
+					{Value: 2.0},
+				},
+			},
+			expectAlert: false,
+		},
+	}
+
+	fm := featureManager.StartManager()
+	rule, err := NewThresholdRule("69", &postableRule, fm, nil, true)
+	if err != nil {
+		assert.NoError(t, err)
+	}
+
+	for idx, c := range cases {
+		values := c.values
+		for i := range values.Points {
+			values.Points[i].Timestamp = time.Now().UnixMilli()
+		}
+
+		smpl, shouldAlert := rule.ShouldAlert(c.values)
+		assert.Equal(t, c.expectAlert, shouldAlert, "Test case %d", idx)
+		if shouldAlert {
+			assert.Equal(t, c.expectedSeverity, smpl.Severity, "Test case %d", idx)
+			assert.Equal(t, c.expectedTarget, smpl.Threshold, "Test case %d", idx)
+		}
+	}
+}
+
+func TestThresholdRuleSeverityTransition(t *testing.T) {
+	critical := 10.0
+	warning := 5.0
+	postableRule := PostableRule{
+		AlertName:  "Severity transition test",
+		AlertType:  AlertTypeMetric,
+		RuleType:   RuleTypeThreshold,
+		EvalWindow: Duration(5 * time.Minute),
+		Frequency:  Duration(1 * time.Minute),
+		RuleCondition: &RuleCondition{
+			CompositeQuery: &v3.CompositeQuery{
+				QueryType: v3.QueryTypeBuilder,
+				BuilderQueries: map[string]*v3.BuilderQuery{
+					"A": {
+						QueryName:    "A",
+						StepInterval: 60,
+						AggregateAttribute: v3.AttributeKey{
+							Key: "signoz_calls_total",
+						},
+						AggregateOperator: v3.AggregateOperatorSumRate,
+						DataSource:        v3.DataSourceMetrics,
+						Expression:        "A",
+					},
+				},
+			},
+			CompareOp: ValueIsAbove,
+			MatchType: AtleastOnce,
+			Thresholds: []RuleThreshold{
+				{Severity: "critical", Target: &critical},
+				{Severity: "warning", Target: &warning},
+			},
+		},
+		Labels: map[string]string{
+			"severity": "info",
+		},
+	}
+	fm := featureManager.StartManager()
+	mock, err := cmock.NewClickHouseWithQueryMatcher(nil, &queryMatcherAny{})
+	if err != nil {
+		t.Errorf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+
+	cols := make([]cmock.ColumnType, 0)
+	cols = append(cols, cmock.ColumnType{Name: "value", Type: "Float64"})
+	cols = append(cols, cmock.ColumnType{Name: "attr", Type: "String"})
+	cols = append(cols, cmock.ColumnType{Name: "timestamp", Type: "String"})
+
+	options := clickhouseReader.NewOptions("", 0, 0, 0, "", "archiveNamespace")
+	reader := clickhouseReader.NewReaderFromClickhouseConnection(mock, options, nil, "", fm, "", true)
+
+	rule, err := NewThresholdRule("69", &postableRule, fm, reader, true)
+	if err != nil {
+		assert.NoError(t, err)
+	}
+	rule.TemporalityMap = map[string]map[v3.Temporality]bool{
+		"signoz_calls_total": {
+			v3.Delta: true,
+		},
+	}
+
+	cases := []struct {
+		value            float64
+		expectedSeverity string
+	}{
+		{value: 7, expectedSeverity: "warning"},
+		{value: 12, expectedSeverity: "critical"},
+		{value: 8, expectedSeverity: "warning"},
+	}
+
+	ts := time.Now()
+	var activeAt time.Time
+	for idx, c := range cases {
+		// We are testing the eval logic after the query is run
+		// so we don't care about the query string here
+		mock.
+			ExpectQuery("SELECT any").
+			WillReturnRows(cmock.NewRows(cols, [][]interface{}{
+				{c.value, "attr", ts},
+			}))
+
+		retVal, err := rule.Eval(context.Background(), ts.Add(time.Duration(idx)*time.Minute))
+		if err != nil {
+			assert.NoError(t, err)
+		}
+
+		// the alert moves between severities without being resolved
+		assert.Equal(t, 1, retVal.(int), "case %d", idx)
+		for _, alert := range rule.Active {
+			assert.Equal(t, model.StateFiring, alert.State, "case %d", idx)
+			assert.Equal(t, c.expectedSeverity, alert.Severity, "case %d", idx)
+			assert.Equal(t, c.expectedSeverity, alert.Labels.Get(labels.AlertSeverityLabel), "case %d", idx)
+			if idx == 0 {
+				activeAt = alert.ActiveAt
+			}
+			assert.Equal(t, activeAt, alert.ActiveAt, "case %d", idx)
+		}
+	}
+}
+
+func TestThresholdRuleAbsentSeries(t *testing.T) {
+	target := 100.0
+	postableRule := PostableRule{
+		AlertName:  "Absent series test",
+		AlertType:  AlertTypeMetric,
+		RuleType:   RuleTypeThreshold,
+		EvalWindow: Duration(5 * time.Minute),
+		Frequency:  Duration(1 * time.Minute),
+		RuleCondition: &RuleCondition{
+			CompositeQuery: &v3.CompositeQuery{
+				QueryType: v3.QueryTypeBuilder,
+				BuilderQueries: map[string]*v3.BuilderQuery{
+					"A": {
+						QueryName:    "A",
+						StepInterval: 60,
+						AggregateAttribute: v3.AttributeKey{
+							Key: "signoz_calls_total",
+						},
+						AggregateOperator: v3.AggregateOperatorSumRate,
+						DataSource:        v3.DataSourceMetrics,
+						Temporality:       v3.Delta,
+						Expression:        "A",
+					},
+				},
+			},
+			Target:        &target,
+			CompareOp:     ValueIsAbove,
+			MatchType:     AtleastOnce,
+			AlertOnAbsent: true,
+			AbsentFor:     2,
+			AbsentMode:    AbsentModeSeries,
+		},
+	}
+	fm := featureManager.StartManager()
+	mock, err := cmock.NewClickHouseWithQueryMatcher(nil, &queryMatcherAny{})
+	require.NoError(t, err)
+
+	cols := []cmock.ColumnType{
+		{Name: "value", Type: "Float64"},
+		{Name: "service_name", Type: "String"},
+	}
+
+	options := clickhouseReader.NewOptions("", 0, 0, 0, "", "archiveNamespace")
+	reader := clickhouseReader.NewReaderFromClickhouseConnection(mock, options, nil, "", fm, "", true)
+
+	rule, err := NewThresholdRule("69", &postableRule, fm, reader, true)
+	require.NoError(t, err)
+
+	noData := func() []string {
+		services := []string{}
+		for _, a := range rule.Active {
+			if a.State == model.StateFiring && strings.HasPrefix(a.Labels.Get(labels.AlertNameLabel), "[No data]") {
+				services = append(services, a.Labels.Get("service_name"))
+			}
+		}
+		return services
+	}
+
+	start := time.Now().Truncate(time.Minute)
+	cases := []struct {
+		services []string
+		noData   []string
+	}{
+		{services: []string{"frontend", "cart"}, noData: []string{}},
+		// cart stops reporting but is not missing for long enough
+		{services: []string{"frontend"}, noData: []string{}},
+		{services: []string{"frontend"}, noData: []string{"cart"}},
+		// an alert is raised for every missing series
+		{services: []string{}, noData: []string{"cart"}},
+		{services: []string{}, noData: []string{"frontend", "cart"}},
+		// the alert resolves when the data returns
+		{services: []string{"cart"}, noData: []string{"frontend"}},
+	}
+
+	for idx, c := range cases {
+		values := [][]interface{}{}
+		for _, service := range c.services {
+			values = append(values, []interface{}{float64(1), service})
+		}
+		mock.ExpectQuery("SELECT any").WillReturnRows(cmock.NewRows(cols, values))
+
+		_, err := rule.Eval(context.Background(), start.Add(time.Duration(idx)*time.Minute))
+		require.NoError(t, err)
+		assert.ElementsMatch(t, c.noData, noData(), "case %d", idx)
+	}
+}
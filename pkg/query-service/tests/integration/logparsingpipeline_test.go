@@ -4,6 +4,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"io"
+	"net/http"
 	"net/http/httptest"
 	"runtime/debug"
 	"strings"
@@ -19,6 +20,7 @@ import (
 	"go.signoz.io/signoz/pkg/query-service/agentConf"
 	"go.signoz.io/signoz/pkg/query-service/app"
 	"go.signoz.io/signoz/pkg/query-service/app/integrations"
+	"go.signoz.io/signoz/pkg/query-service/app/logmetrics"
 	"go.signoz.io/signoz/pkg/query-service/app/logparsingpipeline"
 	"go.signoz.io/signoz/pkg/query-service/app/opamp"
 	opampModel "go.signoz.io/signoz/pkg/query-service/app/opamp/model"
@@ -417,6 +419,211 @@ func TestCanSavePipelinesWithoutConnectedAgents(t *testing.T) {
 
 }
 
+func TestLogPipelinesStagedRollout(t *testing.T) {
+	require := require.New(t)
+	testbed := NewLogPipelinesTestBed(t, nil)
+
+	pipelineFilterSet := &v3.FilterSet{
+		Operator: "AND",
+		Items: []v3.FilterItem{
+			{
+				Key: v3.AttributeKey{
+					Key:      "method",
+					DataType: v3.AttributeKeyDataTypeString,
+					Type:     v3.AttributeKeyTypeTag,
+				},
+				Operator: "=",
+				Value:    "GET",
+			},
+		},
+	}
+	postablePipeline := func(alias string) logparsingpipeline.PostablePipeline {
+		return logparsingpipeline.PostablePipeline{
+			OrderId: 1,
+			Name:    alias,
+			Alias:   alias,
+			Enabled: true,
+			Filter:  pipelineFilterSet,
+			Config: []logparsingpipeline.PipelineOperator{
+				{
+					OrderId: 1,
+					ID:      "add",
+					Type:    "add",
+					Field:   "attributes.test",
+					Value:   "val",
+					Enabled: true,
+					Name:    "test add",
+				},
+			},
+		}
+	}
+
+	v1Pipelines := testbed.PostPipelinesToQS(logparsingpipeline.PostablePipelines{
+		Pipelines: []logparsingpipeline.PostablePipeline{postablePipeline("pipeline1")},
+	}).Pipelines
+	testbed.assertPipelinesSentToOpampClient(v1Pipelines)
+	testbed.simulateOpampClientAcknowledgementForLatestConfig()
+
+	canaryConn := &opamp.MockOpAmpConnection{}
+	testbed.opampServer.OnMessage(canaryConn, &protobufs.AgentToServer{
+		InstanceUid: "canary",
+		AgentDescription: &protobufs.AgentDescription{
+			NonIdentifyingAttributes: []*protobufs.KeyValue{{
+				Key: "env",
+				Value: &protobufs.AnyValue{
+					Value: &protobufs.AnyValue_StringValue{StringValue: "canary"},
+				},
+			}},
+		},
+		EffectiveConfig: &protobufs.EffectiveConfig{
+			ConfigMap: newInitialAgentConfigMap(),
+		},
+	})
+	assertPipelinesRecommendedInRemoteConfig(t, canaryConn.LatestMsgFromServer(), v1Pipelines)
+
+	// A staged version should be deployed only to the matching agents
+	v2Postable := logparsingpipeline.PostablePipelines{
+		Pipelines:      []logparsingpipeline.PostablePipeline{postablePipeline("pipeline1"), postablePipeline("pipeline2")},
+		StagedSelector: agentConf.AgentLabels{"env": "canary"},
+	}
+	v2Postable.Pipelines[1].OrderId = 2
+	v2Pipelines := testbed.PostPipelinesToQS(v2Postable).Pipelines
+	assertPipelinesRecommendedInRemoteConfig(t, canaryConn.LatestMsgFromServer(), v2Pipelines)
+	testbed.assertPipelinesSentToOpampClient(v1Pipelines)
+	testbed.assertNewAgentGetsPipelinesOnConnection(v1Pipelines)
+
+	var rollout logparsingpipeline.PipelinesRolloutResponse
+	testbed.callPipelinesHandler(
+		testbed.apiHandler.GetLogsPipelinesRolloutHandler,
+		"/api/v1/logs/pipelines/rollout", nil, nil, &rollout,
+	)
+	require.NotNil(rollout.Rollout)
+	require.Equal(1, rollout.Rollout.FleetVersion)
+	require.Equal(2, *rollout.Rollout.StagedVersion)
+	agentDeployments := map[string]agentConf.AgentDeployment{}
+	for _, d := range rollout.Agents {
+		agentDeployments[d.AgentID] = d
+	}
+	require.Equal(1, agentDeployments["test"].Version)
+	require.Equal(agentConf.Deployed, agentDeployments["test"].DeployStatus)
+	require.Equal(2, agentDeployments["canary"].Version)
+	require.Equal(agentConf.DeployInitiated, agentDeployments["canary"].DeployStatus)
+	require.Equal("canary", agentDeployments["canary"].Labels["env"])
+
+	var diff logparsingpipeline.PipelinesDiffResponse
+	testbed.callPipelinesHandler(
+		testbed.apiHandler.DiffLogsPipelinesHandler,
+		"/api/v1/logs/pipelines/diff?from=1&to=2", nil, nil, &diff,
+	)
+	require.Equal(1, len(diff.Pipelines))
+	require.Equal("pipeline2", diff.Pipelines[0].Alias)
+	require.Equal(logparsingpipeline.PipelineAdded, diff.Pipelines[0].Type)
+
+	// Promoting the staged version should deploy it to all the agents
+	testbed.callPipelinesHandler(
+		testbed.apiHandler.PromoteLogsPipelinesHandler,
+		"/api/v1/logs/pipelines/rollout/promote", struct{}{}, nil, &rollout,
+	)
+	require.Nil(rollout.Rollout)
+	testbed.assertPipelinesSentToOpampClient(v2Pipelines)
+	testbed.simulateOpampClientAcknowledgementForLatestConfig()
+
+	// Rolling back should deploy the earlier version to all the agents
+	var rollbackResp logparsingpipeline.PipelinesResponse
+	testbed.callPipelinesHandler(
+		testbed.apiHandler.RollbackLogsPipelinesHandler,
+		"/api/v1/logs/pipelines/1/rollback", struct{}{}, map[string]string{"version": "1"}, &rollbackResp,
+	)
+	require.Equal(1, rollbackResp.Version)
+	testbed.assertPipelinesSentToOpampClient(v1Pipelines)
+	assertPipelinesRecommendedInRemoteConfig(t, canaryConn.LatestMsgFromServer(), v1Pipelines)
+	testbed.assertNewAgentGetsPipelinesOnConnection(v1Pipelines)
+
+	testbed.callPipelinesHandler(
+		testbed.apiHandler.GetLogsPipelinesRolloutHandler,
+		"/api/v1/logs/pipelines/rollout", nil, nil, &rollout,
+	)
+	require.Equal(1, rollout.Rollout.FleetVersion)
+	require.Nil(rollout.Rollout.StagedVersion)
+}
+
+func TestLogMetricsSentOnlyToCapableAgents(t *testing.T) {
+	require := require.New(t)
+	testbed := NewLogPipelinesTestBed(t, nil)
+
+	connectAgent := func(id string, capability string) *opamp.MockOpAmpConnection {
+		conn := &opamp.MockOpAmpConnection{}
+		testbed.opampServer.OnMessage(conn, &protobufs.AgentToServer{
+			InstanceUid: id,
+			AgentDescription: &protobufs.AgentDescription{
+				NonIdentifyingAttributes: []*protobufs.KeyValue{{
+					Key: constants.LogMetricsConnectorCapability,
+					Value: &protobufs.AnyValue{
+						Value: &protobufs.AnyValue_StringValue{StringValue: capability},
+					},
+				}},
+			},
+			EffectiveConfig: &protobufs.EffectiveConfig{
+				ConfigMap: newAgentConfigMapWithMetricsPipeline(),
+			},
+		})
+		return conn
+	}
+	capableConn := connectAgent("capable", "1")
+	incapableConn := connectAgent("incapable", "0")
+
+	var metricsResp logmetrics.LogMetricsResponse
+	testbed.callPipelinesHandler(
+		testbed.apiHandler.CreateLogMetricsHandler,
+		"/api/v1/derived_metrics", logmetrics.PostableLogMetrics{
+			Metrics: []logmetrics.LogMetric{{
+				Name:            "errors",
+				Enabled:         true,
+				LogMetricConfig: logmetrics.LogMetricConfig{Type: logmetrics.LogMetricTypeCounter},
+			}},
+		}, nil, &metricsResp,
+	)
+	require.Equal(1, len(metricsResp.Metrics))
+
+	require.True(remoteConfigHasLogMetricsConnector(t, capableConn.LatestMsgFromServer()))
+	require.False(remoteConfigHasLogMetricsConnector(t, incapableConn.LatestMsgFromServer()))
+
+	// the pipelines are still deployed to the agents without the connector
+	pipelines := testbed.PostPipelinesToQS(logparsingpipeline.PostablePipelines{
+		Pipelines: []logparsingpipeline.PostablePipeline{{
+			OrderId: 1,
+			Name:    "pipeline1",
+			Alias:   "pipeline1",
+			Enabled: true,
+			Filter: &v3.FilterSet{
+				Operator: "AND",
+				Items: []v3.FilterItem{{
+					Key: v3.AttributeKey{
+						Key:      "method",
+						DataType: v3.AttributeKeyDataTypeString,
+						Type:     v3.AttributeKeyTypeTag,
+					},
+					Operator: "=",
+					Value:    "GET",
+				}},
+			},
+			Config: []logparsingpipeline.PipelineOperator{{
+				OrderId: 1,
+				ID:      "add",
+				Type:    "add",
+				Field:   "attributes.test",
+				Value:   "val",
+				Enabled: true,
+				Name:    "test add",
+			}},
+		}},
+	}).Pipelines
+	assertPipelinesRecommendedInRemoteConfig(t, incapableConn.LatestMsgFromServer(), pipelines)
+	require.False(remoteConfigHasLogMetricsConnector(t, incapableConn.LatestMsgFromServer()))
+	assertPipelinesRecommendedInRemoteConfig(t, capableConn.LatestMsgFromServer(), pipelines)
+	require.True(remoteConfigHasLogMetricsConnector(t, capableConn.LatestMsgFromServer()))
+}
+
 // LogPipelinesTestBed coordinates and mocks components involved in
 // configuring log pipelines and provides test helpers.
 type LogPipelinesTestBed struct {
@@ -446,9 +653,15 @@ func NewTestbedWithoutOpamp(t *testing.T, testDB *sqlx.DB) *LogPipelinesTestBed
 		t.Fatalf("could not create a logparsingpipelines controller: %v", err)
 	}
 
+	logMetricsController, err := logmetrics.NewLogMetricsController(testDB, "sqlite")
+	if err != nil {
+		t.Fatalf("could not create a log metrics controller: %v", err)
+	}
+
 	apiHandler, err := app.NewAPIHandler(app.APIHandlerOpts{
 		AppDao:                        dao.DB(),
 		LogsParsingPipelineController: controller,
+		LogMetricsController:          logMetricsController,
 	})
 	if err != nil {
 		t.Fatalf("could not create a new ApiHandler: %v", err)
@@ -468,6 +681,7 @@ func NewTestbedWithoutOpamp(t *testing.T, testDB *sqlx.DB) *LogPipelinesTestBed
 		DBEngine: "sqlite",
 		AgentFeatures: []agentConf.AgentFeature{
 			apiHandler.LogsParsingPipelineController,
+			apiHandler.LogMetricsController,
 		}})
 	require.Nil(t, err, "failed to init agentConf")
 
@@ -718,6 +932,36 @@ func (tb *LogPipelinesTestBed) assertNewAgentGetsPipelinesOnConnection(
 	)
 }
 
+// callPipelinesHandler calls a log pipelines api handler and unmarshals
+// the data in its response into result
+func (tb *LogPipelinesTestBed) callPipelinesHandler(
+	handler http.HandlerFunc,
+	path string,
+	postData interface{},
+	urlVars map[string]string,
+	result interface{},
+) {
+	req, err := AuthenticatedRequestForTest(tb.testUser, path, postData)
+	require.Nil(tb.t, err, "couldn't create authenticated test request")
+	if urlVars != nil {
+		req = mux.SetURLVars(req, urlVars)
+	}
+	req = req.WithContext(auth.AttachJwtToContext(req.Context(), req))
+
+	respWriter := httptest.NewRecorder()
+	handler(respWriter, req)
+	response := respWriter.Result()
+	responseBody, err := io.ReadAll(response.Body)
+	require.Nil(tb.t, err, "couldn't read response body received from QS")
+	require.Equal(tb.t, 200, response.StatusCode, string(responseBody))
+
+	var apiResponse app.ApiResponse
+	require.Nil(tb.t, json.Unmarshal(responseBody, &apiResponse))
+	dataJson, err := json.Marshal(apiResponse.Data)
+	require.Nil(tb.t, err)
+	require.Nil(tb.t, json.Unmarshal(dataJson, result))
+}
+
 func unmarshalPipelinesResponse(apiResponse *app.ApiResponse) (
 	*logparsingpipeline.PipelinesResponse,
 	error,
@@ -753,6 +997,54 @@ func assertPipelinesResponseMatchesPostedPipelines(
 	}
 }
 
+func newAgentConfigMapWithMetricsPipeline() *protobufs.AgentConfigMap {
+	return &protobufs.AgentConfigMap{
+		ConfigMap: map[string]*protobufs.AgentConfigFile{
+			"otel-collector.yaml": {
+				Body: []byte(`
+          receivers:
+            otlp:
+              protocols:
+                grpc:
+                  endpoint: 0.0.0.0:4317
+          processors:
+            batch: {}
+          exporters:
+            otlp:
+              endpoint: otelcol2:4317
+          service:
+            pipelines:
+              logs:
+                receivers: [otlp]
+                processors: [batch]
+                exporters: [otlp]
+              metrics:
+                receivers: [otlp]
+                processors: [batch]
+                exporters: [otlp]
+        `),
+				ContentType: "text/yaml",
+			},
+		},
+	}
+}
+
+// remoteConfigHasLogMetricsConnector returns whether the logs of the config
+// sent to the agent are exported to the log metrics connector
+func remoteConfigHasLogMetricsConnector(t *testing.T, msg *protobufs.ServerToAgent) bool {
+	collectorConfigYaml := maps.Values(msg.RemoteConfig.Config.ConfigMap)[0].Body
+	collectorConf, err := yaml.Parser().Unmarshal(collectorConfigYaml)
+	require.NoError(t, err)
+
+	logsSvc := collectorConf["service"].(map[string]interface{})["pipelines"].(map[string]interface{})["logs"].(map[string]interface{})
+	for _, exporter := range logsSvc["exporters"].([]interface{}) {
+		if exporter == constants.LogMetricsConnector {
+			return true
+		}
+	}
+	return false
+}
+
 func newInitialAgentConfigMap() *protobufs.AgentConfigMap {
 	return &protobufs.AgentConfigMap{
 		ConfigMap: map[string]*protobufs.AgentConfigFile{
@@ -0,0 +1,37 @@
+package utils
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	v3 "go.signoz.io/signoz/pkg/query-service/model/v3"
+)
+
+// defaultHistogramBucketBase is the base of the log-scale buckets when the
+// buckets of a query don't have one
+const defaultHistogramBucketBase = 2
+
+// HistogramBucketExpression returns the clickhouse expression of the upper bound
+// of the bucket of value as a string, to be selected as the le label of the series
+func HistogramBucketExpression(buckets *v3.HistogramBuckets, value string) string {
+	value = fmt.Sprintf("toFloat64(%s)", value)
+
+	if buckets != nil && len(buckets.Bounds) > 0 {
+		bounds := make([]string, 0, len(buckets.Bounds)+1)
+		for _, bound := range buckets.Bounds {
+			bounds = append(bounds, strconv.FormatFloat(bound, 'f', -1, 64))
+		}
+		bounds = append(bounds, "inf")
+		return fmt.Sprintf("toString(arrayFirst(x -> %s <= x, [%s]))", value, strings.Join(bounds, ", "))
+	}
+
+	base := float64(defaultHistogramBucketBase)
+	if buckets != nil && buckets.Base > 1 {
+		base = buckets.Base
+	}
+	// the exponent is rounded so that the exact powers of the base aren't
+	// moved to the next bucket by floating point errors
+	return fmt.Sprintf("if(%s <= 0, '0', toString(pow(%s, ceil(round(log(%s) / log(%s), 9)))))",
+		value, strconv.FormatFloat(base, 'f', -1, 64), value, strconv.FormatFloat(base, 'f', -1, 64))
+}
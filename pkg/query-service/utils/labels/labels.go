@@ -25,6 +25,7 @@ const (
 	RuleSourceLabel  = "ruleSource"
 
 	RuleThresholdLabel    = "threshold"
+	AlertSeverityLabel    = "severity"
 	AlertSummaryLabel     = "summary"
 	AlertDescriptionLabel = "description"
 )